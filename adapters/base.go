@@ -2,10 +2,15 @@ package adapters
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"shopify-extractor/archive"
 	"shopify-extractor/internal/types"
 	"shopify-extractor/utils"
 
@@ -20,35 +25,275 @@ type BaseAdapter struct {
 	logger        types.Logger   // Structured logging interface
 	httpClient    *utils.HTTPClient    // HTTP client for standard requests
 	browserClient *utils.BrowserClient // Headless browser client for dynamic content
+
+	// Size chart containers expected on product pages; static HTML without any of them
+	// is refetched with the headless browser (see GetPageContent)
+	expectedContainers []string
+
+	// productPage recognises the platform's product page URLs; see SetProductURLMatcher
+	productPage func(pageURL string) bool
+
+	browserCheck     sync.Once
+	browserAvailable bool
+
+	// Store-specific chart parsers; see ParseSizeCharts
+	parsers []prioritizedParser
+
+	// Parsers and selectors that found the charts of previous products, tried first;
+	// persisted under storeName, see SetStoreName
+	strategies *strategyCache
+	storeName  string
+
+	// Div layouts read by the pseudo-table parser; see SetPseudoTables
+	pseudoTables []PseudoTable
+
+	// Product title resolution; see ExtractProductTitleFromDoc
+	titleSources   []TitleSource
+	titleSelectors []string
+
+	// Raw product page archive and WARC file, opened on first use when Config.ArchiveDir
+	// or Config.WARCDir is set
+	archiveOnce sync.Once
+	archive     *archive.Archive
+	warc        *archive.WARCWriter
 }
 
 // NewBaseAdapter creates a new base adapter with initialized HTTP and browser clients.
 // This is the factory method that sets up the common infrastructure used by all store adapters.
 func NewBaseAdapter(config *types.Config, logger types.Logger) *BaseAdapter {
-	return &BaseAdapter{
+	// HTTP and browser fetches share one per-host limiter so both respect RequestDelay
+	// and the politeness pools shared with other stores
+	limiter := utils.NewConfigLimiter(config)
+	b := &BaseAdapter{
 		config:        config,
 		logger:        logger,
-		httpClient:    utils.NewHTTPClient(config, logger),
-		browserClient: utils.NewBrowserClient(config, logger),
+		httpClient:    utils.NewHTTPClientWithLimiter(config, logger, limiter),
+		browserClient: utils.NewBrowserClientWithLimiter(config, logger, limiter),
+		parsers:       []prioritizedParser{{parser: ocrParser{}, priority: PriorityOCR}},
+		strategies:    newStrategyCache(),
+		pseudoTables:  DefaultPseudoTables,
 	}
+	b.AddChartParser(NewChartParser("pseudo-table", b.hasPseudoTable, b.ParsePseudoTables), PriorityPseudoTable)
+	return b
+}
+
+// loggerFor returns the logger of an adapter call: the caller's logger, which carries
+// the run, store and product fields, or the adapter's own logger
+func (b *BaseAdapter) loggerFor(ctx types.Context) types.Logger {
+	if ctx.Logger != nil {
+		return ctx.Logger
+	}
+	return b.logger
+}
+
+// loggerFrom returns the logger carried by ctx (see utils.ContextWithLogger), or the
+// adapter's own logger
+func (b *BaseAdapter) loggerFrom(ctx context.Context) types.Logger {
+	return utils.LoggerFrom(ctx, b.logger)
+}
+
+// fetchContext returns a context for the fetches of an adapter call, ending with the
+// caller's context and carrying its logger so the HTTP and browser clients log with the
+// call's fields
+func (b *BaseAdapter) fetchContext(ctx types.Context) context.Context {
+	parent := ctx.Ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	return utils.ContextWithLogger(parent, b.loggerFor(ctx))
 }
 
 // GetPageContent retrieves the HTML content of a page using either HTTP client or headless browser.
 // The choice between HTTP and browser is determined by the UseHeadlessBrowser configuration.
 // This method is used by all store adapters to fetch page content for parsing.
+//
+// With Config.FetchFallback enabled, a failed browser navigation (crash, timeout) is retried
+// over plain HTTP, and a static product page lacking every expected size chart container is
+// refetched with the browser in case the chart is rendered by JavaScript.
+//
+// With Config.ArchiveDir or Config.WARCDir set, the HTML of every product page is archived
+// as fetched.
+// With Config.PageSource set, pages are read from it and nothing is fetched.
 func (b *BaseAdapter) GetPageContent(ctx context.Context, url string) (string, error) {
+	if b.config.PageSource != nil {
+		html, err := b.config.PageSource.PageContent(ctx, url)
+		if err != nil {
+			return "", &ExtractionError{Reason: types.MissingReasonFetchBlocked, Err: err}
+		}
+		return html, nil
+	}
+
+	html, err := b.fetchPageContent(ctx, url)
+	if err == nil && (b.config.ArchiveDir != "" || b.config.WARCDir != "") && b.IsProductURL(url) {
+		b.archivePage(url, html)
+	}
+	return html, err
+}
+
+// GetPageContentWhenVisible is GetPageContent for pages filled in by a script after they
+// loaded: with the headless browser it waits up to wait for the element matching
+// selector instead of a fixed settle time, and returns the page as rendered when the
+// element never shows up. HTTP fetches and Config.PageSource are unaffected.
+func (b *BaseAdapter) GetPageContentWhenVisible(ctx context.Context, url, selector string, wait time.Duration) (string, error) {
+	if b.config.PageSource != nil || !b.config.UseHeadlessBrowser {
+		return b.GetPageContent(ctx, url)
+	}
+	html, err := b.browserClient.GetPageContentWhenVisible(ctx, url, selector, wait)
+	if err != nil {
+		return "", fetchError(err)
+	}
+	return html, nil
+}
+
+// GetPageContentScrolled is GetPageContent for pages that load more items as they are
+// scrolled: with the headless browser it scrolls until no more items load, see
+// utils.ScrollOptions. HTTP fetches and Config.PageSource only see the first items.
+func (b *BaseAdapter) GetPageContentScrolled(ctx context.Context, url string, scroll utils.ScrollOptions) (string, error) {
+	if b.config.PageSource != nil || !b.config.UseHeadlessBrowser {
+		return b.GetPageContent(ctx, url)
+	}
+	html, err := b.browserClient.GetPageContentScrolled(ctx, url, scroll)
+	if err != nil {
+		return "", fetchError(err)
+	}
+	return html, nil
+}
+
+// archivePage stores the raw HTML of a product page in the archive and WARC file;
+// failures are logged, not returned, so archiving never fails an extraction
+func (b *BaseAdapter) archivePage(pageURL, html string) {
+	store := ""
+	if parsed, err := url.Parse(pageURL); err == nil {
+		store = types.CanonicalStore(parsed.Hostname())
+	}
+
+	b.archiveOnce.Do(func() {
+		if b.config.ArchiveDir != "" {
+			a, err := archive.Open(b.config.ArchiveDir)
+			if err != nil {
+				b.logger.Warnf("Page archiving disabled: %v", err)
+			}
+			b.archive = a
+		}
+		if b.config.WARCDir != "" {
+			path := filepath.Join(b.config.WARCDir, fmt.Sprintf("%s-%d.warc.gz", store, time.Now().UnixNano()))
+			w, err := archive.CreateWARC(path, map[string]string{"run-id": b.config.RunID, "store": store})
+			if err != nil {
+				b.logger.Warnf("WARC recording disabled: %v", err)
+			}
+			b.warc = w
+		}
+	})
+
+	if b.archive != nil {
+		if _, err := b.archive.Put(b.config.RunID, store, pageURL, html); err != nil {
+			b.logger.Warnf("Failed to archive %s: %v", pageURL, err)
+		}
+	}
+	if b.warc != nil {
+		if err := b.warc.WriteResource(pageURL, time.Now(), html); err != nil {
+			b.logger.Warnf("Failed to record %s in WARC file: %v", pageURL, err)
+		}
+	}
+}
+
+// fetchPageContent fetches a page with the configured client and fallbacks
+func (b *BaseAdapter) fetchPageContent(ctx context.Context, url string) (string, error) {
 	// Use headless browser for JavaScript-heavy sites (like Westside)
 	if b.config.UseHeadlessBrowser {
-		return b.browserClient.GetPageContent(ctx, url)
+		html, err := b.browserClient.GetPageContent(ctx, url)
+		if err == nil {
+			return html, nil
+		}
+		// An interstitial page would be served over HTTP all the same
+		var interstitial *utils.InterstitialError
+		if !b.config.FetchFallback || ctx.Err() != nil || errors.As(err, &interstitial) {
+			return "", fetchError(err)
+		}
+
+		b.loggerFrom(ctx).Warnf("Browser fetch of %s failed (%v), retrying over HTTP", url, err)
+		body, httpErr := b.httpClient.Get(ctx, url)
+		if httpErr != nil {
+			return "", fetchError(fmt.Errorf("browser: %v; http: %w", err, httpErr))
+		}
+		return string(body), nil
 	}
 
 	// Use standard HTTP client for static content (faster and more efficient)
 	body, err := b.httpClient.Get(ctx, url)
 	if err != nil {
-		return "", err
+		return "", fetchError(err)
+	}
+	html := string(body)
+
+	if b.config.FetchFallback && b.needsBrowserRender(url, html) {
+		b.loggerFrom(ctx).Debugf("No size chart container in static HTML of %s, retrying with headless browser", url)
+		rendered, err := b.browserClient.GetPageContent(ctx, url)
+		if err != nil {
+			b.loggerFrom(ctx).Warnf("Browser fallback for %s failed: %v", url, err)
+			return html, nil
+		}
+		return rendered, nil
+	}
+
+	return html, nil
+}
+
+// SetExpectedContainers registers the CSS selectors of size chart containers that a fully
+// rendered product page contains. Static product pages matching none of them are
+// refetched with the headless browser.
+func (b *BaseAdapter) SetExpectedContainers(selectors ...string) {
+	b.expectedContainers = selectors
+}
+
+// SetProductURLMatcher registers how the platform's product page URLs are recognised, e.g.
+// IsShopifyProductURL. Only product pages are archived and refetched with the browser for
+// a missing size chart container.
+func (b *BaseAdapter) SetProductURLMatcher(match func(pageURL string) bool) {
+	b.productPage = match
+}
+
+// IsProductURL reports whether pageURL is a product page; without a registered matcher
+// no page is
+func (b *BaseAdapter) IsProductURL(pageURL string) bool {
+	return b.productPage != nil && b.productPage(pageURL)
+}
+
+// needsBrowserRender reports whether static HTML of a product page lacks every expected
+// container and a browser is available to render it
+func (b *BaseAdapter) needsBrowserRender(pageURL string, html string) bool {
+	if len(b.expectedContainers) == 0 || !b.IsProductURL(pageURL) {
+		return false
+	}
+
+	doc, err := b.ParseHTML(html)
+	if err != nil {
+		return false
 	}
+	for _, selector := range b.expectedContainers {
+		if doc.Find(selector).Length() > 0 {
+			return false
+		}
+	}
+
+	return b.canUseBrowser()
+}
 
-	return string(body), nil
+// canUseBrowser reports whether the browser backend can run here, checking for a Chrome
+// binary once per adapter
+func (b *BaseAdapter) canUseBrowser() bool {
+	b.browserCheck.Do(func() {
+		if !utils.UsesLocalChrome(b.config.Browser) {
+			b.browserAvailable = true
+			return
+		}
+		_, err := utils.FindChrome(b.config.Browser)
+		b.browserAvailable = err == nil
+		if err != nil {
+			b.logger.Debugf("Browser fallback disabled: %v", err)
+		}
+	})
+	return b.browserAvailable
 }
 
 // ParseHTML parses HTML content into a goquery document
@@ -66,42 +311,9 @@ func (b *BaseAdapter) ExtractTableData(doc *goquery.Document, tableSelector stri
 		return nil, fmt.Errorf("table not found with selector: %s", tableSelector)
 	}
 
-	// Extract headers from the first row or thead section
-	// Supports multiple header formats: thead > tr > th, tr:first-child > th/td
-	var headers []string
-	table.Find("thead tr th, tr:first-child th, tr:first-child td").Each(func(i int, s *goquery.Selection) {
-		headers = append(headers, strings.TrimSpace(s.Text()))
-	})
-
-	if len(headers) == 0 {
-		return nil, fmt.Errorf("no headers found in table")
-	}
-
-	// Extract data rows from tbody or all rows except the first
-	// Maps each cell to its corresponding header
-	var rows []map[string]string
-	table.Find("tbody tr, tr:not(:first-child)").Each(func(i int, s *goquery.Selection) {
-		row := make(map[string]string)
-		s.Find("td, th").Each(func(j int, cell *goquery.Selection) {
-			if j < len(headers) {
-				row[headers[j]] = strings.TrimSpace(cell.Text())
-			}
-		})
-
-		// Only add rows that have actual data (not empty rows)
-		if len(row) > 0 {
-			rows = append(rows, row)
-		}
-	})
-
-	if len(rows) == 0 {
-		return nil, fmt.Errorf("no data rows found in table")
-	}
-
-	return &types.SizeChart{
-		Headers: headers,
-		Rows:    rows,
-	}, nil
+	// Headers come from the first row or thead section, with rowspan / colspan
+	// expanded so merged cells keep every column aligned
+	return tableChart(table)
 }
 
 // ExtractText extracts text from an element using a CSS selector
@@ -129,11 +341,35 @@ func (b *BaseAdapter) ExtractAttribute(doc *goquery.Document, selector string, a
 	return value, nil
 }
 
+// Prewarm readies the adapter for its first extraction: it looks for the browser once
+// and fetches the store's robots.txt and sitemap.xml, leaving keep-alive connections to
+// the store open
+func (b *BaseAdapter) Prewarm(ctx context.Context, baseURL string) error {
+	if b.config.UseHeadlessBrowser || b.config.FetchFallback {
+		b.canUseBrowser()
+	}
+	if b.config.PageSource != nil {
+		return nil
+	}
+	for _, path := range []string{"/robots.txt", "/sitemap.xml"} {
+		if err := b.httpClient.Warm(ctx, baseURL+path); err != nil {
+			return fmt.Errorf("failed to prewarm %s: %w", baseURL, err)
+		}
+	}
+	return nil
+}
+
 // Close cleans up resources
 func (b *BaseAdapter) Close() {
+	b.saveStrategies()
 	if b.httpClient != nil {
 		b.httpClient.Close()
 	}
+	if b.warc != nil {
+		if err := b.warc.Close(); err != nil {
+			b.logger.Warnf("Failed to close WARC file: %v", err)
+		}
+	}
 }
 
 // FilterSizeChart normalizes and filters size chart data to a standard format.
@@ -145,24 +381,40 @@ func (b *BaseAdapter) Close() {
 // 2. Filters out irrelevant columns (keeping only Size, Bust, Waist, Hip)
 // 3. Normalizes data to ensure consistent structure
 // 4. Filters out empty rows to maintain data quality
-func (b *BaseAdapter) FilterSizeChart(sizeChart *types.SizeChart) *types.SizeChart {
+//
+// A chart without a unit in its headers is labelled from its values (see InferUnit);
+// only when they do not decide is it assumed to be in inches. With Config.IncludeRaw
+// the original table is kept as the filtered chart's Raw. The header mapping is logged
+// through the logger of the adapter call.
+func (b *BaseAdapter) FilterSizeChart(ctx types.Context, sizeChart *types.SizeChart) *types.SizeChart {
 	if sizeChart == nil {
 		return nil
 	}
 
+	unit, confidence := sizeChart.Unit, sizeChart.UnitConfidence
+	if unit == "" {
+		if unit, confidence = InferUnit(sizeChart); unit == "" {
+			unit = types.UnitInches
+		}
+	}
+	if unit != types.UnitCentimeters {
+		unit = types.UnitInches
+	}
+	bust, waist, hip := "Bust ("+unit+")", "Waist ("+unit+")", "Hip ("+unit+")"
+
 	// Define the canonical output headers that all stores should produce
 	// This ensures consistent JSON output across different stores
-	outputHeaders := []string{"Size", "Bust (in)", "Waist (in)", "Hip (in)"}
+	outputHeaders := []string{"Size", bust, waist, hip}
 
 	// Map various possible header names to canonical output headers
 	// This handles the fact that different stores use different naming conventions
 	// e.g., "BUST", "Bust Size", "Chest" all map to "Bust (in)"
 	headerMap := map[string]string{
 		"size":  "Size",
-		"bust":  "Bust (in)",
-		"waist": "Waist (in)",
-		"hip":   "Hip (in)",
-		"hips":  "Hip (in)", // Handle both singular and plural forms
+		"bust":  bust,
+		"waist": waist,
+		"hip":   hip,
+		"hips":  hip, // Handle both singular and plural forms
 	}
 
 	// Create a mapping from input headers to canonical output headers
@@ -179,8 +431,9 @@ func (b *BaseAdapter) FilterSizeChart(sizeChart *types.SizeChart) *types.SizeCha
 	}
 
 	// Debug logging to help troubleshoot header mapping issues
-	fmt.Printf("Processing headers: %v\n", sizeChart.Headers)
-	fmt.Printf("Input to output mapping: %v\n", inputToOutput)
+	logger := b.loggerFor(ctx)
+	logger.Debugf("Processing headers: %v", sizeChart.Headers)
+	logger.Debugf("Input to output mapping: %v", inputToOutput)
 
 	// If no relevant headers found (Bust/Waist/Hip/Size), return nil
 	// This prevents processing tables that aren't actually size charts
@@ -214,15 +467,66 @@ func (b *BaseAdapter) FilterSizeChart(sizeChart *types.SizeChart) *types.SizeCha
 		
 		// Only add rows that have at least one measurement value
 		// This filters out completely empty rows or rows with only size labels
-		if filteredRow["Bust (in)"] != "" || filteredRow["Waist (in)"] != "" || filteredRow["Hip (in)"] != "" {
+		if filteredRow[bust] != "" || filteredRow[waist] != "" || filteredRow[hip] != "" {
 			filteredRows = append(filteredRows, filteredRow)
 		}
 	}
 
-	return &types.SizeChart{
-		Headers: outputHeaders,
-		Rows:    filteredRows,
+	filtered := &types.SizeChart{
+		Name:           sizeChart.Name,
+		Unit:           unit,
+		Source:         sizeChart.Source,
+		Headers:        outputHeaders,
+		Rows:           filteredRows,
+		Parser:         sizeChart.Parser,
+		UnitConfidence: confidence,
+		UnitCheck:      sizeChart.UnitCheck,
+	}
+	// Consumers asking for the raw table get the columns the filter dropped too
+	if b.config.IncludeRaw {
+		filtered.Raw = &types.RawChart{
+			Headers: append([]string(nil), sizeChart.Headers...),
+			Rows:    append([]map[string]string(nil), sizeChart.Rows...),
+		}
 	}
+	return filtered
+}
+
+// DetectUnit inspects the " (in)" / " (cm)" header suffixes of a chart and returns
+// the unit shared by all measurement columns, UnitMixed when both appear, or an
+// empty string when no header carries a unit suffix.
+func (b *BaseAdapter) DetectUnit(headers []string) string {
+	hasInches, hasCentimeters := false, false
+	for _, h := range headers {
+		lower := strings.ToLower(strings.TrimSpace(h))
+		if strings.HasSuffix(lower, "(in)") || strings.HasSuffix(lower, "(inches)") {
+			hasInches = true
+		}
+		if strings.HasSuffix(lower, "(cm)") {
+			hasCentimeters = true
+		}
+	}
+
+	switch {
+	case hasInches && hasCentimeters:
+		return types.UnitMixed
+	case hasInches:
+		return types.UnitInches
+	case hasCentimeters:
+		return types.UnitCentimeters
+	}
+	return ""
+}
+
+// ChartNameFromHeaders derives a human readable chart name from the raw table headers.
+// "To fit" columns describe the wearer's body rather than the garment itself.
+func (b *BaseAdapter) ChartNameFromHeaders(headers []string) string {
+	for _, h := range headers {
+		if strings.Contains(strings.ToLower(h), "to fit") {
+			return "Body Measurements"
+		}
+	}
+	return ""
 }
 
 // IsValidSizeChart checks if the extracted data looks like a valid size chart
@@ -274,99 +578,19 @@ func (b *BaseAdapter) RemoveDuplicateURLs(urls []string) []string {
 	return uniqueURLs
 }
 
-// ExtractCollectionURLs finds all collection URLs from the products page
-// This is a shared utility that can be used by all adapters
-func (b *BaseAdapter) ExtractCollectionURLs(doc *goquery.Document, baseURL string) ([]string, error) {
-	var collectionURLs []string
-
-	// Find all <a> tags that contain "collections" in their href
-	doc.Find("a[href*='collections']").Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if !exists {
-			return
-		}
-
-		// Clean and normalize the URL
-		href = strings.TrimSpace(href)
-		if href == "" {
-			return
-		}
-
-		// Convert relative URLs to absolute URLs
-		if strings.HasPrefix(href, "/") {
-			href = baseURL + href
-		} else if !strings.HasPrefix(href, "http") {
-			href = baseURL + "/" + href
-		}
-
-		// Validate URL
-		if _, err := url.Parse(href); err == nil {
-			collectionURLs = append(collectionURLs, href)
-		}
-	})
-
-	if len(collectionURLs) == 0 {
-		return nil, fmt.Errorf("no collection URLs found")
-	}
-
-	return collectionURLs, nil
-}
-
-// ExtractProductURLsFromCollection extracts product URLs from a collection page
+// ExtractLinks returns the absolute URLs of the links matching selector, resolving
+// relative hrefs against baseURL
 // This is a shared utility that can be used by all adapters
-func (b *BaseAdapter) ExtractProductURLsFromCollection(doc *goquery.Document, baseURL string) ([]string, error) {
-	var productURLs []string
-
-	// Find all <a> tags that contain "/products/" in their href
-	doc.Find("a[href*='/products/']").Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if !exists {
-			return
-		}
-
-		// Clean and normalize the URL
-		href = strings.TrimSpace(href)
-		if href == "" {
-			return
-		}
-
-		// Convert relative URLs to absolute URLs
-		if strings.HasPrefix(href, "/") {
-			href = baseURL + href
-		} else if !strings.HasPrefix(href, "http") {
-			href = baseURL + "/" + href
-		}
+func (b *BaseAdapter) ExtractLinks(doc *goquery.Document, baseURL string, selector string) []string {
+	var links []string
 
-		// Validate URL
-		if _, err := url.Parse(href); err == nil {
-			productURLs = append(productURLs, href)
+	doc.Find(selector).Each(func(i int, s *goquery.Selection) {
+		if link := ResolveURL(baseURL, s.AttrOr("href", "")); link != "" {
+			links = append(links, link)
 		}
 	})
 
-	return productURLs, nil
-}
-
-// ExtractProductTitleFromDoc extracts the product title from an already parsed document
-// This is a shared utility that can be used by all adapters
-func (b *BaseAdapter) ExtractProductTitleFromDoc(doc *goquery.Document) (string, error) {
-	// Try different selectors for product title
-	selectors := []string{
-		"h1.product-title",
-		"h1[class*='title']",
-		".product-name h1",
-		".product-info h1",
-		".product-details h1",
-		"h1",
-	}
-
-	for _, selector := range selectors {
-		title, err := b.ExtractText(doc, selector)
-		if err == nil && title != "" {
-			return title, nil
-		}
-	}
-
-	return "", fmt.Errorf("product title not found on page")
+	return links
 }
 
 // Config returns the config field of the BaseAdapter
@@ -0,0 +1,193 @@
+package adapters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/archive"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+)
+
+const (
+	staticProductPage   = `<html><body><h1>Dress</h1></body></html>`
+	renderedProductPage = `<html><body><h1>Dress</h1><table class="ks-table"></table></body></html>`
+)
+
+// newFallbackTestAdapter returns an adapter whose "browser" is a render service stub
+func newFallbackTestAdapter(t *testing.T, renderHandler http.HandlerFunc) *BaseAdapter {
+	renderServer := httptest.NewServer(renderHandler)
+	t.Cleanup(renderServer.Close)
+
+	config := types.DefaultConfig()
+	config.RequestDelay = 0
+	config.MaxRetries = 0
+	config.Timeout = 5 * time.Second
+	config.Browser.Backend = utils.BrowserBackendRender
+	config.Browser.RenderServiceURL = renderServer.URL
+
+	adapter := NewShopifyBaseAdapter(config, logrus.New())
+	adapter.SetExpectedContainers("table.ks-table")
+	return adapter.BaseAdapter
+}
+
+func TestGetPageContent_BrowserFailureFallsBackToHTTP(t *testing.T) {
+	store := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(renderedProductPage))
+	}))
+	defer store.Close()
+
+	adapter := newFallbackTestAdapter(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "browser crashed", http.StatusInternalServerError)
+	})
+	adapter.config.UseHeadlessBrowser = true
+
+	html, err := adapter.GetPageContent(context.Background(), store.URL+"/products/dress")
+	require.NoError(t, err)
+	assert.Equal(t, renderedProductPage, html)
+
+	adapter.config.FetchFallback = false
+	_, err = adapter.GetPageContent(context.Background(), store.URL+"/products/dress")
+	assert.Equal(t, types.MissingReasonFetchBlocked, FailureReason(err))
+}
+
+func TestGetPageContent_InterstitialPage(t *testing.T) {
+	fetches := 0
+	store := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Write([]byte(renderedProductPage))
+	}))
+	defer store.Close()
+
+	adapter := newFallbackTestAdapter(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<form action="/challenge" method="post"></form>`))
+	})
+	adapter.config.UseHeadlessBrowser = true
+
+	_, err := adapter.GetPageContent(context.Background(), store.URL+"/products/dress")
+	assert.Equal(t, types.MissingReasonChallenge, FailureReason(err))
+	assert.Zero(t, fetches, "interstitial pages are not refetched over HTTP")
+}
+
+func TestGetPageContentWhenVisible(t *testing.T) {
+	adapter := newFallbackTestAdapter(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(renderedProductPage))
+	})
+	adapter.config.UseHeadlessBrowser = true
+
+	// The render service returns loaded pages, so the wait is a no-op
+	html, err := adapter.GetPageContentWhenVisible(context.Background(), "https://store.test/collections/all", ".grid a", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, renderedProductPage, html)
+}
+
+func TestGetPageContentScrolled(t *testing.T) {
+	adapter := newFallbackTestAdapter(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(renderedProductPage))
+	})
+	adapter.config.UseHeadlessBrowser = true
+
+	// Render services cannot scroll, so the page is returned as first loaded
+	scroll := utils.ScrollOptions{Items: ".grid a", Wait: time.Second, Settle: time.Millisecond, MaxScrolls: 3}
+	html, err := adapter.GetPageContentScrolled(context.Background(), "https://store.test/collections/all", scroll)
+	require.NoError(t, err)
+	assert.Equal(t, renderedProductPage, html)
+}
+
+func TestGetPageContent_StaticPageWithoutContainerIsRendered(t *testing.T) {
+	store := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(staticProductPage))
+	}))
+	defer store.Close()
+
+	renders := 0
+	adapter := newFallbackTestAdapter(t, func(w http.ResponseWriter, r *http.Request) {
+		renders++
+		w.Write([]byte(renderedProductPage))
+	})
+	adapter.config.UseHeadlessBrowser = false
+
+	html, err := adapter.GetPageContent(context.Background(), store.URL+"/products/dress")
+	require.NoError(t, err)
+	assert.Equal(t, renderedProductPage, html)
+	assert.Equal(t, 1, renders)
+
+	// Collection pages are never rendered for a missing chart container
+	html, err = adapter.GetPageContent(context.Background(), store.URL+"/collections/dresses")
+	require.NoError(t, err)
+	assert.Equal(t, staticProductPage, html)
+	assert.Equal(t, 1, renders)
+}
+
+func TestGetPageContent_ArchivesProductPages(t *testing.T) {
+	store := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(renderedProductPage))
+	}))
+	defer store.Close()
+
+	adapter := newFallbackTestAdapter(t, func(w http.ResponseWriter, r *http.Request) {})
+	adapter.config.UseHeadlessBrowser = false
+	adapter.config.ArchiveDir = t.TempDir()
+	adapter.config.RunID = "run-1"
+
+	_, err := adapter.GetPageContent(context.Background(), store.URL+"/products/dress")
+	require.NoError(t, err)
+	_, err = adapter.GetPageContent(context.Background(), store.URL+"/collections/dresses")
+	require.NoError(t, err)
+
+	a, err := archive.Open(adapter.config.ArchiveDir)
+	require.NoError(t, err)
+	entries, err := a.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "only product pages are archived")
+	assert.Equal(t, "run-1", entries[0].RunID)
+	assert.Equal(t, store.URL+"/products/dress", entries[0].URL)
+
+	html, err := a.Get(entries[0].Digest)
+	require.NoError(t, err)
+	assert.Equal(t, renderedProductPage, html)
+}
+
+func TestBaseAdapter_ProductURLMatcher(t *testing.T) {
+	adapter := NewBaseAdapter(types.DefaultConfig(), logrus.New())
+	assert.False(t, adapter.IsProductURL("https://shop.example/products/dress"), "the base adapter assumes no platform")
+
+	adapter.SetProductURLMatcher(func(pageURL string) bool { return strings.Contains(pageURL, "/product/") })
+	assert.True(t, adapter.IsProductURL("https://shop.example/product/dress"))
+	assert.False(t, adapter.IsProductURL("https://shop.example/shop/page/2"))
+
+	shopify := NewShopifyBaseAdapter(types.DefaultConfig(), logrus.New())
+	assert.True(t, shopify.IsProductURL("https://shop.example/products/dress"))
+	assert.False(t, shopify.IsProductURL("https://shop.example/collections/dresses"))
+}
+
+func TestGetPageContent_LogsThroughContextLogger(t *testing.T) {
+	store := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(renderedProductPage))
+	}))
+	defer store.Close()
+
+	adapter := newFallbackTestAdapter(t, func(w http.ResponseWriter, r *http.Request) {})
+	adapter.config.UseHeadlessBrowser = false
+
+	var out strings.Builder
+	runLogger := logrus.New()
+	runLogger.SetOutput(&out)
+	runLogger.SetLevel(logrus.DebugLevel)
+	ctx := utils.ContextWithLogger(context.Background(), utils.WithFields(runLogger, map[string]interface{}{"run_id": "abc"}))
+
+	_, err := adapter.GetPageContent(ctx, store.URL+"/products/dress")
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Successfully retrieved")
+	assert.Contains(t, out.String(), "run_id=abc")
+
+	// Adapter calls without a logger fall back to the adapter's own
+	assert.Equal(t, adapter.logger, adapter.loggerFor(types.Context{}))
+}
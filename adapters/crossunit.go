@@ -0,0 +1,276 @@
+package adapters
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"shopify-extractor/internal/types"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// unitTolerance is the relative error allowed between a centimeter value and its inch
+// value × 2.54, covering charts rounding to whole numbers
+const unitTolerance = 0.05
+
+// unitAgreement is the share of compared cells that must agree for a verdict
+const unitAgreement = 0.8
+
+var (
+	inchWords       = regexp.MustCompile(`(?i)\b(inch|inches)\b`)
+	centimeterWords = regexp.MustCompile(`(?i)\b(cm|cms|centimeters?|centimetres?)\b`)
+)
+
+// unitPair is a measurement read in both units: the inch and centimeter cells of one
+// row, from two columns of a chart or from two charts
+type unitPair struct {
+	in, cm string
+}
+
+// compareUnits returns the verdict for a set of pairs: "" when the cm values are the
+// inch values × 2.54, UnitCheckSwapped when they match the other way round and
+// UnitCheckMismatch otherwise. ok is false when no pair holds numbers on both sides.
+func compareUnits(pairs []unitPair) (verdict string, ok bool) {
+	compared, agree, swapped := 0, 0, 0
+	for _, pair := range pairs {
+		in, inOK := types.ParseMeasurement(pair.in)
+		cm, cmOK := types.ParseMeasurement(pair.cm)
+		if !inOK || !cmOK {
+			continue
+		}
+		compared++
+		switch {
+		case converts(in.Min, cm.Min) && converts(in.Max, cm.Max):
+			agree++
+		case converts(cm.Min, in.Min) && converts(cm.Max, in.Max):
+			swapped++
+		}
+	}
+
+	switch {
+	case compared == 0:
+		return "", false
+	case float64(agree) >= unitAgreement*float64(compared):
+		return "", true
+	case float64(swapped) >= unitAgreement*float64(compared):
+		return types.UnitCheckSwapped, true
+	}
+	return types.UnitCheckMismatch, true
+}
+
+// converts reports whether cm is inches × 2.54 within unitTolerance
+func converts(inches, cm float64) bool {
+	return math.Abs(inches*2.54-cm) <= unitTolerance*math.Max(cm, 1)
+}
+
+// pageUnit returns the unit the size guide text names most ("measurements in inches"),
+// or "" when it names none or both as often. The expected chart containers are read
+// when present, else the whole page.
+func (b *BaseAdapter) pageUnit(doc *goquery.Document) string {
+	var text string
+	for _, selector := range b.expectedContainers {
+		text += " " + doc.Find(selector).Text()
+	}
+	if strings.TrimSpace(text) == "" {
+		text = doc.Find("body").Text()
+	}
+
+	inches, centimeters := len(inchWords.FindAllString(text, -1)), len(centimeterWords.FindAllString(text, -1))
+	switch {
+	case inches > centimeters:
+		return types.UnitInches
+	case centimeters > inches:
+		return types.UnitCentimeters
+	}
+	return ""
+}
+
+// crossCheckUnits validates charts holding both units: the "(in)" and "(cm)" columns of
+// one chart, or an inches chart and a centimeters chart with the same rows. Swapped
+// values are put back; on any other disagreement the charts are flagged and, when the
+// page text names a unit, only that unit is kept. The checked charts are returned.
+func (b *BaseAdapter) crossCheckUnits(doc *goquery.Document, charts []*types.SizeChart) []*types.SizeChart {
+	preferred := ""
+	preferredUnit := func() string {
+		if preferred == "" {
+			preferred = b.pageUnit(doc)
+		}
+		return preferred
+	}
+
+	for _, chart := range charts {
+		columns := unitColumns(chart)
+		var pairs []unitPair
+		for _, row := range chart.Rows {
+			for _, column := range columns {
+				pairs = append(pairs, unitPair{in: row[column.in], cm: row[column.cm]})
+			}
+		}
+		verdict, ok := compareUnits(pairs)
+		if !ok || verdict == "" {
+			continue
+		}
+
+		b.logger.Warnf("Inch and centimeter values of chart %q disagree: %s", chart.Name, verdict)
+		chart.UnitCheck = verdict
+		if verdict == types.UnitCheckSwapped {
+			for _, row := range chart.Rows {
+				for _, column := range columns {
+					row[column.in], row[column.cm] = row[column.cm], row[column.in]
+				}
+			}
+		} else if unit := preferredUnit(); unit != "" {
+			keepUnitColumns(chart, unit)
+		}
+	}
+
+	inChart, cmChart := unitChartPair(charts)
+	if inChart == nil {
+		return charts
+	}
+	var pairs []unitPair
+	for i, inRow := range inChart.Rows {
+		for _, header := range inChart.Headers {
+			if name := unitlessHeader(header); name != "" {
+				pairs = append(pairs, unitPair{in: inRow[header], cm: cellByName(cmChart.Rows[i], name)})
+			}
+		}
+	}
+	verdict, ok := compareUnits(pairs)
+	if !ok || verdict == "" {
+		return charts
+	}
+
+	b.logger.Warnf("Inch and centimeter charts disagree: %s", verdict)
+	inChart.UnitCheck, cmChart.UnitCheck = verdict, verdict
+	if verdict == types.UnitCheckSwapped {
+		relabelUnit(inChart, types.UnitInches, types.UnitCentimeters)
+		relabelUnit(cmChart, types.UnitCentimeters, types.UnitInches)
+		return charts
+	}
+	var dropped *types.SizeChart
+	switch preferredUnit() {
+	case types.UnitInches:
+		dropped = cmChart
+	case types.UnitCentimeters:
+		dropped = inChart
+	default:
+		return charts
+	}
+	var kept []*types.SizeChart
+	for _, chart := range charts {
+		if chart != dropped {
+			kept = append(kept, chart)
+		}
+	}
+	return kept
+}
+
+// unitColumn is a measurement present in a chart as an "(in)" and a "(cm)" column
+type unitColumn struct {
+	in, cm string
+}
+
+// unitColumns pairs up the "X (in)" and "X (cm)" headers of a chart
+func unitColumns(chart *types.SizeChart) []unitColumn {
+	var columns []unitColumn
+	for _, header := range chart.Headers {
+		name, unit := splitUnitSuffix(header)
+		if unit != types.UnitInches {
+			continue
+		}
+		for _, other := range chart.Headers {
+			if otherName, otherUnit := splitUnitSuffix(other); otherUnit == types.UnitCentimeters && strings.EqualFold(otherName, name) {
+				columns = append(columns, unitColumn{in: header, cm: other})
+				break
+			}
+		}
+	}
+	return columns
+}
+
+// unitChartPair returns an inches chart and a centimeters chart with the same number of
+// rows, or nils when the charts are not such a pair
+func unitChartPair(charts []*types.SizeChart) (inChart, cmChart *types.SizeChart) {
+	for _, chart := range charts {
+		switch {
+		case chart.Unit == types.UnitInches && inChart == nil:
+			inChart = chart
+		case chart.Unit == types.UnitCentimeters && cmChart == nil:
+			cmChart = chart
+		}
+	}
+	if inChart == nil || cmChart == nil || len(inChart.Rows) != len(cmChart.Rows) {
+		return nil, nil
+	}
+	return inChart, cmChart
+}
+
+// splitUnitSuffix splits "Bust (in)" into "Bust" and "in"; unit is "" for other headers
+func splitUnitSuffix(header string) (name, unit string) {
+	for _, u := range []string{types.UnitInches, types.UnitCentimeters} {
+		if trimmed := strings.TrimSuffix(header, " ("+u+")"); trimmed != header {
+			return trimmed, u
+		}
+	}
+	return header, ""
+}
+
+// unitlessHeader returns a measurement header without its unit suffix, or "" for the
+// size column
+func unitlessHeader(header string) string {
+	name, _ := splitUnitSuffix(header)
+	if strings.Contains(strings.ToLower(name), "size") {
+		return ""
+	}
+	return name
+}
+
+// cellByName returns the cell of a row whose header, unit suffix aside, is name
+func cellByName(row map[string]string, name string) string {
+	for header, cell := range row {
+		if other, _ := splitUnitSuffix(header); strings.EqualFold(other, name) {
+			return cell
+		}
+	}
+	return ""
+}
+
+// keepUnitColumns removes the columns of the other unit from a chart holding both
+func keepUnitColumns(chart *types.SizeChart, unit string) {
+	var headers []string
+	for _, header := range chart.Headers {
+		if _, headerUnit := splitUnitSuffix(header); headerUnit == "" || headerUnit == unit {
+			headers = append(headers, header)
+			continue
+		}
+		for _, row := range chart.Rows {
+			delete(row, header)
+		}
+	}
+	chart.Headers = headers
+	chart.Unit = unit
+}
+
+// relabelUnit turns a chart read as one unit into the other: its Unit and the unit
+// suffixes of its headers and row keys
+func relabelUnit(chart *types.SizeChart, from, to string) {
+	chart.Unit = to
+	rename := func(header string) string {
+		if name, unit := splitUnitSuffix(header); unit == from {
+			return name + " (" + to + ")"
+		}
+		return header
+	}
+	for i, header := range chart.Headers {
+		chart.Headers[i] = rename(header)
+	}
+	for i, row := range chart.Rows {
+		renamed := make(map[string]string, len(row))
+		for header, cell := range row {
+			renamed[rename(header)] = cell
+		}
+		chart.Rows[i] = renamed
+	}
+}
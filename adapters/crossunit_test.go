@@ -0,0 +1,81 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+// westsideSwappedSpans has the default / alt spans the wrong way round: span.default
+// should hold centimeters but holds inches
+const westsideSwappedSpans = `<html><body><div class="sizeguide"><table>
+<thead><tr><th>Size</th><th>Chest</th><th>Waist</th></tr></thead>
+<tbody><tr><td><span class="default">S</span></td><td><span class="default">36</span><span class="alt">91</span></td><td><span class="default">30</span><span class="alt">76</span></td></tr>
+<tr><td><span class="default">M</span></td><td><span class="default">38</span><span class="alt">97</span></td><td><span class="default">32</span><span class="alt">81</span></td></tr></tbody>
+</table></div></body></html>`
+
+func chartByUnit(charts []*types.SizeChart, unit string) *types.SizeChart {
+	for _, chart := range charts {
+		if chart.Unit == unit {
+			return chart
+		}
+	}
+	return nil
+}
+
+func TestCrossCheckUnits_SwappedWestsideSpans(t *testing.T) {
+	adapter := NewWestsideAdapter(types.DefaultConfig(), logrus.New())
+
+	charts, err := adapter.ParseSizeCharts(parseTestDoc(t, westsideSwappedSpans))
+	require.NoError(t, err)
+	require.Len(t, charts, 2)
+
+	inches := chartByUnit(charts, types.UnitInches)
+	require.NotNil(t, inches)
+	assert.Equal(t, types.UnitCheckSwapped, inches.UnitCheck)
+	assert.Equal(t, "36", inches.Rows[0]["Chest (in)"])
+	assert.Equal(t, "81", chartByUnit(charts, types.UnitCentimeters).Rows[1]["Waist (cm)"])
+}
+
+func TestCrossCheckUnits_MixedChart(t *testing.T) {
+	adapter := newParserTestAdapter()
+	mixed := func(cm string) *types.SizeChart {
+		return &types.SizeChart{
+			Unit:    types.UnitMixed,
+			Headers: []string{"Size", "Bust (in)", "Bust (cm)"},
+			Rows: []map[string]string{
+				{"Size": "S", "Bust (in)": "34", "Bust (cm)": cm},
+				{"Size": "M", "Bust (in)": "36", "Bust (cm)": "91"},
+			},
+		}
+	}
+
+	agreeing := mixed("86")
+	adapter.crossCheckUnits(parseTestDoc(t, "<p>All measurements in inches</p>"), []*types.SizeChart{agreeing})
+	assert.Empty(t, agreeing.UnitCheck)
+
+	// Off by far more than rounding: flagged, and the unit named by the page text is kept
+	disagreeing := mixed("70")
+	disagreeing.Rows[1]["Bust (cm)"] = "40"
+	adapter.crossCheckUnits(parseTestDoc(t, "<p>All measurements in inches</p>"), []*types.SizeChart{disagreeing})
+	assert.Equal(t, types.UnitCheckMismatch, disagreeing.UnitCheck)
+	assert.Equal(t, types.UnitInches, disagreeing.Unit)
+	assert.Equal(t, []string{"Size", "Bust (in)"}, disagreeing.Headers)
+	assert.Equal(t, map[string]string{"Size": "S", "Bust (in)": "34"}, disagreeing.Rows[0])
+}
+
+func TestCompareUnits(t *testing.T) {
+	verdict, ok := compareUnits([]unitPair{{in: "34-36", cm: "86-91"}, {in: "38", cm: "97"}})
+	assert.True(t, ok)
+	assert.Empty(t, verdict)
+
+	verdict, ok = compareUnits([]unitPair{{in: "86", cm: "34"}})
+	assert.True(t, ok)
+	assert.Equal(t, types.UnitCheckSwapped, verdict)
+
+	_, ok = compareUnits([]unitPair{{in: "-", cm: ""}})
+	assert.False(t, ok)
+}
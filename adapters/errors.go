@@ -0,0 +1,57 @@
+package adapters
+
+import (
+	"errors"
+
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+)
+
+// ExtractionError annotates an extraction failure with the probable reason a product
+// yielded no size chart, so callers can report missing charts grouped by cause
+type ExtractionError struct {
+	Reason string // One of the types.MissingReason* constants
+	Err    error
+}
+
+func (e *ExtractionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExtractionError) Unwrap() error {
+	return e.Err
+}
+
+// noTableError reports that no size chart markup was found on the page
+func noTableError(message string) error {
+	return &ExtractionError{Reason: types.MissingReasonNoTable, Err: errors.New(message)}
+}
+
+// rejectedError reports that a table was found but could not be used as a size chart
+func rejectedError(message string) error {
+	return &ExtractionError{Reason: types.MissingReasonRejected, Err: errors.New(message)}
+}
+
+// fetchError annotates a failed page fetch: interstitial pages (password, bot challenge,
+// queue) are reported by their kind, every other failure as blocked
+func fetchError(err error) error {
+	var interstitial *utils.InterstitialError
+	if errors.As(err, &interstitial) {
+		return &ExtractionError{Reason: interstitial.Kind, Err: err}
+	}
+	return &ExtractionError{Reason: types.MissingReasonFetchBlocked, Err: err}
+}
+
+// FailureReason classifies an extraction error into a types.MissingReason* constant.
+// A nil error means the page was parsed but every candidate chart was filtered out.
+func FailureReason(err error) string {
+	if err == nil {
+		return types.MissingReasonRejected
+	}
+
+	var extractionErr *ExtractionError
+	if errors.As(err, &extractionErr) {
+		return extractionErr.Reason
+	}
+	return types.MissingReasonUnknown
+}
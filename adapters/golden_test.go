@@ -0,0 +1,68 @@
+package adapters
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files under testdata/golden")
+
+// goldenAdapters creates the adapter that parses the fixtures of each testdata/golden
+// directory
+var goldenAdapters = map[string]func(config *types.Config) *BaseAdapter{
+	"nykaafashion": func(config *types.Config) *BaseAdapter {
+		return NewNykaaFashionAdapter(config, logrus.New()).BaseAdapter
+	},
+}
+
+// goldenResult is what a fixture is expected to yield
+type goldenResult struct {
+	Title      string             `json:"title"`
+	SizeCharts []*types.SizeChart `json:"size_charts"`
+}
+
+// TestGolden parses every testdata/golden/<store>/*.html fixture with the store's adapter
+// and compares the title and size charts with the .json file next to it. Run with -update
+// to rewrite the golden files after an intended parser change.
+func TestGolden(t *testing.T) {
+	for store, newAdapter := range goldenAdapters {
+		fixtures, err := filepath.Glob(filepath.Join("testdata", "golden", store, "*.html"))
+		require.NoError(t, err)
+		require.NotEmpty(t, fixtures, "no fixtures for %s", store)
+
+		adapter := newAdapter(types.DefaultConfig())
+		for _, fixture := range fixtures {
+			name := store + "/" + strings.TrimSuffix(filepath.Base(fixture), ".html")
+			t.Run(name, func(t *testing.T) {
+				html, err := os.ReadFile(fixture)
+				require.NoError(t, err)
+				doc, err := adapter.ParseHTML(string(html))
+				require.NoError(t, err)
+
+				var result goldenResult
+				result.Title, _ = adapter.ExtractProductTitleFromDoc(doc)
+				result.SizeCharts, err = adapter.ParseSizeCharts(doc)
+				require.NoError(t, err)
+				got, err := json.MarshalIndent(result, "", "  ")
+				require.NoError(t, err)
+
+				golden := strings.TrimSuffix(fixture, ".html") + ".json"
+				if *updateGolden {
+					require.NoError(t, os.WriteFile(golden, append(got, '\n'), 0o644))
+				}
+				want, err := os.ReadFile(golden)
+				require.NoError(t, err, "missing golden file; run go test ./adapters -run TestGolden -update")
+				assert.JSONEq(t, string(want), string(got))
+			})
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package adapters
+
+import "strings"
+
+// SizeKeywords are the tokens, in lower case, of which at least one appears on every page
+// carrying a size chart: in headers, captions or the chart widget's markup
+var SizeKeywords = []string{"size", "bust", "cm", "measurement"}
+
+// HasSizeKeywords reports whether html contains any of SizeKeywords, ignoring case. It
+// is a cheap scan over the raw HTML, run before parsing the page and trying the chart
+// parsers and selectors.
+func HasSizeKeywords(html string) bool {
+	lower := strings.ToLower(html)
+	for _, keyword := range SizeKeywords {
+		if strings.Contains(lower, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipChartless returns a no_table error for pages without any of SizeKeywords, so they
+// are reported as missing a chart without being parsed
+func skipChartless(html string) error {
+	if HasSizeKeywords(html) {
+		return nil
+	}
+	return noTableError("no size-related keywords on page")
+}
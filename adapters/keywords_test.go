@@ -0,0 +1,22 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"shopify-extractor/internal/types"
+)
+
+func TestHasSizeKeywords(t *testing.T) {
+	assert.True(t, HasSizeKeywords(`<div class="SizeGuide">Chart</div>`))
+	assert.True(t, HasSizeKeywords(`<td>Bust</td><td>86</td>`))
+	assert.True(t, HasSizeKeywords(`<p>Measurements in inches</p>`))
+	assert.False(t, HasSizeKeywords(`<html><body><h1>Gift card</h1><p>Redeem online.</p></body></html>`))
+}
+
+func TestSkipChartless(t *testing.T) {
+	assert.NoError(t, skipChartless(`<table><tr><th>Size</th></tr></table>`))
+
+	err := skipChartless(`<html><body><h1>Gift card</h1></body></html>`)
+	assert.Equal(t, types.MissingReasonNoTable, FailureReason(err))
+}
@@ -4,24 +4,29 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
 	"strings"
 
+	"shopify-extractor/frontier"
 	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
 // LittleBoxIndiaAdapter handles extraction for littleboxindia.com
 type LittleBoxIndiaAdapter struct {
-	*BaseAdapter
+	*ShopifyBaseAdapter
 }
 
 // NewLittleBoxIndiaAdapter creates a new LittleBoxIndia adapter
 func NewLittleBoxIndiaAdapter(config *types.Config, logger types.Logger) *LittleBoxIndiaAdapter {
-	return &LittleBoxIndiaAdapter{
-		BaseAdapter: NewBaseAdapter(config, logger),
+	adapter := &LittleBoxIndiaAdapter{
+		ShopifyBaseAdapter: NewShopifyBaseAdapter(config, logger),
 	}
+	adapter.SetExpectedContainers("table.ks-table")
+	adapter.AddChartParser(NewChartParser("kiwi", HasElement("table.ks-table"), adapter.parseKiwiTable), PriorityKiwi)
+	adapter.SetStoreName(adapter.GetStoreName())
+	return adapter
 }
 
 // GetStoreName returns the store name
@@ -29,64 +34,113 @@ func (l *LittleBoxIndiaAdapter) GetStoreName() string {
 	return "littleboxindia.com"
 }
 
+// BaseURL returns the storefront root URL
+func (l *LittleBoxIndiaAdapter) BaseURL() string {
+	return "https://www.littleboxindia.com"
+}
+
 // GetProductURLs returns a list of product URLs for LittleBoxIndia
 func (l *LittleBoxIndiaAdapter) GetProductURLs(ctx types.Context) ([]string, error) {
-	l.logger.Info("Starting product discovery for LittleBoxIndia")
+	var productURLs []string
+	err := l.StreamProductURLs(l.fetchContext(ctx), func(productURL string) bool {
+		productURLs = append(productURLs, productURL)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return productURLs, nil
+}
+
+// StreamProductURLs discovers LittleBoxIndia product URLs from /products.json with json
+// discovery, otherwise from /collections/all, or collection by collection when the store
+// does not list its catalog there, passing each unique URL to emit as soon as it is found.
+// Discovery stops early when emit returns false.
+func (l *LittleBoxIndiaAdapter) StreamProductURLs(ctx context.Context, emit func(productURL string) bool) error {
+	l.loggerFrom(ctx).Info("Starting product discovery for LittleBoxIndia")
+
+	seen := frontier.NewSet(l.config.FrontierMemoryLimit, l.config.FrontierDir)
+	defer seen.Close()
+	if handled, err := l.tryProductsJSON(ctx, "https://www.littleboxindia.com", seen, emit); handled {
+		return err
+	}
+
+	// /collections/all lists the whole catalog without per-collection duplicates
+	err := l.StreamAllCollection(ctx, "https://www.littleboxindia.com", seen, emit)
+	if err == nil {
+		l.loggerFrom(ctx).Infof("Total unique products found on /collections/all: %d", seen.Len())
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	l.loggerFrom(ctx).Infof("Crawling collections instead of /collections/all: %v", err)
 
 	// Step 1: Get the products page
 	productsPageURL := "https://www.littleboxindia.com/products"
-	l.logger.Debugf("Fetching products page: %s", productsPageURL)
+	l.loggerFrom(ctx).Debugf("Fetching products page: %s", productsPageURL)
 
-	html, err := l.GetPageContent(context.Background(), productsPageURL)
+	html, err := l.GetPageContent(ctx, productsPageURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get products page: %w", err)
+		return fmt.Errorf("failed to get products page: %w", err)
 	}
 
 	doc, err := l.ParseHTML(html)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse products page: %w", err)
+		return fmt.Errorf("failed to parse products page: %w", err)
 	}
 
 	// Step 2: Find all collection URLs
 	collectionURLs, err := l.ExtractCollectionURLs(doc, "https://www.littleboxindia.com")
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract collection URLs: %w", err)
+		return fmt.Errorf("failed to extract collection URLs: %w", err)
 	}
 
-	l.logger.Infof("Found %d collections", len(collectionURLs))
+	l.loggerFrom(ctx).Infof("Found %d collections", len(collectionURLs))
 
-	// Step 3: Iterate through collections to find product URLs
-	var allProductURLs []string
+	// Step 3: Iterate through collections, emitting new product URLs as they are found
 	for i, collectionURL := range collectionURLs {
-		l.logger.Debugf("Processing collection: %s %d", collectionURL, i+1)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		l.loggerFrom(ctx).Debugf("Processing collection: %s %d", collectionURL, i+1)
 
-		productURLs, err := l.extractProductURLsFromCollection(collectionURL)
+		productURLs, err := l.extractProductURLsFromCollection(ctx, collectionURL)
 		if err != nil {
-			l.logger.Warnf("Failed to extract products from collection %s: %v", collectionURL, err)
+			l.loggerFrom(ctx).Warnf("Failed to extract products from collection %s: %v", collectionURL, err)
 			continue
 		}
 
-		allProductURLs = append(allProductURLs, productURLs...)
-		l.logger.Debugf("Found %d products in collection %s", len(productURLs), collectionURL)
+		l.loggerFrom(ctx).Debugf("Found %d products in collection %s", len(productURLs), collectionURL)
+		for _, productURL := range productURLs {
+			isNew, err := seen.Add(productURL)
+			if err != nil {
+				return fmt.Errorf("failed to record product URL: %w", err)
+			}
+			if !isNew {
+				continue
+			}
+			if !emit(productURL) {
+				l.loggerFrom(ctx).Infof("Product discovery stopped early after %d unique products", seen.Len())
+				return nil
+			}
+		}
 		// Process only first few collections for speed testing
 		if i >= 4 { // Process first 3 collections only
 			break
 		}
 	}
 
-	// Remove duplicates
-	uniqueProductURLs := l.RemoveDuplicateURLs(allProductURLs)
-
-	l.logger.Infof("Total unique products found: %d", len(uniqueProductURLs))
-	return uniqueProductURLs, nil
+	l.loggerFrom(ctx).Infof("Total unique products found: %d", seen.Len())
+	return nil
 }
 
 // extractProductURLsFromCollection extracts product URLs from a collection page
-func (l *LittleBoxIndiaAdapter) extractProductURLsFromCollection(collectionURL string) ([]string, error) {
-	l.logger.Debugf("Extracting products from collection: %s", collectionURL)
+func (l *LittleBoxIndiaAdapter) extractProductURLsFromCollection(ctx context.Context, collectionURL string) ([]string, error) {
+	l.loggerFrom(ctx).Debugf("Extracting products from collection: %s", collectionURL)
 
 	// Get the collection page
-	html, err := l.GetPageContent(context.Background(), collectionURL)
+	html, err := l.GetPageContent(ctx, collectionURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get collection page: %w", err)
 	}
@@ -96,43 +150,18 @@ func (l *LittleBoxIndiaAdapter) extractProductURLsFromCollection(collectionURL s
 		return nil, fmt.Errorf("failed to parse collection page: %w", err)
 	}
 
-	var productURLs []string
-
 	// Find all <a> tags that contain "/products/" in their href
-	doc.Find("a[href*='/products/']").Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if !exists {
-			return
-		}
-
-		// Clean and normalize the URL
-		href = strings.TrimSpace(href)
-		if href == "" {
-			return
-		}
-
-		// Convert relative URLs to absolute URLs
-		if strings.HasPrefix(href, "/") {
-			href = "https://www.littleboxindia.com" + href
-		} else if !strings.HasPrefix(href, "http") {
-			href = "https://www.littleboxindia.com/" + href
-		}
-
-		// Validate URL
-		if _, err := url.Parse(href); err == nil {
-			productURLs = append(productURLs, href)
-		}
-	})
+	productURLs := l.ExtractLinks(doc, l.BaseURL(), "a[href*='/products/']")
 
 	return productURLs, nil
 }
 
 // ExtractSizeChart extracts the size chart from a LittleBoxIndia product page
 func (l *LittleBoxIndiaAdapter) ExtractSizeChart(ctx types.Context, productURL string) (*types.SizeChart, error) {
-	l.logger.Debugf("Extracting size chart from %s", productURL)
+	l.loggerFor(ctx).Debugf("Extracting size chart from %s", productURL)
 
 	// Get page content
-	html, err := l.GetPageContent(context.Background(), productURL)
+	html, err := l.GetPageContent(l.fetchContext(ctx), productURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get page content: %w", err)
 	}
@@ -146,25 +175,25 @@ func (l *LittleBoxIndiaAdapter) ExtractSizeChart(ctx types.Context, productURL s
 	// Find the ks-table (custom size chart table)
 	table := doc.Find("table.ks-table").First()
 	if table.Length() == 0 {
-		l.logger.Debugf("No table found with selector: table.ks-table")
+		l.loggerFor(ctx).Debugf("No table found with selector: table.ks-table")
 		// Let's also check if there are any tables at all
 		allTables := doc.Find("table")
-		l.logger.Debugf("Found %d total tables on the page", allTables.Length())
+		l.loggerFor(ctx).Debugf("Found %d total tables on the page", allTables.Length())
 		allTables.Each(func(i int, s *goquery.Selection) {
 			class, _ := s.Attr("class")
-			l.logger.Debugf("Table %d has class: %s", i, class)
+			l.loggerFor(ctx).Debugf("Table %d has class: %s", i, class)
 		})
-		return nil, fmt.Errorf("no valid size chart found on page")
+		return nil, noTableError("no valid size chart found on page")
 	}
-	l.logger.Debugf("Found table with selector: table.ks-table")
+	l.loggerFor(ctx).Debugf("Found table with selector: table.ks-table")
 
 	// Get all rows with ks-table-row class
 	rows := table.Find("tr.ks-table-row")
 	if rows.Length() == 0 {
-		l.logger.Debugf("No rows found with selector: tr.ks-table-row")
-		return nil, fmt.Errorf("no valid size chart rows found")
+		l.loggerFor(ctx).Debugf("No rows found with selector: tr.ks-table-row")
+		return nil, rejectedError("no valid size chart rows found")
 	}
-	l.logger.Debugf("Found %d rows with ks-table-row class", rows.Length())
+	l.loggerFor(ctx).Debugf("Found %d rows with ks-table-row class", rows.Length())
 
 	// Extract headers from the first row (skip the first cell "SIZE")
 	var sizes []string
@@ -178,10 +207,10 @@ func (l *LittleBoxIndiaAdapter) ExtractSizeChart(ctx types.Context, productURL s
 			sizes = append(sizes, size)
 		}
 	})
-	l.logger.Debugf("Extracted sizes: %v", sizes)
+	l.loggerFor(ctx).Debugf("Extracted sizes: %v", sizes)
 
 	if len(sizes) == 0 {
-		return nil, fmt.Errorf("no size headers found")
+		return nil, rejectedError("no size headers found")
 	}
 
 	// Define the measurement types we want to extract
@@ -206,7 +235,7 @@ func (l *LittleBoxIndiaAdapter) ExtractSizeChart(ctx types.Context, productURL s
 			continue // Skip rows we don't want
 		}
 
-		l.logger.Debugf("Processing measurement: %s -> %s", label, outLabel)
+		l.loggerFor(ctx).Debugf("Processing measurement: %s -> %s", label, outLabel)
 
 		// Prepare row for this measurement
 		inchRow := []string{outLabel}
@@ -220,9 +249,8 @@ func (l *LittleBoxIndiaAdapter) ExtractSizeChart(ctx types.Context, productURL s
 			// Check for data-unit-values attribute
 			dataUnitValues := cell.AttrOr("data-unit-values", "")
 			if dataUnitValues != "" {
-				// Parse the JSON data-unit-values
-				// Replace &quot; with " for proper JSON parsing
-				cleanJSON := strings.ReplaceAll(dataUnitValues, "&quot;", `"`)
+				// Parse the JSON data-unit-values, whose quotes may still be encoded as &quot;
+				cleanJSON := utils.CleanText(dataUnitValues)
 				var unitMap map[string]string
 				if err := json.Unmarshal([]byte(cleanJSON), &unitMap); err == nil {
 					// "0" = inches, "1" = cm - use inches for single chart
@@ -232,7 +260,7 @@ func (l *LittleBoxIndiaAdapter) ExtractSizeChart(ctx types.Context, productURL s
 						inchRow = append(inchRow, "")
 					}
 				} else {
-					l.logger.Debugf("Failed to parse data-unit-values: %s, error: %v", dataUnitValues, err)
+					l.loggerFor(ctx).Debugf("Failed to parse data-unit-values: %s, error: %v", dataUnitValues, err)
 					// Fallback to text content
 					val := strings.TrimSpace(cell.Text())
 					inchRow = append(inchRow, val)
@@ -250,7 +278,7 @@ func (l *LittleBoxIndiaAdapter) ExtractSizeChart(ctx types.Context, productURL s
 		}
 	}
 
-	l.logger.Debugf("Extracted %d inch rows", len(inchRows))
+	l.loggerFor(ctx).Debugf("Extracted %d inch rows", len(inchRows))
 
 	// Build the size chart for inches (default)
 	if len(inchRows) > 0 {
@@ -271,25 +299,28 @@ func (l *LittleBoxIndiaAdapter) ExtractSizeChart(ctx types.Context, productURL s
 		}
 
 		sizeChart := &types.SizeChart{
+			Name:    "Body Measurements",
+			Unit:    types.UnitInches,
+			Source:  types.SourceApp,
 			Headers: headers,
 			Rows:    rows,
 		}
 
 		if l.IsValidSizeChart(sizeChart) {
-			l.logger.Debugf("Successfully extracted size chart with %d rows", len(rows))
+			l.loggerFor(ctx).Debugf("Successfully extracted size chart with %d rows", len(rows))
 			return sizeChart, nil
 		}
 	}
 
-	return nil, fmt.Errorf("no valid size chart found on page")
+	return nil, rejectedError("no valid size chart found on page")
 }
 
 // GetProductTitle extracts the product title from a LittleBoxIndia product page
 func (l *LittleBoxIndiaAdapter) GetProductTitle(ctx types.Context, productURL string) (string, error) {
-	l.logger.Debugf("Extracting product title from %s", productURL)
+	l.loggerFor(ctx).Debugf("Extracting product title from %s", productURL)
 
 	// Get page content
-	html, err := l.GetPageContent(context.Background(), productURL)
+	html, err := l.GetPageContent(l.fetchContext(ctx), productURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to get page content: %w", err)
 	}
@@ -300,34 +331,14 @@ func (l *LittleBoxIndiaAdapter) GetProductTitle(ctx types.Context, productURL st
 		return "", fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
-	// Try different selectors for product title
-	selectors := []string{
-		"h1.product-title",
-		"h1[class*='title']",
-		".product-name h1",
-		".product-info h1",
-		".product-details h1",
-		"h1",
-	}
-
-	for _, selector := range selectors {
-		title, err := l.ExtractText(doc, selector)
-		if err == nil && title != "" {
-			l.logger.Debugf("Successfully extracted product title using selector: %s", selector)
-			return title, nil
-		}
-	}
-
-	return "", fmt.Errorf("product title not found on page")
+	return l.ExtractProductTitleFromDoc(doc)
 }
 
 // ExtractAllSizeCharts extracts all size charts from a LittleBoxIndia product page
 func (l *LittleBoxIndiaAdapter) ExtractAllSizeCharts(ctx types.Context, productURL string) ([]*types.SizeChart, error) {
-	l.logger.Debugf("Extracting all size charts from %s", productURL)
-	var charts []*types.SizeChart
-
+	l.loggerFor(ctx).Debugf("Extracting all size charts from %s", productURL)
 	// Get page content
-	html, err := l.GetPageContent(context.Background(), productURL)
+	html, err := l.GetPageContent(l.fetchContext(ctx), productURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get page content: %w", err)
 	}
@@ -338,11 +349,19 @@ func (l *LittleBoxIndiaAdapter) ExtractAllSizeCharts(ctx types.Context, productU
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
+	return l.ParseSizeCharts(doc)
+}
+
+// parseKiwiTable reads a Kiwi Sizing app table (table.ks-table) into separate inches
+// and centimeters charts
+func (l *LittleBoxIndiaAdapter) parseKiwiTable(doc *goquery.Document) ([]*types.SizeChart, error) {
+	var charts []*types.SizeChart
+
 	// Find the ks-table (custom size chart table)
 	table := doc.Find("table.ks-table").First()
 	if table.Length() == 0 {
 		l.logger.Debugf("No table found with selector: table.ks-table")
-		return nil, fmt.Errorf("no valid size chart found on page")
+		return nil, noTableError("no valid size chart found on page")
 	}
 	l.logger.Debugf("Found table with selector: table.ks-table")
 
@@ -350,7 +369,7 @@ func (l *LittleBoxIndiaAdapter) ExtractAllSizeCharts(ctx types.Context, productU
 	rows := table.Find("tr.ks-table-row")
 	if rows.Length() == 0 {
 		l.logger.Debugf("No rows found with selector: tr.ks-table-row")
-		return nil, fmt.Errorf("no valid size chart rows found")
+		return nil, rejectedError("no valid size chart rows found")
 	}
 	l.logger.Debugf("Found %d rows with ks-table-row class", rows.Length())
 
@@ -369,7 +388,7 @@ func (l *LittleBoxIndiaAdapter) ExtractAllSizeCharts(ctx types.Context, productU
 	l.logger.Debugf("Extracted sizes: %v", sizes)
 
 	if len(sizes) == 0 {
-		return nil, fmt.Errorf("no size headers found")
+		return nil, rejectedError("no size headers found")
 	}
 
 	// Define the measurement types we want to extract
@@ -417,9 +436,8 @@ func (l *LittleBoxIndiaAdapter) ExtractAllSizeCharts(ctx types.Context, productU
 				// Check for data-unit-values attribute
 				dataUnitValues := cell.AttrOr("data-unit-values", "")
 				if dataUnitValues != "" {
-					// Parse the JSON data-unit-values
-					// Replace &quot; with " for proper JSON parsing
-					cleanJSON := strings.ReplaceAll(dataUnitValues, "&quot;", `"`)
+					// Parse the JSON data-unit-values, whose quotes may still be encoded as &quot;
+					cleanJSON := utils.CleanText(dataUnitValues)
 					var unitMap map[string]string
 					if err := json.Unmarshal([]byte(cleanJSON), &unitMap); err == nil {
 						// "0" = inches, "1" = cm
@@ -467,6 +485,9 @@ func (l *LittleBoxIndiaAdapter) ExtractAllSizeCharts(ctx types.Context, productU
 
 	if len(inchRows) > 0 {
 		inchChart := &types.SizeChart{
+			Name:    "Body Measurements",
+			Unit:    types.UnitInches,
+			Source:  types.SourceApp,
 			Headers: inchHeaders,
 			Rows:    inchRows,
 		}
@@ -495,6 +516,9 @@ func (l *LittleBoxIndiaAdapter) ExtractAllSizeCharts(ctx types.Context, productU
 
 	if len(cmRows) > 0 {
 		cmChart := &types.SizeChart{
+			Name:    "Body Measurements",
+			Unit:    types.UnitCentimeters,
+			Source:  types.SourceApp,
 			Headers: cmHeaders,
 			Rows:    cmRows,
 		}
@@ -506,7 +530,7 @@ func (l *LittleBoxIndiaAdapter) ExtractAllSizeCharts(ctx types.Context, productU
 	}
 
 	if len(charts) == 0 {
-		return nil, fmt.Errorf("no valid size chart found on page")
+		return nil, rejectedError("no valid size chart found on page")
 	}
 	return charts, nil
 }
@@ -514,14 +538,19 @@ func (l *LittleBoxIndiaAdapter) ExtractAllSizeCharts(ctx types.Context, productU
 // ExtractProductTitleAndSizeCharts extracts both product title and size charts from a LittleBoxIndia product page
 // This method fetches the page once and extracts both pieces of information
 func (l *LittleBoxIndiaAdapter) ExtractProductTitleAndSizeCharts(ctx types.Context, productURL string) (string, []*types.SizeChart, error) {
-	l.logger.Debugf("Extracting product title and size charts from %s", productURL)
+	l.loggerFor(ctx).Debugf("Extracting product title and size charts from %s", productURL)
 
 	// Get page content once
-	html, err := l.GetPageContent(context.Background(), productURL)
+	html, err := l.GetPageContent(l.fetchContext(ctx), productURL)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to get page content: %w", err)
 	}
 
+	// Pages without a single size-related word carry no chart
+	if err := skipChartless(html); err != nil {
+		return "", nil, err
+	}
+
 	// Parse HTML once
 	doc, err := l.ParseHTML(html)
 	if err != nil {
@@ -529,197 +558,15 @@ func (l *LittleBoxIndiaAdapter) ExtractProductTitleAndSizeCharts(ctx types.Conte
 	}
 
 	// Extract product title
-	var title string
-	selectors := []string{
-		"h1.product-title",
-		"h1[class*='title']",
-		".product-name h1",
-		".product-info h1",
-		".product-details h1",
-		"h1",
-	}
-
-	for _, selector := range selectors {
-		title, err = l.ExtractText(doc, selector)
-		if err == nil && title != "" {
-			l.logger.Debugf("Successfully extracted product title using selector: %s", selector)
-			break
-		}
-	}
-
-	if title == "" {
+	title, err := l.ExtractProductTitleFromDoc(doc)
+	if err != nil {
 		title = "Unknown Product"
 	}
 
 	// Extract size charts using the same document
-	var charts []*types.SizeChart
-
-	// Find the ks-table (custom size chart table)
-	table := doc.Find("table.ks-table").First()
-	if table.Length() == 0 {
-		l.logger.Debugf("No table found with selector: table.ks-table")
-		return title, nil, fmt.Errorf("no valid size chart found on page")
-	}
-	l.logger.Debugf("Found table with selector: table.ks-table")
-
-	// Get all rows with ks-table-row class
-	rows := table.Find("tr.ks-table-row")
-	if rows.Length() == 0 {
-		l.logger.Debugf("No rows found with selector: tr.ks-table-row")
-		return title, nil, fmt.Errorf("no valid size chart rows found")
-	}
-	l.logger.Debugf("Found %d rows with ks-table-row class", rows.Length())
-
-	// Extract headers from the first row (skip the first cell "SIZE")
-	var sizes []string
-	firstRow := rows.First()
-	firstRow.Find("td, th").Each(func(i int, s *goquery.Selection) {
-		if i == 0 {
-			return // Skip the first cell which is "SIZE"
-		}
-		size := strings.TrimSpace(s.Text())
-		if size != "" {
-			sizes = append(sizes, size)
-		}
-	})
-	l.logger.Debugf("Extracted sizes: %v", sizes)
-
-	if len(sizes) == 0 {
-		return title, nil, fmt.Errorf("no size headers found")
-	}
-
-	// Define the measurement types we want to extract
-	wantedMeasurements := map[string]string{
-		"TO FIT BUST":  "Bust",
-		"TO FIT WAIST": "Waist",
-		"TO FIT HIP":   "Hip",
-	}
-
-	// Prepare data structures for inches and centimeters
-	// Each size will have its own row with measurements as columns
-	inchData := make(map[string]map[string]string) // size -> measurement -> value
-	cmData := make(map[string]map[string]string)   // size -> measurement -> value
-
-	// Initialize data structures for each size
-	for _, size := range sizes {
-		inchData[size] = make(map[string]string)
-		cmData[size] = make(map[string]string)
-	}
-
-	// Process each row starting from the second row
-	for i := 1; i < rows.Length(); i++ {
-		row := rows.Eq(i)
-		cells := row.Find("td, th")
-
-		// Get the measurement label (first cell)
-		label := strings.ToUpper(strings.TrimSpace(cells.First().Text()))
-		outLabel, ok := wantedMeasurements[label]
-		if !ok {
-			continue // Skip rows we don't want
-		}
-
-		l.logger.Debugf("Processing measurement: %s -> %s", label, outLabel)
-
-		// Process each data cell (skip the first cell which is the label)
-		cellIndex := 0
-		cells.Each(func(j int, cell *goquery.Selection) {
-			if j == 0 {
-				return // Skip the label cell
-			}
-
-			if cellIndex < len(sizes) {
-				size := sizes[cellIndex]
-
-				// Check for data-unit-values attribute
-				dataUnitValues := cell.AttrOr("data-unit-values", "")
-				if dataUnitValues != "" {
-					// Parse the JSON data-unit-values
-					// Replace &quot; with " for proper JSON parsing
-					cleanJSON := strings.ReplaceAll(dataUnitValues, "&quot;", `"`)
-					var unitMap map[string]string
-					if err := json.Unmarshal([]byte(cleanJSON), &unitMap); err == nil {
-						// "0" = inches, "1" = cm
-						if inchVal, ok := unitMap["0"]; ok {
-							inchData[size][outLabel] = inchVal
-						}
-						if cmVal, ok := unitMap["1"]; ok {
-							cmData[size][outLabel] = cmVal
-						}
-					} else {
-						l.logger.Debugf("Failed to parse data-unit-values: %s, error: %v", dataUnitValues, err)
-						// Fallback to text content
-						val := strings.TrimSpace(cell.Text())
-						inchData[size][outLabel] = val
-						cmData[size][outLabel] = val
-					}
-				} else {
-					// No data-unit-values, use text content
-					val := strings.TrimSpace(cell.Text())
-					inchData[size][outLabel] = val
-					cmData[size][outLabel] = val
-				}
-			}
-			cellIndex++
-		})
-	}
-
-	l.logger.Debugf("Extracted data for %d sizes", len(sizes))
-
-	// Build size chart for inches
-	inchHeaders := []string{"Size", "Bust (in)", "Waist (in)", "Hip (in)"}
-	var inchRows []map[string]string
-	for _, size := range sizes {
-		row := map[string]string{"Size": size}
-		for _, measurement := range []string{"Bust", "Waist", "Hip"} {
-			if val, ok := inchData[size][measurement]; ok {
-				row[measurement+" (in)"] = val
-			}
-		}
-		// Only add row if it has at least one measurement
-		if row["Bust (in)"] != "" || row["Waist (in)"] != "" || row["Hip (in)"] != "" {
-			inchRows = append(inchRows, row)
-		}
-	}
-
-	if len(inchRows) > 0 {
-		inchChart := &types.SizeChart{
-			Headers: inchHeaders,
-			Rows:    inchRows,
-		}
-
-		if l.IsValidSizeChart(inchChart) {
-			l.logger.Debugf("Successfully extracted inches size chart with %d rows", len(inchRows))
-			charts = append(charts, inchChart)
-		}
-	}
-
-	// Build size chart for centimeters
-	cmHeaders := []string{"Size", "Bust (cm)", "Waist (cm)", "Hip (cm)"}
-	var cmRows []map[string]string
-	for _, size := range sizes {
-		row := map[string]string{"Size": size}
-		for _, measurement := range []string{"Bust", "Waist", "Hip"} {
-			if val, ok := cmData[size][measurement]; ok {
-				row[measurement+" (cm)"] = val
-			}
-		}
-		// Only add row if it has at least one measurement
-		if row["Bust (cm)"] != "" || row["Waist (cm)"] != "" || row["Hip (cm)"] != "" {
-			cmRows = append(cmRows, row)
-		}
-	}
-
-	if len(cmRows) > 0 {
-		cmChart := &types.SizeChart{
-			Headers: cmHeaders,
-			Rows:    cmRows,
-		}
-
-		if l.IsValidSizeChart(cmChart) {
-			l.logger.Debugf("Successfully extracted centimeters size chart with %d rows", len(cmRows))
-			charts = append(charts, cmChart)
-		}
+	charts, err := l.ParseSizeCharts(doc)
+	if err != nil {
+		return title, nil, err
 	}
-
 	return title, charts, nil
 }
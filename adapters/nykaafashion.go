@@ -0,0 +1,174 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+
+	"shopify-extractor/frontier"
+	"shopify-extractor/internal/types"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// nykaaFashionGuide is the tabbed IN / CM size guide modal of Nykaa Fashion product pages
+var nykaaFashionGuide = TabbedGuide{
+	Trigger: "button.size-guide-trigger, a[data-modal='size-guide']",
+	Tabs:    "#size-guide-modal [role='tab']",
+	Panels:  "#size-guide-modal [role='tabpanel']",
+}
+
+// NykaaFashionAdapter handles extraction for nykaafashion.com, whose size guides sit in
+// a modal with one tab per unit
+type NykaaFashionAdapter struct {
+	*ShopifyBaseAdapter
+}
+
+// NewNykaaFashionAdapter creates a new Nykaa Fashion adapter
+func NewNykaaFashionAdapter(config *types.Config, logger types.Logger) *NykaaFashionAdapter {
+	adapter := &NykaaFashionAdapter{
+		ShopifyBaseAdapter: NewShopifyBaseAdapter(config, logger),
+	}
+	adapter.AddChartParser(NewChartParser("tabbed", nykaaFashionGuide.HasCharts, adapter.parseTabbedCharts), PriorityDualUnit)
+	adapter.SetStoreName(adapter.GetStoreName())
+	return adapter
+}
+
+// GetStoreName returns the store name
+func (n *NykaaFashionAdapter) GetStoreName() string {
+	return "nykaafashion.com"
+}
+
+// BaseURL returns the storefront root URL
+func (n *NykaaFashionAdapter) BaseURL() string {
+	return "https://www.nykaafashion.com"
+}
+
+// GetProductURLs returns a list of product URLs for Nykaa Fashion
+func (n *NykaaFashionAdapter) GetProductURLs(ctx types.Context) ([]string, error) {
+	var productURLs []string
+	err := n.StreamProductURLs(n.fetchContext(ctx), func(productURL string) bool {
+		productURLs = append(productURLs, productURL)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return productURLs, nil
+}
+
+// StreamProductURLs discovers Nykaa Fashion product URLs from /products.json with json
+// discovery, otherwise from /collections/all, or collection by collection when the store
+// does not list its catalog there, passing each unique URL to emit as soon as it is found.
+// Discovery stops early when emit returns false.
+func (n *NykaaFashionAdapter) StreamProductURLs(ctx context.Context, emit func(productURL string) bool) error {
+	n.loggerFrom(ctx).Info("Starting product discovery for Nykaa Fashion")
+
+	seen := frontier.NewSet(n.config.FrontierMemoryLimit, n.config.FrontierDir)
+	defer seen.Close()
+	if handled, err := n.tryProductsJSON(ctx, n.BaseURL(), seen, emit); handled {
+		return err
+	}
+
+	// /collections/all lists the whole catalog without per-collection duplicates
+	err := n.StreamAllCollection(ctx, n.BaseURL(), seen, emit)
+	if err == nil {
+		n.loggerFrom(ctx).Infof("Total unique products found on /collections/all: %d", seen.Len())
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	n.loggerFrom(ctx).Infof("Crawling collections instead of /collections/all: %v", err)
+
+	doc, err := n.fetchDocument(ctx, n.BaseURL()+"/products")
+	if err != nil {
+		return fmt.Errorf("failed to get products page: %w", err)
+	}
+
+	collectionURLs, err := n.ExtractCollectionURLs(doc, n.BaseURL())
+	if err != nil {
+		return fmt.Errorf("failed to extract collection URLs: %w", err)
+	}
+	n.loggerFrom(ctx).Infof("Found %d collections", len(collectionURLs))
+
+	for _, collectionURL := range n.RemoveDuplicateURLs(collectionURLs) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		collection, err := n.fetchDocument(ctx, collectionURL)
+		if err != nil {
+			n.loggerFrom(ctx).Warnf("Failed to extract products from collection %s: %v", collectionURL, err)
+			continue
+		}
+		productURLs, _ := n.ExtractProductURLsFromCollection(collection, n.BaseURL())
+		n.loggerFrom(ctx).Debugf("Found %d products in collection %s", len(productURLs), collectionURL)
+
+		for _, productURL := range productURLs {
+			isNew, err := seen.Add(productURL)
+			if err != nil {
+				return fmt.Errorf("failed to record product URL: %w", err)
+			}
+			if !isNew {
+				continue
+			}
+			if !emit(productURL) {
+				n.loggerFrom(ctx).Infof("Product discovery stopped early after %d unique products", seen.Len())
+				return nil
+			}
+		}
+	}
+
+	n.loggerFrom(ctx).Infof("Total unique products found: %d", seen.Len())
+	return nil
+}
+
+// ExtractProductTitleAndSizeCharts extracts the product title and the size charts of every
+// size guide tab from a single page fetch. When the static page does not carry the guide,
+// the browser opens the modal and clicks through the tabs.
+func (n *NykaaFashionAdapter) ExtractProductTitleAndSizeCharts(ctx context.Context, productURL string) (string, []*types.SizeChart, error) {
+	n.loggerFrom(ctx).Debugf("Extracting product title and size charts from %s", productURL)
+
+	doc, err := n.fetchDocument(ctx, productURL)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !nykaaFashionGuide.HasCharts(doc) && n.config.PageSource == nil && n.canUseBrowser() {
+		n.loggerFrom(ctx).Debugf("Size guide not in static HTML of %s, opening it in the browser", productURL)
+		if html, err := n.RenderTabbedGuide(ctx, productURL, nykaaFashionGuide); err != nil {
+			n.loggerFrom(ctx).Warnf("Failed to open size guide of %s: %v", productURL, err)
+		} else if rendered, err := n.ParseHTML(html); err == nil {
+			doc = rendered
+		}
+	}
+
+	title, err := n.ExtractProductTitleFromDoc(doc)
+	if err != nil {
+		title = "Unknown Product"
+	}
+
+	charts, err := n.ParseSizeCharts(doc)
+	if err != nil {
+		return title, nil, err
+	}
+	return title, charts, nil
+}
+
+// parseTabbedCharts reads one chart per size guide tab
+func (n *NykaaFashionAdapter) parseTabbedCharts(doc *goquery.Document) ([]*types.SizeChart, error) {
+	return n.ParseTabbedGuide(doc, nykaaFashionGuide)
+}
+
+// fetchDocument fetches and parses a page
+func (n *NykaaFashionAdapter) fetchDocument(ctx context.Context, pageURL string) (*goquery.Document, error) {
+	html, err := n.GetPageContent(ctx, pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page content: %w", err)
+	}
+	doc, err := n.ParseHTML(html)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	return doc, nil
+}
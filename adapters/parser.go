@@ -0,0 +1,246 @@
+package adapters
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"shopify-extractor/internal/types"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ChartParser extracts size charts in one specific markup format (a size chart app
+// widget, a store's own table layout, a chart image, ...)
+type ChartParser interface {
+	// Name identifies the parser in logs
+	Name() string
+
+	// CanParse reports whether the page contains markup this parser understands
+	CanParse(doc *goquery.Document) bool
+
+	// Parse extracts the size charts from the page
+	Parse(doc *goquery.Document) ([]*types.SizeChart, error)
+}
+
+// Priorities of the built-in parsers. Parsers run in ascending priority order, so a
+// custom parser registered below PriorityKiwi is tried before every built-in one.
+const (
+	PriorityKiwi         = 100 // Kiwi Sizing app tables (table.ks-table)
+	PriorityDualUnit     = 200 // Tables holding cm and inches in span.default / span.alt
+	PriorityGenericTable = 300 // Plain HTML size chart tables
+	PriorityPseudoTable  = 350 // Div-based (CSS grid / flexbox) size charts
+	PriorityOCR          = 400 // Size chart images, read by a registered OCREngine
+)
+
+// prioritizedParser is a parser with its position in the chain
+type prioritizedParser struct {
+	parser   ChartParser
+	priority int
+}
+
+var (
+	registryMu        sync.RWMutex
+	registeredParsers []prioritizedParser
+)
+
+// RegisterChartParser adds a parser that every adapter consults alongside its built-in
+// parsers. Use it to support proprietary chart widgets without forking an adapter.
+func RegisterChartParser(parser ChartParser, priority int) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registeredParsers = append(registeredParsers, prioritizedParser{parser: parser, priority: priority})
+}
+
+// funcParser adapts a pair of functions to the ChartParser interface
+type funcParser struct {
+	name     string
+	canParse func(doc *goquery.Document) bool
+	parse    func(doc *goquery.Document) ([]*types.SizeChart, error)
+}
+
+// NewChartParser creates a ChartParser from a detection and a parse function
+func NewChartParser(name string, canParse func(doc *goquery.Document) bool, parse func(doc *goquery.Document) ([]*types.SizeChart, error)) ChartParser {
+	return &funcParser{name: name, canParse: canParse, parse: parse}
+}
+
+func (p *funcParser) Name() string                        { return p.name }
+func (p *funcParser) CanParse(doc *goquery.Document) bool { return p.canParse(doc) }
+func (p *funcParser) Parse(doc *goquery.Document) ([]*types.SizeChart, error) {
+	return p.parse(doc)
+}
+
+// HasElement returns a CanParse function matching pages that contain any of the selectors
+func HasElement(selectors ...string) func(doc *goquery.Document) bool {
+	return func(doc *goquery.Document) bool {
+		for _, selector := range selectors {
+			if doc.Find(selector).Length() > 0 {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AddChartParser adds a parser to this adapter's chain only
+func (b *BaseAdapter) AddChartParser(parser ChartParser, priority int) {
+	b.parsers = append(b.parsers, prioritizedParser{parser: parser, priority: priority})
+}
+
+// chartParsers returns the adapter's parsers merged with the registered ones, in
+// priority order. On equal priority the adapter's own parsers run first.
+func (b *BaseAdapter) chartParsers() []prioritizedParser {
+	registryMu.RLock()
+	parsers := make([]prioritizedParser, 0, len(b.parsers)+len(registeredParsers))
+	parsers = append(parsers, b.parsers...)
+	parsers = append(parsers, registeredParsers...)
+	registryMu.RUnlock()
+
+	sort.SliceStable(parsers, func(i, j int) bool { return parsers[i].priority < parsers[j].priority })
+	return parsers
+}
+
+// preferredParsers returns the chart parsers in priority order, except that the parser
+// that found the charts of the previous products runs first
+func (b *BaseAdapter) preferredParsers() []prioritizedParser {
+	parsers := b.chartParsers()
+	preferred := b.strategies.first(scopeChartParser)
+	for i, entry := range parsers {
+		if i > 0 && entry.parser.Name() == preferred {
+			ordered := append([]prioritizedParser{entry}, parsers[:i]...)
+			return append(ordered, parsers[i+1:]...)
+		}
+	}
+	return parsers
+}
+
+// ParseSizeCharts runs the parser chain over the page and returns the charts of the
+// first parser that finds any. The parser that found the charts of the store's previous
+// products is tried first. When every applicable parser fails, the first parser error is
+// returned so the failure reason is kept.
+func (b *BaseAdapter) ParseSizeCharts(doc *goquery.Document) ([]*types.SizeChart, error) {
+	var firstErr error
+	for _, entry := range b.preferredParsers() {
+		parser := entry.parser
+		if !parser.CanParse(doc) {
+			continue
+		}
+
+		charts, err := parser.Parse(doc)
+		if err != nil {
+			b.logger.Debugf("Chart parser %s failed: %v", parser.Name(), err)
+			b.RecordStrategy(scopeChartParser, parser.Name(), false)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if len(charts) > 0 {
+			b.logger.Debugf("Chart parser %s extracted %d size charts", parser.Name(), len(charts))
+			b.RecordStrategy(scopeChartParser, parser.Name(), true)
+			for _, chart := range charts {
+				if chart.Parser == "" {
+					chart.Parser = parser.Name()
+				}
+				cleanChart(chart)
+				b.labelUnit(chart)
+			}
+			return b.crossCheckUnits(doc, charts), nil
+		}
+		b.RecordStrategy(scopeChartParser, parser.Name(), false)
+		if firstErr == nil {
+			firstErr = rejectedError("parser " + parser.Name() + " found no size chart")
+		}
+	}
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, noTableError("no valid size chart found on page")
+}
+
+// OCREngine reads size charts out of chart images. No engine is built in; register
+// one with SetOCREngine to enable the OCR parser.
+type OCREngine interface {
+	RecognizeSizeChart(imageURL string) ([]*types.SizeChart, error)
+}
+
+var (
+	ocrMu     sync.RWMutex
+	ocrEngine OCREngine
+)
+
+// SetOCREngine sets the engine used by the OCR parser (nil disables it)
+func SetOCREngine(engine OCREngine) {
+	ocrMu.Lock()
+	defer ocrMu.Unlock()
+	ocrEngine = engine
+}
+
+func currentOCREngine() OCREngine {
+	ocrMu.RLock()
+	defer ocrMu.RUnlock()
+	return ocrEngine
+}
+
+// ocrParser hands size chart images to the registered OCREngine
+type ocrParser struct{}
+
+func (ocrParser) Name() string { return "ocr" }
+
+func (ocrParser) CanParse(doc *goquery.Document) bool {
+	return currentOCREngine() != nil && len(sizeChartImages(doc)) > 0
+}
+
+func (ocrParser) Parse(doc *goquery.Document) ([]*types.SizeChart, error) {
+	engine := currentOCREngine()
+	if engine == nil {
+		return nil, noTableError("no OCR engine registered")
+	}
+
+	var charts []*types.SizeChart
+	var firstErr error
+	for _, imageURL := range sizeChartImages(doc) {
+		recognized, err := engine.RecognizeSizeChart(imageURL)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = rejectedError("failed to read size chart image " + imageURL + ": " + err.Error())
+			}
+			continue
+		}
+		for _, chart := range recognized {
+			if chart.Source == "" {
+				chart.Source = types.SourceOCR
+			}
+			charts = append(charts, chart)
+		}
+	}
+	if len(charts) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	return charts, nil
+}
+
+// sizeChartImages returns the absolute URLs of images that look like size charts
+func sizeChartImages(doc *goquery.Document) []string {
+	var images []string
+	seen := make(map[string]bool)
+	doc.Find("img").Each(func(i int, s *goquery.Selection) {
+		src := s.AttrOr("data-src", s.AttrOr("src", ""))
+		if src == "" {
+			return
+		}
+		hint := strings.ToLower(src + " " + s.AttrOr("alt", "") + " " + s.AttrOr("class", ""))
+		if !strings.Contains(hint, "size") || !(strings.Contains(hint, "chart") || strings.Contains(hint, "guide")) {
+			return
+		}
+		if strings.HasPrefix(src, "//") {
+			src = "https:" + src
+		}
+		if !seen[src] {
+			seen[src] = true
+			images = append(images, src)
+		}
+	})
+	return images
+}
@@ -0,0 +1,137 @@
+package adapters
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+const kiwiProductPage = `<html><body><h1>Dress</h1>
+<table class="ks-table">
+  <tr class="ks-table-row"><td>SIZE</td><td>S</td><td>M</td></tr>
+  <tr class="ks-table-row"><td>TO FIT BUST</td><td data-unit-values='{"0":"34","1":"86"}'>34</td><td data-unit-values='{"0":"36","1":"91"}'>36</td></tr>
+  <tr class="ks-table-row"><td>TO FIT WAIST</td><td data-unit-values='{"0":"28","1":"71"}'>28</td><td data-unit-values='{"0":"30","1":"76"}'>30</td></tr>
+</table></body></html>`
+
+func parseTestDoc(t *testing.T, html string) *goquery.Document {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	require.NoError(t, err)
+	return doc
+}
+
+func newParserTestAdapter() *BaseAdapter {
+	return NewBaseAdapter(types.DefaultConfig(), logrus.New())
+}
+
+// stubParser records whether it ran and returns fixed charts
+func stubParser(name string, ran *[]string, charts []*types.SizeChart, err error) ChartParser {
+	return NewChartParser(name, func(doc *goquery.Document) bool { return true }, func(doc *goquery.Document) ([]*types.SizeChart, error) {
+		*ran = append(*ran, name)
+		return charts, err
+	})
+}
+
+func TestParseSizeCharts_RunsInPriorityOrder(t *testing.T) {
+	adapter := newParserTestAdapter()
+	chart := &types.SizeChart{Name: "custom"}
+
+	var ran []string
+	adapter.AddChartParser(stubParser("generic", &ran, []*types.SizeChart{{Name: "generic"}}, nil), PriorityGenericTable)
+	adapter.AddChartParser(stubParser("failing", &ran, nil, rejectedError("widget empty")), PriorityKiwi)
+	adapter.AddChartParser(stubParser("custom", &ran, []*types.SizeChart{chart}, nil), PriorityKiwi+1)
+
+	charts, err := adapter.ParseSizeCharts(parseTestDoc(t, "<html></html>"))
+	require.NoError(t, err)
+	assert.Equal(t, []*types.SizeChart{chart}, charts)
+	assert.Equal(t, []string{"failing", "custom"}, ran)
+	assert.Equal(t, "custom", chart.Parser, "charts are tagged with their parser")
+}
+
+func TestParseSizeCharts_FailureReasons(t *testing.T) {
+	adapter := newParserTestAdapter()
+	adapter.AddChartParser(NewChartParser("kiwi", HasElement("table.ks-table"), func(doc *goquery.Document) ([]*types.SizeChart, error) {
+		return nil, errors.New("unreachable")
+	}), PriorityKiwi)
+
+	_, err := adapter.ParseSizeCharts(parseTestDoc(t, "<html><body><p>no chart</p></body></html>"))
+	assert.Equal(t, types.MissingReasonNoTable, FailureReason(err))
+
+	var ran []string
+	adapter.AddChartParser(stubParser("empty", &ran, nil, nil), PriorityGenericTable)
+	_, err = adapter.ParseSizeCharts(parseTestDoc(t, "<html></html>"))
+	assert.Equal(t, types.MissingReasonRejected, FailureReason(err))
+}
+
+func TestParseSizeCharts_CleansText(t *testing.T) {
+	adapter := newParserTestAdapter()
+	dirty := &types.SizeChart{
+		Headers: []string{"Size\u200b", "Bust&nbsp;(in)"},
+		Rows:    []map[string]string{{"Size\u200b": " S ", "Bust&nbsp;(in)": "34\u00a0-\n36"}},
+	}
+	var ran []string
+	adapter.AddChartParser(stubParser("dirty", &ran, []*types.SizeChart{dirty}, nil), PriorityKiwi)
+
+	charts, err := adapter.ParseSizeCharts(parseTestDoc(t, "<html></html>"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Size", "Bust (in)"}, charts[0].Headers)
+	assert.Equal(t, map[string]string{"Size": "S", "Bust (in)": "34 - 36"}, charts[0].Rows[0])
+}
+
+func TestRegisterChartParser_AppliesToEveryAdapter(t *testing.T) {
+	defer func() { registeredParsers = nil }()
+
+	chart := &types.SizeChart{Name: "widget"}
+	RegisterChartParser(NewChartParser("widget", HasElement(".size-widget"), func(doc *goquery.Document) ([]*types.SizeChart, error) {
+		return []*types.SizeChart{chart}, nil
+	}), 0)
+
+	adapter := NewLittleBoxIndiaAdapter(types.DefaultConfig(), logrus.New())
+	charts, err := adapter.ParseSizeCharts(parseTestDoc(t, `<div class="size-widget"></div>`+kiwiProductPage))
+	require.NoError(t, err)
+	assert.Equal(t, []*types.SizeChart{chart}, charts)
+}
+
+func TestKiwiParser(t *testing.T) {
+	adapter := NewLittleBoxIndiaAdapter(types.DefaultConfig(), logrus.New())
+
+	charts, err := adapter.ParseSizeCharts(parseTestDoc(t, kiwiProductPage))
+	require.NoError(t, err)
+	require.Len(t, charts, 2)
+	assert.Equal(t, types.UnitInches, charts[0].Unit)
+	assert.Equal(t, "34", charts[0].Rows[0]["Bust (in)"])
+	assert.Equal(t, types.UnitCentimeters, charts[1].Unit)
+	assert.Equal(t, "76", charts[1].Rows[1]["Waist (cm)"])
+}
+
+type stubOCREngine struct {
+	images []string
+}
+
+func (e *stubOCREngine) RecognizeSizeChart(imageURL string) ([]*types.SizeChart, error) {
+	e.images = append(e.images, imageURL)
+	return []*types.SizeChart{{Name: "Body Measurements", Headers: []string{"Size"}}}, nil
+}
+
+func TestOCRParser(t *testing.T) {
+	page := `<html><body><img src="//cdn.example.com/size-chart.png"><img src="/logo.png"></body></html>`
+	adapter := newParserTestAdapter()
+
+	_, err := adapter.ParseSizeCharts(parseTestDoc(t, page))
+	assert.Equal(t, types.MissingReasonNoTable, FailureReason(err), "OCR parser is inactive without an engine")
+
+	engine := &stubOCREngine{}
+	SetOCREngine(engine)
+	defer SetOCREngine(nil)
+
+	charts, err := adapter.ParseSizeCharts(parseTestDoc(t, page))
+	require.NoError(t, err)
+	require.Len(t, charts, 1)
+	assert.Equal(t, types.SourceOCR, charts[0].Source)
+	assert.Equal(t, []string{"https://cdn.example.com/size-chart.png"}, engine.images)
+}
@@ -0,0 +1,141 @@
+package adapters
+
+import (
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// PseudoTable describes a size chart laid out with divs (CSS grid, flexbox) rather than
+// a <table>. Rows and cells left empty are inferred from the markup: the container's
+// children are the rows and each row's children its cells, as long as the rows repeat
+// the same number of cells.
+type PseudoTable struct {
+	Container string // The chart element
+	Row       string // Rows inside the container ("" = the container's children)
+	Cell      string // Cells inside a row ("" = the row's children)
+
+	// Header matches header rows or header cells; the first row is always a header row
+	Header string
+}
+
+// DefaultPseudoTables are the div layouts every adapter recognises out of the box
+var DefaultPseudoTables = []PseudoTable{
+	{
+		Container: "[role='table'], [role='grid']",
+		Row:       "[role='row']",
+		Cell:      "[role='columnheader'], [role='rowheader'], [role='cell'], [role='gridcell']",
+		Header:    "[role='columnheader']",
+	},
+	{
+		Container: ".size-chart-grid, .size-guide-grid, .size-chart-table, div.size-chart",
+		Header:    "[class*='header'], [class*='head']",
+	},
+}
+
+// SetPseudoTables replaces the div layouts recognised by the pseudo-table parser
+func (b *BaseAdapter) SetPseudoTables(layouts ...PseudoTable) {
+	b.pseudoTables = layouts
+}
+
+// hasPseudoTable reports whether the page holds a container of a pseudo-table layout
+// that is not a real table
+func (b *BaseAdapter) hasPseudoTable(doc *goquery.Document) bool {
+	for _, layout := range b.pseudoTables {
+		if doc.Find(layout.Container).Not("table").Length() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePseudoTables reads every div-based chart of the page into a size chart, through
+// the same grid and header handling as HTML tables
+func (b *BaseAdapter) ParsePseudoTables(doc *goquery.Document) ([]*types.SizeChart, error) {
+	var charts []*types.SizeChart
+	found := false
+	for _, layout := range b.pseudoTables {
+		doc.Find(layout.Container).Not("table").Each(func(i int, container *goquery.Selection) {
+			grid := pseudoTableGrid(container, layout)
+			if len(grid) < 2 {
+				return
+			}
+			found = true
+
+			chart, err := gridChart(grid)
+			if err != nil {
+				b.logger.Debugf("Skipping pseudo-table %s: %v", layout.Container, err)
+				return
+			}
+			chart.Unit = b.DetectUnit(chart.Headers)
+			chart.Name = b.ChartNameFromHeaders(chart.Headers)
+			chart.Source = types.SourceSelector
+			if b.IsValidSizeChart(chart) {
+				charts = append(charts, chart)
+			}
+		})
+		if len(charts) > 0 {
+			return charts, nil
+		}
+	}
+
+	if !found {
+		return nil, noTableError("no pseudo-table found on page")
+	}
+	return nil, rejectedError("no valid size chart in the pseudo-tables")
+}
+
+// pseudoTableGrid lays a div-based chart out as a grid. Cells may span columns or rows
+// through aria-colspan / aria-rowspan or data-colspan / data-rowspan.
+func pseudoTableGrid(container *goquery.Selection, layout PseudoTable) []gridRow {
+	rows := outermost(container, layout.Row)
+	var cells [][]gridCell
+	var headers []bool
+	rows.Each(func(i int, row *goquery.Selection) {
+		var rowCells []gridCell
+		allHeaders := layout.Header != ""
+		outermost(row, layout.Cell).Each(func(j int, cell *goquery.Selection) {
+			header := layout.Header != "" && cell.Is(layout.Header)
+			if !header {
+				allHeaders = false
+			}
+			rowCells = append(rowCells, gridCell{
+				text:    utils.CleanText(cell.Text()),
+				rowspan: maxSpan(cellSpan(cell, "aria-rowspan"), cellSpan(cell, "data-rowspan")),
+				colspan: maxSpan(cellSpan(cell, "aria-colspan"), cellSpan(cell, "data-colspan")),
+				header:  header,
+			})
+		})
+		cells = append(cells, rowCells)
+		headers = append(headers, allHeaders || (layout.Header != "" && row.Is(layout.Header)))
+	})
+
+	// Inferred layouts must repeat: every row holds the same number of cells
+	if layout.Row == "" || layout.Cell == "" {
+		for _, rowCells := range cells {
+			if len(rowCells) < 2 || len(rowCells) != len(cells[0]) {
+				return nil
+			}
+		}
+	}
+	return expandSpans(cells, headers)
+}
+
+// outermost returns the elements under scope matching selector that are not nested in
+// another match, or scope's children when selector is empty
+func outermost(scope *goquery.Selection, selector string) *goquery.Selection {
+	if selector == "" {
+		return scope.Children()
+	}
+	return scope.Find(selector).FilterFunction(func(i int, s *goquery.Selection) bool {
+		return s.ParentsUntilSelection(scope).Filter(selector).Length() == 0
+	})
+}
+
+func maxSpan(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
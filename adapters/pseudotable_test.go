@@ -0,0 +1,65 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func parsePseudoTableFixture(t *testing.T, adapter *BaseAdapter, name string) []*types.SizeChart {
+	t.Helper()
+	html, err := os.ReadFile(filepath.Join("testdata", "tables", name))
+	require.NoError(t, err)
+	doc, err := adapter.ParseHTML(string(html))
+	require.NoError(t, err)
+	charts, err := adapter.ParseSizeCharts(doc)
+	require.NoError(t, err)
+	return charts
+}
+
+func TestPseudoTable_AriaGrid(t *testing.T) {
+	adapter := NewBaseAdapter(types.DefaultConfig(), logrus.New())
+	charts := parsePseudoTableFixture(t, adapter, "aria-grid.html")
+
+	require.Len(t, charts, 1)
+	assert.Equal(t, []string{"Size", "Bust (in)", "Bust (cm)"}, charts[0].Headers)
+	assert.Equal(t, map[string]string{"Size": "M", "Bust (in)": "36", "Bust (cm)": "91"}, charts[0].Rows[1])
+	assert.Equal(t, types.SourceSelector, charts[0].Source)
+}
+
+func TestPseudoTable_InferredRowsAndCells(t *testing.T) {
+	adapter := NewBaseAdapter(types.DefaultConfig(), logrus.New())
+	charts := parsePseudoTableFixture(t, adapter, "css-grid.html")
+
+	require.Len(t, charts, 1)
+	assert.Equal(t, []string{"Size", "Chest (in)", "Length (in)"}, charts[0].Headers)
+	assert.Equal(t, map[string]string{"Size": "L", "Chest (in)": "40", "Length (in)": "29"}, charts[0].Rows[1])
+}
+
+func TestPseudoTable_CustomLayout(t *testing.T) {
+	adapter := NewBaseAdapter(types.DefaultConfig(), logrus.New())
+	adapter.SetPseudoTables(PseudoTable{Container: ".chart", Row: ".r", Cell: ".c"})
+	doc, err := adapter.ParseHTML(`<div class="chart">
+		<div class="r"><p class="c">Size</p><p class="c">Waist (cm)</p></div>
+		<div class="r"><p class="c">S</p><p class="c">71</p></div>
+		<div class="r"><p class="c">M</p><p class="c">76</p></div>
+	</div>`)
+	require.NoError(t, err)
+
+	charts, err := adapter.ParsePseudoTables(doc)
+	require.NoError(t, err)
+	require.Len(t, charts, 1)
+	assert.Equal(t, "76", charts[0].Rows[1]["Waist (cm)"])
+}
+
+func TestExtractTableData_SkipsNestedTableRows(t *testing.T) {
+	chart := extractFixtureTable(t, "nested-table.html")
+
+	require.Len(t, chart.Rows, 2)
+	assert.Equal(t, "M", chart.Rows[1]["Size"])
+}
@@ -0,0 +1,247 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"shopify-extractor/frontier"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	// productsJSONPageSize is the largest page size the Shopify storefront accepts
+	productsJSONPageSize = 250
+
+	// productsJSONMaxPages bounds pagination on very large or misbehaving catalogs
+	productsJSONMaxPages = 200
+
+	// allCollectionMaxPages bounds the pagination of /collections/all
+	allCollectionMaxPages = 200
+)
+
+// ShopifyBaseAdapter extends BaseAdapter with the conventions shared by Shopify storefronts:
+// /collections/ and /products/ URLs and the public /products.json catalog endpoint.
+// Adapters for other platforms embed BaseAdapter directly.
+type ShopifyBaseAdapter struct {
+	*BaseAdapter
+}
+
+// NewShopifyBaseAdapter creates a base adapter for a Shopify store
+func NewShopifyBaseAdapter(config *types.Config, logger types.Logger) *ShopifyBaseAdapter {
+	adapter := &ShopifyBaseAdapter{BaseAdapter: NewBaseAdapter(config, logger)}
+	adapter.SetProductURLMatcher(IsShopifyProductURL)
+	return adapter
+}
+
+// IsShopifyProductURL reports whether pageURL is a Shopify product page
+func IsShopifyProductURL(pageURL string) bool {
+	return strings.Contains(pageURL, "/products/")
+}
+
+// ExtractCollectionURLs finds all collection URLs from the products page, ordered by the
+// store's collection_priority rules
+func (s *ShopifyBaseAdapter) ExtractCollectionURLs(doc *goquery.Document, baseURL string) ([]string, error) {
+	collectionURLs := s.ExtractLinks(doc, baseURL, "a[href*='collections']")
+	if len(collectionURLs) == 0 {
+		return nil, fmt.Errorf("no collection URLs found")
+	}
+	return utils.PrioritizeCollections(s.config, collectionURLs), nil
+}
+
+// ExtractProductURLsFromCollection extracts product URLs from a collection page
+func (s *ShopifyBaseAdapter) ExtractProductURLsFromCollection(doc *goquery.Document, baseURL string) ([]string, error) {
+	return s.ExtractLinks(doc, baseURL, "a[href*='/products/']"), nil
+}
+
+// shopifyProduct is the subset of a /products.json entry used by the extractor
+type shopifyProduct struct {
+	ID     int64  `json:"id"`
+	Handle string `json:"handle"`
+	Title  string `json:"title"`
+}
+
+// shopifyProductsPage is a single page of the public /products.json endpoint
+type shopifyProductsPage struct {
+	Products []shopifyProduct `json:"products"`
+}
+
+// fetchProductsJSONPage fetches one page of the store's public /products.json endpoint.
+// JSON endpoints never need a browser, so the HTTP client is always used.
+func (s *ShopifyBaseAdapter) fetchProductsJSONPage(ctx context.Context, baseURL string, page int) ([]shopifyProduct, error) {
+	pageURL := fmt.Sprintf("%s/products.json?limit=%d&page=%d", strings.TrimSuffix(baseURL, "/"), productsJSONPageSize, page)
+
+	body, err := s.httpClient.Get(ctx, pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", pageURL, err)
+	}
+
+	var productsPage shopifyProductsPage
+	if err := json.Unmarshal(body, &productsPage); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", pageURL, err)
+	}
+	return productsPage.Products, nil
+}
+
+// CountCatalogProducts counts the products a Shopify store publishes through /products.json,
+// paginating until an empty page is returned
+func (s *ShopifyBaseAdapter) CountCatalogProducts(ctx context.Context, baseURL string) (int, error) {
+	total := 0
+	for page := 1; page <= productsJSONMaxPages; page++ {
+		products, err := s.fetchProductsJSONPage(ctx, baseURL, page)
+		if err != nil {
+			return 0, err
+		}
+		if len(products) == 0 {
+			break
+		}
+		total += len(products)
+		if len(products) < productsJSONPageSize {
+			break
+		}
+	}
+
+	s.loggerFrom(ctx).Debugf("Catalog of %s has %d products according to /products.json", baseURL, total)
+	return total, nil
+}
+
+// UsesJSONDiscovery reports whether the store discovers its products through
+// /products.json rather than its HTML pages (see utils.StoreDiscoveryMode)
+func (s *ShopifyBaseAdapter) UsesJSONDiscovery() bool {
+	return utils.StoreDiscoveryMode(s.config, s.storeName) == utils.DiscoveryJSON
+}
+
+// tryProductsJSON discovers the store's product URLs from /products.json when it uses json
+// discovery (see StreamProductsJSON). handled reports that discovery is over, complete or
+// cancelled with the returned error; otherwise the endpoint was not used or could not be
+// read, and the caller crawls the storefront pages with the same seen set.
+func (s *ShopifyBaseAdapter) tryProductsJSON(ctx context.Context, baseURL string, seen *frontier.Set, emit func(productURL string) bool) (handled bool, err error) {
+	if !s.UsesJSONDiscovery() {
+		return false, nil
+	}
+	// /products.json lists the whole catalog, 250 products a page, without rendering
+	err = s.StreamProductsJSON(ctx, baseURL, seen, emit)
+	if err == nil {
+		s.loggerFrom(ctx).Infof("Total unique products found on /products.json: %d", seen.Len())
+		return true, nil
+	}
+	if ctx.Err() != nil {
+		return true, ctx.Err()
+	}
+	s.loggerFrom(ctx).Infof("Crawling storefront pages instead of /products.json: %v", err)
+	return false, nil
+}
+
+// StreamProductsJSON discovers product URLs from the public /products.json endpoint,
+// walking ?page=1, ?page=2, ... until a page is empty or emit returns false. Each
+// product's canonical URL, baseURL/products/<handle>, is recorded in seen and passed to
+// emit when new. An error is returned when the first page cannot be read, e.g. when the
+// store disables the endpoint, so callers can fall back to crawling HTML pages.
+func (s *ShopifyBaseAdapter) StreamProductsJSON(ctx context.Context, baseURL string, seen *frontier.Set, emit func(productURL string) bool) error {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	for page := 1; page <= productsJSONMaxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		products, err := s.fetchProductsJSONPage(ctx, baseURL, page)
+		if err != nil {
+			if page == 1 {
+				return err
+			}
+			s.loggerFrom(ctx).Warnf("Catalog pagination ended at page %d: %v", page, err)
+			return nil
+		}
+		if len(products) == 0 {
+			if page == 1 {
+				return fmt.Errorf("no products listed on %s/products.json", baseURL)
+			}
+			return nil
+		}
+
+		added := 0
+		for _, product := range products {
+			if product.Handle == "" {
+				continue
+			}
+			productURL := baseURL + "/products/" + product.Handle
+			isNew, err := seen.Add(productURL)
+			if err != nil {
+				return fmt.Errorf("failed to record product URL: %w", err)
+			}
+			if !isNew {
+				continue
+			}
+			added++
+			if !emit(productURL) {
+				s.loggerFrom(ctx).Infof("Product discovery stopped early after %d unique products", seen.Len())
+				return nil
+			}
+		}
+		s.loggerFrom(ctx).Debugf("Found %d new products on page %d of /products.json", added, page)
+	}
+	return nil
+}
+
+// StreamAllCollection discovers product URLs from /collections/all, which many Shopify
+// themes use to list the entire catalog, walking ?page=2, ?page=3, ... until a page adds
+// no new product or emit returns false. New URLs are recorded in seen and passed to emit.
+// An error is returned when the first page lists no product, e.g. when the theme does not
+// publish it, so callers can fall back to crawling collections.
+func (s *ShopifyBaseAdapter) StreamAllCollection(ctx context.Context, baseURL string, seen *frontier.Set, emit func(productURL string) bool) error {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	for page := 1; page <= allCollectionMaxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		pageURL := baseURL + "/collections/all"
+		if page > 1 {
+			pageURL = fmt.Sprintf("%s?page=%d", pageURL, page)
+		}
+		s.loggerFrom(ctx).Debugf("Fetching catalog page: %s", pageURL)
+
+		html, err := s.GetPageContent(ctx, pageURL)
+		if err != nil {
+			if page == 1 {
+				return fmt.Errorf("failed to get %s: %w", pageURL, err)
+			}
+			s.loggerFrom(ctx).Debugf("Catalog pagination ended at page %d: %v", page, err)
+			return nil
+		}
+		doc, err := s.ParseHTML(html)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", pageURL, err)
+		}
+
+		added := 0
+		productURLs, _ := s.ExtractProductURLsFromCollection(doc, baseURL)
+		for _, productURL := range productURLs {
+			isNew, err := seen.Add(productURL)
+			if err != nil {
+				return fmt.Errorf("failed to record product URL: %w", err)
+			}
+			if !isNew {
+				continue
+			}
+			added++
+			if !emit(productURL) {
+				s.loggerFrom(ctx).Infof("Product discovery stopped early after %d unique products", seen.Len())
+				return nil
+			}
+		}
+		s.loggerFrom(ctx).Debugf("Found %d new products on %s", added, pageURL)
+		// Past the last page Shopify repeats an empty grid
+		if added == 0 {
+			if page == 1 {
+				return fmt.Errorf("no products listed on %s", pageURL)
+			}
+			return nil
+		}
+	}
+	return nil
+}
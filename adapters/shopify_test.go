@@ -0,0 +1,157 @@
+package adapters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/frontier"
+	"shopify-extractor/internal/types"
+)
+
+func newShopifyTestAdapter() *ShopifyBaseAdapter {
+	config := types.DefaultConfig()
+	config.RequestDelay = 0
+	config.MaxRetries = 0
+	config.UseHeadlessBrowser = false
+	return NewShopifyBaseAdapter(config, logrus.New())
+}
+
+func TestStreamAllCollection(t *testing.T) {
+	pages := map[string]string{
+		"":  `<div class="grid"><a href="/products/dress">Dress</a><a href="/collections/all/products/kurta">Kurta</a></div>`,
+		"2": `<div class="grid"><a href="/products/top">Top</a><a href="/products/dress">Dress</a></div>`,
+		"3": `<div class="grid"></div>`,
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/collections/all", r.URL.Path)
+		requests++
+		w.Write([]byte(pages[r.URL.Query().Get("page")]))
+	}))
+	defer server.Close()
+
+	adapter := newShopifyTestAdapter()
+	seen := frontier.NewSet(0, "")
+	defer seen.Close()
+	var urls []string
+	err := adapter.StreamAllCollection(context.Background(), server.URL, seen, func(productURL string) bool {
+		urls = append(urls, productURL)
+		return true
+	})
+	require.NoError(t, err)
+	assert.Len(t, urls, 3)
+	assert.Equal(t, 3, seen.Len())
+	assert.Equal(t, 3, requests)
+
+	// Discovery stops as soon as emit returns false
+	stopSeen := frontier.NewSet(0, "")
+	defer stopSeen.Close()
+	err = adapter.StreamAllCollection(context.Background(), server.URL, stopSeen, func(string) bool { return false })
+	require.NoError(t, err)
+	assert.Equal(t, 1, stopSeen.Len())
+}
+
+func TestStreamAllCollection_MissingPage(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	seen := frontier.NewSet(0, "")
+	defer seen.Close()
+	err := newShopifyTestAdapter().StreamAllCollection(context.Background(), server.URL, seen, func(string) bool { return true })
+	assert.Error(t, err)
+	assert.Zero(t, seen.Len())
+}
+
+func TestStreamProductsJSON(t *testing.T) {
+	pages := map[string]string{
+		"1": `{"products": [{"id": 1, "handle": "dress"}, {"id": 2, "handle": "kurta"}, {"id": 3, "handle": ""}]}`,
+		"2": `{"products": [{"id": 4, "handle": "top"}, {"id": 1, "handle": "dress"}]}`,
+		"3": `{"products": []}`,
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/products.json", r.URL.Path)
+		assert.Equal(t, "250", r.URL.Query().Get("limit"))
+		requests++
+		w.Write([]byte(pages[r.URL.Query().Get("page")]))
+	}))
+	defer server.Close()
+
+	adapter := newShopifyTestAdapter()
+	seen := frontier.NewSet(0, "")
+	defer seen.Close()
+	var urls []string
+	err := adapter.StreamProductsJSON(context.Background(), server.URL+"/", seen, func(productURL string) bool {
+		urls = append(urls, productURL)
+		return true
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{server.URL + "/products/dress", server.URL + "/products/kurta", server.URL + "/products/top"}, urls)
+	assert.Equal(t, 3, requests, "pagination stops at the first empty page")
+
+	// A store without the endpoint is an error, so adapters can crawl HTML instead
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	missingSeen := frontier.NewSet(0, "")
+	defer missingSeen.Close()
+	err = adapter.StreamProductsJSON(context.Background(), missing.URL, missingSeen, func(string) bool { return true })
+	assert.Error(t, err)
+}
+
+func TestTryProductsJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "1" {
+			w.Write([]byte(`{"products": [{"id": 1, "handle": "dress"}]}`))
+			return
+		}
+		w.Write([]byte(`{"products": []}`))
+	}))
+	defer server.Close()
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+
+	adapter := newShopifyTestAdapter()
+	adapter.SetStoreName("westside.com")
+	seen := frontier.NewSet(0, "")
+	defer seen.Close()
+	emit := func(string) bool { return true }
+
+	handled, err := adapter.tryProductsJSON(context.Background(), server.URL, seen, emit)
+	assert.False(t, handled, "HTML discovery does not read the endpoint")
+	assert.NoError(t, err)
+	assert.Zero(t, seen.Len())
+
+	adapter.config.DiscoveryMode = "json"
+	handled, err = adapter.tryProductsJSON(context.Background(), server.URL, seen, emit)
+	assert.True(t, handled)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, seen.Len())
+
+	// A store without the endpoint falls back to crawling its pages
+	handled, err = adapter.tryProductsJSON(context.Background(), missing.URL, seen, emit)
+	assert.False(t, handled)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	handled, err = adapter.tryProductsJSON(ctx, server.URL, seen, emit)
+	assert.True(t, handled, "a cancelled discovery does not fall back")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestUsesJSONDiscovery(t *testing.T) {
+	adapter := newShopifyTestAdapter()
+	adapter.SetStoreName("westside.com")
+	assert.False(t, adapter.UsesJSONDiscovery(), "HTML discovery by default")
+
+	adapter.config.DiscoveryMode = "json"
+	assert.True(t, adapter.UsesJSONDiscovery())
+
+	adapter.config.Stores = map[string]types.StoreOptions{"westside.com": {Discovery: "html"}}
+	assert.False(t, adapter.UsesJSONDiscovery(), "the store's discovery overrides the global mode")
+}
@@ -0,0 +1,219 @@
+package adapters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// strategyDemoteAfter is the number of consecutive failures after which a remembered
+// parser or selector stops being tried first
+const strategyDemoteAfter = 3
+
+// Strategy scopes remembered by the cache
+const (
+	scopeChartParser = "chart_parser" // name of the ChartParser that found the charts
+)
+
+// strategyCache remembers, per scope, which parser or selector found the size charts
+// of the store's previous products so later products try it first. A remembered
+// strategy failing strategyDemoteAfter times in a row is forgotten, and the one that
+// succeeds next takes its place.
+type strategyCache struct {
+	mu        sync.Mutex
+	preferred map[string]string // by scope
+	failures  map[string]int    // consecutive failures of the preferred strategy, by scope
+}
+
+func newStrategyCache() *strategyCache {
+	return &strategyCache{preferred: make(map[string]string), failures: make(map[string]int)}
+}
+
+// first returns the remembered strategy of a scope, "" when there is none
+func (c *strategyCache) first(scope string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.preferred[scope]
+}
+
+// succeeded remembers name as the strategy to try first in scope
+func (c *strategyCache) succeeded(scope, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.preferred[scope] = name
+	c.failures[scope] = 0
+}
+
+// failed counts a failure of name, demoting it when it is the remembered strategy and
+// failed strategyDemoteAfter times in a row. It reports whether name was demoted.
+func (c *strategyCache) failed(scope, name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.preferred[scope] != name {
+		return false
+	}
+	c.failures[scope]++
+	if c.failures[scope] < strategyDemoteAfter {
+		return false
+	}
+	delete(c.preferred, scope)
+	delete(c.failures, scope)
+	return true
+}
+
+// snapshot returns a copy of the remembered strategies
+func (c *strategyCache) snapshot() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	preferred := make(map[string]string, len(c.preferred))
+	for scope, name := range c.preferred {
+		preferred[scope] = name
+	}
+	return preferred
+}
+
+// restore remembers the strategies of a previous run
+func (c *strategyCache) restore(preferred map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for scope, name := range preferred {
+		c.preferred[scope] = name
+	}
+}
+
+// preferFirst returns names with the remembered strategy of scope moved to the front
+func (c *strategyCache) preferFirst(scope string, names []string) []string {
+	first := c.first(scope)
+	if first == "" || len(names) == 0 || names[0] == first {
+		return names
+	}
+	ordered := make([]string, 0, len(names))
+	ordered = append(ordered, first)
+	found := false
+	for _, name := range names {
+		if name == first {
+			found = true
+			continue
+		}
+		ordered = append(ordered, name)
+	}
+	if !found {
+		return names
+	}
+	return ordered
+}
+
+// strategyFiles guards the parser cache files shared by the adapters of a process
+var strategyFiles sync.Mutex
+
+// loadStrategies reads the strategies remembered for store from a parser cache file,
+// a JSON object of store name to scope to strategy. A missing file is empty.
+func loadStrategies(path, store string) (map[string]string, error) {
+	strategyFiles.Lock()
+	defer strategyFiles.Unlock()
+	stores, err := readStrategyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return stores[store], nil
+}
+
+// saveStrategies replaces the strategies remembered for store in a parser cache file,
+// keeping those of the other stores
+func saveStrategies(path, store string, preferred map[string]string) error {
+	strategyFiles.Lock()
+	defer strategyFiles.Unlock()
+	stores, err := readStrategyFile(path)
+	if err != nil {
+		return err
+	}
+	if len(preferred) == 0 {
+		delete(stores, store)
+	} else {
+		stores[store] = preferred
+	}
+
+	data, err := json.MarshalIndent(stores, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode parser cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".parser-cache-*")
+	if err != nil {
+		return fmt.Errorf("failed to create parser cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write parser cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write parser cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace parser cache: %w", err)
+	}
+	return nil
+}
+
+// readStrategyFile reads a parser cache file; the caller holds strategyFiles
+func readStrategyFile(path string) (map[string]map[string]string, error) {
+	stores := make(map[string]map[string]string)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return stores, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parser cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &stores); err != nil {
+		return nil, fmt.Errorf("failed to parse parser cache %s: %w", path, err)
+	}
+	return stores, nil
+}
+
+// SetStoreName names the store the adapter extracts, under which the parsers and
+// selectors that worked are remembered across runs when Config.ParserCacheFile is set
+func (b *BaseAdapter) SetStoreName(store string) {
+	b.storeName = store
+	if b.config.ParserCacheFile == "" {
+		return
+	}
+	preferred, err := loadStrategies(b.config.ParserCacheFile, store)
+	if err != nil {
+		b.logger.Warnf("Parser cache not loaded: %v", err)
+		return
+	}
+	b.strategies.restore(preferred)
+}
+
+// saveStrategies persists the remembered strategies when Config.ParserCacheFile is set
+func (b *BaseAdapter) saveStrategies() {
+	if b.config.ParserCacheFile == "" || b.storeName == "" {
+		return
+	}
+	if err := saveStrategies(b.config.ParserCacheFile, b.storeName, b.strategies.snapshot()); err != nil {
+		b.logger.Warnf("Parser cache not saved: %v", err)
+	}
+}
+
+// PreferredOrder returns candidates (parser names, selectors, ...) with the one that
+// last worked in scope first; report the outcome of each tried candidate with
+// RecordStrategy
+func (b *BaseAdapter) PreferredOrder(scope string, candidates []string) []string {
+	return b.strategies.preferFirst(scope, candidates)
+}
+
+// RecordStrategy reports whether candidate found the size charts of a product, so
+// later products try the successful one first and a failing one is demoted
+func (b *BaseAdapter) RecordStrategy(scope, candidate string, ok bool) {
+	if ok {
+		b.strategies.succeeded(scope, candidate)
+		return
+	}
+	if b.strategies.failed(scope, candidate) {
+		b.logger.Debugf("Demoted %s %s after %d failures in a row", scope, candidate, strategyDemoteAfter)
+	}
+}
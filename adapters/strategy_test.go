@@ -0,0 +1,75 @@
+package adapters
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func TestParseSizeCharts_TriesLastSuccessfulParserFirst(t *testing.T) {
+	adapter := newParserTestAdapter()
+	var ran []string
+	adapter.AddChartParser(stubParser("widget", &ran, nil, rejectedError("widget empty")), PriorityKiwi)
+	adapter.AddChartParser(stubParser("table", &ran, []*types.SizeChart{{Name: "table"}}, nil), PriorityGenericTable)
+
+	doc := parseTestDoc(t, "<html></html>")
+	_, err := adapter.ParseSizeCharts(doc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"widget", "table"}, ran)
+
+	ran = nil
+	_, err = adapter.ParseSizeCharts(doc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"table"}, ran)
+}
+
+func TestStrategyCache_DemotesFailingStrategy(t *testing.T) {
+	cache := newStrategyCache()
+	candidates := []string{"a", "b", "c"}
+	assert.Equal(t, candidates, cache.preferFirst("selector", candidates))
+
+	cache.succeeded("selector", "c")
+	assert.Equal(t, []string{"c", "a", "b"}, cache.preferFirst("selector", candidates))
+
+	// Failures of other candidates do not count against it
+	assert.False(t, cache.failed("selector", "a"))
+	for i := 1; i < strategyDemoteAfter; i++ {
+		assert.False(t, cache.failed("selector", "c"))
+	}
+	assert.True(t, cache.failed("selector", "c"))
+	assert.Equal(t, candidates, cache.preferFirst("selector", candidates))
+
+	// A remembered strategy that is no longer a candidate is ignored
+	cache.succeeded("selector", "gone")
+	assert.Equal(t, candidates, cache.preferFirst("selector", candidates))
+}
+
+func TestParserCacheFile_PersistsAcrossRuns(t *testing.T) {
+	config := types.DefaultConfig()
+	config.ParserCacheFile = filepath.Join(t.TempDir(), "parsers.json")
+
+	other := NewBaseAdapter(config, logrus.New())
+	other.SetStoreName("other.com")
+	other.RecordStrategy(scopeChartParser, "kiwi", true)
+	other.Close()
+
+	first := NewBaseAdapter(config, logrus.New())
+	first.SetStoreName("store.com")
+	first.RecordStrategy(scopeChartParser, "generic-table", true)
+	first.Close()
+
+	next := NewBaseAdapter(config, logrus.New())
+	next.SetStoreName("store.com")
+	assert.Equal(t, []string{"generic-table", "kiwi"}, next.PreferredOrder(scopeChartParser, []string{"kiwi", "generic-table"}))
+
+	stores, err := readStrategyFile(config.ParserCacheFile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"other.com": {scopeChartParser: "kiwi"},
+		"store.com": {scopeChartParser: "generic-table"},
+	}, stores)
+}
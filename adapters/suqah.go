@@ -3,25 +3,46 @@ package adapters
 import (
 	"context"
 	"fmt"
-	"net/url"
 	"strings"
 
+	"shopify-extractor/frontier"
 	"shopify-extractor/internal/types"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
+// scopeSuqahTable remembers which of suqahTableSelectors found the previous charts
+const scopeSuqahTable = "suqah_table_selector"
+
+// suqahTableSelectors locate the size chart table on Suqah product pages, tried in
+// order unless one found the charts of the previous products
+var suqahTableSelectors = []string{
+	".chart_block table",
+	".chart_block",
+	"table",
+	".size-chart table",
+	".product-size-chart table",
+	".size-guide table",
+	"table[class*='size']",
+	"table[class*='chart']",
+	".product-details table",
+}
+
 // SuqahAdapter handles extraction for suqah.com
 type SuqahAdapter struct {
-	*BaseAdapter
+	*ShopifyBaseAdapter
 }
 
 // NewSuqahAdapter creates a new Suqah adapter
 func NewSuqahAdapter(config *types.Config, logger types.Logger) *SuqahAdapter {
 	config.UseHeadlessBrowser = true // Always use browser for Suqah
-	return &SuqahAdapter{
-		BaseAdapter: NewBaseAdapter(config, logger),
+	adapter := &SuqahAdapter{
+		ShopifyBaseAdapter: NewShopifyBaseAdapter(config, logger),
 	}
+	adapter.SetExpectedContainers(".chart_block", ".size-chart", ".product-size-chart", ".size-guide")
+	adapter.AddChartParser(NewChartParser("generic-table", HasElement("table"), adapter.parseTableCharts), PriorityGenericTable)
+	adapter.SetStoreName(adapter.GetStoreName())
+	return adapter
 }
 
 // GetStoreName returns the store name
@@ -29,64 +50,113 @@ func (s *SuqahAdapter) GetStoreName() string {
 	return "suqah.com"
 }
 
+// BaseURL returns the storefront root URL
+func (s *SuqahAdapter) BaseURL() string {
+	return "https://www.suqah.com"
+}
+
 // GetProductURLs returns a list of product URLs for Suqah
 func (s *SuqahAdapter) GetProductURLs(ctx types.Context) ([]string, error) {
-	s.logger.Info("Starting product discovery for Suqah")
+	var productURLs []string
+	err := s.StreamProductURLs(s.fetchContext(ctx), func(productURL string) bool {
+		productURLs = append(productURLs, productURL)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return productURLs, nil
+}
+
+// StreamProductURLs discovers Suqah product URLs from /products.json with json
+// discovery, otherwise from /collections/all, or collection by collection when the store
+// does not list its catalog there, passing each unique URL to emit as soon as it is found.
+// Discovery stops early when emit returns false.
+func (s *SuqahAdapter) StreamProductURLs(ctx context.Context, emit func(productURL string) bool) error {
+	s.loggerFrom(ctx).Info("Starting product discovery for Suqah")
+
+	seen := frontier.NewSet(s.config.FrontierMemoryLimit, s.config.FrontierDir)
+	defer seen.Close()
+	if handled, err := s.tryProductsJSON(ctx, "https://www.suqah.com", seen, emit); handled {
+		return err
+	}
+
+	// /collections/all lists the whole catalog without per-collection duplicates
+	err := s.StreamAllCollection(ctx, "https://www.suqah.com", seen, emit)
+	if err == nil {
+		s.loggerFrom(ctx).Infof("Total unique products found on /collections/all: %d", seen.Len())
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	s.loggerFrom(ctx).Infof("Crawling collections instead of /collections/all: %v", err)
 
 	// Step 1: Get the products page
 	productsPageURL := "https://www.suqah.com/products"
-	s.logger.Debugf("Fetching products page: %s", productsPageURL)
+	s.loggerFrom(ctx).Debugf("Fetching products page: %s", productsPageURL)
 
-	html, err := s.GetPageContent(context.Background(), productsPageURL)
+	html, err := s.GetPageContent(ctx, productsPageURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get products page: %w", err)
+		return fmt.Errorf("failed to get products page: %w", err)
 	}
 
 	doc, err := s.ParseHTML(html)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse products page: %w", err)
+		return fmt.Errorf("failed to parse products page: %w", err)
 	}
 
 	// Step 2: Find all collection URLs
 	collectionURLs, err := s.ExtractCollectionURLs(doc, "https://www.suqah.com")
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract collection URLs: %w", err)
+		return fmt.Errorf("failed to extract collection URLs: %w", err)
 	}
 
-	s.logger.Infof("Found %d collections", len(collectionURLs))
+	s.loggerFrom(ctx).Infof("Found %d collections", len(collectionURLs))
 
-	// Step 3: Iterate through collections to find product URLs
-	var allProductURLs []string
+	// Step 3: Iterate through collections, emitting new product URLs as they are found
 	for i, collectionURL := range collectionURLs {
-		s.logger.Debugf("Processing collection: %s %d", collectionURL, i+1)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.loggerFrom(ctx).Debugf("Processing collection: %s %d", collectionURL, i+1)
 
-		productURLs, err := s.extractProductURLsFromCollection(collectionURL)
+		productURLs, err := s.extractProductURLsFromCollection(ctx, collectionURL)
 		if err != nil {
-			s.logger.Warnf("Failed to extract products from collection %s: %v", collectionURL, err)
+			s.loggerFrom(ctx).Warnf("Failed to extract products from collection %s: %v", collectionURL, err)
 			continue
 		}
 
-		allProductURLs = append(allProductURLs, productURLs...)
-		s.logger.Debugf("Found %d products in collection %s", len(productURLs), collectionURL)
+		s.loggerFrom(ctx).Debugf("Found %d products in collection %s", len(productURLs), collectionURL)
+		for _, productURL := range productURLs {
+			isNew, err := seen.Add(productURL)
+			if err != nil {
+				return fmt.Errorf("failed to record product URL: %w", err)
+			}
+			if !isNew {
+				continue
+			}
+			if !emit(productURL) {
+				s.loggerFrom(ctx).Infof("Product discovery stopped early after %d unique products", seen.Len())
+				return nil
+			}
+		}
 		// Process only first few collections for speed testing
 		// if i >= 4 { // Process first 3 collections only
 		// 	break
 		// }
 	}
 
-	// Remove duplicates
-	uniqueProductURLs := s.RemoveDuplicateURLs(allProductURLs)
-
-	s.logger.Infof("Total unique products found: %d", len(uniqueProductURLs))
-	return uniqueProductURLs, nil
+	s.loggerFrom(ctx).Infof("Total unique products found: %d", seen.Len())
+	return nil
 }
 
 // extractProductURLsFromCollection extracts product URLs from a collection page
-func (s *SuqahAdapter) extractProductURLsFromCollection(collectionURL string) ([]string, error) {
-	s.logger.Debugf("Extracting products from collection: %s", collectionURL)
+func (s *SuqahAdapter) extractProductURLsFromCollection(ctx context.Context, collectionURL string) ([]string, error) {
+	s.loggerFrom(ctx).Debugf("Extracting products from collection: %s", collectionURL)
 
 	// Get the collection page
-	html, err := s.GetPageContent(context.Background(), collectionURL)
+	html, err := s.GetPageContent(ctx, collectionURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get collection page: %w", err)
 	}
@@ -96,43 +166,18 @@ func (s *SuqahAdapter) extractProductURLsFromCollection(collectionURL string) ([
 		return nil, fmt.Errorf("failed to parse collection page: %w", err)
 	}
 
-	var productURLs []string
-
 	// Find all <a> tags that contain "/products/" in their href
-	doc.Find("a[href*='/products/']").Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if !exists {
-			return
-		}
-
-		// Clean and normalize the URL
-		href = strings.TrimSpace(href)
-		if href == "" {
-			return
-		}
-
-		// Convert relative URLs to absolute URLs
-		if strings.HasPrefix(href, "/") {
-			href = "https://www.suqah.com" + href
-		} else if !strings.HasPrefix(href, "http") {
-			href = "https://www.suqah.com/" + href
-		}
-
-		// Validate URL
-		if _, err := url.Parse(href); err == nil {
-			productURLs = append(productURLs, href)
-		}
-	})
+	productURLs := s.ExtractLinks(doc, s.BaseURL(), "a[href*='/products/']")
 
 	return productURLs, nil
 }
 
 // ExtractSizeChart extracts the size chart from a Suqah product page
 func (s *SuqahAdapter) ExtractSizeChart(ctx types.Context, productURL string) (*types.SizeChart, error) {
-	s.logger.Debugf("Extracting size chart from %s", productURL)
+	s.loggerFor(ctx).Debugf("Extracting size chart from %s", productURL)
 
 	// Get page content
-	html, err := s.GetPageContent(context.Background(), productURL)
+	html, err := s.GetPageContent(s.fetchContext(ctx), productURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get page content: %w", err)
 	}
@@ -144,44 +189,46 @@ func (s *SuqahAdapter) ExtractSizeChart(ctx types.Context, productURL string) (*
 	}
 
 	// Look for table tags that contain size-related content
-	selectors := []string{
-		".chart_block table",
-		".chart_block",
-		"table",
-		".size-chart table",
-		".product-size-chart table",
-		".size-guide table",
-		"table[class*='size']",
-		"table[class*='chart']",
-		".product-details table",
-	}
+	selectors := s.PreferredOrder(scopeSuqahTable, suqahTableSelectors)
 
+	// Remember whether any table was seen, to tell missing charts from rejected ones
+	foundTable := false
 	for _, selector := range selectors {
-		s.logger.Debugf("Trying selector: %s", selector)
+		s.loggerFor(ctx).Debugf("Trying selector: %s", selector)
 		sizeChart, err := s.extractSuqahTableData(doc, selector)
 		if err != nil {
-			s.logger.Debugf("Selector %s failed: %v", selector, err)
+			if FailureReason(err) == types.MissingReasonRejected {
+				foundTable = true
+			}
+			s.loggerFor(ctx).Debugf("Selector %s failed: %v", selector, err)
+			s.RecordStrategy(scopeSuqahTable, selector, false)
 			continue
 		}
+		foundTable = true
 		if s.IsValidSizeChart(sizeChart) {
-			s.logger.Debugf("Successfully extracted size chart using selector: %s", selector)
-			filtered := s.FilterSizeChart(sizeChart)
+			s.loggerFor(ctx).Debugf("Successfully extracted size chart using selector: %s", selector)
+			filtered := s.FilterSizeChart(ctx, sizeChart)
 			if filtered != nil && len(filtered.Rows) > 0 {
+				s.RecordStrategy(scopeSuqahTable, selector, true)
 				return filtered, nil
 			}
 		} else {
-			s.logger.Debugf("Selector %s found table but it's not a valid size chart", selector)
+			s.loggerFor(ctx).Debugf("Selector %s found table but it's not a valid size chart", selector)
+			s.RecordStrategy(scopeSuqahTable, selector, false)
 		}
 	}
 
-	return nil, fmt.Errorf("no valid size chart found on page")
+	if foundTable {
+		return nil, rejectedError("no valid size chart found on page")
+	}
+	return nil, noTableError("no valid size chart found on page")
 }
 
 // extractSuqahTableData extracts table data specifically for Suqah's table structure
 func (s *SuqahAdapter) extractSuqahTableData(doc *goquery.Document, tableSelector string) (*types.SizeChart, error) {
 	table := doc.Find(tableSelector)
 	if table.Length() == 0 {
-		return nil, fmt.Errorf("table not found with selector: %s", tableSelector)
+		return nil, noTableError(fmt.Sprintf("table not found with selector: %s", tableSelector))
 	}
 
 	s.logger.Debugf("Found %d elements with selector: %s", table.Length(), tableSelector)
@@ -191,7 +238,7 @@ func (s *SuqahAdapter) extractSuqahTableData(doc *goquery.Document, tableSelecto
 		s.logger.Debugf("Found chart_block container, looking for tables inside")
 		table = table.Find("table")
 		if table.Length() == 0 {
-			return nil, fmt.Errorf("no table found inside chart_block")
+			return nil, noTableError("no table found inside chart_block")
 		}
 		s.logger.Debugf("Found %d tables inside chart_block", table.Length())
 	}
@@ -204,7 +251,7 @@ func (s *SuqahAdapter) extractSuqahTableData(doc *goquery.Document, tableSelecto
 	})
 
 	if len(headers) == 0 {
-		return nil, fmt.Errorf("no headers found in table")
+		return nil, rejectedError("no headers found in table")
 	}
 
 	s.logger.Debugf("Original headers from table: %v", headers)
@@ -297,12 +344,14 @@ func (s *SuqahAdapter) extractSuqahTableData(doc *goquery.Document, tableSelecto
 	})
 
 	if len(rows) == 0 {
-		return nil, fmt.Errorf("no data rows found in table")
+		return nil, rejectedError("no data rows found in table")
 	}
 
 	s.logger.Debugf("Extracted %d rows", len(rows))
 
 	return &types.SizeChart{
+		Unit:    s.DetectUnit(headers),
+		Source:  types.SourceSelector,
 		Headers: headers,
 		Rows:    rows,
 	}, nil
@@ -376,10 +425,10 @@ func (s *SuqahAdapter) isHeaderRow(row map[string]string) bool {
 
 // GetProductTitle extracts the product title from a Suqah product page
 func (s *SuqahAdapter) GetProductTitle(ctx types.Context, productURL string) (string, error) {
-	s.logger.Debugf("Extracting product title from %s", productURL)
+	s.loggerFor(ctx).Debugf("Extracting product title from %s", productURL)
 
 	// Get page content
-	html, err := s.GetPageContent(context.Background(), productURL)
+	html, err := s.GetPageContent(s.fetchContext(ctx), productURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to get page content: %w", err)
 	}
@@ -390,38 +439,15 @@ func (s *SuqahAdapter) GetProductTitle(ctx types.Context, productURL string) (st
 		return "", fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
-	return s.GetProductTitleFromDoc(doc)
-}
-
-// GetProductTitleFromDoc extracts the product title from an already parsed document
-func (s *SuqahAdapter) GetProductTitleFromDoc(doc *goquery.Document) (string, error) {
-	// Try different selectors for product title
-	selectors := []string{
-		"h1.product-title",
-		"h1[class*='title']",
-		".product-name h1",
-		".product-info h1",
-		".product-details h1",
-		"h1",
-	}
-
-	for _, selector := range selectors {
-		title, err := s.ExtractText(doc, selector)
-		if err == nil && title != "" {
-			s.logger.Debugf("Successfully extracted product title using selector: %s", selector)
-			return title, nil
-		}
-	}
-
-	return "", fmt.Errorf("product title not found on page")
+	return s.ExtractProductTitleFromDoc(doc)
 }
 
 // ExtractAllSizeCharts extracts all size charts from a Suqah product page
 func (s *SuqahAdapter) ExtractAllSizeCharts(ctx types.Context, productURL string) ([]*types.SizeChart, error) {
-	s.logger.Debugf("Extracting all size charts from %s", productURL)
+	s.loggerFor(ctx).Debugf("Extracting all size charts from %s", productURL)
 
 	// Get page content once and reuse it
-	html, err := s.GetPageContent(context.Background(), productURL)
+	html, err := s.GetPageContent(s.fetchContext(ctx), productURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get page content: %w", err)
 	}
@@ -433,33 +459,30 @@ func (s *SuqahAdapter) ExtractAllSizeCharts(ctx types.Context, productURL string
 	}
 
 	// Extract both title and size chart from the same document
-	title, _ := s.GetProductTitleFromDoc(doc)
+	title, _ := s.ExtractProductTitleFromDoc(doc)
 	if title != "" {
-		s.logger.Debugf("Extracted title: %s", title)
-	}
-
-	// Extract size chart using the cached document
-	sizeChart, err := s.extractSizeChartFromDoc(doc, productURL)
-	if err != nil {
-		return nil, err
+		s.loggerFor(ctx).Debugf("Extracted title: %s", title)
 	}
 
-	if sizeChart != nil {
-		return []*types.SizeChart{sizeChart}, nil
-	}
-	return nil, fmt.Errorf("no size chart found")
+	// Extract size charts using the cached document
+	return s.ParseSizeCharts(doc)
 }
 
 // ExtractProductData extracts both title and size charts in a single page fetch
 func (s *SuqahAdapter) ExtractProductData(ctx types.Context, productURL string) (string, []*types.SizeChart, error) {
-	s.logger.Debugf("Extracting product data from %s", productURL)
+	s.loggerFor(ctx).Debugf("Extracting product data from %s", productURL)
 
 	// Get page content once
-	html, err := s.GetPageContent(context.Background(), productURL)
+	html, err := s.GetPageContent(s.fetchContext(ctx), productURL)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to get page content: %w", err)
 	}
 
+	// Pages without a single size-related word carry no chart
+	if err := skipChartless(html); err != nil {
+		return "", nil, err
+	}
+
 	// Parse HTML once
 	doc, err := s.ParseHTML(html)
 	if err != nil {
@@ -467,60 +490,67 @@ func (s *SuqahAdapter) ExtractProductData(ctx types.Context, productURL string)
 	}
 
 	// Extract title
-	title, err := s.GetProductTitleFromDoc(doc)
+	title, err := s.ExtractProductTitleFromDoc(doc)
 	if err != nil {
-		s.logger.Debugf("Failed to extract title: %v", err)
+		s.loggerFor(ctx).Debugf("Failed to extract title: %v", err)
 		title = "Unknown Product"
 	}
 
-	// Extract size chart
-	sizeChart, err := s.extractSizeChartFromDoc(doc, productURL)
+	// Extract size charts
+	charts, err := s.ParseSizeCharts(doc)
 	if err != nil {
-		s.logger.Debugf("Failed to extract size chart: %v", err)
+		s.loggerFor(ctx).Debugf("Failed to extract size chart: %v", err)
+		return title, nil, err
 	}
 
-	var sizeCharts []*types.SizeChart
-	if sizeChart != nil {
-		sizeCharts = append(sizeCharts, sizeChart)
-	}
+	return title, charts, nil
+}
 
-	return title, sizeCharts, nil
+// parseTableCharts reads the first valid plain HTML size chart table on the page
+func (s *SuqahAdapter) parseTableCharts(doc *goquery.Document) ([]*types.SizeChart, error) {
+	sizeChart, err := s.extractSizeChartFromDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+	return []*types.SizeChart{sizeChart}, nil
 }
 
 // extractSizeChartFromDoc extracts size chart from an already parsed document
-func (s *SuqahAdapter) extractSizeChartFromDoc(doc *goquery.Document, productURL string) (*types.SizeChart, error) {
-	s.logger.Debugf("Extracting size chart from document for %s", productURL)
+func (s *SuqahAdapter) extractSizeChartFromDoc(doc *goquery.Document) (*types.SizeChart, error) {
+	s.logger.Debugf("Extracting size chart from document")
 
 	// Look for table tags that contain size-related content
-	selectors := []string{
-		".chart_block table",
-		".chart_block",
-		"table",
-		".size-chart table",
-		".product-size-chart table",
-		".size-guide table",
-		"table[class*='size']",
-		"table[class*='chart']",
-		".product-details table",
-	}
+	selectors := s.PreferredOrder(scopeSuqahTable, suqahTableSelectors)
 
+	// Remember whether any table was seen, to tell missing charts from rejected ones
+	foundTable := false
 	for _, selector := range selectors {
 		s.logger.Debugf("Trying selector: %s", selector)
 		sizeChart, err := s.extractSuqahTableData(doc, selector)
 		if err != nil {
+			if FailureReason(err) == types.MissingReasonRejected {
+				foundTable = true
+			}
 			s.logger.Debugf("Selector %s failed: %v", selector, err)
+			s.RecordStrategy(scopeSuqahTable, selector, false)
 			continue
 		}
+		foundTable = true
 		if s.IsValidSizeChart(sizeChart) {
 			s.logger.Debugf("Successfully extracted size chart using selector: %s", selector)
-			filtered := s.FilterSizeChart(sizeChart)
+			filtered := s.FilterSizeChart(types.Context{}, sizeChart)
 			if filtered != nil && len(filtered.Rows) > 0 {
+				s.RecordStrategy(scopeSuqahTable, selector, true)
 				return filtered, nil
 			}
 		} else {
 			s.logger.Debugf("Selector %s found table but it's not a valid size chart", selector)
+			s.RecordStrategy(scopeSuqahTable, selector, false)
 		}
 	}
 
-	return nil, fmt.Errorf("no valid size chart found on page")
+	if foundTable {
+		return nil, rejectedError("no valid size chart found on page")
+	}
+	return nil, noTableError("no valid size chart found on page")
 }
@@ -0,0 +1,314 @@
+package adapters
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxCellSpan caps rowspan / colspan so a malformed attribute cannot blow up the grid
+const maxCellSpan = 64
+
+// gridRow is one row of a span-expanded table
+type gridRow struct {
+	cells []string
+
+	// header is set for rows in <thead> and rows made of <th> (or pseudo-table header)
+	// cells only
+	header bool
+
+	// headerCells counts the slots filled by <th> (or pseudo-table header) cells
+	headerCells int
+}
+
+// gridCell is a cell before span expansion
+type gridCell struct {
+	text             string
+	rowspan, colspan int
+	header           bool
+}
+
+// spanCarry is a cell spanning down into the rows below its own
+type spanCarry struct {
+	text   string
+	rows   int
+	header bool
+}
+
+// tableGrid lays a table out as a rectangular grid, copying a cell that spans several
+// rows or columns (rowspan / colspan) into every slot it covers. Rows of nested tables
+// are left out.
+func tableGrid(table *goquery.Selection) []gridRow {
+	table = table.First()
+	var rows [][]gridCell
+	var headers []bool
+	table.Find("tr").Each(func(i int, tr *goquery.Selection) {
+		if owner := tr.Closest("table"); len(owner.Nodes) == 0 || owner.Nodes[0] != table.Nodes[0] {
+			return
+		}
+
+		var cells []gridCell
+		allHeaders := true
+		tr.ChildrenFiltered("th, td").Each(func(j int, cell *goquery.Selection) {
+			if !cell.Is("th") {
+				allHeaders = false
+			}
+			cells = append(cells, gridCell{
+				text:    utils.CleanText(cell.Text()),
+				rowspan: cellSpan(cell, "rowspan"),
+				colspan: cellSpan(cell, "colspan"),
+				header:  cell.Is("th"),
+			})
+		})
+		rows = append(rows, cells)
+		headers = append(headers, tr.Parent().Is("thead") || (allHeaders && len(cells) > 0))
+	})
+	return expandSpans(rows, headers)
+}
+
+// expandSpans turns rows of cells into a rectangular grid, copying a cell spanning
+// several rows or columns into every slot it covers. headers flags the header rows.
+func expandSpans(rows [][]gridCell, headers []bool) []gridRow {
+	var grid []gridRow
+	var carry []spanCarry // indexed by column
+	width := 0
+
+	for i, cells := range rows {
+		row := gridRow{header: headers[i]}
+		col := 0
+		for _, cell := range cells {
+			for col < len(carry) && carry[col].rows > 0 {
+				row.add(carry[col].text, carry[col].header)
+				carry[col].rows--
+				col++
+			}
+			for k := 0; k < cell.colspan; k++ {
+				row.add(cell.text, cell.header)
+				for len(carry) <= col {
+					carry = append(carry, spanCarry{})
+				}
+				carry[col] = spanCarry{text: cell.text, rows: cell.rowspan - 1, header: cell.header}
+				col++
+			}
+		}
+		// Cells spanning down from above may also sit after the row's last own cell
+		for ; col < len(carry); col++ {
+			if carry[col].rows > 0 {
+				row.add(carry[col].text, carry[col].header)
+				carry[col].rows--
+			} else {
+				row.cells = append(row.cells, "")
+			}
+		}
+
+		if len(row.cells) == 0 {
+			continue
+		}
+		if len(row.cells) > width {
+			width = len(row.cells)
+		}
+		grid = append(grid, row)
+	}
+
+	for i := range grid {
+		for len(grid[i].cells) < width {
+			grid[i].cells = append(grid[i].cells, "")
+		}
+	}
+	return grid
+}
+
+// add appends a slot to the row
+func (r *gridRow) add(text string, header bool) {
+	r.cells = append(r.cells, text)
+	if header {
+		r.headerCells++
+	}
+}
+
+// cellSpan reads a span attribute (rowspan, colspan, aria-colspan, ...), 1 when missing
+// or invalid
+func cellSpan(cell *goquery.Selection, attr string) int {
+	span, err := strconv.Atoi(strings.TrimSpace(cell.AttrOr(attr, "1")))
+	if err != nil || span < 1 {
+		return 1
+	}
+	if span > maxCellSpan {
+		return maxCellSpan
+	}
+	return span
+}
+
+// tableChart reads a table into a chart. The header row is picked by headerRow, which
+// skips caption and unit toggle rows above it; the <thead> or all-<th> rows right after
+// it are headers too. Merged header cells are combined per column, so "Bust" spanning
+// "in" and "cm" sub-columns gives "Bust (in)" and "Bust (cm)".
+func tableChart(table *goquery.Selection) (*types.SizeChart, error) {
+	return gridChart(tableGrid(table))
+}
+
+// gridChart builds a chart from a span-expanded grid, see tableChart
+func gridChart(grid []gridRow) (*types.SizeChart, error) {
+	if len(grid) == 0 {
+		return nil, fmt.Errorf("no headers found in table")
+	}
+
+	start := headerRow(grid)
+	depth := start + 1
+	for depth < len(grid)-1 && grid[depth].header {
+		depth++
+	}
+	headers := gridHeaders(grid[start:depth])
+
+	var rows []map[string]string
+	for _, gridRow := range grid[depth:] {
+		row := make(map[string]string)
+		empty := true
+		for i, header := range headers {
+			row[header] = gridRow.cells[i]
+			if gridRow.cells[i] != "" {
+				empty = false
+			}
+		}
+		if !empty {
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no data rows found in table")
+	}
+
+	return &types.SizeChart{Headers: headers, Rows: rows}, nil
+}
+
+// cleanChart runs utils.CleanText over the name, headers and every row key and value of a
+// chart, so no parser leaves entities, zero-width characters or stray whitespace behind
+func cleanChart(chart *types.SizeChart) {
+	chart.Name = utils.CleanText(chart.Name)
+	for i, header := range chart.Headers {
+		chart.Headers[i] = utils.CleanText(header)
+	}
+	for i, row := range chart.Rows {
+		cleaned := make(map[string]string, len(row))
+		for key, value := range row {
+			key, value = utils.CleanText(key), utils.CleanText(value)
+			if _, ok := cleaned[key]; !ok || value != "" {
+				cleaned[key] = value
+			}
+		}
+		chart.Rows[i] = cleaned
+	}
+}
+
+// gridHeaders combines the header rows of a grid into one header per column. A unit
+// sub-header becomes a suffix ("Bust" over "cm" gives "Bust (cm)"), other levels are
+// joined with a space; repeated headers are numbered so no column is lost.
+func gridHeaders(headerRows []gridRow) []string {
+	headers := make([]string, len(headerRows[0].cells))
+	seen := make(map[string]int)
+	for col := range headers {
+		var parts []string
+		for _, row := range headerRows {
+			text := row.cells[col]
+			if text != "" && (len(parts) == 0 || parts[len(parts)-1] != text) {
+				parts = append(parts, text)
+			}
+		}
+
+		header := ""
+		for i, part := range parts {
+			if unit := tabUnit(part); unit != "" && i > 0 {
+				header += " (" + unit + ")"
+			} else if header != "" {
+				header += " " + part
+			} else {
+				header = part
+			}
+		}
+
+		seen[header]++
+		if n := seen[header]; n > 1 {
+			header += " " + strconv.Itoa(n)
+		}
+		headers[col] = header
+	}
+	return headers
+}
+
+// numericCell matches measurement values such as "34", "34.5" or "34-36"
+var numericCell = regexp.MustCompile(`^[\d.,]+(\s*[-–/]\s*[\d.,]+)?$`)
+
+// maxHeaderSearch is how many rows from the top may hold the header row
+const maxHeaderSearch = 5
+
+// headerKeywords are words found in size chart headers
+var headerKeywords = []string{
+	"size", "bust", "chest", "waist", "hip", "seat", "shoulder", "sleeve", "length",
+	"inseam", "neck", "thigh", "rise", "to fit", "uk", "us", "eu", "int",
+}
+
+// headerRow returns the index of the grid's header row: the top row scoring best on
+// header cell density and header keywords. Rows with fewer than two distinct cells
+// (captions, "Size Chart" banners) never qualify, nor do rows of mostly numbers; when no
+// row qualifies the first row is used.
+func headerRow(grid []gridRow) int {
+	best, bestScore := 0, 0.0
+	for i := 0; i < len(grid)-1 && i < maxHeaderSearch; i++ {
+		if score := headerScore(grid[i]); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// headerScore rates how much a row looks like a header row, 0 when it cannot be one
+func headerScore(row gridRow) float64 {
+	distinct := make(map[string]bool)
+	keywords, numbers := 0, 0
+	for _, cell := range row.cells {
+		if cell == "" || distinct[cell] {
+			continue
+		}
+		distinct[cell] = true
+		if numericCell.MatchString(cell) {
+			numbers++
+		}
+		lower := strings.ToLower(cell)
+		for _, keyword := range headerKeywords {
+			if containsWord(lower, keyword) {
+				keywords++
+				break
+			}
+		}
+	}
+	if len(distinct) < 2 || numbers*2 > len(distinct) {
+		return 0
+	}
+	density := float64(row.headerCells) / float64(len(row.cells))
+	return 2*density + 3*float64(keywords)/float64(len(distinct)) + 0.1
+}
+
+// containsWord reports whether word appears in text on word boundaries
+func containsWord(text, word string) bool {
+	for rest := text; ; {
+		i := strings.Index(rest, word)
+		if i < 0 {
+			return false
+		}
+		before, after := i == 0 || !isLetter(rest[i-1]), i+len(word) == len(rest) || !isLetter(rest[i+len(word)])
+		if before && after {
+			return true
+		}
+		rest = rest[i+len(word):]
+	}
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
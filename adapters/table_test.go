@@ -0,0 +1,76 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func extractFixtureTable(t *testing.T, name string) *types.SizeChart {
+	t.Helper()
+	html, err := os.ReadFile(filepath.Join("testdata", "tables", name))
+	require.NoError(t, err)
+	adapter := NewBaseAdapter(types.DefaultConfig(), logrus.New())
+	doc, err := adapter.ParseHTML(string(html))
+	require.NoError(t, err)
+	chart, err := adapter.ExtractTableData(doc, "table.size-chart")
+	require.NoError(t, err)
+	return chart
+}
+
+func TestExtractTableData_MergedHeaderCells(t *testing.T) {
+	chart := extractFixtureTable(t, "merged-unit-header.html")
+
+	assert.Equal(t, []string{"Size", "Bust (in)", "Bust (cm)", "Waist (in)", "Waist (cm)"}, chart.Headers)
+	require.Len(t, chart.Rows, 2)
+	assert.Equal(t, map[string]string{"Size": "M", "Bust (in)": "36", "Bust (cm)": "91", "Waist (in)": "30", "Waist (cm)": "76"}, chart.Rows[1])
+}
+
+func TestExtractTableData_SpannedBodyCells(t *testing.T) {
+	chart := extractFixtureTable(t, "rowspan-body.html")
+
+	require.Len(t, chart.Rows, 3)
+	assert.Equal(t, map[string]string{"Size": "M", "Fit": "Tall", "Length (in)": "42"}, chart.Rows[1])
+	assert.Equal(t, map[string]string{"Size": "L", "Fit": "Made to order", "Length (in)": "Made to order"}, chart.Rows[2])
+}
+
+func TestGridHeaders_NumbersRepeatedHeaders(t *testing.T) {
+	headers := gridHeaders([]gridRow{{cells: []string{"Size", "Bust", "Bust"}}})
+	assert.Equal(t, []string{"Size", "Bust", "Bust 2"}, headers)
+}
+
+func TestExtractTableData_SkipsCaptionRow(t *testing.T) {
+	chart := extractFixtureTable(t, "caption-row.html")
+
+	assert.Equal(t, []string{"Size", "Bust", "Waist", "Hip"}, chart.Headers)
+	require.Len(t, chart.Rows, 2)
+	assert.Equal(t, "34", chart.Rows[0]["Bust"])
+}
+
+func TestExtractTableData_SkipsUnitToggleRow(t *testing.T) {
+	chart := extractFixtureTable(t, "unit-toggle-row.html")
+
+	assert.Equal(t, []string{"Size", "Chest", "Length", "Shoulder"}, chart.Headers)
+	require.Len(t, chart.Rows, 2)
+	assert.Equal(t, map[string]string{"Size": "L", "Chest": "40", "Length": "29", "Shoulder": "18"}, chart.Rows[1])
+}
+
+func TestHeaderRow(t *testing.T) {
+	grid := []gridRow{
+		{cells: []string{"34", "28", "36"}},
+		{cells: []string{"36", "30", "38"}},
+	}
+	assert.Equal(t, 0, headerRow(grid), "falls back to the first row")
+
+	grid = []gridRow{
+		{cells: []string{"Fit guide", "Fit guide", "Fit guide"}},
+		{cells: []string{"Size", "Bust", "Waist"}},
+		{cells: []string{"S", "34", "28"}},
+	}
+	assert.Equal(t, 1, headerRow(grid))
+}
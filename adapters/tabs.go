@@ -0,0 +1,159 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// TabbedGuide describes a size guide showing one chart per tab (typically inches and
+// centimeters), often inside a modal opened by a "Size guide" button
+type TabbedGuide struct {
+	// Trigger opens the modal in the browser ("" = the guide is always on the page)
+	Trigger string
+
+	// Tabs selects the tab buttons, whose labels name the charts
+	Tabs string
+
+	// Panels selects the tab panels, in tab order; a tab's panel is looked up through its
+	// aria-controls, data-target or #href first
+	Panels string
+}
+
+// HasCharts reports whether the document holds at least one tab panel with a table
+func (g TabbedGuide) HasCharts(doc *goquery.Document) bool {
+	return doc.Find(g.Panels).Find("table").Length() > 0
+}
+
+// ParseTabbedGuide reads the table of every tab panel into its own chart, named after the
+// tab and, for IN / CM tabs, in the tab's unit
+func (b *BaseAdapter) ParseTabbedGuide(doc *goquery.Document, guide TabbedGuide) ([]*types.SizeChart, error) {
+	tabs := doc.Find(guide.Tabs)
+	panels := doc.Find(guide.Panels)
+	if panels.Length() == 0 {
+		return nil, noTableError("no size guide tabs found on page")
+	}
+
+	var charts []*types.SizeChart
+	for i := 0; i < panels.Length(); i++ {
+		label, panel := "", panels.Eq(i)
+		if i < tabs.Length() {
+			tab := tabs.Eq(i)
+			label = utils.CleanText(tab.Text())
+			if target := tabPanel(doc, tab); target != nil {
+				panel = target
+			}
+		}
+
+		table := panel.Find("table").First()
+		if table.Length() == 0 {
+			continue
+		}
+		chart, err := tableChart(table)
+		if err != nil {
+			b.logger.Debugf("Skipping size guide tab %q: %v", label, err)
+			continue
+		}
+
+		chart.Unit = tabUnit(label)
+		if chart.Unit == "" {
+			chart.Unit = b.DetectUnit(chart.Headers)
+		} else {
+			withUnitSuffix(chart, chart.Unit)
+		}
+		chart.Name = b.ChartNameFromHeaders(chart.Headers)
+		if chart.Name == "" && chart.Unit == "" {
+			chart.Name = label
+		}
+		chart.Source = types.SourceSelector
+
+		if b.IsValidSizeChart(chart) {
+			charts = append(charts, chart)
+		}
+	}
+
+	if len(charts) == 0 {
+		return nil, rejectedError("no valid size chart in the size guide tabs")
+	}
+	return charts, nil
+}
+
+// RenderTabbedGuide loads pageURL in the browser, opens the guide's modal and clicks
+// through every tab so lazily rendered panels are filled in, and returns the resulting HTML
+func (b *BaseAdapter) RenderTabbedGuide(ctx context.Context, pageURL string, guide TabbedGuide) (string, error) {
+	trigger, err := json.Marshal(guide.Trigger)
+	if err != nil {
+		return "", err
+	}
+	tabs, err := json.Marshal(guide.Tabs)
+	if err != nil {
+		return "", err
+	}
+
+	script := fmt.Sprintf(`(() => {
+		const trigger = %s ? document.querySelector(%s) : null;
+		if (trigger) trigger.click();
+		document.querySelectorAll(%s).forEach(tab => tab.click());
+		return document.documentElement.outerHTML;
+	})()`, trigger, trigger, tabs)
+
+	html, err := b.browserClient.ExecuteJavaScript(ctx, pageURL, script)
+	if err != nil {
+		return "", fmt.Errorf("failed to open size guide tabs: %w", err)
+	}
+	return html, nil
+}
+
+// tabPanel returns the panel a tab points at, or nil when it does not name one
+func tabPanel(doc *goquery.Document, tab *goquery.Selection) *goquery.Selection {
+	for _, attr := range []string{"aria-controls", "data-target", "data-tab-target", "href"} {
+		value := strings.TrimPrefix(strings.TrimSpace(tab.AttrOr(attr, "")), "#")
+		if value == "" || strings.ContainsAny(value, "/:?") {
+			continue
+		}
+		if panel := doc.Find("[id='" + value + "']"); panel.Length() > 0 {
+			return panel.First()
+		}
+	}
+	return nil
+}
+
+// tabUnit returns the unit named by a tab label ("IN", "Inches", "CM", ...), if any
+func tabUnit(label string) string {
+	switch strings.ToLower(strings.Trim(strings.TrimSpace(label), "()")) {
+	case "in", "inch", "inches":
+		return types.UnitInches
+	case "cm", "cms", "centimeters", "centimetres":
+		return types.UnitCentimeters
+	}
+	return ""
+}
+
+// withUnitSuffix appends the " (in)" / " (cm)" suffix to the measurement headers of a
+// chart read from a unit tab, keeping the size column as is
+func withUnitSuffix(chart *types.SizeChart, unit string) {
+	suffix := " (" + unit + ")"
+	renamed := make(map[string]string, len(chart.Headers))
+	for i, header := range chart.Headers {
+		lower := strings.ToLower(header)
+		if i == 0 || strings.Contains(lower, "size") || strings.HasSuffix(lower, ")") {
+			continue
+		}
+		renamed[header] = header + suffix
+		chart.Headers[i] = header + suffix
+	}
+	for _, row := range chart.Rows {
+		for from, to := range renamed {
+			if value, ok := row[from]; ok {
+				delete(row, from)
+				row[to] = value
+			}
+		}
+	}
+}
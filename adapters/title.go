@@ -0,0 +1,136 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"shopify-extractor/utils"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// TitleSource finds the product title of a page, returning "" when it has none
+type TitleSource func(doc *goquery.Document) string
+
+// DefaultTitleSelectors are the title elements tried, in order, after the structured
+// title sources
+var DefaultTitleSelectors = []string{
+	"h1.product-title",
+	"h1[class*='title']",
+	".product-name h1",
+	".product-info h1",
+	".product-details h1",
+	"h1",
+}
+
+// AddTitleSource adds a title source tried before the built-in chain
+func (b *BaseAdapter) AddTitleSource(source TitleSource) {
+	b.titleSources = append(b.titleSources, source)
+}
+
+// SetTitleSelectors replaces the title elements tried last in the chain
+func (b *BaseAdapter) SetTitleSelectors(selectors ...string) {
+	b.titleSelectors = selectors
+}
+
+// ExtractProductTitleFromDoc resolves the product title of a parsed page through a chain
+// of sources, stopping at the first that yields a title: the sources added with
+// AddTitleSource, the JSON-LD Product name, the Shopify product JSON title, the og:title
+// meta tag and finally the title selectors (DefaultTitleSelectors unless set with
+// SetTitleSelectors)
+func (b *BaseAdapter) ExtractProductTitleFromDoc(doc *goquery.Document) (string, error) {
+	chain := append(append([]TitleSource{}, b.titleSources...), jsonLDTitle, shopifyJSONTitle, ogTitle, b.selectorTitle)
+	for _, source := range chain {
+		if title := utils.CleanText(source(doc)); title != "" {
+			return title, nil
+		}
+	}
+	return "", fmt.Errorf("product title not found on page")
+}
+
+// selectorTitle returns the text of the first non-empty title element
+func (b *BaseAdapter) selectorTitle(doc *goquery.Document) string {
+	selectors := b.titleSelectors
+	if selectors == nil {
+		selectors = DefaultTitleSelectors
+	}
+	for _, selector := range selectors {
+		if title, err := b.ExtractText(doc, selector); err == nil && title != "" {
+			return title
+		}
+	}
+	return ""
+}
+
+// jsonLDTitle returns the name of the Product described by the page's JSON-LD, which may
+// be a single object, a list or an @graph
+func jsonLDTitle(doc *goquery.Document) string {
+	title := ""
+	doc.Find("script[type='application/ld+json']").EachWithBreak(func(i int, script *goquery.Selection) bool {
+		var data interface{}
+		if err := json.Unmarshal([]byte(script.Text()), &data); err != nil {
+			return true
+		}
+		title = jsonLDProductName(data)
+		return title == ""
+	})
+	return title
+}
+
+// jsonLDProductName searches a decoded JSON-LD value for a Product node and returns its name
+func jsonLDProductName(data interface{}) string {
+	switch value := data.(type) {
+	case []interface{}:
+		for _, item := range value {
+			if name := jsonLDProductName(item); name != "" {
+				return name
+			}
+		}
+	case map[string]interface{}:
+		if isJSONLDType(value["@type"], "Product") {
+			if name, ok := value["name"].(string); ok {
+				return name
+			}
+		}
+		if graph, ok := value["@graph"]; ok {
+			return jsonLDProductName(graph)
+		}
+	}
+	return ""
+}
+
+// isJSONLDType reports whether a JSON-LD @type, a string or a list, includes want
+func isJSONLDType(value interface{}, want string) bool {
+	switch t := value.(type) {
+	case string:
+		return t == want
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// shopifyJSONTitle returns the title of the product JSON Shopify themes embed for their
+// scripts (script[data-product-json] or #ProductJson-*)
+func shopifyJSONTitle(doc *goquery.Document) string {
+	title := ""
+	doc.Find("script[data-product-json], script[id^='ProductJson']").EachWithBreak(func(i int, script *goquery.Selection) bool {
+		var product struct {
+			Title string `json:"title"`
+		}
+		if err := json.Unmarshal([]byte(script.Text()), &product); err == nil {
+			title = product.Title
+		}
+		return title == ""
+	})
+	return title
+}
+
+// ogTitle returns the content of the og:title meta tag
+func ogTitle(doc *goquery.Document) string {
+	return doc.Find("meta[property='og:title']").First().AttrOr("content", "")
+}
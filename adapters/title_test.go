@@ -0,0 +1,61 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractProductTitleFromDoc_Chain(t *testing.T) {
+	adapter := newParserTestAdapter()
+	tests := map[string]struct {
+		html string
+		want string
+	}{
+		"json-ld graph": {
+			html: `<script type="application/ld+json">{"@graph":[{"@type":"BreadcrumbList","name":"Home"},{"@type":["Product"],"name":"Linen &amp; Cotton Kurta"}]}</script>
+				<meta property="og:title" content="OG title"><h1>Heading</h1>`,
+			want: "Linen & Cotton Kurta",
+		},
+		"shopify product json": {
+			html: `<script type="application/ld+json">{"@type":"Organization","name":"Store"}</script>
+				<script type="application/json" data-product-json>{"id":1,"title":"Wrap Dress"}</script><h1>Heading</h1>`,
+			want: "Wrap Dress",
+		},
+		"og:title": {
+			html: `<meta property="og:title" content=" Printed  Shirt "><h1 class="logo"></h1>`,
+			want: "Printed Shirt",
+		},
+		"selectors": {
+			html: `<div class="product-info"><h1>Denim Jacket</h1></div>`,
+			want: "Denim Jacket",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			title, err := adapter.ExtractProductTitleFromDoc(parseTestDoc(t, tt.html))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, title)
+		})
+	}
+
+	_, err := adapter.ExtractProductTitleFromDoc(parseTestDoc(t, "<p>no title</p>"))
+	assert.Error(t, err)
+}
+
+func TestExtractProductTitleFromDoc_CustomSources(t *testing.T) {
+	adapter := newParserTestAdapter()
+	adapter.SetTitleSelectors(".pdp-name")
+	doc := parseTestDoc(t, `<h1>Generic</h1><span class="pdp-name">Kurta Set</span>`)
+
+	title, err := adapter.ExtractProductTitleFromDoc(doc)
+	require.NoError(t, err)
+	assert.Equal(t, "Kurta Set", title)
+
+	adapter.AddTitleSource(func(doc *goquery.Document) string { return "From source" })
+	title, err = adapter.ExtractProductTitleFromDoc(doc)
+	require.NoError(t, err)
+	assert.Equal(t, "From source", title)
+}
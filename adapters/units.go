@@ -0,0 +1,95 @@
+package adapters
+
+import (
+	"math"
+	"strings"
+
+	"shopify-extractor/internal/types"
+)
+
+// measurementRange is the plausible range of one kind of measurement column, in inches
+// and in centimeters
+type measurementRange struct {
+	keywords []string
+	in, cm   [2]float64
+}
+
+// measurementRanges covers adult apparel, body and garment measurements alike. Values
+// falling in both ranges of a column (e.g. a 30 long sleeve) are ambiguous and ignored.
+var measurementRanges = []measurementRange{
+	{keywords: []string{"bust", "chest"}, in: [2]float64{28, 50}, cm: [2]float64{71, 127}},
+	{keywords: []string{"waist"}, in: [2]float64{22, 46}, cm: [2]float64{56, 117}},
+	{keywords: []string{"hip", "seat"}, in: [2]float64{30, 54}, cm: [2]float64{76, 137}},
+	{keywords: []string{"shoulder"}, in: [2]float64{12, 21}, cm: [2]float64{30, 53}},
+	{keywords: []string{"inseam"}, in: [2]float64{24, 36}, cm: [2]float64{61, 91}},
+	{keywords: []string{"sleeve"}, in: [2]float64{5, 36}, cm: [2]float64{12, 91}},
+	{keywords: []string{"length"}, in: [2]float64{10, 60}, cm: [2]float64{25, 152}},
+}
+
+// rangeFor returns the measurement range of a column header, if it names a known measurement
+func rangeFor(header string) (measurementRange, bool) {
+	lower := strings.ToLower(header)
+	for _, r := range measurementRanges {
+		for _, keyword := range r.keywords {
+			if strings.Contains(lower, keyword) {
+				return r, true
+			}
+		}
+	}
+	return measurementRange{}, false
+}
+
+// InferUnit guesses the unit of a chart whose headers carry none from the numeric ranges
+// of its measurement columns: a bust of 30-46 reads as inches, 76-117 as centimeters. The
+// confidence is the share of recognised values that agree with the returned unit (0-1);
+// an empty unit means the values do not decide.
+func InferUnit(chart *types.SizeChart) (string, float64) {
+	if chart == nil {
+		return "", 0
+	}
+
+	inches, centimeters, total := 0, 0, 0
+	for _, header := range chart.Headers {
+		r, ok := rangeFor(header)
+		if !ok {
+			continue
+		}
+		for _, row := range chart.Rows {
+			for _, value := range types.ParseMeasurementValues(row[header]) {
+				total++
+				inInches := value >= r.in[0] && value <= r.in[1]
+				inCentimeters := value >= r.cm[0] && value <= r.cm[1]
+				switch {
+				case inInches && !inCentimeters:
+					inches++
+				case inCentimeters && !inInches:
+					centimeters++
+				}
+			}
+		}
+	}
+
+	if total == 0 || inches == centimeters {
+		return "", 0
+	}
+	unit, votes := types.UnitInches, inches
+	if centimeters > inches {
+		unit, votes = types.UnitCentimeters, centimeters
+	}
+	return unit, math.Round(float64(votes)/float64(total)*100) / 100
+}
+
+// labelUnit sets the unit of a chart without one, from its header suffixes or else from
+// its values, recording the confidence of a value-based guess
+func (b *BaseAdapter) labelUnit(chart *types.SizeChart) {
+	if chart == nil || chart.Unit != "" {
+		return
+	}
+	if chart.Unit = b.DetectUnit(chart.Headers); chart.Unit != "" {
+		return
+	}
+	chart.Unit, chart.UnitConfidence = InferUnit(chart)
+	if chart.Unit != "" {
+		b.logger.Debugf("Inferred unit %s of chart %q from its values (confidence %.2f)", chart.Unit, chart.Name, chart.UnitConfidence)
+	}
+}
@@ -0,0 +1,86 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+)
+
+func chartOf(headers []string, rows ...[]string) *types.SizeChart {
+	chart := &types.SizeChart{Headers: headers}
+	for _, values := range rows {
+		row := make(map[string]string)
+		for i, value := range values {
+			row[headers[i]] = value
+		}
+		chart.Rows = append(chart.Rows, row)
+	}
+	return chart
+}
+
+func TestInferUnit(t *testing.T) {
+	headers := []string{"Size", "Bust", "Waist", "Length"}
+
+	unit, confidence := InferUnit(chartOf(headers, []string{"S", "34", "28", "40"}, []string{"M", "36-38", "30", "41"}))
+	assert.Equal(t, types.UnitInches, unit)
+	assert.Equal(t, 0.71, confidence, "the lengths fit both units and do not vote")
+
+	unit, confidence = InferUnit(chartOf(headers, []string{"S", "86", "71", "102"}, []string{"M", "91", "76", "104"}))
+	assert.Equal(t, types.UnitCentimeters, unit)
+	assert.Equal(t, 1.0, confidence)
+
+	unit, _ = InferUnit(chartOf([]string{"Size", "Length"}, []string{"S", "40"}))
+	assert.Empty(t, unit, "ambiguous values do not decide")
+	unit, _ = InferUnit(chartOf([]string{"Size", "Fit"}, []string{"S", "Regular"}))
+	assert.Empty(t, unit)
+}
+
+func TestFilterSizeChart_InfersUnit(t *testing.T) {
+	adapter := NewBaseAdapter(types.DefaultConfig(), logrus.New())
+
+	filtered := adapter.FilterSizeChart(types.Context{}, chartOf([]string{"SIZE", "BUST", "WAIST", "HIP"}, []string{"S", "86", "71", "94"}))
+	assert.Equal(t, types.UnitCentimeters, filtered.Unit)
+	assert.Equal(t, []string{"Size", "Bust (cm)", "Waist (cm)", "Hip (cm)"}, filtered.Headers)
+	assert.Equal(t, "86", filtered.Rows[0]["Bust (cm)"])
+	assert.Equal(t, 1.0, filtered.UnitConfidence)
+
+	// Charts whose values do not decide keep the inches default
+	filtered = adapter.FilterSizeChart(types.Context{}, chartOf([]string{"Size", "Bust"}, []string{"S", "60"}))
+	assert.Equal(t, types.UnitInches, filtered.Unit)
+	assert.Zero(t, filtered.UnitConfidence)
+}
+
+func TestFilterSizeChart_IncludeRaw(t *testing.T) {
+	raw := chartOf([]string{"Size", "Bust", "Length", "Shoulder"}, []string{"S", "34", "40", "14"})
+
+	adapter := NewBaseAdapter(types.DefaultConfig(), logrus.New())
+	assert.Nil(t, adapter.FilterSizeChart(types.Context{}, raw).Raw)
+
+	config := types.DefaultConfig()
+	config.IncludeRaw = true
+	adapter = NewBaseAdapter(config, logrus.New())
+	filtered := adapter.FilterSizeChart(types.Context{}, raw)
+	assert.Equal(t, []string{"Size", "Bust (in)", "Waist (in)", "Hip (in)"}, filtered.Headers)
+	require.NotNil(t, filtered.Raw)
+	assert.Equal(t, []string{"Size", "Bust", "Length", "Shoulder"}, filtered.Raw.Headers)
+	assert.Equal(t, "14", filtered.Raw.Rows[0]["Shoulder"])
+	assert.Equal(t, filtered.ComputeFingerprint(), adapter.FilterSizeChart(types.Context{}, chartOf([]string{"Size", "Bust"}, []string{"S", "34"})).ComputeFingerprint(),
+		"the raw table does not change the chart's identity")
+}
+
+func TestFilterSizeChart_LogsThroughCallLogger(t *testing.T) {
+	adapter := NewBaseAdapter(types.DefaultConfig(), logrus.New())
+	tracer := utils.NewTracer(logrus.New())
+
+	adapter.FilterSizeChart(types.Context{Logger: tracer}, chartOf([]string{"Size", "Bust"}, []string{"S", "34"}))
+
+	var messages []string
+	for _, event := range tracer.Events() {
+		messages = append(messages, event.Message)
+	}
+	assert.Contains(t, messages, "Processing headers: [Size Bust]")
+}
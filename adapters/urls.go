@@ -0,0 +1,38 @@
+package adapters
+
+import (
+	"net/url"
+	"strings"
+
+	"shopify-extractor/utils"
+)
+
+// ResolveURL turns a link href into an absolute, normalized URL (see utils.NormalizeURL),
+// resolving relative ("/products/x", "products/x", "../x") and protocol-relative
+// ("//cdn...") hrefs against base. It returns "" for empty or malformed hrefs and for
+// non-HTTP links (mailto:, javascript:, ...).
+func ResolveURL(base, href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" {
+		return ""
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if !ref.IsAbs() {
+		baseURL, err := url.Parse(base)
+		if err != nil {
+			return ""
+		}
+		// A bare storefront root resolves "products/x" against "/"
+		if baseURL.Path == "" {
+			baseURL.Path = "/"
+		}
+		ref = baseURL.ResolveReference(ref)
+	}
+	if ref.Scheme != "http" && ref.Scheme != "https" {
+		return ""
+	}
+	return utils.NormalizeURL(ref.String())
+}
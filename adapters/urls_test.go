@@ -0,0 +1,34 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveURL(t *testing.T) {
+	base := "https://www.suqah.com"
+	tests := map[string]string{
+		"/products/kurta":                     "https://www.suqah.com/products/kurta",
+		"products/kurta":                      "https://www.suqah.com/products/kurta",
+		" /products/kurta?variant=1 ":         "https://www.suqah.com/products/kurta",
+		"https://www.suqah.com/products/a":    "https://www.suqah.com/products/a",
+		"//cdn.shopify.com/s/files/chart.png": "https://cdn.shopify.com/s/files/chart.png",
+		"mailto:care@suqah.com":               "",
+		"javascript:void(0)":                  "",
+		"":                                    "",
+	}
+	for href, want := range tests {
+		assert.Equal(t, want, ResolveURL(base, href), "%q", href)
+	}
+
+	assert.Equal(t, "https://shop.example/collections/dresses/products/a", ResolveURL("https://shop.example/collections/dresses/", "products/a"))
+	assert.Equal(t, "https://shop.example/products/a", ResolveURL("https://shop.example/collections/dresses/", "../../products/a/"))
+}
+
+func TestExtractLinks_ResolvesAgainstBase(t *testing.T) {
+	adapter := newParserTestAdapter()
+	doc := parseTestDoc(t, `<a href="/products/a">A</a><a href="products/b">B</a><a href="tel:123">Call</a>`)
+
+	assert.Equal(t, []string{"https://shop.example/products/a", "https://shop.example/products/b"}, adapter.ExtractLinks(doc, "https://shop.example", "a"))
+}
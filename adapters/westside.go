@@ -7,22 +7,46 @@ import (
 	"strings"
 	"time"
 
+	"shopify-extractor/frontier"
 	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
+// wizzyResultsSelector matches the product links of the wizzy search widget, which
+// renders collection grids after the page loaded
+const wizzyResultsSelector = ".wizzy-search-results a[href*='/products/']"
+
+// wizzyWait bounds the wait for the wizzy widget; collections without it are parsed as
+// rendered once it is over
+const wizzyWait = 10 * time.Second
+
+// wizzyScroll loads the whole wizzy grid, which shows about 24 products and appends more
+// as the page is scrolled
+var wizzyScroll = utils.ScrollOptions{
+	Items:      wizzyResultsSelector,
+	Wait:       wizzyWait,
+	Settle:     time.Second,
+	MaxScrolls: 20,
+}
+
 // WestsideAdapter handles extraction for westside.com
 type WestsideAdapter struct {
-	*BaseAdapter
+	*ShopifyBaseAdapter
 }
 
 // NewWestsideAdapter creates a new Westside adapter
 func NewWestsideAdapter(config *types.Config, logger types.Logger) *WestsideAdapter {
 	config.UseHeadlessBrowser = true // Always use browser for Westside
-	return &WestsideAdapter{
-		BaseAdapter: NewBaseAdapter(config, logger),
-	}
+	adapter := &WestsideAdapter{
+		ShopifyBaseAdapter: NewShopifyBaseAdapter(config, logger),
+	}
+	adapter.SetExpectedContainers(".sizeguide")
+	adapter.SetTitleSelectors(append([]string{".product__title h1"}, DefaultTitleSelectors...)...)
+	adapter.AddChartParser(NewChartParser("dual-unit", HasElement(".sizeguide table"), adapter.parseDualUnitCharts), PriorityDualUnit)
+	adapter.SetStoreName(adapter.GetStoreName())
+	return adapter
 }
 
 // GetStoreName returns the store name
@@ -30,53 +54,92 @@ func (w *WestsideAdapter) GetStoreName() string {
 	return "westside.com"
 }
 
+// BaseURL returns the storefront root URL
+func (w *WestsideAdapter) BaseURL() string {
+	return "https://www.westside.com"
+}
+
 // GetProductURLs returns a list of product URLs for Westside
 func (w *WestsideAdapter) GetProductURLs(ctx types.Context) ([]string, error) {
+	var productURLs []string
+	err := w.StreamProductURLs(w.fetchContext(ctx), func(productURL string) bool {
+		productURLs = append(productURLs, productURL)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return productURLs, nil
+}
+
+// StreamProductURLs discovers Westside product URLs collection by collection, or from
+// /products.json with json discovery, passing each unique URL to emit as soon as it is
+// found. Discovery stops early when emit returns false.
+func (w *WestsideAdapter) StreamProductURLs(ctx context.Context, emit func(productURL string) bool) error {
 	startTime := time.Now()
-	w.logger.Info("Starting product discovery for Westside")
+	w.loggerFrom(ctx).Info("Starting product discovery for Westside")
 
-	// Step 1: Get the products page
+	seen := frontier.NewSet(w.config.FrontierMemoryLimit, w.config.FrontierDir)
+	defer seen.Close()
+	if handled, err := w.tryProductsJSON(ctx, "https://www.westside.com", seen, emit); handled {
+		return err
+	}
+
+	// Step 1: Get the products page. Unlike the other Shopify stores, /collections/all is
+	// not used: the wizzy widget renders every listing by scrolling and ignores ?page=.
 	productsPageURL := "https://www.westside.com/products"
-	w.logger.Debugf("Fetching products page: %s", productsPageURL)
+	w.loggerFrom(ctx).Debugf("Fetching products page: %s", productsPageURL)
 
-	html, err := w.GetPageContent(context.Background(), productsPageURL)
+	html, err := w.GetPageContent(ctx, productsPageURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get products page: %w", err)
+		return fmt.Errorf("failed to get products page: %w", err)
 	}
 
 	doc, err := w.ParseHTML(html)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse products page: %w", err)
+		return fmt.Errorf("failed to parse products page: %w", err)
 	}
 
 	// Step 2: Find all collection URLs
 	collectionURLs, err := w.ExtractCollectionURLs(doc, "https://www.westside.com")
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract collection URLs: %w", err)
+		return fmt.Errorf("failed to extract collection URLs: %w", err)
 	}
 
-	w.logger.Infof("Found %d collections", len(collectionURLs))
+	w.loggerFrom(ctx).Infof("Found %d collections", len(collectionURLs))
 
-	// Step 3: Iterate through collections to find product URLs
-	var allProductURLs []string
+	// Step 3: Iterate through collections, emitting new product URLs as they are found
 	totalProductsFound := 0
 	for i, collectionURL := range collectionURLs {
-		// if i == 0 {
-		// 	continue
-		// }
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		collectionStartTime := time.Now()
-		w.logger.Debugf("Processing collection %d/%d: %s", i+1, len(collectionURLs), collectionURL)
+		w.loggerFrom(ctx).Debugf("Processing collection %d/%d: %s", i+1, len(collectionURLs), collectionURL)
 
-		productURLs, err := w.extractProductURLsFromCollection(collectionURL)
+		productURLs, err := w.extractProductURLsFromCollection(ctx, collectionURL)
 		if err != nil {
-			w.logger.Warnf("Failed to extract products from collection %s: %v", collectionURL, err)
+			w.loggerFrom(ctx).Warnf("Failed to extract products from collection %s: %v", collectionURL, err)
 			continue
 		}
 
 		collectionTime := time.Since(collectionStartTime)
-		allProductURLs = append(allProductURLs, productURLs...)
 		totalProductsFound += len(productURLs)
-		w.logger.Debugf("Collection %s processed in %v, found %d products (total so far: %d)", collectionURL, collectionTime, len(productURLs), totalProductsFound)
+		w.loggerFrom(ctx).Debugf("Collection %s processed in %v, found %d products (total so far: %d)", collectionURL, collectionTime, len(productURLs), totalProductsFound)
+
+		for _, productURL := range productURLs {
+			isNew, err := seen.Add(productURL)
+			if err != nil {
+				return fmt.Errorf("failed to record product URL: %w", err)
+			}
+			if !isNew {
+				continue
+			}
+			if !emit(productURL) {
+				w.loggerFrom(ctx).Infof("Product discovery stopped early after %d unique products", seen.Len())
+				return nil
+			}
+		}
 
 		// Process only first few collections for speed testing
 		// if i >= 4 { // Process first 3 collections only
@@ -84,21 +147,18 @@ func (w *WestsideAdapter) GetProductURLs(ctx types.Context) ([]string, error) {
 		// }
 	}
 
-	// Remove duplicates
-	uniqueProductURLs := w.RemoveDuplicateURLs(allProductURLs)
-
 	totalTime := time.Since(startTime)
-	w.logger.Infof("Product discovery completed in %v", totalTime)
-	w.logger.Infof("Total unique products found: %d", len(uniqueProductURLs))
-	return uniqueProductURLs, nil
+	w.loggerFrom(ctx).Infof("Product discovery completed in %v", totalTime)
+	w.loggerFrom(ctx).Infof("Total unique products found: %d", seen.Len())
+	return nil
 }
 
 // extractProductURLsFromCollection extracts product URLs from a collection page
-func (w *WestsideAdapter) extractProductURLsFromCollection(collectionURL string) ([]string, error) {
+func (w *WestsideAdapter) extractProductURLsFromCollection(ctx context.Context, collectionURL string) ([]string, error) {
 	w.logger.Debugf("Extracting products from collection: %s", collectionURL)
 
-	// Get the collection page
-	html, err := w.GetPageContent(context.Background(), collectionURL)
+	// Get the collection page once the wizzy widget loaded its whole grid
+	html, err := w.GetPageContentScrolled(ctx, collectionURL, wizzyScroll)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get collection page: %w", err)
 	}
@@ -108,109 +168,40 @@ func (w *WestsideAdapter) extractProductURLsFromCollection(collectionURL string)
 		return nil, fmt.Errorf("failed to parse collection page: %w", err)
 	}
 
-	var productURLs []string
-
-	// First, try to find products in the wizzy-search-results container (much faster)
-	doc.Find(".wizzy-search-results a[href*='/products/']").Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if !exists {
-			return
-		}
-
-		// Clean and normalize the URL
-		href = strings.TrimSpace(href)
-		if href == "" {
-			return
-		}
-
-		// Convert relative URLs to absolute URLs
-		if strings.HasPrefix(href, "/") {
-			href = "https://www.westside.com" + href
-		} else if !strings.HasPrefix(href, "http") {
-			href = "https://www.westside.com/" + href
-		}
-
-		// Validate URL and ensure it's a Westside product
-		if parsedURL, err := url.Parse(href); err == nil {
-			// Only include URLs from westside.com domain
-			if strings.Contains(parsedURL.Hostname(), "westside.com") {
-				productURLs = append(productURLs, href)
-			}
-		}
-	})
-
-	// Also try to find products in swiper containers
-	doc.Find(".swiper a[href*='/products/']").Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if !exists {
-			return
-		}
-
-		// Clean and normalize the URL
-		href = strings.TrimSpace(href)
-		if href == "" {
-			return
-		}
-
-		// Convert relative URLs to absolute URLs
-		if strings.HasPrefix(href, "/") {
-			href = "https://www.westside.com" + href
-		} else if !strings.HasPrefix(href, "http") {
-			href = "https://www.westside.com/" + href
-		}
-
-		// Validate URL and ensure it's a Westside product
-		if parsedURL, err := url.Parse(href); err == nil {
-			// Only include URLs from westside.com domain
-			if strings.Contains(parsedURL.Hostname(), "westside.com") {
-				productURLs = append(productURLs, href)
-			}
-		}
-	})
+	// First, try to find products in the wizzy-search-results container (much faster),
+	// then in swiper containers
+	productURLs := w.westsideLinks(doc, wizzyResultsSelector)
+	productURLs = append(productURLs, w.westsideLinks(doc, ".swiper a[href*='/products/']")...)
 
 	// If no products found in wizzy-search-results and swiper, fall back to searching the entire page
 	if len(productURLs) == 0 {
 		w.logger.Debugf("No products found in .wizzy-search-results or .swiper, searching entire page")
-		doc.Find("a[href*='/products/']").Each(func(i int, s *goquery.Selection) {
-			href, exists := s.Attr("href")
-			if !exists {
-				return
-			}
-
-			// Clean and normalize the URL
-			href = strings.TrimSpace(href)
-			if href == "" {
-				return
-			}
-
-			// Convert relative URLs to absolute URLs
-			if strings.HasPrefix(href, "/") {
-				href = "https://www.westside.com" + href
-			} else if !strings.HasPrefix(href, "http") {
-				href = "https://www.westside.com/" + href
-			}
-
-			// Validate URL and ensure it's a Westside product
-			if parsedURL, err := url.Parse(href); err == nil {
-				// Only include URLs from westside.com domain
-				if strings.Contains(parsedURL.Hostname(), "westside.com") {
-					productURLs = append(productURLs, href)
-				}
-			}
-		})
+		productURLs = w.westsideLinks(doc, "a[href*='/products/']")
 	}
 
 	w.logger.Debugf("Found %d products using .wizzy-search-results and .swiper selectors", len(productURLs))
 	return productURLs, nil
 }
 
+// westsideLinks returns the absolute URLs of the links matching selector that stay on
+// westside.com
+func (w *WestsideAdapter) westsideLinks(doc *goquery.Document, selector string) []string {
+	var links []string
+	for _, link := range w.ExtractLinks(doc, w.BaseURL(), selector) {
+		if parsedURL, err := url.Parse(link); err == nil && strings.Contains(parsedURL.Hostname(), "westside.com") {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
 // ExtractSizeChart extracts the size chart from a Westside product page
 func (w *WestsideAdapter) ExtractSizeChart(ctx types.Context, productURL string) (*types.SizeChart, error) {
 	startTime := time.Now()
-	w.logger.Debugf("Extracting size chart from %s", productURL)
+	w.loggerFor(ctx).Debugf("Extracting size chart from %s", productURL)
 
 	// Get page content
-	html, err := w.GetPageContent(context.Background(), productURL)
+	html, err := w.GetPageContent(w.fetchContext(ctx), productURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get page content: %w", err)
 	}
@@ -225,17 +216,17 @@ func (w *WestsideAdapter) ExtractSizeChart(ctx types.Context, productURL string)
 	selector := ".sizeguide table"
 	table := doc.Find(selector).First()
 	if table.Length() == 0 {
-		return nil, fmt.Errorf("size chart table not found in .sizeguide container")
+		return nil, noTableError("size chart table not found in .sizeguide container")
 	}
 
-	w.logger.Debugf("Found size chart table using selector: %s", selector)
+	w.loggerFor(ctx).Debugf("Found size chart table using selector: %s", selector)
 
 	// Extract both inches and centimeters from the same table
 	// The table contains both units in span elements with classes "default" (cm) and "alt" (inches)
 	result, err := w.extractDualUnitSizeChart(doc, selector)
 	if err == nil {
 		extractionTime := time.Since(startTime)
-		w.logger.Debugf("Size chart extraction completed in %v", extractionTime)
+		w.loggerFor(ctx).Debugf("Size chart extraction completed in %v", extractionTime)
 	}
 	return result, err
 }
@@ -244,7 +235,7 @@ func (w *WestsideAdapter) ExtractSizeChart(ctx types.Context, productURL string)
 func (w *WestsideAdapter) extractDualUnitSizeChart(doc *goquery.Document, selector string) (*types.SizeChart, error) {
 	table := doc.Find(selector).First()
 	if table.Length() == 0 {
-		return nil, fmt.Errorf("size chart table not found")
+		return nil, noTableError("size chart table not found")
 	}
 
 	// Extract headers
@@ -257,13 +248,16 @@ func (w *WestsideAdapter) extractDualUnitSizeChart(doc *goquery.Document, select
 	})
 
 	if len(headers) == 0 {
-		return nil, fmt.Errorf("no headers found in size chart")
+		return nil, rejectedError("no headers found in size chart")
 	}
 
 	w.logger.Debugf("Found headers: %v", headers)
 
 	// Create size chart with clean headers
 	sizeChart := &types.SizeChart{
+		Name:    w.ChartNameFromHeaders(headers),
+		Unit:    types.UnitMixed,
+		Source:  types.SourceSelector,
 		Headers: []string{"Size"},
 		Rows:    []map[string]string{},
 	}
@@ -321,7 +315,7 @@ func (w *WestsideAdapter) extractDualUnitSizeChart(doc *goquery.Document, select
 	})
 
 	if len(sizeChart.Rows) == 0 {
-		return nil, fmt.Errorf("no data rows found in size chart")
+		return nil, rejectedError("no data rows found in size chart")
 	}
 
 	return sizeChart, nil
@@ -355,20 +349,15 @@ func (w *WestsideAdapter) cleanHeader(header string) string {
 // cleanSizeText removes duplicate size text
 func (w *WestsideAdapter) cleanSizeText(sizeText string) string {
 	// Remove duplicates like "XS - 36XS - 36" -> "XS - 36"
-	parts := strings.Fields(sizeText)
-	if len(parts) >= 2 {
-		// Take first two parts (e.g., "XS - 36")
-		return strings.Join(parts[:2], " ")
-	}
-	return sizeText
+	return utils.CollapseRepeats(sizeText)
 }
 
 // GetProductTitle extracts the product title from a Westside product page
 func (w *WestsideAdapter) GetProductTitle(ctx types.Context, productURL string) (string, error) {
-	w.logger.Debugf("Extracting product title from %s", productURL)
+	w.loggerFor(ctx).Debugf("Extracting product title from %s", productURL)
 
 	// Get page content
-	html, err := w.GetPageContent(context.Background(), productURL)
+	html, err := w.GetPageContent(w.fetchContext(ctx), productURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to get page content: %w", err)
 	}
@@ -379,50 +368,7 @@ func (w *WestsideAdapter) GetProductTitle(ctx types.Context, productURL string)
 		return "", fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
-	// Try different selectors for product title
-	selectors := []string{
-		".product__title h1",
-		"h1.product-title",
-		"h1[class*='title']",
-		".product-name h1",
-		".product-info h1",
-		".product-details h1",
-		"h1",
-	}
-
-	for _, selector := range selectors {
-		title, err := w.ExtractText(doc, selector)
-		if err == nil && title != "" {
-			w.logger.Debugf("Successfully extracted product title using selector: %s", selector)
-			return title, nil
-		}
-	}
-
-	return "", fmt.Errorf("product title not found on page")
-}
-
-// GetProductTitleFromDoc extracts the product title from an already parsed document
-func (w *WestsideAdapter) GetProductTitleFromDoc(doc *goquery.Document) (string, error) {
-	// Try different selectors for product title
-	selectors := []string{
-		".product__title h1",
-		"h1.product-title",
-		"h1[class*='title']",
-		".product-name h1",
-		".product-info h1",
-		".product-details h1",
-		"h1",
-	}
-
-	for _, selector := range selectors {
-		title, err := w.ExtractText(doc, selector)
-		if err == nil && title != "" {
-			w.logger.Debugf("Successfully extracted product title using selector: %s", selector)
-			return title, nil
-		}
-	}
-
-	return "", fmt.Errorf("product title not found on page")
+	return w.ExtractProductTitleFromDoc(doc)
 }
 
 func normalizeHeader(header, unit string) string {
@@ -457,14 +403,19 @@ func splitValue(val string) (string, string) {
 // ExtractAllSizeCharts extracts all size charts from a Westside product page
 func (w *WestsideAdapter) ExtractAllSizeCharts(ctx types.Context, productURL string) (string, []*types.SizeChart, error) {
 	startTime := time.Now()
-	w.logger.Debugf("Extracting all size charts from %s", productURL)
+	w.loggerFor(ctx).Debugf("Extracting all size charts from %s", productURL)
 
 	// Get page content once and reuse it
-	html, err := w.GetPageContent(context.Background(), productURL)
+	html, err := w.GetPageContent(w.fetchContext(ctx), productURL)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to get page content: %w", err)
 	}
 
+	// Pages without a single size-related word carry no chart
+	if err := skipChartless(html); err != nil {
+		return "", nil, err
+	}
+
 	// Parse HTML once
 	doc, err := w.ParseHTML(html)
 	if err != nil {
@@ -472,22 +423,32 @@ func (w *WestsideAdapter) ExtractAllSizeCharts(ctx types.Context, productURL str
 	}
 
 	// Extract both title and size chart from the same document
-	title, _ := w.GetProductTitleFromDoc(doc)
+	title, _ := w.ExtractProductTitleFromDoc(doc)
 	if title != "" {
-		w.logger.Debugf("Extracted title: %s", title)
+		w.loggerFor(ctx).Debugf("Extracted title: %s", title)
 	}
 
-	// Extract size chart using the cached document
-	sizeChart, err := w.extractSizeChartFromDoc(doc, productURL)
+	// Extract size charts using the cached document
+	charts, err := w.ParseSizeCharts(doc)
 	if err != nil {
 		return title, nil, err
 	}
 
 	extractionTime := time.Since(startTime)
-	w.logger.Debugf("Complete product extraction completed in %v", extractionTime)
+	w.loggerFor(ctx).Debugf("Complete product extraction completed in %v", extractionTime)
+	return title, charts, nil
+}
+
+// parseDualUnitCharts reads the .sizeguide table and splits it into separate inches
+// and centimeters charts
+func (w *WestsideAdapter) parseDualUnitCharts(doc *goquery.Document) ([]*types.SizeChart, error) {
+	sizeChart, err := w.extractSizeChartFromDoc(doc)
+	if err != nil {
+		return nil, err
+	}
 
 	if sizeChart == nil {
-		return title, nil, fmt.Errorf("no size chart found")
+		return nil, rejectedError("no size chart found")
 	}
 
 	// Build two separate charts: one for inches, one for centimeters
@@ -516,6 +477,9 @@ func (w *WestsideAdapter) ExtractAllSizeCharts(ctx types.Context, productURL str
 
 	// Build inches chart
 	inchesChart := &types.SizeChart{
+		Name:    sizeChart.Name,
+		Unit:    types.UnitInches,
+		Source:  sizeChart.Source,
 		Headers: []string{"Size"},
 		Rows:    []map[string]string{},
 	}
@@ -540,6 +504,9 @@ func (w *WestsideAdapter) ExtractAllSizeCharts(ctx types.Context, productURL str
 
 	// Build centimeters chart
 	cmChart := &types.SizeChart{
+		Name:    sizeChart.Name,
+		Unit:    types.UnitCentimeters,
+		Source:  sizeChart.Source,
 		Headers: []string{"Size"},
 		Rows:    []map[string]string{},
 	}
@@ -563,21 +530,21 @@ func (w *WestsideAdapter) ExtractAllSizeCharts(ctx types.Context, productURL str
 	}
 
 	if len(charts) == 0 {
-		return title, nil, fmt.Errorf("no valid size chart found")
+		return nil, rejectedError("no valid size chart found")
 	}
-	return title, charts, nil
+	return charts, nil
 }
 
 // extractSizeChartFromDoc extracts size chart from an already parsed document
-func (w *WestsideAdapter) extractSizeChartFromDoc(doc *goquery.Document, productURL string) (*types.SizeChart, error) {
+func (w *WestsideAdapter) extractSizeChartFromDoc(doc *goquery.Document) (*types.SizeChart, error) {
 	startTime := time.Now()
-	w.logger.Debugf("Extracting size chart from document for %s", productURL)
+	w.logger.Debugf("Extracting size chart from document")
 
 	// Use the specific sizeguide selector for faster extraction
 	selector := ".sizeguide table"
 	table := doc.Find(selector).First()
 	if table.Length() == 0 {
-		return nil, fmt.Errorf("size chart table not found in .sizeguide container")
+		return nil, noTableError("size chart table not found in .sizeguide container")
 	}
 
 	w.logger.Debugf("Found size chart table using selector: %s", selector)
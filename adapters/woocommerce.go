@@ -0,0 +1,209 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"shopify-extractor/frontier"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+)
+
+const (
+	// wooStoreAPIPageSize is the largest page size the WooCommerce Store API accepts
+	wooStoreAPIPageSize = 100
+
+	// wooMaxPages bounds pagination of the Store API and the shop pages on very large or
+	// misbehaving catalogs
+	wooMaxPages = 200
+
+	// wooProductLinks selects product links on WooCommerce shop and category pages
+	wooProductLinks = "li.product a.woocommerce-LoopProduct-link, li.product a[href*='/product/'], .products a[href*='/product/']"
+)
+
+// WooCommerceBaseAdapter extends BaseAdapter with product discovery for WooCommerce
+// storefronts: the public Store API (/wp-json/wc/store/products) when the store exposes
+// it, and the paginated /shop/ pages otherwise
+type WooCommerceBaseAdapter struct {
+	*BaseAdapter
+}
+
+// NewWooCommerceBaseAdapter creates a base adapter for a WooCommerce store
+func NewWooCommerceBaseAdapter(config *types.Config, logger types.Logger) *WooCommerceBaseAdapter {
+	adapter := &WooCommerceBaseAdapter{BaseAdapter: NewBaseAdapter(config, logger)}
+	adapter.SetProductURLMatcher(IsWooCommerceProductURL)
+	return adapter
+}
+
+// IsWooCommerceProductURL reports whether pageURL is a WooCommerce product page, which
+// lives under /product/ with the default permalink settings
+func IsWooCommerceProductURL(pageURL string) bool {
+	parsed, err := url.Parse(pageURL)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(parsed.Path, "/product/")
+}
+
+// wooProduct is the subset of a Store API product used by the extractor
+type wooProduct struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Permalink string `json:"permalink"`
+}
+
+// fetchStoreAPIPage fetches one page of the store's public Store API product listing.
+// JSON endpoints never need a browser, so the HTTP client is always used.
+func (w *WooCommerceBaseAdapter) fetchStoreAPIPage(ctx context.Context, baseURL string, page int) ([]wooProduct, error) {
+	pageURL := fmt.Sprintf("%s/wp-json/wc/store/products?per_page=%d&page=%d", strings.TrimSuffix(baseURL, "/"), wooStoreAPIPageSize, page)
+
+	body, err := w.httpClient.Get(ctx, pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", pageURL, err)
+	}
+
+	var products []wooProduct
+	if err := json.Unmarshal(body, &products); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", pageURL, err)
+	}
+	return products, nil
+}
+
+// StreamWooCommerceProducts discovers the product URLs of the WooCommerce store at baseURL,
+// passing each unique URL to emit as soon as it is found. The Store API is used when the
+// store exposes it; otherwise the /shop/ pages are paginated. Discovery stops early when
+// emit returns false.
+func (w *WooCommerceBaseAdapter) StreamWooCommerceProducts(ctx context.Context, baseURL string, emit func(productURL string) bool) error {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	seen := frontier.NewSet(w.config.FrontierMemoryLimit, w.config.FrontierDir)
+	defer seen.Close()
+
+	stopped := false
+	add := func(productURL string) (bool, error) {
+		isNew, err := seen.Add(productURL)
+		if err != nil {
+			return false, fmt.Errorf("failed to record product URL: %w", err)
+		}
+		if isNew && !emit(productURL) {
+			w.loggerFrom(ctx).Infof("Product discovery stopped early after %d unique products", seen.Len())
+			stopped = true
+		}
+		return isNew, nil
+	}
+
+	err := w.streamStoreAPI(ctx, baseURL, add, &stopped)
+	if err == nil {
+		w.loggerFrom(ctx).Infof("Total unique products found through the Store API: %d", seen.Len())
+		return nil
+	}
+	if seen.Len() > 0 || ctx.Err() != nil {
+		return err
+	}
+
+	w.loggerFrom(ctx).Infof("WooCommerce Store API unavailable for %s, paginating shop pages: %v", baseURL, err)
+	if err := w.streamShopPages(ctx, baseURL, add, &stopped); err != nil {
+		return err
+	}
+	w.loggerFrom(ctx).Infof("Total unique products found on shop pages: %d", seen.Len())
+	return nil
+}
+
+// streamStoreAPI pages through the Store API product listing until a short page
+func (w *WooCommerceBaseAdapter) streamStoreAPI(ctx context.Context, baseURL string, add func(string) (bool, error), stopped *bool) error {
+	for page := 1; page <= wooMaxPages && !*stopped; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		products, err := w.fetchStoreAPIPage(ctx, baseURL, page)
+		if err != nil {
+			return err
+		}
+		for _, product := range products {
+			if product.Permalink == "" {
+				continue
+			}
+			if _, err := add(utils.NormalizeURL(product.Permalink)); err != nil {
+				return err
+			}
+			if *stopped {
+				return nil
+			}
+		}
+		if len(products) < wooStoreAPIPageSize {
+			break
+		}
+	}
+	return nil
+}
+
+// streamShopPages walks /shop/, /shop/page/2/, ... until a page fails to load or adds no
+// new product
+func (w *WooCommerceBaseAdapter) streamShopPages(ctx context.Context, baseURL string, add func(string) (bool, error), stopped *bool) error {
+	for page := 1; page <= wooMaxPages && !*stopped; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		pageURL := baseURL + "/shop/"
+		if page > 1 {
+			pageURL = fmt.Sprintf("%s/shop/page/%d/", baseURL, page)
+		}
+		w.loggerFrom(ctx).Debugf("Fetching shop page: %s", pageURL)
+
+		html, err := w.GetPageContent(ctx, pageURL)
+		if err != nil {
+			if page == 1 {
+				return fmt.Errorf("failed to get shop page: %w", err)
+			}
+			// Past the last page WooCommerce answers 404
+			w.loggerFrom(ctx).Debugf("Shop pagination ended at page %d: %v", page, err)
+			return nil
+		}
+		doc, err := w.ParseHTML(html)
+		if err != nil {
+			return fmt.Errorf("failed to parse shop page %s: %w", pageURL, err)
+		}
+
+		added := 0
+		for _, productURL := range w.RemoveDuplicateURLs(w.ExtractLinks(doc, baseURL, wooProductLinks)) {
+			isNew, err := add(productURL)
+			if err != nil {
+				return err
+			}
+			if isNew {
+				added++
+			}
+			if *stopped {
+				return nil
+			}
+		}
+		w.loggerFrom(ctx).Debugf("Found %d new products on %s", added, pageURL)
+		if added == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// CountCatalogProducts counts the products a WooCommerce store publishes through the
+// Store API, paginating until a short page is returned
+func (w *WooCommerceBaseAdapter) CountCatalogProducts(ctx context.Context, baseURL string) (int, error) {
+	total := 0
+	for page := 1; page <= wooMaxPages; page++ {
+		products, err := w.fetchStoreAPIPage(ctx, baseURL, page)
+		if err != nil {
+			return 0, err
+		}
+		total += len(products)
+		if len(products) < wooStoreAPIPageSize {
+			break
+		}
+	}
+
+	w.loggerFrom(ctx).Debugf("Catalog of %s has %d products according to the Store API", baseURL, total)
+	return total, nil
+}
@@ -0,0 +1,79 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func newWooTestAdapter() *WooCommerceBaseAdapter {
+	config := types.DefaultConfig()
+	config.RequestDelay = 0
+	config.MaxRetries = 0
+	config.UseHeadlessBrowser = false
+	return NewWooCommerceBaseAdapter(config, logrus.New())
+}
+
+func streamAll(t *testing.T, adapter *WooCommerceBaseAdapter, baseURL string) []string {
+	t.Helper()
+	var urls []string
+	err := adapter.StreamWooCommerceProducts(context.Background(), baseURL, func(productURL string) bool {
+		urls = append(urls, productURL)
+		return true
+	})
+	require.NoError(t, err)
+	return urls
+}
+
+func TestWooCommerce_StoreAPI(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/wp-json/wc/store/products", r.URL.Path)
+		if r.URL.Query().Get("page") != "1" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		fmt.Fprintf(w, `[{"id":1,"name":"Dress","permalink":"%[1]s/product/dress/"},{"id":2,"name":"Kurta","permalink":"%[1]s/product/kurta/"}]`, server.URL)
+	}))
+	defer server.Close()
+
+	adapter := newWooTestAdapter()
+	assert.Equal(t, []string{server.URL + "/product/dress", server.URL + "/product/kurta"}, streamAll(t, adapter, server.URL))
+
+	count, err := adapter.CountCatalogProducts(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestWooCommerce_ShopPagesFallback(t *testing.T) {
+	pages := map[string]string{
+		"/shop/":        `<ul class="products"><li class="product"><a class="woocommerce-LoopProduct-link" href="/product/dress/">Dress</a></li></ul>`,
+		"/shop/page/2/": `<ul class="products"><li class="product"><a href="/product/kurta/">Kurta</a><a href="/product/dress/">Dress</a></li></ul>`,
+		"/shop/page/3/": `<ul class="products"><li class="product"><a href="/product/kurta/">Kurta</a></li></ul>`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	adapter := newWooTestAdapter()
+	assert.Equal(t, []string{server.URL + "/product/dress", server.URL + "/product/kurta"}, streamAll(t, adapter, server.URL))
+}
+
+func TestIsWooCommerceProductURL(t *testing.T) {
+	assert.True(t, IsWooCommerceProductURL("https://shop.example/product/dress/"))
+	assert.False(t, IsWooCommerceProductURL("https://shop.example/shop/page/2/"))
+	assert.False(t, IsWooCommerceProductURL("https://shop.example/products/dress"))
+}
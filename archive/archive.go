@@ -0,0 +1,245 @@
+// Package archive stores the raw HTML of fetched product pages so extractions can later
+// be re-parsed without re-crawling.
+//
+// Pages are gzip-compressed and content-addressed under objects/ by the SHA-256 of their
+// HTML, so a page unchanged between runs is stored once. manifest.ndjson records one
+// Entry per fetch, tying a product URL to its content in a given run.
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"shopify-extractor/internal/types"
+)
+
+// manifestFile is the name of the manifest inside an archive directory
+const manifestFile = "manifest.ndjson"
+
+// Entry records one archived fetch of a product page
+type Entry struct {
+	RunID     string    `json:"run_id,omitempty"`
+	Store     string    `json:"store"`
+	URL       string    `json:"url"`
+	Digest    string    `json:"sha256"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Archive is a directory of archived pages. It is safe for concurrent use, and several
+// Archives may append to the same directory.
+type Archive struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// Open opens the archive in dir, creating the directory when needed
+func Open(dir string) (*Archive, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "objects"), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	return &Archive{dir: dir}, nil
+}
+
+// Dir returns the archive directory
+func (a *Archive) Dir() string {
+	return a.dir
+}
+
+// Digest returns the content address of a page
+func Digest(html string) string {
+	sum := sha256.Sum256([]byte(html))
+	return hex.EncodeToString(sum[:])
+}
+
+// objectPath returns where the content with the given digest is stored
+func (a *Archive) objectPath(digest string) string {
+	return filepath.Join(a.dir, "objects", digest[:2], digest+".html.gz")
+}
+
+// Put archives the HTML of a page fetched for a store and records it in the manifest.
+// Content already in the archive is not written again.
+func (a *Archive) Put(runID, store, pageURL, html string) (Entry, error) {
+	entry := Entry{
+		RunID:     runID,
+		Store:     store,
+		URL:       pageURL,
+		Digest:    Digest(html),
+		FetchedAt: time.Now().UTC(),
+	}
+	if err := a.writeObject(entry.Digest, html); err != nil {
+		return Entry{}, err
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to marshal archive entry: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f, err := os.OpenFile(filepath.Join(a.dir, manifestFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to open archive manifest: %w", err)
+	}
+	defer f.Close()
+	// One write per line keeps concurrent appenders from interleaving entries
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return Entry{}, fmt.Errorf("failed to write archive manifest: %w", err)
+	}
+	return entry, nil
+}
+
+// writeObject stores compressed content under its digest, through a temporary file so
+// readers never see a partial object
+func (a *Archive) writeObject(digest, html string) error {
+	path := a.objectPath(digest)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create archive object directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create archive object: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := gzip.NewWriter(tmp)
+	if _, err := io.WriteString(zw, html); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write archive object: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write archive object: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write archive object: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store archive object: %w", err)
+	}
+	return nil
+}
+
+// Get returns the HTML archived under a digest
+func (a *Archive) Get(digest string) (string, error) {
+	if len(digest) < 2 || strings.ContainsAny(digest, `/\.`) {
+		return "", fmt.Errorf("invalid archive digest %q", digest)
+	}
+	f, err := os.Open(a.objectPath(digest))
+	if err != nil {
+		return "", fmt.Errorf("failed to open archived page %s: %w", digest, err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress archived page %s: %w", digest, err)
+	}
+	defer zr.Close()
+	html, err := io.ReadAll(zr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read archived page %s: %w", digest, err)
+	}
+	return string(html), nil
+}
+
+// Entries returns every manifest entry in the order the pages were archived
+func (a *Archive) Entries() ([]Entry, error) {
+	f, err := os.Open(filepath.Join(a.dir, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive manifest: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse archive manifest line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read archive manifest: %w", err)
+	}
+	return entries, nil
+}
+
+// Latest returns the most recent entry of every archived URL, in the order the URLs were
+// first archived. Entries are limited to store and runID when they are not empty.
+func (a *Archive) Latest(store, runID string) ([]Entry, error) {
+	entries, err := a.Entries()
+	if err != nil {
+		return nil, err
+	}
+	store = normalizeStore(store)
+
+	index := make(map[string]int)
+	var latest []Entry
+	for _, entry := range entries {
+		if (store != "" && normalizeStore(entry.Store) != store) || (runID != "" && entry.RunID != runID) {
+			continue
+		}
+		if i, ok := index[entry.URL]; ok {
+			latest[i] = entry
+			continue
+		}
+		index[entry.URL] = len(latest)
+		latest = append(latest, entry)
+	}
+	return latest, nil
+}
+
+// normalizeStore returns the canonical store of a domain, see types.CanonicalStore
+func normalizeStore(store string) string {
+	return types.CanonicalStore(store)
+}
+
+// Replay serves archived pages in place of fetching them. It implements
+// types.PageSource.
+type Replay struct {
+	archive *Archive
+	digests map[string]string
+}
+
+// NewReplay serves the pages of the given entries
+func NewReplay(a *Archive, entries []Entry) *Replay {
+	digests := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		digests[entry.URL] = entry.Digest
+	}
+	return &Replay{archive: a, digests: digests}
+}
+
+// PageContent returns the archived HTML of a page
+func (r *Replay) PageContent(ctx context.Context, pageURL string) (string, error) {
+	digest, ok := r.digests[pageURL]
+	if !ok {
+		return "", fmt.Errorf("%s is not in the archive", pageURL)
+	}
+	return r.archive.Get(digest)
+}
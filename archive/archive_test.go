@@ -0,0 +1,83 @@
+package archive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchive_PutGet(t *testing.T) {
+	a, err := Open(t.TempDir())
+	require.NoError(t, err)
+
+	html := "<html><table class=\"size-chart\"></table></html>"
+	first, err := a.Put("run-1", "westside.com", "https://www.westside.com/products/dress", html)
+	require.NoError(t, err)
+	second, err := a.Put("run-2", "westside.com", "https://www.westside.com/products/dress", html)
+	require.NoError(t, err)
+	assert.Equal(t, first.Digest, second.Digest, "identical pages share one object")
+
+	objects, err := filepath.Glob(filepath.Join(a.Dir(), "objects", "*", "*.html.gz"))
+	require.NoError(t, err)
+	assert.Len(t, objects, 1)
+
+	got, err := a.Get(first.Digest)
+	require.NoError(t, err)
+	assert.Equal(t, html, got)
+
+	entries, err := a.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "run-1", entries[0].RunID)
+	assert.Equal(t, "run-2", entries[1].RunID)
+
+	_, err = a.Get("../manifest")
+	assert.Error(t, err)
+}
+
+func TestArchive_EmptyManifest(t *testing.T) {
+	dir := t.TempDir()
+	a, err := Open(filepath.Join(dir, "new"))
+	require.NoError(t, err)
+	entries, err := a.Entries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = os.Stat(filepath.Join(dir, "new", "objects"))
+	assert.NoError(t, err)
+}
+
+func TestArchive_LatestAndReplay(t *testing.T) {
+	a, err := Open(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = a.Put("run-1", "westside.com", "https://www.westside.com/products/a", "a1")
+	require.NoError(t, err)
+	_, err = a.Put("run-1", "suqah.com", "https://suqah.com/products/b", "b1")
+	require.NoError(t, err)
+	_, err = a.Put("run-2", "westside.com", "https://www.westside.com/products/a", "a2")
+	require.NoError(t, err)
+
+	latest, err := a.Latest("www.westside.com", "")
+	require.NoError(t, err)
+	require.Len(t, latest, 1)
+	assert.Equal(t, "run-2", latest[0].RunID)
+
+	replay := NewReplay(a, latest)
+	html, err := replay.PageContent(context.Background(), "https://www.westside.com/products/a")
+	require.NoError(t, err)
+	assert.Equal(t, "a2", html)
+	_, err = replay.PageContent(context.Background(), "https://suqah.com/products/b")
+	assert.Error(t, err, "pages outside the replayed entries are not served")
+
+	latest, err = a.Latest("westside.com", "run-1")
+	require.NoError(t, err)
+	require.Len(t, latest, 1)
+	html, err = NewReplay(a, latest).PageContent(context.Background(), "https://www.westside.com/products/a")
+	require.NoError(t, err)
+	assert.Equal(t, "a1", html)
+}
@@ -0,0 +1,152 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// purgeGrace keeps objects written this recently, whose manifest entry a concurrent Put
+// may not have appended yet
+const purgeGrace = time.Minute
+
+// PurgeResult counts what a purge removed
+type PurgeResult struct {
+	Entries int   `json:"entries"` // Manifest entries or WARC files removed
+	Files   int   `json:"files"`   // Files deleted from disk
+	Bytes   int64 `json:"bytes"`   // Disk space freed
+}
+
+// Purge removes the manifest entries for which expired returns true, then deletes the
+// objects no remaining entry references. The manifest is rewritten through a temporary
+// file, so an interrupted purge leaves the archive readable.
+func (a *Archive) Purge(expired func(store string, fetchedAt time.Time) bool) (PurgeResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var result PurgeResult
+	started := time.Now()
+	entries, err := a.Entries()
+	if err != nil {
+		return result, err
+	}
+
+	var kept []Entry
+	referenced := make(map[string]bool)
+	for _, entry := range entries {
+		if expired(entry.Store, entry.FetchedAt) {
+			result.Entries++
+			continue
+		}
+		kept = append(kept, entry)
+		referenced[entry.Digest] = true
+	}
+	if result.Entries == 0 {
+		return result, nil
+	}
+	if err := a.writeManifest(kept); err != nil {
+		return result, err
+	}
+
+	err = filepath.WalkDir(filepath.Join(a.dir, "objects"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		digest := strings.TrimSuffix(d.Name(), ".html.gz")
+		if digest == d.Name() || referenced[digest] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.ModTime().After(started.Add(-purgeGrace)) {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		result.Files++
+		result.Bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to delete purged archive objects: %w", err)
+	}
+	return result, nil
+}
+
+// writeManifest replaces the manifest with the given entries; callers hold the lock
+func (a *Archive) writeManifest(entries []Entry) error {
+	tmp, err := os.CreateTemp(a.dir, ".manifest-*")
+	if err != nil {
+		return fmt.Errorf("failed to create archive manifest: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	encoder := json.NewEncoder(tmp)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to write archive manifest: %w", err)
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write archive manifest: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(a.dir, manifestFile)); err != nil {
+		return fmt.Errorf("failed to replace archive manifest: %w", err)
+	}
+	return nil
+}
+
+// PurgeWARC deletes the WARC files in dir for which expired returns true. Files are
+// named <store>-<unix nanos>.warc.gz as written with --warc-dir; the modification time
+// stands in for files named otherwise.
+func PurgeWARC(dir string, expired func(store string, recordedAt time.Time) bool) (PurgeResult, error) {
+	var result PurgeResult
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return result, fmt.Errorf("failed to read WARC directory: %w", err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".warc.gz") {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			return result, fmt.Errorf("failed to stat WARC file: %w", err)
+		}
+		store, recordedAt := warcFileOrigin(file.Name(), info.ModTime())
+		if !expired(store, recordedAt) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, file.Name())); err != nil {
+			return result, fmt.Errorf("failed to delete WARC file: %w", err)
+		}
+		result.Entries++
+		result.Files++
+		result.Bytes += info.Size()
+	}
+	return result, nil
+}
+
+// warcFileOrigin reads the store and recording time from a WARC file name
+func warcFileOrigin(name string, modTime time.Time) (string, time.Time) {
+	base := strings.TrimSuffix(name, ".warc.gz")
+	i := strings.LastIndex(base, "-")
+	if i < 0 {
+		return base, modTime
+	}
+	nanos, err := strconv.ParseInt(base[i+1:], 10, 64)
+	if err != nil {
+		return base, modTime
+	}
+	return base[:i], time.Unix(0, nanos)
+}
@@ -0,0 +1,74 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchive_Purge(t *testing.T) {
+	a, err := Open(t.TempDir())
+	require.NoError(t, err)
+
+	old, err := a.Put("run-1", "oldstore.com", "https://oldstore.com/products/a", "a")
+	require.NoError(t, err)
+	kept, err := a.Put("run-1", "westside.com", "https://www.westside.com/products/b", "b")
+	require.NoError(t, err)
+
+	// Objects past the grace period are deleted once no entry references them
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(a.objectPath(old.Digest), past, past))
+
+	result, err := a.Purge(func(store string, fetchedAt time.Time) bool { return store == "oldstore.com" })
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Entries)
+	assert.Equal(t, 1, result.Files)
+	assert.Positive(t, result.Bytes)
+
+	entries, err := a.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, kept.Digest, entries[0].Digest)
+
+	_, err = a.Get(old.Digest)
+	assert.Error(t, err)
+	_, err = a.Get(kept.Digest)
+	assert.NoError(t, err)
+}
+
+func TestPurgeWARC(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().AddDate(0, 0, -40)
+	for _, name := range []string{
+		"westside.com-" + formatNanos(old) + ".warc.gz",
+		"westside.com-" + formatNanos(time.Now()) + ".warc.gz",
+		"notes.txt",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644))
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -30)
+	result, err := PurgeWARC(dir, func(store string, recordedAt time.Time) bool {
+		assert.Equal(t, "westside.com", store)
+		return recordedAt.Before(cutoff)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Files)
+
+	remaining, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	assert.Len(t, remaining, 2)
+
+	result, err = PurgeWARC(filepath.Join(dir, "missing"), func(string, time.Time) bool { return true })
+	require.NoError(t, err)
+	assert.Zero(t, result.Files)
+}
+
+func formatNanos(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
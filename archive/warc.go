@@ -0,0 +1,253 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// warcVersion is the WARC format version written by WARCWriter
+const warcVersion = "WARC/1.1"
+
+// WARCRecord is one record of a WARC file
+type WARCRecord struct {
+	Type      string // WARC-Type, e.g. "resource" or "warcinfo"
+	TargetURI string
+	Date      time.Time
+	Header    textproto.MIMEHeader
+	Content   []byte
+}
+
+// WARCWriter writes fetched pages as a WARC/1.1 file of gzip-compressed "resource"
+// records, readable by standard web-archiving tools. It is safe for concurrent use.
+type WARCWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	infoID string
+}
+
+// NewWARCWriter starts a WARC file on w with a warcinfo record describing the crawl
+func NewWARCWriter(w io.Writer, info map[string]string) (*WARCWriter, error) {
+	ww := &WARCWriter{w: w, infoID: newRecordID()}
+
+	var fields bytes.Buffer
+	fields.WriteString("software: shopify-extractor\r\nformat: WARC File Format 1.1\r\n")
+	keys := make([]string, 0, len(info))
+	for key := range info {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&fields, "%s: %s\r\n", key, info[key])
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("WARC-Type", "warcinfo")
+	header.Set("WARC-Record-ID", ww.infoID)
+	header.Set("Content-Type", "application/warc-fields")
+	if err := ww.writeRecord(header, time.Now(), fields.Bytes()); err != nil {
+		return nil, err
+	}
+	return ww, nil
+}
+
+// CreateWARC creates a WARC file at path, creating its directory when needed
+func CreateWARC(path string, info map[string]string) (*WARCWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create WARC directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create WARC file: %w", err)
+	}
+	ww, err := NewWARCWriter(f, info)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	ww.closer = f
+	return ww, nil
+}
+
+// WriteResource records the HTML of a page fetched at the given time
+func (ww *WARCWriter) WriteResource(pageURL string, fetchedAt time.Time, html string) error {
+	sum := sha256.Sum256([]byte(html))
+	header := textproto.MIMEHeader{}
+	header.Set("WARC-Type", "resource")
+	header.Set("WARC-Record-ID", newRecordID())
+	header.Set("WARC-Warcinfo-ID", ww.infoID)
+	header.Set("WARC-Target-URI", pageURL)
+	header.Set("WARC-Payload-Digest", "sha256:"+hex.EncodeToString(sum[:]))
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	return ww.writeRecord(header, fetchedAt, []byte(html))
+}
+
+// headerOrder lists the WARC header fields in the order they are written
+var headerOrder = []string{"WARC-Type", "WARC-Record-ID", "WARC-Warcinfo-ID", "WARC-Date", "WARC-Target-URI", "WARC-Payload-Digest", "Content-Type"}
+
+// writeRecord writes one record as its own gzip member, so readers can seek to records
+func (ww *WARCWriter) writeRecord(header textproto.MIMEHeader, date time.Time, content []byte) error {
+	header.Set("WARC-Date", date.UTC().Format(time.RFC3339))
+
+	var record bytes.Buffer
+	zw := gzip.NewWriter(&record)
+	fmt.Fprintf(zw, "%s\r\n", warcVersion)
+	for _, key := range headerOrder {
+		if value := header.Get(key); value != "" {
+			fmt.Fprintf(zw, "%s: %s\r\n", key, value)
+		}
+	}
+	fmt.Fprintf(zw, "Content-Length: %d\r\n\r\n", len(content))
+	zw.Write(content)
+	zw.Write([]byte("\r\n\r\n"))
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress WARC record: %w", err)
+	}
+
+	ww.mu.Lock()
+	defer ww.mu.Unlock()
+	if _, err := ww.w.Write(record.Bytes()); err != nil {
+		return fmt.Errorf("failed to write WARC record: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file when the writer created it
+func (ww *WARCWriter) Close() error {
+	if ww.closer == nil {
+		return nil
+	}
+	return ww.closer.Close()
+}
+
+// ReadWARC reads every record of a WARC file, compressed per record or not at all
+func ReadWARC(r io.Reader) ([]WARCRecord, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress WARC file: %w", err)
+		}
+		defer zr.Close()
+		br = bufio.NewReader(zr)
+	}
+
+	var records []WARCRecord
+	tp := textproto.NewReader(br)
+	for {
+		version, err := tp.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read WARC record: %w", err)
+		}
+		if version == "" {
+			continue // blank lines between records
+		}
+		if !strings.HasPrefix(version, "WARC/") {
+			return nil, fmt.Errorf("malformed WARC record %d: unexpected line %q", len(records)+1, version)
+		}
+
+		header, err := tp.ReadMIMEHeader()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read WARC record %d header: %w", len(records)+1, err)
+		}
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("malformed WARC record %d: invalid Content-Length", len(records)+1)
+		}
+		content := make([]byte, length)
+		if _, err := io.ReadFull(br, content); err != nil {
+			return nil, fmt.Errorf("failed to read WARC record %d content: %w", len(records)+1, err)
+		}
+
+		record := WARCRecord{
+			Type:      header.Get("WARC-Type"),
+			TargetURI: header.Get("WARC-Target-URI"),
+			Header:    header,
+			Content:   content,
+		}
+		record.Date, _ = time.Parse(time.RFC3339, header.Get("WARC-Date"))
+		records = append(records, record)
+	}
+}
+
+// WARCReplay serves the pages of WARC files in place of fetching them. It implements
+// types.PageSource; a page recorded more than once is served from its latest record.
+type WARCReplay struct {
+	pages map[string][]byte
+}
+
+// NewWARCReplay loads the resource and response records of the given WARC files.
+// Response records have their HTTP status line and headers stripped.
+func NewWARCReplay(paths ...string) (*WARCReplay, error) {
+	replay := &WARCReplay{pages: make(map[string][]byte)}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open WARC file: %w", err)
+		}
+		records, err := ReadWARC(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		for _, record := range records {
+			switch record.Type {
+			case "resource":
+				replay.pages[record.TargetURI] = record.Content
+			case "response":
+				if end := bytes.Index(record.Content, []byte("\r\n\r\n")); end >= 0 {
+					replay.pages[record.TargetURI] = record.Content[end+4:]
+				}
+			}
+		}
+	}
+	return replay, nil
+}
+
+// URLs returns the recorded page URLs in sorted order
+func (r *WARCReplay) URLs() []string {
+	urls := make([]string, 0, len(r.pages))
+	for pageURL := range r.pages {
+		urls = append(urls, pageURL)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// PageContent returns the recorded HTML of a page
+func (r *WARCReplay) PageContent(ctx context.Context, pageURL string) (string, error) {
+	content, ok := r.pages[pageURL]
+	if !ok {
+		return "", fmt.Errorf("%s is not in the WARC files", pageURL)
+	}
+	return string(content), nil
+}
+
+// newRecordID returns a random urn:uuid record identifier
+func newRecordID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	h := hex.EncodeToString(id[:])
+	return fmt.Sprintf("<urn:uuid:%s-%s-%s-%s-%s>", h[0:8], h[8:12], h[12:16], h[16:20], h[20:])
+}
@@ -0,0 +1,66 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWARC_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWARCWriter(&buf, map[string]string{"run-id": "run-1"})
+	require.NoError(t, err)
+	fetchedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, w.WriteResource("https://www.westside.com/products/a", fetchedAt, "<html>a</html>"))
+	require.NoError(t, w.WriteResource("https://www.westside.com/products/b", fetchedAt, "<html>b\r\n\r\n</html>"))
+
+	records, err := ReadWARC(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "warcinfo", records[0].Type)
+	assert.Contains(t, string(records[0].Content), "run-id: run-1")
+	assert.Equal(t, "resource", records[2].Type)
+	assert.Equal(t, "https://www.westside.com/products/b", records[2].TargetURI)
+	assert.Equal(t, "<html>b\r\n\r\n</html>", string(records[2].Content))
+	assert.Equal(t, fetchedAt, records[2].Date)
+	assert.Equal(t, records[0].Header.Get("WARC-Record-ID"), records[1].Header.Get("WARC-Warcinfo-ID"))
+	assert.True(t, strings.HasPrefix(records[1].Header.Get("WARC-Payload-Digest"), "sha256:"))
+}
+
+func TestWARCReplay(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "crawl", "westside.warc.gz")
+	w, err := CreateWARC(path, nil)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteResource("https://www.westside.com/products/a", time.Now(), "old"))
+	require.NoError(t, w.WriteResource("https://www.westside.com/products/a", time.Now(), "new"))
+	require.NoError(t, w.Close())
+
+	// Uncompressed file from another tool, holding an HTTP response record
+	response := "HTTP/1.1 200 OK\r\nContent-Type: text/html\r\n\r\n<html>c</html>"
+	plain := "WARC/1.0\r\nWARC-Type: response\r\nWARC-Target-URI: https://suqah.com/products/c\r\n" +
+		"Content-Length: " + strconv.Itoa(len(response)) + "\r\n\r\n" + response + "\r\n\r\n"
+	plainPath := filepath.Join(dir, "suqah.warc")
+	require.NoError(t, os.WriteFile(plainPath, []byte(plain), 0o644))
+
+	replay, err := NewWARCReplay(path, plainPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://suqah.com/products/c", "https://www.westside.com/products/a"}, replay.URLs())
+
+	html, err := replay.PageContent(context.Background(), "https://www.westside.com/products/a")
+	require.NoError(t, err)
+	assert.Equal(t, "new", html)
+	html, err = replay.PageContent(context.Background(), "https://suqah.com/products/c")
+	require.NoError(t, err)
+	assert.Equal(t, "<html>c</html>", html)
+	_, err = replay.PageContent(context.Background(), "https://suqah.com/products/missing")
+	assert.Error(t, err)
+}
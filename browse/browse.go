@@ -0,0 +1,224 @@
+// Package browse implements a line-oriented terminal UI for exploring extraction
+// results: stores, then their products, then each product's rendered size charts.
+package browse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"shopify-extractor/internal/types"
+	"shopify-extractor/render"
+)
+
+// DefaultPageSize is the number of products listed per page
+const DefaultPageSize = 20
+
+// Browser walks a user through extraction results, reading one command per line
+type Browser struct {
+	result   *types.ExtractionResult
+	in       *bufio.Scanner
+	out      io.Writer
+	pageSize int
+
+	store   int // selected store, -1 while the store list is shown
+	product int // selected product (index into the filtered list), -1 while products are listed
+	page    int
+	query   string // case-insensitive substring of product title or URL
+	size    string // size label that one of the product's charts must contain
+}
+
+// New creates a browser over result that reads commands from in and draws to out
+func New(result *types.ExtractionResult, in io.Reader, out io.Writer) *Browser {
+	return &Browser{
+		result:   result,
+		in:       bufio.NewScanner(in),
+		out:      out,
+		pageSize: DefaultPageSize,
+		store:    -1,
+		product:  -1,
+	}
+}
+
+// SetPageSize sets how many products are listed per page
+func (b *Browser) SetPageSize(n int) {
+	if n > 0 {
+		b.pageSize = n
+	}
+}
+
+// Run shows the store list and processes commands until the user quits or input ends
+func (b *Browser) Run() error {
+	for {
+		b.draw()
+		fmt.Fprint(b.out, "> ")
+		if !b.in.Scan() {
+			fmt.Fprintln(b.out)
+			return b.in.Err()
+		}
+		if !b.handle(strings.TrimSpace(b.in.Text())) {
+			return nil
+		}
+	}
+}
+
+// handle applies a command and reports whether browsing continues
+func (b *Browser) handle(command string) bool {
+	switch {
+	case command == "q" || command == "quit":
+		return false
+	case command == "b" || command == "back":
+		b.back()
+	case command == "n" && b.listingProducts():
+		if (b.page+1)*b.pageSize < len(b.filtered()) {
+			b.page++
+		}
+	case command == "p" && b.listingProducts():
+		if b.page > 0 {
+			b.page--
+		}
+	case strings.HasPrefix(command, "/") && b.store >= 0:
+		b.query = strings.TrimSpace(command[1:])
+		b.product, b.page = -1, 0
+	case (command == "size" || strings.HasPrefix(command, "size ")) && b.store >= 0:
+		b.size = strings.TrimSpace(strings.TrimPrefix(command, "size"))
+		b.product, b.page = -1, 0
+	default:
+		if n, err := strconv.Atoi(command); err == nil {
+			b.open(n - 1)
+		} else if command != "" {
+			fmt.Fprintf(b.out, "Unknown command %q\n", command)
+		}
+	}
+	return true
+}
+
+func (b *Browser) listingProducts() bool {
+	return b.store >= 0 && b.product < 0
+}
+
+// back returns to the previous screen, clearing filters when leaving a store
+func (b *Browser) back() {
+	switch {
+	case b.product >= 0:
+		b.product = -1
+	case b.store >= 0:
+		b.store, b.page, b.query, b.size = -1, 0, "", ""
+	}
+}
+
+// open selects the store or product numbered index on the current screen
+func (b *Browser) open(index int) {
+	switch {
+	case b.store < 0:
+		if index >= 0 && index < len(b.result.Stores) {
+			b.store = index
+		}
+	case b.product < 0:
+		if index >= 0 && index < len(b.filtered()) {
+			b.product = index
+		}
+	}
+}
+
+// filtered returns the selected store's products that match the search and size filter
+func (b *Browser) filtered() []types.Product {
+	var products []types.Product
+	for _, product := range b.result.Stores[b.store].Products {
+		if b.matches(product) {
+			products = append(products, product)
+		}
+	}
+	return products
+}
+
+func (b *Browser) matches(product types.Product) bool {
+	if b.query != "" {
+		query := strings.ToLower(b.query)
+		if !strings.Contains(strings.ToLower(product.ProductTitle), query) && !strings.Contains(strings.ToLower(product.ProductURL), query) {
+			return false
+		}
+	}
+	if b.size == "" {
+		return true
+	}
+	for _, chart := range product.SizeCharts {
+		for _, row := range chart.Rows {
+			if strings.EqualFold(strings.TrimSpace(row["Size"]), b.size) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// draw renders the current screen
+func (b *Browser) draw() {
+	switch {
+	case b.store < 0:
+		b.drawStores()
+	case b.product < 0:
+		b.drawProducts()
+	default:
+		b.drawProduct()
+	}
+}
+
+func (b *Browser) drawStores() {
+	fmt.Fprintf(b.out, "\nStores (%d)\n", len(b.result.Stores))
+	for i, store := range b.result.Stores {
+		line := fmt.Sprintf("%3d) %s - %d products", i+1, store.StoreName, len(store.Products))
+		if store.Coverage != nil {
+			line += fmt.Sprintf(", %.1f%% coverage", store.Coverage.Percent)
+		}
+		if store.Error != "" {
+			line += " (error: " + store.Error + ")"
+		}
+		fmt.Fprintln(b.out, line)
+	}
+	fmt.Fprintln(b.out, "number: open store · q: quit")
+}
+
+func (b *Browser) drawProducts() {
+	store := b.result.Stores[b.store]
+	products := b.filtered()
+
+	header := fmt.Sprintf("\n%s - %d of %d products", store.StoreName, len(products), len(store.Products))
+	if b.query != "" {
+		header += fmt.Sprintf(" [search: %q]", b.query)
+	}
+	if b.size != "" {
+		header += fmt.Sprintf(" [size: %s]", b.size)
+	}
+	fmt.Fprintln(b.out, header)
+
+	start := b.page * b.pageSize
+	end := start + b.pageSize
+	if end > len(products) {
+		end = len(products)
+	}
+	for i := start; i < end; i++ {
+		fmt.Fprintf(b.out, "%3d) %s (%d charts)\n", i+1, products[i].ProductTitle, len(products[i].SizeCharts))
+	}
+	if pages := (len(products) + b.pageSize - 1) / b.pageSize; pages > 1 {
+		fmt.Fprintf(b.out, "page %d/%d\n", b.page+1, pages)
+	}
+	fmt.Fprintln(b.out, "number: open product · /text: search · size M: filter by size · n/p: page · b: back · q: quit")
+}
+
+func (b *Browser) drawProduct() {
+	product := b.filtered()[b.product]
+	fmt.Fprintf(b.out, "\n%s\n%s\n", product.ProductTitle, product.ProductURL)
+	for _, chart := range product.SizeCharts {
+		fmt.Fprintln(b.out)
+		RenderChart(b.out, chart)
+	}
+	fmt.Fprintln(b.out, "b: back · q: quit")
+}
+
+// RenderChart writes a size chart as an aligned text table, see render.ASCII
+func RenderChart(w io.Writer, chart *types.SizeChart) {
+	render.ASCII(w, chart)
+}
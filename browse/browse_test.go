@@ -0,0 +1,60 @@
+package browse
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func testResult() *types.ExtractionResult {
+	chart := func(sizes ...string) []*types.SizeChart {
+		rows := make([]map[string]string, 0, len(sizes))
+		for _, size := range sizes {
+			rows = append(rows, map[string]string{"Size": size, "Bust (in)": "34"})
+		}
+		return []*types.SizeChart{{Name: "Body Measurements", Unit: types.UnitInches, Headers: []string{"Size", "Bust (in)"}, Rows: rows}}
+	}
+	return &types.ExtractionResult{Stores: []types.StoreResult{{
+		StoreName: "westside.com",
+		Products: []types.Product{
+			{ProductTitle: "Linen Dress", ProductURL: "https://www.westside.com/products/linen-dress", SizeCharts: chart("S", "M")},
+			{ProductTitle: "Cotton Top", ProductURL: "https://www.westside.com/products/cotton-top", SizeCharts: chart("XL")},
+		},
+	}}}
+}
+
+func TestBrowser_SearchAndOpenProduct(t *testing.T) {
+	var out bytes.Buffer
+	err := New(testResult(), strings.NewReader("1\n/top\n1\nq\n"), &out).Run()
+	require.NoError(t, err)
+
+	screen := out.String()
+	assert.Contains(t, screen, "westside.com - 2 of 2 products")
+	assert.Contains(t, screen, `westside.com - 1 of 2 products [search: "top"]`)
+	assert.Contains(t, screen, "Cotton Top\nhttps://www.westside.com/products/cotton-top")
+	assert.Contains(t, screen, "XL   | 34")
+}
+
+func TestBrowser_SizeFilter(t *testing.T) {
+	browser := New(testResult(), strings.NewReader(""), &bytes.Buffer{})
+	browser.handle("1")
+	browser.handle("size m")
+
+	products := browser.filtered()
+	require.Len(t, products, 1)
+	assert.Equal(t, "Linen Dress", products[0].ProductTitle)
+
+	browser.handle("b")
+	assert.Equal(t, -1, browser.store)
+	assert.Empty(t, browser.size)
+}
+
+func TestRenderChart(t *testing.T) {
+	var out bytes.Buffer
+	RenderChart(&out, testResult().Stores[0].Products[0].SizeCharts[0])
+	assert.Equal(t, "Body Measurements (in)\nSize | Bust (in)\n-----+----------\nS    | 34\nM    | 34\n", out.String())
+}
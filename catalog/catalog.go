@@ -0,0 +1,528 @@
+// Package catalog indexes extracted products so they can be queried by store, size
+// label and body measurements after the extraction run that produced them.
+package catalog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"shopify-extractor/internal/types"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	productsBucket = []byte("products")
+	chartsBucket   = []byte("charts")
+	diffsBucket    = []byte("diffs")
+)
+
+// Lifecycle states of a catalog product, set by the last run of its store
+const (
+	StateNew     = "new"     // First extracted by the last run
+	StateActive  = "active"  // Extracted again with the same size charts
+	StateChanged = "changed" // Extracted again with different size charts
+	StateRemoved = "removed" // Missing from the last complete run of its store
+)
+
+// Product is an extracted product as kept in the catalog
+type Product struct {
+	ID        string `json:"id"`
+	StoreName string `json:"store_name"`
+	types.Product
+	ExtractedAt time.Time `json:"extracted_at"`
+
+	// Lifecycle across runs: the state set by the last run of the store, when the product
+	// was first extracted, when its size charts last changed and when it disappeared
+	State     string     `json:"state"`
+	FirstSeen time.Time  `json:"first_seen"`
+	ChangedAt time.Time  `json:"changed_at"`
+	RemovedAt *time.Time `json:"removed_at,omitempty"`
+
+	// seq orders products by first extraction
+	seq uint64
+}
+
+// storedProduct is the database record of a product. Size charts are stored once in
+// the charts bucket, keyed by fingerprint, and referenced from Charts.
+type storedProduct struct {
+	*Product
+	Seq    uint64   `json:"seq"`
+	Charts []string `json:"charts,omitempty"`
+}
+
+// StoreSummary describes one store in the catalog
+type StoreSummary struct {
+	StoreName string `json:"store_name"`
+	Products  int    `json:"products"`
+}
+
+// Index holds the latest extraction of every product, optionally persisted to a
+// bbolt database so the catalog survives restarts. Identical size charts shared by
+// several products are kept once, by fingerprint.
+type Index struct {
+	mu       sync.RWMutex
+	products map[string]*Product
+	ordered  []*Product
+	charts   map[string]*types.SizeChart
+	seq      uint64
+	db       *bolt.DB
+
+	// diffs keeps the lifecycle changes of the most recent runs, by run ID; persisted
+	// indexes also store every diff in the database
+	diffs     map[string]*Diff
+	diffOrder []string
+}
+
+// NewIndex creates an empty in-memory index
+func NewIndex() *Index {
+	return &Index{
+		products: make(map[string]*Product),
+		charts:   make(map[string]*types.SizeChart),
+		diffs:    make(map[string]*Diff),
+	}
+}
+
+// Open creates an index persisted to the bbolt database at path, loading the products
+// stored there
+func Open(path string) (*Index, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open catalog database: %w", err)
+	}
+
+	index := NewIndex()
+	index.db = db
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(productsBucket)
+		if err != nil {
+			return err
+		}
+		charts, err := tx.CreateBucketIfNotExists(chartsBucket)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists(diffsBucket); err != nil {
+			return err
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			stored := storedProduct{Product: &Product{}}
+			if err := json.Unmarshal(v, &stored); err != nil {
+				return fmt.Errorf("failed to decode product %s: %w", k, err)
+			}
+			for _, fingerprint := range stored.Charts {
+				chart, err := index.loadChart(charts, fingerprint)
+				if err != nil {
+					return fmt.Errorf("failed to load chart of product %s: %w", k, err)
+				}
+				stored.SizeCharts = append(stored.SizeCharts, chart)
+			}
+			// Records written before charts were normalized embed their charts
+			stored.SizeCharts = index.internCharts(stored.SizeCharts)
+			stored.Product.seq = stored.Seq
+			// Records written before lifecycle tracking start out active
+			if stored.State == "" {
+				stored.State = StateActive
+				stored.FirstSeen = stored.ExtractedAt
+				stored.ChangedAt = stored.ExtractedAt
+			}
+			index.products[stored.ID] = stored.Product
+			if stored.Seq > index.seq {
+				index.seq = stored.Seq
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load catalog: %w", err)
+	}
+
+	index.reorder()
+	return index, nil
+}
+
+// Close closes the underlying database, if any
+func (i *Index) Close() error {
+	if i.db == nil {
+		return nil
+	}
+	return i.db.Close()
+}
+
+// ProductID derives the stable identifier of a product from its URL
+func ProductID(productURL string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(productURL))))
+	return hex.EncodeToString(sum[:8])
+}
+
+// normalizeStore returns the canonical store of a domain, see types.CanonicalStore
+func normalizeStore(store string) string {
+	return types.CanonicalStore(store)
+}
+
+// Add indexes every product of an extraction result, replacing earlier extractions
+// of the same product, and records how the run changed each store's products (see
+// Diff). Products missing from a complete store result (no error, not Partial, discovery
+// not truncated) are marked removed; they stay in the catalog until purged.
+func (i *Index) Add(result *types.ExtractionResult, extractedAt time.Time) error {
+	_, err := i.add(result, extractedAt)
+	return err
+}
+
+// add implements Add and returns the run's diff
+func (i *Index) add(result *types.ExtractionResult, extractedAt time.Time) (*Diff, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	diff := &Diff{RunID: result.RunID, CreatedAt: extractedAt, Stores: []StoreDiff{}}
+	var added []*Product
+	for _, store := range result.Stores {
+		storeName := normalizeStore(store.StoreName)
+		storeDiff := StoreDiff{
+			StoreName: storeName,
+			New:       []string{},
+			Changed:   []string{},
+			Removed:   []string{},
+			Complete:  completeStoreResult(store),
+		}
+		seen := make(map[string]bool)
+		for _, missing := range store.MissingCharts {
+			for _, product := range missing {
+				seen[ProductID(product.ProductURL)] = true
+			}
+		}
+
+		for _, extracted := range store.Products {
+			id := ProductID(extracted.ProductURL)
+			seen[id] = true
+			product := &Product{
+				ID:          id,
+				StoreName:   storeName,
+				Product:     extracted,
+				ExtractedAt: extractedAt,
+				State:       StateNew,
+				FirstSeen:   extractedAt,
+				ChangedAt:   extractedAt,
+			}
+			product.SizeCharts = i.internCharts(extracted.SizeCharts)
+			existing, ok := i.products[id]
+			switch {
+			case !ok:
+				i.seq++
+				product.seq = i.seq
+			case existing.State == StateRemoved:
+				product.seq, product.FirstSeen = existing.seq, existing.FirstSeen
+			case sameCharts(existing.SizeCharts, product.SizeCharts):
+				product.seq, product.FirstSeen, product.ChangedAt = existing.seq, existing.FirstSeen, existing.ChangedAt
+				product.State = StateActive
+			default:
+				product.seq, product.FirstSeen = existing.seq, existing.FirstSeen
+				product.State = StateChanged
+			}
+			switch product.State {
+			case StateNew:
+				storeDiff.New = append(storeDiff.New, product.ProductURL)
+			case StateChanged:
+				storeDiff.Changed = append(storeDiff.Changed, product.ProductURL)
+			default:
+				storeDiff.Active++
+			}
+			i.products[id] = product
+			added = append(added, product)
+		}
+
+		if storeDiff.Complete {
+			for id, existing := range i.products {
+				if existing.StoreName != storeName || seen[id] || existing.State == StateRemoved {
+					continue
+				}
+				removed := *existing
+				removed.State = StateRemoved
+				removedAt := extractedAt
+				removed.RemovedAt = &removedAt
+				i.products[id] = &removed
+				added = append(added, &removed)
+				storeDiff.Removed = append(storeDiff.Removed, removed.ProductURL)
+			}
+			sort.Strings(storeDiff.Removed)
+		}
+		diff.Stores = append(diff.Stores, storeDiff)
+	}
+	i.reorder()
+	i.keepDiff(diff)
+
+	if i.db == nil {
+		return diff, nil
+	}
+	return diff, i.db.Update(func(tx *bolt.Tx) error {
+		if diff.RunID != "" {
+			data, err := json.Marshal(diff)
+			if err != nil {
+				return fmt.Errorf("failed to encode diff of run %s: %w", diff.RunID, err)
+			}
+			if err := tx.Bucket(diffsBucket).Put([]byte(diff.RunID), data); err != nil {
+				return fmt.Errorf("failed to store diff of run %s: %w", diff.RunID, err)
+			}
+		}
+
+		bucket := tx.Bucket(productsBucket)
+		charts := tx.Bucket(chartsBucket)
+		for _, product := range added {
+			record := storedProduct{Seq: product.seq}
+			for _, chart := range product.SizeCharts {
+				if err := storeChart(charts, chart); err != nil {
+					return err
+				}
+				record.Charts = append(record.Charts, chart.Fingerprint)
+			}
+			withoutCharts := *product
+			withoutCharts.SizeCharts = nil
+			record.Product = &withoutCharts
+
+			data, err := json.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("failed to encode product %s: %w", product.ID, err)
+			}
+			if err := bucket.Put([]byte(product.ID), data); err != nil {
+				return fmt.Errorf("failed to store product %s: %w", product.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Purge removes the products for which expired returns true, along with the size charts
+// no remaining product uses, and returns how many products were removed
+func (i *Index) Purge(expired func(store string, extractedAt time.Time) bool) (int, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var removed []string
+	for id, product := range i.products {
+		if expired(product.StoreName, product.ExtractedAt) {
+			removed = append(removed, id)
+			delete(i.products, id)
+		}
+	}
+	if len(removed) == 0 {
+		return 0, nil
+	}
+	i.reorder()
+
+	used := make(map[string]bool)
+	for _, product := range i.ordered {
+		for _, chart := range product.SizeCharts {
+			used[chart.Fingerprint] = true
+		}
+	}
+	var unused []string
+	for fingerprint := range i.charts {
+		if !used[fingerprint] {
+			unused = append(unused, fingerprint)
+			delete(i.charts, fingerprint)
+		}
+	}
+
+	if i.db == nil {
+		return len(removed), nil
+	}
+	err := i.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(productsBucket)
+		for _, id := range removed {
+			if err := bucket.Delete([]byte(id)); err != nil {
+				return fmt.Errorf("failed to delete product %s: %w", id, err)
+			}
+		}
+		charts := tx.Bucket(chartsBucket)
+		for _, fingerprint := range unused {
+			if err := charts.Delete([]byte(fingerprint)); err != nil {
+				return fmt.Errorf("failed to delete chart %s: %w", fingerprint, err)
+			}
+		}
+		return nil
+	})
+	return len(removed), err
+}
+
+// internCharts returns the charts with their fingerprint set, each replaced by the chart
+// already indexed with the same fingerprint; callers hold the write lock. The charts
+// passed in are not modified.
+func (i *Index) internCharts(charts []*types.SizeChart) []*types.SizeChart {
+	if len(charts) == 0 {
+		return charts
+	}
+	interned := make([]*types.SizeChart, 0, len(charts))
+	for _, chart := range charts {
+		if chart == nil {
+			continue
+		}
+		fingerprint := chart.Fingerprint
+		if fingerprint == "" {
+			fingerprint = chart.ComputeFingerprint()
+		}
+		existing, ok := i.charts[fingerprint]
+		if !ok {
+			copied := *chart
+			copied.Fingerprint = fingerprint
+			existing = &copied
+			i.charts[fingerprint] = existing
+		}
+		interned = append(interned, existing)
+	}
+	return interned
+}
+
+// loadChart reads a chart from the charts bucket, reusing one already loaded
+func (i *Index) loadChart(bucket *bolt.Bucket, fingerprint string) (*types.SizeChart, error) {
+	if chart, ok := i.charts[fingerprint]; ok {
+		return chart, nil
+	}
+	data := bucket.Get([]byte(fingerprint))
+	if data == nil {
+		return nil, fmt.Errorf("chart %s not found", fingerprint)
+	}
+	chart := &types.SizeChart{}
+	if err := json.Unmarshal(data, chart); err != nil {
+		return nil, fmt.Errorf("failed to decode chart %s: %w", fingerprint, err)
+	}
+	chart.Fingerprint = fingerprint
+	i.charts[fingerprint] = chart
+	return chart, nil
+}
+
+// storeChart writes a chart to the charts bucket unless it is already stored
+func storeChart(bucket *bolt.Bucket, chart *types.SizeChart) error {
+	key := []byte(chart.Fingerprint)
+	if bucket.Get(key) != nil {
+		return nil
+	}
+	data, err := json.Marshal(chart)
+	if err != nil {
+		return fmt.Errorf("failed to encode chart %s: %w", chart.Fingerprint, err)
+	}
+	if err := bucket.Put(key, data); err != nil {
+		return fmt.Errorf("failed to store chart %s: %w", chart.Fingerprint, err)
+	}
+	return nil
+}
+
+// Charts returns the number of distinct size charts used by the catalog's products
+func (i *Index) Charts() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	used := make(map[string]bool)
+	for _, product := range i.ordered {
+		for _, chart := range product.SizeCharts {
+			used[chart.Fingerprint] = true
+		}
+	}
+	return len(used)
+}
+
+// reorder rebuilds the ordered product list; callers hold the write lock
+func (i *Index) reorder() {
+	i.ordered = i.ordered[:0]
+	for _, product := range i.products {
+		i.ordered = append(i.ordered, product)
+	}
+	sort.Slice(i.ordered, func(a, b int) bool { return i.ordered[a].seq < i.ordered[b].seq })
+}
+
+// Get returns a product by ID
+func (i *Index) Get(id string) (*Product, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	product, ok := i.products[id]
+	return product, ok
+}
+
+// Stores summarises the stores in the catalog in alphabetical order
+func (i *Index) Stores() []StoreSummary {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, product := range i.ordered {
+		counts[product.StoreName]++
+	}
+	stores := make([]StoreSummary, 0, len(counts))
+	for store, count := range counts {
+		stores = append(stores, StoreSummary{StoreName: store, Products: count})
+	}
+	sort.Slice(stores, func(a, b int) bool { return stores[a].StoreName < stores[b].StoreName })
+	return stores
+}
+
+// Query returns one page of the products matching q, in first-extraction order
+func (i *Index) Query(q Query) Page {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	page := Page{Products: []*Product{}}
+	for _, product := range i.ordered {
+		if !q.Matches(product) {
+			continue
+		}
+		if page.Total >= q.Offset && len(page.Products) < limit {
+			page.Products = append(page.Products, product)
+		}
+		page.Total++
+	}
+	if next := q.Offset + len(page.Products); next < page.Total {
+		page.NextOffset = next
+		page.HasMore = true
+	}
+	return page
+}
+
+// Products returns a snapshot of every product of a store (all stores when store is
+// empty) in first-extraction order
+func (i *Index) Products(store string) []*Product {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	store = normalizeStore(store)
+	products := make([]*Product, 0, len(i.ordered))
+	for _, product := range i.ordered {
+		if store == "" || product.StoreName == store {
+			products = append(products, product)
+		}
+	}
+	return products
+}
+
+// MeasurementTypes returns the distinct body measurements (e.g. "bust", "waist") found
+// in the size chart headers of a store's products, in sorted order. The size column and
+// unit suffixes such as "(in)" are dropped.
+func (i *Index) MeasurementTypes(store string) []string {
+	seen := make(map[string]bool)
+	for _, product := range i.Products(store) {
+		for _, chart := range product.SizeCharts {
+			for _, header := range chart.Headers {
+				if name := measurementName(header); name != "" {
+					seen[name] = true
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
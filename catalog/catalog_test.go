@@ -0,0 +1,225 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func testResult() *types.ExtractionResult {
+	return &types.ExtractionResult{Stores: []types.StoreResult{
+		{StoreName: "westside.com", Products: []types.Product{{
+			ProductTitle: "Linen Dress",
+			ProductURL:   "https://www.westside.com/products/linen-dress",
+			SizeCharts: []*types.SizeChart{{
+				Unit:    types.UnitInches,
+				Headers: []string{"Size", "Bust (in)"},
+				Rows: []map[string]string{
+					{"Size": "S", "Bust (in)": "34"},
+					{"Size": "M", "Bust (in)": "36-37"},
+				},
+			}},
+		}}},
+		{StoreName: "www.suqah.com", Products: []types.Product{{
+			ProductTitle: "Kurta",
+			ProductURL:   "https://suqah.com/products/kurta",
+			SizeCharts: []*types.SizeChart{{
+				Unit:    types.UnitCentimeters,
+				Headers: []string{"Size", "Bust"},
+				Rows:    []map[string]string{{"Size": "M", "Bust": "92"}},
+			}},
+		}}},
+	}}
+}
+
+func TestIndex_Query(t *testing.T) {
+	index := NewIndex()
+	require.NoError(t, index.Add(testResult(), time.Now()))
+
+	page := index.Query(Query{Store: "suqah.com"})
+	require.Equal(t, 1, page.Total)
+	assert.Equal(t, "Kurta", page.Products[0].ProductTitle)
+
+	page = index.Query(Query{Size: "m"})
+	assert.Equal(t, 2, page.Total)
+	assert.Equal(t, 1, index.Query(Query{Size: "Small"}).Total)
+
+	minBust, ok, err := ParseMeasurementFilter("min_bust_in", "36")
+	require.NoError(t, err)
+	require.True(t, ok)
+	page = index.Query(Query{Size: "M", Measurements: []MeasurementFilter{minBust}})
+	require.Equal(t, 1, page.Total)
+	assert.Equal(t, "Linen Dress", page.Products[0].ProductTitle)
+
+	// The bound must hold on the same row as the size
+	page = index.Query(Query{Size: "S", Measurements: []MeasurementFilter{minBust}})
+	assert.Equal(t, 0, page.Total)
+
+	maxBust, _, _ := ParseMeasurementFilter("max_bust_cm", "90")
+	assert.Equal(t, 0, index.Query(Query{Measurements: []MeasurementFilter{maxBust}}).Total)
+}
+
+func TestIndex_Paging(t *testing.T) {
+	index := NewIndex()
+	require.NoError(t, index.Add(testResult(), time.Now()))
+
+	page := index.Query(Query{Limit: 1})
+	require.Len(t, page.Products, 1)
+	assert.True(t, page.HasMore)
+
+	offset, err := DecodeCursor(EncodeCursor(page.NextOffset))
+	require.NoError(t, err)
+	page = index.Query(Query{Limit: 1, Offset: offset})
+	require.Len(t, page.Products, 1)
+	assert.Equal(t, "Kurta", page.Products[0].ProductTitle)
+	assert.False(t, page.HasMore)
+
+	_, err = DecodeCursor("not-a-cursor")
+	assert.Error(t, err)
+}
+
+func TestOpen_PersistsProducts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.db")
+	index, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, index.Add(testResult(), time.Now()))
+	require.NoError(t, index.Close())
+
+	index, err = Open(path)
+	require.NoError(t, err)
+	defer index.Close()
+
+	assert.Equal(t, []StoreSummary{{StoreName: "suqah.com", Products: 1}, {StoreName: "westside.com", Products: 1}}, index.Stores())
+	product, ok := index.Get(ProductID("https://suqah.com/products/kurta"))
+	require.True(t, ok)
+	assert.Equal(t, "Kurta", product.ProductTitle)
+	assert.Equal(t, "Linen Dress", index.Query(Query{}).Products[0].ProductTitle)
+}
+
+func TestIndex_Purge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.db")
+	index, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, index.Add(testResult(), time.Now()))
+	assert.Equal(t, 2, index.Charts())
+
+	removed, err := index.Purge(func(store string, extractedAt time.Time) bool { return store == "suqah.com" })
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, 1, index.Charts())
+	require.NoError(t, index.Close())
+
+	index, err = Open(path)
+	require.NoError(t, err)
+	defer index.Close()
+	assert.Equal(t, []StoreSummary{{StoreName: "westside.com", Products: 1}}, index.Stores())
+	assert.Len(t, index.charts, 1, "unused charts are deleted from the database")
+}
+
+func TestSchema_ProductsQuery(t *testing.T) {
+	index := NewIndex()
+	require.NoError(t, index.Add(testResult(), time.Now()))
+	schema, err := NewSchema(index)
+	require.NoError(t, err)
+
+	result := graphql.Do(graphql.Params{
+		Schema: schema,
+		RequestString: `{
+			products(size: "M", filters: [{measurement: "bust", unit: "in", min: 36}], first: 1) {
+				totalCount
+				pageInfo { hasNextPage endCursor }
+				edges { node { storeName title charts { unit rows { size cells { header value } } } } }
+			}
+			store(domain: "www.suqah.com") { name productCount products { totalCount } }
+		}`,
+	})
+	require.Empty(t, result.Errors)
+
+	data, err := json.Marshal(result.Data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"products": {
+			"totalCount": 1,
+			"pageInfo": {"hasNextPage": false, "endCursor": "`+EncodeCursor(1)+`"},
+			"edges": [{"node": {"storeName": "westside.com", "title": "Linen Dress", "charts": [{"unit": "in", "rows": [
+				{"size": "S", "cells": [{"header": "Size", "value": "S"}, {"header": "Bust (in)", "value": "34"}]},
+				{"size": "M", "cells": [{"header": "Size", "value": "M"}, {"header": "Bust (in)", "value": "36-37"}]}
+			]}]}}]
+		},
+		"store": {"name": "suqah.com", "productCount": 1, "products": {"totalCount": 1}}
+	}`, string(data))
+}
+
+func TestExport(t *testing.T) {
+	index := NewIndex()
+	require.NoError(t, index.Add(testResult(), time.Now()))
+
+	var out bytes.Buffer
+	require.NoError(t, Export(&out, FormatCSV, index.Products("westside.com")))
+	assert.Equal(t, strings.Join([]string{
+		"store_name,product_id,product_title,product_url,chart_name,unit,size,measurement,value",
+		"westside.com," + ProductID("https://www.westside.com/products/linen-dress") + ",Linen Dress,https://www.westside.com/products/linen-dress,,in,S,Bust (in),34",
+		"westside.com," + ProductID("https://www.westside.com/products/linen-dress") + ",Linen Dress,https://www.westside.com/products/linen-dress,,in,M,Bust (in),36-37",
+	}, "\n")+"\n", out.String())
+
+	out.Reset()
+	require.NoError(t, Export(&out, FormatJSON, index.Products("")))
+	var products []map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &products))
+	assert.Len(t, products, 2)
+
+	assert.Error(t, Export(&out, "parquet", nil))
+}
+
+func TestIndex_SharesIdenticalCharts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.db")
+	index, err := Open(path)
+	require.NoError(t, err)
+
+	chart := func(size string) *types.SizeChart {
+		return &types.SizeChart{
+			Name:    "Size Guide",
+			Unit:    types.UnitInches,
+			Headers: []string{"Size", "Bust"},
+			Rows:    []map[string]string{{"Size": size, "Bust": "34"}},
+		}
+	}
+	shared := chart("S")
+	// Whitespace and case differences do not change the fingerprint
+	spaced := chart(" s ")
+	spaced.Name = "Other name"
+	assert.Equal(t, shared.ComputeFingerprint(), spaced.ComputeFingerprint())
+	assert.NotEqual(t, shared.ComputeFingerprint(), chart("M").ComputeFingerprint())
+
+	require.NoError(t, index.Add(&types.ExtractionResult{Stores: []types.StoreResult{{
+		StoreName: "westside.com",
+		Products: []types.Product{
+			{ProductTitle: "Top", ProductURL: "https://www.westside.com/products/top", SizeCharts: []*types.SizeChart{shared}},
+			{ProductTitle: "Shirt", ProductURL: "https://www.westside.com/products/shirt", SizeCharts: []*types.SizeChart{spaced}},
+		},
+	}}}, time.Now()))
+	assert.Empty(t, shared.Fingerprint, "charts passed to Add are not modified")
+	assert.Equal(t, 1, index.Charts())
+	require.NoError(t, index.Close())
+
+	index, err = Open(path)
+	require.NoError(t, err)
+	defer index.Close()
+
+	top, ok := index.Get(ProductID("https://www.westside.com/products/top"))
+	require.True(t, ok)
+	shirt, ok := index.Get(ProductID("https://www.westside.com/products/shirt"))
+	require.True(t, ok)
+	require.Len(t, top.SizeCharts, 1)
+	assert.Same(t, top.SizeCharts[0], shirt.SizeCharts[0])
+	assert.Equal(t, shared.ComputeFingerprint(), top.SizeCharts[0].Fingerprint)
+	assert.Equal(t, "Size Guide", top.SizeCharts[0].Name)
+}
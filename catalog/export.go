@@ -0,0 +1,72 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Export formats supported by Export
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// ExportFormats lists the supported export formats
+func ExportFormats() []string {
+	return []string{FormatJSON, FormatCSV}
+}
+
+// csvHeader is the column layout of CSV exports: one line per chart cell, so charts with
+// different measurements share one flat table
+var csvHeader = []string{"store_name", "product_id", "product_title", "product_url", "chart_name", "unit", "size", "measurement", "value"}
+
+// Export writes products in the given format. JSON exports are a single array of
+// products; CSV exports have one line per size chart cell.
+func Export(w io.Writer, format string, products []*Product) error {
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(products); err != nil {
+			return fmt.Errorf("failed to write JSON export: %w", err)
+		}
+		return nil
+	case FormatCSV:
+		return exportCSV(w, products)
+	default:
+		return fmt.Errorf("unsupported export format %q (available: %s)", format, strings.Join(ExportFormats(), ", "))
+	}
+}
+
+func exportCSV(w io.Writer, products []*Product) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write CSV export: %w", err)
+	}
+
+	for _, product := range products {
+		for _, chart := range product.SizeCharts {
+			for _, row := range chart.Rows {
+				for _, header := range chart.Headers {
+					value, ok := row[header]
+					if !ok || header == "Size" {
+						continue
+					}
+					record := []string{product.StoreName, product.ID, product.ProductTitle, product.ProductURL, chart.Name, chart.Unit, row["Size"], header, value}
+					if err := writer.Write(record); err != nil {
+						return fmt.Errorf("failed to write CSV export: %w", err)
+					}
+				}
+			}
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV export: %w", err)
+	}
+	return nil
+}
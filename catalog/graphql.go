@@ -0,0 +1,292 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+
+	"shopify-extractor/internal/types"
+)
+
+// NewSchema builds the GraphQL schema over the catalog: stores, products, size charts
+// and measurement filters, with Relay-style cursor pagination
+func NewSchema(index *Index) (graphql.Schema, error) {
+	cellType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "SizeChartCell",
+		Fields: graphql.Fields{
+			"header": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"value":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	rowType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "SizeChartRow",
+		Fields: graphql.Fields{
+			"size": &graphql.Field{Type: graphql.String},
+			"cells": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(cellType))),
+			},
+		},
+	})
+
+	chartType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "SizeChart",
+		Fields: graphql.Fields{
+			"name":        &graphql.Field{Type: graphql.String},
+			"unit":        &graphql.Field{Type: graphql.String},
+			"source":      &graphql.Field{Type: graphql.String},
+			"fingerprint": &graphql.Field{Type: graphql.String},
+			"headers":     &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+			"rows": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(rowType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return chartRows(p.Source.(*types.SizeChart)), nil
+				},
+			},
+		},
+	})
+
+	productType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Product",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"storeName": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"title": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*Product).ProductTitle, nil },
+			},
+			"url": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*Product).ProductURL, nil },
+			},
+			"extractedAt": &graphql.Field{Type: graphql.DateTime},
+			"state": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*Product).State, nil },
+			},
+			"charts": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(chartType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*Product).SizeCharts, nil },
+			},
+		},
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	edgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProductEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: graphql.NewNonNull(productType)},
+		},
+	})
+
+	connectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProductConnection",
+		Fields: graphql.Fields{
+			"edges":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edgeType)))},
+			"pageInfo":   &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+			"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	filterInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "MeasurementFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"measurement": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"unit":        &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"min":         &graphql.InputObjectFieldConfig{Type: graphql.Float},
+			"max":         &graphql.InputObjectFieldConfig{Type: graphql.Float},
+		},
+	})
+
+	productArgs := graphql.FieldConfigArgument{
+		"size":    &graphql.ArgumentConfig{Type: graphql.String},
+		"state":   &graphql.ArgumentConfig{Type: graphql.String},
+		"filters": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(filterInput))},
+		"first":   &graphql.ArgumentConfig{Type: graphql.Int},
+		"after":   &graphql.ArgumentConfig{Type: graphql.String},
+	}
+	storeProductArgs := graphql.FieldConfigArgument{}
+	for name, arg := range productArgs {
+		storeProductArgs[name] = arg
+	}
+	productArgs["store"] = &graphql.ArgumentConfig{Type: graphql.String}
+
+	storeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Store",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(StoreSummary).StoreName, nil },
+			},
+			"productCount": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(StoreSummary).Products, nil },
+			},
+			"products": &graphql.Field{
+				Type: graphql.NewNonNull(connectionType),
+				Args: storeProductArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return resolveProducts(index, p.Source.(StoreSummary).StoreName, p.Args)
+				},
+			},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"stores": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(storeType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return index.Stores(), nil
+				},
+			},
+			"store": &graphql.Field{
+				Type: storeType,
+				Args: graphql.FieldConfigArgument{
+					"domain": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					domain := normalizeStore(p.Args["domain"].(string))
+					for _, store := range index.Stores() {
+						if store.StoreName == domain {
+							return store, nil
+						}
+					}
+					return nil, nil
+				},
+			},
+			"products": &graphql.Field{
+				Type: graphql.NewNonNull(connectionType),
+				Args: productArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					store, _ := p.Args["store"].(string)
+					return resolveProducts(index, store, p.Args)
+				},
+			},
+			"product": &graphql.Field{
+				Type: productType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if product, ok := index.Get(p.Args["id"].(string)); ok {
+						return product, nil
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// chartCell and chartRow are the GraphQL shapes of a size chart row
+type chartCell struct {
+	Header string `json:"header"`
+	Value  string `json:"value"`
+}
+
+type chartRow struct {
+	Size  string      `json:"size"`
+	Cells []chartCell `json:"cells"`
+}
+
+// chartRows orders each row's cells by the chart headers, since GraphQL has no map type
+func chartRows(chart *types.SizeChart) []chartRow {
+	rows := make([]chartRow, 0, len(chart.Rows))
+	for _, row := range chart.Rows {
+		out := chartRow{Size: row["Size"], Cells: []chartCell{}}
+		for _, header := range chart.Headers {
+			if value, ok := row[header]; ok {
+				out.Cells = append(out.Cells, chartCell{Header: header, Value: value})
+			}
+		}
+		rows = append(rows, out)
+	}
+	return rows
+}
+
+// productEdge and productConnection are the GraphQL shapes of a page of products
+type productEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Product `json:"node"`
+}
+
+type pageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor"`
+}
+
+type productConnection struct {
+	Edges      []productEdge `json:"edges"`
+	PageInfo   pageInfo      `json:"pageInfo"`
+	TotalCount int           `json:"totalCount"`
+}
+
+// resolveProducts runs a catalog query from GraphQL arguments
+func resolveProducts(index *Index, store string, args map[string]interface{}) (interface{}, error) {
+	query := Query{Store: store}
+	query.Size, _ = args["size"].(string)
+	query.State, _ = args["state"].(string)
+
+	if first, ok := args["first"].(int); ok {
+		if first < 1 || first > MaxLimit {
+			return nil, fmt.Errorf("first must be between 1 and %d", MaxLimit)
+		}
+		query.Limit = first
+	}
+	if after, ok := args["after"].(string); ok && after != "" {
+		offset, err := DecodeCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		query.Offset = offset
+	}
+
+	filters, _ := args["filters"].([]interface{})
+	for _, raw := range filters {
+		input, _ := raw.(map[string]interface{})
+		measurement, _ := input["measurement"].(string)
+		unit, _ := input["unit"].(string)
+		unit = strings.ToLower(unit)
+		if unit != types.UnitInches && unit != types.UnitCentimeters {
+			return nil, fmt.Errorf("unit must be %q or %q", types.UnitInches, types.UnitCentimeters)
+		}
+		base := MeasurementFilter{Measurement: strings.ToLower(measurement), Unit: unit}
+		if min, ok := input["min"].(float64); ok {
+			filter := base
+			filter.Min, filter.Value = true, min
+			query.Measurements = append(query.Measurements, filter)
+		}
+		if max, ok := input["max"].(float64); ok {
+			filter := base
+			filter.Value = max
+			query.Measurements = append(query.Measurements, filter)
+		}
+	}
+
+	page := index.Query(query)
+	connection := productConnection{Edges: []productEdge{}, TotalCount: page.Total}
+	for i, product := range page.Products {
+		connection.Edges = append(connection.Edges, productEdge{
+			Cursor: EncodeCursor(query.Offset + i + 1),
+			Node:   product,
+		})
+	}
+	if n := len(connection.Edges); n > 0 {
+		connection.PageInfo.EndCursor = &connection.Edges[n-1].Cursor
+	}
+	connection.PageInfo.HasNextPage = page.HasMore
+	return connection, nil
+}
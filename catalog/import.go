@@ -0,0 +1,122 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"shopify-extractor/internal/types"
+)
+
+// ReadExport reads a catalog export written by Export back into an extraction result,
+// with one store result per store in the order the stores first appear. CSV exports
+// carry no chart metadata beyond name and unit, so their charts are rebuilt from the
+// cells with a "Size" column followed by the measurements in the order they appear.
+func ReadExport(r io.Reader, format string) (*types.ExtractionResult, error) {
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		var products []*Product
+		if err := json.NewDecoder(r).Decode(&products); err != nil {
+			return nil, fmt.Errorf("failed to read JSON export: %w", err)
+		}
+		result := &types.ExtractionResult{}
+		stores := make(map[string]int)
+		for _, product := range products {
+			index := storeResultIndex(result, stores, product.StoreName)
+			result.Stores[index].Products = append(result.Stores[index].Products, product.Product)
+		}
+		return result, nil
+	case FormatCSV:
+		return readCSVExport(r)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q (available: %s)", format, strings.Join(ExportFormats(), ", "))
+	}
+}
+
+// storeResultIndex returns the index of a store's result, appending it when missing
+func storeResultIndex(result *types.ExtractionResult, stores map[string]int, store string) int {
+	index, ok := stores[store]
+	if !ok {
+		index = len(result.Stores)
+		stores[store] = index
+		result.Stores = append(result.Stores, types.StoreResult{StoreName: store})
+	}
+	return index
+}
+
+// csvChart is a size chart being rebuilt from CSV export lines
+type csvChart struct {
+	chart        *types.SizeChart
+	rows         map[string]map[string]string // by size
+	measurements map[string]bool
+}
+
+func readCSVExport(r io.Reader) (*types.ExtractionResult, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV export: %w", err)
+	}
+	if strings.Join(header, ",") != strings.Join(csvHeader, ",") {
+		return nil, fmt.Errorf("failed to read CSV export: header must be %s", strings.Join(csvHeader, ","))
+	}
+
+	result := &types.ExtractionResult{}
+	stores := make(map[string]int)
+	products := make(map[string]*types.Product) // by store and product URL
+	charts := make(map[string]*csvChart)        // by store, product URL, chart name and unit
+	var order []string                          // product keys in order of appearance
+	var productStores []string                  // store of each key in order
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV export: %w", err)
+		}
+		store, title, productURL := record[0], record[2], record[3]
+		name, unit, size, measurement, value := record[4], record[5], record[6], record[7], record[8]
+
+		productKey := store + "\x00" + productURL
+		product, ok := products[productKey]
+		if !ok {
+			product = &types.Product{ProductTitle: title, ProductURL: productURL}
+			products[productKey] = product
+			order = append(order, productKey)
+			productStores = append(productStores, store)
+		}
+
+		chartKey := productKey + "\x00" + name + "\x00" + unit
+		c, ok := charts[chartKey]
+		if !ok {
+			c = &csvChart{
+				chart:        &types.SizeChart{Name: name, Unit: unit, Headers: []string{"Size"}},
+				rows:         make(map[string]map[string]string),
+				measurements: make(map[string]bool),
+			}
+			charts[chartKey] = c
+			product.SizeCharts = append(product.SizeCharts, c.chart)
+		}
+		row, ok := c.rows[size]
+		if !ok {
+			row = map[string]string{"Size": size}
+			c.rows[size] = row
+			c.chart.Rows = append(c.chart.Rows, row)
+		}
+		if !c.measurements[measurement] {
+			c.measurements[measurement] = true
+			c.chart.Headers = append(c.chart.Headers, measurement)
+		}
+		row[measurement] = value
+	}
+
+	for i, key := range order {
+		index := storeResultIndex(result, stores, productStores[i])
+		result.Stores[index].Products = append(result.Stores[index].Products, *products[key])
+	}
+	return result, nil
+}
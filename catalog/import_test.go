@@ -0,0 +1,41 @@
+package catalog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadExport(t *testing.T) {
+	index := NewIndex()
+	require.NoError(t, index.Add(testResult(), time.Now()))
+
+	for _, format := range ExportFormats() {
+		var out bytes.Buffer
+		require.NoError(t, Export(&out, format, index.Products("")))
+
+		result, err := ReadExport(&out, format)
+		require.NoError(t, err, format)
+		require.Len(t, result.Stores, 2, format)
+		assert.Equal(t, "westside.com", result.Stores[0].StoreName, format)
+		assert.Equal(t, "suqah.com", result.Stores[1].StoreName, format)
+
+		product := result.Stores[0].Products[0]
+		assert.Equal(t, "Linen Dress", product.ProductTitle, format)
+		require.Len(t, product.SizeCharts, 1, format)
+		assert.Equal(t, []string{"Size", "Bust (in)"}, product.SizeCharts[0].Headers, format)
+		assert.Equal(t, []map[string]string{
+			{"Size": "S", "Bust (in)": "34"},
+			{"Size": "M", "Bust (in)": "36-37"},
+		}, product.SizeCharts[0].Rows, format)
+	}
+
+	_, err := ReadExport(strings.NewReader("store,url\n"), FormatCSV)
+	assert.Error(t, err)
+	_, err = ReadExport(strings.NewReader("[]"), "parquet")
+	assert.Error(t, err)
+}
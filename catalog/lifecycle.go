@@ -0,0 +1,116 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"shopify-extractor/internal/types"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// maxDiffs bounds how many run diffs an index keeps in memory
+const maxDiffs = 50
+
+// StoreDiff lists how one run changed the products of a store. Products are identified
+// by URL.
+type StoreDiff struct {
+	StoreName string   `json:"store_name"`
+	New       []string `json:"new"`
+	Changed   []string `json:"changed"`
+	Removed   []string `json:"removed"`
+	Active    int      `json:"active"` // Products extracted again unchanged
+
+	// Complete is false when the run saw only part of the store (the store failed, was
+	// sampled or chunked, or its discovery was truncated), so no product was marked removed
+	Complete bool `json:"complete"`
+}
+
+// Diff is the lifecycle report of one run: what it added to, changed in and removed
+// from the catalog, per store
+type Diff struct {
+	RunID     string      `json:"run_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Stores    []StoreDiff `json:"stores"`
+}
+
+// States returns the lifecycle states a product can be in
+func States() []string {
+	return []string{StateNew, StateActive, StateChanged, StateRemoved}
+}
+
+// completeStoreResult reports whether a store result covers the whole catalog the
+// adapter can discover, so products missing from it are gone from the store
+func completeStoreResult(store types.StoreResult) bool {
+	return store.Error == "" && !store.Partial && !store.DiscoveryTruncated
+}
+
+// sameCharts reports whether two interned chart lists hold the same charts in any order
+func sameCharts(a, b []*types.SizeChart) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int, len(a))
+	for _, chart := range a {
+		counts[chart.Fingerprint]++
+	}
+	for _, chart := range b {
+		if counts[chart.Fingerprint] == 0 {
+			return false
+		}
+		counts[chart.Fingerprint]--
+	}
+	return true
+}
+
+// keepDiff remembers the diff of a run, evicting the oldest beyond maxDiffs; callers
+// hold the write lock
+func (i *Index) keepDiff(diff *Diff) {
+	if diff.RunID == "" {
+		return
+	}
+	if _, ok := i.diffs[diff.RunID]; !ok {
+		i.diffOrder = append(i.diffOrder, diff.RunID)
+	}
+	i.diffs[diff.RunID] = diff
+	for len(i.diffOrder) > maxDiffs {
+		delete(i.diffs, i.diffOrder[0])
+		i.diffOrder = i.diffOrder[1:]
+	}
+}
+
+// Diff returns the lifecycle report recorded when the run's result was added. Persisted
+// indexes keep the reports of every run, in-memory ones those of the latest runs.
+func (i *Index) Diff(runID string) (*Diff, error) {
+	i.mu.RLock()
+	diff, ok := i.diffs[runID]
+	db := i.db
+	i.mu.RUnlock()
+	if ok || db == nil {
+		return diff, nil
+	}
+
+	err := db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(diffsBucket).Get([]byte(runID))
+		if data == nil {
+			return nil
+		}
+		diff = &Diff{}
+		if err := json.Unmarshal(data, diff); err != nil {
+			return fmt.Errorf("failed to decode diff of run %s: %w", runID, err)
+		}
+		return nil
+	})
+	return diff, err
+}
+
+// Compare reports how the products of current changed from those of previous, as if
+// both results had been added to an empty catalog in order
+func Compare(previous, current *types.ExtractionResult) *Diff {
+	index := NewIndex()
+	now := time.Now().UTC()
+	index.add(previous, now)
+	diff, _ := index.add(current, now)
+	return diff
+}
@@ -0,0 +1,103 @@
+package catalog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func chart(bust string) []*types.SizeChart {
+	return []*types.SizeChart{{Headers: []string{"Size", "Bust"}, Rows: []map[string]string{{"Size": "M", "Bust": bust}}}}
+}
+
+func TestIndex_Lifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.db")
+	index, err := Open(path)
+	require.NoError(t, err)
+
+	first := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, index.Add(&types.ExtractionResult{RunID: "run-1", Stores: []types.StoreResult{
+		{StoreName: "westside.com", Products: []types.Product{
+			{ProductURL: "https://www.westside.com/products/dress", SizeCharts: chart("36")},
+			{ProductURL: "https://www.westside.com/products/kurta", SizeCharts: chart("38")},
+			{ProductURL: "https://www.westside.com/products/top", SizeCharts: chart("34")},
+		}},
+	}}, first))
+
+	second := first.AddDate(0, 0, 7)
+	require.NoError(t, index.Add(&types.ExtractionResult{RunID: "run-2", Stores: []types.StoreResult{
+		{StoreName: "westside.com", Products: []types.Product{
+			{ProductURL: "https://www.westside.com/products/dress", SizeCharts: chart("36")},
+			{ProductURL: "https://www.westside.com/products/kurta", SizeCharts: chart("39")},
+			{ProductURL: "https://www.westside.com/products/shirt", SizeCharts: chart("40")},
+		}},
+	}}, second))
+
+	diff, err := index.Diff("run-2")
+	require.NoError(t, err)
+	assert.Equal(t, []StoreDiff{{
+		StoreName: "westside.com",
+		New:       []string{"https://www.westside.com/products/shirt"},
+		Changed:   []string{"https://www.westside.com/products/kurta"},
+		Removed:   []string{"https://www.westside.com/products/top"},
+		Active:    1,
+		Complete:  true,
+	}}, diff.Stores)
+
+	dress, _ := index.Get(ProductID("https://www.westside.com/products/dress"))
+	assert.Equal(t, StateActive, dress.State)
+	assert.Equal(t, first, dress.FirstSeen)
+	assert.Equal(t, first, dress.ChangedAt)
+	kurta, _ := index.Get(ProductID("https://www.westside.com/products/kurta"))
+	assert.Equal(t, StateChanged, kurta.State)
+	assert.Equal(t, second, kurta.ChangedAt)
+
+	// A sampled run sees only part of the store, so nothing is removed
+	require.NoError(t, index.Add(&types.ExtractionResult{RunID: "run-3", Stores: []types.StoreResult{
+		{StoreName: "westside.com", Partial: true, Products: []types.Product{
+			{ProductURL: "https://www.westside.com/products/top", SizeCharts: chart("34")},
+		}},
+	}}, second.AddDate(0, 0, 7)))
+	require.NoError(t, index.Close())
+
+	index, err = Open(path)
+	require.NoError(t, err)
+	defer index.Close()
+
+	diff, err = index.Diff("run-3")
+	require.NoError(t, err)
+	assert.False(t, diff.Stores[0].Complete)
+	assert.Equal(t, []string{"https://www.westside.com/products/top"}, diff.Stores[0].New, "a removed product that returns is new again")
+	assert.Empty(t, diff.Stores[0].Removed)
+
+	top, _ := index.Get(ProductID("https://www.westside.com/products/top"))
+	assert.Equal(t, StateNew, top.State)
+	assert.Equal(t, first, top.FirstSeen)
+	assert.Nil(t, top.RemovedAt)
+	assert.Equal(t, 1, index.Query(Query{State: StateChanged}).Total)
+
+	missing, err := index.Diff("run-unknown")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestCompare(t *testing.T) {
+	previous := &types.ExtractionResult{Stores: []types.StoreResult{{StoreName: "suqah.com", Products: []types.Product{
+		{ProductURL: "https://suqah.com/products/a", SizeCharts: chart("36")},
+		{ProductURL: "https://suqah.com/products/b", SizeCharts: chart("38")},
+	}}}}
+	current := &types.ExtractionResult{Stores: []types.StoreResult{{StoreName: "suqah.com", Products: []types.Product{
+		{ProductURL: "https://suqah.com/products/a", SizeCharts: chart("36")},
+	}, MissingCharts: map[string][]types.MissingProduct{
+		types.MissingReasonFetchBlocked: {{ProductURL: "https://suqah.com/products/b"}},
+	}}}}
+
+	diff := Compare(previous, current)
+	require.Len(t, diff.Stores, 1)
+	assert.Equal(t, 1, diff.Stores[0].Active)
+	assert.Empty(t, diff.Stores[0].Removed, "products that failed to fetch are not removed")
+}
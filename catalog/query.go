@@ -0,0 +1,176 @@
+package catalog
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"shopify-extractor/internal/types"
+)
+
+// Page sizes accepted by Query
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// MeasurementFilter bounds one body measurement, e.g. bust in inches of at least 36
+type MeasurementFilter struct {
+	Measurement string  // e.g. "bust"
+	Unit        string  // types.UnitInches or types.UnitCentimeters
+	Min         bool    // true for a lower bound, false for an upper bound
+	Value       float64 // the bound
+}
+
+// Query selects products from the catalog. A product matches when one row of one of
+// its charts satisfies the size label and every measurement filter.
+type Query struct {
+	Store        string
+	State        string // lifecycle state, e.g. StateRemoved ("" = any)
+	Size         string
+	Measurements []MeasurementFilter
+	Offset       int
+	Limit        int
+}
+
+// Page is one page of query results
+type Page struct {
+	Products   []*Product
+	Total      int // products matching the query
+	NextOffset int // offset of the next page, valid when HasMore
+	HasMore    bool
+}
+
+// measurementFilterPattern matches filter parameters such as min_bust_in or max_waist_cm
+var measurementFilterPattern = regexp.MustCompile(`^(min|max)_([a-z_]+)_(in|cm)$`)
+
+// ParseMeasurementFilter parses a parameter such as min_bust_in=36. ok is false when
+// the key is not a measurement filter.
+func ParseMeasurementFilter(key, value string) (filter MeasurementFilter, ok bool, err error) {
+	match := measurementFilterPattern.FindStringSubmatch(strings.ToLower(key))
+	if match == nil {
+		return filter, false, nil
+	}
+	bound, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return filter, true, fmt.Errorf("%s must be a number", key)
+	}
+	return MeasurementFilter{
+		Measurement: strings.ReplaceAll(match[2], "_", " "),
+		Unit:        match[3],
+		Min:         match[1] == "min",
+		Value:       bound,
+	}, true, nil
+}
+
+// Matches reports whether the product satisfies the query's filters (ignoring paging)
+func (q Query) Matches(product *Product) bool {
+	if q.Store != "" && product.StoreName != normalizeStore(q.Store) {
+		return false
+	}
+	if q.State != "" && product.State != q.State {
+		return false
+	}
+	if q.Size == "" && len(q.Measurements) == 0 {
+		return true
+	}
+	for _, chart := range product.SizeCharts {
+		for _, row := range chart.Rows {
+			if q.rowMatches(chart, row) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sizeMatches compares sizes by their canonical label, so "XXL" matches "2XL" and
+// "Extra Small" matches "XS"
+func sizeMatches(size, want string) bool {
+	if strings.EqualFold(strings.TrimSpace(size), strings.TrimSpace(want)) {
+		return true
+	}
+	label := types.ParseSizeLabel(want)
+	return label.System != "" && types.ParseSizeLabel(size).Key() == label.Key()
+}
+
+func (q Query) rowMatches(chart *types.SizeChart, row map[string]string) bool {
+	if q.Size != "" && !sizeMatches(row[chart.SizeColumn()], q.Size) {
+		return false
+	}
+	for _, filter := range q.Measurements {
+		low, high, ok := measurementValue(chart, row, filter.Measurement, filter.Unit)
+		if !ok {
+			return false
+		}
+		// Ranges such as "34-36" match when any part of the range satisfies the bound
+		if filter.Min && high < filter.Value {
+			return false
+		}
+		if !filter.Min && low > filter.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// splitHeader lowercases a chart header and splits off its unit suffix, so "Bust (in)"
+// becomes "bust" and "in". unit is empty for bare headers.
+func splitHeader(header string) (name, unit string) {
+	name = strings.ToLower(strings.TrimSpace(header))
+	if open := strings.LastIndex(name, "("); open >= 0 && strings.HasSuffix(name, ")") {
+		unit = strings.TrimSpace(name[open+1 : len(name)-1])
+		name = strings.TrimSpace(name[:open])
+	}
+	return name, unit
+}
+
+// measurementName returns the measurement a chart header describes, or "" for the size
+// column
+func measurementName(header string) string {
+	name, _ := splitHeader(header)
+	if name == "" || strings.Contains(name, "size") {
+		return ""
+	}
+	return name
+}
+
+// measurementValue finds the measurement in a chart row, accepting headers with a unit
+// suffix ("Bust (in)") or bare headers in a single-unit chart, and returns the lowest
+// and highest number in the cell
+func measurementValue(chart *types.SizeChart, row map[string]string, measurement, unit string) (low, high float64, ok bool) {
+	for header, cell := range row {
+		name, headerUnit := splitHeader(header)
+		if headerUnit == "" {
+			headerUnit = chart.Unit
+		}
+		if headerUnit != unit || !strings.Contains(name, measurement) {
+			continue
+		}
+
+		if m, ok := types.ParseMeasurement(cell); ok {
+			return m.Min, m.Max, true
+		}
+	}
+	return 0, 0, false
+}
+
+// EncodeCursor turns a result offset into an opaque pagination cursor
+func EncodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+// DecodeCursor returns the offset encoded in a pagination cursor
+func DecodeCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(data), "offset:") {
+		return 0, fmt.Errorf("malformed cursor")
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(data), "offset:"))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("malformed cursor")
+	}
+	return offset, nil
+}
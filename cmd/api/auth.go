@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"shopify-extractor/secrets"
+)
+
+// apiKeyHeader carries the API key, as an alternative to "Authorization: Bearer <key>"
+const apiKeyHeader = "X-API-Key"
+
+// Role is the access level of an API key. Each role is granted everything the roles
+// below it are.
+type Role int
+
+const (
+	RolePublic   Role = iota // no key required
+	RoleReader               // query runs, the catalog, statistics and events
+	RoleOperator             // start extractions and exports
+	RoleAdmin                // administrative endpoints under /admin/
+)
+
+// roleNames are the names of the roles in API_KEYS
+var roleNames = map[string]Role{
+	"read":     RoleReader,
+	"operator": RoleOperator,
+	"admin":    RoleAdmin,
+}
+
+func (r Role) String() string {
+	for name, role := range roleNames {
+		if role == r {
+			return name
+		}
+	}
+	return "public"
+}
+
+// apiKey is a configured API key and the role it grants
+type apiKey struct {
+	id   string // keyID of key
+	role Role
+	key  []byte
+}
+
+// parseAPIKeys parses API_KEYS, a comma-separated list of role:key entries such as
+// "read:k1,admin:vault:secret/api#admin_key". Keys may be secret references, see the
+// secrets package.
+func parseAPIKeys(ctx context.Context, value string) ([]apiKey, error) {
+	var keys []apiKey
+	for _, entry := range splitList(value) {
+		name, key, ok := strings.Cut(entry, ":")
+		role, known := roleNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok || !known {
+			return nil, fmt.Errorf("invalid API key entry %q: want role:key with role read, operator or admin", name)
+		}
+		key, err := secrets.Resolve(ctx, strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s API key: %w", name, err)
+		}
+		if key == "" {
+			return nil, fmt.Errorf("empty %s API key", name)
+		}
+		keys = append(keys, apiKey{id: keyID([]byte(key)), role: role, key: []byte(key)})
+	}
+	return keys, nil
+}
+
+// policy is the role a route requires for each HTTP method; the "*" entry covers the
+// methods not listed
+type policy struct {
+	roles map[string]Role
+
+	// queryKey also accepts the key as ?api_key=, for EventSource clients that cannot
+	// send headers
+	queryKey bool
+}
+
+// Route policies, see Server.Start
+var (
+	readerPolicy   = policy{roles: map[string]Role{"*": RoleReader}}
+	operatorPolicy = policy{roles: map[string]Role{"*": RoleOperator}}
+	adminPolicy    = policy{roles: map[string]Role{"*": RoleAdmin}}
+	exportsPolicy  = policy{roles: map[string]Role{"GET": RoleReader, "*": RoleOperator}}
+	eventsPolicy   = policy{roles: map[string]Role{"*": RoleReader}, queryKey: true}
+)
+
+// required returns the role the policy requires for a method
+func (p policy) required(method string) Role {
+	if role, ok := p.roles[method]; ok {
+		return role
+	}
+	return p.roles["*"]
+}
+
+// lookupKey returns the configured API key matching key
+func (s *Server) lookupKey(key string) (apiKey, bool) {
+	var match apiKey
+	found := false
+	for _, k := range s.apiKeys {
+		// Compare against every key so the response time does not reveal a match
+		if subtle.ConstantTimeCompare(k.key, []byte(key)) == 1 {
+			match, found = k, true
+		}
+	}
+	return match, found
+}
+
+// withPolicy rejects requests whose API key does not grant the role the policy
+// requires. Authentication is disabled when no API keys are configured, and CORS
+// preflight requests are always let through.
+func (s *Server) withPolicy(p policy, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		required := p.required(r.Method)
+		if len(s.apiKeys) == 0 || required == RolePublic || r.Method == "OPTIONS" {
+			next(w, r)
+			return
+		}
+
+		key := requestAPIKey(r, p.queryKey)
+		match, ok := s.lookupKey(key)
+		if ok && match.role >= required {
+			next(w, withCaller(r, caller{key: match.id, role: match.role}))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		s.applyCORS(w, r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			message := "Invalid API key"
+			if key == "" {
+				message = "API key required"
+			}
+			s.sendError(w, message, http.StatusUnauthorized)
+			return
+		}
+		s.requestLogger(r).Warnf("Rejected %s %s: %s key %s, %s required", r.Method, r.URL.Path, match.role, match.id, required)
+		s.sendError(w, fmt.Sprintf("This endpoint requires the %s role", required), http.StatusForbidden)
+	}
+}
+
+// requestAPIKey returns the API key sent with a request
+func requestAPIKey(r *http.Request, queryKey bool) string {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return key
+	}
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	if queryKey {
+		return r.URL.Query().Get("api_key")
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveWithHeader runs a bodyless request through the handler with an optional
+// "Name: value" header
+func serveWithHeader(t *testing.T, handler http.HandlerFunc, method, path, header string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, path, nil)
+	if name, value, ok := strings.Cut(header, ": "); ok {
+		r.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	withRequestID(handler)(w, r)
+	return w
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	t.Setenv("ADMIN_KEY", "admin-secret")
+
+	keys, err := parseAPIKeys(context.Background(), "read:r1, operator:o1,admin:env:ADMIN_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, []apiKey{
+		{id: keyID([]byte("r1")), role: RoleReader, key: []byte("r1")},
+		{id: keyID([]byte("o1")), role: RoleOperator, key: []byte("o1")},
+		{id: keyID([]byte("admin-secret")), role: RoleAdmin, key: []byte("admin-secret")},
+	}, keys)
+
+	keys, err = parseAPIKeys(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	for _, value := range []string{"r1", "owner:k1", "read:", "admin:env:UNSET_ADMIN_KEY"} {
+		_, err := parseAPIKeys(context.Background(), value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestWithPolicy(t *testing.T) {
+	s := newTestServer(&fakeExtraction{})
+	s.apiKeys = []apiKey{
+		{role: RoleReader, key: []byte("reader")},
+		{role: RoleOperator, key: []byte("operator")},
+		{role: RoleAdmin, key: []byte("admin")},
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+
+	tests := []struct {
+		name   string
+		policy policy
+		method string
+		path   string
+		header string
+		status int
+	}{
+		{"missing key", readerPolicy, "GET", "/stores", "", http.StatusUnauthorized},
+		{"unknown key", readerPolicy, "GET", "/stores", "X-API-Key: nope", http.StatusUnauthorized},
+		{"reader reads", readerPolicy, "GET", "/stores", "X-API-Key: reader", http.StatusNoContent},
+		{"bearer token", readerPolicy, "GET", "/stores", "Authorization: Bearer reader", http.StatusNoContent},
+		{"reader cannot extract", operatorPolicy, "POST", "/extract", "X-API-Key: reader", http.StatusForbidden},
+		{"operator extracts", operatorPolicy, "POST", "/extract", "X-API-Key: operator", http.StatusNoContent},
+		{"admin extracts", operatorPolicy, "POST", "/extract", "X-API-Key: admin", http.StatusNoContent},
+		{"operator cannot purge", adminPolicy, "POST", "/admin/purge", "X-API-Key: operator", http.StatusForbidden},
+		{"reader downloads export", exportsPolicy, "GET", "/exports/x", "X-API-Key: reader", http.StatusNoContent},
+		{"reader cannot export", exportsPolicy, "POST", "/exports", "X-API-Key: reader", http.StatusForbidden},
+		{"preflight", adminPolicy, "OPTIONS", "/admin/purge", "", http.StatusNoContent},
+		{"query key on events", eventsPolicy, "GET", "/events?api_key=reader", "", http.StatusNoContent},
+		{"query key elsewhere", readerPolicy, "GET", "/stores?api_key=reader", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveWithHeader(t, s.withPolicy(tt.policy, ok), tt.method, tt.path, tt.header)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+
+	// Without API keys every route is open
+	s.apiKeys = nil
+	w := serveWithHeader(t, s.withPolicy(adminPolicy, ok), "POST", "/admin/purge", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"shopify-extractor/extractor"
+)
+
+// LastRun summarizes the most recent successful extraction of a store
+type LastRun struct {
+	RunID       string    `json:"run_id"`
+	CompletedAt time.Time `json:"completed_at"`
+	Products    int       `json:"products"`
+}
+
+// ChartYield reports how often the store's products yielded a size chart, over every
+// product extracted since the server started
+type ChartYield struct {
+	ProductsProcessed  int64   `json:"products_processed"`
+	ProductsWithCharts int64   `json:"products_with_charts"`
+	Rate               float64 `json:"rate"`               // products with charts / products processed
+	ChartsPerProduct   float64 `json:"charts_per_product"` // charts / products with charts
+}
+
+// StoreCapabilities is the response payload of GET /stores/{domain}/capabilities
+type StoreCapabilities struct {
+	Store string `json:"store"`
+	extractor.Capabilities
+
+	// MeasurementTypes lists the measurements found in the store's indexed charts
+	MeasurementTypes []string    `json:"measurement_types"`
+	LastRun          *LastRun    `json:"last_successful_run,omitempty"`
+	ChartYield       *ChartYield `json:"chart_yield,omitempty"`
+}
+
+// storeCapabilities reports the adapter capabilities and extraction history of a store
+func (s *Server) storeCapabilities(store string) (StoreCapabilities, bool) {
+	caps, ok := s.extraction.Capabilities(store)
+	if !ok {
+		return StoreCapabilities{}, false
+	}
+
+	report := StoreCapabilities{
+		Store:            store,
+		Capabilities:     caps,
+		MeasurementTypes: s.catalog.MeasurementTypes(store),
+	}
+	if run, result, ok := s.runs.lastSuccess(store); ok {
+		report.LastRun = &LastRun{RunID: run.ID, CompletedAt: run.CreatedAt, Products: len(result.Products)}
+	}
+	if snapshot := s.stats.Store(store); snapshot.ProductsProcessed > 0 {
+		yield := &ChartYield{
+			ProductsProcessed:  snapshot.ProductsProcessed,
+			ProductsWithCharts: snapshot.ProductsWithCharts,
+			Rate:               float64(snapshot.ProductsWithCharts) / float64(snapshot.ProductsProcessed),
+		}
+		if snapshot.ProductsWithCharts > 0 {
+			yield.ChartsPerProduct = float64(snapshot.ChartsExtracted) / float64(snapshot.ProductsWithCharts)
+		}
+		report.ChartYield = yield
+	}
+	return report, true
+}
+
+// sendCapabilities writes the capabilities of a store, or 404 for unsupported stores
+func (s *Server) sendCapabilities(w http.ResponseWriter, store string) {
+	report, ok := s.storeCapabilities(store)
+	if !ok {
+		s.sendError(w, "Store not supported", http.StatusNotFound)
+		return
+	}
+	s.sendData(w, report)
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+)
+
+const (
+	defaultChunkSize = 10
+	maxChunkSize     = 100
+
+	// discoveryTTL bounds how long discovered product URLs are reused between chunks
+	discoveryTTL = 30 * time.Minute
+)
+
+// ChunkedRequest represents the request body for POST /extract/chunked.
+// Either Cursor (from a previous response) or Offset selects where to continue.
+type ChunkedRequest struct {
+	Store  string `json:"store"`
+	Cursor string `json:"cursor,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+
+	// IncludeRaw adds the original table, with every column, to each filtered size chart
+	IncludeRaw bool `json:"include_raw,omitempty"`
+
+	// Optional product sampling, see types.Config. The cursor keeps the sample, so later
+	// chunks continue through the same products.
+	SampleRate  float64 `json:"sample_rate,omitempty"`
+	SampleCount int     `json:"sample_count,omitempty"`
+	Seed        int64   `json:"seed,omitempty"`
+}
+
+// ChunkedResult is one batch of products extracted from a store
+type ChunkedResult struct {
+	StoreName     string          `json:"store_name"`
+	Products      []types.Product `json:"products"`
+	Offset        int             `json:"offset"`
+	Processed     int             `json:"processed"`
+	TotalProducts int             `json:"total_products"`
+	NextCursor    string          `json:"next_cursor,omitempty"`
+	Done          bool            `json:"done"`
+}
+
+// ChunkedResponse represents the response of POST /extract/chunked
+type ChunkedResponse struct {
+	Success bool           `json:"success"`
+	Data    *ChunkedResult `json:"data,omitempty"`
+	Error   *APIError      `json:"error,omitempty"`
+}
+
+// discoverySnapshot caches the product URLs discovered for a store
+type discoverySnapshot struct {
+	id           string
+	urls         []string
+	discoveredAt time.Time
+}
+
+// chunkCursor is the decoded form of the opaque continuation cursor
+type chunkCursor struct {
+	Store    string `json:"store"`
+	Offset   int    `json:"offset"`
+	Snapshot string `json:"snapshot"`
+
+	SampleRate  float64 `json:"sample_rate,omitempty"`
+	SampleCount int     `json:"sample_count,omitempty"`
+	Seed        int64   `json:"seed,omitempty"`
+}
+
+func encodeCursor(c chunkCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (chunkCursor, error) {
+	var c chunkCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, fmt.Errorf("malformed cursor")
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("malformed cursor")
+	}
+	return c, nil
+}
+
+// productURLsFor returns the cached product URLs for a store, discovering them when
+// the cache is empty, expired, or does not match the snapshot the client started from
+func (s *Server) productURLsFor(ctx context.Context, logger types.Logger, store, snapshotID string) (*discoverySnapshot, error) {
+	s.discoveryMu.Lock()
+	snapshot, ok := s.discoveries[store]
+	s.discoveryMu.Unlock()
+
+	if ok && time.Since(snapshot.discoveredAt) < discoveryTTL && (snapshotID == "" || snapshotID == snapshot.id) {
+		return snapshot, nil
+	}
+	if snapshotID != "" && ok && snapshotID != snapshot.id {
+		logger.Warnf("Cursor snapshot %s for %s is no longer cached, rediscovering products", snapshotID, store)
+	}
+
+	config := s.currentConfig()
+	urls, err := s.extraction.DiscoverProductURLs(ctx, store, &config, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	snapshot = &discoverySnapshot{
+		id:           strconv.FormatInt(now.UnixNano(), 36),
+		urls:         urls,
+		discoveredAt: now,
+	}
+
+	s.discoveryMu.Lock()
+	s.discoveries[store] = snapshot
+	s.discoveryMu.Unlock()
+
+	return snapshot, nil
+}
+
+// handleExtractChunked extracts the next batch of products from a single store and
+// returns a continuation cursor, letting clients spread a large catalog over many requests
+func (s *Server) handleExtractChunked(w http.ResponseWriter, r *http.Request) {
+	// Set CORS headers
+	w.Header().Set("Content-Type", "application/json")
+	if s.applyCORS(w, r) {
+		return
+	}
+
+	if r.Method != "POST" {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ChunkedRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		s.sendRequestError(w, err)
+		return
+	}
+
+	// Clean the store name; "www.", aliases and *.myshopify.com domains name the same
+	// store, so every spelling shares its discovery cache and cursors
+	req.Store = types.CanonicalStore(req.Store)
+	if failures := validateChunkedRequest(&req); len(failures) > 0 {
+		s.sendValidationError(w, failures)
+		return
+	}
+	if req.Store != "" {
+		req.Store = utils.ResolveStore(r.Context(), req.Store)
+	}
+	offset, snapshotID := req.Offset, ""
+	sampleRate, sampleCount, seed := req.SampleRate, req.SampleCount, req.Seed
+	if req.Cursor != "" {
+		cursor, err := decodeCursor(req.Cursor)
+		if err != nil {
+			s.sendError(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		cursor.Store = types.CanonicalStore(cursor.Store)
+		if req.Store != "" && req.Store != cursor.Store {
+			s.sendError(w, "Cursor does not belong to the requested store", http.StatusBadRequest)
+			return
+		}
+		req.Store, offset, snapshotID = cursor.Store, cursor.Offset, cursor.Snapshot
+		sampleRate, sampleCount, seed = cursor.SampleRate, cursor.SampleCount, cursor.Seed
+	}
+	if (sampleRate > 0 || sampleCount > 0) && seed == 0 {
+		// Every chunk samples the same products from the cached discovery
+		seed = time.Now().UnixNano()
+	}
+
+	if offset < 0 {
+		s.sendError(w, "Invalid cursor", http.StatusBadRequest)
+		return
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultChunkSize
+	}
+
+	logger := s.requestLogger(r).WithField("store", req.Store)
+	if !s.extraction.Supports(req.Store) {
+		s.sendError(w, fmt.Sprintf("Unknown store: %s", req.Store), http.StatusBadRequest)
+		return
+	}
+	if !s.startJob(w, r) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.extractTimeout)
+	defer cancel()
+
+	snapshot, err := s.productURLsFor(ctx, logger, req.Store, snapshotID)
+	if err != nil {
+		logger.Warnf("Failed to discover products for %s: %v", req.Store, err)
+		s.sendError(w, fmt.Sprintf("Failed to discover products: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	productURLs := snapshot.urls
+	if sampleRate > 0 || sampleCount > 0 {
+		productURLs = utils.SampleURLs(productURLs, sampleRate, sampleCount, seed)
+		logger.Infof("Sampling enabled: extracting %d of %d discovered products", len(productURLs), len(snapshot.urls))
+	}
+
+	total := len(productURLs)
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	logger.Infof("Chunked extraction for %s: products %d-%d of %d", req.Store, offset, end, total)
+
+	result := &ChunkedResult{
+		StoreName:     req.Store,
+		Products:      []types.Product{},
+		Offset:        offset,
+		TotalProducts: total,
+	}
+
+	var batch []string
+	if offset < end {
+		batch = productURLs[offset:end]
+	}
+	config := s.currentConfig()
+	if req.IncludeRaw {
+		config.IncludeRaw = true
+	}
+	err = s.extraction.ExtractProducts(ctx, req.Store, batch, &config, logger, func(productURL string, product *types.Product, err error) {
+		result.Processed++
+		if err != nil {
+			logger.Warnf("Failed to extract data for %s: %v", productURL, err)
+			return
+		}
+		if len(product.SizeCharts) > 0 {
+			product.SetFingerprints()
+			product.SetSizeLabels()
+			product.SetMeasurements()
+			result.Products = append(result.Products, *product)
+		}
+	})
+	s.usage.addPages(requestCaller(r), result.Processed)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to extract products: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	chunk := &types.ExtractionResult{Stores: []types.StoreResult{{StoreName: req.Store, Products: result.Products, Partial: true}}}
+	if err := s.catalog.Add(chunk, time.Now()); err != nil {
+		logger.Errorf("Failed to index chunk of %s: %v", req.Store, err)
+	}
+
+	next := offset + result.Processed
+	if next >= total {
+		result.Done = true
+	} else {
+		result.NextCursor = encodeCursor(chunkCursor{
+			Store:       req.Store,
+			Offset:      next,
+			Snapshot:    snapshot.id,
+			SampleRate:  sampleRate,
+			SampleCount: sampleCount,
+			Seed:        seed,
+		})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(ChunkedResponse{Success: true, Data: result}); err != nil {
+		logger.Errorf("Failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CORSConfig controls the cross-origin headers sent by the API
+type CORSConfig struct {
+	AllowedOrigins   []string // "*" allows any origin
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int // Preflight cache duration in seconds, 0 to omit
+}
+
+// DefaultCORSConfig returns the permissive configuration used when nothing is configured
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", apiKeyHeader},
+	}
+}
+
+// LoadCORSConfig reads the CORS configuration from environment variables:
+// CORS_ALLOWED_ORIGINS, CORS_ALLOWED_METHODS, CORS_ALLOWED_HEADERS (comma-separated),
+// CORS_ALLOW_CREDENTIALS (bool) and CORS_MAX_AGE (seconds). Credentials are only
+// granted to listed origins, so combining them with "*" is an error.
+func LoadCORSConfig() (CORSConfig, error) {
+	config := DefaultCORSConfig()
+
+	if v := os.Getenv("CORS_ALLOWED_ORIGINS"); v != "" {
+		config.AllowedOrigins = splitList(v)
+	}
+	if v := os.Getenv("CORS_ALLOWED_METHODS"); v != "" {
+		config.AllowedMethods = splitList(strings.ToUpper(v))
+	}
+	if v := os.Getenv("CORS_ALLOWED_HEADERS"); v != "" {
+		config.AllowedHeaders = splitList(v)
+	}
+	if v := os.Getenv("CORS_ALLOW_CREDENTIALS"); v != "" {
+		if allow, err := strconv.ParseBool(v); err == nil {
+			config.AllowCredentials = allow
+		}
+	}
+	if v := os.Getenv("CORS_MAX_AGE"); v != "" {
+		if maxAge, err := strconv.Atoi(v); err == nil && maxAge > 0 {
+			config.MaxAge = maxAge
+		}
+	}
+
+	if config.AllowCredentials {
+		for _, origin := range config.AllowedOrigins {
+			if origin == "*" {
+				return CORSConfig{}, fmt.Errorf("invalid CORS_ALLOWED_ORIGINS: \"*\" cannot be combined with CORS_ALLOW_CREDENTIALS=true, list the allowed origins instead")
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for a request origin,
+// or an empty string when the origin is not allowed
+func (c CORSConfig) allowOrigin(origin string) string {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// applyCORS sets the CORS headers for a request. It returns true when the request
+// was a preflight request that has been fully answered.
+func (s *Server) applyCORS(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	allowed := s.cors.allowOrigin(origin)
+
+	if allowed != "" {
+		w.Header().Set("Access-Control-Allow-Origin", allowed)
+		if allowed != "*" {
+			w.Header().Add("Vary", "Origin")
+		}
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(s.cors.AllowedMethods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(s.cors.AllowedHeaders, ", "))
+		w.Header().Set("Access-Control-Expose-Headers", requestIDHeader)
+		// Only explicitly listed origins receive credentials
+		if s.cors.AllowCredentials && allowed != "*" {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		if s.cors.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(s.cors.MaxAge))
+		}
+	}
+
+	if r.Method != "OPTIONS" {
+		return false
+	}
+
+	// Handle preflight requests
+	if allowed == "" && origin != "" {
+		w.WriteHeader(http.StatusForbidden)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	return true
+}
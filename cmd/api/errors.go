@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes reported in APIError.Code
+const (
+	CodeInvalidRequest       = "invalid_request"        // malformed body, parameters or cursor
+	CodeValidationFailed     = "validation_failed"      // well-formed request with invalid fields
+	CodeUnsupportedMediaType = "unsupported_media_type" // body is not JSON
+	CodeRequestTooLarge      = "request_too_large"      // body exceeds maxRequestBodyBytes
+	CodeUnauthorized         = "unauthorized"           // missing or unknown API key
+	CodeForbidden            = "forbidden"              // the API key's role is not allowed
+	CodeNotFound             = "not_found"              // unknown route or resource
+	CodeMethodNotAllowed     = "method_not_allowed"     // route does not accept the method
+	CodeConflict             = "conflict"               // resource is not in the required state
+	CodeQuotaExceeded        = "quota_exceeded"         // the API key used up a daily quota
+	CodeExtractionFailed     = "extraction_failed"      // the store could not be crawled
+	CodeTimeout              = "timeout"                // extraction did not finish in time
+	CodeUnavailable          = "unavailable"            // the server cannot handle the request now
+	CodeInternal             = "internal_error"         // unexpected server failure
+)
+
+// APIError is the error object of every failed API response
+type APIError struct {
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	Details   []ValidationError `json:"details,omitempty"`
+	Retryable bool              `json:"retryable"`
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+// codeForStatus returns the default error code of an HTTP status
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMediaType
+	case http.StatusRequestEntityTooLarge:
+		return CodeRequestTooLarge
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeQuotaExceeded
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return CodeExtractionFailed
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	default:
+		return CodeInternal
+	}
+}
+
+// retryableStatus reports whether the same request may succeed when retried later
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// sendError sends an error response with the default code of the status
+func (s *Server) sendError(w http.ResponseWriter, message string, statusCode int) {
+	s.sendAPIError(w, statusCode, &APIError{
+		Code:      codeForStatus(statusCode),
+		Message:   message,
+		Retryable: retryableStatus(statusCode),
+	})
+}
+
+// sendAPIError sends a structured error response, filling in the request ID
+func (s *Server) sendAPIError(w http.ResponseWriter, statusCode int, apiErr *APIError) {
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = w.Header().Get(requestIDHeader)
+	}
+
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(APIResponse{Success: false, Error: apiErr}); err != nil {
+		s.logger.Errorf("Failed to encode error response: %v", err)
+	}
+}
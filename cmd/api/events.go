@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"shopify-extractor/internal/types"
+)
+
+// eventsInterval is how often GET /events sends a statistics snapshot
+const eventsInterval = time.Second
+
+// eventBuffer is how many events a slow subscriber may fall behind before events are
+// dropped for it
+const eventBuffer = 32
+
+// Event types sent on GET /events
+const (
+	EventStats = "stats" // stats.Stats snapshot, sent every eventsInterval
+	EventStore = "store" // StoreEvent, a store of a run finished
+	EventRun   = "run"   // RunEvent, a run finished
+
+	// RunProgress of every running extraction, sent every eventsInterval
+	EventProgress = "progress"
+)
+
+// StoreEvent reports a store finishing within an extraction run
+type StoreEvent struct {
+	RunID     string `json:"run_id"`
+	StoreName string `json:"store_name"`
+	Products  int    `json:"products"`
+	Error     string `json:"error,omitempty"`
+}
+
+// RunEvent reports an extraction run finishing
+type RunEvent struct {
+	RunID    string `json:"run_id"`
+	Stores   int    `json:"stores"`
+	Products int    `json:"products"`
+	Failed   bool   `json:"failed,omitempty"` // every store failed, the run was not stored
+}
+
+// countProducts returns the number of products over every store of a result
+func countProducts(result *types.ExtractionResult) int {
+	count := 0
+	for _, store := range result.Stores {
+		count += len(store.Products)
+	}
+	return count
+}
+
+// event is a server-sent event waiting to be written to subscribers
+type event struct {
+	name string
+	data []byte
+}
+
+// eventHub fans out extraction events to the GET /events subscribers. A nil *eventHub
+// ignores published events.
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan event]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subscribers: make(map[chan event]struct{})}
+}
+
+// subscribe returns a channel receiving every event published until unsubscribe
+func (h *eventHub) subscribe() chan event {
+	ch := make(chan event, eventBuffer)
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch
+}
+
+// unsubscribe stops delivering events to ch
+func (h *eventHub) unsubscribe(ch chan event) {
+	h.mu.Lock()
+	delete(h.subscribers, ch)
+	h.mu.Unlock()
+}
+
+// publish sends an event to every subscriber without blocking; subscribers whose
+// buffer is full miss it
+func (h *eventHub) publish(name string, payload interface{}) {
+	if h == nil {
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- event{name: name, data: data}:
+		default:
+		}
+	}
+}
+
+// handleEvents streams extraction progress as server-sent events: a statistics
+// snapshot and the progress of every running extraction every eventsInterval, and an
+// event whenever a store or run finishes
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.applyCORS(w, r) {
+		return
+	}
+	if r.Method != "GET" {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.sendError(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events := s.events.subscribe()
+	defer s.events.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(eventsInterval)
+	defer ticker.Stop()
+
+	send := func(name string, payload interface{}) {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return
+		}
+		writeEvent(w, event{name: name, data: data})
+	}
+
+	send(EventStats, s.stats.Snapshot())
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			send(EventStats, s.stats.Snapshot())
+			for _, status := range s.active.list() {
+				send(EventProgress, status)
+			}
+		case e := <-events:
+			writeEvent(w, e)
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes an event in the text/event-stream format
+func writeEvent(w http.ResponseWriter, e event) {
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.name, e.data)
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"shopify-extractor/catalog"
+)
+
+// Export job states
+const (
+	exportPending   = "pending"
+	exportCompleted = "completed"
+	exportFailed    = "failed"
+)
+
+// ExportRequest is the body of a POST /exports request
+type ExportRequest struct {
+	Format string `json:"format"`
+	Store  string `json:"store,omitempty"`
+}
+
+// ExportJob describes an export and, once completed, where to download it
+type ExportJob struct {
+	ID          string     `json:"id"`
+	Format      string     `json:"format"`
+	Store       string     `json:"store,omitempty"`
+	Status      string     `json:"status"`
+	Products    int        `json:"products"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	StatusURL   string     `json:"status_url"`
+	DownloadURL string     `json:"download_url,omitempty"`
+	Error       string     `json:"error,omitempty"`
+
+	path string
+}
+
+// exportStore generates exports in the background and keeps them in a directory
+type exportStore struct {
+	dir     string
+	baseURL string
+
+	mu   sync.RWMutex
+	jobs map[string]*ExportJob
+}
+
+// newExportStore creates an export store writing to EXPORT_DIR (a temporary directory
+// by default). When EXPORT_BASE_URL is set, download URLs point there instead of the API,
+// e.g. when EXPORT_DIR is synced to a bucket or CDN.
+func newExportStore() *exportStore {
+	dir := os.Getenv("EXPORT_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "shopify-extractor-exports")
+	}
+	return &exportStore{
+		dir:     dir,
+		baseURL: strings.TrimRight(os.Getenv("EXPORT_BASE_URL"), "/"),
+		jobs:    make(map[string]*ExportJob),
+	}
+}
+
+// get returns a copy of an export job
+func (es *exportStore) get(id string) (ExportJob, bool) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	job, ok := es.jobs[id]
+	if !ok {
+		return ExportJob{}, false
+	}
+	return *job, true
+}
+
+// purge deletes the export jobs for which expired returns true, along with their files,
+// and returns how many were removed. Pending jobs are kept.
+func (es *exportStore) purge(expired func(store string, at time.Time) bool) int {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	removed := 0
+	for id, job := range es.jobs {
+		if job.Status == exportPending || !expired(job.Store, job.CreatedAt) {
+			continue
+		}
+		if job.path != "" {
+			os.Remove(job.path)
+		}
+		delete(es.jobs, id)
+		removed++
+	}
+	return removed
+}
+
+// start registers a new export job and generates it in the background
+func (es *exportStore) start(index *catalog.Index, req ExportRequest) ExportJob {
+	job := &ExportJob{
+		ID:        newRunID(),
+		Format:    strings.ToLower(req.Format),
+		Store:     req.Store,
+		Status:    exportPending,
+		CreatedAt: time.Now(),
+	}
+	job.StatusURL = "/exports/" + job.ID
+
+	es.mu.Lock()
+	es.jobs[job.ID] = job
+	snapshot := *job
+	es.mu.Unlock()
+
+	go es.generate(index, job)
+	return snapshot
+}
+
+// generate writes the export file and records the outcome on the job
+func (es *exportStore) generate(index *catalog.Index, job *ExportJob) {
+	products := index.Products(job.Store)
+	name := fmt.Sprintf("export-%s.%s", job.ID, job.Format)
+	path, err := es.write(name, job.Format, products)
+
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	now := time.Now()
+	job.CompletedAt = &now
+	if err != nil {
+		job.Status = exportFailed
+		job.Error = err.Error()
+		return
+	}
+	job.Status = exportCompleted
+	job.Products = len(products)
+	job.path = path
+	if es.baseURL != "" {
+		job.DownloadURL = es.baseURL + "/" + name
+	} else {
+		job.DownloadURL = "/exports/" + job.ID + "/download"
+	}
+}
+
+// write exports products to a file in the export directory, renaming it into place so
+// a partially written export is never served
+func (es *exportStore) write(name, format string, products []*catalog.Product) (string, error) {
+	if err := os.MkdirAll(es.dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(es.dir, name+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := catalog.Export(tmp, format, products); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	path := filepath.Join(es.dir, name)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to store export file: %w", err)
+	}
+	return path, nil
+}
+
+// validateExportRequest checks a POST /exports body
+func validateExportRequest(req *ExportRequest) []ValidationError {
+	var failures []ValidationError
+	switch strings.ToLower(req.Format) {
+	case catalog.FormatJSON, catalog.FormatCSV:
+	case "":
+		failures = append(failures, ValidationError{Field: "format", Message: "is required"})
+	default:
+		failures = append(failures, ValidationError{
+			Field:   "format",
+			Message: fmt.Sprintf("must be one of: %s", strings.Join(catalog.ExportFormats(), ", ")),
+		})
+	}
+	if req.Store != "" {
+		if msg := validateStoreDomain(req.Store); msg != "" {
+			failures = append(failures, ValidationError{Field: "store", Message: msg})
+		}
+	}
+	return failures
+}
+
+// exportContentTypes maps export formats to their download content type
+var exportContentTypes = map[string]string{
+	catalog.FormatJSON: "application/json",
+	catalog.FormatCSV:  "text/csv",
+}
+
+// handleExports serves POST /exports, GET /exports/{id} and GET /exports/{id}/download
+func (s *Server) handleExports(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.applyCORS(w, r) {
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/exports"), "/"), "/")
+	if parts[0] == "" && len(parts) == 1 {
+		if r.Method != "POST" {
+			s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req ExportRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			s.sendRequestError(w, err)
+			return
+		}
+		if failures := validateExportRequest(&req); len(failures) > 0 {
+			s.sendValidationError(w, failures)
+			return
+		}
+		if !s.startJob(w, r) {
+			return
+		}
+
+		job := s.exports.start(s.catalog, req)
+		s.logger.Infof("Started %s export %s", job.Format, job.ID)
+		w.Header().Set("Location", job.StatusURL)
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(RunResponse{Success: true, Data: job}); err != nil {
+			s.logger.Errorf("Failed to encode response: %v", err)
+		}
+		return
+	}
+
+	if r.Method != "GET" {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if len(parts) > 2 || (len(parts) == 2 && parts[1] != "download") {
+		s.sendError(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	job, ok := s.exports.get(parts[0])
+	if !ok {
+		s.sendError(w, "Export not found", http.StatusNotFound)
+		return
+	}
+	if len(parts) == 1 {
+		s.sendData(w, job)
+		return
+	}
+	if job.Status != exportCompleted {
+		s.sendError(w, fmt.Sprintf("Export is %s", job.Status), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", exportContentTypes[job.Format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(job.path)))
+	http.ServeFile(w, r, job.path)
+}
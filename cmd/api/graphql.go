@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+)
+
+// GraphQLRequest is the body of a POST /graphql request
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// handleGraphQL serves GraphQL queries over the product catalog (GET ?query= or POST JSON)
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.applyCORS(w, r) {
+		return
+	}
+
+	var req GraphQLRequest
+	switch r.Method {
+	case "GET":
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				s.sendError(w, "Invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	case "POST":
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			s.sendRequestError(w, err)
+			return
+		}
+	default:
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if req.Query == "" {
+		s.sendError(w, "query is required", http.StatusBadRequest)
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         s.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        r.Context(),
+	})
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		s.logger.Errorf("Failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"shopify-extractor/internal/types"
+)
+
+// Extraction job states
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobCompleted = "completed"
+	jobFailed    = "failed"
+)
+
+const (
+	// defaultJobTTL is how long a finished job and its result are kept
+	defaultJobTTL = time.Hour
+
+	// defaultJobTimeout bounds one async extraction, which is not tied to an HTTP request
+	defaultJobTimeout = time.Hour
+
+	// defaultJobConcurrency is how many async extractions run at once; later jobs queue
+	defaultJobConcurrency = 2
+
+	// defaultJobQueue is how many async extractions may wait for a free slot
+	defaultJobQueue = 100
+
+	// jobQueueRetryAfter is the Retry-After sent while the job queue is full
+	jobQueueRetryAfter = 30 * time.Second
+)
+
+// errJobQueueFull is returned when no more async extractions can be queued
+var errJobQueueFull = errors.New("job queue is full")
+
+// ExtractJob describes an async extraction and, once completed, where to download its
+// result
+type ExtractJob struct {
+	ID          string       `json:"id"`
+	Status      string       `json:"status"`
+	Stores      []string     `json:"stores"`
+	CreatedAt   time.Time    `json:"created_at"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time   `json:"expires_at,omitempty"`
+	Progress    *RunProgress `json:"progress,omitempty"`
+	StatusURL   string       `json:"status_url"`
+	ResultURL   string       `json:"result_url,omitempty"`
+	Error       *APIError    `json:"error,omitempty"`
+
+	result        *types.ExtractionResult
+	schemaVersion int
+}
+
+// jobStore runs async extractions, at most a fixed number at once with a bounded queue,
+// and keeps finished jobs until their TTL expires
+type jobStore struct {
+	ttl     time.Duration
+	timeout time.Duration
+	slots   chan struct{}
+	queue   int
+
+	mu      sync.Mutex
+	jobs    map[string]*ExtractJob
+	pending int // jobs queued or running
+}
+
+// newJobStore creates a job store running concurrency jobs at once, each within timeout,
+// with up to queue more waiting, and keeping finished jobs for ttl
+func newJobStore(ttl, timeout time.Duration, concurrency, queue int) *jobStore {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if queue < 0 {
+		queue = 0
+	}
+	return &jobStore{
+		ttl:     ttl,
+		timeout: timeout,
+		slots:   make(chan struct{}, concurrency),
+		queue:   queue,
+		jobs:    make(map[string]*ExtractJob),
+	}
+}
+
+// loadJobStore creates the job store from JOB_TTL, JOB_TIMEOUT, JOB_CONCURRENCY and
+// JOB_QUEUE
+func loadJobStore() (*jobStore, error) {
+	ttl, timeout, concurrency, queue := defaultJobTTL, defaultJobTimeout, defaultJobConcurrency, defaultJobQueue
+	if value := os.Getenv("JOB_TTL"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid JOB_TTL %q: want a positive duration", value)
+		}
+		ttl = d
+	}
+	if value := os.Getenv("JOB_TIMEOUT"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid JOB_TIMEOUT %q: want a positive duration", value)
+		}
+		timeout = d
+	}
+	if value := os.Getenv("JOB_CONCURRENCY"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid JOB_CONCURRENCY %q: want a positive number", value)
+		}
+		concurrency = n
+	}
+	if value := os.Getenv("JOB_QUEUE"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid JOB_QUEUE %q: want a non-negative number", value)
+		}
+		queue = n
+	}
+	return newJobStore(ttl, timeout, concurrency, queue), nil
+}
+
+// expire removes the finished jobs whose TTL ended before now; the caller holds mu
+func (js *jobStore) expire(now time.Time) {
+	for id, job := range js.jobs {
+		if job.ExpiresAt != nil && now.After(*job.ExpiresAt) {
+			delete(js.jobs, id)
+		}
+	}
+}
+
+// get returns a copy of a job and its result, nil until the job completed
+func (js *jobStore) get(id string) (ExtractJob, *types.ExtractionResult, bool) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	js.expire(time.Now())
+	job, ok := js.jobs[id]
+	if !ok {
+		return ExtractJob{}, nil, false
+	}
+	return *job, job.result, true
+}
+
+// start registers a queued job for a prepared extraction and runs it with run once a
+// slot is free. It returns errJobQueueFull when every slot is busy and the queue is full.
+func (js *jobStore) start(e *extraction, run func(*extraction, time.Duration) (*types.ExtractionResult, *APIError, int)) (ExtractJob, error) {
+	job := &ExtractJob{
+		ID:        e.runID,
+		Status:    jobQueued,
+		Stores:    e.req.Stores,
+		CreatedAt: time.Now(),
+		StatusURL: "/jobs/" + e.runID,
+
+		schemaVersion: e.config.SchemaVersion,
+	}
+
+	js.mu.Lock()
+	if js.pending >= cap(js.slots)+js.queue {
+		js.mu.Unlock()
+		return ExtractJob{}, errJobQueueFull
+	}
+	js.pending++
+	js.expire(job.CreatedAt)
+	js.jobs[job.ID] = job
+	snapshot := *job
+	js.mu.Unlock()
+
+	go func() {
+		js.slots <- struct{}{}
+		defer func() { <-js.slots }()
+		js.setStatus(job, jobRunning)
+
+		result, apiErr, _ := run(e, js.timeout)
+		js.finish(job, result, apiErr)
+	}()
+	return snapshot, nil
+}
+
+// setStatus records a job's status
+func (js *jobStore) setStatus(job *ExtractJob, status string) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	job.Status = status
+}
+
+// finish records the outcome of a job and starts its TTL
+func (js *jobStore) finish(job *ExtractJob, result *types.ExtractionResult, apiErr *APIError) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if js.pending > 0 {
+		js.pending--
+	}
+	now := time.Now()
+	expires := now.Add(js.ttl)
+	job.CompletedAt = &now
+	job.ExpiresAt = &expires
+	if apiErr != nil {
+		job.Status = jobFailed
+		job.Error = apiErr
+		return
+	}
+	job.Status = jobCompleted
+	job.result = result
+	job.ResultURL = job.StatusURL + "/result"
+}
+
+// handleExtractAsync serves POST /extract/async: the request is validated as for
+// /extract, then extracted in the background while the job is returned right away
+func (s *Server) handleExtractAsync(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.applyCORS(w, r) {
+		return
+	}
+
+	if r.Method != "POST" {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	e, ok := s.prepareExtraction(w, r)
+	if !ok {
+		return
+	}
+	job, err := s.jobs.start(e, s.runExtraction)
+	if err != nil {
+		e.logger.Warnf("Rejected extraction job %s: %v", e.runID, err)
+		w.Header().Set("Retry-After", strconv.Itoa(int(jobQueueRetryAfter.Seconds())))
+		s.sendAPIError(w, http.StatusServiceUnavailable, &APIError{
+			Code:      CodeUnavailable,
+			Message:   "Too many extraction jobs are queued, retry later",
+			Retryable: true,
+		})
+		return
+	}
+	e.logger.Infof("Queued extraction job %s", job.ID)
+
+	w.Header().Set("Location", job.StatusURL)
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(RunResponse{Success: true, Data: job}); err != nil {
+		s.logger.Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// handleJobs serves GET /jobs/{id}, the status and progress of an async extraction, and
+// GET /jobs/{id}/result, its result once completed
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.applyCORS(w, r) {
+		return
+	}
+
+	if r.Method != "GET" {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/jobs"), "/"), "/")
+	if parts[0] == "" || len(parts) > 2 || (len(parts) == 2 && parts[1] != "result") {
+		s.sendError(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	job, result, ok := s.jobs.get(parts[0])
+	if !ok {
+		s.sendError(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	if len(parts) == 1 {
+		if job.Status == jobRunning {
+			if progress, ok := s.active.get(job.ID); ok {
+				job.Progress = &progress
+			}
+		}
+		s.sendData(w, job)
+		return
+	}
+	if job.Status != jobCompleted {
+		s.sendError(w, fmt.Sprintf("Job is %s", job.Status), http.StatusConflict)
+		return
+	}
+
+	// The job's schema version was validated with its request
+	result, _ = result.AsSchema(job.schemaVersion)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(APIResponse{Success: true, RunID: job.ID, Data: result}); err != nil {
+		s.logger.Errorf("Failed to encode response: %v", err)
+	}
+}
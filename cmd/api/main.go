@@ -3,35 +3,110 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"strings"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/graphql-go/graphql"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
+	"shopify-extractor/catalog"
+	"shopify-extractor/config"
 	"shopify-extractor/extractor"
 	"shopify-extractor/internal/types"
+	"shopify-extractor/pipeline"
+	"shopify-extractor/stats"
+	"shopify-extractor/utils"
 )
 
+// defaultExtractTimeout bounds how long a single /extract request may run
+const defaultExtractTimeout = 10 * time.Minute
+
 // APIRequest represents the request body for the API
 type APIRequest struct {
-	Stores []string `json:"stores"`
+	Stores []string `json:"stores,omitempty"`
+
+	// Portfolios names configured groups of stores to extract along with Stores
+	Portfolios []string `json:"portfolios,omitempty"`
+
+	// Optional product sampling, see types.Config
+	SampleRate  float64 `json:"sample_rate,omitempty"`
+	SampleCount int     `json:"sample_count,omitempty"`
+	Seed        int64   `json:"seed,omitempty"`
+
+	// Optional crawl order, see types.Config
+	Order     string `json:"order,omitempty"`
+	OrderSeed int64  `json:"order_seed,omitempty"`
+
+	// IncludeRaw adds the original table, with every column, to each filtered size chart
+	IncludeRaw bool `json:"include_raw,omitempty"`
+
+	// Trace attaches each product's extraction diagnostics to the result
+	Trace bool `json:"trace,omitempty"`
+
+	// SchemaVersion is the structure of the returned result, see types.SchemaV*
+	SchemaVersion int `json:"schema_version,omitempty"`
+
+	// Optional page size: the response then holds the first page_size products and a
+	// next_token for fetching the rest from GET /runs/{id}
+	PageSize int `json:"page_size,omitempty"`
 }
 
 // APIResponse represents the response from the API
 type APIResponse struct {
 	Success bool                    `json:"success"`
+	RunID   string                  `json:"run_id,omitempty"`
 	Data    *types.ExtractionResult `json:"data,omitempty"`
-	Error   string                  `json:"error,omitempty"`
+	Error   *APIError               `json:"error,omitempty"`
+
+	// NextToken fetches the next page of a paged result from GET /runs/{id}
+	NextToken string `json:"next_token,omitempty"`
 }
 
 // Server holds the API server configuration
 type Server struct {
 	logger *logrus.Logger
 	config *types.Config
+	cors   CORSConfig
+
+	// apiKeys grant roles checked by withPolicy; authentication is off when empty
+	apiKeys []apiKey
+	usage   *usageTracker
+
+	// settings is the loaded configuration behind config; both are replaced on reload
+	configMu       sync.RWMutex
+	settings       *config.Settings
+	reloadInterval time.Duration
+
+	runs    *runStore
+	active  *activeRuns
+	jobs    *jobStore
+	catalog *catalog.Index
+	schema  graphql.Schema
+	exports *exportStore
+	stats   *stats.Collector
+	events  *eventHub
+	ready   readiness
+
+	// extraction runs the store extractors; extractTimeout bounds each /extract request
+	extraction     ExtractionService
+	extractTimeout time.Duration
+
+	// pool keeps store extractors and their browsers alive across requests; nil when the
+	// extraction service does not use one
+	pool *extractorPool
+
+	// prewarmStores are readied at startup, see prewarm
+	prewarmStores []string
+
+	// Discovered product URLs reused across chunked extraction requests
+	discoveryMu sync.Mutex
+	discoveries map[string]*discoverySnapshot
 }
 
 // NewServer creates a new API server
@@ -56,19 +131,77 @@ func NewServer() *Server {
 		logger.SetLevel(logrus.InfoLevel)
 	}
 
-	// Create configuration
-	config := &types.Config{
-		RequestDelay:           1 * time.Second,
-		MaxRetries:            3,
-		Timeout:               30 * time.Second,
-		MaxConcurrentRequests: 5,
-		UseHeadlessBrowser:    true,
-		UserAgent:             "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	// Load the extraction configuration: defaults, then EXTRACTOR_PROFILE (prod unless
+	// set), then CONFIG_FILE, then environment
+	settings, err := config.Load(nil, config.ProfileProd)
+	if err != nil {
+		logger.Fatalf("%v", err)
 	}
+	settings.Log(logger)
 
+	// Config files are polled for changes unless CONFIG_RELOAD_INTERVAL is 0
+	reloadInterval := defaultReloadInterval
+	if interval, err := time.ParseDuration(os.Getenv("CONFIG_RELOAD_INTERVAL")); err == nil {
+		reloadInterval = interval
+	}
+
+	// Extracted products are indexed for the query endpoints, persisted when CATALOG_PATH is set
+	index := catalog.NewIndex()
+	if path := os.Getenv("CATALOG_PATH"); path != "" {
+		var err error
+		if index, err = catalog.Open(path); err != nil {
+			logger.Fatalf("Failed to open catalog: %v", err)
+		}
+	}
+
+	schema, err := catalog.NewSchema(index)
+	if err != nil {
+		logger.Fatalf("Failed to build GraphQL schema: %v", err)
+	}
+
+	apiKeys, err := parseAPIKeys(context.Background(), os.Getenv("API_KEYS"))
+	if err != nil {
+		logger.Fatalf("Invalid API_KEYS: %v", err)
+	}
+	if len(apiKeys) == 0 {
+		logger.Warn("API_KEYS is not set, API authentication is disabled")
+	}
+	quotas, err := LoadQuotas()
+	if err != nil {
+		logger.Fatalf("%v", err)
+	}
+	jobs, err := loadJobStore()
+	if err != nil {
+		logger.Fatalf("%v", err)
+	}
+	cors, err := LoadCORSConfig()
+	if err != nil {
+		logger.Fatalf("%v", err)
+	}
+
+	collector := stats.NewCollector()
+	pool := newExtractorPool(logger, collector)
 	return &Server{
-		logger: logger,
-		config: config,
+		logger:         logger,
+		config:         settings.Config,
+		settings:       settings,
+		reloadInterval: reloadInterval,
+		cors:           cors,
+		apiKeys:        apiKeys,
+		usage:          newUsageTracker(quotas),
+		runs:           newRunStore(maxStoredRuns),
+		active:         newActiveRuns(),
+		jobs:           jobs,
+		catalog:        index,
+		schema:         schema,
+		exports:        newExportStore(),
+		stats:          collector,
+		events:         newEventHub(),
+		extraction:     newExtractorService(collector, pool),
+		extractTimeout: defaultExtractTimeout,
+		pool:           pool,
+		prewarmStores:  parsePrewarmStores(os.Getenv("PREWARM_STORES")),
+		discoveries:    make(map[string]*discoverySnapshot),
 	}
 }
 
@@ -76,13 +209,7 @@ func NewServer() *Server {
 func (s *Server) handleExtract(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	// Handle preflight requests
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
+	if s.applyCORS(w, r) {
 		return
 	}
 
@@ -92,99 +219,183 @@ func (s *Server) handleExtract(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse request body
-	var req APIRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.sendError(w, "Invalid request body", http.StatusBadRequest)
+	e, ok := s.prepareExtraction(w, r)
+	if !ok {
 		return
 	}
-
-	// Validate request
-	if len(req.Stores) == 0 {
-		s.sendError(w, "No stores provided", http.StatusBadRequest)
+	results, apiErr, status := s.runExtraction(e, s.extractTimeout)
+	if apiErr != nil {
+		s.sendAPIError(w, status, apiErr)
 		return
 	}
 
-	// Clean store names
+	// Send success response
+	response := APIResponse{
+		Success: true,
+		RunID:   e.runID,
+		Data:    results,
+	}
+	if e.req.PageSize > 0 {
+		response.Data, response.NextToken = pageResult(results, pageToken{Run: e.runID, Size: e.req.PageSize})
+	}
+	// The validated schema version always converts
+	response.Data, _ = response.Data.AsSchema(e.config.SchemaVersion)
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		s.logger.Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// extraction is a validated extraction request, run by runExtraction
+type extraction struct {
+	runID      string
+	req        APIRequest
+	portfolios map[string][]string
+	config     types.Config
+	caller     caller
+	logger     *logrus.Entry
+}
+
+// prepareExtraction decodes and validates the body of an extraction request and counts
+// it against the API key's quota. When the request is rejected it has been answered and
+// ok is false.
+func (s *Server) prepareExtraction(w http.ResponseWriter, r *http.Request) (e *extraction, ok bool) {
+	// Parse request body
+	var req APIRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		s.sendRequestError(w, err)
+		return nil, false
+	}
+
+	// Clean store names; "www." and registered aliases name the same store
 	for i, store := range req.Stores {
-		req.Stores[i] = strings.TrimSpace(store)
+		req.Stores[i] = types.CanonicalStore(store)
 	}
 
-	s.logger.Infof("API request received for stores: %v", req.Stores)
+	// Each request works on its own copy of the configuration
+	config := s.currentConfig()
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-	defer cancel()
+	// Expand the requested portfolios into their stores
+	portfolios, failures := resolvePortfolios(&config, &req)
 
-	// Extract size charts using individual store extractors
-	var storeResults []types.StoreResult
-	
-	for _, store := range req.Stores {
-		s.logger.Infof("Processing store: %s", store)
-		
-		var storeExtractor interface {
-			ExtractAll(context.Context) ([]types.Product, error)
-			Close()
-		}
-		
-		// Create the appropriate extractor based on store name
-		switch store {
-		case "westside.com":
-			storeExtractor = extractor.NewWestsideExtractor(s.config, s.logger)
-		case "littleboxindia.com":
-			storeExtractor = extractor.NewLittleBoxIndiaExtractor(s.config, s.logger)
-		case "suqah.com":
-			storeExtractor = extractor.NewSuqahExtractor(s.config, s.logger)
-		default:
-			s.logger.Warnf("Unknown store: %s, skipping", store)
-			continue
-		}
-		
-		defer storeExtractor.Close()
-		
-		// Extract from this store
-		products, err := storeExtractor.ExtractAll(ctx)
-		if err != nil {
-			s.logger.Warnf("Failed to extract from %s: %v", store, err)
-			continue
-		}
-		
-		// Create store result with actual store name
-		storeResult := types.StoreResult{
-			StoreName: store,
-			Products:  products,
+	// Validate request
+	failures = append(failures, validateExtractRequest(&req)...)
+	if len(failures) == 0 {
+		// Resolve *.myshopify.com domains to the store they serve
+		req.Stores = utils.CanonicalStores(r.Context(), req.Stores)
+	}
+	for i, store := range req.Stores {
+		if validateStoreDomain(store) == "" && !s.extraction.Supports(store) {
+			failures = append(failures, ValidationError{Field: fmt.Sprintf("stores[%d]", i), Message: "is not a supported store"})
 		}
-		storeResults = append(storeResults, storeResult)
 	}
-	
-	// Create the final result structure with separate store results
-	results := &types.ExtractionResult{
-		Stores: storeResults,
+	if len(failures) > 0 {
+		s.sendValidationError(w, failures)
+		return nil, false
 	}
 
-	// Send success response
-	response := APIResponse{
-		Success: true,
-		Data:    results,
+	// Each extraction counts as a job against the API key's daily quota
+	if !s.startJob(w, r) {
+		return nil, false
 	}
 
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		s.logger.Errorf("Failed to encode response: %v", err)
+	runID := newRunID()
+	config.SampleRate = req.SampleRate
+	config.SampleCount = req.SampleCount
+	config.SampleSeed = req.Seed
+	config.CrawlOrder = req.Order
+	config.CrawlSeed = req.OrderSeed
+	if req.IncludeRaw {
+		config.IncludeRaw = true
 	}
+	if req.Trace {
+		config.Trace = true
+	}
+	if req.SchemaVersion != 0 {
+		config.SchemaVersion = req.SchemaVersion
+	}
+	config.RunID = runID
+
+	return &extraction{
+		runID:      runID,
+		req:        req,
+		portfolios: portfolios,
+		config:     config,
+		caller:     requestCaller(r),
+		logger:     s.requestLogger(r).WithField("run_id", runID),
+	}, true
 }
 
-// sendError sends an error response
-func (s *Server) sendError(w http.ResponseWriter, message string, statusCode int) {
-	response := APIResponse{
-		Success: false,
-		Error:   message,
+// runExtraction extracts the stores of a prepared request within timeout and keeps the
+// result as a run. When every store failed it returns the error to answer with and its
+// HTTP status instead.
+func (s *Server) runExtraction(e *extraction, timeout time.Duration) (*types.ExtractionResult, *APIError, int) {
+	runID, logger, config := e.runID, e.logger, e.config
+	logger.Infof("API request received for stores: %v", e.req.Stores)
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if config.DiscoveryTimeout == 0 {
+		// Leave at least half of the request's time to extraction
+		config.DiscoveryTimeout = timeout / 2
 	}
 
-	w.WriteHeader(statusCode)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		s.logger.Errorf("Failed to encode error response: %v", err)
+	// Track the run's progress until it is stored, see GET /runs/{id} and GET /events
+	active, ctx := s.active.start(ctx, runID, e.req.Stores)
+	defer s.active.finish(runID)
+
+	// Extract size charts store by store through the shared pipeline; a failed store is
+	// reported in its result
+	var storeFailures []ValidationError
+	runner := pipeline.Runner{
+		Extract: s.extraction.ExtractStore,
+		OnStore: func(i int, store string) { active.setStore(store) },
+		OnStoreDone: func(i int, storeResult types.StoreResult, err error) {
+			if err != nil {
+				storeFailures = append(storeFailures, ValidationError{Field: fmt.Sprintf("stores[%d]", i), Message: err.Error()})
+			}
+			s.events.publish(EventStore, StoreEvent{RunID: runID, StoreName: storeResult.StoreName, Products: len(storeResult.Products), Error: storeResult.Error})
+		},
 	}
+	results := runner.Run(ctx, e.req.Stores, e.portfolios, &config, logger)
+
+	s.usage.addPages(e.caller, pagesFetched(results))
+
+	// A request where every store failed is an upstream error
+	if len(storeFailures) == len(e.req.Stores) {
+		s.events.publish(EventRun, RunEvent{RunID: runID, Stores: len(e.req.Stores), Failed: true})
+		apiErr := &APIError{
+			Code:      CodeExtractionFailed,
+			Message:   "Extraction failed for every requested store",
+			Details:   storeFailures,
+			Retryable: true,
+		}
+		status := http.StatusBadGateway
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			apiErr.Code = CodeTimeout
+			apiErr.Message = fmt.Sprintf("Extraction did not finish within %v", timeout)
+			status = http.StatusGatewayTimeout
+		}
+		return nil, apiErr, status
+	}
+
+	// Keep the result so it can be inspected later through /runs/{id}
+	run := s.runs.add(runID, results)
+	if err := s.catalog.Add(results, run.CreatedAt); err != nil {
+		logger.Errorf("Failed to index run %s: %v", run.ID, err)
+	}
+	s.events.publish(EventRun, RunEvent{RunID: run.ID, Stores: len(results.Stores), Products: countProducts(results)})
+	return results, nil, http.StatusOK
+}
+
+// handleStats returns the extraction statistics aggregated since the server started
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(s.stats.Snapshot())
 }
 
 // handleHealth handles the health check endpoint
@@ -196,21 +407,72 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 
 // Start starts the API server
 func (s *Server) Start(port string) error {
-	// Setup routes
-	http.HandleFunc("/extract", s.handleExtract)
-	http.HandleFunc("/health", s.handleHealth)
+	// Setup routes. Each route requires the role of its policy when API_KEYS is set;
+	// /health, /readyz and the dashboard page are public
+	http.HandleFunc("/extract", withRequestID(s.withPolicy(operatorPolicy, withGzip(s.handleExtract))))
+	http.HandleFunc("/extract/chunked", withRequestID(s.withPolicy(operatorPolicy, withGzip(s.handleExtractChunked))))
+	http.HandleFunc("/extract/async", withRequestID(s.withPolicy(operatorPolicy, s.handleExtractAsync)))
+	http.HandleFunc("/jobs/", withRequestID(s.withPolicy(readerPolicy, withGzip(s.handleJobs))))
+	http.HandleFunc("/runs/", withRequestID(s.withPolicy(readerPolicy, withGzip(s.handleRuns))))
+	http.HandleFunc("/stores", withRequestID(s.withPolicy(readerPolicy, s.handleStores)))
+	http.HandleFunc("/stores/", withRequestID(s.withPolicy(readerPolicy, s.handleStores)))
+	http.HandleFunc("/products", withRequestID(s.withPolicy(readerPolicy, s.handleProducts)))
+	http.HandleFunc("/products/", withRequestID(s.withPolicy(readerPolicy, s.handleProducts)))
+	http.HandleFunc("/graphql", withRequestID(s.withPolicy(readerPolicy, s.handleGraphQL)))
+	http.HandleFunc("/exports", withRequestID(s.withPolicy(exportsPolicy, s.handleExports)))
+	http.HandleFunc("/exports/", withRequestID(s.withPolicy(exportsPolicy, s.handleExports)))
+	http.HandleFunc("/stats", withRequestID(s.withPolicy(readerPolicy, s.handleStats)))
+	http.HandleFunc("/metrics", withRequestID(s.withPolicy(readerPolicy, s.handleMetrics)))
+	http.HandleFunc("/health", withRequestID(s.handleHealth))
+	http.HandleFunc("/readyz", withRequestID(s.handleReadyz))
+	http.HandleFunc("/usage", withRequestID(s.withPolicy(readerPolicy, s.handleUsage)))
+	http.HandleFunc("/admin/purge", withRequestID(s.withPolicy(adminPolicy, s.handlePurge)))
+	http.HandleFunc("/events", withRequestID(s.withPolicy(eventsPolicy, s.handleEvents)))
+	http.HandleFunc("/ui", withRequestID(withGzip(s.handleUI)))
+	http.HandleFunc("/ui/", withRequestID(withGzip(s.handleUI)))
+
+	// Verify the browser can render a page without delaying startup
+	go s.checkReadiness(context.Background())
+	go s.prewarm(context.Background())
+	go s.watchConfig(context.Background())
 
 	s.logger.Infof("Starting API server on port %s", port)
 	s.logger.Info("Available endpoints:")
 	s.logger.Info("  POST /extract - Extract size charts from multiple stores")
+	s.logger.Info("  POST /extract/chunked - Extract the next batch of products from one store")
+	s.logger.Info("  POST /extract/async - Start an extraction in the background and return its job")
+	s.logger.Info("  GET  /jobs/{id} - Status and progress of an async extraction")
+	s.logger.Info("  GET  /jobs/{id}/result - Result of a completed async extraction")
+	s.logger.Info("  GET  /runs/{id} - Result of a previous extraction run, or the progress of a running one")
+	s.logger.Info("  GET  /runs/{id}/missing - Products without size chart, grouped by reason")
+	s.logger.Info("  GET  /stores - Stores in the product catalog")
+	s.logger.Info("  GET  /stores/{domain}/products - Extracted products of a store")
+	s.logger.Info("  GET  /stores/{domain}/capabilities - What the store's adapter supports")
+	s.logger.Info("  GET  /products?size=M&min_bust_in=36 - Search extracted products")
+	s.logger.Info("  GET  /products/{id}/charts - Size charts of a product")
+	s.logger.Info("  POST /graphql - GraphQL queries over stores, products and charts")
+	s.logger.Info("  POST /exports - Export the catalog as JSON or CSV in the background")
+	s.logger.Info("  GET  /exports/{id} - Export status and download URL")
+	s.logger.Info("  GET  /stats   - Extraction statistics since startup")
+	s.logger.Info("  GET  /metrics - Extraction statistics in the Prometheus text format")
 	s.logger.Info("  GET  /health  - Health check")
+	s.logger.Info("  GET  /readyz  - Readiness check (browser can render a page)")
+	s.logger.Info("  GET  /events  - Live extraction progress as server-sent events")
+	s.logger.Info("  GET  /usage   - Pages fetched and jobs run today by the API key, and its quotas")
+	s.logger.Info("  GET  /ui      - Web dashboard")
 
 	return http.ListenAndServe(":"+port, nil)
 }
 
 // Close closes the server and cleanup resources
 func (s *Server) Close() {
-	// No cleanup needed since we create extractors per request
+	// Extractors still serving a request are closed when they are released
+	if s.pool != nil {
+		s.pool.close()
+	}
+	if err := s.catalog.Close(); err != nil {
+		s.logger.Errorf("Failed to close catalog: %v", err)
+	}
 }
 
 func main() {
@@ -227,6 +489,28 @@ func main() {
 	server := NewServer()
 	defer server.Close()
 
+	if _, err := utils.NewBrowserBackend(server.config, server.logger); err != nil {
+		log.Fatalf("Invalid browser configuration: %v", err)
+	}
+
+	// Register third-party store adapters shipped as Go plugins
+	if err := extractor.LoadPlugins(os.Getenv("ADAPTER_PLUGINS"), server.logger); err != nil {
+		log.Fatalf("Failed to load adapter plugins: %v", err)
+	}
+
+	// Fail fast when the browser is enabled but Chrome is missing. In container mode this
+	// is fatal so the orchestrator reports a clear error instead of a crash loop at runtime.
+	if server.config.UseHeadlessBrowser && utils.UsesLocalChrome(server.config.Browser) {
+		if chromePath, err := utils.FindChrome(server.config.Browser); err != nil {
+			if containerMode, _ := strconv.ParseBool(os.Getenv("CONTAINER_MODE")); containerMode {
+				log.Fatalf("Startup check failed: %v", err)
+			}
+			log.Printf("Warning: %v", err)
+		} else {
+			log.Printf("Using Chrome binary: %s", chromePath)
+		}
+	}
+
 	// Start the server
 	log.Printf("Starting API server on port %s", serverPort)
 	log.Fatal(server.Start(serverPort))
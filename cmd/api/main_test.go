@@ -0,0 +1,755 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/catalog"
+	"shopify-extractor/config"
+	"shopify-extractor/extractor"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/stats"
+)
+
+// fakeExtraction is an ExtractionService serving canned products and errors per store
+type fakeExtraction struct {
+	products map[string][]types.Product
+	errs     map[string]error
+	block    bool // ExtractStore waits for the context to end
+
+	// during runs inside ExtractStore, while the run is in progress
+	during func(ctx context.Context, config *types.Config)
+}
+
+func (f *fakeExtraction) Supports(store string) bool {
+	_, ok := f.products[store]
+	if !ok {
+		_, ok = f.errs[store]
+	}
+	return ok || f.block
+}
+
+func (f *fakeExtraction) Capabilities(store string) (extractor.Capabilities, bool) {
+	if !f.Supports(store) {
+		return extractor.Capabilities{}, false
+	}
+	return extractor.Capabilities{DiscoveryStrategies: []string{extractor.DiscoveryCollections}, ChartParsers: []string{}}, true
+}
+
+func (f *fakeExtraction) ExtractStore(ctx context.Context, store string, config *types.Config, logger types.Logger) (types.StoreResult, error) {
+	if f.during != nil {
+		f.during(ctx, config)
+	}
+	if f.block {
+		<-ctx.Done()
+		return types.StoreResult{}, ctx.Err()
+	}
+	if err := f.errs[store]; err != nil {
+		return types.StoreResult{}, err
+	}
+	return types.StoreResult{StoreName: store, Products: f.products[store]}, nil
+}
+
+func (f *fakeExtraction) DiscoverProductURLs(ctx context.Context, store string, config *types.Config, logger types.Logger) ([]string, error) {
+	if err := f.errs[store]; err != nil {
+		return nil, err
+	}
+	var urls []string
+	for _, product := range f.products[store] {
+		urls = append(urls, product.ProductURL)
+	}
+	return urls, nil
+}
+
+func (f *fakeExtraction) ExtractProducts(ctx context.Context, store string, productURLs []string, config *types.Config, logger types.Logger, visit func(string, *types.Product, error)) error {
+	for _, productURL := range productURLs {
+		for _, product := range f.products[store] {
+			if product.ProductURL == productURL {
+				product := product
+				visit(productURL, &product, nil)
+			}
+		}
+	}
+	return nil
+}
+
+func testProducts(store string, n int) []types.Product {
+	var products []types.Product
+	for i := 0; i < n; i++ {
+		url := "https://" + store + "/products/item-" + string(rune('a'+i))
+		products = append(products, types.Product{
+			ProductTitle: "Item",
+			ProductURL:   url,
+			SizeCharts:   []*types.SizeChart{{Headers: []string{"Size", "Bust"}, Rows: []map[string]string{{"Size": "M", "Bust": "36"}}}},
+		})
+	}
+	return products
+}
+
+func newTestServer(extraction ExtractionService) *Server {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return &Server{
+		logger:         logger,
+		config:         &types.Config{},
+		runs:           newRunStore(maxStoredRuns),
+		active:         newActiveRuns(),
+		jobs:           newJobStore(time.Minute, time.Second, 1, 10),
+		catalog:        catalog.NewIndex(),
+		stats:          stats.NewCollector(),
+		events:         newEventHub(),
+		usage:          newUsageTracker(Quotas{}),
+		extraction:     extraction,
+		extractTimeout: time.Second,
+		discoveries:    make(map[string]*discoverySnapshot),
+	}
+}
+
+// serve runs a request through the handler and decodes the JSON response into out
+func serve(t *testing.T, handler http.HandlerFunc, method, path, body string, out interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	withRequestID(handler)(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	if out != nil {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+	}
+	return w
+}
+
+func TestHandleExtract_Validation(t *testing.T) {
+	s := newTestServer(&fakeExtraction{products: map[string][]types.Product{"westside.com": nil}})
+
+	var response APIResponse
+	w := serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["https://westside.com", "unknown.com"], "sample_rate": 2}`, &response)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, CodeValidationFailed, response.Error.Code)
+	assert.False(t, response.Error.Retryable)
+	assert.Equal(t, w.Header().Get(requestIDHeader), response.Error.RequestID)
+	assert.ElementsMatch(t, []ValidationError{
+		{Field: "stores[0]", Message: "must be a bare domain such as westside.com, without scheme, port or path"},
+		{Field: "sample_rate", Message: "must be between 0 and 1"},
+		{Field: "stores[1]", Message: "is not a supported store"},
+	}, response.Error.Details)
+
+	w = serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["westside.com"], "bogus": 1}`, &response)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, CodeInvalidRequest, response.Error.Code)
+
+	w = serve(t, s.handleExtract, "GET", "/extract", "", &response)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, CodeMethodNotAllowed, response.Error.Code)
+}
+
+func TestHandleExtract_PartialFailure(t *testing.T) {
+	s := newTestServer(&fakeExtraction{
+		products: map[string][]types.Product{"westside.com": testProducts("westside.com", 2)},
+		errs:     map[string]error{"suqah.com": errors.New("discovery failed")},
+	})
+
+	var response APIResponse
+	w := serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["westside.com", "suqah.com"]}`, &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.True(t, response.Success)
+	require.Len(t, response.Data.Stores, 2)
+	assert.Len(t, response.Data.Stores[0].Products, 2)
+	assert.Equal(t, "suqah.com", response.Data.Stores[1].StoreName)
+	assert.Equal(t, "discovery failed", response.Data.Stores[1].Error)
+	assert.Equal(t, response.RunID, response.Data.RunID)
+
+	_, ok := s.runs.get(response.RunID)
+	assert.True(t, ok, "the run is kept for /runs")
+	assert.Equal(t, 2, s.catalog.Query(catalog.Query{}).Total, "extracted products are indexed")
+}
+
+func TestHandleExtract_Portfolio(t *testing.T) {
+	s := newTestServer(&fakeExtraction{
+		products: map[string][]types.Product{
+			"westside.com":       testProducts("westside.com", 2),
+			"littleboxindia.com": testProducts("littleboxindia.com", 1),
+		},
+		errs: map[string]error{"suqah.com": errors.New("blocked")},
+	})
+	s.config.Portfolios = map[string][]string{"womenswear": {"westside.com", "suqah.com"}}
+
+	var response APIResponse
+	w := serve(t, s.handleExtract, "POST", "/extract", `{"portfolios": ["womenswear"], "stores": ["littleboxindia.com", "westside.com"]}`, &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, response.Data.Stores, 3, "portfolio stores are extracted once")
+	assert.Equal(t, []types.PortfolioResult{{
+		Name:               "womenswear",
+		Stores:             []string{"westside.com", "suqah.com"},
+		FailedStores:       []string{"suqah.com"},
+		ProductsWithCharts: 2,
+	}}, response.Data.Portfolios)
+
+	w = serve(t, s.handleExtract, "POST", "/extract", `{"portfolios": ["menswear"]}`, &response)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, response.Error.Details, ValidationError{Field: "portfolios[0]", Message: "is not a configured portfolio"})
+}
+
+func TestHandleExtract_SchemaVersion(t *testing.T) {
+	s := newTestServer(&fakeExtraction{products: map[string][]types.Product{"westside.com": testProducts("westside.com", 1)}})
+
+	var response APIResponse
+	w := serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["westside.com"]}`, &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, types.CurrentSchemaVersion, response.Data.SchemaVersion)
+
+	var v1 APIResponse
+	w = serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["westside.com"], "schema_version": 1}`, &v1)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "schema_version")
+	assert.NotEmpty(t, v1.RunID)
+	assert.Empty(t, v1.Data.RunID)
+	assert.Len(t, v1.Data.Stores[0].Products, 1)
+
+	w = serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["westside.com"], "schema_version": 3}`, &response)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "schema_version", response.Error.Details[0].Field)
+}
+
+func TestHandleExtract_AllStoresFail(t *testing.T) {
+	s := newTestServer(&fakeExtraction{errs: map[string]error{"suqah.com": errors.New("blocked")}})
+
+	var response APIResponse
+	w := serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["suqah.com"]}`, &response)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, CodeExtractionFailed, response.Error.Code)
+	assert.True(t, response.Error.Retryable)
+	assert.Equal(t, []ValidationError{{Field: "stores[0]", Message: "blocked"}}, response.Error.Details)
+}
+
+func TestHandleExtract_Timeout(t *testing.T) {
+	s := newTestServer(&fakeExtraction{block: true})
+	s.extractTimeout = 20 * time.Millisecond
+
+	var response APIResponse
+	w := serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["westside.com"]}`, &response)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Equal(t, CodeTimeout, response.Error.Code)
+	assert.True(t, response.Error.Retryable)
+}
+
+func TestHandleExtractAsync(t *testing.T) {
+	release := make(chan struct{})
+	s := newTestServer(&fakeExtraction{
+		products: map[string][]types.Product{"westside.com": testProducts("westside.com", 2)},
+		errs:     map[string]error{"suqah.com": errors.New("blocked")},
+		during:   func(ctx context.Context, config *types.Config) { <-release },
+	})
+
+	var started struct {
+		Success bool       `json:"success"`
+		Data    ExtractJob `json:"data"`
+	}
+	w := serve(t, s.handleExtractAsync, "POST", "/extract/async", `{"stores": ["westside.com"]}`, &started)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	job := started.Data
+	assert.Equal(t, "/jobs/"+job.ID, w.Header().Get("Location"))
+	assert.Equal(t, []string{"westside.com"}, job.Stores)
+
+	var status struct {
+		Data ExtractJob `json:"data"`
+	}
+	require.Eventually(t, func() bool {
+		serve(t, s.handleJobs, "GET", job.StatusURL, "", &status)
+		return status.Data.Status == jobRunning && status.Data.Progress != nil
+	}, time.Second, 5*time.Millisecond)
+
+	var response APIResponse
+	w = serve(t, s.handleJobs, "GET", job.StatusURL+"/result", "", &response)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		serve(t, s.handleJobs, "GET", job.StatusURL, "", &status)
+		return status.Data.Status == jobCompleted
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, job.StatusURL+"/result", status.Data.ResultURL)
+	assert.NotNil(t, status.Data.ExpiresAt)
+
+	w = serve(t, s.handleJobs, "GET", status.Data.ResultURL, "", &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, job.ID, response.RunID)
+	require.Len(t, response.Data.Stores, 1)
+	assert.Len(t, response.Data.Stores[0].Products, 2)
+
+	// A job where every store failed carries the error /extract would answer with
+	w = serve(t, s.handleExtractAsync, "POST", "/extract/async", `{"stores": ["suqah.com"]}`, &started)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Eventually(t, func() bool {
+		serve(t, s.handleJobs, "GET", started.Data.StatusURL, "", &status)
+		return status.Data.Status == jobFailed
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, CodeExtractionFailed, status.Data.Error.Code)
+
+	w = serve(t, s.handleExtractAsync, "POST", "/extract/async", `{"stores": ["unknown.com"]}`, &response)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = serve(t, s.handleJobs, "GET", "/jobs/missing", "", &response)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandleExtractAsync_QueueFull(t *testing.T) {
+	release := make(chan struct{})
+	s := newTestServer(&fakeExtraction{
+		products: map[string][]types.Product{"westside.com": testProducts("westside.com", 2)},
+		during:   func(ctx context.Context, config *types.Config) { <-release },
+	})
+	s.jobs = newJobStore(time.Minute, time.Second, 1, 1)
+
+	// One job runs and one waits; the next is turned away until a slot frees up
+	var started RunResponse
+	for i := 0; i < 2; i++ {
+		w := serve(t, s.handleExtractAsync, "POST", "/extract/async", `{"stores": ["westside.com"]}`, &started)
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	}
+	var response APIResponse
+	w := serve(t, s.handleExtractAsync, "POST", "/extract/async", `{"stores": ["westside.com"]}`, &response)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	require.NotNil(t, response.Error)
+	assert.Equal(t, CodeUnavailable, response.Error.Code)
+	assert.True(t, response.Error.Retryable)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		s.jobs.mu.Lock()
+		defer s.jobs.mu.Unlock()
+		return s.jobs.pending == 0
+	}, time.Second, 5*time.Millisecond)
+	w = serve(t, s.handleExtractAsync, "POST", "/extract/async", `{"stores": ["westside.com"]}`, &started)
+	assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+}
+
+func TestJobStore_Expiry(t *testing.T) {
+	jobs := newJobStore(time.Minute, time.Second, 1, 10)
+	job := &ExtractJob{ID: "done", Status: jobRunning}
+	jobs.jobs[job.ID] = job
+	jobs.finish(job, &types.ExtractionResult{}, nil)
+
+	_, result, ok := jobs.get("done")
+	require.True(t, ok)
+	assert.NotNil(t, result)
+
+	jobs.mu.Lock()
+	jobs.expire(job.ExpiresAt.Add(time.Second))
+	jobs.mu.Unlock()
+	_, _, ok = jobs.get("done")
+	assert.False(t, ok, "finished jobs expire after their TTL")
+}
+
+func TestHandleExtractChunked(t *testing.T) {
+	s := newTestServer(&fakeExtraction{products: map[string][]types.Product{"westside.com": testProducts("westside.com", 3)}})
+
+	var response ChunkedResponse
+	w := serve(t, s.handleExtractChunked, "POST", "/extract/chunked", `{"store": "westside.com", "limit": 2}`, &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Len(t, response.Data.Products, 2)
+	assert.Equal(t, 3, response.Data.TotalProducts)
+	require.NotEmpty(t, response.Data.NextCursor)
+	assert.NotEmpty(t, response.Data.Products[0].SizeCharts[0].Fingerprint)
+
+	w = serve(t, s.handleExtractChunked, "POST", "/extract/chunked", `{"cursor": "`+response.Data.NextCursor+`", "limit": 2}`, &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Len(t, response.Data.Products, 1)
+	assert.True(t, response.Data.Done)
+
+	var failed APIResponse
+	w = serve(t, s.handleExtractChunked, "POST", "/extract/chunked", `{"store": "unknown.com"}`, &failed)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleExtractChunked_CanonicalStore(t *testing.T) {
+	types.RegisterStoreAlias("westside-chunked.myshopify.com", "westside.com")
+	extraction := &fakeExtraction{products: map[string][]types.Product{"westside.com": testProducts("westside.com", 3)}}
+	s := newTestServer(extraction)
+
+	var response ChunkedResponse
+	w := serve(t, s.handleExtractChunked, "POST", "/extract/chunked", `{"store": "WWW.Westside.com", "limit": 2}`, &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "westside.com", response.Data.StoreName)
+	require.NotEmpty(t, response.Data.NextCursor)
+
+	// Another spelling of the store continues the same cursor
+	for _, store := range []string{"westside.com", "westside-chunked.myshopify.com"} {
+		var next ChunkedResponse
+		w = serve(t, s.handleExtractChunked, "POST", "/extract/chunked", `{"store": "`+store+`", "cursor": "`+response.Data.NextCursor+`", "limit": 2}`, &next)
+		require.Equal(t, http.StatusOK, w.Code, store+": "+w.Body.String())
+		assert.Equal(t, "westside.com", next.Data.StoreName)
+		assert.Len(t, next.Data.Products, 1, store)
+		assert.True(t, next.Data.Done, store)
+	}
+}
+
+func TestHandleExtractChunked_Sampling(t *testing.T) {
+	s := newTestServer(&fakeExtraction{products: map[string][]types.Product{"westside.com": testProducts("westside.com", 6)}})
+
+	var response ChunkedResponse
+	w := serve(t, s.handleExtractChunked, "POST", "/extract/chunked", `{"store": "westside.com", "limit": 2, "sample_count": 3}`, &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 3, response.Data.TotalProducts)
+	require.Len(t, response.Data.Products, 2)
+	require.NotEmpty(t, response.Data.NextCursor)
+	sampled := []string{response.Data.Products[0].ProductURL, response.Data.Products[1].ProductURL}
+
+	// The cursor keeps the sample: the next chunk holds its last product
+	w = serve(t, s.handleExtractChunked, "POST", "/extract/chunked", `{"cursor": "`+response.Data.NextCursor+`", "limit": 2}`, &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 3, response.Data.TotalProducts)
+	require.Len(t, response.Data.Products, 1)
+	assert.NotContains(t, sampled, response.Data.Products[0].ProductURL)
+	assert.True(t, response.Data.Done)
+
+	var failed APIResponse
+	w = serve(t, s.handleExtractChunked, "POST", "/extract/chunked", `{"store": "westside.com", "sample_rate": 2}`, &failed)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestLoadCORSConfig_Credentials(t *testing.T) {
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com,*")
+	_, err := LoadCORSConfig()
+	require.Error(t, err, "credentials are not granted to any origin")
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com")
+	cors, err := LoadCORSConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "https://app.example.com", cors.allowOrigin("https://app.example.com"))
+	assert.Equal(t, "", cors.allowOrigin("https://evil.example.com"))
+
+	// A wildcard never echoes the request origin
+	cors = DefaultCORSConfig()
+	assert.Equal(t, "*", cors.allowOrigin("https://evil.example.com"))
+}
+
+func TestHandleStores_Capabilities(t *testing.T) {
+	s := newTestServer(&fakeExtraction{products: map[string][]types.Product{"westside.com": testProducts("westside.com", 2)}})
+
+	var response struct {
+		Data StoreCapabilities `json:"data"`
+	}
+	w := serve(t, s.handleStores, "GET", "/stores/westside.com/capabilities", "", &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{extractor.DiscoveryCollections}, response.Data.DiscoveryStrategies)
+	assert.Empty(t, response.Data.MeasurementTypes)
+	assert.Nil(t, response.Data.LastRun)
+	assert.Nil(t, response.Data.ChartYield)
+
+	var extracted APIResponse
+	serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["westside.com"]}`, &extracted)
+	s.stats.RecordProduct("westside.com", time.Second, 2, nil)
+	s.stats.RecordProduct("westside.com", time.Second, 0, nil)
+
+	w = serve(t, s.handleStores, "GET", "/stores/westside.com/capabilities", "", &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"bust"}, response.Data.MeasurementTypes)
+	require.NotNil(t, response.Data.LastRun)
+	assert.Equal(t, extracted.RunID, response.Data.LastRun.RunID)
+	assert.Equal(t, 2, response.Data.LastRun.Products)
+	require.NotNil(t, response.Data.ChartYield)
+	assert.Equal(t, 0.5, response.Data.ChartYield.Rate)
+	assert.Equal(t, 2.0, response.Data.ChartYield.ChartsPerProduct)
+
+	var failed APIResponse
+	w = serve(t, s.handleStores, "GET", "/stores/unknown.com/capabilities", "", &failed)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServer_ReloadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"request_delay": "1s"}`), 0o644))
+	t.Setenv("CONFIG_FILE", path)
+	settings, err := config.Load(nil, "")
+	require.NoError(t, err)
+
+	s := newTestServer(&fakeExtraction{})
+	s.settings, s.config = settings, settings.Config
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"request_delay": "3s", "no_sandbox": true}`), 0o644))
+	s.reloadConfig()
+	assert.Equal(t, 3*time.Second, s.currentConfig().RequestDelay)
+	assert.False(t, s.currentConfig().Browser.NoSandbox, "browser options need a restart")
+
+	// An invalid file keeps the running configuration
+	require.NoError(t, os.WriteFile(path, []byte(`{"request_delay": "soon"}`), 0o644))
+	s.reloadConfig()
+	assert.Equal(t, 3*time.Second, s.currentConfig().RequestDelay)
+}
+
+func TestParsePrewarmStores(t *testing.T) {
+	assert.Nil(t, parsePrewarmStores(""))
+	assert.Equal(t, []string{"westside.com", "suqah.com"}, parsePrewarmStores(" westside.com, ,suqah.com "))
+	assert.Equal(t, []string{"all"}, parsePrewarmStores("all"))
+}
+
+func TestHandleExtract_PagedResult(t *testing.T) {
+	s := newTestServer(&fakeExtraction{
+		products: map[string][]types.Product{
+			"westside.com": testProducts("westside.com", 3),
+			"suqah.com":    testProducts("suqah.com", 2),
+		},
+		errs: map[string]error{"littleboxindia.com": errors.New("discovery failed")},
+	})
+
+	var response APIResponse
+	w := serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["westside.com", "suqah.com", "littleboxindia.com"], "page_size": 2}`, &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, response.Data.Stores, 1)
+	assert.Len(t, response.Data.Stores[0].Products, 2)
+	require.NotEmpty(t, response.NextToken)
+
+	// Follow the tokens through GET /runs/{id}
+	var pages [][]string
+	for token := response.NextToken; token != ""; {
+		var page struct {
+			Data      types.ExtractionResult `json:"data"`
+			NextToken string                 `json:"next_token"`
+		}
+		w = serve(t, s.handleRuns, "GET", "/runs/"+response.RunID+"?next_token="+token, "", &page)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var stores []string
+		for _, store := range page.Data.Stores {
+			stores = append(stores, store.StoreName)
+		}
+		pages = append(pages, stores)
+		token = page.NextToken
+	}
+	assert.Equal(t, [][]string{{"westside.com", "suqah.com"}, {"suqah.com", "littleboxindia.com"}}, pages)
+
+	var failed APIResponse
+	w = serve(t, s.handleRuns, "GET", "/runs/"+response.RunID+"?page_size=5000", "", &failed)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = serve(t, s.handleRuns, "GET", "/runs/"+response.RunID+"?next_token=bogus", "", &failed)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestPageResult_StoreDetailsOnFirstPage(t *testing.T) {
+	result := &types.ExtractionResult{RunID: "run", Stores: []types.StoreResult{
+		{StoreName: "westside.com", Products: testProducts("westside.com", 3), Coverage: &types.Coverage{CatalogProducts: 3}},
+		{StoreName: "suqah.com", Products: []types.Product{}, Error: "blocked"},
+	}}
+
+	first, next := pageResult(result, pageToken{Run: "run", Size: 2})
+	require.Len(t, first.Stores, 1)
+	assert.NotNil(t, first.Stores[0].Coverage)
+
+	token, err := decodePageToken(next)
+	require.NoError(t, err)
+	second, next := pageResult(result, token)
+	assert.Empty(t, next)
+	require.Len(t, second.Stores, 2)
+	assert.Len(t, second.Stores[0].Products, 1)
+	assert.Nil(t, second.Stores[0].Coverage, "store details come with the store's first page")
+	assert.Equal(t, "blocked", second.Stores[1].Error)
+}
+
+func TestWithGzip(t *testing.T) {
+	s := newTestServer(&fakeExtraction{products: map[string][]types.Product{"westside.com": testProducts("westside.com", 2)}})
+
+	r := httptest.NewRequest("POST", "/extract", strings.NewReader(`{"stores": ["westside.com"]}`))
+	r.Header.Set("Accept-Encoding", "br, gzip")
+	w := httptest.NewRecorder()
+	withGzip(s.handleExtract)(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	gz, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	var response APIResponse
+	require.NoError(t, json.NewDecoder(gz).Decode(&response))
+	assert.True(t, response.Success)
+
+	r = httptest.NewRequest("POST", "/extract", strings.NewReader(`{"stores": ["westside.com"]}`))
+	w = httptest.NewRecorder()
+	withGzip(s.handleExtract)(w, r)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+}
+
+func TestHandleMetrics(t *testing.T) {
+	s := newTestServer(&fakeExtraction{})
+	s.stats.RecordProduct("westside.com", time.Second, 2, nil)
+	s.stats.RecordChartParsers("westside.com", map[string]int{"kiwi": 2})
+
+	w := serve(t, s.handleMetrics, "GET", "/metrics", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "# TYPE shopify_extractor_charts_extracted_total counter\n")
+	assert.Contains(t, w.Body.String(), `shopify_extractor_charts_extracted_total{store="westside.com"} 2`)
+	assert.Contains(t, w.Body.String(), `shopify_extractor_charts_by_parser_total{store="westside.com",parser="kiwi"} 2`)
+}
+
+func TestHandlePurge(t *testing.T) {
+	s := newTestServer(&fakeExtraction{})
+	s.exports = &exportStore{dir: t.TempDir(), jobs: make(map[string]*ExportJob)}
+
+	result := &types.ExtractionResult{Stores: []types.StoreResult{
+		{StoreName: "westside.com", Products: testProducts("westside.com", 1)},
+		{StoreName: "oldstore.com", Products: testProducts("oldstore.com", 2)},
+	}}
+	s.runs.add("mixed", result)
+	s.runs.add("old", &types.ExtractionResult{Stores: []types.StoreResult{{StoreName: "oldstore.com"}}})
+	require.NoError(t, s.catalog.Add(result, time.Now()))
+
+	var response RunResponse
+	w := serve(t, s.handlePurge, "POST", "/admin/purge", `{}`, &response)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = serve(t, s.handlePurge, "POST", "/admin/purge", `{"deregistered": true}`, &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	report := response.Data.(map[string]interface{})
+	assert.Equal(t, float64(1), report["runs"])
+	assert.Equal(t, float64(2), report["products"])
+
+	run, ok := s.runs.get("mixed")
+	require.True(t, ok)
+	require.Len(t, run.Result.Stores, 1)
+	assert.Equal(t, "westside.com", run.Result.Stores[0].StoreName)
+	assert.Len(t, result.Stores, 2, "stored results are replaced, not modified")
+	_, ok = s.runs.get("old")
+	assert.False(t, ok)
+	assert.Equal(t, []catalog.StoreSummary{{StoreName: "westside.com", Products: 1}}, s.catalog.Stores())
+}
+
+func TestHandleRuns_Diff(t *testing.T) {
+	extraction := &fakeExtraction{products: map[string][]types.Product{"westside.com": testProducts("westside.com", 3)}}
+	s := newTestServer(extraction)
+
+	var first, second APIResponse
+	serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["westside.com"]}`, &first)
+	extraction.products["westside.com"] = testProducts("westside.com", 2)
+	serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["westside.com"]}`, &second)
+
+	var response RunResponse
+	w := serve(t, s.handleRuns, "GET", "/runs/"+second.RunID+"/diff", "", &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	stores := response.Data.(map[string]interface{})["stores"].([]interface{})
+	require.Len(t, stores, 1)
+	diff := stores[0].(map[string]interface{})
+	assert.Equal(t, float64(2), diff["active"])
+	assert.Equal(t, []interface{}{"https://westside.com/products/item-c"}, diff["removed"])
+
+	var products RunResponse
+	w = serve(t, s.handleProducts, "GET", "/products?state=removed", "", &products)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, float64(1), products.Data.(map[string]interface{})["total"])
+
+	w = serve(t, s.handleProducts, "GET", "/products?state=gone", "", &products)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = serve(t, s.handleRuns, "GET", "/runs/unknown/diff", "", &response)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandleEvents(t *testing.T) {
+	s := newTestServer(&fakeExtraction{
+		products: map[string][]types.Product{"westside.com": testProducts("westside.com", 2)},
+		errs:     map[string]error{"broken.com": errors.New("store unreachable")},
+	})
+	server := httptest.NewServer(withRequestID(s.handleEvents))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// The subscription is registered before the response headers are sent
+	var response APIResponse
+	w := serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["westside.com", "broken.com"]}`, &response)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	events := map[string][]string{}
+	scanner := bufio.NewScanner(resp.Body)
+	var name string
+	for len(events[EventRun]) == 0 && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			events[name] = append(events[name], strings.TrimPrefix(line, "data: "))
+		}
+	}
+	require.NoError(t, scanner.Err())
+
+	assert.NotEmpty(t, events[EventStats])
+	require.Len(t, events[EventStore], 2)
+	var store StoreEvent
+	require.NoError(t, json.Unmarshal([]byte(events[EventStore][0]), &store))
+	assert.Equal(t, StoreEvent{RunID: response.RunID, StoreName: "westside.com", Products: 2}, store)
+	require.NoError(t, json.Unmarshal([]byte(events[EventStore][1]), &store))
+	assert.Equal(t, "store unreachable", store.Error)
+
+	var run RunEvent
+	require.NoError(t, json.Unmarshal([]byte(events[EventRun][0]), &run))
+	assert.Equal(t, RunEvent{RunID: response.RunID, Stores: 2, Products: 2}, run)
+}
+
+func TestHandleRuns_Progress(t *testing.T) {
+	extraction := &fakeExtraction{products: map[string][]types.Product{"westside.com": testProducts("westside.com", 2)}}
+	s := newTestServer(extraction)
+
+	var status RunProgress
+	var code int
+	extraction.during = func(ctx context.Context, config *types.Config) {
+		progress := stats.ProgressFrom(ctx)
+		progress.RecordDiscovered(4)
+		progress.RecordCompleted(false)
+		progress.RecordCompleted(true)
+
+		var response struct {
+			Data RunProgress `json:"data"`
+		}
+		code = serve(t, s.handleRuns, "GET", "/runs/"+config.RunID, "", &response).Code
+		status = response.Data
+	}
+
+	var response APIResponse
+	serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["westside.com"]}`, &response)
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, response.RunID, status.RunID)
+	assert.Equal(t, RunStatusRunning, status.Status)
+	assert.Equal(t, "westside.com", status.CurrentStore)
+	assert.Equal(t, int64(4), status.Progress.Discovered)
+	assert.Equal(t, int64(2), status.Progress.Completed)
+	assert.Equal(t, int64(1), status.Progress.Failed)
+	assert.Equal(t, 50.0, status.Progress.Percent)
+	require.NotNil(t, status.Progress.ETASeconds)
+
+	// Once stored, the run's result is returned
+	w := serve(t, s.handleRuns, "GET", "/runs/"+response.RunID, "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, s.active.list())
+}
+
+func TestHandleUI(t *testing.T) {
+	s := newTestServer(&fakeExtraction{})
+
+	w := serve(t, s.handleUI, "GET", "/ui", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "new EventSource(")
+
+	w = serve(t, s.handleUI, "GET", "/ui/missing.js", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serve(t, s.handleUI, "POST", "/ui", "", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
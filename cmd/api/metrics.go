@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"shopify-extractor/stats"
+)
+
+// metricPrefix namespaces the Prometheus metrics of the server
+const metricPrefix = "shopify_extractor_"
+
+// storeCounters are the per-store counters exported as Prometheus counters
+var storeCounters = []struct {
+	name  string
+	help  string
+	value func(stats.Snapshot) int64
+}{
+	{"products_discovered_total", "Product URLs discovered.", func(s stats.Snapshot) int64 { return s.ProductsDiscovered }},
+	{"products_processed_total", "Product pages processed.", func(s stats.Snapshot) int64 { return s.ProductsProcessed }},
+	{"products_with_charts_total", "Products that yielded at least one size chart.", func(s stats.Snapshot) int64 { return s.ProductsWithCharts }},
+	{"products_failed_total", "Products whose extraction failed.", func(s stats.Snapshot) int64 { return s.ProductsFailed }},
+	{"products_timed_out_total", "Failed products that hit their deadline.", func(s stats.Snapshot) int64 { return s.ProductsTimedOut }},
+	{"charts_extracted_total", "Size charts extracted.", func(s stats.Snapshot) int64 { return s.ChartsExtracted }},
+}
+
+// labelEscaper escapes Prometheus label values
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// handleMetrics exposes the extraction statistics in the Prometheus text format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.Header().Set("Content-Type", "application/json")
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	writeMetrics(w, s.stats.Snapshot())
+}
+
+// writeMetrics writes the per-store counters and the size charts per store and chart
+// parser, in a stable order
+func writeMetrics(w io.Writer, snapshot stats.Stats) {
+	stores := make([]string, 0, len(snapshot.Stores))
+	for store := range snapshot.Stores {
+		stores = append(stores, store)
+	}
+	sort.Strings(stores)
+
+	for _, counter := range storeCounters {
+		fmt.Fprintf(w, "# HELP %s%s %s\n# TYPE %s%s counter\n", metricPrefix, counter.name, counter.help, metricPrefix, counter.name)
+		for _, store := range stores {
+			fmt.Fprintf(w, "%s%s{store=\"%s\"} %d\n", metricPrefix, counter.name, labelEscaper.Replace(store), counter.value(snapshot.Stores[store]))
+		}
+	}
+
+	name := metricPrefix + "charts_by_parser_total"
+	fmt.Fprintf(w, "# HELP %s Size charts extracted, by chart parser.\n# TYPE %s counter\n", name, name)
+	for _, store := range stores {
+		byParser := snapshot.Stores[store].ChartsByParser
+		parsers := make([]string, 0, len(byParser))
+		for parser := range byParser {
+			parsers = append(parsers, parser)
+		}
+		sort.Strings(parsers)
+		for _, parser := range parsers {
+			fmt.Fprintf(w, "%s{store=\"%s\",parser=\"%s\"} %d\n", name, labelEscaper.Replace(store), labelEscaper.Replace(parser), byParser[parser])
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"shopify-extractor/internal/types"
+)
+
+// maxPageSize caps how many products a page of a run result holds
+const maxPageSize = 1000
+
+// pageToken is the decoded form of the opaque next_token of a paged run result
+type pageToken struct {
+	Run    string `json:"run"`
+	Offset int    `json:"offset"`
+	Size   int    `json:"size"`
+}
+
+func encodePageToken(t pageToken) string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(token string) (pageToken, error) {
+	var t pageToken
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return t, fmt.Errorf("malformed next_token")
+	}
+	if err := json.Unmarshal(data, &t); err != nil || t.Offset < 0 || t.Size < 1 || t.Size > maxPageSize {
+		return t, fmt.Errorf("malformed next_token")
+	}
+	return t, nil
+}
+
+// pageQuery reads the page_size and next_token query parameters of GET /runs/{id}. ok is
+// false when the client asked for the whole result.
+func pageQuery(runID string, query url.Values) (page pageToken, ok bool, failures []ValidationError) {
+	page = pageToken{Run: runID}
+	if token := query.Get("next_token"); token != "" {
+		decoded, err := decodePageToken(token)
+		if err != nil || decoded.Run != runID {
+			return page, false, []ValidationError{{Field: "next_token", Message: "is not a token of this run"}}
+		}
+		page, ok = decoded, true
+	}
+	if value := query.Get("page_size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > maxPageSize {
+			return page, false, []ValidationError{{Field: "page_size", Message: fmt.Sprintf("must be between 1 and %d", maxPageSize)}}
+		}
+		page.Size, ok = size, true
+	}
+	return page, ok, nil
+}
+
+// pageResult returns the page of result holding the products from page.Offset on, at
+// most page.Size of them, with the token of the following page ("" on the last page).
+// Products are counted across stores in order. A store is listed on every page holding
+// some of its products; its error, missing charts and coverage come with its first page,
+// as do the portfolios with the first page of the run.
+func pageResult(result *types.ExtractionResult, page pageToken) (*types.ExtractionResult, string) {
+	end := page.Offset + page.Size
+	paged := &types.ExtractionResult{SchemaVersion: result.SchemaVersion, RunID: result.RunID, Stores: []types.StoreResult{}, Charts: result.Charts}
+	if page.Offset == 0 {
+		paged.Portfolios = result.Portfolios
+	}
+
+	total := 0
+	for _, store := range result.Stores {
+		total += len(store.Products)
+	}
+
+	position := 0
+	for _, store := range result.Stores {
+		start, count := position, len(store.Products)
+		position += count
+
+		// A store without products belongs to the page its position falls on, stores
+		// after the last product to the last page
+		if count == 0 {
+			if start >= page.Offset && (start < end || end >= total) {
+				paged.Stores = append(paged.Stores, store)
+			}
+			continue
+		}
+		if start+count <= page.Offset || start >= end {
+			continue
+		}
+
+		from, to := maxInt(page.Offset-start, 0), minInt(end-start, count)
+		entry := types.StoreResult{StoreName: store.StoreName, Products: store.Products[from:to]}
+		if from == 0 {
+			entry.Error, entry.MissingCharts, entry.Coverage = store.Error, store.MissingCharts, store.Coverage
+		}
+		paged.Stores = append(paged.Stores, entry)
+	}
+
+	if end >= total {
+		return paged, ""
+	}
+	return paged, encodePageToken(pageToken{Run: page.Run, Offset: end, Size: page.Size})
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"shopify-extractor/extractor"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/stats"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultMaxIdlePerStore bounds how many idle extractors (and their browsers) are kept per store
+const defaultMaxIdlePerStore = 2
+
+// pooledExtractor is an extractor checked out of an extractorPool. config is the copy the
+// extractor and its adapter read; it is only touched by the request holding the extractor.
+type pooledExtractor struct {
+	extractor.StoreExtractor
+	store      string
+	config     *types.Config
+	generation int
+}
+
+// extractorPool keeps store extractors, with their HTTP and browser clients, alive across
+// API requests. An extractor serves one request at a time: concurrent requests for the
+// same store get extractors of their own, returned to the pool when done.
+type extractorPool struct {
+	mu         sync.Mutex
+	idle       map[string][]*pooledExtractor
+	generation int // bumped by reset; extractors of older generations are closed on release
+	closed     bool
+
+	logger  *logrus.Logger
+	stats   *stats.Collector
+	maxIdle int
+
+	// newExtractor creates a store extractor, nil for unknown stores (extractor.New)
+	newExtractor func(store string, config *types.Config, logger types.Logger) extractor.StoreExtractor
+}
+
+// newExtractorPool creates a pool recording statistics to collector
+func newExtractorPool(logger *logrus.Logger, collector *stats.Collector) *extractorPool {
+	return &extractorPool{
+		idle:         make(map[string][]*pooledExtractor),
+		logger:       logger,
+		stats:        collector,
+		maxIdle:      defaultMaxIdlePerStore,
+		newExtractor: extractor.New,
+	}
+}
+
+// acquire checks out an extractor for the store, reusing an idle one when possible. The
+// run settings of config (run ID, sampling, crawl order) are applied to it; settings
+// shaping the clients come from the configuration the extractor was created with, which
+// reset retires after a reload. Pooled extractors log through the server logger, as they
+// outlive the request that created them.
+func (p *extractorPool) acquire(store string, config *types.Config) (*pooledExtractor, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("extractor pool is closed")
+	}
+	if idle := p.idle[store]; len(idle) > 0 {
+		pooled := idle[len(idle)-1]
+		p.idle[store] = idle[:len(idle)-1]
+		p.mu.Unlock()
+		applyRunSettings(pooled.config, config)
+		return pooled, nil
+	}
+	generation := p.generation
+	p.mu.Unlock()
+
+	owned := *config
+	storeExtractor := p.newExtractor(store, &owned, p.logger.WithField("store", store))
+	if storeExtractor == nil {
+		return nil, fmt.Errorf("%w: %s", errUnknownStore, store)
+	}
+	storeExtractor.SetStatsCollector(p.stats)
+	return &pooledExtractor{StoreExtractor: storeExtractor, store: store, config: &owned, generation: generation}, nil
+}
+
+// release returns an extractor to the pool, or closes it when the pool is closed, was
+// reset since the extractor was created or already holds enough idle extractors
+func (p *extractorPool) release(pooled *pooledExtractor) {
+	p.mu.Lock()
+	if p.closed || pooled.generation != p.generation || len(p.idle[pooled.store]) >= p.maxIdle {
+		p.mu.Unlock()
+		pooled.Close()
+		return
+	}
+	p.idle[pooled.store] = append(p.idle[pooled.store], pooled)
+	p.mu.Unlock()
+}
+
+// reset closes the idle extractors and retires the ones in use, so every extractor is
+// created anew from the current configuration
+func (p *extractorPool) reset() {
+	p.mu.Lock()
+	p.generation++
+	idle := p.idle
+	p.idle = make(map[string][]*pooledExtractor)
+	p.mu.Unlock()
+	closeAll(idle)
+}
+
+// close closes every idle extractor; extractors still in use are closed on release
+func (p *extractorPool) close() {
+	p.mu.Lock()
+	p.closed = true
+	idle := p.idle
+	p.idle = make(map[string][]*pooledExtractor)
+	p.mu.Unlock()
+	closeAll(idle)
+}
+
+// closeAll closes the extractors of every store
+func closeAll(idle map[string][]*pooledExtractor) {
+	for _, extractors := range idle {
+		for _, pooled := range extractors {
+			pooled.Close()
+		}
+	}
+}
+
+// applyRunSettings copies the per-run settings of an API request (including the discovery
+// budget, which depends on the request timeout) onto a pooled extractor's configuration
+func applyRunSettings(dst, src *types.Config) {
+	dst.RunID = src.RunID
+	dst.IncludeRaw = src.IncludeRaw
+	dst.Trace = src.Trace
+	dst.SampleRate = src.SampleRate
+	dst.SampleCount = src.SampleCount
+	dst.SampleSeed = src.SampleSeed
+	dst.CrawlOrder = src.CrawlOrder
+	dst.CrawlSeed = src.CrawlSeed
+	dst.DiscoveryTimeout = src.DiscoveryTimeout
+}
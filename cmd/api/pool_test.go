@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"shopify-extractor/extractor"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/stats"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// poolExtractor is a StoreExtractor counting how often it is closed
+type poolExtractor struct {
+	config *types.Config
+	closed int32
+}
+
+func (f *poolExtractor) ExtractAll(ctx context.Context) ([]types.Product, error) { return nil, nil }
+func (f *poolExtractor) DiscoverProductURLs(ctx context.Context) ([]string, error) {
+	return []string{"https://" + f.config.RunID + "/products/a", "https://" + f.config.RunID + "/products/b"}, nil
+}
+func (f *poolExtractor) ExtractProduct(ctx context.Context, productURL string) (*types.Product, error) {
+	return &types.Product{ProductURL: productURL, SizeCharts: []*types.SizeChart{{Headers: []string{"Size"}}}}, nil
+}
+func (f *poolExtractor) MissingCharts() map[string][]types.MissingProduct { return nil }
+func (f *poolExtractor) Coverage() *types.Coverage                        { return nil }
+func (f *poolExtractor) DiscoveryTruncated() bool                         { return false }
+func (f *poolExtractor) WrittenProducts() int                             { return 0 }
+func (f *poolExtractor) SetStatsCollector(c *stats.Collector)             {}
+func (f *poolExtractor) SetResultWriter(w extractor.ResultWriter)         {}
+func (f *poolExtractor) Close()                                           { atomic.AddInt32(&f.closed, 1) }
+
+// newTestPool returns a pool creating poolExtractors for every store but "unknown"
+func newTestPool() (*extractorPool, *int32) {
+	var created int32
+	pool := newExtractorPool(logrus.New(), stats.NewCollector())
+	pool.newExtractor = func(store string, config *types.Config, logger types.Logger) extractor.StoreExtractor {
+		if store == "unknown" {
+			return nil
+		}
+		atomic.AddInt32(&created, 1)
+		return &poolExtractor{config: config}
+	}
+	return pool, &created
+}
+
+func TestExtractorPool_ReusesReleasedExtractor(t *testing.T) {
+	pool, created := newTestPool()
+
+	first, err := pool.acquire("westside.com", &types.Config{RunID: "run-1"})
+	require.NoError(t, err)
+	pool.release(first)
+
+	second, err := pool.acquire("westside.com", &types.Config{RunID: "run-2", SampleCount: 5})
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(created))
+
+	// The run settings of the new request reach the extractor's configuration
+	config := second.StoreExtractor.(*poolExtractor).config
+	assert.Equal(t, "run-2", config.RunID)
+	assert.Equal(t, 5, config.SampleCount)
+}
+
+func TestExtractorPool_RunSettingsPerRequest(t *testing.T) {
+	pool, _ := newTestPool()
+
+	first, err := pool.acquire("westside.com", &types.Config{RunID: "run-1", IncludeRaw: true, Trace: true})
+	require.NoError(t, err)
+	assert.True(t, first.config.IncludeRaw)
+	assert.True(t, first.config.Trace)
+	pool.release(first)
+
+	// A reused extractor takes the settings of the request acquiring it, not of the one
+	// that created it
+	second, err := pool.acquire("westside.com", &types.Config{RunID: "run-2"})
+	require.NoError(t, err)
+	require.Same(t, first, second)
+	assert.False(t, second.config.IncludeRaw)
+	assert.False(t, second.config.Trace)
+	pool.release(second)
+
+	third, err := pool.acquire("westside.com", &types.Config{RunID: "run-3", IncludeRaw: true, Trace: true})
+	require.NoError(t, err)
+	require.Same(t, first, third)
+	assert.True(t, third.config.IncludeRaw)
+	assert.True(t, third.config.Trace)
+}
+
+func TestExtractorPool_UnknownStore(t *testing.T) {
+	pool, _ := newTestPool()
+
+	_, err := pool.acquire("unknown", &types.Config{})
+	assert.True(t, errors.Is(err, errUnknownStore))
+}
+
+func TestExtractorPool_ConcurrentAcquiresGetOwnExtractors(t *testing.T) {
+	pool, created := newTestPool()
+
+	const workers = 8
+	got := make([]*pooledExtractor, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pooled, err := pool.acquire("westside.com", &types.Config{})
+			assert.NoError(t, err)
+			got[i] = pooled
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[*pooledExtractor]bool)
+	for _, pooled := range got {
+		assert.False(t, seen[pooled], "extractor handed out twice")
+		seen[pooled] = true
+	}
+	assert.Equal(t, int32(workers), atomic.LoadInt32(created))
+
+	// Only maxIdle extractors are kept, the others are closed on release
+	for _, pooled := range got {
+		pool.release(pooled)
+	}
+	closed := 0
+	for _, pooled := range got {
+		closed += int(atomic.LoadInt32(&pooled.StoreExtractor.(*poolExtractor).closed))
+	}
+	assert.Equal(t, workers-defaultMaxIdlePerStore, closed)
+}
+
+func TestExtractorPool_ResetRetiresExtractors(t *testing.T) {
+	pool, created := newTestPool()
+
+	idle, err := pool.acquire("westside.com", &types.Config{})
+	require.NoError(t, err)
+	busy, err := pool.acquire("westside.com", &types.Config{})
+	require.NoError(t, err)
+	pool.release(idle)
+
+	pool.reset()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&idle.StoreExtractor.(*poolExtractor).closed))
+
+	// An extractor in use during the reset is closed instead of pooled
+	pool.release(busy)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&busy.StoreExtractor.(*poolExtractor).closed))
+
+	fresh, err := pool.acquire("westside.com", &types.Config{})
+	require.NoError(t, err)
+	assert.NotSame(t, idle, fresh)
+	assert.NotSame(t, busy, fresh)
+	assert.Equal(t, int32(3), atomic.LoadInt32(created))
+}
+
+func TestExtractorPool_Close(t *testing.T) {
+	pool, _ := newTestPool()
+
+	idle, err := pool.acquire("westside.com", &types.Config{})
+	require.NoError(t, err)
+	busy, err := pool.acquire("suqah.com", &types.Config{})
+	require.NoError(t, err)
+	pool.release(idle)
+
+	pool.close()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&idle.StoreExtractor.(*poolExtractor).closed))
+
+	pool.release(busy)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&busy.StoreExtractor.(*poolExtractor).closed))
+
+	_, err = pool.acquire("westside.com", &types.Config{})
+	assert.Error(t, err)
+}
+
+func TestExtractorService_Hooks(t *testing.T) {
+	const store = "hooks.test"
+	extractor.RegisterHooks(extractor.Hooks{
+		OnProductDiscovered: func(ctx context.Context, s, productURL string) bool {
+			return s != store || productURL != "https://hooks.test/products/b"
+		},
+		OnChartExtracted: func(ctx context.Context, s string, product *types.Product) bool {
+			if s != store {
+				return true
+			}
+			product.Attributes = map[string]string{"sku": "A-1"}
+			return product.ProductURL != "https://hooks.test/products/c"
+		},
+	})
+
+	pool, _ := newTestPool()
+	service := newExtractorService(stats.NewCollector(), pool)
+	config := &types.Config{RunID: store}
+	logger := logrus.New()
+
+	productURLs, err := service.DiscoverProductURLs(context.Background(), store, config, logger)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://hooks.test/products/a"}, productURLs)
+
+	extracted := map[string]*types.Product{}
+	err = service.ExtractProducts(context.Background(), store, []string{"https://hooks.test/products/a", "https://hooks.test/products/c"}, config, logger,
+		func(productURL string, product *types.Product, err error) { extracted[productURL] = product })
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"sku": "A-1"}, extracted["https://hooks.test/products/a"].Attributes)
+	assert.Len(t, extracted["https://hooks.test/products/a"].SizeCharts, 1)
+	assert.Empty(t, extracted["https://hooks.test/products/c"].SizeCharts)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"shopify-extractor/extractor"
+	"shopify-extractor/internal/types"
+)
+
+// prewarmTimeout bounds the startup prewarming of all stores
+const prewarmTimeout = 2 * time.Minute
+
+// parsePrewarmStores reads PREWARM_STORES: a comma-separated list of store domains, or
+// "all" for every supported store
+func parsePrewarmStores(value string) []string {
+	var stores []string
+	for _, store := range strings.Split(value, ",") {
+		if store = strings.TrimSpace(store); store != "" {
+			stores = append(stores, store)
+		}
+	}
+	return stores
+}
+
+// prewarm readies the stores named by PREWARM_STORES so the first request does not pay
+// for the cold start: each store's host is resolved and a pooled extractor is created,
+// connected to the store (robots.txt and sitemap.xml fetched) and left idle in the pool.
+// The browser itself is launched by the readiness check. Failures are logged only.
+func (s *Server) prewarm(ctx context.Context) {
+	stores := s.prewarmStores
+	if len(stores) == 1 && strings.EqualFold(stores[0], "all") {
+		// Resolved here, as plugin adapters are registered after NewServer
+		stores = extractor.Domains()
+	}
+	if len(stores) == 0 || s.pool == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, prewarmTimeout)
+	defer cancel()
+
+	config := s.currentConfig()
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, store := range stores {
+		wg.Add(1)
+		go func(store string) {
+			defer wg.Done()
+			s.prewarmStore(ctx, store, &config)
+		}(store)
+	}
+	wg.Wait()
+	s.logger.Infof("Prewarmed %d stores in %v", len(stores), time.Since(start).Round(time.Millisecond))
+}
+
+// prewarmStore resolves the store's host and leaves a connected extractor in the pool
+func (s *Server) prewarmStore(ctx context.Context, store string, config *types.Config) {
+	logger := s.logger.WithField("store", store)
+	start := time.Now()
+
+	if _, err := net.DefaultResolver.LookupHost(ctx, store); err != nil {
+		logger.Warnf("Prewarm: failed to resolve %s: %v", store, err)
+		return
+	}
+
+	pooled, err := s.pool.acquire(store, config)
+	if err != nil {
+		logger.Warnf("Prewarm: %v", err)
+		return
+	}
+	defer s.pool.release(pooled)
+
+	if warmer, ok := pooled.StoreExtractor.(extractor.Prewarmer); ok {
+		if err := warmer.Prewarm(ctx); err != nil {
+			logger.Warnf("Prewarm: %v", err)
+			return
+		}
+	}
+	logger.Infof("Prewarmed %s in %v", store, time.Since(start).Round(time.Millisecond))
+}
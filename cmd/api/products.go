@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"shopify-extractor/catalog"
+	"shopify-extractor/internal/types"
+)
+
+// ProductsPage is the response payload of the product listing endpoints
+type ProductsPage struct {
+	Products   []*catalog.Product `json:"products"`
+	Total      int                `json:"total"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// ProductCharts is the response payload of GET /products/{id}/charts
+type ProductCharts struct {
+	ID         string             `json:"id"`
+	ProductURL string             `json:"product_url"`
+	SizeCharts []*types.SizeChart `json:"size_chart"`
+}
+
+// sendData writes a successful JSON response
+func (s *Server) sendData(w http.ResponseWriter, data interface{}) {
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(RunResponse{Success: true, Data: data}); err != nil {
+		s.logger.Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// parseProductQuery reads the filter and paging parameters of a product listing
+func parseProductQuery(values url.Values) (catalog.Query, []ValidationError) {
+	query := catalog.Query{
+		Store: values.Get("store"),
+		Size:  values.Get("size"),
+	}
+	var failures []ValidationError
+
+	if state := values.Get("state"); state != "" {
+		valid := false
+		for _, known := range catalog.States() {
+			valid = valid || state == known
+		}
+		if !valid {
+			failures = append(failures, ValidationError{Field: "state", Message: "must be one of: " + strings.Join(catalog.States(), ", ")})
+		}
+		query.State = state
+	}
+
+	for key, vals := range values {
+		filter, ok, err := catalog.ParseMeasurementFilter(key, vals[0])
+		if !ok {
+			continue
+		}
+		if err != nil {
+			failures = append(failures, ValidationError{Field: key, Message: "must be a number"})
+			continue
+		}
+		query.Measurements = append(query.Measurements, filter)
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > catalog.MaxLimit {
+			failures = append(failures, ValidationError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", catalog.MaxLimit)})
+		}
+		query.Limit = n
+	}
+	if cursor := values.Get("cursor"); cursor != "" {
+		offset, err := catalog.DecodeCursor(cursor)
+		if err != nil {
+			failures = append(failures, ValidationError{Field: "cursor", Message: err.Error()})
+		}
+		query.Offset = offset
+	}
+	return query, failures
+}
+
+// sendProducts runs a product query and writes one page of results
+func (s *Server) sendProducts(w http.ResponseWriter, r *http.Request, store string) {
+	query, failures := parseProductQuery(r.URL.Query())
+	if len(failures) > 0 {
+		s.sendValidationError(w, failures)
+		return
+	}
+	if store != "" {
+		query.Store = store
+	}
+
+	page := s.catalog.Query(query)
+	data := ProductsPage{Products: page.Products, Total: page.Total}
+	if page.HasMore {
+		data.NextCursor = catalog.EncodeCursor(page.NextOffset)
+	}
+	s.sendData(w, data)
+}
+
+// handleStores serves GET /stores, GET /stores/{domain}/products and
+// GET /stores/{domain}/capabilities
+func (s *Server) handleStores(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.applyCORS(w, r) {
+		return
+	}
+	if r.Method != "GET" {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/stores"), "/"), "/")
+	switch {
+	case parts[0] == "" && len(parts) == 1:
+		s.sendData(w, s.catalog.Stores())
+	case len(parts) == 2 && (parts[1] == "products" || parts[1] == "capabilities"):
+		if msg := validateStoreDomain(parts[0]); msg != "" {
+			s.sendValidationError(w, []ValidationError{{Field: "domain", Message: msg}})
+			return
+		}
+		if parts[1] == "capabilities" {
+			s.sendCapabilities(w, parts[0])
+			return
+		}
+		s.sendProducts(w, r, parts[0])
+	default:
+		s.sendError(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// handleProducts serves GET /products, GET /products/{id} and GET /products/{id}/charts
+func (s *Server) handleProducts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.applyCORS(w, r) {
+		return
+	}
+	if r.Method != "GET" {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/products"), "/"), "/")
+	if parts[0] == "" && len(parts) == 1 {
+		s.sendProducts(w, r, "")
+		return
+	}
+	if len(parts) > 2 || (len(parts) == 2 && parts[1] != "charts") {
+		s.sendError(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	product, ok := s.catalog.Get(parts[0])
+	if !ok {
+		s.sendError(w, "Product not found", http.StatusNotFound)
+		return
+	}
+	if len(parts) == 2 {
+		s.sendData(w, ProductCharts{ID: product.ID, ProductURL: product.ProductURL, SizeCharts: product.SizeCharts})
+		return
+	}
+	s.sendData(w, product)
+}
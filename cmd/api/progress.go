@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"shopify-extractor/stats"
+)
+
+// RunStatusRunning is the status of a run still extracting
+const RunStatusRunning = "running"
+
+// RunProgress is the status of a running extraction, returned by GET /runs/{id} until
+// the run completes and sent as "progress" events on GET /events
+type RunProgress struct {
+	RunID        string                 `json:"run_id"`
+	Status       string                 `json:"status"`
+	Stores       []string               `json:"stores"`
+	CurrentStore string                 `json:"current_store,omitempty"`
+	StartedAt    time.Time              `json:"started_at"`
+	Progress     stats.ProgressSnapshot `json:"progress"`
+}
+
+// activeRun is an extraction in progress
+type activeRun struct {
+	id        string
+	stores    []string
+	startedAt time.Time
+	progress  *stats.Progress
+
+	mu      sync.Mutex
+	current string
+}
+
+// setStore records the store being extracted
+func (r *activeRun) setStore(store string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.current = store
+}
+
+// status returns the run's progress
+func (r *activeRun) status() RunProgress {
+	r.mu.Lock()
+	current := r.current
+	r.mu.Unlock()
+	return RunProgress{
+		RunID:        r.id,
+		Status:       RunStatusRunning,
+		Stores:       r.stores,
+		CurrentStore: current,
+		StartedAt:    r.startedAt,
+		Progress:     r.progress.Snapshot(),
+	}
+}
+
+// activeRuns tracks the extractions in progress. A nil *activeRuns tracks nothing.
+type activeRuns struct {
+	mu   sync.Mutex
+	runs map[string]*activeRun
+}
+
+func newActiveRuns() *activeRuns {
+	return &activeRuns{runs: make(map[string]*activeRun)}
+}
+
+// start registers a run and returns it with a context recording its progress; the
+// caller calls finish once the run completed
+func (a *activeRuns) start(ctx context.Context, id string, stores []string) (*activeRun, context.Context) {
+	run := &activeRun{id: id, stores: stores, startedAt: time.Now(), progress: stats.NewProgress()}
+	if a != nil {
+		a.mu.Lock()
+		a.runs[id] = run
+		a.mu.Unlock()
+	}
+	return run, stats.ContextWithProgress(ctx, run.progress)
+}
+
+// finish forgets a completed run
+func (a *activeRuns) finish(id string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.runs, id)
+}
+
+// get returns the status of a running run
+func (a *activeRuns) get(id string) (RunProgress, bool) {
+	if a == nil {
+		return RunProgress{}, false
+	}
+	a.mu.Lock()
+	run, ok := a.runs[id]
+	a.mu.Unlock()
+	if !ok {
+		return RunProgress{}, false
+	}
+	return run.status(), true
+}
+
+// list returns the status of every running run, oldest first
+func (a *activeRuns) list() []RunProgress {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	runs := make([]*activeRun, 0, len(a.runs))
+	for _, run := range a.runs {
+		runs = append(runs, run)
+	}
+	a.mu.Unlock()
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].startedAt.Before(runs[j].startedAt) })
+	statuses := make([]RunProgress, len(runs))
+	for i, run := range runs {
+		statuses[i] = run.status()
+	}
+	return statuses
+}
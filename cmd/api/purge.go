@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"shopify-extractor/archive"
+	"shopify-extractor/extractor"
+	"shopify-extractor/utils"
+)
+
+// PurgeRequest is the body of a POST /admin/purge request
+type PurgeRequest struct {
+	// OlderThanDays removes data stored more than this many days ago (0 = any age)
+	OlderThanDays int `json:"older_than_days"`
+
+	// Deregistered removes the data of stores no longer served by any extractor
+	Deregistered bool `json:"deregistered"`
+}
+
+// PurgeReport counts what a purge removed
+type PurgeReport struct {
+	Runs     int                 `json:"runs"`
+	Products int                 `json:"products"`
+	Exports  int                 `json:"exports"`
+	Archive  archive.PurgeResult `json:"archive"`
+	WARC     archive.PurgeResult `json:"warc"`
+}
+
+// validatePurgeRequest checks a POST /admin/purge body
+func validatePurgeRequest(req *PurgeRequest) []ValidationError {
+	var failures []ValidationError
+	if req.OlderThanDays < 0 {
+		failures = append(failures, ValidationError{Field: "older_than_days", Message: "must not be negative"})
+	}
+	if req.OlderThanDays == 0 && !req.Deregistered {
+		failures = append(failures, ValidationError{Field: "older_than_days", Message: "is required unless deregistered is set"})
+	}
+	return failures
+}
+
+// retention turns a purge request into the retention policy it applies
+func (req *PurgeRequest) retention() utils.Retention {
+	retention := utils.Retention{MaxAge: time.Duration(req.OlderThanDays) * 24 * time.Hour}
+	if req.Deregistered {
+		retention.Registered = extractor.Supports
+	}
+	return retention
+}
+
+// purge removes the stored runs, catalog products, exports, archived pages and WARC
+// files the retention expires
+func (s *Server) purge(retention utils.Retention) (PurgeReport, error) {
+	var report PurgeReport
+	var err error
+	report.Runs = s.runs.purge(retention.Expired)
+	report.Exports = s.exports.purge(retention.Expired)
+	if report.Products, err = s.catalog.Purge(retention.Expired); err != nil {
+		return report, err
+	}
+
+	config := s.currentConfig()
+	if config.ArchiveDir != "" {
+		if _, statErr := os.Stat(config.ArchiveDir); statErr == nil {
+			a, err := archive.Open(config.ArchiveDir)
+			if err != nil {
+				return report, err
+			}
+			if report.Archive, err = a.Purge(retention.Expired); err != nil {
+				return report, err
+			}
+		}
+	}
+	if config.WARCDir != "" {
+		if report.WARC, err = archive.PurgeWARC(config.WARCDir, retention.Expired); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// handlePurge serves POST /admin/purge, removing data older than older_than_days or
+// belonging to deregistered stores
+func (s *Server) handlePurge(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.applyCORS(w, r) {
+		return
+	}
+
+	if r.Method != "POST" {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req PurgeRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		s.sendRequestError(w, err)
+		return
+	}
+	if failures := validatePurgeRequest(&req); len(failures) > 0 {
+		s.sendValidationError(w, failures)
+		return
+	}
+
+	report, err := s.purge(req.retention())
+	if err != nil {
+		s.requestLogger(r).Errorf("Purge failed: %v", err)
+		s.sendError(w, "Purge failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.requestLogger(r).Infof("Purged %d runs, %d products, %d exports, %d archived pages and %d WARC files",
+		report.Runs, report.Products, report.Exports, report.Archive.Entries, report.WARC.Files)
+	s.sendData(w, report)
+}
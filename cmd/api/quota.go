@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"shopify-extractor/internal/types"
+)
+
+// anonymousKey accounts the usage of requests sent without an API key, which is only
+// possible when API_KEYS is not set
+const anonymousKey = "anonymous"
+
+// Quotas limits the work each API key may do per UTC day; 0 is unlimited
+type Quotas struct {
+	PagesPerDay int `json:"pages_per_day,omitempty"` // product pages fetched by extractions
+	JobsPerDay  int `json:"jobs_per_day,omitempty"`  // extraction and export jobs started
+}
+
+// LoadQuotas reads QUOTA_PAGES_PER_DAY and QUOTA_JOBS_PER_DAY
+func LoadQuotas() (Quotas, error) {
+	var quotas Quotas
+	for name, value := range map[string]*int{
+		"QUOTA_PAGES_PER_DAY": &quotas.PagesPerDay,
+		"QUOTA_JOBS_PER_DAY":  &quotas.JobsPerDay,
+	} {
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return Quotas{}, fmt.Errorf("invalid %s %q: must be a non-negative integer", name, v)
+		}
+		*value = n
+	}
+	return quotas, nil
+}
+
+// KeyUsage is the usage of one API key on one day
+type KeyUsage struct {
+	Key          string `json:"key"` // keyID of the API key, or "anonymous"
+	Role         string `json:"role"`
+	Date         string `json:"date"`
+	PagesFetched int    `json:"pages_fetched"`
+	Jobs         int    `json:"jobs"`
+	Quotas       Quotas `json:"quotas"`
+}
+
+// UsageReport is the response payload of GET /usage
+type UsageReport struct {
+	Date string     `json:"date"`
+	Keys []KeyUsage `json:"keys"`
+}
+
+// usageTracker counts the pages fetched and jobs run per API key for the current UTC
+// day. Counts are kept in memory and start over at midnight.
+type usageTracker struct {
+	quotas Quotas
+	now    func() time.Time
+
+	mu    sync.Mutex
+	date  string
+	usage map[string]*KeyUsage
+}
+
+func newUsageTracker(quotas Quotas) *usageTracker {
+	return &usageTracker{quotas: quotas, now: time.Now, usage: make(map[string]*KeyUsage)}
+}
+
+// entry returns the usage of a key today, starting a new day when the date changed.
+// The caller holds mu.
+func (u *usageTracker) entry(c caller) *KeyUsage {
+	date := u.now().UTC().Format("2006-01-02")
+	if date != u.date {
+		u.date = date
+		u.usage = make(map[string]*KeyUsage)
+	}
+	usage, ok := u.usage[c.key]
+	if !ok {
+		usage = &KeyUsage{Key: c.key, Role: c.role.String(), Date: date, Quotas: u.quotas}
+		u.usage[c.key] = usage
+	}
+	return usage
+}
+
+// startJob counts a new job for the caller, or returns an error when the caller used
+// up a daily quota
+func (u *usageTracker) startJob(c caller) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	usage := u.entry(c)
+	if u.quotas.JobsPerDay > 0 && usage.Jobs >= u.quotas.JobsPerDay {
+		return fmt.Errorf("daily quota of %d jobs used up", u.quotas.JobsPerDay)
+	}
+	if u.quotas.PagesPerDay > 0 && usage.PagesFetched >= u.quotas.PagesPerDay {
+		return fmt.Errorf("daily quota of %d pages used up", u.quotas.PagesPerDay)
+	}
+	usage.Jobs++
+	return nil
+}
+
+// addPages counts pages fetched by one of the caller's jobs. A job started within quota
+// runs to completion, so the page count may end the day above the quota.
+func (u *usageTracker) addPages(c caller, pages int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.entry(c).PagesFetched += pages
+}
+
+// report returns today's usage of the caller, or of every key for admins
+func (u *usageTracker) report(c caller) UsageReport {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	own := *u.entry(c)
+	report := UsageReport{Date: u.date, Keys: []KeyUsage{own}}
+	if c.role < RoleAdmin {
+		return report
+	}
+	report.Keys = report.Keys[:0]
+	for _, usage := range u.usage {
+		report.Keys = append(report.Keys, *usage)
+	}
+	sort.Slice(report.Keys, func(i, j int) bool { return report.Keys[i].Key < report.Keys[j].Key })
+	return report
+}
+
+// resetIn returns the time left until the quotas reset at the next UTC midnight
+func (u *usageTracker) resetIn() time.Duration {
+	now := u.now().UTC()
+	return now.Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+}
+
+// caller identifies the API key a request was authorized with
+type caller struct {
+	key  string
+	role Role
+}
+
+type callerKey struct{}
+
+// keyID identifies an API key in usage reports and logs without revealing it
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return "key-" + hex.EncodeToString(sum[:6])
+}
+
+// withCaller records the API key that authorized the request, see requestCaller
+func withCaller(r *http.Request, c caller) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), callerKey{}, c))
+}
+
+// requestCaller returns the caller of a request. Without API keys every request is
+// anonymous and may see all usage.
+func requestCaller(r *http.Request) caller {
+	if c, ok := r.Context().Value(callerKey{}).(caller); ok {
+		return c
+	}
+	return caller{key: anonymousKey, role: RoleAdmin}
+}
+
+// startJob counts a job against the caller's quota, answering 429 and returning false
+// when it is used up
+func (s *Server) startJob(w http.ResponseWriter, r *http.Request) bool {
+	err := s.usage.startJob(requestCaller(r))
+	if err == nil {
+		return true
+	}
+	retryAfter := s.usage.resetIn()
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+	s.sendAPIError(w, http.StatusTooManyRequests, &APIError{
+		Code:      CodeQuotaExceeded,
+		Message:   fmt.Sprintf("Quota exceeded: %v, resets in %v", err, retryAfter.Round(time.Minute)),
+		Retryable: true,
+	})
+	return false
+}
+
+// pagesFetched returns the number of product pages an extraction fetched: the products
+// with a chart plus every product reported missing one
+func pagesFetched(result *types.ExtractionResult) int {
+	pages := 0
+	for _, store := range result.Stores {
+		pages += len(store.Products)
+		for _, missing := range store.MissingCharts {
+			pages += len(missing)
+		}
+	}
+	return pages
+}
+
+// handleUsage serves GET /usage, today's usage and quotas of the calling API key, or of
+// every key for admin keys
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.applyCORS(w, r) {
+		return
+	}
+	if r.Method != "GET" {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.sendData(w, s.usage.report(requestCaller(r)))
+}
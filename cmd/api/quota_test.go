@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func TestUsageTracker(t *testing.T) {
+	now := time.Date(2024, 5, 1, 23, 30, 0, 0, time.UTC)
+	usage := newUsageTracker(Quotas{PagesPerDay: 10, JobsPerDay: 2})
+	usage.now = func() time.Time { return now }
+	reader := caller{key: "key-reader", role: RoleReader}
+	admin := caller{key: "key-admin", role: RoleAdmin}
+
+	require.NoError(t, usage.startJob(reader))
+	usage.addPages(reader, 4)
+	require.NoError(t, usage.startJob(reader))
+	assert.EqualError(t, usage.startJob(reader), "daily quota of 2 jobs used up")
+
+	// Quotas are per key
+	require.NoError(t, usage.startJob(admin))
+	usage.addPages(admin, 12)
+	assert.EqualError(t, usage.startJob(admin), "daily quota of 10 pages used up")
+
+	assert.Equal(t, UsageReport{Date: "2024-05-01", Keys: []KeyUsage{
+		{Key: "key-reader", Role: "read", Date: "2024-05-01", PagesFetched: 4, Jobs: 2, Quotas: usage.quotas},
+	}}, usage.report(reader))
+	assert.Len(t, usage.report(admin).Keys, 2)
+	assert.Equal(t, 30*time.Minute, usage.resetIn())
+
+	// Usage starts over at midnight UTC
+	now = now.Add(time.Hour)
+	require.NoError(t, usage.startJob(reader))
+	assert.Equal(t, UsageReport{Date: "2024-05-02", Keys: []KeyUsage{
+		{Key: "key-reader", Role: "read", Date: "2024-05-02", Jobs: 1, Quotas: usage.quotas},
+	}}, usage.report(reader))
+}
+
+func TestHandleExtract_Quota(t *testing.T) {
+	s := newTestServer(&fakeExtraction{products: map[string][]types.Product{"westside.com": testProducts("westside.com", 3)}})
+	s.usage.quotas = Quotas{JobsPerDay: 1}
+
+	var response APIResponse
+	w := serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["westside.com"]}`, &response)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(t, s.handleExtract, "POST", "/extract", `{"stores": ["westside.com"]}`, &response)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, CodeQuotaExceeded, response.Error.Code)
+	assert.True(t, response.Error.Retryable)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	var usage struct {
+		Success bool        `json:"success"`
+		Data    UsageReport `json:"data"`
+	}
+	w = serve(t, s.handleUsage, "GET", "/usage", "", &usage)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, usage.Data.Keys, 1)
+	assert.Equal(t, anonymousKey, usage.Data.Keys[0].Key)
+	assert.Equal(t, 3, usage.Data.Keys[0].PagesFetched)
+	assert.Equal(t, 1, usage.Data.Keys[0].Jobs)
+}
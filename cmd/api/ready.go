@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"shopify-extractor/utils"
+)
+
+// readinessTimeout bounds the startup render check
+const readinessTimeout = 60 * time.Second
+
+// readiness records the outcome of the startup render check
+type readiness struct {
+	mu        sync.RWMutex
+	checked   bool
+	err       error
+	checkedAt time.Time
+}
+
+// set stores the result of a readiness check
+func (r *readiness) set(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checked = true
+	r.err = err
+	r.checkedAt = time.Now()
+}
+
+// get returns the result of the last readiness check
+func (r *readiness) get() (bool, error, time.Time) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.checked, r.err, r.checkedAt
+}
+
+// checkReadiness verifies that the headless browser can render a page. Servers with
+// the browser disabled are ready immediately.
+func (s *Server) checkReadiness(ctx context.Context) {
+	config := s.currentConfig()
+	if !config.UseHeadlessBrowser {
+		s.ready.set(nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := utils.NewBrowserClient(&config, s.logger).CheckRender(ctx)
+	if err != nil {
+		s.logger.Errorf("Readiness check failed: %v", err)
+	} else {
+		s.logger.Infof("Readiness check passed: browser rendered test page in %v", time.Since(start))
+	}
+	s.ready.set(err)
+}
+
+// handleReadyz reports whether the startup render check succeeded
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	checked, err, checkedAt := s.ready.get()
+
+	response := map[string]string{"status": "ready"}
+	status := http.StatusOK
+	switch {
+	case !checked:
+		response["status"] = "starting"
+		status = http.StatusServiceUnavailable
+	case err != nil:
+		response["status"] = "not_ready"
+		response["error"] = err.Error()
+		status = http.StatusServiceUnavailable
+	}
+	if checked {
+		response["checked_at"] = checkedAt.UTC().Format(time.RFC3339)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
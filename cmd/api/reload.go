@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"shopify-extractor/config"
+	"shopify-extractor/internal/types"
+)
+
+// defaultReloadInterval is how often the config files are polled for changes
+const defaultReloadInterval = 5 * time.Second
+
+// currentConfig returns a copy of the running configuration, safe to modify per request
+func (s *Server) currentConfig() types.Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return *s.config
+}
+
+// watchConfig reloads the configuration whenever the config file or the per-store
+// options file changes, until ctx is done
+func (s *Server) watchConfig(ctx context.Context) {
+	s.configMu.RLock()
+	settings := s.settings
+	s.configMu.RUnlock()
+	if settings == nil || s.reloadInterval <= 0 {
+		return
+	}
+	paths := settings.WatchedFiles()
+	if len(paths) == 0 {
+		return
+	}
+
+	s.logger.Infof("Watching %s for configuration changes", strings.Join(paths, ", "))
+	config.Watch(ctx, s.reloadInterval, paths, s.reloadConfig)
+}
+
+// reloadConfig applies the changes of the config files that are safe at runtime. Requests
+// already running keep the configuration they started with.
+func (s *Server) reloadConfig() {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	result, err := config.Reload(s.settings)
+	if err != nil {
+		s.logger.Errorf("Configuration reload failed, keeping the running configuration: %v", err)
+		return
+	}
+	for _, key := range result.Rejected {
+		s.logger.Warnf("Configuration reload: %s changed but is only read at startup; restart the server to apply it", key)
+	}
+	if len(result.Applied) == 0 {
+		s.logger.Info("Configuration reload: no changes to apply")
+		return
+	}
+
+	s.settings = result.Settings
+	s.config = result.Settings.Config
+	if s.pool != nil {
+		// Pooled extractors were built from the old configuration
+		s.pool.reset()
+	}
+	s.logger.Infof("Configuration reloaded: %s", strings.Join(result.Applied, ", "))
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+// requestIDHeader carries the request ID on requests and responses
+const requestIDHeader = "X-Request-ID"
+
+// requestIDPattern limits client-supplied request IDs to short printable tokens
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+type requestIDKey struct{}
+
+// withRequestID tags every request with an ID, reusing a valid X-Request-ID sent by the
+// client, and echoes it in the response so errors can be correlated with server logs
+func withRequestID(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRunID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	}
+}
+
+// requestID returns the ID assigned to the request by withRequestID
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestLogger returns the server logger tagged with the request ID, so every line
+// logged while serving the request, including by extractors, can be correlated
+func (s *Server) requestLogger(r *http.Request) *logrus.Entry {
+	return s.logger.WithField("request_id", requestID(r))
+}
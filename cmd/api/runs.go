@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+)
+
+// maxStoredRuns bounds how many completed runs the server keeps in memory
+const maxStoredRuns = 50
+
+// runRecord is a completed extraction run kept for later inspection
+type runRecord struct {
+	ID        string
+	CreatedAt time.Time
+	Result    *types.ExtractionResult
+}
+
+// runStore keeps the most recent extraction runs in memory, evicting the oldest first
+type runStore struct {
+	mu    sync.RWMutex
+	runs  map[string]*runRecord
+	order []string
+	max   int
+}
+
+func newRunStore(max int) *runStore {
+	return &runStore{
+		runs: make(map[string]*runRecord),
+		max:  max,
+	}
+}
+
+// newRunID generates a random run identifier
+func newRunID() string {
+	return utils.NewRunID()
+}
+
+// add stores the result of the run with the given ID
+func (rs *runStore) add(id string, result *types.ExtractionResult) *runRecord {
+	run := &runRecord{
+		ID:        id,
+		CreatedAt: time.Now(),
+		Result:    result,
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.runs[run.ID] = run
+	rs.order = append(rs.order, run.ID)
+	for len(rs.order) > rs.max {
+		delete(rs.runs, rs.order[0])
+		rs.order = rs.order[1:]
+	}
+	return run
+}
+
+// get returns a stored run
+func (rs *runStore) get(id string) (*runRecord, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	run, ok := rs.runs[id]
+	return run, ok
+}
+
+// lastSuccess returns the most recent stored run in which the store was extracted
+// without error, along with the store's result
+func (rs *runStore) lastSuccess(store string) (*runRecord, *types.StoreResult, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	for i := len(rs.order) - 1; i >= 0; i-- {
+		run := rs.runs[rs.order[i]]
+		for j := range run.Result.Stores {
+			result := &run.Result.Stores[j]
+			if types.CanonicalStore(result.StoreName) == types.CanonicalStore(store) && result.Error == "" {
+				return run, result, true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// purge drops the store results for which expired returns true from the stored runs,
+// and the runs left without stores, returning how many runs were removed
+func (rs *runStore) purge(expired func(store string, at time.Time) bool) int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	removed := 0
+	order := rs.order[:0]
+	for _, id := range rs.order {
+		run := rs.runs[id]
+		var stores []types.StoreResult
+		for _, store := range run.Result.Stores {
+			if !expired(store.StoreName, run.CreatedAt) {
+				stores = append(stores, store)
+			}
+		}
+		if len(stores) == 0 {
+			delete(rs.runs, id)
+			removed++
+			continue
+		}
+		if len(stores) < len(run.Result.Stores) {
+			// Readers may hold the record, so it is replaced rather than modified
+			result := *run.Result
+			result.Stores = stores
+			rs.runs[id] = &runRecord{ID: run.ID, CreatedAt: run.CreatedAt, Result: &result}
+		}
+		order = append(order, id)
+	}
+	rs.order = order
+	return removed
+}
+
+// StoreMissingCharts lists the products of one store that yielded no size chart
+type StoreMissingCharts struct {
+	StoreName     string                            `json:"store_name"`
+	TotalMissing  int                               `json:"total_missing"`
+	MissingCharts map[string][]types.MissingProduct `json:"missing_charts"`
+}
+
+// MissingReport is the response payload of GET /runs/{id}/missing
+type MissingReport struct {
+	RunID  string               `json:"run_id"`
+	Stores []StoreMissingCharts `json:"stores"`
+}
+
+// RunResponse represents the response of the /runs endpoints
+type RunResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   *APIError   `json:"error,omitempty"`
+
+	// NextToken fetches the next page of a paged run result
+	NextToken string `json:"next_token,omitempty"`
+}
+
+// handleRuns serves GET /runs/{id}, GET /runs/{id}/missing and GET /runs/{id}/diff. The
+// run result is paged with the page_size and next_token query parameters.
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.applyCORS(w, r) {
+		return
+	}
+
+	if r.Method != "GET" {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/runs/"), "/"), "/")
+	if parts[0] == "" || len(parts) > 2 || (len(parts) == 2 && parts[1] != "missing" && parts[1] != "diff") {
+		s.sendError(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	// Diffs are kept by the catalog, so a persisted catalog serves them after the run
+	// itself was evicted
+	if len(parts) == 2 && parts[1] == "diff" {
+		diff, err := s.catalog.Diff(parts[0])
+		if err != nil {
+			s.sendError(w, "Failed to load run diff: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if diff == nil {
+			s.sendError(w, "Run not found", http.StatusNotFound)
+			return
+		}
+		s.sendData(w, diff)
+		return
+	}
+
+	run, ok := s.runs.get(parts[0])
+	if !ok {
+		// A running extraction reports its progress until its result is stored
+		if status, running := s.active.get(parts[0]); running && len(parts) == 1 {
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(RunResponse{Success: true, Data: status})
+			return
+		}
+		s.sendError(w, "Run not found", http.StatusNotFound)
+		return
+	}
+
+	response := RunResponse{Success: true, Data: run.Result}
+	if len(parts) == 1 {
+		page, paged, failures := pageQuery(run.ID, r.URL.Query())
+		if len(failures) > 0 {
+			s.sendValidationError(w, failures)
+			return
+		}
+		if paged {
+			response.Data, response.NextToken = pageResult(run.Result, page)
+		}
+	} else {
+		report := MissingReport{RunID: run.ID, Stores: []StoreMissingCharts{}}
+		for _, store := range run.Result.Stores {
+			storeReport := StoreMissingCharts{
+				StoreName:     store.StoreName,
+				MissingCharts: store.MissingCharts,
+			}
+			if storeReport.MissingCharts == nil {
+				storeReport.MissingCharts = map[string][]types.MissingProduct{}
+			}
+			for _, products := range store.MissingCharts {
+				storeReport.TotalMissing += len(products)
+			}
+			report.Stores = append(report.Stores, storeReport)
+		}
+		response.Data = report
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		s.logger.Errorf("Failed to encode response: %v", err)
+	}
+}
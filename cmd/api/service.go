@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"shopify-extractor/extractor"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/pipeline"
+	"shopify-extractor/stats"
+	"shopify-extractor/utils"
+)
+
+// errUnknownStore is returned by an ExtractionService for stores without an adapter
+var errUnknownStore = pipeline.ErrUnknownStore
+
+// ExtractionService runs extractions on behalf of the API handlers. The default
+// implementation drives the store extractors; tests inject a fake so handlers can be
+// exercised without network access.
+type ExtractionService interface {
+	// Supports reports whether the store has an adapter
+	Supports(store string) bool
+
+	// Capabilities describes the store's adapter; ok is false for unsupported stores
+	Capabilities(store string) (caps extractor.Capabilities, ok bool)
+
+	// ExtractStore discovers and extracts every product of the store
+	ExtractStore(ctx context.Context, store string, config *types.Config, logger types.Logger) (types.StoreResult, error)
+
+	// DiscoverProductURLs returns the product URLs of the store
+	DiscoverProductURLs(ctx context.Context, store string, config *types.Config, logger types.Logger) ([]string, error)
+
+	// ExtractProducts extracts the given products of the store one by one, calling
+	// visit with each outcome, until done or ctx is cancelled
+	ExtractProducts(ctx context.Context, store string, productURLs []string, config *types.Config, logger types.Logger, visit func(productURL string, product *types.Product, err error)) error
+}
+
+// extractorService is the ExtractionService backed by the store extractors
+type extractorService struct {
+	stats *stats.Collector
+	pool  *extractorPool
+}
+
+// newExtractorService creates the default extraction service, recording statistics
+// to collector and reusing the extractors of pool
+func newExtractorService(collector *stats.Collector, pool *extractorPool) *extractorService {
+	return &extractorService{stats: collector, pool: pool}
+}
+
+// open checks an extractor for a store out of the pool; the caller releases it with close
+func (e *extractorService) open(store string, config *types.Config, logger types.Logger) (*pooledExtractor, error) {
+	pooled, err := e.pool.acquire(store, config)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debugf("Using pooled extractor for %s", store)
+	return pooled, nil
+}
+
+// close returns an extractor to the pool
+func (e *extractorService) close(pooled *pooledExtractor) {
+	e.pool.release(pooled)
+}
+
+// Supports reports whether the store has a built-in or plugin adapter
+func (e *extractorService) Supports(store string) bool {
+	return extractor.Supports(store)
+}
+
+// Capabilities returns the capabilities registered for the store's adapter
+func (e *extractorService) Capabilities(store string) (extractor.Capabilities, bool) {
+	return extractor.CapabilitiesOf(store)
+}
+
+// ExtractStore runs the store's extraction pipeline with a pooled extractor
+func (e *extractorService) ExtractStore(ctx context.Context, store string, config *types.Config, logger types.Logger) (types.StoreResult, error) {
+	storeExtractor, err := e.open(store, config, logger)
+	if err != nil {
+		return types.StoreResult{}, err
+	}
+	defer e.close(storeExtractor)
+
+	// Pooled extractors were created for another request, so the request's logger
+	// travels with the context
+	return pipeline.ExtractStore(utils.ContextWithLogger(ctx, logger), storeExtractor, store, config)
+}
+
+// DiscoverProductURLs runs the store adapter's product discovery
+func (e *extractorService) DiscoverProductURLs(ctx context.Context, store string, config *types.Config, logger types.Logger) ([]string, error) {
+	storeExtractor, err := e.open(store, config, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer e.close(storeExtractor)
+
+	productURLs, err := storeExtractor.DiscoverProductURLs(utils.ContextWithLogger(ctx, logger))
+	if err != nil {
+		return nil, err
+	}
+	kept := productURLs[:0]
+	for _, productURL := range productURLs {
+		if extractor.KeepDiscovered(ctx, store, productURL) {
+			kept = append(kept, productURL)
+		}
+	}
+	return kept, nil
+}
+
+// ExtractProducts extracts the products with a single extractor, so the browser is
+// reused across the batch
+func (e *extractorService) ExtractProducts(ctx context.Context, store string, productURLs []string, config *types.Config, logger types.Logger, visit func(productURL string, product *types.Product, err error)) error {
+	storeExtractor, err := e.open(store, config, logger)
+	if err != nil {
+		return err
+	}
+	defer e.close(storeExtractor)
+
+	for _, productURL := range productURLs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		productStartTime := time.Now()
+		productLogger := utils.WithField(logger, "product_url", productURL)
+		product, err := extractor.ExtractWithDeadline(utils.ContextWithLogger(ctx, productLogger), config.ProductTimeout, productURL, storeExtractor.ExtractProduct)
+		if product != nil && len(product.SizeCharts) > 0 && !extractor.KeepExtracted(ctx, store, product) {
+			// Dropped by an OnChartExtracted hook, so kept out of the results
+			product.SizeCharts = nil
+		}
+		charts := 0
+		if product != nil {
+			charts = len(product.SizeCharts)
+			extractor.SaveChartImages(config, store, product, productLogger)
+		}
+		e.stats.RecordProduct(store, time.Since(productStartTime), charts, err)
+		if product != nil {
+			e.stats.RecordChartParsers(store, types.CountChartsByParser([]types.Product{*product}))
+		}
+		visit(productURL, product, err)
+	}
+	return nil
+}
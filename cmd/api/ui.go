@@ -0,0 +1,32 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// dashboard is the web UI served at /ui. It is a single page that only uses the public
+// API endpoints: POST /extract, GET /events, /runs, /stores, /products and /readyz.
+//
+//go:embed ui/index.html
+var dashboard []byte
+
+// handleUI serves the web dashboard
+func (s *Server) handleUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "GET" && r.Method != "HEAD" {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.URL.Path != "/ui" && r.URL.Path != "/ui/" {
+		s.sendError(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == "GET" {
+		w.Write(dashboard)
+	}
+}
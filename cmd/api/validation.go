@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"shopify-extractor/config"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+)
+
+const (
+	// maxRequestBodyBytes caps the size of JSON request bodies
+	maxRequestBodyBytes = 64 << 10
+
+	// maxStoresPerRequest caps how many stores a single extraction may target
+	maxStoresPerRequest = 20
+)
+
+// storeDomainPattern matches bare hostnames such as "westside.com" or "www.suqah.com"
+var storeDomainPattern = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)+$`)
+
+// ValidationError describes a single invalid field in a request
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// requestError is returned by decodeJSONBody with the HTTP status to respond with
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// decodeJSONBody strictly decodes a JSON request body into dst: the body size is capped,
+// unknown fields are rejected, and trailing data after the JSON object is an error
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	if ct := r.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(strings.ToLower(ct), "application/json") {
+		return &requestError{status: http.StatusUnsupportedMediaType, message: "Content-Type must be application/json"}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &syntaxErr):
+			return &requestError{status: http.StatusBadRequest, message: fmt.Sprintf("Malformed JSON at position %d", syntaxErr.Offset)}
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return &requestError{status: http.StatusBadRequest, message: "Malformed JSON: unexpected end of body"}
+		case errors.As(err, &typeErr):
+			return &requestError{status: http.StatusBadRequest, message: fmt.Sprintf("Invalid type for field %q", typeErr.Field)}
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+			return &requestError{status: http.StatusBadRequest, message: fmt.Sprintf("Unknown field %s", field)}
+		case errors.As(err, &maxBytesErr):
+			return &requestError{status: http.StatusRequestEntityTooLarge, message: fmt.Sprintf("Request body must not exceed %d bytes", maxRequestBodyBytes)}
+		case errors.Is(err, io.EOF):
+			return &requestError{status: http.StatusBadRequest, message: "Request body must not be empty"}
+		default:
+			return &requestError{status: http.StatusBadRequest, message: "Invalid request body"}
+		}
+	}
+
+	if decoder.More() {
+		return &requestError{status: http.StatusBadRequest, message: "Request body must contain a single JSON object"}
+	}
+	return nil
+}
+
+// validateStoreDomain checks that a store is a bare domain name (no scheme, path or port)
+func validateStoreDomain(store string) string {
+	switch {
+	case store == "":
+		return "must not be empty"
+	case len(store) > 253:
+		return "must not exceed 253 characters"
+	case strings.Contains(store, "://") || strings.ContainsAny(store, "/:?#"):
+		return "must be a bare domain such as westside.com, without scheme, port or path"
+	case !storeDomainPattern.MatchString(store):
+		return "is not a valid domain name"
+	}
+	return ""
+}
+
+// validateExtractRequest returns every validation failure of an extraction request
+func validateExtractRequest(req *APIRequest) []ValidationError {
+	var failures []ValidationError
+
+	if len(req.Stores) == 0 {
+		failures = append(failures, ValidationError{Field: "stores", Message: "at least one store is required"})
+	}
+	if len(req.Stores) > maxStoresPerRequest {
+		failures = append(failures, ValidationError{Field: "stores", Message: fmt.Sprintf("at most %d stores are allowed per request", maxStoresPerRequest)})
+	}
+
+	for i, store := range req.Stores {
+		if msg := validateStoreDomain(store); msg != "" {
+			failures = append(failures, ValidationError{Field: fmt.Sprintf("stores[%d]", i), Message: msg})
+		}
+	}
+
+	if req.SampleRate < 0 || req.SampleRate > 1 {
+		failures = append(failures, ValidationError{Field: "sample_rate", Message: "must be between 0 and 1"})
+	}
+	if req.SampleCount < 0 {
+		failures = append(failures, ValidationError{Field: "sample_count", Message: "must not be negative"})
+	}
+	if !utils.ValidCrawlOrder(req.Order) {
+		failures = append(failures, ValidationError{Field: "order", Message: fmt.Sprintf("must be one of: %s", strings.Join(utils.CrawlOrders(), ", "))})
+	}
+	if !types.ValidSchemaVersion(req.SchemaVersion) {
+		failures = append(failures, ValidationError{Field: "schema_version", Message: fmt.Sprintf("must be one of %v", types.SchemaVersions())})
+	}
+	if req.PageSize < 0 || req.PageSize > maxPageSize {
+		failures = append(failures, ValidationError{Field: "page_size", Message: fmt.Sprintf("must be between 0 and %d", maxPageSize)})
+	}
+	return failures
+}
+
+// resolvePortfolios replaces the stores of an extraction request with the stores of its
+// portfolios followed by its own stores, and returns the requested portfolios. Unknown
+// portfolio names are validation failures.
+func resolvePortfolios(c *types.Config, req *APIRequest) (map[string][]string, []ValidationError) {
+	var failures []ValidationError
+	var names []string
+	for i, name := range req.Portfolios {
+		if _, ok := c.Portfolios[name]; !ok {
+			failures = append(failures, ValidationError{Field: fmt.Sprintf("portfolios[%d]", i), Message: "is not a configured portfolio"})
+			continue
+		}
+		names = append(names, name)
+	}
+
+	names = append(names, req.Stores...)
+	var portfolios map[string][]string
+	req.Stores, portfolios = config.ResolveStores(c, names)
+	return portfolios, failures
+}
+
+// validateChunkedRequest returns every validation failure of a chunked extraction request
+func validateChunkedRequest(req *ChunkedRequest) []ValidationError {
+	var failures []ValidationError
+
+	if req.Store == "" && req.Cursor == "" {
+		failures = append(failures, ValidationError{Field: "store", Message: "store or cursor is required"})
+	}
+	if req.Store != "" {
+		if msg := validateStoreDomain(req.Store); msg != "" {
+			failures = append(failures, ValidationError{Field: "store", Message: msg})
+		}
+	}
+	if req.Offset < 0 {
+		failures = append(failures, ValidationError{Field: "offset", Message: "must not be negative"})
+	}
+	if req.Limit < 0 || req.Limit > maxChunkSize {
+		failures = append(failures, ValidationError{Field: "limit", Message: fmt.Sprintf("must be between 0 and %d", maxChunkSize)})
+	}
+	if req.SampleRate < 0 || req.SampleRate > 1 {
+		failures = append(failures, ValidationError{Field: "sample_rate", Message: "must be between 0 and 1"})
+	}
+	if req.SampleCount < 0 {
+		failures = append(failures, ValidationError{Field: "sample_count", Message: "must not be negative"})
+	}
+	return failures
+}
+
+// sendRequestError responds to a decodeJSONBody failure
+func (s *Server) sendRequestError(w http.ResponseWriter, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		s.sendError(w, reqErr.message, reqErr.status)
+		return
+	}
+	s.sendError(w, "Invalid request body", http.StatusBadRequest)
+}
+
+// sendValidationError responds with 422 and the list of validation failures
+func (s *Server) sendValidationError(w http.ResponseWriter, failures []ValidationError) {
+	s.sendAPIError(w, http.StatusUnprocessableEntity, &APIError{
+		Code:    CodeValidationFailed,
+		Message: "Request validation failed",
+		Details: failures,
+	})
+}
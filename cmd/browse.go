@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"shopify-extractor/browse"
+	"shopify-extractor/output"
+)
+
+// runBrowse implements the browse subcommand: an interactive view over a results file
+// written by --output (a JSON document or the NDJSON lines of a --stream run)
+func runBrowse(args []string) int {
+	flags := flag.NewFlagSet("browse", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: shopify_extractor browse RESULTS_FILE")
+		flags.PrintDefaults()
+	}
+	pageSize := flags.Int("page-size", browse.DefaultPageSize, "Products listed per page")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	result, err := output.ReadResultsFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read results: %v\n", err)
+		return 1
+	}
+
+	browser := browse.New(result, os.Stdin, os.Stdout)
+	browser.SetPageSize(*pageSize)
+	if err := browser.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Browse failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
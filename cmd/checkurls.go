@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"shopify-extractor/config"
+	"shopify-extractor/linkcheck"
+	"shopify-extractor/output"
+	"shopify-extractor/utils"
+)
+
+// runCheckURLs implements the check-urls subcommand: it re-checks the product URLs of a
+// results file written by --output with cheap HEAD requests and reports the products the
+// stores have removed (404 or 410) since, without re-extracting them
+func runCheckURLs(args []string) int {
+	flags := flag.NewFlagSet("check-urls", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: shopify_extractor check-urls [--output FILE] [--fail-on-removed] RESULTS_FILE")
+		flags.PrintDefaults()
+	}
+	outputPath := flags.String("output", "", "Report file path (default: stdout)")
+	failOnRemoved := flags.Bool("fail-on-removed", false, "Exit with status 3 when any product was removed, for monitoring jobs")
+	verbose := flags.Bool("verbose", false, "Enable verbose logging")
+	// Request delay, concurrency, retries, timeout and store options apply as for extraction
+	config.RegisterFlags(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(os.Stderr)
+	if *verbose {
+		logger.SetLevel(logrus.DebugLevel)
+	}
+
+	settings, err := config.Load(flags, config.ProfileDev)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	result, err := output.ReadResultsFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read results: %v\n", err)
+		return 1
+	}
+
+	client := utils.NewHTTPClient(settings.Config, logger)
+	defer client.Close()
+	report := linkcheck.Check(context.Background(), client, result, settings.Config.MaxConcurrentRequests)
+	for _, store := range report.Stores {
+		logger.Infof("%s: %d checked, %d live, %d removed, %d failed", store.StoreName, store.Checked, store.Live, store.Removed, store.Failed)
+	}
+
+	out := os.Stdout
+	if *outputPath != "" {
+		if out, err = os.Create(*outputPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create report: %v\n", err)
+			return 1
+		}
+		defer out.Close()
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+
+	if *failOnRemoved && len(report.Removed) > 0 {
+		return 3
+	}
+	return 0
+}
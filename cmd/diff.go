@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"shopify-extractor/catalog"
+	"shopify-extractor/output"
+)
+
+// runDiff implements the diff subcommand: it compares two results files written by
+// --output and reports, per store, the products that are new, changed (different size
+// charts) or removed in the later one
+func runDiff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: shopify_extractor diff [--output FILE] PREVIOUS_RESULTS CURRENT_RESULTS")
+		flags.PrintDefaults()
+	}
+	outputPath := flags.String("output", "", "Report file path (default: stdout)")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	previous, err := output.ReadResultsFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read previous results: %v\n", err)
+		return 1
+	}
+	current, err := output.ReadResultsFile(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read current results: %v\n", err)
+		return 1
+	}
+	diff := catalog.Compare(previous, current)
+
+	out := os.Stdout
+	if *outputPath != "" {
+		if out, err = os.Create(*outputPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create report: %v\n", err)
+			return 1
+		}
+		defer out.Close()
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(diff); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"shopify-extractor/catalog"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/output"
+	"shopify-extractor/utils"
+)
+
+// Import formats of the import subcommand
+const (
+	importAuto       = "auto"
+	importResults    = "results"     // JSON or NDJSON results written by --output
+	importExportJSON = "export-json" // JSON catalog export (POST /exports)
+	importExportCSV  = "export-csv"  // CSV catalog export
+)
+
+// runImport implements the import subcommand: it loads results files and catalog
+// exports into the catalog database, so a historical dataset kept in files can seed
+// the API server's catalog
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: shopify_extractor import [--catalog FILE] [--format FORMAT] [--at TIME] [--complete] FILE...")
+		flags.PrintDefaults()
+	}
+	catalogPath := flags.String("catalog", os.Getenv("CATALOG_PATH"), "Catalog database of the API server (default: CATALOG_PATH)")
+	format := flags.String("format", importAuto, "File format: auto, results (JSON/NDJSON from --output), export-json or export-csv")
+	at := flags.String("at", "", "Extraction time of the imported products, RFC 3339 or YYYY-MM-DD (default: each file's modification time)")
+	complete := flags.Bool("complete", false, "Files hold complete store catalogs: products missing from them are marked removed")
+	flags.Parse(args)
+
+	if *catalogPath == "" || flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	switch *format {
+	case importAuto, importResults, importExportJSON, importExportCSV:
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %q\n", *format)
+		return 2
+	}
+	var extractedAt time.Time
+	if *at != "" {
+		var err error
+		if extractedAt, err = parseImportTime(*at); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --at: %v\n", err)
+			return 2
+		}
+	}
+
+	index, err := catalog.Open(*catalogPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open catalog (is the API server running?): %v\n", err)
+		return 1
+	}
+	defer index.Close()
+
+	// Files are imported in the order given, so pass historical files oldest first
+	for _, path := range flags.Args() {
+		result, modTime, err := readImportFile(path, *format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read %s: %v\n", path, err)
+			return 1
+		}
+		when := extractedAt
+		if when.IsZero() {
+			when = modTime
+		}
+		if result.RunID == "" {
+			result.RunID = utils.NewRunID()
+		}
+		products := 0
+		for i := range result.Stores {
+			result.Stores[i].Partial = !*complete
+			products += len(result.Stores[i].Products)
+		}
+
+		if err := index.Add(result, when); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to import %s: %v\n", path, err)
+			return 1
+		}
+		fmt.Printf("Imported %d products of %d stores from %s as run %s (%s)\n", products, len(result.Stores), path, result.RunID, when.UTC().Format(time.RFC3339))
+	}
+	return 0
+}
+
+// parseImportTime parses an RFC 3339 time or a YYYY-MM-DD date
+func parseImportTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// readImportFile reads a file in the given import format, detecting it with
+// importAuto, and returns it with the file's modification time
+func readImportFile(path, format string) (*types.ExtractionResult, time.Time, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	reader := bufio.NewReader(file)
+	if format == importAuto {
+		format = detectImportFormat(path, reader)
+	}
+	var result *types.ExtractionResult
+	switch format {
+	case importExportJSON:
+		result, err = catalog.ReadExport(reader, catalog.FormatJSON)
+	case importExportCSV:
+		result, err = catalog.ReadExport(reader, catalog.FormatCSV)
+	default:
+		result, err = output.ReadResults(reader)
+	}
+	return result, info.ModTime(), err
+}
+
+// detectImportFormat picks the format of a file: CSV exports by extension, JSON exports
+// by their top-level array, anything else is read as results
+func detectImportFormat(path string, reader *bufio.Reader) string {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return importExportCSV
+	}
+	start, err := reader.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return importResults
+	}
+	if bytes.HasPrefix(bytes.TrimLeft(start, " \t\r\n"), []byte("[")) {
+		return importExportJSON
+	}
+	return importResults
+}
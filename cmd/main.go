@@ -2,37 +2,78 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
-	"fmt"
+	"io"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
+	"shopify-extractor/config"
 	"shopify-extractor/extractor"
 	"shopify-extractor/internal/types"
+	"shopify-extractor/output"
+	"shopify-extractor/pipeline"
+	"shopify-extractor/render"
+	"shopify-extractor/stats"
+	"shopify-extractor/utils"
 )
 
 func main() {
 	// Load .env file if present
 	_ = godotenv.Load()
 
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "browse" {
+		os.Exit(runBrowse(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "reparse" {
+		os.Exit(runReparse(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "purge" {
+		os.Exit(runPurge(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "check-urls" {
+		os.Exit(runCheckURLs(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(runDiff(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		os.Exit(runRender(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "retry-run" {
+		os.Exit(runRetryRun(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "smoke" {
+		os.Exit(runSmoke(os.Args[2:]))
+	}
+
 	// Parse command line flags
 	var (
-		storeFlag      = flag.String("store", "", "Single store to extract (westside, littleboxindia, suqah)")
-		storesFlag     = flag.String("stores", "", "Comma-separated list of store domains (for multi-store extraction)")
-		outputFlag     = flag.String("output", "", "Output file path (default: stdout)")
-		requestDelay   = flag.Duration("delay", 1*time.Second, "Delay between requests")
-		maxRetries     = flag.Int("retries", 3, "Maximum retry attempts")
-		timeout        = flag.Duration("timeout", 30*time.Second, "Request timeout")
-		maxConcurrent  = flag.Int("concurrent", 5, "Maximum concurrent requests")
-		useBrowser     = flag.Bool("browser", true, "Use headless browser for JavaScript-heavy sites")
-		httpOnly       = flag.Bool("http-only", false, "Use HTTP requests only (disable headless browser)")
-		verbose        = flag.Bool("verbose", false, "Enable verbose logging")
+		storeFlag     = flag.String("store", "", "Single store to extract (westside.com, littleboxindia.com, suqah.com, nykaafashion.com, a plugin store or a portfolio name)")
+		storesFlag    = flag.String("stores", "", "Comma-separated list of store domains or portfolio names (for multi-store extraction)")
+		outputFlag    = flag.String("output", "", "Output file path (default: stdout)")
+		formatFlag    = flag.String("format", "json", "Output format: json, or markdown/ascii size chart tables per product for reviewing results")
+		streamOutput  = flag.Bool("stream", false, "Write each product as an NDJSON line as soon as it is extracted (keeps memory flat for large catalogs)")
+		dedupeCharts  = flag.Bool("dedupe-charts", false, "Write each distinct size chart once and reference it from products by chart ID")
+		writeQueue    = flag.Int("write-queue", output.DefaultQueueSize, "Products buffered between the extraction workers and the --stream writer; extraction waits when it is full (0 = write directly)")
+		httpOnly      = flag.Bool("http-only", false, "Use HTTP requests only (disable headless browser)")
+		verbose       = flag.Bool("verbose", false, "Enable verbose logging")
+		logFile       = flag.String("log-file", "", "Append logs to this file instead of stderr (or set LOG_FILE)")
+		containerMode = flag.Bool("container", false, "Tune Chrome for containers and fail fast when it is missing (or set CONTAINER_MODE=true)")
+		pluginPaths   = flag.String("plugins", "", "Comma-separated adapter plugin files or directories of .so files (or set ADAPTER_PLUGINS)")
 	)
+
+	// Extraction settings: defaults, then --profile, then --config file, then environment,
+	// then flags
+	config.RegisterFlags(flag.CommandLine)
 	flag.Parse()
 
 	// Validate flags - either --store or --stores must be provided
@@ -42,6 +83,15 @@ func main() {
 	if *storeFlag != "" && *storesFlag != "" {
 		log.Fatal("Cannot use both --store and --stores flags")
 	}
+	if *formatFlag != "json" && !render.IsFormat(*formatFlag) {
+		log.Fatalf("Unknown --format %q: use json, %s", *formatFlag, strings.Join(render.Formats(), " or "))
+	}
+	if render.IsFormat(*formatFlag) && *dedupeCharts {
+		log.Fatal("--dedupe-charts only applies to --format json")
+	}
+	if *writeQueue < 0 {
+		log.Fatalf("--write-queue must not be negative (got %d)", *writeQueue)
+	}
 
 	// Parse stores
 	var stores []string
@@ -56,8 +106,25 @@ func main() {
 		}
 	}
 
+	// Only results go to stdout; logs go to stderr or --log-file
+	var results io.Writer = os.Stdout
+
 	// Setup logging
 	logger := logrus.New()
+	logger.SetOutput(os.Stderr)
+	if *logFile == "" {
+		*logFile = os.Getenv("LOG_FILE")
+	}
+	if *logFile != "" {
+		file, err := os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			log.Fatalf("Failed to open log file: %v", err)
+		}
+		defer file.Close()
+		logger.SetOutput(file)
+		// Library messages through the standard logger follow the same file
+		log.SetOutput(file)
+	}
 	
 	// Set timestamp format with milliseconds
 	logger.SetFormatter(&logrus.TextFormatter{
@@ -76,103 +143,166 @@ func main() {
 		logger.SetLevel(logrus.InfoLevel)
 	}
 
-	// Create configuration
-	config := &types.Config{
-		RequestDelay:           *requestDelay,
-		MaxRetries:            *maxRetries,
-		Timeout:               *timeout,
-		MaxConcurrentRequests: *maxConcurrent,
-		UseHeadlessBrowser:    *useBrowser && !*httpOnly,
-		UserAgent:             "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	// Register third-party store adapters before resolving the requested stores
+	if *pluginPaths == "" {
+		*pluginPaths = os.Getenv("ADAPTER_PLUGINS")
+	}
+	if err := extractor.LoadPlugins(*pluginPaths, logger); err != nil {
+		logger.Fatalf("Failed to load adapter plugins: %v", err)
+	}
+
+	// Load the extraction configuration; invalid values are reported together
+	// Without a profile the CLI runs as dev, so a bare invocation samples a few products
+	// instead of crawling whole catalogs
+	settings, err := config.Load(flag.CommandLine, config.ProfileDev)
+	if err != nil {
+		logger.Fatalf("%v", err)
+	}
+	if settings.Profile == config.ProfileDev && settings.Config.SampleCount > 0 {
+		logger.Warnf("Profile dev: extracting at most %d products per store; use --profile prod for a full crawl", settings.Config.SampleCount)
+	}
+	if envContainer, _ := strconv.ParseBool(os.Getenv("CONTAINER_MODE")); envContainer {
+		*containerMode = true
+	}
+	if *containerMode {
+		settings.Config.Browser = utils.ContainerBrowserOptions(settings.Config.Browser)
+	}
+	if *httpOnly {
+		settings.Set("use_browser", "false", config.SourceFlag)
+	}
+	settings.Log(logger)
+
+	// Portfolio names among the requested stores expand to their member stores
+	stores, portfolios := config.ResolveStores(settings.Config, stores)
+	// Stores named by their *.myshopify.com domain resolve to the store they serve
+	stores = utils.CanonicalStores(context.Background(), stores)
+	for _, name := range config.PortfolioNames(portfolios) {
+		logger.Infof("Portfolio %s: %s", name, strings.Join(portfolios[name], ", "))
+	}
+	config := settings.Config
+
+	if _, err := utils.NewBrowserBackend(config, logger); err != nil {
+		logger.Fatalf("Invalid browser configuration: %v", err)
+	}
+
+	// Check for a Chrome binary up front rather than failing on the first browser fetch
+	if config.UseHeadlessBrowser && utils.UsesLocalChrome(config.Browser) {
+		if chromePath, err := utils.FindChrome(config.Browser); err != nil {
+			if *containerMode {
+				logger.Fatalf("Startup check failed: %v", err)
+			}
+			logger.Warnf("%v (stores that need the browser will fail; use --http-only to silence)", err)
+		} else {
+			logger.Debugf("Using Chrome binary: %s", chromePath)
+		}
 	}
 
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
+	// Every log line of the run carries its ID, which is also written to the results
+	runID := utils.NewRunID()
+	runLogger := logger.WithField("run_id", runID)
+	config.RunID = runID
+	if config.ArchiveDir != "" {
+		runLogger.Infof("Archiving product pages to %s", config.ArchiveDir)
+	}
+	if config.WARCDir != "" {
+		runLogger.Infof("Recording product pages as WARC files in %s", config.WARCDir)
+	}
+
 	// Extract size charts using individual store extractors
 	startTime := time.Now()
-	logger.Infof("Starting extraction for stores: %v", stores)
+	runLogger.Infof("Starting extraction for stores: %v", stores)
 	
-	var storeResults []types.StoreResult
-	totalProducts := 0
-	productsWithSizeCharts := 0
-
-	for _, store := range stores {
-		logger.Infof("Processing store: %s", store)
-		
-		var storeExtractor interface {
-			ExtractAll(context.Context) ([]types.Product, error)
-			Close()
-		}
-		
-		// Create the appropriate extractor based on store name
-		switch store {
-		case "westside.com":
-			storeExtractor = extractor.NewWestsideExtractor(config, logger)
-		case "littleboxindia.com":
-			storeExtractor = extractor.NewLittleBoxIndiaExtractor(config, logger)
-		case "suqah.com":
-			storeExtractor = extractor.NewSuqahExtractor(config, logger)
-		default:
-			logger.Warnf("Unknown store: %s, skipping", store)
-			continue
-		}
-		
-		defer storeExtractor.Close()
-		
-		// Extract from this store
-		products, err := storeExtractor.ExtractAll(ctx)
-		if err != nil {
-			logger.Warnf("Failed to extract from %s: %v", store, err)
-			continue
-		}
-		
-		// Create store result with actual store name
-		storeResult := types.StoreResult{
-			StoreName: store,
-			Products:  products,
+	collector := stats.NewCollector()
+
+	// Output results through the configured sink (file when --output is set, stdout otherwise)
+	var sink output.Sink
+	switch {
+	case render.IsFormat(*formatFlag):
+		sink, err = newRenderSink(*outputFlag, *formatFlag, results)
+	case *outputFlag != "":
+		sink, err = output.NewSink("file", *outputFlag)
+	default:
+		sink = output.NewWriterSink(results)
+	}
+	if err != nil {
+		logger.Fatalf("Failed to create output sink: %v", err)
+	}
+	if *dedupeCharts {
+		if config.SchemaVersion == types.SchemaV1 {
+			logger.Fatal("--dedupe-charts needs --schema-version 2")
 		}
-		storeResults = append(storeResults, storeResult)
-		
-		totalProducts += len(products)
-		for _, product := range products {
-			if len(product.SizeCharts) > 0 {
-				productsWithSizeCharts++
-			}
+		sink = output.NewDedupeSink(sink)
+	}
+	// JSON results are written in the structure consumers asked for, the current one by
+	// default
+	if !render.IsFormat(*formatFlag) {
+		if sink, err = output.NewSchemaSink(sink, config.SchemaVersion); err != nil {
+			logger.Fatalf("Failed to create output sink: %v", err)
 		}
 	}
+	// A bounded queue lets workers continue while the sink writes, without buffering
+	// more than --write-queue products when it falls behind
+	var queue *output.QueuedSink
+	if *streamOutput && *writeQueue > 0 {
+		queue = output.NewQueuedSink(sink, *writeQueue)
+		sink = queue
+	}
+
+	// Every store is extracted through the shared pipeline, as by the API server
+	runner := pipeline.Runner{Stats: collector}
+	if *streamOutput {
+		runner.Writer = sink
+	}
+	finalResults := runner.Run(ctx, stores, portfolios, config, runLogger)
 	
 	extractionTime := time.Since(startTime)
-	logger.Infof("Extraction completed in %v", extractionTime)
+	runLogger.Infof("Extraction completed in %v", extractionTime)
 
-	// Create the final result structure with separate store results
-	finalResults := types.ExtractionResult{
-		Stores: storeResults,
+	// In streaming mode products were already written as NDJSON lines
+	if !*streamOutput {
+		if err := sink.Write(ctx, finalResults); err != nil {
+			logger.Fatalf("Failed to write results: %v", err)
+		}
 	}
-
-	// Marshal results to JSON
-	jsonData, err := json.MarshalIndent(finalResults, "", "  ")
-	if err != nil {
-		logger.Fatalf("Failed to marshal results: %v", err)
+	if err := sink.Close(); err != nil {
+		logger.Fatalf("Failed to close output sink: %v", err)
+	}
+	if queue != nil {
+		runLogger.Infof("Output queue: %s", queue.Stats())
 	}
-
-	// Output results
 	if *outputFlag != "" {
-		// Write to file
-		err = os.WriteFile(*outputFlag, jsonData, 0644)
-		if err != nil {
-			logger.Fatalf("Failed to write output file: %v", err)
+		runLogger.Infof("Results written to: %s", *outputFlag)
+	}
+	if config.RunDir != "" {
+		// Streamed products were not kept, so the stored run only lists the failures
+		if err := output.SaveRun(config.RunDir, finalResults); err != nil {
+			runLogger.Warnf("Run not stored for retry-run: %v", err)
+		} else {
+			runLogger.Infof("Run stored in %s; retry its failures with: retry-run %s", config.RunDir, runID)
 		}
-		logger.Infof("Results written to: %s", *outputFlag)
-	} else {
-		// Write to stdout
-		fmt.Println(string(jsonData))
 	}
 
 	// Print summary
-	logger.Infof("Extraction completed successfully")
-	logger.Infof("Total stores processed: %d", len(stores))
-	logger.Infof("Total products found: %d", totalProducts)
-	logger.Infof("Products with size charts: %d", productsWithSizeCharts)
+	runLogger.Infof("Extraction completed successfully")
+	runLogger.Infof("Total stores processed: %d", len(stores))
+	summary := collector.Snapshot().Global
+	runLogger.Infof("Total products found: %d", summary.ProductsDiscovered)
+	runLogger.Infof("Products processed: %d (failed: %d, timed out: %d, mean time: %v)", summary.ProductsProcessed, summary.ProductsFailed, summary.ProductsTimedOut, summary.ProductDuration.Mean)
+	runLogger.Infof("Products with size charts: %d", summary.ProductsWithCharts)
+	for _, storeResult := range finalResults.Stores {
+		if storeResult.Coverage != nil {
+			runLogger.Infof("%s: %s", storeResult.StoreName, storeResult.Coverage)
+		}
+	}
+	for _, portfolio := range finalResults.Portfolios {
+		runLogger.Infof("Portfolio %s: %d products with size charts, %d without, %d of %d stores failed",
+			portfolio.Name, portfolio.ProductsWithCharts, portfolio.ProductsMissingCharts, len(portfolio.FailedStores), len(portfolio.Stores))
+		if portfolio.Coverage != nil {
+			runLogger.Infof("Portfolio %s: %s", portfolio.Name, portfolio.Coverage)
+		}
+	}
 } 
\ No newline at end of file
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"shopify-extractor/archive"
+	"shopify-extractor/catalog"
+	"shopify-extractor/extractor"
+	"shopify-extractor/utils"
+)
+
+// runPurge implements the purge subcommand: it deletes archived pages, WARC files and
+// catalog products older than a number of days or belonging to stores no extractor
+// serves anymore, so long-running deployments do not grow their disk without bound
+func runPurge(args []string) int {
+	flags := flag.NewFlagSet("purge", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: shopify_extractor purge (--older-than-days N | --deregistered) [--archive DIR] [--warc DIR] [--catalog FILE]")
+		flags.PrintDefaults()
+	}
+	archiveDir := flags.String("archive", os.Getenv("ARCHIVE_DIR"), "Archive directory written by --archive-dir (default: ARCHIVE_DIR)")
+	warcDir := flags.String("warc", os.Getenv("WARC_DIR"), "WARC directory written by --warc-dir (default: WARC_DIR)")
+	catalogPath := flags.String("catalog", os.Getenv("CATALOG_PATH"), "Catalog database of the API server (default: CATALOG_PATH)")
+	days := flags.Int("older-than-days", 0, "Remove data stored more than this many days ago")
+	deregistered := flags.Bool("deregistered", false, "Remove data of stores no longer served by any extractor")
+	pluginPaths := flags.String("plugins", os.Getenv("ADAPTER_PLUGINS"), "Adapter plugins whose stores count as registered (default: ADAPTER_PLUGINS)")
+	flags.Parse(args)
+
+	if (*days <= 0 && !*deregistered) || *days < 0 || flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	retention := utils.Retention{MaxAge: time.Duration(*days) * 24 * time.Hour}
+	if *deregistered {
+		logger := logrus.New()
+		logger.SetOutput(os.Stderr)
+		if err := extractor.LoadPlugins(*pluginPaths, logger); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load adapter plugins: %v\n", err)
+			return 1
+		}
+		retention.Registered = extractor.Supports
+	}
+
+	if _, err := os.Stat(*archiveDir); *archiveDir != "" && err == nil {
+		a, err := archive.Open(*archiveDir)
+		if err == nil {
+			var result archive.PurgeResult
+			if result, err = a.Purge(retention.Expired); err == nil {
+				fmt.Printf("Archive: removed %d entries and %d pages (%d bytes)\n", result.Entries, result.Files, result.Bytes)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to purge archive: %v\n", err)
+			return 1
+		}
+	}
+	if *warcDir != "" {
+		result, err := archive.PurgeWARC(*warcDir, retention.Expired)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to purge WARC files: %v\n", err)
+			return 1
+		}
+		fmt.Printf("WARC: removed %d files (%d bytes)\n", result.Files, result.Bytes)
+	}
+	if *catalogPath != "" {
+		index, err := catalog.Open(*catalogPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open catalog (is the API server running?): %v\n", err)
+			return 1
+		}
+		removed, err := index.Purge(retention.Expired)
+		if closeErr := index.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to purge catalog: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Catalog: removed %d products\n", removed)
+	}
+	return 0
+}
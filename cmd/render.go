@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"shopify-extractor/output"
+	"shopify-extractor/render"
+)
+
+// runRender implements the render subcommand: it prints the size charts of a results
+// file as Markdown or ASCII tables, for pasting into tickets and PR descriptions
+func runRender(args []string) int {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: shopify_extractor render [--format markdown|ascii] [--store DOMAIN] [--output FILE] RESULTS")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", render.FormatMarkdown, "Table format: "+strings.Join(render.Formats(), " or "))
+	store := flags.String("store", "", "Only render this store")
+	outputPath := flags.String("output", "", "Output file path (default: stdout)")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	if !render.IsFormat(*format) {
+		fmt.Fprintf(os.Stderr, "Unknown format %q\n", *format)
+		return 2
+	}
+
+	result, err := output.ReadResultsFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read results: %v\n", err)
+		return 1
+	}
+	if *store != "" {
+		stores := result.Stores[:0]
+		for _, s := range result.Stores {
+			if s.StoreName == *store {
+				stores = append(stores, s)
+			}
+		}
+		result.Stores = stores
+	}
+
+	sink, err := newRenderSink(*outputPath, *format, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	if err := sink.Write(context.Background(), result); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to render results: %v\n", err)
+		return 1
+	}
+	if err := sink.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", *outputPath, err)
+		return 1
+	}
+	return 0
+}
+
+// newRenderSink creates a render sink writing to path, or to stdout when path is empty
+func newRenderSink(path, format string, stdout io.Writer) (*render.Sink, error) {
+	out := stdout
+	var closer io.Closer
+	if path != "" {
+		file, err := output.CreateAtomic(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create output file: %w", err)
+		}
+		out, closer = file, file
+	}
+	return render.NewSink(out, closer, format)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"shopify-extractor/adapters"
+	"shopify-extractor/archive"
+	"shopify-extractor/extractor"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/output"
+	"shopify-extractor/utils"
+)
+
+// runReparse implements the reparse subcommand: it runs a store adapter's parsing code
+// over pages archived with --archive-dir or recorded with --warc-dir and writes a fresh extraction result, without
+// fetching anything
+func runReparse(args []string) int {
+	flags := flag.NewFlagSet("reparse", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: shopify_extractor reparse (--archive DIR | --warc FILES) --store DOMAIN [--run RUN_ID] [--output FILE]")
+		flags.PrintDefaults()
+	}
+	archiveDir := flags.String("archive", "", "Archive directory written by --archive-dir")
+	warcFiles := flags.String("warc", "", "Comma-separated WARC files to re-parse instead of an archive directory")
+	store := flags.String("store", "", "Store whose archived pages are re-parsed")
+	runID := flags.String("run", "", "Only re-parse pages archived by this run (default: latest copy of every page)")
+	outputPath := flags.String("output", "", "Output file path (default: stdout)")
+	dedupeCharts := flags.Bool("dedupe-charts", false, "Write each distinct size chart once and reference it from products by chart ID")
+	verbose := flags.Bool("verbose", false, "Enable verbose logging")
+	flags.Parse(args)
+
+	if (*archiveDir == "") == (*warcFiles == "") || *store == "" || flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(os.Stderr)
+	if *verbose {
+		logger.SetLevel(logrus.DebugLevel)
+	}
+
+	var result *types.ExtractionResult
+	var err error
+	if *warcFiles != "" {
+		result, err = reparseWARC(context.Background(), strings.Split(*warcFiles, ","), *store, logger)
+	} else {
+		result, err = reparseArchive(context.Background(), *archiveDir, *store, *runID, logger)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Reparse failed: %v\n", err)
+		return 1
+	}
+
+	sinkName, sinkTarget := "stdout", ""
+	if *outputPath != "" {
+		sinkName, sinkTarget = "file", *outputPath
+	}
+	sink, err := output.NewSink(sinkName, sinkTarget)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create output sink: %v\n", err)
+		return 1
+	}
+	if *dedupeCharts {
+		sink = output.NewDedupeSink(sink)
+	}
+	if err := sink.Write(context.Background(), result); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write results: %v\n", err)
+		return 1
+	}
+	if err := sink.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to close output sink: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// reparseArchive extracts every archived product page of a store through the store's
+// extractor, serving pages from the archive instead of the network
+func reparseArchive(ctx context.Context, archiveDir, store, runID string, logger *logrus.Logger) (*types.ExtractionResult, error) {
+	a, err := archive.Open(archiveDir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := a.Latest(store, runID)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no archived pages for %s in %s", store, archiveDir)
+	}
+
+	urls := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		urls = append(urls, entry.URL)
+	}
+	return reparsePages(ctx, store, archive.NewReplay(a, entries), urls, logger)
+}
+
+// reparseWARC extracts every product page of a store recorded in the WARC files
+func reparseWARC(ctx context.Context, paths []string, store string, logger *logrus.Logger) (*types.ExtractionResult, error) {
+	replay, err := archive.NewWARCReplay(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	for _, pageURL := range replay.URLs() {
+		parsed, err := url.Parse(pageURL)
+		if err != nil || !strings.Contains(parsed.Path, "/products/") {
+			continue
+		}
+		if types.CanonicalStore(parsed.Hostname()) == types.CanonicalStore(store) {
+			urls = append(urls, pageURL)
+		}
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no product pages of %s in the WARC files", store)
+	}
+	return reparsePages(ctx, store, replay, urls, logger)
+}
+
+// reparsePages runs the store's extractor over the given product pages, served by source
+func reparsePages(ctx context.Context, store string, source types.PageSource, urls []string, logger *logrus.Logger) (*types.ExtractionResult, error) {
+	reparseID := utils.NewRunID()
+	runLogger := logger.WithField("run_id", reparseID)
+	runLogger.Infof("Re-parsing %d archived pages of %s", len(urls), store)
+
+	config := types.DefaultConfig()
+	config.RunID = reparseID
+	config.EstimateCoverage = false
+	config.PageSource = source
+
+	storeExtractor := extractor.New(store, config, runLogger.WithField("store", store))
+	if storeExtractor == nil {
+		return nil, fmt.Errorf("unknown store: %s", store)
+	}
+	defer storeExtractor.Close()
+
+	storeResult := types.StoreResult{
+		StoreName:     store,
+		Products:      []types.Product{},
+		MissingCharts: map[string][]types.MissingProduct{},
+	}
+	for _, productURL := range urls {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		product, err := storeExtractor.ExtractProduct(ctx, productURL)
+		if err != nil || product == nil || len(product.SizeCharts) == 0 {
+			reason := adapters.FailureReason(err)
+			missing := types.MissingProduct{ProductURL: productURL}
+			if err != nil {
+				missing.Error = err.Error()
+			}
+			storeResult.MissingCharts[reason] = append(storeResult.MissingCharts[reason], missing)
+			continue
+		}
+		product.SetFingerprints()
+		product.SetSizeLabels()
+		product.SetMeasurements()
+		storeResult.Products = append(storeResult.Products, *product)
+	}
+
+	storeResult.ChartsByParser = types.CountChartsByParser(storeResult.Products)
+	runLogger.Infof("Re-parsed %s: %d products with size charts, %d without", store, len(storeResult.Products), len(urls)-len(storeResult.Products))
+	return &types.ExtractionResult{SchemaVersion: types.CurrentSchemaVersion, RunID: reparseID, Stores: []types.StoreResult{storeResult}}, nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"shopify-extractor/adapters"
+	"shopify-extractor/config"
+	"shopify-extractor/extractor"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/output"
+	"shopify-extractor/utils"
+)
+
+// runRetryRun implements the retry-run subcommand: it extracts again only the products
+// of a run stored with --run-dir that failed to fetch or timed out, and merges the
+// outcome back into the stored run instead of re-crawling the stores
+func runRetryRun(args []string) int {
+	flags := flag.NewFlagSet("retry-run", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: shopify_extractor retry-run RUN_ID [--run-dir DIR] [--output FILE]")
+		flags.PrintDefaults()
+	}
+	outputPath := flags.String("output", "", "Also write the merged result to this file")
+	verbose := flags.Bool("verbose", false, "Enable verbose logging")
+	// Run directory, delays, concurrency, retries and store options apply as for extraction
+	config.RegisterFlags(flags)
+
+	// The run ID may come before the flags
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		args = append(args[1:], args[0])
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	runID := flags.Arg(0)
+
+	logger := logrus.New()
+	logger.SetOutput(os.Stderr)
+	if *verbose {
+		logger.SetLevel(logrus.DebugLevel)
+	}
+
+	settings, err := config.Load(flags, config.ProfileProd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	config := settings.Config
+	if config.RunDir == "" {
+		fmt.Fprintln(os.Stderr, "retry-run needs the run directory: set --run-dir or RUN_DIR")
+		return 2
+	}
+	if err := extractor.LoadPlugins(os.Getenv("ADAPTER_PLUGINS"), logger); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load adapter plugins: %v\n", err)
+		return 1
+	}
+
+	run, err := output.LoadRun(config.RunDir, runID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	runLogger := logger.WithField("run_id", runID)
+	config.RunID = runID
+	retried := 0
+	for i := range run.Stores {
+		store := &run.Stores[i]
+		urls := output.RetryURLs(*store)
+		if len(urls) == 0 {
+			continue
+		}
+		runLogger.Infof("Retrying %d failed products of %s", len(urls), store.StoreName)
+
+		storeExtractor := extractor.New(store.StoreName, config, runLogger.WithField("store", store.StoreName))
+		if storeExtractor == nil {
+			runLogger.Warnf("Unknown store: %s, skipping", store.StoreName)
+			continue
+		}
+		products, missing := retryProducts(ctx, storeExtractor, urls, utils.StoreConcurrency(config, store.StoreName))
+		storeExtractor.Close()
+
+		output.MergeRetry(store, urls, products, missing)
+		retried += len(urls)
+		runLogger.Infof("%s: %d of %d retried products now have size charts", store.StoreName, len(products), len(urls))
+	}
+	if retried == 0 {
+		runLogger.Infof("Run %s has no failed products to retry", runID)
+		return 0
+	}
+
+	// Portfolio totals follow the merged store results
+	portfolios := make(map[string][]string, len(run.Portfolios))
+	for _, portfolio := range run.Portfolios {
+		portfolios[portfolio.Name] = portfolio.Stores
+	}
+	run.Portfolios = types.NewPortfolioResults(portfolios, run.Stores)
+
+	if err := output.SaveRun(config.RunDir, run); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	runLogger.Infof("Merged %d retried products into run %s", retried, runID)
+
+	if *outputPath != "" {
+		sink, err := output.NewSchemaSink(output.NewFileSink(*outputPath), config.SchemaVersion)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		if err := sink.Write(ctx, run); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write results: %v\n", err)
+			return 1
+		}
+		if err := sink.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close output sink: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// retryProducts extracts the product URLs with the given number of workers, returning
+// the products extracted with size charts and the others grouped by failure reason
+func retryProducts(ctx context.Context, storeExtractor extractor.StoreExtractor, urls []string, workers int) ([]types.Product, map[string][]types.MissingProduct) {
+	var (
+		mu       sync.Mutex
+		products []types.Product
+		missing  = make(map[string][]types.MissingProduct)
+		wg       sync.WaitGroup
+		queue    = make(chan string)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for productURL := range queue {
+				product, err := storeExtractor.ExtractProduct(ctx, productURL)
+
+				mu.Lock()
+				if err != nil || product == nil || len(product.SizeCharts) == 0 {
+					entry := types.MissingProduct{ProductURL: productURL}
+					if err != nil {
+						entry.Error = err.Error()
+					}
+					reason := adapters.FailureReason(err)
+					missing[reason] = append(missing[reason], entry)
+				} else {
+					product.SetFingerprints()
+					product.SetSizeLabels()
+					product.SetMeasurements()
+					products = append(products, *product)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, productURL := range urls {
+		queue <- productURL
+	}
+	close(queue)
+	wg.Wait()
+	return products, missing
+}
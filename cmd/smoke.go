@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"shopify-extractor/config"
+	"shopify-extractor/extractor"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/pipeline"
+	"shopify-extractor/smoke"
+	"shopify-extractor/utils"
+)
+
+// runSmoke implements the smoke subcommand: a tiny live extraction of a few products per
+// store whose result is checked against basic invariants (smoke.Check), for a scheduled
+// canary that alerts when a store's theme changes
+func runSmoke(args []string) int {
+	flags := flag.NewFlagSet("smoke", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: shopify_extractor smoke --store DOMAIN[,DOMAIN...] [--products N] [--output FILE]")
+		flags.PrintDefaults()
+	}
+	storeFlag := flags.String("store", "", "Store, or comma-separated stores, to extract")
+	products := flags.Int("products", 3, "Products extracted per store")
+	timeout := flags.Duration("timeout", 5*time.Minute, "Time allowed for the whole extraction")
+	outputPath := flags.String("output", "", "Report file path (default: stdout)")
+	verbose := flags.Bool("verbose", false, "Enable verbose logging")
+	// Delays, retries, browser and store options apply as for extraction
+	config.RegisterFlags(flags)
+	flags.Parse(args)
+
+	if *storeFlag == "" || *products < 1 || flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(os.Stderr)
+	if *verbose {
+		logger.SetLevel(logrus.DebugLevel)
+	}
+
+	settings, err := config.Load(flags, config.ProfileProd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	if err := extractor.LoadPlugins(os.Getenv("ADAPTER_PLUGINS"), logger); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load adapter plugins: %v\n", err)
+		return 1
+	}
+	config := settings.Config
+	config.SampleRate = 0
+	config.SampleCount = *products
+	config.RunID = utils.NewRunID()
+
+	var stores []string
+	for _, store := range strings.Split(*storeFlag, ",") {
+		stores = append(stores, types.CanonicalStore(store))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	runner := pipeline.Runner{}
+	result := runner.Run(ctx, stores, nil, config, logger.WithField("run_id", config.RunID))
+
+	report := smoke.Check(result)
+	for _, store := range report.Stores {
+		logger.Infof("%s: %d products, %d charts, passed: %t", store.StoreName, store.Products, store.Charts, store.Passed)
+	}
+	for _, failure := range report.Failures {
+		logger.Warnf("%s %s (%s): %s", failure.Store, failure.ProductURL, failure.Check, failure.Message)
+	}
+
+	out := os.Stdout
+	if *outputPath != "" {
+		if out, err = os.Create(*outputPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create report: %v\n", err)
+			return 1
+		}
+		defer out.Close()
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+
+	if !report.Passed {
+		return 3
+	}
+	return 0
+}
@@ -0,0 +1,427 @@
+// Package config builds the extractor configuration shared by the CLI and the API.
+//
+// Every setting is resolved from five layers, each overriding the previous one:
+//
+//  1. built-in defaults (types.DefaultConfig)
+//  2. the profile named by --profile or EXTRACTOR_PROFILE (dev, staging or prod)
+//  3. the JSON config file named by --config or CONFIG_FILE
+//  4. environment variables
+//  5. command line flags that were explicitly set
+//
+// The merged configuration is validated as a whole, so every invalid value is reported
+// at once, and can be printed with the layer each value came from. Secret settings may
+// hold a secrets reference (env:NAME, file:/path, vault:path#field) instead of the
+// secret itself.
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"shopify-extractor/internal/types"
+	"shopify-extractor/secrets"
+	"shopify-extractor/utils"
+)
+
+// Sources of a configuration value, in order of precedence
+const (
+	SourceDefault = "default"
+	SourceProfile = "profile"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// sourceRank orders the sources by precedence
+var sourceRank = map[string]int{SourceDefault: 0, SourceProfile: 1, SourceFile: 2, SourceEnv: 3, SourceFlag: 4}
+
+// maxConcurrentRequests is the highest accepted concurrency; more only gets a crawler banned
+const maxConcurrentRequests = 100
+
+// Settings is the effective configuration of a process
+type Settings struct {
+	Config *types.Config
+
+	// Profile is the environment profile that was applied ("" = none)
+	Profile string
+
+	// File is the config file that was loaded ("" = none)
+	File string
+
+	// StoreConfig is the JSON file of per-store options loaded into Config.Stores
+	StoreConfig string
+
+	// sources records which layer set each setting, by config file key
+	sources map[string]string
+
+	// flags are the parsed command line flags, reapplied by Reload
+	flags *flag.FlagSet
+}
+
+// Source returns the layer that set a setting, by config file key
+func (s *Settings) Source(key string) string {
+	if source, ok := s.sources[key]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// Set overrides a setting, by config file key, on behalf of the given source, e.g. for
+// a command line switch that implies other settings
+func (s *Settings) Set(key, value, source string) error {
+	setting, ok := lookup(key)
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	if err := setting.set(s, value); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	s.sources[key] = source
+	return nil
+}
+
+// flagValue is the flag.Value of a setting; the raw value is applied by Load
+type flagValue struct {
+	setting setting
+	def     string
+	value   *string
+}
+
+func (v *flagValue) String() string {
+	if v.value == nil || *v.value == "" {
+		return v.def
+	}
+	return *v.value
+}
+
+func (v *flagValue) Set(value string) error {
+	*v.value = value
+	return nil
+}
+
+// IsBoolFlag lets boolean settings be set with a bare --flag
+func (v *flagValue) IsBoolFlag() bool {
+	return v.setting.isBool
+}
+
+// RegisterFlags defines --config, --profile and a flag for every setting that has one on fs, with
+// the built-in default as the documented default
+func RegisterFlags(fs *flag.FlagSet) {
+	fs.String("config", "", "JSON config file (or set CONFIG_FILE); environment variables and flags override it")
+	fs.String("profile", "", "Environment profile: "+strings.Join(Profiles(), ", ")+" (or set EXTRACTOR_PROFILE)")
+
+	defaults := &Settings{Config: types.DefaultConfig()}
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		usage := s.usage
+		if s.env != "" {
+			usage += " (or set " + s.env + ")"
+		}
+		fs.Var(&flagValue{setting: s, def: s.get(defaults), value: new(string)}, s.flag, usage)
+	}
+}
+
+// Load merges defaults, the profile, the config file, the environment and the flags
+// explicitly set on fs (nil when the process takes no flags), loads the per-store options
+// and validates the result. defaultProfile applies when no profile is selected ("" = none).
+func Load(fs *flag.FlagSet, defaultProfile string) (*Settings, error) {
+	file := os.Getenv("CONFIG_FILE")
+	profile := os.Getenv("EXTRACTOR_PROFILE")
+	if fs != nil {
+		if f := fs.Lookup("config"); f != nil && f.Value.String() != "" {
+			file = f.Value.String()
+		}
+		if f := fs.Lookup("profile"); f != nil && f.Value.String() != "" {
+			profile = f.Value.String()
+		}
+	}
+	if profile == "" {
+		profile = defaultProfile
+	}
+	return load(profile, file, fs)
+}
+
+// load builds the settings from the given profile and config file ("" = none), the
+// environment and fs
+func load(profile, file string, fs *flag.FlagSet) (*Settings, error) {
+	s := &Settings{Config: types.DefaultConfig(), Profile: profile, File: file, sources: make(map[string]string), flags: fs}
+	if err := s.applyProfile(profile); err != nil {
+		return nil, err
+	}
+	if s.File != "" {
+		if err := s.applyFile(s.File); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	if fs != nil {
+		if err := s.applyFlags(fs); err != nil {
+			return nil, err
+		}
+	}
+
+	// SampleCount takes precedence over SampleRate, so a rate set at a higher layer than
+	// the count (e.g. --sample-rate over the dev profile's sample) clears the count
+	if sourceRank[s.Source("sample_rate")] > sourceRank[s.Source("sample_count")] {
+		s.Config.SampleCount = 0
+		s.sources["sample_count"] = s.Source("sample_rate")
+	}
+
+	if err := s.resolveSecrets(); err != nil {
+		return nil, err
+	}
+
+	if s.StoreConfig != "" {
+		stores, err := utils.LoadStoreOptions(s.StoreConfig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid store configuration: %w", err)
+		}
+		s.Config.Stores = stores
+	}
+
+	if err := Validate(s.Config); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// resolveSecrets replaces the secret references held by secret settings with the
+// secrets they point to
+func (s *Settings) resolveSecrets() error {
+	for _, setting := range settings {
+		value := setting.get(s)
+		if !setting.secret || !secrets.IsReference(value) {
+			continue
+		}
+		resolved, err := secrets.Resolve(context.Background(), value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", setting.key, err)
+		}
+		if err := setting.set(s, resolved); err != nil {
+			return fmt.Errorf("%s: %w", setting.key, err)
+		}
+	}
+	return nil
+}
+
+// applyFile applies a JSON object of settings keyed by their config file keys. Values
+// may be strings, numbers, booleans or, for list settings, arrays of strings.
+func (s *Settings) applyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	var unknown []string
+	for key := range values {
+		if _, ok := lookup(key); !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("config file %s: unknown settings: %s", path, strings.Join(unknown, ", "))
+	}
+
+	// Settings are applied in table order so the result does not depend on key order
+	for _, setting := range settings {
+		raw, ok := values[setting.key]
+		if !ok {
+			continue
+		}
+		value, err := fileValue(raw)
+		if err != nil {
+			return fmt.Errorf("config file %s: %s: %w", path, setting.key, err)
+		}
+		if err := setting.set(s, value); err != nil {
+			return fmt.Errorf("config file %s: %s: %w", path, setting.key, err)
+		}
+		s.sources[setting.key] = SourceFile
+	}
+	return nil
+}
+
+// fileValue converts a JSON value of the config file to its flag syntax
+func fileValue(raw interface{}) (string, error) {
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case map[string]interface{}:
+		// Portfolios: {"womenswear": ["westside.com", "suqah.com"]}, politeness pools:
+		// {"tata:2s": ["westside.com", "zudio.com"]}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		groups := make([]string, 0, len(v))
+		for _, name := range names {
+			members, err := fileValue(v[name])
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", name, err)
+			}
+			groups = append(groups, name+"="+members)
+		}
+		return strings.Join(groups, ";"), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return "", fmt.Errorf("list items must be strings")
+			}
+			items = append(items, text)
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", raw)
+}
+
+// applyEnv applies the environment variables of the settings. Chrome launch options are
+// read by utils.LoadBrowserOptions, the same as for every other browser user.
+func (s *Settings) applyEnv() error {
+	for _, setting := range settings {
+		if setting.env == "" || setting.browser {
+			continue
+		}
+		value := os.Getenv(setting.env)
+		if value == "" {
+			continue
+		}
+		if err := setting.set(s, value); err != nil {
+			return fmt.Errorf("%s: %w", setting.env, err)
+		}
+		s.sources[setting.key] = SourceEnv
+	}
+
+	before := make(map[string]string)
+	for _, setting := range settings {
+		if setting.browser {
+			before[setting.key] = setting.get(s)
+		}
+	}
+	s.Config.Browser = utils.LoadBrowserOptions(s.Config.Browser)
+	for _, setting := range settings {
+		if setting.browser && setting.get(s) != before[setting.key] {
+			s.sources[setting.key] = SourceEnv
+		}
+	}
+	return nil
+}
+
+// applyFlags applies the setting flags explicitly set on the command line
+func (s *Settings) applyFlags(fs *flag.FlagSet) error {
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		v, ok := f.Value.(*flagValue)
+		if !ok || err != nil {
+			return
+		}
+		if setErr := v.setting.set(s, *v.value); setErr != nil {
+			err = fmt.Errorf("--%s: %w", f.Name, setErr)
+			return
+		}
+		s.sources[v.setting.key] = SourceFlag
+	})
+	return err
+}
+
+// Validate reports every invalid value of a configuration in one error
+func Validate(c *types.Config) error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.RequestDelay >= 0, "request_delay must not be negative (got %v)", c.RequestDelay)
+	check(c.MaxRetries >= 0, "max_retries must not be negative (got %d)", c.MaxRetries)
+	check(c.Timeout > 0, "timeout must be positive (got %v)", c.Timeout)
+	check(c.MaxConcurrentRequests >= 1 && c.MaxConcurrentRequests <= maxConcurrentRequests,
+		"max_concurrent_requests must be between 1 and %d (got %d)", maxConcurrentRequests, c.MaxConcurrentRequests)
+	check(c.SampleRate >= 0 && c.SampleRate <= 1, "sample_rate must be between 0 and 1 (got %v)", c.SampleRate)
+	check(c.SampleCount >= 0, "sample_count must not be negative (got %d)", c.SampleCount)
+	check(c.CrawlOrder == "" || utils.ValidCrawlOrder(c.CrawlOrder),
+		"crawl_order must be one of: %s (got %q)", strings.Join(utils.CrawlOrders(), ", "), c.CrawlOrder)
+	check(c.ProductTimeout >= 0, "product_timeout must not be negative (got %v)", c.ProductTimeout)
+	check(c.QueueRetryDelay >= 0, "queue_retry_delay must not be negative (got %v)", c.QueueRetryDelay)
+	check(c.DiscoveryTimeout >= 0, "discovery_timeout must not be negative (got %v)", c.DiscoveryTimeout)
+	check(c.FailureBudgetAttempts >= 0, "failure_budget must not be negative (got %d)", c.FailureBudgetAttempts)
+	check(c.FailureBudgetPercent >= 0 && c.FailureBudgetPercent <= 100,
+		"failure_budget_percent must be between 0 and 100 (got %v)", c.FailureBudgetPercent)
+	check(utils.ValidDiscoveryMode(c.DiscoveryMode),
+		"discovery_mode must be one of: %s (got %q)", strings.Join(utils.DiscoveryModes(), ", "), c.DiscoveryMode)
+	for store, options := range c.Stores {
+		check(utils.ValidDiscoveryMode(options.Discovery),
+			"discovery of store %s must be one of: %s (got %q)", store, strings.Join(utils.DiscoveryModes(), ", "), options.Discovery)
+		check(options.Concurrency >= 0 && options.Concurrency <= maxConcurrentRequests,
+			"concurrency of store %s must be between 0 and %d (got %d)", store, maxConcurrentRequests, options.Concurrency)
+	}
+	for name, stores := range c.Portfolios {
+		check(name != "" && !strings.Contains(name, "."), "portfolio name %q must not be empty or look like a store domain", name)
+		check(len(stores) > 0, "portfolio %q must list at least one store", name)
+	}
+	for name, pool := range c.PolitenessPools {
+		check(name != "", "politeness pool names must not be empty")
+		check(len(pool.Hosts) > 0, "politeness pool %q must list at least one host", name)
+		check(pool.Delay > 0, "politeness pool %q delay must be positive (got %v)", name, pool.Delay)
+	}
+	check(types.ValidSchemaVersion(c.SchemaVersion), "schema_version must be one of %v (got %d)", types.SchemaVersions(), c.SchemaVersion)
+	check(c.FrontierMemoryLimit >= 0, "frontier_memory_limit must not be negative (got %d)", c.FrontierMemoryLimit)
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+}
+
+// Log prints the effective configuration, one setting per line with the layer it came
+// from. Secrets are redacted.
+func (s *Settings) Log(logger types.Logger) {
+	var origin []string
+	if s.Profile != "" {
+		origin = append(origin, "profile "+s.Profile)
+	}
+	if s.File != "" {
+		origin = append(origin, "config file "+s.File)
+	}
+	if len(origin) > 0 {
+		logger.Infof("Effective configuration (%s):", strings.Join(origin, ", "))
+	} else {
+		logger.Infof("Effective configuration:")
+	}
+	for _, setting := range settings {
+		value := setting.get(s)
+		if setting.secret && value != "" {
+			value = "<redacted>"
+		}
+		if value == "" {
+			value = `""`
+		}
+		logger.Infof("  %-24s %-40s (%s)", setting.key, value, s.Source(setting.key))
+	}
+	if len(s.Config.Stores) > 0 {
+		logger.Infof("  %-24s %d stores", "stores", len(s.Config.Stores))
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"shopify-extractor/internal/types"
+)
+
+// parsePolitenessPools parses the politeness_pools setting, semicolon-separated
+// name:delay=host,host groups
+func parsePolitenessPools(value string) (map[string]types.PolitenessPool, error) {
+	pools := make(map[string]types.PolitenessPool)
+	for _, group := range strings.Split(value, ";") {
+		if strings.TrimSpace(group) == "" {
+			continue
+		}
+		key, members, ok := strings.Cut(group, "=")
+		name, delayText, hasDelay := strings.Cut(strings.TrimSpace(key), ":")
+		if !ok || !hasDelay || name == "" {
+			return nil, fmt.Errorf("invalid politeness pool %q, want name:delay=host,host", group)
+		}
+		delay, err := time.ParseDuration(strings.TrimSpace(delayText))
+		if err != nil {
+			return nil, fmt.Errorf("invalid delay of politeness pool %s: %w", name, err)
+		}
+		pool := types.PolitenessPool{Delay: delay}
+		for _, host := range strings.Split(members, ",") {
+			if host = types.CanonicalStore(host); host != "" {
+				pool.Hosts = append(pool.Hosts, host)
+			}
+		}
+		pools[name] = pool
+	}
+	if len(pools) == 0 {
+		return nil, nil
+	}
+	return pools, nil
+}
+
+// formatPolitenessPools renders pools in the syntax of the politeness_pools setting,
+// sorted by name
+func formatPolitenessPools(pools map[string]types.PolitenessPool) string {
+	names := make([]string, 0, len(pools))
+	for name := range pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	groups := make([]string, 0, len(pools))
+	for _, name := range names {
+		groups = append(groups, fmt.Sprintf("%s:%v=%s", name, pools[name].Delay, strings.Join(pools[name].Hosts, ",")))
+	}
+	return strings.Join(groups, ";")
+}
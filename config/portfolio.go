@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"shopify-extractor/internal/types"
+)
+
+// parsePortfolios parses the "name=store,store;name=store" syntax of the portfolios setting
+func parsePortfolios(value string) (map[string][]string, error) {
+	portfolios := make(map[string][]string)
+	for _, group := range strings.Split(value, ";") {
+		if strings.TrimSpace(group) == "" {
+			continue
+		}
+		name, members, ok := strings.Cut(group, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid portfolio %q, want name=store,store", group)
+		}
+		stores := []string{}
+		for _, store := range strings.Split(members, ",") {
+			if store = strings.ToLower(strings.TrimSpace(store)); store != "" {
+				stores = append(stores, store)
+			}
+		}
+		portfolios[name] = stores
+	}
+	if len(portfolios) == 0 {
+		return nil, nil
+	}
+	return portfolios, nil
+}
+
+// formatPortfolios renders portfolios in the syntax of the portfolios setting, sorted by name
+func formatPortfolios(portfolios map[string][]string) string {
+	groups := make([]string, 0, len(portfolios))
+	for _, name := range PortfolioNames(portfolios) {
+		groups = append(groups, name+"="+strings.Join(portfolios[name], ","))
+	}
+	return strings.Join(groups, ";")
+}
+
+// PortfolioNames returns the names of the portfolios in sorted order
+func PortfolioNames(portfolios map[string][]string) []string {
+	names := make([]string, 0, len(portfolios))
+	for name := range portfolios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveStores expands the portfolio names among names into their stores, keeping the
+// order of first appearance and dropping duplicates. It returns the stores to extract and
+// the requested portfolios with their stores; names that are not portfolios are stores,
+// returned as their canonical domain (types.CanonicalStore).
+func ResolveStores(c *types.Config, names []string) ([]string, map[string][]string) {
+	var stores []string
+	requested := make(map[string][]string)
+	seen := make(map[string]bool)
+	add := func(store string) {
+		key := types.CanonicalStore(store)
+		if !seen[key] {
+			seen[key] = true
+			stores = append(stores, key)
+		}
+	}
+
+	for _, name := range names {
+		if members, ok := c.Portfolios[name]; ok {
+			requested[name] = members
+			for _, store := range members {
+				add(store)
+			}
+			continue
+		}
+		add(name)
+	}
+	return stores, requested
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Profile names
+const (
+	ProfileDev     = "dev"
+	ProfileStaging = "staging"
+	ProfileProd    = "prod"
+)
+
+// profiles hold the defaults of each environment, by config file key. A profile replaces
+// built-in defaults only; the config file, the environment and flags still override it.
+var profiles = map[string]map[string]string{
+	// dev: a quick look at a few products with tight timeouts
+	ProfileDev: {
+		"sample_count":            "10",
+		"max_concurrent_requests": "2",
+		"max_retries":             "1",
+		"request_delay":           "500ms",
+		"timeout":                 "15s",
+		"product_timeout":         "20s",
+		"discovery_timeout":       "2m",
+		"failure_budget":          "5",
+	},
+	// staging: a representative sample at production politeness
+	ProfileStaging: {
+		"sample_count":            "100",
+		"max_concurrent_requests": "3",
+		"max_retries":             "2",
+		"request_delay":           "1s",
+		"timeout":                 "30s",
+		"product_timeout":         "45s",
+	},
+	// prod: the full catalog, conservative delays and patient retries
+	ProfileProd: {
+		"sample_count":            "0",
+		"sample_rate":             "0",
+		"max_concurrent_requests": "3",
+		"max_retries":             "3",
+		"request_delay":           "2s",
+		"timeout":                 "45s",
+		"product_timeout":         "90s",
+	},
+}
+
+// Profiles returns the profile names in sorted order
+func Profiles() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyProfile applies the defaults of a profile ("" = built-in defaults only)
+func (s *Settings) applyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	values, ok := profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(Profiles(), ", "))
+	}
+	for _, setting := range settings {
+		value, ok := values[setting.key]
+		if !ok {
+			continue
+		}
+		if err := setting.set(s, value); err != nil {
+			return fmt.Errorf("profile %s: %s: %w", name, setting.key, err)
+		}
+		s.sources[setting.key] = SourceProfile
+	}
+	return nil
+}
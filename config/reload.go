@@ -0,0 +1,69 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ReloadResult describes how a reload changed the running configuration
+type ReloadResult struct {
+	// Settings is the configuration to run with: the current one with every reloadable
+	// change applied
+	Settings *Settings
+
+	// Applied and Rejected list the changed settings by config file key; rejected
+	// changes only take effect after a restart
+	Applied  []string
+	Rejected []string
+}
+
+// Reload re-reads the config file, the per-store options and the environment of current,
+// reapplies its flags and returns current updated with the changes that are safe at
+// runtime (rate limits, timeouts, budgets, store options). Changes to settings checked
+// only at startup, such as Chrome launch options, are rejected. current is not modified;
+// an invalid configuration returns an error and leaves it in effect.
+func Reload(current *Settings) (*ReloadResult, error) {
+	next, err := load(current.Profile, current.File, current.flags)
+	if err != nil {
+		return nil, err
+	}
+
+	config := *current.Config
+	merged := &Settings{
+		Config:      &config,
+		Profile:     current.Profile,
+		File:        current.File,
+		StoreConfig: current.StoreConfig,
+		sources:     make(map[string]string, len(current.sources)),
+		flags:       current.flags,
+	}
+	for key, source := range current.sources {
+		merged.sources[key] = source
+	}
+
+	result := &ReloadResult{Settings: merged}
+	for _, setting := range settings {
+		value := setting.get(next)
+		if value == setting.get(current) {
+			continue
+		}
+		if setting.restart {
+			result.Rejected = append(result.Rejected, setting.key)
+			continue
+		}
+		if err := setting.set(merged, value); err != nil {
+			return nil, err
+		}
+		merged.sources[setting.key] = next.Source(setting.key)
+		result.Applied = append(result.Applied, setting.key)
+	}
+
+	// Store options may change in the store config file without its path changing
+	if !reflect.DeepEqual(next.Config.Stores, current.Config.Stores) {
+		merged.Config.Stores = next.Config.Stores
+		result.Applied = append(result.Applied, "stores")
+	}
+	sort.Strings(result.Applied)
+	sort.Strings(result.Rejected)
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	stores := writeFile(t, "stores.json", `{"westside.com": {"headers": {"Referer": "https://www.westside.com/"}}}`)
+	path := writeFile(t, "config.json", `{"request_delay": "1s", "store_config": "`+stores+`"}`)
+	t.Setenv("CONFIG_FILE", path)
+
+	current, err := Load(nil, "")
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"request_delay": "4s", "max_concurrent_requests": 2, "browser_backend": "rod", "store_config": "`+stores+`"}`), 0o644))
+	require.NoError(t, os.WriteFile(stores, []byte(`{"westside.com": {"headers": {"Referer": "https://westside.com/"}}}`), 0o644))
+
+	result, err := Reload(current)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"max_concurrent_requests", "request_delay", "stores"}, result.Applied)
+	assert.Equal(t, []string{"browser_backend"}, result.Rejected)
+
+	next := result.Settings.Config
+	assert.Equal(t, 4*time.Second, next.RequestDelay)
+	assert.Equal(t, 2, next.MaxConcurrentRequests)
+	assert.Equal(t, "", next.Browser.Backend)
+	assert.Equal(t, "https://westside.com/", next.Stores["westside.com"].Headers["Referer"])
+	assert.Equal(t, SourceFile, result.Settings.Source("max_concurrent_requests"))
+
+	// The current settings are left untouched
+	assert.Equal(t, time.Second, current.Config.RequestDelay)
+}
+
+func TestReload_InvalidKeepsCurrent(t *testing.T) {
+	path := writeFile(t, "config.json", `{"request_delay": "1s"}`)
+	t.Setenv("CONFIG_FILE", path)
+	current, err := Load(nil, "")
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"request_delay": "-1s"}`), 0o644))
+	_, err = Reload(current)
+	assert.ErrorContains(t, err, "request_delay must not be negative")
+}
+
+func TestWatch(t *testing.T) {
+	path := writeFile(t, "config.json", `{}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{}, 1)
+	go Watch(ctx, 10*time.Millisecond, []string{path}, func() { changes <- struct{}{} })
+
+	time.Sleep(30 * time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte(`{"max_retries": 1}`), 0o644))
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("change not detected")
+	}
+}
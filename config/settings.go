@@ -0,0 +1,300 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+)
+
+// setting is one configuration value, settable from the config file, the environment
+// and the command line
+type setting struct {
+	key    string // config file key, e.g. "request_delay"
+	env    string // environment variable ("" = none)
+	flag   string // command line flag ("" = none)
+	usage  string
+	secret bool // redacted when the configuration is printed
+
+	// browser settings are read from the environment by utils.LoadBrowserOptions
+	browser bool
+
+	// restart settings are only checked at startup, so Reload rejects changes to them
+	restart bool
+
+	isBool bool
+	get    func(s *Settings) string
+	set    func(s *Settings, value string) error
+}
+
+// durationSetting returns a setting backed by a time.Duration field
+func durationSetting(key, env, flag, usage string, field func(c *types.Config) *time.Duration) setting {
+	return setting{key: key, env: env, flag: flag, usage: usage,
+		get: func(s *Settings) string { return field(s.Config).String() },
+		set: func(s *Settings, value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid duration %q", value)
+			}
+			*field(s.Config) = d
+			return nil
+		},
+	}
+}
+
+// intSetting returns a setting backed by an int field
+func intSetting(key, env, flag, usage string, field func(c *types.Config) *int) setting {
+	return setting{key: key, env: env, flag: flag, usage: usage,
+		get: func(s *Settings) string { return strconv.Itoa(*field(s.Config)) },
+		set: func(s *Settings, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid integer %q", value)
+			}
+			*field(s.Config) = n
+			return nil
+		},
+	}
+}
+
+// int64Setting returns a setting backed by an int64 field
+func int64Setting(key, env, flag, usage string, field func(c *types.Config) *int64) setting {
+	return setting{key: key, env: env, flag: flag, usage: usage,
+		get: func(s *Settings) string { return strconv.FormatInt(*field(s.Config), 10) },
+		set: func(s *Settings, value string) error {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid integer %q", value)
+			}
+			*field(s.Config) = n
+			return nil
+		},
+	}
+}
+
+// floatSetting returns a setting backed by a float64 field
+func floatSetting(key, env, flag, usage string, field func(c *types.Config) *float64) setting {
+	return setting{key: key, env: env, flag: flag, usage: usage,
+		get: func(s *Settings) string { return strconv.FormatFloat(*field(s.Config), 'f', -1, 64) },
+		set: func(s *Settings, value string) error {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid number %q", value)
+			}
+			*field(s.Config) = f
+			return nil
+		},
+	}
+}
+
+// boolSetting returns a setting backed by a bool field
+func boolSetting(key, env, flag, usage string, field func(c *types.Config) *bool) setting {
+	return setting{key: key, env: env, flag: flag, usage: usage, isBool: true,
+		get: func(s *Settings) string { return strconv.FormatBool(*field(s.Config)) },
+		set: func(s *Settings, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q", value)
+			}
+			*field(s.Config) = b
+			return nil
+		},
+	}
+}
+
+// stringSetting returns a setting backed by a string field
+func stringSetting(key, env, flag, usage string, field func(c *types.Config) *string) setting {
+	return setting{key: key, env: env, flag: flag, usage: usage,
+		get: func(s *Settings) string { return *field(s.Config) },
+		set: func(s *Settings, value string) error {
+			*field(s.Config) = value
+			return nil
+		},
+	}
+}
+
+// browserSetting marks a setting as a Chrome launch option. The browser is checked at
+// startup, so launch options cannot be reloaded.
+func browserSetting(s setting) setting {
+	s.browser = true
+	s.restart = true
+	return s
+}
+
+// restartSetting marks a setting as requiring a restart to change
+func restartSetting(s setting) setting {
+	s.restart = true
+	return s
+}
+
+// secretSetting marks a setting as redacted when printed. Its value may be a secret
+// reference (env:, file:, vault:), resolved once every layer is applied.
+func secretSetting(s setting) setting {
+	s.secret = true
+	return s
+}
+
+// settings lists every configuration value, in the order the effective configuration
+// is printed
+var settings = []setting{
+	durationSetting("request_delay", "REQUEST_DELAY", "delay", "Delay between requests",
+		func(c *types.Config) *time.Duration { return &c.RequestDelay }),
+	intSetting("max_retries", "MAX_RETRIES", "retries", "Maximum retry attempts",
+		func(c *types.Config) *int { return &c.MaxRetries }),
+	durationSetting("timeout", "HTTP_TIMEOUT", "timeout", "Request timeout",
+		func(c *types.Config) *time.Duration { return &c.Timeout }),
+	intSetting("max_concurrent_requests", "MAX_CONCURRENT_REQUESTS", "concurrent", "Maximum concurrent requests",
+		func(c *types.Config) *int { return &c.MaxConcurrentRequests }),
+	restartSetting(boolSetting("use_browser", "USE_HEADLESS_BROWSER", "browser", "Use headless browser for JavaScript-heavy sites",
+		func(c *types.Config) *bool { return &c.UseHeadlessBrowser })),
+	stringSetting("user_agent", "USER_AGENT", "user-agent", "User agent of HTTP requests and the browser",
+		func(c *types.Config) *string { return &c.UserAgent }),
+	floatSetting("sample_rate", "", "sample-rate", "Extract a random fraction (0-1) of discovered products per store",
+		func(c *types.Config) *float64 { return &c.SampleRate }),
+	intSetting("sample_count", "", "sample-count", "Extract a random fixed number of discovered products per store (overrides --sample-rate)",
+		func(c *types.Config) *int { return &c.SampleCount }),
+	int64Setting("sample_seed", "", "seed", "Random seed for product sampling (0 = random)",
+		func(c *types.Config) *int64 { return &c.SampleSeed }),
+	stringSetting("crawl_order", "", "order", "Order products are extracted in: discovery, alphabetical (by handle) or random",
+		func(c *types.Config) *string { return &c.CrawlOrder }),
+	int64Setting("crawl_seed", "", "order-seed", "Random seed for --order=random (0 = different order every run)",
+		func(c *types.Config) *int64 { return &c.CrawlSeed }),
+	stringSetting("discovery_mode", "DISCOVERY_MODE", "discovery", "How Shopify stores discover products: html (listing and collection pages) or json (/products.json)",
+		func(c *types.Config) *string { return &c.DiscoveryMode }),
+	boolSetting("estimate_coverage", "ESTIMATE_COVERAGE", "coverage", "Count each store's catalog via /products.json to report coverage (extra requests, paced by request_delay)",
+		func(c *types.Config) *bool { return &c.EstimateCoverage }),
+	boolSetting("include_raw", "INCLUDE_RAW", "include-raw", "Keep the original table (all columns, original headers) of each filtered size chart under raw",
+		func(c *types.Config) *bool { return &c.IncludeRaw }),
+	intSetting("schema_version", "SCHEMA_VERSION", "schema-version", "Structure of the written results: 1 for the original one, 2 for the current one (0 = current)",
+		func(c *types.Config) *int { return &c.SchemaVersion }),
+	boolSetting("trace", "EXTRACTION_TRACE", "trace", "Attach each product's extraction diagnostics, at every log level, to the results as trace",
+		func(c *types.Config) *bool { return &c.Trace }),
+	boolSetting("fetch_fallback", "FETCH_FALLBACK", "fetch-fallback", "Retry failed browser fetches over HTTP and render static pages missing a size chart in the browser",
+		func(c *types.Config) *bool { return &c.FetchFallback }),
+	durationSetting("product_timeout", "PRODUCT_TIMEOUT", "product-timeout", "Deadline for extracting one product, browser fallback included (0 = no limit)",
+		func(c *types.Config) *time.Duration { return &c.ProductTimeout }),
+	durationSetting("discovery_timeout", "DISCOVERY_TIMEOUT", "discovery-timeout", "Time budget of product discovery per store; past it the products found so far are extracted (0 = no limit)",
+		func(c *types.Config) *time.Duration { return &c.DiscoveryTimeout }),
+	intSetting("failure_budget", "FAILURE_BUDGET_ATTEMPTS", "failure-budget", "Abort a store as unreachable when too many of its first N product fetches fail (0 = never abort)",
+		func(c *types.Config) *int { return &c.FailureBudgetAttempts }),
+	floatSetting("failure_budget_percent", "FAILURE_BUDGET_PERCENT", "failure-budget-percent", "Percentage of the first --failure-budget fetches that may fail before the store is aborted",
+		func(c *types.Config) *float64 { return &c.FailureBudgetPercent }),
+	intSetting("frontier_memory_limit", "FRONTIER_MEMORY_LIMIT", "frontier-memory", "Discovered URLs kept in memory per store before spilling to disk",
+		func(c *types.Config) *int { return &c.FrontierMemoryLimit }),
+	stringSetting("frontier_dir", "FRONTIER_DIR", "frontier-dir", "Directory for spilled discovery data (default: system temp directory)",
+		func(c *types.Config) *string { return &c.FrontierDir }),
+	stringSetting("archive_dir", "ARCHIVE_DIR", "archive-dir", "Archive the raw HTML of every product page in this directory for later re-parsing",
+		func(c *types.Config) *string { return &c.ArchiveDir }),
+	stringSetting("warc_dir", "WARC_DIR", "warc-dir", "Record every product page in WARC files in this directory",
+		func(c *types.Config) *string { return &c.WARCDir }),
+	durationSetting("queue_retry_delay", "QUEUE_RETRY_DELAY", "queue-retry-delay", "Wait this long and retry when a store sends visitors to a queue page (0 = report the product as queued)",
+		func(c *types.Config) *time.Duration { return &c.QueueRetryDelay }),
+	stringSetting("chart_image_dir", "CHART_IMAGE_DIR", "chart-images", "Save a PNG snapshot of every extracted size chart in this directory for visual QA",
+		func(c *types.Config) *string { return &c.ChartImageDir }),
+	stringSetting("parser_cache_file", "PARSER_CACHE_FILE", "parser-cache", "Remember across runs which parser or selector found each store's size charts in this JSON file",
+		func(c *types.Config) *string { return &c.ParserCacheFile }),
+	stringSetting("run_dir", "RUN_DIR", "run-dir", "Store every run's result and failed product URLs in this directory for retry-run",
+		func(c *types.Config) *string { return &c.RunDir }),
+	{key: "store_config", env: "STORE_CONFIG", flag: "store-config", usage: "JSON file of per-store options, e.g. request headers",
+		get: func(s *Settings) string { return s.StoreConfig },
+		set: func(s *Settings, value string) error {
+			s.StoreConfig = value
+			return nil
+		},
+	},
+	{key: "portfolios", env: "PORTFOLIOS", flag: "portfolios", usage: "Named groups of stores, e.g. womenswear=westside.com,suqah.com;ethnic=suqah.com",
+		get: func(s *Settings) string { return formatPortfolios(s.Config.Portfolios) },
+		set: func(s *Settings, value string) error {
+			portfolios, err := parsePortfolios(value)
+			if err != nil {
+				return err
+			}
+			s.Config.Portfolios = portfolios
+			return nil
+		},
+	},
+
+	{key: "politeness_pools", env: "POLITENESS_POOLS", flag: "politeness-pools", usage: "Hosts sharing one rate limit, e.g. tata:2s=westside.com,zudio.com;other:500ms=a.com,b.com",
+		get: func(s *Settings) string { return formatPolitenessPools(s.Config.PolitenessPools) },
+		set: func(s *Settings, value string) error {
+			pools, err := parsePolitenessPools(value)
+			if err != nil {
+				return err
+			}
+			s.Config.PolitenessPools = pools
+			return nil
+		},
+	},
+
+	browserSetting(boolSetting("headful", "CHROME_HEADFUL", "headful", "Show the browser window instead of running headless",
+		func(c *types.Config) *bool { return &c.Browser.Headful })),
+	browserSetting(boolSetting("new_headless", "CHROME_NEW_HEADLESS", "new-headless", "Use Chrome's new headless mode",
+		func(c *types.Config) *bool { return &c.Browser.NewHeadless })),
+	browserSetting(boolSetting("no_sandbox", "CHROME_NO_SANDBOX", "no-sandbox", "Disable the Chrome sandbox (needed in most containers)",
+		func(c *types.Config) *bool { return &c.Browser.NoSandbox })),
+	browserSetting(setting{key: "window_size", env: "CHROME_WINDOW_SIZE", flag: "window-size", usage: "Browser window size as WIDTHxHEIGHT",
+		get: func(s *Settings) string {
+			return fmt.Sprintf("%dx%d", s.Config.Browser.WindowWidth, s.Config.Browser.WindowHeight)
+		},
+		set: func(s *Settings, value string) error {
+			width, height, ok := utils.ParseWindowSize(value)
+			if !ok {
+				return fmt.Errorf("window size must be WIDTHxHEIGHT, e.g. 1280x800, not %q", value)
+			}
+			s.Config.Browser.WindowWidth, s.Config.Browser.WindowHeight = width, height
+			return nil
+		},
+	}),
+	browserSetting(stringSetting("user_data_dir", "CHROME_USER_DATA_DIR", "user-data-dir", "Chrome profile directory (default: temporary profile)",
+		func(c *types.Config) *string { return &c.Browser.UserDataDir })),
+	secretSetting(browserSetting(stringSetting("proxy", "CHROME_PROXY", "proxy", "Proxy server for browser traffic (may embed credentials)",
+		func(c *types.Config) *string { return &c.Browser.ProxyServer }))),
+	browserSetting(stringSetting("lang", "CHROME_LANG", "lang", "Browser language, e.g. en-US",
+		func(c *types.Config) *string { return &c.Browser.Language })),
+	browserSetting(setting{key: "chrome_flags", env: "CHROME_FLAGS", flag: "chrome-flags", usage: "Comma-separated extra Chrome switches, e.g. --disable-gpu,--foo=bar",
+		get: func(s *Settings) string { return strings.Join(s.Config.Browser.ExtraFlags, ",") },
+		set: func(s *Settings, value string) error {
+			for _, chromeFlag := range strings.Split(value, ",") {
+				if chromeFlag = strings.TrimSpace(chromeFlag); chromeFlag != "" {
+					s.Config.Browser.ExtraFlags = append(s.Config.Browser.ExtraFlags, chromeFlag)
+				}
+			}
+			return nil
+		},
+	}),
+	browserSetting(stringSetting("chrome_path", "CHROME_PATH", "", "Chrome binary to launch",
+		func(c *types.Config) *string { return &c.Browser.ExecPath })),
+	browserSetting(boolSetting("stealth", "CHROME_STEALTH", "stealth", "Hide common headless browser tells from store pages",
+		func(c *types.Config) *bool { return &c.Browser.Stealth })),
+	browserSetting(stringSetting("timezone", "CHROME_TIMEZONE", "timezone", "IANA timezone reported to pages in stealth mode, e.g. Asia/Kolkata",
+		func(c *types.Config) *string { return &c.Browser.Timezone })),
+	browserSetting(stringSetting("browser_backend", "BROWSER_BACKEND", "browser-backend", "Browser automation backend: chromedp (default), rod or render",
+		func(c *types.Config) *string { return &c.Browser.Backend })),
+	browserSetting(setting{key: "render_url", env: "RENDER_SERVICE_URL", flag: "render-url", usage: "Rendering service base URL for the render backend (token via RENDER_SERVICE_TOKEN)",
+		get: func(s *Settings) string { return s.Config.Browser.RenderServiceURL },
+		set: func(s *Settings, value string) error {
+			s.Config.Browser.RenderServiceURL = value
+			if value != "" && s.Config.Browser.Backend == "" {
+				s.Config.Browser.Backend = utils.BrowserBackendRender
+			}
+			return nil
+		},
+	}),
+	browserSetting(stringSetting("render_type", "RENDER_SERVICE_TYPE", "render-type", "Rendering service type: browserless, splash or generic",
+		func(c *types.Config) *string { return &c.Browser.RenderServiceType })),
+	secretSetting(browserSetting(stringSetting("render_token", "RENDER_SERVICE_TOKEN", "", "Rendering service API token",
+		func(c *types.Config) *string { return &c.Browser.RenderServiceToken }))),
+}
+
+// lookup returns the setting with the given config file key
+func lookup(key string) (setting, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// fileState identifies a version of a watched file
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// equal reports whether two states are the same version of a file
+func (f fileState) equal(other fileState) bool {
+	return f.exists == other.exists && f.size == other.size && f.modTime.Equal(other.modTime)
+}
+
+// statFile returns the current state of a file
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+}
+
+// Watch polls the given files every interval and calls onChange once per poll in which
+// any of them was created, modified or removed, until ctx is done. Polling keeps the
+// watcher portable and works for files replaced by editors or mounted config maps.
+func Watch(ctx context.Context, interval time.Duration, paths []string, onChange func()) {
+	states := make(map[string]fileState, len(paths))
+	for _, path := range paths {
+		states[path] = statFile(path)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed := false
+		for _, path := range paths {
+			if state := statFile(path); !state.equal(states[path]) {
+				states[path] = state
+				changed = true
+			}
+		}
+		if changed {
+			onChange()
+		}
+	}
+}
+
+// WatchedFiles returns the files a reload reads: the config file and the per-store
+// options file
+func (s *Settings) WatchedFiles() []string {
+	var paths []string
+	if s.File != "" {
+		paths = append(paths, s.File)
+	}
+	if s.StoreConfig != "" {
+		paths = append(paths, s.StoreConfig)
+	}
+	return paths
+}
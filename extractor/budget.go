@@ -0,0 +1,62 @@
+package extractor
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"shopify-extractor/adapters"
+	"shopify-extractor/internal/types"
+)
+
+// ErrStoreUnreachable is returned (wrapped) by ExtractAll when a store exhausted its
+// failure budget: too many of its first product fetches failed to keep crawling it
+var ErrStoreUnreachable = errors.New("store unreachable or blocked")
+
+// failureBudget tracks the outcome of a store's first product fetches. A nil budget is
+// disabled and never exhausted.
+type failureBudget struct {
+	mu       sync.Mutex
+	limit    int
+	percent  float64
+	attempts int
+	failures int
+}
+
+// newFailureBudget returns the failure budget configured for a run, or nil when it is
+// disabled
+func newFailureBudget(config *types.Config) *failureBudget {
+	if config.FailureBudgetAttempts <= 0 || config.FailureBudgetPercent <= 0 || config.FailureBudgetPercent >= 100 {
+		return nil
+	}
+	return &failureBudget{limit: config.FailureBudgetAttempts, percent: config.FailureBudgetPercent}
+}
+
+// record counts the outcome of a product extraction and returns a non-nil error once
+// more than the allowed share of the first attempts failed to fetch. The budget is
+// exhausted as soon as the failures make that share unavoidable, without waiting for
+// the remaining attempts.
+func (b *failureBudget) record(err error) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.attempts >= b.limit {
+		return nil
+	}
+	b.attempts++
+	if err != nil {
+		switch adapters.FailureReason(err) {
+		case types.MissingReasonFetchBlocked, types.MissingReasonPasswordPage, types.MissingReasonChallenge, types.MissingReasonQueue:
+			b.failures++
+		}
+	}
+	if float64(b.failures)*100 <= b.percent*float64(b.limit) {
+		return nil
+	}
+	err = fmt.Errorf("%w: %d of %d product fetches failed (budget: %.0f%% of the first %d)", ErrStoreUnreachable, b.failures, b.attempts, b.percent, b.limit)
+	b.attempts = b.limit // report the exhaustion only once
+	return err
+}
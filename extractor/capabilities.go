@@ -0,0 +1,68 @@
+package extractor
+
+import "sync"
+
+// Discovery strategies reported in Capabilities
+const (
+	DiscoveryCollections  = "collections"   // collection pages linked from the storefront's /products page
+	DiscoveryProductsJSON = "products_json" // Shopify's public /products.json endpoint (discovery_mode json)
+	DiscoveryStream       = "stream"        // adapter-defined streaming discovery (external adapters)
+)
+
+// Capabilities describes what a store's adapter supports, so callers can judge a store
+// before committing to a full crawl
+type Capabilities struct {
+	DiscoveryStrategies []string `json:"discovery_strategies"`
+	RequiresBrowser     bool     `json:"requires_browser"`
+	ChartParsers        []string `json:"chart_parsers"`
+}
+
+var (
+	capabilitiesMu sync.RWMutex
+	capabilities   = map[string]Capabilities{
+		"westside.com": {
+			DiscoveryStrategies: []string{DiscoveryCollections, DiscoveryProductsJSON},
+			RequiresBrowser:     true,
+			ChartParsers:        []string{"dual-unit"},
+		},
+		"littleboxindia.com": {
+			DiscoveryStrategies: []string{DiscoveryCollections, DiscoveryProductsJSON},
+			ChartParsers:        []string{"kiwi"},
+		},
+		"suqah.com": {
+			DiscoveryStrategies: []string{DiscoveryCollections, DiscoveryProductsJSON},
+			RequiresBrowser:     true,
+			ChartParsers:        []string{"generic-table"},
+		},
+		"nykaafashion.com": {
+			DiscoveryStrategies: []string{DiscoveryCollections, DiscoveryProductsJSON},
+			ChartParsers:        []string{"tabbed"},
+		},
+	}
+)
+
+// RegisterCapabilities describes the adapter registered for a store domain
+func RegisterCapabilities(domain string, caps Capabilities) {
+	capabilitiesMu.Lock()
+	defer capabilitiesMu.Unlock()
+	capabilities[normalizeDomain(domain)] = caps
+}
+
+// CapabilitiesOf returns the capabilities of a supported store. Stores registered without
+// a description report the streaming discovery used by every external adapter.
+func CapabilitiesOf(domain string) (Capabilities, bool) {
+	if !Supports(domain) {
+		return Capabilities{}, false
+	}
+
+	capabilitiesMu.RLock()
+	defer capabilitiesMu.RUnlock()
+	caps, ok := capabilities[normalizeDomain(domain)]
+	if !ok {
+		caps = Capabilities{DiscoveryStrategies: []string{DiscoveryStream}}
+	}
+	if caps.ChartParsers == nil {
+		caps.ChartParsers = []string{}
+	}
+	return caps, true
+}
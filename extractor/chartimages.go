@@ -0,0 +1,22 @@
+package extractor
+
+import (
+	"path/filepath"
+
+	"shopify-extractor/internal/types"
+	"shopify-extractor/render"
+)
+
+// SaveChartImages writes PNG snapshots of a product's size charts under
+// Config.ChartImageDir, in a directory per run and store, so QA can compare them with
+// the page. It does nothing when ChartImageDir is not set; failures are only logged,
+// as snapshots never affect the extraction.
+func SaveChartImages(config *types.Config, store string, product *types.Product, logger types.Logger) {
+	if config.ChartImageDir == "" || len(product.SizeCharts) == 0 {
+		return
+	}
+	dir := filepath.Join(config.ChartImageDir, config.RunID, store)
+	if _, err := render.SaveChartImages(dir, *product); err != nil {
+		logger.Warnf("Failed to save size chart images of %s: %v", product.ProductURL, err)
+	}
+}
@@ -0,0 +1,169 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+)
+
+// ExternalAdapter is the ABI for store adapters shipped outside this repository, such
+// as adapters loaded from Go plugins (see LoadPlugin). Embedding *adapters.BaseAdapter
+// gives them the shared fetching and parsing helpers; *adapters.ShopifyBaseAdapter adds
+// the Shopify conventions, including CountCatalogProducts.
+type ExternalAdapter interface {
+	// GetStoreName returns the display name of the store
+	GetStoreName() string
+
+	// BaseURL returns the store's base URL
+	BaseURL() string
+
+	// StreamProductURLs calls emit with every unique product URL as soon as it is
+	// discovered, stopping early when emit returns false
+	StreamProductURLs(ctx context.Context, emit func(productURL string) bool) error
+
+	// ExtractProduct extracts the title and size charts of a single product page
+	ExtractProduct(ctx context.Context, productURL string) (*types.Product, error)
+
+	// Close releases the adapter's resources
+	Close()
+}
+
+// catalogCountingAdapter is implemented by external adapters that can count their catalog
+type catalogCountingAdapter interface {
+	CountCatalogProducts(ctx context.Context, baseURL string) (int, error)
+}
+
+// ExternalFactory creates an external adapter for one extraction run
+type ExternalFactory func(config *types.Config, logger types.Logger) (ExternalAdapter, error)
+
+// RegisterExternal makes an external adapter available under the given store domains
+func RegisterExternal(factory ExternalFactory, domains ...string) {
+	for _, domain := range domains {
+		Register(domain, func(config *types.Config, logger types.Logger) StoreExtractor {
+			return NewExternalExtractor(factory, config, logger)
+		})
+	}
+}
+
+// ExternalExtractor runs an ExternalAdapter through the standard extraction pipeline
+type ExternalExtractor struct {
+	factory   ExternalFactory
+	adapterMu sync.Mutex
+	adapter   ExternalAdapter
+	config    *types.Config
+	logger    types.Logger
+	runReport
+}
+
+// NewExternalExtractor creates an extractor for an external adapter. The adapter itself
+// is created lazily so a failing factory surfaces as an extraction error.
+func NewExternalExtractor(factory ExternalFactory, config *types.Config, logger types.Logger) *ExternalExtractor {
+	return &ExternalExtractor{
+		factory: factory,
+		config:  config,
+		logger:  logger,
+	}
+}
+
+// storeAdapter returns the external adapter, creating it on first use
+func (e *ExternalExtractor) storeAdapter() (ExternalAdapter, error) {
+	e.adapterMu.Lock()
+	defer e.adapterMu.Unlock()
+
+	if e.adapter != nil {
+		return e.adapter, nil
+	}
+	adapter, err := e.factory(e.config, e.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create external adapter: %w", err)
+	}
+	e.adapter = adapter
+	return adapter, nil
+}
+
+// ExtractAll extracts all size charts of the store, extracting products while
+// discovery is still running
+func (e *ExternalExtractor) ExtractAll(ctx context.Context) ([]types.Product, error) {
+	adapter, err := e.storeAdapter()
+	if err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+	logger := utils.LoggerFrom(ctx, e.logger)
+	logger.Infof("Starting %s extraction at %v", adapter.GetStoreName(), startTime.Format("15:04:05.000"))
+
+	p := &pipeline{
+		adapter: &externalStore{ExternalAdapter: adapter, config: e.config},
+		logger:  logger,
+		report:  &e.runReport,
+		extract: e.ExtractProduct,
+	}
+	results, err := p.run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("%s extraction completed in %v", adapter.GetStoreName(), time.Since(startTime))
+	return results, nil
+}
+
+// DiscoverProductURLs returns the unique product URLs of the store
+func (e *ExternalExtractor) DiscoverProductURLs(ctx context.Context) ([]string, error) {
+	adapter, err := e.storeAdapter()
+	if err != nil {
+		return nil, err
+	}
+
+	var productURLs []string
+	err = adapter.StreamProductURLs(ctx, func(productURL string) bool {
+		productURLs = append(productURLs, productURL)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product URLs: %w", err)
+	}
+	return productURLs, nil
+}
+
+// ExtractProduct extracts the title and size charts of a single product
+func (e *ExternalExtractor) ExtractProduct(ctx context.Context, productURL string) (*types.Product, error) {
+	adapter, err := e.storeAdapter()
+	if err != nil {
+		return nil, err
+	}
+	return adapter.ExtractProduct(ctx, productURL)
+}
+
+// Close cleans up resources
+func (e *ExternalExtractor) Close() {
+	e.adapterMu.Lock()
+	defer e.adapterMu.Unlock()
+
+	if e.adapter != nil {
+		e.adapter.Close()
+	}
+}
+
+// externalStore gives an external adapter the methods the pipeline expects
+type externalStore struct {
+	ExternalAdapter
+	config *types.Config
+}
+
+// Config returns the configuration of the run
+func (s *externalStore) Config() *types.Config {
+	return s.config
+}
+
+// CountCatalogProducts delegates to the adapter when it can count its catalog
+func (s *externalStore) CountCatalogProducts(ctx context.Context, baseURL string) (int, error) {
+	if counter, ok := s.ExternalAdapter.(catalogCountingAdapter); ok {
+		return counter.CountCatalogProducts(ctx, baseURL)
+	}
+	return 0, fmt.Errorf("%s does not support catalog counting", s.GetStoreName())
+}
@@ -0,0 +1,251 @@
+package extractor
+
+import (
+	"context"
+	"sync"
+
+	"shopify-extractor/adapters"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/stats"
+	"shopify-extractor/utils"
+)
+
+// StoreExtractor is implemented by every store-specific extractor
+type StoreExtractor interface {
+	// ExtractAll discovers and extracts every product of the store
+	ExtractAll(ctx context.Context) ([]types.Product, error)
+
+	// DiscoverProductURLs returns the unique product URLs of the store
+	DiscoverProductURLs(ctx context.Context) ([]string, error)
+
+	// ExtractProduct extracts the title and size charts of a single product page
+	ExtractProduct(ctx context.Context, productURL string) (*types.Product, error)
+
+	// MissingCharts returns the products of the last ExtractAll run that yielded
+	// no size chart, grouped by probable reason
+	MissingCharts() map[string][]types.MissingProduct
+
+	// Coverage returns how much of the store's catalog the last ExtractAll run covered,
+	// or nil when coverage estimation is disabled
+	Coverage() *types.Coverage
+
+	// DiscoveryTruncated reports whether the last ExtractAll run stopped discovery at
+	// Config.DiscoveryTimeout and extracted only the products found by then
+	DiscoveryTruncated() bool
+
+	// WrittenProducts returns how many products the last ExtractAll run wrote to the
+	// result writer instead of returning them
+	WrittenProducts() int
+
+	// SetStatsCollector makes ExtractAll record per-product statistics into c
+	SetStatsCollector(c *stats.Collector)
+
+	// SetResultWriter makes ExtractAll write each product to w as soon as it is extracted
+	// instead of returning it, keeping memory independent of catalog size
+	SetResultWriter(w ResultWriter)
+
+	// Close cleans up resources
+	Close()
+}
+
+// Prewarmer is implemented by extractors that can connect to their store ahead of the
+// first run, so the run does not pay for DNS, TLS and browser discovery
+type Prewarmer interface {
+	Prewarm(ctx context.Context) error
+}
+
+var (
+	_ StoreExtractor = (*WestsideExtractor)(nil)
+	_ StoreExtractor = (*LittleBoxIndiaExtractor)(nil)
+	_ StoreExtractor = (*SuqahExtractor)(nil)
+	_ StoreExtractor = (*NykaaFashionExtractor)(nil)
+	_ StoreExtractor = (*ExternalExtractor)(nil)
+
+	_ Prewarmer = (*WestsideExtractor)(nil)
+	_ Prewarmer = (*LittleBoxIndiaExtractor)(nil)
+	_ Prewarmer = (*SuqahExtractor)(nil)
+	_ Prewarmer = (*NykaaFashionExtractor)(nil)
+)
+
+// ResultWriter receives products as they are extracted. Every output.Sink is a ResultWriter.
+type ResultWriter interface {
+	WriteProduct(ctx context.Context, storeName string, product types.Product) error
+}
+
+// orderProductURLs applies the configured crawl order to the discovered URLs
+func orderProductURLs(config *types.Config, logger types.Logger, productURLs []string) []string {
+	if config.CrawlOrder == "" || config.CrawlOrder == utils.OrderDiscovery {
+		return productURLs
+	}
+	logger.Infof("Crawling %d discovered products in %s order", len(productURLs), config.CrawlOrder)
+	return utils.OrderURLs(productURLs, config.CrawlOrder, config.CrawlSeed)
+}
+
+// sampleProductURLs applies the configured product sampling to the discovered URLs
+func sampleProductURLs(config *types.Config, logger types.Logger, productURLs []string) []string {
+	if config.SampleCount <= 0 && config.SampleRate <= 0 {
+		return productURLs
+	}
+
+	sampled := utils.SampleURLs(productURLs, config.SampleRate, config.SampleCount, config.SampleSeed)
+	logger.Infof("Sampling enabled: extracting %d of %d discovered products", len(sampled), len(productURLs))
+	return sampled
+}
+
+// runReport collects per-run details beyond the extracted products: products without
+// a size chart (grouped by failure reason) and catalog coverage.
+// It is embedded by the store extractors.
+type runReport struct {
+	mu       sync.Mutex
+	missing  map[string][]types.MissingProduct
+	coverage *types.Coverage
+	stats    *stats.Collector
+	writer   ResultWriter
+
+	// truncated is set when discovery ran out of Config.DiscoveryTimeout
+	truncated bool
+
+	// written counts the products sent to writer
+	written int
+}
+
+// SetStatsCollector sets the collector that receives per-product statistics
+func (m *runReport) SetStatsCollector(c *stats.Collector) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = c
+}
+
+// SetResultWriter sets the writer that receives products while extraction runs
+func (m *runReport) SetResultWriter(w ResultWriter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writer = w
+}
+
+// resultWriter returns the configured result writer, or nil to collect products in memory
+func (m *runReport) resultWriter() ResultWriter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.writer
+}
+
+// collector returns the configured stats collector (nil-safe when unset)
+func (m *runReport) collector() *stats.Collector {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// recordMissing classifies err and records the product as missing its size chart,
+// along with its extraction trace when tracing
+func (m *runReport) recordMissing(productURL string, err error, trace []types.TraceEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.missing == nil {
+		m.missing = make(map[string][]types.MissingProduct)
+	}
+
+	missing := types.MissingProduct{ProductURL: productURL, Trace: trace}
+	if err != nil {
+		missing.Error = err.Error()
+	}
+	reason := adapters.FailureReason(err)
+	m.missing[reason] = append(m.missing[reason], missing)
+}
+
+// resetReport clears the report before a new run
+func (m *runReport) resetReport() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.missing = nil
+	m.coverage = nil
+	m.truncated = false
+	m.written = 0
+}
+
+// markDiscoveryTruncated records that discovery was cut short by its time budget
+func (m *runReport) markDiscoveryTruncated() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.truncated = true
+}
+
+// DiscoveryTruncated reports whether discovery of the last run was cut short by its time
+// budget, leaving part of the catalog undiscovered
+func (m *runReport) DiscoveryTruncated() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.truncated
+}
+
+// setWritten records how many products the run wrote to the result writer
+func (m *runReport) setWritten(written int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.written = written
+}
+
+// WrittenProducts returns how many products the last run wrote to the result writer
+func (m *runReport) WrittenProducts() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.written
+}
+
+// MissingCharts returns the products without a size chart, grouped by reason
+func (m *runReport) MissingCharts() map[string][]types.MissingProduct {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report := make(map[string][]types.MissingProduct, len(m.missing))
+	for reason, products := range m.missing {
+		report[reason] = append([]types.MissingProduct(nil), products...)
+	}
+	return report
+}
+
+// catalogCounter is implemented by adapters that can count their store's catalog
+type catalogCounter interface {
+	BaseURL() string
+	Config() *types.Config
+	CountCatalogProducts(ctx context.Context, baseURL string) (int, error)
+}
+
+// estimateCoverage compares the run's results with the catalog size reported by
+// /products.json, falling back to the number of discovered products
+func (m *runReport) estimateCoverage(ctx context.Context, adapter catalogCounter, logger types.Logger, discovered, withCharts int) {
+	if !adapter.Config().EstimateCoverage {
+		return
+	}
+
+	catalogSize, source := discovered, "discovery"
+	if count, err := adapter.CountCatalogProducts(ctx, adapter.BaseURL()); err != nil {
+		logger.Debugf("Could not count catalog of %s, using discovered products: %v", adapter.BaseURL(), err)
+	} else if count > 0 {
+		catalogSize, source = count, "products.json"
+	}
+
+	coverage := types.NewCoverage(catalogSize, source, discovered, withCharts)
+	logger.Infof("Coverage for %s: %s", adapter.BaseURL(), coverage)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.coverage = coverage
+}
+
+// Coverage returns the catalog coverage of the last run
+func (m *runReport) Coverage() *types.Coverage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.coverage
+}
+
+// chartCount returns the number of size charts of a possibly nil product
+func chartCount(product *types.Product) int {
+	if product == nil {
+		return 0
+	}
+	return len(product.SizeCharts)
+}
@@ -1,7 +1,6 @@
 package extractor
 
 import (
-	"context"
 	"testing"
 
 	"github.com/sirupsen/logrus"
@@ -10,78 +9,29 @@ import (
 	"shopify-extractor/internal/types"
 )
 
-func TestNewExtractor(t *testing.T) {
-	config := types.DefaultConfig()
-	logger := logrus.New()
-	
-	extractor := NewExtractor(config, logger)
-	
-	assert.NotNil(t, extractor)
-	assert.Equal(t, config, extractor.config)
-	assert.Equal(t, logger, extractor.logger)
-	assert.NotNil(t, extractor.adapters)
-	
-	// Check that Westside adapter is initialized
-	_, exists := extractor.adapters["westside.com"]
-	assert.True(t, exists)
-}
-
-func TestExtractSizeCharts_EmptyStores(t *testing.T) {
-	config := types.DefaultConfig()
-	logger := logrus.New()
-	extractor := NewExtractor(config, logger)
+func TestNew(t *testing.T) {
+	extractor := New("westside.com", types.DefaultConfig(), logrus.New())
+	require.NotNil(t, extractor)
 	defer extractor.Close()
-	
-	ctx := context.Background()
-	results, err := extractor.ExtractSizeCharts(ctx, []string{})
-	
-	require.NoError(t, err)
-	assert.NotNil(t, results)
-	assert.Empty(t, results.Stores)
-}
+	assert.IsType(t, &WestsideExtractor{}, extractor)
 
-func TestExtractSizeCharts_UnsupportedStore(t *testing.T) {
-	config := types.DefaultConfig()
-	logger := logrus.New()
-	extractor := NewExtractor(config, logger)
-	defer extractor.Close()
-	
-	ctx := context.Background()
-	results, err := extractor.ExtractSizeCharts(ctx, []string{"unsupported-store.com"})
-	
-	require.NoError(t, err)
-	assert.NotNil(t, results)
-	assert.Len(t, results.Stores, 1)
-	assert.Equal(t, "unsupported-store.com", results.Stores[0].StoreName)
-	assert.Contains(t, results.Stores[0].Error, "no adapter found")
+	// Aliases of a store reach its extractor
+	alias := New("www.suqah.com", types.DefaultConfig(), logrus.New())
+	require.NotNil(t, alias)
+	defer alias.Close()
+	assert.IsType(t, &SuqahExtractor{}, alias)
 }
 
-func TestExtractStore_ValidStore(t *testing.T) {
-	config := types.DefaultConfig()
-	logger := logrus.New()
-	extractor := NewExtractor(config, logger)
-	defer extractor.Close()
-	
-	ctx := context.Background()
-	result, err := extractor.extractStore(ctx, "westside.com")
-	
-	require.NoError(t, err)
-	assert.NotNil(t, result)
-	assert.Equal(t, "westside.com", result.StoreName)
-	assert.Empty(t, result.Error)
+func TestNew_UnsupportedStore(t *testing.T) {
+	assert.Nil(t, New("unsupported-store.com", types.DefaultConfig(), logrus.New()))
+	assert.False(t, Supports("unsupported-store.com"))
 }
 
-func TestExtractStore_InvalidStore(t *testing.T) {
-	config := types.DefaultConfig()
-	logger := logrus.New()
-	extractor := NewExtractor(config, logger)
-	defer extractor.Close()
-	
-	ctx := context.Background()
-	result, err := extractor.extractStore(ctx, "invalid-store.com")
-	
-	require.NoError(t, err)
-	assert.NotNil(t, result)
-	assert.Equal(t, "invalid-store.com", result.StoreName)
-	assert.Contains(t, result.Error, "no adapter found")
-} 
\ No newline at end of file
+func TestDomains(t *testing.T) {
+	domains := Domains()
+	assert.Subset(t, domains, []string{"littleboxindia.com", "nykaafashion.com", "suqah.com", "westside.com"})
+	assert.IsIncreasing(t, domains)
+	for _, domain := range domains {
+		assert.True(t, Supports(domain), domain)
+	}
+}
@@ -0,0 +1,62 @@
+package extractor
+
+import (
+	"context"
+	"sync"
+
+	"shopify-extractor/internal/types"
+)
+
+// Hooks let library users enrich or veto extraction results without modifying adapter
+// code, e.g. attach internal product IDs or skip blacklisted vendors. Either function
+// may be nil. Hooks run on the extraction workers and must be safe for concurrent use.
+type Hooks struct {
+	// OnProductDiscovered runs for every discovered product URL before it is queued for
+	// extraction; returning false skips the product
+	OnProductDiscovered func(ctx context.Context, store, productURL string) bool
+
+	// OnChartExtracted runs for every product extracted with size charts before it is
+	// kept. It may modify the product, e.g. set Attributes, and returns false to drop it.
+	OnChartExtracted func(ctx context.Context, store string, product *types.Product) bool
+}
+
+var (
+	hooksMu sync.RWMutex
+	hooks   []Hooks
+)
+
+// RegisterHooks adds hooks to every extraction run, after the hooks registered before
+func RegisterHooks(h Hooks) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, h)
+}
+
+// registeredHooks returns a snapshot of the registered hooks
+func registeredHooks() []Hooks {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	return hooks
+}
+
+// KeepDiscovered runs the OnProductDiscovered hooks, reporting whether the product
+// should be extracted
+func KeepDiscovered(ctx context.Context, store, productURL string) bool {
+	for _, h := range registeredHooks() {
+		if h.OnProductDiscovered != nil && !h.OnProductDiscovered(ctx, store, productURL) {
+			return false
+		}
+	}
+	return true
+}
+
+// KeepExtracted runs the OnChartExtracted hooks, reporting whether the product should
+// be kept
+func KeepExtracted(ctx context.Context, store string, product *types.Product) bool {
+	for _, h := range registeredHooks() {
+		if h.OnChartExtracted != nil && !h.OnChartExtracted(ctx, store, product) {
+			return false
+		}
+	}
+	return true
+}
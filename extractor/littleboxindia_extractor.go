@@ -8,12 +8,14 @@ import (
 
 	"shopify-extractor/adapters"
 	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
 )
 
 // LittleBoxIndiaExtractor handles extraction for LittleBoxIndia store only
 type LittleBoxIndiaExtractor struct {
 	adapter *adapters.LittleBoxIndiaAdapter
 	logger  types.Logger
+	runReport
 }
 
 // NewLittleBoxIndiaExtractor creates a new LittleBoxIndia extractor
@@ -24,62 +26,59 @@ func NewLittleBoxIndiaExtractor(config *types.Config, logger types.Logger) *Litt
 	}
 }
 
-// ExtractAll extracts all size charts from LittleBoxIndia
+// ExtractAll extracts all size charts from LittleBoxIndia, extracting products while
+// discovery is still running
 func (l *LittleBoxIndiaExtractor) ExtractAll(ctx context.Context) ([]types.Product, error) {
 	startTime := time.Now()
-	l.logger.Infof("Starting LittleBoxIndia extraction at %v", startTime.Format("15:04:05.000"))
-
-	// Step 1: Get all product URLs
-	l.logger.Info("Step 1: Discovering product URLs...")
-	storeCtx := types.Context{
-		Config: l.adapter.Config(),
-		Logger: l.logger,
+	logger := utils.LoggerFrom(ctx, l.logger)
+	logger.Infof("Starting LittleBoxIndia extraction at %v", startTime.Format("15:04:05.000"))
+
+	p := &pipeline{
+		adapter:     l.adapter,
+		logger:      logger,
+		report:      &l.runReport,
+		extract:     l.ExtractProduct,
+		maxProducts: 6, // limit exceed
 	}
-	productURLs, err := l.adapter.GetProductURLs(storeCtx)
+	results, err := p.run(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product URLs: %w", err)
+		return nil, err
 	}
 
-	l.logger.Infof("Found %d product URLs", len(productURLs))
-
-	// Step 2: Extract size charts from each product
-	l.logger.Info("Step 2: Extracting size charts...")
-	var results []types.Product
-	processedCount := 0
-
-	for i, productURL := range productURLs {
-		productStartTime := time.Now()
-		l.logger.Debugf("Processing product %d/%d: %s", i+1, len(productURLs), productURL)
-
-		// Use optimized method that fetches page once and extracts both title and size charts
-		title, sizeCharts, err := l.adapter.ExtractProductTitleAndSizeCharts(storeCtx, productURL)
-		if err != nil {
-			l.logger.Warnf("Failed to extract data for %s: %v", productURL, err)
-			continue
-		}
-
-		if len(sizeCharts) > 0 {
-			result := types.Product{
-				ProductTitle: title,
-				ProductURL:   productURL,
-				SizeCharts:   sizeCharts,
-			}
-			results = append(results, result)
-			processedCount++
-		}
-
-		productTime := time.Since(productStartTime)
-		l.logger.Debugf("Product %s processed in %v", productURL, productTime)
-		if i >= 5 {
-			break // limit exceed
-		}
+	logger.Infof("LittleBoxIndia extraction completed in %v", time.Since(startTime))
+	return results, nil
+}
+
+// DiscoverProductURLs returns the unique product URLs of the LittleBoxIndia catalog
+func (l *LittleBoxIndiaExtractor) DiscoverProductURLs(ctx context.Context) ([]string, error) {
+	productURLs, err := l.adapter.GetProductURLs(l.storeContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product URLs: %w", err)
 	}
+	return productURLs, nil
+}
 
-	totalTime := time.Since(startTime)
-	l.logger.Infof("LittleBoxIndia extraction completed in %v", totalTime)
-	l.logger.Infof("Successfully processed %d/%d products", processedCount, len(productURLs))
+// ExtractProduct extracts the title and size charts of a single LittleBoxIndia product
+func (l *LittleBoxIndiaExtractor) ExtractProduct(ctx context.Context, productURL string) (*types.Product, error) {
+	title, sizeCharts, err := l.adapter.ExtractProductTitleAndSizeCharts(l.storeContext(ctx), productURL)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Product{
+		ProductTitle: title,
+		ProductURL:   productURL,
+		SizeCharts:   sizeCharts,
+	}, nil
+}
 
-	return results, nil
+// storeContext returns the adapter context of a call, ending with ctx and logging through
+// its logger
+func (l *LittleBoxIndiaExtractor) storeContext(ctx context.Context) types.Context {
+	return types.Context{
+		Config: l.adapter.Config(),
+		Logger: utils.LoggerFrom(ctx, l.logger),
+		Ctx:    ctx,
+	}
 }
 
 // ExtractToJSON extracts all size charts and saves to JSON file
@@ -102,6 +101,11 @@ func (l *LittleBoxIndiaExtractor) ExtractToJSON(ctx context.Context, filename st
 	return nil
 }
 
+// Prewarm connects to the store ahead of the first run
+func (l *LittleBoxIndiaExtractor) Prewarm(ctx context.Context) error {
+	return l.adapter.Prewarm(ctx, l.adapter.BaseURL())
+}
+
 // Close cleans up resources
 func (l *LittleBoxIndiaExtractor) Close() {
 	if l.adapter != nil {
@@ -0,0 +1,113 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"shopify-extractor/adapters"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+)
+
+// NykaaFashionExtractor handles extraction for Nykaa Fashion store only
+type NykaaFashionExtractor struct {
+	adapter *adapters.NykaaFashionAdapter
+	logger  types.Logger
+	runReport
+}
+
+// NewNykaaFashionExtractor creates a new Nykaa Fashion extractor
+func NewNykaaFashionExtractor(config *types.Config, logger types.Logger) *NykaaFashionExtractor {
+	return &NykaaFashionExtractor{
+		adapter: adapters.NewNykaaFashionAdapter(config, logger),
+		logger:  logger,
+	}
+}
+
+// ExtractAll extracts all size charts from Nykaa Fashion, extracting products while
+// discovery is still running
+func (n *NykaaFashionExtractor) ExtractAll(ctx context.Context) ([]types.Product, error) {
+	startTime := time.Now()
+	logger := utils.LoggerFrom(ctx, n.logger)
+	logger.Infof("Starting Nykaa Fashion extraction at %v", startTime.Format("15:04:05.000"))
+
+	p := &pipeline{
+		adapter: n.adapter,
+		logger:  logger,
+		report:  &n.runReport,
+		extract: n.ExtractProduct,
+	}
+	results, err := p.run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Nykaa Fashion extraction completed in %v", time.Since(startTime))
+	return results, nil
+}
+
+// DiscoverProductURLs returns the unique product URLs of the Nykaa Fashion catalog
+func (n *NykaaFashionExtractor) DiscoverProductURLs(ctx context.Context) ([]string, error) {
+	productURLs, err := n.adapter.GetProductURLs(n.storeContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product URLs: %w", err)
+	}
+	return productURLs, nil
+}
+
+// ExtractProduct extracts the title and size charts of a single Nykaa Fashion product
+func (n *NykaaFashionExtractor) ExtractProduct(ctx context.Context, productURL string) (*types.Product, error) {
+	title, sizeCharts, err := n.adapter.ExtractProductTitleAndSizeCharts(ctx, productURL)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Product{
+		ProductTitle: title,
+		ProductURL:   productURL,
+		SizeCharts:   sizeCharts,
+	}, nil
+}
+
+// storeContext returns the adapter context of a call, ending with ctx and logging through
+// its logger
+func (n *NykaaFashionExtractor) storeContext(ctx context.Context) types.Context {
+	return types.Context{
+		Config: n.adapter.Config(),
+		Logger: utils.LoggerFrom(ctx, n.logger),
+		Ctx:    ctx,
+	}
+}
+
+// ExtractToJSON extracts all size charts and saves to JSON file
+func (n *NykaaFashionExtractor) ExtractToJSON(ctx context.Context, filename string) error {
+	results, err := n.ExtractAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	jsonData, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results to JSON: %w", err)
+	}
+
+	if err := writeToFile(filename, jsonData); err != nil {
+		return fmt.Errorf("failed to write results to file: %w", err)
+	}
+
+	n.logger.Infof("Results saved to %s", filename)
+	return nil
+}
+
+// Prewarm connects to the store ahead of the first run
+func (n *NykaaFashionExtractor) Prewarm(ctx context.Context) error {
+	return n.adapter.Prewarm(ctx, n.adapter.BaseURL())
+}
+
+// Close cleans up resources
+func (n *NykaaFashionExtractor) Close() {
+	if n.adapter != nil {
+		n.adapter.Close()
+	}
+}
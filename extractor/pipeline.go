@@ -0,0 +1,358 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"shopify-extractor/adapters"
+	"shopify-extractor/frontier"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/stats"
+	"shopify-extractor/utils"
+)
+
+// productStreamer is implemented by adapters that emit product URLs while discovery
+// is still running
+type productStreamer interface {
+	catalogCounter
+	GetStoreName() string
+	StreamProductURLs(ctx context.Context, emit func(productURL string) bool) error
+}
+
+// pipeline overlaps product discovery with extraction: URLs are fed into a pool of
+// extraction workers as soon as the adapter finds them, instead of after the whole
+// catalog has been discovered.
+type pipeline struct {
+	adapter productStreamer
+	logger  types.Logger
+	report  *runReport
+
+	// extract extracts a single product page
+	extract func(ctx context.Context, productURL string) (*types.Product, error)
+
+	// maxProducts caps how many discovered products are extracted (0 = no limit)
+	maxProducts int
+}
+
+// queuedProduct is a discovered product URL tagged with its discovery order
+type queuedProduct struct {
+	index int
+	url   string
+}
+
+// extractedProduct is an extracted product tagged with its discovery order
+type extractedProduct struct {
+	index   int
+	product types.Product
+}
+
+// run discovers and extracts the store's products. Returned products keep discovery
+// order; with a result writer set, products are written in completion order instead
+// and the returned slice is empty.
+func (p *pipeline) run(ctx context.Context) ([]types.Product, error) {
+	p.report.resetReport()
+	storeName := p.adapter.GetStoreName()
+	config := p.adapter.Config()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Every store has its own worker pool, sized by its concurrency option, and its own
+	// host limiter (see adapters.NewBaseAdapter), so a slow store never holds up another
+	workers := utils.StoreConcurrency(config, storeName)
+	queue := make(chan queuedProduct, workers)
+
+	// Discovered URLs wait in the frontier queue, which spills to disk past the memory
+	// limit, so discovery never blocks on extraction and memory stays bounded
+	pending := frontier.NewQueue(config.FrontierMemoryLimit, config.FrontierDir)
+	defer pending.Close()
+
+	var (
+		discovered   int
+		queued       int
+		discoverErr  error
+		discoverDone = make(chan struct{})
+	)
+
+	// vetoed reports whether an OnProductDiscovered hook skips a discovered URL
+	vetoed := func(productURL string) bool {
+		if KeepDiscovered(ctx, storeName, productURL) {
+			return false
+		}
+		p.logger.Debugf("Skipping %s: rejected by an OnProductDiscovered hook", productURL)
+		return true
+	}
+
+	// enqueue adds a URL to the frontier, honouring maxProducts. The job's progress
+	// counts queued products, the ones it will extract.
+	progress := stats.ProgressFrom(ctx)
+	enqueue := func(productURL string) bool {
+		if p.maxProducts > 0 && queued >= p.maxProducts {
+			return true
+		}
+		if err := pending.Push(productURL); err != nil {
+			discoverErr = err
+			return false
+		}
+		queued++
+		progress.RecordDiscovered(1)
+		return ctx.Err() == nil
+	}
+
+	// Discovery runs under its own deadline (Config.DiscoveryTimeout) so crawling a big
+	// catalog cannot use up the whole run; the products found by then are extracted and
+	// the run is reported as truncated
+	discoverCtx, cancelDiscover := ctx, context.CancelFunc(func() {})
+	if config.DiscoveryTimeout > 0 {
+		discoverCtx, cancelDiscover = context.WithTimeout(ctx, config.DiscoveryTimeout)
+	}
+	defer cancelDiscover()
+	truncated := func(err error) bool {
+		if err == nil || ctx.Err() != nil || !errors.Is(discoverCtx.Err(), context.DeadlineExceeded) {
+			return false
+		}
+		p.logger.Warnf("Product discovery of %s exceeded %v, extracting the %d products found so far", storeName, config.DiscoveryTimeout, discovered)
+		p.report.markDiscoveryTruncated()
+		return true
+	}
+
+	ordered := config.CrawlOrder != "" && config.CrawlOrder != utils.OrderDiscovery
+	if ordered || config.SampleCount > 0 || config.SampleRate > 0 {
+		// Sampling and crawl ordering work on the complete catalog, so discovery has to
+		// finish first
+		p.logger.Info("Step 1: Discovering product URLs...")
+		var productURLs []string
+		err := p.adapter.StreamProductURLs(discoverCtx, func(productURL string) bool {
+			discovered++
+			if !vetoed(productURL) {
+				productURLs = append(productURLs, productURL)
+			}
+			return true
+		})
+		if err != nil && !truncated(err) {
+			return nil, fmt.Errorf("failed to get product URLs: %w", err)
+		}
+		p.report.collector().RecordDiscovered(storeName, discovered)
+		productURLs = orderProductURLs(config, p.logger, productURLs)
+		productURLs = sampleProductURLs(config, p.logger, productURLs)
+
+		go func() {
+			defer close(discoverDone)
+			for _, productURL := range productURLs {
+				if !enqueue(productURL) {
+					return
+				}
+			}
+		}()
+	} else {
+		p.logger.Info("Discovering and extracting products concurrently...")
+		go func() {
+			defer close(discoverDone)
+			err := p.adapter.StreamProductURLs(discoverCtx, func(productURL string) bool {
+				discovered++
+				p.report.collector().RecordDiscovered(storeName, 1)
+				if vetoed(productURL) {
+					return true
+				}
+				return enqueue(productURL)
+			})
+			if truncated(err) {
+				err = nil
+			}
+			if discoverErr == nil {
+				discoverErr = err
+			}
+		}()
+	}
+
+	// Feed the workers from the frontier in discovery order until discovery has
+	// finished and the frontier is drained
+	go func() {
+		defer close(queue)
+		for index := 0; ; {
+			productURL, ok, err := pending.Pop()
+			if err != nil {
+				p.logger.Errorf("Failed to read URL frontier: %v", err)
+				cancel()
+				return
+			}
+			if ok {
+				select {
+				case queue <- queuedProduct{index: index, url: productURL}:
+					index++
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
+			select {
+			case <-pending.Ready():
+			case <-discoverDone:
+				if pending.Len() == 0 {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		mu       sync.Mutex
+		results  []extractedProduct
+		written  int
+		writeErr error
+		abortErr error
+		budget   = newFailureBudget(config)
+		wg       sync.WaitGroup
+		writer   = p.report.resultWriter()
+	)
+
+	// keep stores a product, either by writing it straight to the result writer
+	// (serialised, in completion order) or by collecting it for the return value
+	keep := func(index int, product *types.Product) {
+		product.SetFingerprints()
+		product.SetSizeLabels()
+		product.SetMeasurements()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if writer == nil {
+			results = append(results, extractedProduct{index: index, product: *product})
+			return
+		}
+		if writeErr != nil {
+			return
+		}
+		if err := writer.WriteProduct(ctx, storeName, *product); err != nil {
+			writeErr = fmt.Errorf("failed to write product %s: %w", product.ProductURL, err)
+			cancel()
+			return
+		}
+		written++
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				productStartTime := time.Now()
+				logger := utils.WithField(p.logger, "product_url", item.url)
+				var tracer *utils.Tracer
+				if config.Trace {
+					tracer = utils.NewTracer(logger)
+					logger = tracer
+				}
+				logger.Debugf("Processing product %d: %s", item.index+1, item.url)
+
+				// Only fetch the product page once and extract both title and size charts. The
+				// adapter logs through the product's logger carried by the context.
+				product, err := p.extractWithDeadline(utils.ContextWithLogger(ctx, logger), config.ProductTimeout, item.url)
+				collector := p.report.collector()
+				collector.RecordProduct(storeName, time.Since(productStartTime), chartCount(product), err)
+				progress.RecordCompleted(err != nil)
+				if product != nil {
+					collector.RecordChartParsers(storeName, types.CountChartsByParser([]types.Product{*product}))
+				}
+				if budgetErr := budget.record(err); budgetErr != nil {
+					p.logger.Errorf("Aborting %s: %v", storeName, budgetErr)
+					mu.Lock()
+					abortErr = budgetErr
+					mu.Unlock()
+					cancel()
+				}
+				if err != nil {
+					logger.Warnf("Failed to extract size charts for %s: %v", item.url, err)
+					p.report.recordMissing(item.url, err, traceEvents(tracer))
+					continue
+				}
+
+				if len(product.SizeCharts) > 0 {
+					if !KeepExtracted(ctx, storeName, product) {
+						logger.Debugf("Dropping %s: rejected by an OnChartExtracted hook", item.url)
+						continue
+					}
+					SaveChartImages(config, storeName, product, logger)
+					product.Trace = traceEvents(tracer)
+					keep(item.index, product)
+					logger.Debugf("Extracted %d size charts for %s", len(product.SizeCharts), item.url)
+				} else {
+					p.report.recordMissing(item.url, nil, traceEvents(tracer))
+				}
+
+				logger.Debugf("Product %s processed in %v", item.url, time.Since(productStartTime))
+			}
+		}()
+	}
+	wg.Wait()
+	<-discoverDone
+
+	if abortErr != nil {
+		return nil, abortErr
+	}
+
+	if discoverErr != nil {
+		if discovered == 0 {
+			return nil, fmt.Errorf("failed to get product URLs: %w", discoverErr)
+		}
+		p.logger.Warnf("Product discovery ended early after %d products: %v", discovered, discoverErr)
+	}
+
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].index < results[j].index })
+	products := make([]types.Product, 0, len(results))
+	for _, result := range results {
+		products = append(products, result.product)
+	}
+
+	withCharts := len(products) + written
+	p.logger.Infof("Found %d product URLs", discovered)
+	p.logger.Infof("Successfully processed %d/%d products", withCharts, queued)
+	p.report.setWritten(written)
+	p.report.estimateCoverage(ctx, p.adapter, p.logger, discovered, withCharts)
+
+	return products, nil
+}
+
+// traceEvents returns the events of a product's trace, nil without tracing
+func traceEvents(tracer *utils.Tracer) []types.TraceEvent {
+	if tracer == nil {
+		return nil
+	}
+	return tracer.Events()
+}
+
+// extractWithDeadline extracts a product within timeout (0 = no limit)
+func (p *pipeline) extractWithDeadline(ctx context.Context, timeout time.Duration, productURL string) (*types.Product, error) {
+	return ExtractWithDeadline(ctx, timeout, productURL, p.extract)
+}
+
+// ExtractWithDeadline calls extract for a product within timeout (0 = no limit). A
+// failure caused by the product's own deadline, rather than ctx, is reported as timed out.
+func ExtractWithDeadline(ctx context.Context, timeout time.Duration, productURL string, extract func(ctx context.Context, productURL string) (*types.Product, error)) (*types.Product, error) {
+	if timeout <= 0 {
+		return extract(ctx, productURL)
+	}
+
+	productCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	product, err := extract(productCtx, productURL)
+	if err != nil && ctx.Err() == nil && errors.Is(productCtx.Err(), context.DeadlineExceeded) {
+		return nil, &adapters.ExtractionError{
+			Reason: types.MissingReasonTimedOut,
+			Err:    fmt.Errorf("product timed out after %v: %w", timeout, context.DeadlineExceeded),
+		}
+	}
+	return product, err
+}
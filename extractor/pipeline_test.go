@@ -0,0 +1,237 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/adapters"
+	"shopify-extractor/internal/types"
+)
+
+// stubStreamer is a productStreamer emitting a fixed list of product URLs, then err, or
+// with block, waiting for its context to end
+type stubStreamer struct {
+	config *types.Config
+	urls   []string
+	err    error
+	block  bool
+}
+
+func (s *stubStreamer) GetStoreName() string  { return "stub.com" }
+func (s *stubStreamer) BaseURL() string       { return "https://stub.com" }
+func (s *stubStreamer) Config() *types.Config { return s.config }
+func (s *stubStreamer) CountCatalogProducts(ctx context.Context, baseURL string) (int, error) {
+	return 0, errors.New("not counted")
+}
+func (s *stubStreamer) StreamProductURLs(ctx context.Context, emit func(productURL string) bool) error {
+	for _, productURL := range s.urls {
+		if !emit(productURL) {
+			return ctx.Err()
+		}
+	}
+	if s.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return s.err
+}
+
+// stubWriter is a ResultWriter recording products, failing every write with err
+type stubWriter struct {
+	mu       sync.Mutex
+	products []types.Product
+	err      error
+}
+
+func (w *stubWriter) WriteProduct(ctx context.Context, storeName string, product types.Product) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.products = append(w.products, product)
+	return nil
+}
+
+func productURLs(n int) []string {
+	urls := make([]string, n)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://stub.com/products/item-%02d", i)
+	}
+	return urls
+}
+
+// newTestPipeline returns a pipeline over the URLs whose products each carry a chart
+func newTestPipeline(urls []string, workers int) (*pipeline, *stubStreamer) {
+	config := types.DefaultConfig()
+	config.MaxConcurrentRequests = workers
+	streamer := &stubStreamer{config: config, urls: urls}
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	return &pipeline{
+		adapter: streamer,
+		logger:  logger,
+		report:  &runReport{},
+		extract: func(ctx context.Context, productURL string) (*types.Product, error) {
+			return &types.Product{
+				ProductTitle: "Item",
+				ProductURL:   productURL,
+				SizeCharts:   []*types.SizeChart{{Headers: []string{"Size", "Bust"}, Rows: []map[string]string{{"Size": "M", "Bust": "36"}}}},
+			}, nil
+		},
+	}, streamer
+}
+
+func resultURLs(products []types.Product) []string {
+	urls := make([]string, 0, len(products))
+	for _, product := range products {
+		urls = append(urls, product.ProductURL)
+	}
+	return urls
+}
+
+func TestPipeline_KeepsDiscoveryOrder(t *testing.T) {
+	urls := productURLs(12)
+	p, _ := newTestPipeline(urls, 4)
+	extract := p.extract
+	p.extract = func(ctx context.Context, productURL string) (*types.Product, error) {
+		// Earlier products finish last
+		for i, u := range urls {
+			if u == productURL {
+				time.Sleep(time.Duration(len(urls)-i) * time.Millisecond)
+			}
+		}
+		return extract(ctx, productURL)
+	}
+
+	products, err := p.run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, urls, resultURLs(products))
+	assert.NotEmpty(t, products[0].SizeCharts[0].Fingerprint)
+}
+
+func TestPipeline_MaxProducts(t *testing.T) {
+	p, _ := newTestPipeline(productURLs(10), 2)
+	p.maxProducts = 3
+	var extracted int32
+	extract := p.extract
+	p.extract = func(ctx context.Context, productURL string) (*types.Product, error) {
+		atomic.AddInt32(&extracted, 1)
+		return extract(ctx, productURL)
+	}
+
+	products, err := p.run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, productURLs(3), resultURLs(products))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&extracted))
+}
+
+func TestPipeline_DiscoveryErrorAfterPartialResults(t *testing.T) {
+	p, streamer := newTestPipeline(productURLs(2), 2)
+	streamer.err = errors.New("collection page failed")
+
+	// The products found before the failure are still extracted
+	products, err := p.run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, productURLs(2), resultURLs(products))
+
+	// A discovery failing before any product is an error
+	p, streamer = newTestPipeline(nil, 2)
+	streamer.err = errors.New("listing page failed")
+	_, err = p.run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "listing page failed")
+}
+
+func TestPipeline_WriteErrorCancelsRun(t *testing.T) {
+	urls := productURLs(50)
+	p, _ := newTestPipeline(urls, 1)
+	writeErr := errors.New("disk full")
+	p.report.SetResultWriter(&stubWriter{err: writeErr})
+	var extracted int32
+	extract := p.extract
+	p.extract = func(ctx context.Context, productURL string) (*types.Product, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		atomic.AddInt32(&extracted, 1)
+		return extract(ctx, productURL)
+	}
+
+	products, err := p.run(context.Background())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, writeErr)
+	assert.Empty(t, products)
+	assert.Less(t, int(atomic.LoadInt32(&extracted)), len(urls), "the failed write stops the run")
+}
+
+func TestPipeline_WritesToResultWriter(t *testing.T) {
+	p, _ := newTestPipeline(productURLs(5), 3)
+	writer := &stubWriter{}
+	p.report.SetResultWriter(writer)
+
+	products, err := p.run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, products, "written products are not returned")
+	assert.ElementsMatch(t, productURLs(5), resultURLs(writer.products))
+	assert.Equal(t, 5, p.report.WrittenProducts())
+}
+
+func TestPipeline_DiscoveryTruncated(t *testing.T) {
+	p, streamer := newTestPipeline(productURLs(2), 2)
+	streamer.block = true
+	streamer.config.DiscoveryTimeout = 50 * time.Millisecond
+
+	products, err := p.run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, productURLs(2), resultURLs(products))
+	assert.True(t, p.report.DiscoveryTruncated())
+}
+
+func TestPipeline_ProductTimeoutStopsStalledFetch(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	config := types.DefaultConfig()
+	config.UseHeadlessBrowser = false
+	config.FetchFallback = false
+	config.RequestDelay = 0
+	config.Timeout = time.Minute
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	littlebox := NewLittleBoxIndiaExtractor(config, logger)
+	defer littlebox.Close()
+	p := &pipeline{extract: littlebox.ExtractProduct}
+
+	// The product's deadline reaches the adapter's fetch, well before the HTTP timeout
+	start := time.Now()
+	_, err := p.extractWithDeadline(context.Background(), 100*time.Millisecond, server.URL+"/products/dress")
+	require.Error(t, err)
+	assert.Equal(t, types.MissingReasonTimedOut, adapters.FailureReason(err), err.Error())
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// Cancelling the run, e.g. when the failure budget is exhausted, stops it as well
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start = time.Now()
+	_, err = p.extractWithDeadline(ctx, 0, server.URL+"/products/dress")
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
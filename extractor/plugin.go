@@ -0,0 +1,82 @@
+package extractor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+	"strings"
+
+	"shopify-extractor/internal/types"
+)
+
+// Symbols a store adapter plugin must export. A plugin is a `package main` built with
+// `go build -buildmode=plugin` against the same version of this module:
+//
+//	var Domains = []string{"mystore.com"}
+//
+//	func NewAdapter(config *types.Config, logger types.Logger) (extractor.ExternalAdapter, error)
+const (
+	PluginDomainsSymbol    = "Domains"
+	PluginNewAdapterSymbol = "NewAdapter"
+)
+
+// LoadPlugin opens a Go plugin and registers its adapter for the domains it declares.
+// It returns the registered domains.
+func LoadPlugin(path string) ([]string, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin %s: %w", path, err)
+	}
+
+	domainsSymbol, err := p.Lookup(PluginDomainsSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	domains, ok := domainsSymbol.(*[]string)
+	if !ok || len(*domains) == 0 {
+		return nil, fmt.Errorf("plugin %s: %s must be a non-empty []string", path, PluginDomainsSymbol)
+	}
+
+	newAdapterSymbol, err := p.Lookup(PluginNewAdapterSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	newAdapter, ok := newAdapterSymbol.(func(*types.Config, types.Logger) (ExternalAdapter, error))
+	if !ok {
+		return nil, fmt.Errorf("plugin %s: %s has type %T, want func(*types.Config, types.Logger) (extractor.ExternalAdapter, error)", path, PluginNewAdapterSymbol, newAdapterSymbol)
+	}
+
+	RegisterExternal(newAdapter, *domains...)
+	return append([]string(nil), *domains...), nil
+}
+
+// LoadPlugins loads every plugin named in a comma-separated list of .so files and
+// directories; directories contribute all the .so files they contain
+func LoadPlugins(paths string, logger types.Logger) error {
+	for _, path := range strings.Split(paths, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		files := []string{path}
+		if info, err := os.Stat(path); err != nil {
+			return fmt.Errorf("failed to stat plugin path: %w", err)
+		} else if info.IsDir() {
+			files, err = filepath.Glob(filepath.Join(path, "*.so"))
+			if err != nil {
+				return fmt.Errorf("failed to list plugins in %s: %w", path, err)
+			}
+		}
+
+		for _, file := range files {
+			domains, err := LoadPlugin(file)
+			if err != nil {
+				return err
+			}
+			logger.Infof("Loaded adapter plugin %s for %s", file, strings.Join(domains, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package extractor
+
+import (
+	"sort"
+	"sync"
+
+	"shopify-extractor/internal/types"
+)
+
+// Factory creates the extractor of a store for one extraction run
+type Factory func(config *types.Config, logger types.Logger) StoreExtractor
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{
+		"westside.com": func(config *types.Config, logger types.Logger) StoreExtractor {
+			return NewWestsideExtractor(config, logger)
+		},
+		"littleboxindia.com": func(config *types.Config, logger types.Logger) StoreExtractor {
+			return NewLittleBoxIndiaExtractor(config, logger)
+		},
+		"suqah.com": func(config *types.Config, logger types.Logger) StoreExtractor {
+			return NewSuqahExtractor(config, logger)
+		},
+		"nykaafashion.com": func(config *types.Config, logger types.Logger) StoreExtractor {
+			return NewNykaaFashionExtractor(config, logger)
+		},
+	}
+)
+
+// normalizeDomain returns the canonical store of a domain, so aliases of a store
+// (www., *.myshopify.com) reach its extractor
+func normalizeDomain(domain string) string {
+	return types.CanonicalStore(domain)
+}
+
+// Register makes a store available under its domain, replacing any existing extractor
+// for that domain
+func Register(domain string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[normalizeDomain(domain)] = factory
+}
+
+// New creates the extractor for a store domain, or returns nil when the store is not supported
+func New(domain string, config *types.Config, logger types.Logger) StoreExtractor {
+	factoriesMu.RLock()
+	factory, ok := factories[normalizeDomain(domain)]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return factory(config, logger)
+}
+
+// Supports reports whether an extractor is registered for the store domain
+func Supports(domain string) bool {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	_, ok := factories[normalizeDomain(domain)]
+	return ok
+}
+
+// Domains returns the supported store domains in sorted order
+func Domains() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	domains := make([]string, 0, len(factories))
+	for domain := range factories {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
@@ -8,12 +8,14 @@ import (
 
 	"shopify-extractor/adapters"
 	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
 )
 
 // SuqahExtractor handles extraction for Suqah store only
 type SuqahExtractor struct {
 	adapter *adapters.SuqahAdapter
 	logger  types.Logger
+	runReport
 }
 
 // NewSuqahExtractor creates a new Suqah extractor
@@ -24,61 +26,58 @@ func NewSuqahExtractor(config *types.Config, logger types.Logger) *SuqahExtracto
 	}
 }
 
-// ExtractAll extracts all size charts from Suqah
+// ExtractAll extracts all size charts from Suqah, extracting products while
+// discovery is still running
 func (s *SuqahExtractor) ExtractAll(ctx context.Context) ([]types.Product, error) {
 	startTime := time.Now()
-	s.logger.Infof("Starting Suqah extraction at %v", startTime.Format("15:04:05.000"))
+	logger := utils.LoggerFrom(ctx, s.logger)
+	logger.Infof("Starting Suqah extraction at %v", startTime.Format("15:04:05.000"))
 
-	s.logger.Info("Step 1: Discovering product URLs...")
-	storeCtx := types.Context{
-		Config: s.adapter.Config(),
-		Logger: s.logger,
+	p := &pipeline{
+		adapter: s.adapter,
+		logger:  logger,
+		report:  &s.runReport,
+		extract: s.ExtractProduct,
 	}
-	productURLs, err := s.adapter.GetProductURLs(storeCtx)
+	results, err := p.run(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product URLs: %w", err)
+		return nil, err
 	}
 
-	s.logger.Infof("Found %d product URLs", len(productURLs))
-
-	s.logger.Info("Step 2: Extracting size charts...")
-	var results []types.Product
-	processedCount := 0
-
-	for i, productURL := range productURLs {
-		productStartTime := time.Now()
-		s.logger.Debugf("Processing product %d/%d: %s", i+1, len(productURLs), productURL)
-
-		// Use optimized method that fetches page once and extracts both title and size charts
-		title, sizeCharts, err := s.adapter.ExtractProductData(storeCtx, productURL)
-		if err != nil {
-			s.logger.Warnf("Failed to extract data for %s: %v", productURL, err)
-			continue
-		}
-
-		if len(sizeCharts) > 0 {
-			result := types.Product{
-				ProductTitle: title,
-				ProductURL:   productURL,
-				SizeCharts:   sizeCharts,
-			}
-			results = append(results, result)
-			processedCount++
-		}
-
-		productTime := time.Since(productStartTime)
-		s.logger.Debugf("Product %s processed in %v", productURL, productTime)
-		// if i >= 5 {
-		// 	break // limit exceed
-		// }
+	logger.Infof("Suqah extraction completed in %v", time.Since(startTime))
+	return results, nil
+}
 
+// DiscoverProductURLs returns the unique product URLs of the Suqah catalog
+func (s *SuqahExtractor) DiscoverProductURLs(ctx context.Context) ([]string, error) {
+	productURLs, err := s.adapter.GetProductURLs(s.storeContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product URLs: %w", err)
 	}
+	return productURLs, nil
+}
 
-	totalTime := time.Since(startTime)
-	s.logger.Infof("Suqah extraction completed in %v", totalTime)
-	s.logger.Infof("Successfully processed %d/%d products", processedCount, len(productURLs))
+// ExtractProduct extracts the title and size charts of a single Suqah product
+func (s *SuqahExtractor) ExtractProduct(ctx context.Context, productURL string) (*types.Product, error) {
+	title, sizeCharts, err := s.adapter.ExtractProductData(s.storeContext(ctx), productURL)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Product{
+		ProductTitle: title,
+		ProductURL:   productURL,
+		SizeCharts:   sizeCharts,
+	}, nil
+}
 
-	return results, nil
+// storeContext returns the adapter context of a call, ending with ctx and logging through
+// its logger
+func (s *SuqahExtractor) storeContext(ctx context.Context) types.Context {
+	return types.Context{
+		Config: s.adapter.Config(),
+		Logger: utils.LoggerFrom(ctx, s.logger),
+		Ctx:    ctx,
+	}
 }
 
 // ExtractToJSON extracts all size charts and saves to JSON file
@@ -101,6 +100,11 @@ func (s *SuqahExtractor) ExtractToJSON(ctx context.Context, filename string) err
 	return nil
 }
 
+// Prewarm connects to the store ahead of the first run
+func (s *SuqahExtractor) Prewarm(ctx context.Context) error {
+	return s.adapter.Prewarm(ctx, s.adapter.BaseURL())
+}
+
 // Close cleans up resources
 func (s *SuqahExtractor) Close() {
 	if s.adapter != nil {
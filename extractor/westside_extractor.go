@@ -9,12 +9,14 @@ import (
 
 	"shopify-extractor/adapters"
 	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
 )
 
 // WestsideExtractor handles extraction for Westside store only
 type WestsideExtractor struct {
 	adapter *adapters.WestsideAdapter
 	logger  types.Logger
+	runReport
 }
 
 // NewWestsideExtractor creates a new Westside extractor
@@ -25,69 +27,63 @@ func NewWestsideExtractor(config *types.Config, logger types.Logger) *WestsideEx
 	}
 }
 
-// ExtractAll extracts all size charts from Westside
+// ExtractAll extracts all size charts from Westside, extracting products while
+// discovery is still running
 func (w *WestsideExtractor) ExtractAll(ctx context.Context) ([]types.Product, error) {
 	startTime := time.Now()
-	w.logger.Infof("Starting Westside extraction at %v", startTime.Format("15:04:05.000"))
+	logger := utils.LoggerFrom(ctx, w.logger)
+	logger.Infof("Starting Westside extraction at %v", startTime.Format("15:04:05.000"))
 
-	// Step 1: Get all product URLs
-	w.logger.Info("Step 1: Discovering product URLs...")
-	storeCtx := types.Context{
-		Config: w.adapter.Config(),
-		Logger: w.logger,
+	p := &pipeline{
+		adapter: w.adapter,
+		logger:  logger,
+		report:  &w.runReport,
+		extract: w.ExtractProduct,
 	}
-	productURLs, err := w.adapter.GetProductURLs(storeCtx)
+	results, err := p.run(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product URLs: %w", err)
+		return nil, err
 	}
 
-	w.logger.Infof("Found %d product URLs", len(productURLs))
-
-	// Step 2: Extract size charts from each product
-	w.logger.Info("Step 2: Extracting size charts...")
-	var results []types.Product
-	processedCount := 0
-
-	for i, productURL := range productURLs {
-		productStartTime := time.Now()
-		w.logger.Debugf("Processing product %d/%d: %s", i+1, len(productURLs), productURL)
-
-		// Only fetch the product page once and extract both title and size charts
-		title, sizeCharts, err := w.adapter.ExtractAllSizeCharts(storeCtx, productURL)
-		if err != nil {
-			w.logger.Warnf("Failed to extract size charts for %s: %v", productURL, err)
-			continue
-		}
-
-		if len(sizeCharts) > 0 {
-			// Use the extracted title, fallback to "Unknown Product" if empty
-			if title == "" {
-				title = "Unknown Product"
-			}
-			result := types.Product{
-				ProductTitle: title,
-				ProductURL:   productURL,
-				SizeCharts:   sizeCharts,
-			}
-			results = append(results, result)
-			w.logger.Debugf("Extracted %d size charts for %s", len(sizeCharts), productURL)
-			processedCount++
-		}
-
-		productTime := time.Since(productStartTime)
-		w.logger.Debugf("Product %s processed in %v", productURL, productTime)
-
-		// if i >= 5 {
-		// 	break // limit exceed
-		// }
+	logger.Infof("Westside extraction completed in %v", time.Since(startTime))
+	return results, nil
+}
+
+// DiscoverProductURLs returns the unique product URLs of the Westside catalog
+func (w *WestsideExtractor) DiscoverProductURLs(ctx context.Context) ([]string, error) {
+	productURLs, err := w.adapter.GetProductURLs(w.storeContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product URLs: %w", err)
+	}
+	return productURLs, nil
+}
 
+// ExtractProduct extracts the title and size charts of a single Westside product
+func (w *WestsideExtractor) ExtractProduct(ctx context.Context, productURL string) (*types.Product, error) {
+	title, sizeCharts, err := w.adapter.ExtractAllSizeCharts(w.storeContext(ctx), productURL)
+	if err != nil {
+		return nil, err
 	}
 
-	totalTime := time.Since(startTime)
-	w.logger.Infof("Westside extraction completed in %v", totalTime)
-	w.logger.Infof("Successfully processed %d/%d products", processedCount, len(productURLs))
+	// Use the extracted title, fallback to "Unknown Product" if empty
+	if title == "" {
+		title = "Unknown Product"
+	}
+	return &types.Product{
+		ProductTitle: title,
+		ProductURL:   productURL,
+		SizeCharts:   sizeCharts,
+	}, nil
+}
 
-	return results, nil
+// storeContext returns the adapter context of a call, ending with ctx and logging through
+// its logger
+func (w *WestsideExtractor) storeContext(ctx context.Context) types.Context {
+	return types.Context{
+		Config: w.adapter.Config(),
+		Logger: utils.LoggerFrom(ctx, w.logger),
+		Ctx:    ctx,
+	}
 }
 
 // ExtractToJSON extracts all size charts and saves to JSON file
@@ -111,6 +107,11 @@ func (w *WestsideExtractor) ExtractToJSON(ctx context.Context, filename string)
 	return nil
 }
 
+// Prewarm connects to the store ahead of the first run
+func (w *WestsideExtractor) Prewarm(ctx context.Context) error {
+	return w.adapter.Prewarm(ctx, w.adapter.BaseURL())
+}
+
 // Close cleans up resources
 func (w *WestsideExtractor) Close() {
 	if w.adapter != nil {
@@ -0,0 +1,100 @@
+package frontier
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet_SpillsPastMemoryLimit(t *testing.T) {
+	dir := t.TempDir()
+	set := NewSet(3, dir)
+
+	for i := 0; i < 10; i++ {
+		added, err := set.Add(fmt.Sprintf("https://example.com/products/%d", i))
+		require.NoError(t, err)
+		assert.True(t, added)
+	}
+	assert.True(t, set.Spilled())
+	assert.Equal(t, 10, set.Len())
+
+	// Duplicates are detected in memory and on disk
+	for _, i := range []int{0, 2, 5, 9} {
+		added, err := set.Add(fmt.Sprintf("https://example.com/products/%d", i))
+		require.NoError(t, err)
+		assert.False(t, added)
+	}
+	assert.Equal(t, 10, set.Len())
+
+	require.NoError(t, set.Close())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "spill file should be removed on close")
+}
+
+func TestSet_InMemoryOnly(t *testing.T) {
+	set := NewSet(0, "")
+	defer set.Close()
+
+	added, err := set.Add("a")
+	require.NoError(t, err)
+	assert.True(t, added)
+	added, err = set.Add("a")
+	require.NoError(t, err)
+	assert.False(t, added)
+	assert.False(t, set.Spilled())
+}
+
+func TestQueue_PreservesOrderAcrossSpill(t *testing.T) {
+	dir := t.TempDir()
+	queue := NewQueue(4, dir)
+
+	next := 0
+	pop := func() {
+		value, ok, err := queue.Pop()
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, fmt.Sprint(next), value)
+		next++
+	}
+
+	// Interleave pushes and pops so entries are spilled while memory still holds older ones
+	for i := 0; i < 10; i++ {
+		require.NoError(t, queue.Push(fmt.Sprint(i)))
+	}
+	pop()
+	pop()
+	for i := 10; i < 25; i++ {
+		require.NoError(t, queue.Push(fmt.Sprint(i)))
+	}
+	assert.Equal(t, 23, queue.Len())
+	for queue.Len() > 0 {
+		pop()
+	}
+	assert.Equal(t, 25, next)
+
+	_, ok, err := queue.Pop()
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, queue.Close())
+	assert.Error(t, queue.Push("late"))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestQueue_ReadySignalsPush(t *testing.T) {
+	queue := NewQueue(0, "")
+	defer queue.Close()
+
+	require.NoError(t, queue.Push("a"))
+	select {
+	case <-queue.Ready():
+	default:
+		t.Fatal("expected ready signal after push")
+	}
+}
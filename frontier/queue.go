@@ -0,0 +1,162 @@
+package frontier
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var queueBucket = []byte("queue")
+
+// Queue is a FIFO queue of strings that keeps up to a memory limit of entries in memory
+// and appends the rest to a temporary on-disk database. Pop refills memory from disk in
+// batches, preserving order. It is safe for concurrent use.
+type Queue struct {
+	mu          sync.Mutex
+	memoryLimit int
+	dir         string
+	memory      []string
+	disk        *spillFile
+	diskLen     int
+	nextSeq     uint64 // sequence number of the next spilled entry
+	headSeq     uint64 // sequence number of the oldest spilled entry
+	closed      bool
+	ready       chan struct{}
+}
+
+// NewQueue creates a queue keeping up to memoryLimit entries in memory (DefaultMemoryLimit
+// when not positive). Spilled entries are stored in dir, or os.TempDir when empty.
+func NewQueue(memoryLimit int, dir string) *Queue {
+	if memoryLimit <= 0 {
+		memoryLimit = DefaultMemoryLimit
+	}
+	return &Queue{
+		memoryLimit: memoryLimit,
+		dir:         dir,
+		ready:       make(chan struct{}, 1),
+	}
+}
+
+// Push appends value to the queue
+func (q *Queue) Push(value string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return fmt.Errorf("push to closed queue")
+	}
+
+	// Once anything is on disk, new entries must follow it to keep FIFO order
+	if q.diskLen == 0 && len(q.memory) < q.memoryLimit {
+		q.memory = append(q.memory, value)
+		q.signal()
+		return nil
+	}
+
+	if q.disk == nil {
+		disk, err := openSpillFile(q.dir, "frontier-queue-*.db", queueBucket)
+		if err != nil {
+			return err
+		}
+		q.disk = disk
+	}
+
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, q.nextSeq)
+	err := q.disk.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(queueBucket).Put(key, []byte(value))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to spill queue entry: %w", err)
+	}
+	q.nextSeq++
+	q.diskLen++
+	q.signal()
+	return nil
+}
+
+// Pop removes and returns the oldest entry. ok is false when the queue is empty.
+func (q *Queue) Pop() (value string, ok bool, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.memory) == 0 && q.diskLen > 0 {
+		if err := q.refill(); err != nil {
+			return "", false, err
+		}
+	}
+	if len(q.memory) == 0 {
+		return "", false, nil
+	}
+
+	value = q.memory[0]
+	q.memory[0] = ""
+	q.memory = q.memory[1:]
+	return value, true, nil
+}
+
+// refill moves up to memoryLimit of the oldest spilled entries back into memory
+func (q *Queue) refill() error {
+	var batch []string
+	err := q.disk.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(queueBucket)
+		start := make([]byte, 8)
+		binary.BigEndian.PutUint64(start, q.headSeq)
+
+		cursor := bucket.Cursor()
+		var keys [][]byte
+		for k, v := cursor.Seek(start); k != nil && len(batch) < q.memoryLimit; k, v = cursor.Next() {
+			batch = append(batch, string(v))
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		for _, k := range keys {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read spilled queue entries: %w", err)
+	}
+
+	q.memory = append(q.memory[:0], batch...)
+	q.headSeq += uint64(len(batch))
+	q.diskLen -= len(batch)
+	return nil
+}
+
+// Len returns the number of queued entries
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.memory) + q.diskLen
+}
+
+// Ready returns a channel that receives a value after Push, for consumers waiting on
+// an empty queue
+func (q *Queue) Ready() <-chan struct{} {
+	return q.ready
+}
+
+// signal wakes a consumer waiting on Ready without blocking
+func (q *Queue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Close releases the queue's on-disk storage. Further pushes fail.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	err := q.disk.close()
+	q.disk = nil
+	q.memory = nil
+	q.diskLen = 0
+	return err
+}
@@ -0,0 +1,109 @@
+package frontier
+
+import (
+	"fmt"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var setBucket = []byte("seen")
+
+// Set is a string set that keeps up to a memory limit of entries in a map and stores
+// the rest in a temporary on-disk database. It is safe for concurrent use.
+type Set struct {
+	mu          sync.Mutex
+	memoryLimit int
+	dir         string
+	memory      map[string]struct{}
+	disk        *spillFile
+	size        int
+}
+
+// NewSet creates a set keeping up to memoryLimit entries in memory (DefaultMemoryLimit
+// when not positive). Spilled entries are stored in dir, or os.TempDir when empty.
+func NewSet(memoryLimit int, dir string) *Set {
+	if memoryLimit <= 0 {
+		memoryLimit = DefaultMemoryLimit
+	}
+	return &Set{
+		memoryLimit: memoryLimit,
+		dir:         dir,
+		memory:      make(map[string]struct{}),
+	}
+}
+
+// Add inserts value and reports whether it was not already present
+func (s *Set) Add(value string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.memory[value]; ok {
+		return false, nil
+	}
+
+	if s.disk != nil {
+		added := false
+		err := s.disk.db.Update(func(tx *bolt.Tx) error {
+			bucket := tx.Bucket(setBucket)
+			if bucket.Get([]byte(value)) != nil {
+				return nil
+			}
+			added = true
+			return bucket.Put([]byte(value), []byte{})
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to add to spilled set: %w", err)
+		}
+		if added {
+			s.size++
+		}
+		return added, nil
+	}
+
+	if len(s.memory) < s.memoryLimit {
+		s.memory[value] = struct{}{}
+		s.size++
+		return true, nil
+	}
+
+	// Memory is full: new entries go to disk from now on
+	disk, err := openSpillFile(s.dir, "frontier-set-*.db", setBucket)
+	if err != nil {
+		return false, err
+	}
+	s.disk = disk
+	err = s.disk.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(setBucket).Put([]byte(value), []byte{})
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to add to spilled set: %w", err)
+	}
+	s.size++
+	return true, nil
+}
+
+// Len returns the number of entries in the set
+func (s *Set) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// Spilled reports whether the set has started storing entries on disk
+func (s *Set) Spilled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.disk != nil
+}
+
+// Close releases the set's on-disk storage
+func (s *Set) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.disk.close()
+	s.disk = nil
+	s.memory = make(map[string]struct{})
+	s.size = 0
+	return err
+}
@@ -0,0 +1,60 @@
+// Package frontier provides the URL frontier used during product discovery: a dedupe
+// set and a FIFO queue that keep a bounded number of entries in memory and spill the
+// rest to a temporary bbolt database, so catalogs with tens of thousands of products
+// do not grow the process without limit.
+package frontier
+
+import (
+	"fmt"
+	"os"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultMemoryLimit is the number of entries kept in memory before spilling to disk
+const DefaultMemoryLimit = 10000
+
+// spillFile is a temporary bbolt database holding spilled entries
+type spillFile struct {
+	path string
+	db   *bolt.DB
+}
+
+// openSpillFile creates a temporary database in dir (os.TempDir when empty) with one bucket
+func openSpillFile(dir, pattern string, bucket []byte) (*spillFile, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spill file: %w", err)
+	}
+	path := f.Name()
+	f.Close()
+
+	// The frontier is rebuilt on every run, so durability is not needed
+	db, err := bolt.Open(path, 0600, &bolt.Options{NoSync: true, NoFreelistSync: true})
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to open spill file: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to initialise spill file: %w", err)
+	}
+	return &spillFile{path: path, db: db}, nil
+}
+
+// close closes and removes the database
+func (s *spillFile) close() error {
+	if s == nil {
+		return nil
+	}
+	err := s.db.Close()
+	if removeErr := os.Remove(s.path); err == nil && removeErr != nil && !os.IsNotExist(removeErr) {
+		err = removeErr
+	}
+	return err
+}
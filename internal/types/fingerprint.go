@@ -0,0 +1,42 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// ComputeFingerprint hashes the chart's normalized content: the unit, the headers and
+// every row's cells in header order, lowercased with whitespace collapsed. The name and
+// source are left out, so the same chart read by different parsers or shared by many
+// products gets the same fingerprint.
+func (c *SizeChart) ComputeFingerprint() string {
+	h := sha256.New()
+	write := func(value string, sep byte) {
+		h.Write([]byte(strings.ToLower(strings.Join(strings.Fields(value), " "))))
+		h.Write([]byte{sep})
+	}
+
+	write(c.Unit, 0x1d)
+	for _, header := range c.Headers {
+		write(header, 0x1f)
+	}
+	for _, row := range c.Rows {
+		h.Write([]byte{0x1e})
+		for _, header := range c.Headers {
+			write(row[header], 0x1f)
+		}
+	}
+
+	sum := h.Sum(nil)
+	return hex.EncodeToString(sum[:16])
+}
+
+// SetFingerprints computes the fingerprint of every size chart of the product
+func (p *Product) SetFingerprints() {
+	for _, chart := range p.SizeCharts {
+		if chart != nil {
+			chart.Fingerprint = chart.ComputeFingerprint()
+		}
+	}
+}
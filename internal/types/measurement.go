@@ -0,0 +1,94 @@
+package types
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Measurement is a chart cell parsed into numbers. Raw keeps the cell as scraped; a
+// single value has Min == Max, a range such as "34-36" spans Min to Max.
+type Measurement struct {
+	Raw string  `json:"raw"`
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// measurementNumber matches one number of a cell: "34", "34.5", "34,5" (a comma followed
+// by a single digit is a decimal comma, so "34,36" stays two numbers), "34½" or "34 1/2"
+var measurementNumber = regexp.MustCompile(`(\d+)(?:\.(\d+)|,(\d)\b)?(?:\s*([½¼¾])|\s+([13])/([24])\b)?`)
+
+// vulgarFractions are the fraction characters found after a number
+var vulgarFractions = map[string]float64{"½": 0.5, "¼": 0.25, "¾": 0.75}
+
+// ParseMeasurementValues returns every number of a measurement cell, e.g. 34 and 36 for
+// "34-36" or 34.5 for `34½"`
+func ParseMeasurementValues(raw string) []float64 {
+	var values []float64
+	for _, match := range measurementNumber.FindAllStringSubmatch(raw, -1) {
+		number := match[1]
+		if decimals := match[2] + match[3]; decimals != "" {
+			number += "." + decimals
+		}
+		value, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			continue
+		}
+		switch {
+		case match[4] != "":
+			value += vulgarFractions[match[4]]
+		case match[5] != "":
+			numerator, _ := strconv.ParseFloat(match[5], 64)
+			denominator, _ := strconv.ParseFloat(match[6], 64)
+			value += numerator / denominator
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// ParseMeasurement parses a measurement cell; ok is false when it holds no number
+func ParseMeasurement(raw string) (m Measurement, ok bool) {
+	values := ParseMeasurementValues(raw)
+	if len(values) == 0 {
+		return Measurement{Raw: raw}, false
+	}
+	m = Measurement{Raw: raw, Min: values[0], Max: values[0]}
+	for _, value := range values[1:] {
+		if value < m.Min {
+			m.Min = value
+		}
+		if value > m.Max {
+			m.Max = value
+		}
+	}
+	return m, true
+}
+
+// SetMeasurements parses the measurement cells of every row into Measurements, one map
+// per row keyed by header. The size column and cells without a number are left out.
+func (c *SizeChart) SetMeasurements() {
+	sizeColumn := c.SizeColumn()
+	c.Measurements = make([]map[string]Measurement, len(c.Rows))
+	for i, row := range c.Rows {
+		values := make(map[string]Measurement)
+		for header, cell := range row {
+			if header == sizeColumn || strings.TrimSpace(cell) == "" {
+				continue
+			}
+			if m, ok := ParseMeasurement(cell); ok {
+				values[header] = m
+			}
+		}
+		c.Measurements[i] = values
+	}
+}
+
+// SetMeasurements parses the measurement cells of every size chart of the product
+func (p *Product) SetMeasurements() {
+	for _, chart := range p.SizeCharts {
+		if chart != nil {
+			chart.SetMeasurements()
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMeasurement(t *testing.T) {
+	tests := []struct {
+		raw      string
+		min, max float64
+	}{
+		{"34", 34, 34},
+		{"34.5", 34.5, 34.5},
+		{"34,5", 34.5, 34.5},
+		{"34½", 34.5, 34.5},
+		{"34 1/2", 34.5, 34.5},
+		{`34"`, 34, 34},
+		{"34 - 36", 34, 36},
+		{"36,34", 34, 36},
+		{"32¾–34¼", 32.75, 34.25},
+	}
+	for _, tt := range tests {
+		m, ok := ParseMeasurement(tt.raw)
+		if assert.True(t, ok, tt.raw) {
+			assert.Equal(t, Measurement{Raw: tt.raw, Min: tt.min, Max: tt.max}, m, tt.raw)
+		}
+	}
+
+	_, ok := ParseMeasurement("N/A")
+	assert.False(t, ok)
+}
+
+func TestSizeChart_SetMeasurements(t *testing.T) {
+	chart := &SizeChart{
+		Headers: []string{"Size", "Bust (in)", "Fit"},
+		Rows:    []map[string]string{{"Size": "32", "Bust (in)": "34½", "Fit": "Relaxed"}},
+	}
+	chart.SetMeasurements()
+	assert.Equal(t, []map[string]Measurement{{"Bust (in)": {Raw: "34½", Min: 34.5, Max: 34.5}}}, chart.Measurements)
+	assert.Equal(t, "34½", chart.Rows[0]["Bust (in)"])
+}
@@ -0,0 +1,74 @@
+package types
+
+import "fmt"
+
+// Versions of the result structure. v1 is the original one: stores with their products,
+// each with the headers and rows of its size charts. v2 adds units, measurements, size
+// labels, provenance (source, parser, fingerprint), missing charts, coverage and the other
+// extraction details, and states its version as schema_version.
+const (
+	SchemaV1 = 1
+	SchemaV2 = 2
+
+	// CurrentSchemaVersion is the structure results are extracted in
+	CurrentSchemaVersion = SchemaV2
+)
+
+// SchemaVersions returns the result structures that can be written
+func SchemaVersions() []int {
+	return []int{SchemaV1, SchemaV2}
+}
+
+// ValidSchemaVersion reports whether results can be written in version; 0 stands for the
+// current version
+func ValidSchemaVersion(version int) bool {
+	return version == 0 || version == SchemaV1 || version == SchemaV2
+}
+
+// AsSchema returns the result in the given structure version, 0 being the current one.
+// The result itself is returned for the current version; v1 is a copy holding only the
+// v1 fields, with shared charts resolved into their products.
+func (r *ExtractionResult) AsSchema(version int) (*ExtractionResult, error) {
+	switch version {
+	case 0, CurrentSchemaVersion:
+		return r, nil
+	case SchemaV1:
+		v1 := &ExtractionResult{Stores: make([]StoreResult, 0, len(r.Stores))}
+		for _, store := range r.Stores {
+			products := make([]Product, 0, len(store.Products))
+			for _, product := range store.Products {
+				products = append(products, product.asV1(r.Charts))
+			}
+			v1.Stores = append(v1.Stores, StoreResult{StoreName: store.StoreName, Products: products, Error: store.Error})
+		}
+		return v1, nil
+	default:
+		return nil, fmt.Errorf("unsupported schema version %d (supported: %v)", version, SchemaVersions())
+	}
+}
+
+// ProductAsSchema returns a product, as streamed on its own, in the given structure
+// version; see AsSchema
+func ProductAsSchema(product Product, version int) Product {
+	if version == SchemaV1 {
+		return product.asV1(nil)
+	}
+	return product
+}
+
+// asV1 returns the v1 fields of a product, resolving ChartIDs through charts
+func (p Product) asV1(charts map[string]*SizeChart) Product {
+	v1 := Product{ProductTitle: p.ProductTitle, ProductURL: p.ProductURL}
+	sizeCharts := p.SizeCharts
+	for _, id := range p.ChartIDs {
+		if chart, ok := charts[id]; ok {
+			sizeCharts = append(sizeCharts, chart)
+		}
+	}
+	for _, chart := range sizeCharts {
+		if chart != nil {
+			v1.SizeCharts = append(v1.SizeCharts, &SizeChart{Headers: chart.Headers, Rows: chart.Rows})
+		}
+	}
+	return v1
+}
@@ -0,0 +1,46 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsSchema(t *testing.T) {
+	chart := &SizeChart{Unit: "in", Parser: "table", Fingerprint: "abc", Headers: []string{"Size", "Bust"}, Rows: []map[string]string{{"Size": "M", "Bust": "36"}}}
+	chart.SetMeasurements()
+	result := &ExtractionResult{
+		SchemaVersion: CurrentSchemaVersion,
+		RunID:         "run-1",
+		Stores: []StoreResult{
+			{StoreName: "westside.com", Products: []Product{
+				{ProductTitle: "Dress", ProductURL: "a", SizeCharts: []*SizeChart{chart}, Attributes: map[string]string{"id": "1"}},
+				{ProductTitle: "Top", ProductURL: "b", ChartIDs: []string{"abc"}},
+			}, MissingCharts: map[string][]MissingProduct{MissingReasonNoTable: {{ProductURL: "c"}}}},
+			{StoreName: "suqah.com", Products: []Product{}, Error: "blocked"},
+		},
+		Charts: map[string]*SizeChart{"abc": chart},
+	}
+
+	current, err := result.AsSchema(0)
+	require.NoError(t, err)
+	assert.Same(t, result, current)
+
+	v1, err := result.AsSchema(SchemaV1)
+	require.NoError(t, err)
+	v1Chart := &SizeChart{Headers: []string{"Size", "Bust"}, Rows: []map[string]string{{"Size": "M", "Bust": "36"}}}
+	assert.Equal(t, &ExtractionResult{Stores: []StoreResult{
+		{StoreName: "westside.com", Products: []Product{
+			{ProductTitle: "Dress", ProductURL: "a", SizeCharts: []*SizeChart{v1Chart}},
+			{ProductTitle: "Top", ProductURL: "b", SizeCharts: []*SizeChart{v1Chart}},
+		}},
+		{StoreName: "suqah.com", Products: []Product{}, Error: "blocked"},
+	}}, v1)
+	assert.NotEmpty(t, chart.Measurements, "the result itself is left as is")
+
+	_, err = result.AsSchema(3)
+	assert.Error(t, err)
+	assert.False(t, ValidSchemaVersion(3))
+	assert.True(t, ValidSchemaVersion(0))
+}
@@ -0,0 +1,172 @@
+package types
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Size systems of a SizeLabel
+const (
+	SizeSystemAlpha   = "alpha"   // XS, S, M, L, XL, 2XL, ...
+	SizeSystemNumeric = "numeric" // Bare numbers such as 28, 30 or 7.5
+	SizeSystemUK      = "uk"
+	SizeSystemUS      = "us"
+	SizeSystemEU      = "eu"
+	SizeSystemBra     = "bra" // Band and cup, e.g. 34B
+	SizeSystemFree    = "free"
+)
+
+// SizeLabel is a size token in canonical form, so the same size written differently by
+// different stores ("XXL" and "2XL", "Extra Small" and "XS") compares equal
+type SizeLabel struct {
+	System string `json:"system,omitempty"` // SizeSystem*, empty when the label is not recognised
+	Value  string `json:"value"`            // Canonical size: "2XL", "10", "7.5", "34"
+
+	// Modifier qualifies the size: the cup of a bra size or a fit such as "petite",
+	// "tall" or "plus"
+	Modifier string `json:"modifier,omitempty"`
+}
+
+var (
+	regionalSizePattern = regexp.MustCompile(`^(UK|US|EU)\s*(\d+(?:\.\d+)?)$`)
+	braSizePattern      = regexp.MustCompile(`^(\d{2})\s*([A-H]{1,3})$`)
+	alphaSizePattern    = regexp.MustCompile(`^(\d*)(X*)(S|M|L)$`)
+	numericSizePattern  = regexp.MustCompile(`^\d+(?:\.\d+)?$`)
+	halfSizePattern     = regexp.MustCompile(`(\d+)\s*(?:½|1/2|,5)`)
+)
+
+// alphaWords spells out alpha sizes, longest first so "EXTRA SMALL" wins over "SMALL"
+var alphaWords = []struct{ words, token string }{
+	{"TRIPLE EXTRA", "XXX"}, {"DOUBLE EXTRA", "XX"}, {"EXTRA", "X"},
+	{"SMALL", "S"}, {"MEDIUM", "M"}, {"LARGE", "L"},
+}
+
+// fitModifiers are words qualifying a size rather than naming it
+var fitModifiers = []string{"PETITE", "TALL", "PLUS", "LONG", "SHORT", "REGULAR"}
+
+// ParseSizeLabel canonicalizes a size token such as "XXL", "Extra Small", "UK 10",
+// "34B" or "7 1/2". Unrecognised tokens keep their cleaned up text as Value and an
+// empty System.
+func ParseSizeLabel(raw string) SizeLabel {
+	text := strings.ToUpper(strings.Join(strings.Fields(raw), " "))
+	text = strings.TrimSpace(strings.TrimPrefix(text, "SIZE"))
+	if text == "" {
+		return SizeLabel{}
+	}
+
+	switch strings.ReplaceAll(text, " ", "") {
+	case "FREESIZE", "FREE", "ONESIZE", "OS", "OSFA":
+		return SizeLabel{System: SizeSystemFree, Value: "ONE SIZE"}
+	}
+
+	var label SizeLabel
+	for _, modifier := range fitModifiers {
+		if trimmed := strings.TrimSpace(strings.Replace(text, modifier, "", 1)); trimmed != text && trimmed != "" {
+			label.Modifier = strings.ToLower(modifier)
+			text = strings.Trim(trimmed, " -/()")
+			break
+		}
+	}
+	text = halfSizePattern.ReplaceAllString(text, "$1.5")
+
+	if match := regionalSizePattern.FindStringSubmatch(text); match != nil {
+		label.System, label.Value = strings.ToLower(match[1]), match[2]
+		return label
+	}
+	if match := braSizePattern.FindStringSubmatch(text); match != nil {
+		return SizeLabel{System: SizeSystemBra, Value: match[1], Modifier: match[2]}
+	}
+	if numericSizePattern.MatchString(text) {
+		label.System, label.Value = SizeSystemNumeric, text
+		return label
+	}
+
+	alpha := text
+	for _, word := range alphaWords {
+		alpha = strings.ReplaceAll(alpha, word.words, word.token)
+	}
+	alpha = strings.NewReplacer(" ", "", "-", "").Replace(alpha)
+	if match := alphaSizePattern.FindStringSubmatch(alpha); match != nil {
+		if value, ok := canonicalAlpha(match[1], len(match[2]), match[3]); ok {
+			label.System, label.Value = SizeSystemAlpha, value
+			return label
+		}
+	}
+
+	label.Value = text
+	return label
+}
+
+// canonicalAlpha writes an alpha size with its X count as a number past one X: XS, XL,
+// 2XL, 3XS. ok is false for malformed tokens such as "XM" or "2XXL".
+func canonicalAlpha(digits string, xs int, letter string) (string, bool) {
+	count := xs
+	if digits != "" {
+		if xs != 1 {
+			return "", false
+		}
+		count, _ = strconv.Atoi(digits)
+	}
+	switch {
+	case letter == "M" && count > 0:
+		return "", false
+	case count == 0:
+		return letter, true
+	case count == 1:
+		return "X" + letter, true
+	}
+	return strconv.Itoa(count) + "X" + letter, true
+}
+
+// Key identifies the size for joins across stores, e.g. "alpha:2XL" or "bra:34B"
+func (l SizeLabel) Key() string {
+	key := l.System + ":" + l.Value
+	if l.Modifier != "" {
+		if l.System == SizeSystemBra {
+			return key + l.Modifier
+		}
+		key += "/" + l.Modifier
+	}
+	return key
+}
+
+// SizeColumn returns the header of the chart's size column: "Size" or the first header
+// mentioning a size, else the first header
+func (c *SizeChart) SizeColumn() string {
+	for _, header := range c.Headers {
+		if strings.EqualFold(strings.TrimSpace(header), "size") {
+			return header
+		}
+	}
+	for _, header := range c.Headers {
+		if strings.Contains(strings.ToLower(header), "size") {
+			return header
+		}
+	}
+	if len(c.Headers) > 0 {
+		return c.Headers[0]
+	}
+	return ""
+}
+
+// SetSizeLabels parses the size of every row into SizeLabels, one label per row
+func (c *SizeChart) SetSizeLabels() {
+	column := c.SizeColumn()
+	if column == "" {
+		return
+	}
+	c.SizeLabels = make([]SizeLabel, len(c.Rows))
+	for i, row := range c.Rows {
+		c.SizeLabels[i] = ParseSizeLabel(row[column])
+	}
+}
+
+// SetSizeLabels parses the row sizes of every size chart of the product
+func (p *Product) SetSizeLabels() {
+	for _, chart := range p.SizeCharts {
+		if chart != nil {
+			chart.SetSizeLabels()
+		}
+	}
+}
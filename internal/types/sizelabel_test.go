@@ -0,0 +1,48 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSizeLabel(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want SizeLabel
+	}{
+		{"XXL", SizeLabel{System: SizeSystemAlpha, Value: "2XL"}},
+		{"2XL", SizeLabel{System: SizeSystemAlpha, Value: "2XL"}},
+		{"Extra Small", SizeLabel{System: SizeSystemAlpha, Value: "XS"}},
+		{"xs", SizeLabel{System: SizeSystemAlpha, Value: "XS"}},
+		{"Medium", SizeLabel{System: SizeSystemAlpha, Value: "M"}},
+		{"Petite M", SizeLabel{System: SizeSystemAlpha, Value: "M", Modifier: "petite"}},
+		{"UK 10", SizeLabel{System: SizeSystemUK, Value: "10"}},
+		{"eu38", SizeLabel{System: SizeSystemEU, Value: "38"}},
+		{"34B", SizeLabel{System: SizeSystemBra, Value: "34", Modifier: "B"}},
+		{"7 1/2", SizeLabel{System: SizeSystemNumeric, Value: "7.5"}},
+		{"28", SizeLabel{System: SizeSystemNumeric, Value: "28"}},
+		{"Free Size", SizeLabel{System: SizeSystemFree, Value: "ONE SIZE"}},
+		{"XM", SizeLabel{Value: "XM"}},
+		{"", SizeLabel{}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ParseSizeLabel(tt.raw), tt.raw)
+	}
+}
+
+func TestSizeLabel_Key(t *testing.T) {
+	assert.Equal(t, ParseSizeLabel("XXL").Key(), ParseSizeLabel("2xl").Key())
+	assert.Equal(t, "bra:34B", ParseSizeLabel("34 B").Key())
+	assert.Equal(t, "alpha:L/tall", ParseSizeLabel("L Tall").Key())
+	assert.NotEqual(t, ParseSizeLabel("L").Key(), ParseSizeLabel("L Tall").Key())
+}
+
+func TestSizeChart_SetSizeLabels(t *testing.T) {
+	chart := &SizeChart{
+		Headers: []string{"Bust (in)", "Size"},
+		Rows:    []map[string]string{{"Size": "XXL", "Bust (in)": "44"}, {"Size": "Extra Small", "Bust (in)": "30"}},
+	}
+	chart.SetSizeLabels()
+	assert.Equal(t, []SizeLabel{{System: SizeSystemAlpha, Value: "2XL"}, {System: SizeSystemAlpha, Value: "XS"}}, chart.SizeLabels)
+}
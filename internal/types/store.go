@@ -0,0 +1,41 @@
+package types
+
+import (
+	"strings"
+	"sync"
+)
+
+// ShopifyDomainSuffix is the suffix of the domain Shopify gives every store, which keeps
+// serving it next to the store's own domain
+const ShopifyDomainSuffix = ".myshopify.com"
+
+var (
+	storeAliasesMu sync.RWMutex
+	storeAliases   = map[string]string{} // alias domain to canonical store domain
+)
+
+// CanonicalStore returns the identity of a store domain or host: lowercased, without a
+// leading "www." or trailing dot, and with registered aliases such as the store's
+// *.myshopify.com domain replaced by the store they serve. "www.westside.com",
+// "WestSide.com" and an aliased myshopify domain are all "westside.com".
+func CanonicalStore(store string) string {
+	key := strings.TrimPrefix(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(store)), "."), "www.")
+	storeAliasesMu.RLock()
+	defer storeAliasesMu.RUnlock()
+	if canonical, ok := storeAliases[key]; ok {
+		return canonical
+	}
+	return key
+}
+
+// RegisterStoreAlias makes alias (e.g. "westside-fashion.myshopify.com") identify the
+// same store as store. Registering an alias again replaces its store.
+func RegisterStoreAlias(alias, store string) {
+	alias, store = CanonicalStore(alias), CanonicalStore(store)
+	if alias == "" || store == "" || alias == store {
+		return
+	}
+	storeAliasesMu.Lock()
+	defer storeAliasesMu.Unlock()
+	storeAliases[alias] = store
+}
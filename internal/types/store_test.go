@@ -0,0 +1,22 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalStore(t *testing.T) {
+	assert.Equal(t, "westside.com", CanonicalStore(" WWW.Westside.com. "))
+	assert.Equal(t, "westside.com", CanonicalStore("westside.com"))
+	assert.Equal(t, "", CanonicalStore(""))
+
+	assert.Equal(t, "canonical-test.myshopify.com", CanonicalStore("canonical-test.myshopify.com"))
+	RegisterStoreAlias("Canonical-Test.myshopify.com", "www.canonical-test.com")
+	assert.Equal(t, "canonical-test.com", CanonicalStore("canonical-test.myshopify.com"))
+	assert.Equal(t, "canonical-test.com", CanonicalStore("www.canonical-test.myshopify.com"))
+
+	// A store is no alias of itself
+	RegisterStoreAlias("canonical-test.com", "canonical-test.com")
+	assert.Equal(t, "canonical-test.com", CanonicalStore("canonical-test.com"))
+}
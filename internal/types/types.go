@@ -1,9 +1,72 @@
 package types
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Measurement units reported on a SizeChart
+const (
+	UnitInches      = "in"
+	UnitCentimeters = "cm"
+	UnitMixed       = "mixed"
+)
+
+// Outcomes of the inches / centimeters cross-check of a chart holding both units
+const (
+	UnitCheckSwapped  = "swapped"  // The in and cm values were swapped and have been put back
+	UnitCheckMismatch = "mismatch" // The in and cm values do not convert into each other
+)
+
+// Chart sources describe where a SizeChart was read from
+const (
+	SourceSelector = "selector" // HTML table located with a CSS selector
+	SourceApp      = "app"      // Third-party size chart app markup (e.g. Kiwi Sizing)
+	SourceAPI      = "api"      // Structured store or app API response
+	SourceOCR      = "ocr"      // Text recognised in a size chart image
+)
 
 // SizeChart represents a product size chart
 type SizeChart struct {
+	Name    string              `json:"name,omitempty"`
+	Unit    string              `json:"unit,omitempty"`
+	Source  string              `json:"source,omitempty"`
+	Headers []string            `json:"headers"`
+	Rows    []map[string]string `json:"rows"`
+
+	// Parser names the chart parser that extracted the chart (see adapters.ChartParser)
+	Parser string `json:"parser,omitempty"`
+
+	// SizeLabels holds the canonical size of each row, in row order (see ParseSizeLabel)
+	SizeLabels []SizeLabel `json:"size_labels,omitempty"`
+
+	// Measurements holds the numeric value of each row's measurement cells, in row order
+	// (see ParseMeasurement); Rows keep the cells as scraped
+	Measurements []map[string]Measurement `json:"measurements,omitempty"`
+
+	// UnitConfidence is set when Unit was inferred from the measurement values because
+	// the page names no unit: the share of values agreeing with it (0-1)
+	UnitConfidence float64 `json:"unit_confidence,omitempty"`
+
+	// UnitCheck flags a chart whose inch and centimeter values disagree (UnitCheck*);
+	// empty when they agree or the chart holds a single unit
+	UnitCheck string `json:"unit_check,omitempty"`
+
+	// Fingerprint identifies the chart's content, see ComputeFingerprint
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// Raw is the table as scraped, with every column under its original header, for a
+	// chart filtered down to the canonical columns; set only with Config.IncludeRaw
+	Raw *RawChart `json:"raw,omitempty"`
+}
+
+// RawChart is a size chart table before filtering, e.g. with Length and Shoulder
+// columns the canonical chart drops
+type RawChart struct {
 	Headers []string            `json:"headers"`
 	Rows    []map[string]string `json:"rows"`
 }
@@ -13,6 +76,46 @@ type Product struct {
 	ProductTitle string       `json:"product_title"`
 	ProductURL   string       `json:"product_url"`
 	SizeCharts   []*SizeChart `json:"size_chart,omitempty"`
+
+	// ChartIDs replaces SizeCharts in deduplicated output: fingerprints of charts listed
+	// once in ExtractionResult.Charts
+	ChartIDs []string `json:"chart_ids,omitempty"`
+
+	// Attributes carries data attached by extraction hooks, e.g. internal product IDs
+	Attributes map[string]string `json:"attributes,omitempty"`
+
+	// Trace holds the diagnostics logged while the product was extracted; set only with
+	// Config.Trace
+	Trace []TraceEvent `json:"trace,omitempty"`
+}
+
+// TraceEvent is one diagnostic line of an extraction trace
+type TraceEvent struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+}
+
+// Probable reasons a fetched product yielded no size chart
+const (
+	MissingReasonNoTable      = "no_table_found"        // No size chart markup on the page
+	MissingReasonRejected     = "rejected_by_validator" // A table was found but failed validation
+	MissingReasonFetchBlocked = "fetch_blocked"         // The page could not be fetched
+	MissingReasonTimedOut     = "timed_out"             // The product exceeded its extraction deadline
+	MissingReasonPasswordPage = "password_page"         // The store redirected to its password form
+	MissingReasonChallenge    = "bot_challenge"         // The store answered with a bot challenge
+	MissingReasonQueue        = "queue_page"            // The store sent the visitor to a queue (waiting room)
+	MissingReasonUnknown      = "unknown"
+)
+
+// MissingProduct describes a product that was processed but produced no size chart
+type MissingProduct struct {
+	ProductURL string `json:"product_url"`
+	Error      string `json:"error,omitempty"`
+
+	// Trace holds the diagnostics logged while the product was extracted; set only with
+	// Config.Trace
+	Trace []TraceEvent `json:"trace,omitempty"`
 }
 
 // StoreResult represents the extraction result for a single store
@@ -20,11 +123,191 @@ type StoreResult struct {
 	StoreName string    `json:"store_name"`
 	Products  []Product `json:"products"`
 	Error     string    `json:"error,omitempty"`
+
+	// Products without a size chart, grouped by MissingReason*
+	MissingCharts map[string][]MissingProduct `json:"missing_charts,omitempty"`
+
+	// How much of the store's catalog the run covered
+	Coverage *Coverage `json:"coverage,omitempty"`
+
+	// DiscoveryTruncated is set when discovery ran out of Config.DiscoveryTimeout and
+	// only the products found by then were extracted
+	DiscoveryTruncated bool `json:"discovery_truncated,omitempty"`
+
+	// Partial is set when only part of the discovered products was extracted on purpose:
+	// a sample (Config.SampleRate, Config.SampleCount) or one chunk of a chunked extraction
+	Partial bool `json:"partial,omitempty"`
+
+	// Number of extracted size charts per chart parser, see CountChartsByParser
+	ChartsByParser map[string]int `json:"charts_by_parser,omitempty"`
+
+	// WrittenProducts counts the products with a size chart that a streaming run wrote
+	// out as they were extracted, and so are not in Products
+	WrittenProducts int `json:"written_products,omitempty"`
+}
+
+// ParserUnknown counts the charts of ChartsByParser that carry no parser name
+const ParserUnknown = "unknown"
+
+// CountChartsByParser counts the size charts of the products per parser, nil when there
+// are no charts
+func CountChartsByParser(products []Product) map[string]int {
+	var counts map[string]int
+	for _, product := range products {
+		for _, chart := range product.SizeCharts {
+			if counts == nil {
+				counts = make(map[string]int)
+			}
+			parser := chart.Parser
+			if parser == "" {
+				parser = ParserUnknown
+			}
+			counts[parser]++
+		}
+	}
+	return counts
+}
+
+// FormatChartsByParser renders chart counts per parser for logs, most charts first:
+// "kiwi: 412, generic-table: 30, ocr: 2"
+func FormatChartsByParser(counts map[string]int) string {
+	parsers := make([]string, 0, len(counts))
+	for parser := range counts {
+		parsers = append(parsers, parser)
+	}
+	sort.Slice(parsers, func(i, j int) bool {
+		if counts[parsers[i]] != counts[parsers[j]] {
+			return counts[parsers[i]] > counts[parsers[j]]
+		}
+		return parsers[i] < parsers[j]
+	})
+
+	parts := make([]string, len(parsers))
+	for i, parser := range parsers {
+		parts[i] = fmt.Sprintf("%s: %d", parser, counts[parser])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Coverage compares the products extracted in a run with the size of the store's catalog
+type Coverage struct {
+	CatalogProducts    int     `json:"catalog_products"`
+	CatalogSource      string  `json:"catalog_source"` // "products.json", "discovery" or "portfolio"
+	DiscoveredProducts int     `json:"discovered_products"`
+	ProductsWithCharts int     `json:"products_with_charts"`
+	Percent            float64 `json:"percent"`
+}
+
+// NewCoverage builds a Coverage, computing the percentage of catalog products with a chart
+func NewCoverage(catalogProducts int, catalogSource string, discovered, withCharts int) *Coverage {
+	coverage := &Coverage{
+		CatalogProducts:    catalogProducts,
+		CatalogSource:      catalogSource,
+		DiscoveredProducts: discovered,
+		ProductsWithCharts: withCharts,
+	}
+	if catalogProducts > 0 {
+		coverage.Percent = math.Round(float64(withCharts)/float64(catalogProducts)*1000) / 10
+	}
+	return coverage
+}
+
+// String renders the coverage as a human readable summary
+func (c *Coverage) String() string {
+	return fmt.Sprintf("extracted charts for %d of %d catalog products (%.1f%%)", c.ProductsWithCharts, c.CatalogProducts, c.Percent)
+}
+
+// PortfolioResult aggregates the results of a named group of stores
+type PortfolioResult struct {
+	Name                  string   `json:"name"`
+	Stores                []string `json:"stores"`
+	FailedStores          []string `json:"failed_stores,omitempty"`
+	ProductsWithCharts    int      `json:"products_with_charts"`
+	ProductsMissingCharts int      `json:"products_missing_charts"`
+
+	// Coverage sums the catalog coverage of the stores that report one
+	Coverage *Coverage `json:"coverage,omitempty"`
+}
+
+// NewPortfolioResult aggregates the results of a portfolio's stores. Stores are matched
+// by domain, ignoring case and a leading "www.".
+func NewPortfolioResult(name string, stores []string, results []StoreResult) PortfolioResult {
+	portfolio := PortfolioResult{Name: name, Stores: stores}
+	members := make(map[string]bool, len(stores))
+	for _, store := range stores {
+		members[storeKey(store)] = true
+	}
+
+	var catalog, discovered, withCharts int
+	reported := make(map[string]bool, len(stores))
+	for _, result := range results {
+		if !members[storeKey(result.StoreName)] {
+			continue
+		}
+		reported[storeKey(result.StoreName)] = true
+		if result.Error != "" {
+			portfolio.FailedStores = append(portfolio.FailedStores, result.StoreName)
+		}
+		portfolio.ProductsWithCharts += len(result.Products) + result.WrittenProducts
+		for _, missing := range result.MissingCharts {
+			portfolio.ProductsMissingCharts += len(missing)
+		}
+		if result.Coverage != nil {
+			catalog += result.Coverage.CatalogProducts
+			discovered += result.Coverage.DiscoveredProducts
+			withCharts += result.Coverage.ProductsWithCharts
+		}
+	}
+	// Stores without a result at all were skipped or failed before reporting one
+	for _, store := range stores {
+		if !reported[storeKey(store)] {
+			portfolio.FailedStores = append(portfolio.FailedStores, store)
+		}
+	}
+	if catalog > 0 {
+		portfolio.Coverage = NewCoverage(catalog, "portfolio", discovered, withCharts)
+	}
+	return portfolio
+}
+
+// NewPortfolioResults aggregates the store results of each portfolio, sorted by name
+func NewPortfolioResults(portfolios map[string][]string, results []StoreResult) []PortfolioResult {
+	names := make([]string, 0, len(portfolios))
+	for name := range portfolios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var aggregated []PortfolioResult
+	for _, name := range names {
+		aggregated = append(aggregated, NewPortfolioResult(name, portfolios[name], results))
+	}
+	return aggregated
+}
+
+// storeKey normalizes a store domain for comparison
+func storeKey(store string) string {
+	return CanonicalStore(store)
 }
 
 // ExtractionResult represents the complete extraction result
 type ExtractionResult struct {
+	// SchemaVersion is the version of the result's structure (SchemaV*); results without
+	// it are v1
+	SchemaVersion int `json:"schema_version,omitempty"`
+
+	// RunID identifies the run that produced the result; log lines of the run carry it
+	// as the run_id field
+	RunID string `json:"run_id,omitempty"`
+
 	Stores []StoreResult `json:"stores"`
+
+	// Portfolios aggregates the stores of each portfolio that was requested by name
+	Portfolios []PortfolioResult `json:"portfolios,omitempty"`
+
+	// Charts holds each distinct size chart once, keyed by fingerprint, when products
+	// reference their charts through ChartIDs
+	Charts map[string]*SizeChart `json:"charts,omitempty"`
 }
 
 // Config holds the configuration for the extractor
@@ -35,6 +318,190 @@ type Config struct {
 	MaxConcurrentRequests int
 	UseHeadlessBrowser    bool
 	UserAgent             string
+
+	// Product sampling for cheap QA runs. SampleCount takes precedence over SampleRate;
+	// leaving both at zero extracts every discovered product. A non-zero SampleSeed
+	// makes the sample reproducible.
+	SampleRate  float64
+	SampleCount int
+	SampleSeed  int64
+
+	// CrawlOrder sets the order discovered products are extracted in: "discovery"
+	// (default), "alphabetical" by product handle, or "random". Random order is seeded
+	// with CrawlSeed, or differently on every run when CrawlSeed is zero, so capped or
+	// sampled runs cover different regions of the catalog over time.
+	CrawlOrder string
+	CrawlSeed  int64
+
+	// DiscoveryMode is how Shopify stores discover their products: "html" (default)
+	// crawls the storefront's listing and collection pages, "json" pages through the
+	// public /products.json endpoint. StoreOptions.Discovery overrides it per store.
+	DiscoveryMode string
+
+	// EstimateCoverage counts the store's catalog through /products.json after a run
+	// to report what fraction of it was extracted. Off by default: the count costs one
+	// request per 250 catalog products, paced like every other fetch.
+	EstimateCoverage bool
+
+	// IncludeRaw keeps the original table of every chart filtered to the canonical
+	// columns as SizeChart.Raw
+	IncludeRaw bool
+
+	// Trace attaches the diagnostics logged while extracting each product, at every
+	// level, to the product or its missing-chart entry (see TraceEvent)
+	Trace bool
+
+	// SchemaVersion is the structure results are written in (SchemaV*); 0 writes the
+	// current one
+	SchemaVersion int
+
+	// FetchFallback retries failed browser navigations over plain HTTP, and refetches
+	// static product pages lacking a size chart container with the browser
+	FetchFallback bool
+
+	// ProductTimeout bounds the extraction of one product, fetch, parse and browser
+	// fallback included, so a pathological page cannot stall the run (0 = no limit)
+	ProductTimeout time.Duration
+
+	// DiscoveryTimeout bounds product discovery of a store, separately from extraction:
+	// past it, the products discovered so far are extracted and the store result is
+	// marked DiscoveryTruncated (0 = no limit)
+	DiscoveryTimeout time.Duration
+
+	// Failure budget: a store is aborted as unreachable or blocked when more than
+	// FailureBudgetPercent of its first FailureBudgetAttempts product fetches fail
+	// (0 attempts = never abort)
+	FailureBudgetAttempts int
+	FailureBudgetPercent  float64
+
+	// URL frontier limits: discovered URLs beyond FrontierMemoryLimit (0 = 10000) are kept
+	// in a temporary database under FrontierDir (empty = system temp directory)
+	FrontierMemoryLimit int
+	FrontierDir         string
+
+	// ArchiveDir, when set, stores the raw HTML of every fetched product page there
+	// (see package archive), tagged with RunID, so the run can be re-parsed later
+	ArchiveDir string
+	RunID      string
+
+	// WARCDir, when set, records every fetched product page in a WARC/1.1 file there,
+	// one file per store and extraction
+	WARCDir string
+
+	// QueueRetryDelay, when positive, waits this long and retries a fetch that landed on
+	// a queue page instead of reporting the product as queued
+	QueueRetryDelay time.Duration
+
+	// ChartImageDir, when set, stores a PNG snapshot of every extracted size chart there
+	// for visual QA against the product page
+	ChartImageDir string
+
+	// ParserCacheFile, when set, remembers across runs which parser or selector found
+	// each store's size charts, so the next run tries it first
+	ParserCacheFile string
+
+	// RunDir, when set, stores every CLI run's result with its failed product URLs as
+	// <run ID>.json there, so retry-run can attempt only the failures later
+	RunDir string
+
+	// PageSource, when set, serves page HTML in place of fetching it, e.g. to re-parse
+	// archived pages
+	PageSource PageSource
+
+	// Browser holds the Chrome launch options used by the headless browser client
+	Browser BrowserOptions
+
+	// Stores holds per-store request options keyed by store domain (without "www.")
+	Stores map[string]StoreOptions
+
+	// Portfolios names groups of stores that can be extracted together by name, e.g.
+	// "womenswear" = westside.com, suqah.com
+	Portfolios map[string][]string
+
+	// PolitenessPools names groups of hosts on shared infrastructure (same Shopify Plus
+	// organisation or CDN) that share one rate limit across every store crawled
+	PolitenessPools map[string]PolitenessPool
+}
+
+// PolitenessPool is a group of hosts that together may receive at most one request per
+// Delay, on top of each host's own RequestDelay
+type PolitenessPool struct {
+	Hosts []string // hosts without "www.", subdomains included
+	Delay time.Duration
+}
+
+// StoreOptions are request options applied to every fetch from one store
+type StoreOptions struct {
+	// Headers are sent with every HTTP request and browser navigation to the store,
+	// overriding the defaults (e.g. Referer, Accept-Language, Cookie, X- headers)
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Password unlocks a password-protected (development or pre-launch) storefront; the
+	// session cookie it yields is reused for every request
+	Password string `json:"password,omitempty"`
+
+	// BasicAuth and BearerToken authenticate requests to staging environments behind
+	// HTTP basic auth or a token-checking proxy
+	BasicAuth   *BasicAuth `json:"basic_auth,omitempty"`
+	BearerToken string     `json:"bearer_token,omitempty"`
+
+	// CollectionPriority weights collections by handle glob (e.g. {"new-arrivals": 10,
+	// "sale*": -5}); higher weighted collections are crawled first, so a run cut short by
+	// a budget has extracted the most relevant products. Unmatched collections weigh 0.
+	CollectionPriority map[string]int `json:"collection_priority,omitempty"`
+
+	// Concurrency is the number of extraction workers of the store, in place of
+	// MaxConcurrentRequests, e.g. fewer for a browser-bound store (0 = MaxConcurrentRequests)
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// Discovery is how the store's products are discovered, in place of DiscoveryMode:
+	// "html" or "json" (empty = DiscoveryMode)
+	Discovery string `json:"discovery,omitempty"`
+
+	// Aliases are other domains serving the store, such as its *.myshopify.com domain;
+	// requests and results naming an alias are treated as the store
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// BasicAuth holds HTTP basic authentication credentials
+type BasicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// BrowserOptions configures how Chrome is launched for headless browsing
+type BrowserOptions struct {
+	Headful      bool     // Show the browser window instead of running headless
+	NewHeadless  bool     // Use Chrome's new headless mode (--headless=new)
+	NoSandbox    bool     // Disable the Chrome sandbox, usually required inside containers
+	WindowWidth  int      // Browser window width in pixels (0 = Chrome default)
+	WindowHeight int      // Browser window height in pixels (0 = Chrome default)
+	UserDataDir  string   // Profile directory (empty = temporary profile)
+	ProxyServer  string   // Proxy for all browser traffic, e.g. http://host:3128
+	Language     string   // Browser language passed as --lang, e.g. en-US
+	ExtraFlags   []string // Additional command line switches, e.g. --disable-gpu or --foo=bar
+	ExecPath     string   // Chrome binary to launch (empty = search PATH and common locations)
+	Backend      string   // Browser automation library: "chromedp" (default), "rod" or "render"
+
+	// Stealth hides common headless browser tells (navigator.webdriver, HeadlessChrome user
+	// agent, WebGL vendor) for themes that serve degraded pages to headless browsers
+	Stealth       bool
+	Timezone      string // IANA timezone reported to pages in stealth mode, e.g. Asia/Kolkata
+	WebGLVendor   string // Reported WebGL vendor (empty = Intel Inc.)
+	WebGLRenderer string // Reported WebGL renderer (empty = Intel Iris OpenGL Engine)
+
+	// External rendering service used by the "render" backend
+	RenderServiceURL   string // Base URL, e.g. https://chrome.browserless.io or http://splash:8050
+	RenderServiceType  string // "browserless", "splash" or "generic"
+	RenderServiceToken string // API token (browserless token query parameter, bearer token otherwise)
+}
+
+// DefaultBrowserOptions returns the default Chrome launch options
+func DefaultBrowserOptions() BrowserOptions {
+	return BrowserOptions{
+		WindowWidth:  1920,
+		WindowHeight: 1080,
+	}
 }
 
 // DefaultConfig returns the default configuration
@@ -46,9 +513,19 @@ func DefaultConfig() *Config {
 		MaxConcurrentRequests: 5,
 		UseHeadlessBrowser:    true,
 		UserAgent:             "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		FetchFallback:         true,
+		ProductTimeout:        45 * time.Second,
+		FailureBudgetAttempts: 20,
+		FailureBudgetPercent:  80,
+		Browser:               DefaultBrowserOptions(),
 	}
 }
 
+// Sampling reports whether the configuration extracts only a sample of each store
+func (c *Config) Sampling() bool {
+	return c.SampleRate > 0 || c.SampleCount > 0
+}
+
 // StoreAdapter defines the interface for store-specific extraction logic
 type StoreAdapter interface {
 	// GetStoreName returns the name of the store
@@ -68,6 +545,15 @@ type StoreAdapter interface {
 type Context struct {
 	Config *Config
 	Logger Logger
+
+	// Ctx carries the caller's deadline and cancellation to the fetches of the call
+	// (nil = context.Background())
+	Ctx context.Context
+}
+
+// PageSource supplies the HTML of pages without fetching them
+type PageSource interface {
+	PageContent(ctx context.Context, pageURL string) (string, error)
 }
 
 // Logger defines the logging interface
@@ -0,0 +1,33 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountChartsByParser(t *testing.T) {
+	products := []Product{
+		{SizeCharts: []*SizeChart{{Parser: "kiwi"}, {Parser: "kiwi"}}},
+		{SizeCharts: []*SizeChart{{Parser: "generic-table"}, {}}},
+		{},
+	}
+
+	counts := CountChartsByParser(products)
+	assert.Equal(t, map[string]int{"kiwi": 2, "generic-table": 1, ParserUnknown: 1}, counts)
+	assert.Equal(t, "kiwi: 2, generic-table: 1, unknown: 1", FormatChartsByParser(counts))
+	assert.Nil(t, CountChartsByParser([]Product{{}}))
+}
+
+func TestNewPortfolioResult_WrittenProducts(t *testing.T) {
+	results := []StoreResult{
+		{StoreName: "westside.com", Products: []Product{{ProductURL: "https://westside.com/products/a"}}},
+		// A streaming run wrote its products out, leaving Products empty
+		{StoreName: "www.suqah.com", Products: []Product{}, WrittenProducts: 3},
+		{StoreName: "littleboxindia.com", WrittenProducts: 5},
+	}
+
+	portfolio := NewPortfolioResult("womenswear", []string{"westside.com", "suqah.com"}, results)
+	assert.Equal(t, 4, portfolio.ProductsWithCharts)
+	assert.Empty(t, portfolio.FailedStores)
+}
@@ -0,0 +1,158 @@
+// Package linkcheck re-checks the product URLs of an earlier extraction and reports the
+// products a store has removed since (404 or 410), without re-extracting anything.
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+)
+
+// Product states reported by a check
+const (
+	StateLive    = "live"
+	StateRemoved = "removed"
+	StateFailed  = "failed"
+)
+
+// StatusChecker returns the HTTP status a URL answers with. *utils.HTTPClient implements
+// it with rate limiting and retries.
+type StatusChecker interface {
+	Status(ctx context.Context, url string) (int, error)
+}
+
+// Result is the outcome of checking one product URL
+type Result struct {
+	Store      string `json:"store"`
+	URL        string `json:"url"`
+	Title      string `json:"title,omitempty"`
+	State      string `json:"state"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// StoreSummary counts the product states of one store
+type StoreSummary struct {
+	StoreName string `json:"store_name"`
+	Checked   int    `json:"checked"`
+	Live      int    `json:"live"`
+	Removed   int    `json:"removed"`
+	Failed    int    `json:"failed"`
+}
+
+// Report is the outcome of a check run. Only removed and failed products are listed.
+type Report struct {
+	CheckedAt time.Time      `json:"checked_at"`
+	Stores    []StoreSummary `json:"stores"`
+	Removed   []Result       `json:"removed"`
+	Failed    []Result       `json:"failed,omitempty"`
+}
+
+// Check checks every product URL of result with up to workers concurrent requests and
+// reports which products are gone. Rate limiting is left to the checker.
+func Check(ctx context.Context, checker StatusChecker, result *types.ExtractionResult, workers int) *Report {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var products []Result
+	for _, store := range result.Stores {
+		for _, product := range store.Products {
+			if product.ProductURL == "" {
+				continue
+			}
+			products = append(products, Result{Store: store.StoreName, URL: product.ProductURL, Title: product.ProductTitle})
+		}
+	}
+
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				check(ctx, checker, &products[i])
+			}
+		}()
+	}
+	for i := range products {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return summarize(products)
+}
+
+// check fills in the state of one product
+func check(ctx context.Context, checker StatusChecker, product *Result) {
+	status, err := checker.Status(ctx, product.URL)
+	var statusErr *utils.StatusError
+	if err != nil && errors.As(err, &statusErr) {
+		status = statusErr.StatusCode
+	}
+	product.StatusCode = status
+
+	switch {
+	case status == http.StatusNotFound || status == http.StatusGone:
+		product.State = StateRemoved
+	case err != nil:
+		product.State = StateFailed
+		product.Error = err.Error()
+	case status >= 200 && status < 400:
+		product.State = StateLive
+	default:
+		product.State = StateFailed
+		product.Error = http.StatusText(status)
+	}
+}
+
+// summarize builds the report of checked products, in store and URL order
+func summarize(products []Result) *Report {
+	report := &Report{CheckedAt: time.Now().UTC(), Removed: []Result{}}
+	summaries := make(map[string]*StoreSummary)
+	for _, product := range products {
+		summary, ok := summaries[product.Store]
+		if !ok {
+			summary = &StoreSummary{StoreName: product.Store}
+			summaries[product.Store] = summary
+		}
+		summary.Checked++
+		switch product.State {
+		case StateLive:
+			summary.Live++
+		case StateRemoved:
+			summary.Removed++
+			report.Removed = append(report.Removed, product)
+		default:
+			summary.Failed++
+			report.Failed = append(report.Failed, product)
+		}
+	}
+
+	report.Stores = make([]StoreSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		report.Stores = append(report.Stores, *summary)
+	}
+	sort.Slice(report.Stores, func(i, j int) bool { return report.Stores[i].StoreName < report.Stores[j].StoreName })
+	sortResults(report.Removed)
+	sortResults(report.Failed)
+	return report
+}
+
+// sortResults orders results by store, then URL
+func sortResults(results []Result) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Store != results[j].Store {
+			return results[i].Store < results[j].Store
+		}
+		return results[i].URL < results[j].URL
+	})
+}
@@ -0,0 +1,65 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+)
+
+// fakeChecker answers with a fixed status per URL
+type fakeChecker map[string]int
+
+func (f fakeChecker) Status(ctx context.Context, url string) (int, error) {
+	status, ok := f[url]
+	if !ok {
+		return 0, errors.New("connection refused")
+	}
+	if status >= 500 {
+		return 0, fmt.Errorf("all retry attempts failed: %w", &utils.StatusError{StatusCode: status})
+	}
+	return status, nil
+}
+
+func TestCheck(t *testing.T) {
+	result := &types.ExtractionResult{Stores: []types.StoreResult{
+		{StoreName: "westside.com", Products: []types.Product{
+			{ProductTitle: "Dress", ProductURL: "https://www.westside.com/products/dress"},
+			{ProductTitle: "Kurta", ProductURL: "https://www.westside.com/products/kurta"},
+			{ProductTitle: "Top", ProductURL: "https://www.westside.com/products/top"},
+		}},
+		{StoreName: "suqah.com", Products: []types.Product{
+			{ProductTitle: "Shirt", ProductURL: "https://suqah.com/products/shirt"},
+			{ProductTitle: "Skirt", ProductURL: "https://suqah.com/products/skirt"},
+		}},
+	}}
+	checker := fakeChecker{
+		"https://www.westside.com/products/dress": http.StatusOK,
+		"https://www.westside.com/products/kurta": http.StatusNotFound,
+		"https://www.westside.com/products/top":   http.StatusServiceUnavailable,
+		"https://suqah.com/products/shirt":        http.StatusGone,
+	}
+
+	report := Check(context.Background(), checker, result, 3)
+
+	assert.Equal(t, []StoreSummary{
+		{StoreName: "suqah.com", Checked: 2, Removed: 1, Failed: 1},
+		{StoreName: "westside.com", Checked: 3, Live: 1, Removed: 1, Failed: 1},
+	}, report.Stores)
+
+	require.Len(t, report.Removed, 2)
+	assert.Equal(t, Result{Store: "suqah.com", URL: "https://suqah.com/products/shirt", Title: "Shirt", State: StateRemoved, StatusCode: http.StatusGone}, report.Removed[0])
+	assert.Equal(t, "https://www.westside.com/products/kurta", report.Removed[1].URL)
+	assert.Equal(t, http.StatusNotFound, report.Removed[1].StatusCode)
+
+	require.Len(t, report.Failed, 2)
+	for _, product := range report.Failed {
+		assert.NotEmpty(t, product.Error)
+	}
+}
@@ -0,0 +1,123 @@
+package output
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"shopify-extractor/internal/types"
+)
+
+// DBSink inserts products into a SQL table through database/sql. The caller owns
+// the *sql.DB (and therefore the driver); the table is expected to have the columns
+// store_name, product_title, product_url and size_charts (JSON text).
+type DBSink struct {
+	db    *sql.DB
+	table string
+
+	// Placeholder renders the n-th (1-based) bind parameter. Defaults to "?";
+	// set it to func(n int) string { return fmt.Sprintf("$%d", n) } for PostgreSQL.
+	Placeholder func(n int) string
+
+	// ChartsTable, when set, stores each distinct size chart once in a table with the
+	// columns fingerprint and size_chart (JSON text); the products' size_charts column
+	// then holds the JSON array of their chart fingerprints
+	ChartsTable string
+}
+
+// NewDBSink creates a sink inserting into the given table
+func NewDBSink(db *sql.DB, table string) *DBSink {
+	return &DBSink{
+		db:          db,
+		table:       table,
+		Placeholder: func(n int) string { return "?" },
+	}
+}
+
+func (d *DBSink) insertQuery() string {
+	return fmt.Sprintf("INSERT INTO %s (store_name, product_title, product_url, size_charts) VALUES (%s, %s, %s, %s)",
+		d.table, d.Placeholder(1), d.Placeholder(2), d.Placeholder(3), d.Placeholder(4))
+}
+
+func (d *DBSink) insertChartQuery() string {
+	return fmt.Sprintf("INSERT INTO %s (fingerprint, size_chart) SELECT %s, %s WHERE NOT EXISTS (SELECT 1 FROM %s WHERE fingerprint = %s)",
+		d.ChartsTable, d.Placeholder(1), d.Placeholder(2), d.ChartsTable, d.Placeholder(3))
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (d *DBSink) insert(ctx context.Context, e execer, storeName string, product types.Product) error {
+	var sizeCharts interface{} = product.SizeCharts
+	if d.ChartsTable != "" {
+		fingerprints, err := d.insertCharts(ctx, e, product.SizeCharts)
+		if err != nil {
+			return err
+		}
+		sizeCharts = fingerprints
+	}
+
+	charts, err := json.Marshal(sizeCharts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal size charts: %w", err)
+	}
+
+	if _, err := e.ExecContext(ctx, d.insertQuery(), storeName, product.ProductTitle, product.ProductURL, string(charts)); err != nil {
+		return fmt.Errorf("failed to insert product %s: %w", product.ProductURL, err)
+	}
+	return nil
+}
+
+// insertCharts stores the charts not yet in the charts table and returns their fingerprints
+func (d *DBSink) insertCharts(ctx context.Context, e execer, charts []*types.SizeChart) ([]string, error) {
+	fingerprints := make([]string, 0, len(charts))
+	for _, chart := range charts {
+		fingerprint := chart.Fingerprint
+		if fingerprint == "" {
+			fingerprint = chart.ComputeFingerprint()
+		}
+		data, err := json.Marshal(chart)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal size chart: %w", err)
+		}
+		if _, err := e.ExecContext(ctx, d.insertChartQuery(), fingerprint, string(data), fingerprint); err != nil {
+			return nil, fmt.Errorf("failed to insert size chart %s: %w", fingerprint, err)
+		}
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	return fingerprints, nil
+}
+
+// Write inserts every product of the result in a single transaction
+func (d *DBSink) Write(ctx context.Context, result *types.ExtractionResult) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	for _, store := range result.Stores {
+		for _, product := range store.Products {
+			if err := d.insert(ctx, tx, store.StoreName, product); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// WriteProduct inserts a single product
+func (d *DBSink) WriteProduct(ctx context.Context, storeName string, product types.Product) error {
+	return d.insert(ctx, d.db, storeName, product)
+}
+
+// Close is a no-op; the caller owns the database handle
+func (d *DBSink) Close() error {
+	return nil
+}
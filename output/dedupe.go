@@ -0,0 +1,172 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"shopify-extractor/internal/types"
+)
+
+// chartRecord is the NDJSON line written for a chart the first time a streamed product
+// references it in deduplicated output
+type chartRecord struct {
+	ChartID string           `json:"chart_id"`
+	Chart   *types.SizeChart `json:"chart"`
+}
+
+// ChartWriter is implemented by sinks that can stream a shared size chart ahead of the
+// products referencing it
+type ChartWriter interface {
+	WriteChart(ctx context.Context, chartID string, chart *types.SizeChart) error
+}
+
+// chartID returns the chart's fingerprint, computing it when the chart has none
+func chartID(chart *types.SizeChart) string {
+	if chart.Fingerprint != "" {
+		return chart.Fingerprint
+	}
+	return chart.ComputeFingerprint()
+}
+
+// dedupeProduct returns a copy of the product referencing its charts by ID, adding
+// charts not seen before to charts
+func dedupeProduct(product types.Product, charts map[string]*types.SizeChart, added func(id string, chart *types.SizeChart)) types.Product {
+	if len(product.SizeCharts) == 0 {
+		return product
+	}
+	ids := make([]string, 0, len(product.SizeCharts))
+	for _, chart := range product.SizeCharts {
+		if chart == nil {
+			continue
+		}
+		id := chartID(chart)
+		if _, ok := charts[id]; !ok {
+			charts[id] = chart
+			if added != nil {
+				added(id, chart)
+			}
+		}
+		ids = append(ids, id)
+	}
+	product.SizeCharts = nil
+	product.ChartIDs = ids
+	return product
+}
+
+// DedupeCharts returns a copy of the result in which every distinct size chart is listed
+// once in Charts and products reference their charts through ChartIDs
+func DedupeCharts(result *types.ExtractionResult) *types.ExtractionResult {
+	deduped := &types.ExtractionResult{
+		SchemaVersion: result.SchemaVersion,
+		Stores:        make([]types.StoreResult, 0, len(result.Stores)),
+		Charts:        make(map[string]*types.SizeChart),
+	}
+	for id, chart := range result.Charts {
+		deduped.Charts[id] = chart
+	}
+	for _, store := range result.Stores {
+		products := make([]types.Product, 0, len(store.Products))
+		for _, product := range store.Products {
+			products = append(products, dedupeProduct(product, deduped.Charts, nil))
+		}
+		store.Products = products
+		deduped.Stores = append(deduped.Stores, store)
+	}
+	return deduped
+}
+
+// ExpandCharts resolves the ChartIDs of every product back into SizeCharts, in place,
+// and drops the shared chart table
+func ExpandCharts(result *types.ExtractionResult) error {
+	for s := range result.Stores {
+		products := result.Stores[s].Products
+		for p := range products {
+			for _, id := range products[p].ChartIDs {
+				chart, ok := result.Charts[id]
+				if !ok {
+					return fmt.Errorf("product %s references unknown chart %s", products[p].ProductURL, id)
+				}
+				products[p].SizeCharts = append(products[p].SizeCharts, chart)
+			}
+			products[p].ChartIDs = nil
+		}
+	}
+	result.Charts = nil
+	return nil
+}
+
+// DedupeSink wraps a sink so that identical size charts are written once: complete
+// results go through DedupeCharts, and streamed products are preceded by a chart line
+// the first time each chart appears. Streamed products keep their charts inline when
+// the wrapped sink does not implement ChartWriter.
+type DedupeSink struct {
+	sink Sink
+
+	mu     sync.Mutex
+	charts map[string]*types.SizeChart
+}
+
+// NewDedupeSink wraps sink with chart deduplication
+func NewDedupeSink(sink Sink) *DedupeSink {
+	return &DedupeSink{sink: sink, charts: make(map[string]*types.SizeChart)}
+}
+
+// Write writes the deduplicated result
+func (d *DedupeSink) Write(ctx context.Context, result *types.ExtractionResult) error {
+	return d.sink.Write(ctx, DedupeCharts(result))
+}
+
+// WriteProduct writes the product's unseen charts, then the product referencing them
+func (d *DedupeSink) WriteProduct(ctx context.Context, storeName string, product types.Product) error {
+	chartWriter, ok := d.sink.(ChartWriter)
+	if !ok {
+		return d.sink.WriteProduct(ctx, storeName, product)
+	}
+
+	// Charts and products are written under one lock so a chart line always precedes
+	// the first product referencing it
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var writeErr error
+	deduped := dedupeProduct(product, d.charts, func(id string, chart *types.SizeChart) {
+		if writeErr == nil {
+			writeErr = chartWriter.WriteChart(ctx, id, chart)
+		}
+	})
+	if writeErr != nil {
+		return writeErr
+	}
+	return d.sink.WriteProduct(ctx, storeName, deduped)
+}
+
+// Close closes the wrapped sink
+func (d *DedupeSink) Close() error {
+	return d.sink.Close()
+}
+
+// WriteChart encodes a shared size chart as one NDJSON line
+func (s *WriterSink) WriteChart(ctx context.Context, chartID string, chart *types.SizeChart) error {
+	line, err := json.Marshal(chartRecord{ChartID: chartID, Chart: chart})
+	if err != nil {
+		return fmt.Errorf("failed to marshal size chart: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write size chart: %w", err)
+	}
+	return nil
+}
+
+// WriteChart appends a shared size chart to the file as an NDJSON line
+func (f *FileSink) WriteChart(ctx context.Context, chartID string, chart *types.SizeChart) error {
+	w, err := f.writer()
+	if err != nil {
+		return err
+	}
+	return w.WriteChart(ctx, chartID, chart)
+}
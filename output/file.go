@@ -0,0 +1,170 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"shopify-extractor/internal/types"
+)
+
+// productRecord is the NDJSON line written for each streamed product
+type productRecord struct {
+	StoreName string        `json:"store_name"`
+	Product   types.Product `json:"product"`
+}
+
+// WriterSink writes results as JSON to an io.Writer. Complete results are written
+// as an indented JSON document; streamed products are written as NDJSON lines.
+type WriterSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewWriterSink creates a sink writing to w
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// NewStdoutSink creates a sink writing to standard output
+func NewStdoutSink() *WriterSink {
+	return NewWriterSink(os.Stdout)
+}
+
+// Write encodes the complete result as indented JSON
+func (s *WriterSink) Write(ctx context.Context, result *types.ExtractionResult) error {
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(jsonData, '\n')); err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	return nil
+}
+
+// WriteProduct encodes a single product as one NDJSON line
+func (s *WriterSink) WriteProduct(ctx context.Context, storeName string, product types.Product) error {
+	line, err := json.Marshal(productRecord{StoreName: storeName, Product: product})
+	if err != nil {
+		return fmt.Errorf("failed to marshal product: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write product: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying writer when the sink owns it
+func (s *WriterSink) Close() error {
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
+
+// AtomicFile is a file written under a temporary name in the directory of its path and
+// renamed into place on Close, so a failed or aborted run keeps the previous file
+type AtomicFile struct {
+	*os.File
+	path string
+}
+
+// CreateAtomic starts writing the file at path; it only replaces path on Close
+func CreateAtomic(path string) (*AtomicFile, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	// os.CreateTemp creates private files; results are as readable as with os.Create
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return &AtomicFile{File: tmp, path: path}, nil
+}
+
+// Close closes the temporary file and renames it to the file's path
+func (f *AtomicFile) Close() error {
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.File.Name())
+		return err
+	}
+	if err := os.Rename(f.File.Name(), f.path); err != nil {
+		os.Remove(f.File.Name())
+		return err
+	}
+	return nil
+}
+
+// FileSink writes results to a file, creating it lazily on first write. The file only
+// replaces an existing one at the same path once the sink is closed (see AtomicFile).
+type FileSink struct {
+	path string
+	mu   sync.Mutex
+	sink *WriterSink
+}
+
+// NewFileSink creates a sink writing to the file at path
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// Path returns the file the sink writes to
+func (f *FileSink) Path() string {
+	return f.path
+}
+
+func (f *FileSink) writer() (*WriterSink, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.sink == nil {
+		file, err := CreateAtomic(f.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create output file: %w", err)
+		}
+		f.sink = &WriterSink{w: file, closer: file}
+	}
+	return f.sink, nil
+}
+
+// Write writes the complete result to the file
+func (f *FileSink) Write(ctx context.Context, result *types.ExtractionResult) error {
+	w, err := f.writer()
+	if err != nil {
+		return err
+	}
+	return w.Write(ctx, result)
+}
+
+// WriteProduct appends a single product to the file as an NDJSON line
+func (f *FileSink) WriteProduct(ctx context.Context, storeName string, product types.Product) error {
+	w, err := f.writer()
+	if err != nil {
+		return err
+	}
+	return w.WriteProduct(ctx, storeName, product)
+}
+
+// Close moves the file into place if it was opened
+func (f *FileSink) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.sink == nil {
+		return nil
+	}
+	return f.sink.Close()
+}
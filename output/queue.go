@@ -0,0 +1,183 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"shopify-extractor/internal/types"
+)
+
+// DefaultQueueSize is the number of streamed products a QueuedSink holds by default
+const DefaultQueueSize = 256
+
+// queuedProduct is a product waiting for the writer
+type queuedProduct struct {
+	store    string
+	product  types.Product
+	queuedAt time.Time
+}
+
+// QueueStats is a point-in-time copy of a QueuedSink's queue metrics
+type QueueStats struct {
+	Depth    int `json:"depth"`     // products waiting for the writer
+	MaxDepth int `json:"max_depth"` // highest depth seen
+	Capacity int `json:"capacity"`
+	Written  int `json:"written"`
+
+	// Blocked counts the WriteProduct calls that waited for room in a full queue, and
+	// BlockedFor their total wait
+	Blocked    int           `json:"blocked"`
+	BlockedFor time.Duration `json:"blocked_ns"`
+
+	// Lag is the time the last written product spent queued, and MaxLag the longest
+	Lag    time.Duration `json:"lag_ns"`
+	MaxLag time.Duration `json:"max_lag_ns"`
+}
+
+// QueuedSink decouples extraction workers from a slow sink (S3, a database, ...):
+// WriteProduct hands products to a bounded queue drained by one writer goroutine, and
+// blocks once the queue is full so a sink that falls behind slows extraction down
+// instead of letting memory grow. A failed write is returned by the next WriteProduct,
+// Write or Close call.
+type QueuedSink struct {
+	sink  Sink
+	queue chan queuedProduct
+	done  chan struct{}
+
+	pending sync.WaitGroup // queued products not written yet
+	closeMu sync.Once
+
+	mu    sync.Mutex
+	err   error
+	stats QueueStats
+}
+
+// NewQueuedSink wraps sink with a queue of size products (DefaultQueueSize when size
+// is not positive) and starts its writer
+func NewQueuedSink(sink Sink, size int) *QueuedSink {
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	q := &QueuedSink{
+		sink:  sink,
+		queue: make(chan queuedProduct, size),
+		done:  make(chan struct{}),
+		stats: QueueStats{Capacity: size},
+	}
+	go q.run()
+	return q
+}
+
+// run writes queued products until the queue is closed. Products are written with a
+// background context: they are already extracted, and the run's context may end
+// before the queue drained.
+func (q *QueuedSink) run() {
+	defer close(q.done)
+	for item := range q.queue {
+		var err error
+		if q.failed() == nil {
+			err = q.sink.WriteProduct(context.Background(), item.store, item.product)
+		}
+		lag := time.Since(item.queuedAt)
+
+		q.mu.Lock()
+		if err != nil && q.err == nil {
+			q.err = fmt.Errorf("failed to write queued product %s: %w", item.product.ProductURL, err)
+		}
+		if err == nil && q.err == nil {
+			q.stats.Written++
+			q.stats.Lag = lag
+			if lag > q.stats.MaxLag {
+				q.stats.MaxLag = lag
+			}
+		}
+		q.mu.Unlock()
+		q.pending.Done()
+	}
+}
+
+// failed returns the first write error
+func (q *QueuedSink) failed() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.err
+}
+
+// WriteProduct queues the product, waiting while the queue is full
+func (q *QueuedSink) WriteProduct(ctx context.Context, storeName string, product types.Product) error {
+	if err := q.failed(); err != nil {
+		return err
+	}
+	item := queuedProduct{store: storeName, product: product, queuedAt: time.Now()}
+
+	q.pending.Add(1)
+	select {
+	case q.queue <- item:
+		q.enqueued()
+		return nil
+	default:
+	}
+
+	// The queue is full: wait for the writer to catch up
+	start := time.Now()
+	select {
+	case q.queue <- item:
+	case <-ctx.Done():
+		q.pending.Done()
+		return ctx.Err()
+	}
+	q.mu.Lock()
+	q.stats.Blocked++
+	q.stats.BlockedFor += time.Since(start)
+	q.mu.Unlock()
+	q.enqueued()
+	return nil
+}
+
+// enqueued records the queue depth after a product entered the queue
+func (q *QueuedSink) enqueued() {
+	depth := len(q.queue)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if depth > q.stats.MaxDepth {
+		q.stats.MaxDepth = depth
+	}
+}
+
+// Write waits for the queued products to be written, then writes the complete result
+func (q *QueuedSink) Write(ctx context.Context, result *types.ExtractionResult) error {
+	q.pending.Wait()
+	if err := q.failed(); err != nil {
+		return err
+	}
+	return q.sink.Write(ctx, result)
+}
+
+// Close writes the queued products and closes the wrapped sink, returning the first
+// write error. No product may be written after Close.
+func (q *QueuedSink) Close() error {
+	q.closeMu.Do(func() { close(q.queue) })
+	<-q.done
+	err := q.failed()
+	if closeErr := q.sink.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Stats returns the queue metrics
+func (q *QueuedSink) Stats() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stats := q.stats
+	stats.Depth = len(q.queue)
+	return stats
+}
+
+// String summarises the metrics for logs
+func (s QueueStats) String() string {
+	return fmt.Sprintf("%d products written, max queue depth %d/%d, writers blocked %d times for %v, max writer lag %v",
+		s.Written, s.MaxDepth, s.Capacity, s.Blocked, s.BlockedFor.Round(time.Millisecond), s.MaxLag.Round(time.Millisecond))
+}
@@ -0,0 +1,135 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+// slowSink records products, each write waiting for release
+type slowSink struct {
+	release chan struct{}
+	err     error
+
+	mu       sync.Mutex
+	products []string
+	results  int
+	closed   bool
+}
+
+func (s *slowSink) Write(ctx context.Context, result *types.ExtractionResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results++
+	return nil
+}
+
+func (s *slowSink) WriteProduct(ctx context.Context, storeName string, product types.Product) error {
+	if s.release != nil {
+		<-s.release
+	}
+	if s.err != nil {
+		return s.err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.products = append(s.products, product.ProductURL)
+	return nil
+}
+
+func (s *slowSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func TestQueuedSink_WritesInOrder(t *testing.T) {
+	inner := &slowSink{}
+	q := NewQueuedSink(inner, 2)
+
+	ctx := context.Background()
+	for _, url := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, q.WriteProduct(ctx, "westside.com", types.Product{ProductURL: url}))
+	}
+	require.NoError(t, q.Write(ctx, &types.ExtractionResult{}))
+	require.NoError(t, q.Close())
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, inner.products)
+	assert.Equal(t, 1, inner.results)
+	assert.True(t, inner.closed)
+	stats := q.Stats()
+	assert.Equal(t, 4, stats.Written)
+	assert.Equal(t, 2, stats.Capacity)
+	assert.Equal(t, 0, stats.Depth)
+}
+
+func TestQueuedSink_Backpressure(t *testing.T) {
+	inner := &slowSink{release: make(chan struct{})}
+	q := NewQueuedSink(inner, 1)
+	ctx := context.Background()
+
+	// The writer holds the first product and the queue the second
+	require.NoError(t, q.WriteProduct(ctx, "westside.com", types.Product{ProductURL: "a"}))
+	require.Eventually(t, func() bool { return q.Stats().Depth == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, q.WriteProduct(ctx, "westside.com", types.Product{ProductURL: "b"}))
+	assert.Equal(t, 1, q.Stats().Depth)
+
+	// A third product waits for room
+	written := make(chan error, 1)
+	go func() { written <- q.WriteProduct(ctx, "westside.com", types.Product{ProductURL: "c"}) }()
+	select {
+	case <-written:
+		t.Fatal("WriteProduct returned while the queue was full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(inner.release)
+	require.NoError(t, <-written)
+	require.NoError(t, q.Close())
+
+	stats := q.Stats()
+	assert.Equal(t, 3, stats.Written)
+	assert.Equal(t, 1, stats.MaxDepth)
+	assert.Equal(t, 1, stats.Blocked)
+	assert.Greater(t, stats.BlockedFor, time.Duration(0))
+	assert.Greater(t, stats.MaxLag, time.Duration(0))
+}
+
+func TestQueuedSink_CanceledWhileFull(t *testing.T) {
+	inner := &slowSink{release: make(chan struct{})}
+	q := NewQueuedSink(inner, 1)
+
+	require.NoError(t, q.WriteProduct(context.Background(), "westside.com", types.Product{ProductURL: "a"}))
+	require.Eventually(t, func() bool { return q.Stats().Depth == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, q.WriteProduct(context.Background(), "westside.com", types.Product{ProductURL: "b"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := q.WriteProduct(ctx, "westside.com", types.Product{ProductURL: "c"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(inner.release)
+	require.NoError(t, q.Close())
+	assert.Equal(t, []string{"a", "b"}, inner.products)
+}
+
+func TestQueuedSink_WriteError(t *testing.T) {
+	inner := &slowSink{err: errors.New("bucket unavailable")}
+	q := NewQueuedSink(inner, 4)
+
+	require.NoError(t, q.WriteProduct(context.Background(), "westside.com", types.Product{ProductURL: "a"}))
+	require.Eventually(t, func() bool { return q.Stats().Depth == 0 }, time.Second, time.Millisecond)
+
+	err := q.Close()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bucket unavailable")
+	assert.True(t, inner.closed)
+	assert.Equal(t, 0, q.Stats().Written)
+}
@@ -0,0 +1,77 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"shopify-extractor/internal/types"
+)
+
+// resultValue is either a complete result document or one streamed NDJSON product
+// or shared chart line
+type resultValue struct {
+	SchemaVersion int                         `json:"schema_version"`
+	Stores        []types.StoreResult         `json:"stores"`
+	Charts        map[string]*types.SizeChart `json:"charts"`
+	StoreName     string                      `json:"store_name"`
+	Product       *types.Product              `json:"product"`
+	ChartID       string                      `json:"chart_id"`
+	Chart         *types.SizeChart            `json:"chart"`
+}
+
+// ReadResults decodes results written by a WriterSink: either a complete JSON
+// document or NDJSON product lines from a streaming run, which are grouped by store
+// in the order they appear. Deduplicated charts are expanded back into each product.
+func ReadResults(r io.Reader) (*types.ExtractionResult, error) {
+	result := &types.ExtractionResult{Charts: make(map[string]*types.SizeChart)}
+	storeIndex := make(map[string]int)
+
+	decoder := json.NewDecoder(r)
+	for {
+		var value resultValue
+		if err := decoder.Decode(&value); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode results: %w", err)
+		}
+
+		if value.Chart != nil {
+			result.Charts[value.ChartID] = value.Chart
+			continue
+		}
+		if value.Product == nil {
+			result.SchemaVersion = value.SchemaVersion
+			result.Stores = append(result.Stores, value.Stores...)
+			for id, chart := range value.Charts {
+				result.Charts[id] = chart
+			}
+			continue
+		}
+		index, ok := storeIndex[value.StoreName]
+		if !ok {
+			index = len(result.Stores)
+			storeIndex[value.StoreName] = index
+			result.Stores = append(result.Stores, types.StoreResult{StoreName: value.StoreName})
+		}
+		result.Stores[index].Products = append(result.Stores[index].Products, *value.Product)
+	}
+
+	if err := ExpandCharts(result); err != nil {
+		return nil, fmt.Errorf("failed to decode results: %w", err)
+	}
+	return result, nil
+}
+
+// ReadResultsFile reads a results file written by the file sink
+func ReadResultsFile(path string) (*types.ExtractionResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results file: %w", err)
+	}
+	defer file.Close()
+	return ReadResults(file)
+}
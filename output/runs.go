@@ -0,0 +1,140 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"shopify-extractor/internal/types"
+)
+
+// retryReasons are the missing-chart reasons worth retrying: the page could not be
+// fetched or parsed in time, unlike pages that had no chart or a rejected one
+var retryReasons = []string{
+	types.MissingReasonFetchBlocked,
+	types.MissingReasonTimedOut,
+	types.MissingReasonChallenge,
+	types.MissingReasonQueue,
+	types.MissingReasonUnknown,
+}
+
+// RetryReasons returns the missing-chart reasons whose products a retry attempts again
+func RetryReasons() []string {
+	return append([]string(nil), retryReasons...)
+}
+
+// runPath returns the file of a stored run
+func runPath(dir, id string) string {
+	return filepath.Join(dir, id+".json")
+}
+
+// SaveRun stores a run's result, including the products that failed, as
+// DIR/<run ID>.json so a later retry can attempt the failures again. An existing file
+// of the run is replaced.
+func SaveRun(dir string, result *types.ExtractionResult) error {
+	if result.RunID == "" {
+		return fmt.Errorf("failed to store run: result has no run ID")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create run directory: %w", err)
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run %s: %w", result.RunID, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".run-*")
+	if err != nil {
+		return fmt.Errorf("failed to store run %s: %w", result.RunID, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to store run %s: %w", result.RunID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to store run %s: %w", result.RunID, err)
+	}
+	if err := os.Rename(tmp.Name(), runPath(dir, result.RunID)); err != nil {
+		return fmt.Errorf("failed to store run %s: %w", result.RunID, err)
+	}
+	return nil
+}
+
+// LoadRun reads a run stored by SaveRun
+func LoadRun(dir, id string) (*types.ExtractionResult, error) {
+	if id == "" || filepath.Base(id) != id {
+		return nil, fmt.Errorf("invalid run ID %q", id)
+	}
+	data, err := os.ReadFile(runPath(dir, id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("run %s not found in %s", id, dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run %s: %w", id, err)
+	}
+	var result types.ExtractionResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse run %s: %w", id, err)
+	}
+	return &result, nil
+}
+
+// RetryURLs returns the product URLs of a store result that failed for a reason worth
+// retrying, in sorted order
+func RetryURLs(store types.StoreResult) []string {
+	var urls []string
+	for _, reason := range retryReasons {
+		for _, missing := range store.MissingCharts[reason] {
+			urls = append(urls, missing.ProductURL)
+		}
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// MergeRetry merges the outcome of retrying urls into a store result: the retried URLs
+// leave the missing charts, products extracted this time are added (replacing any
+// earlier copy) and products that failed again are listed under their new reason
+func MergeRetry(store *types.StoreResult, urls []string, products []types.Product, missing map[string][]types.MissingProduct) {
+	retried := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		retried[url] = true
+	}
+
+	for reason, entries := range store.MissingCharts {
+		kept := entries[:0]
+		for _, entry := range entries {
+			if !retried[entry.ProductURL] {
+				kept = append(kept, entry)
+			}
+		}
+		if len(kept) == 0 {
+			delete(store.MissingCharts, reason)
+		} else {
+			store.MissingCharts[reason] = kept
+		}
+	}
+	for reason, entries := range missing {
+		if store.MissingCharts == nil {
+			store.MissingCharts = make(map[string][]types.MissingProduct)
+		}
+		store.MissingCharts[reason] = append(store.MissingCharts[reason], entries...)
+	}
+
+	extracted := make(map[string]bool, len(products))
+	for _, product := range products {
+		extracted[product.ProductURL] = true
+	}
+	kept := make([]types.Product, 0, len(store.Products)+len(products))
+	for _, product := range store.Products {
+		if !extracted[product.ProductURL] {
+			kept = append(kept, product)
+		}
+	}
+	store.Products = append(kept, products...)
+	store.ChartsByParser = types.CountChartsByParser(store.Products)
+}
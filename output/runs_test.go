@@ -0,0 +1,72 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func TestSaveRun_LoadRun(t *testing.T) {
+	dir := t.TempDir()
+	result := &types.ExtractionResult{
+		RunID: "run-1",
+		Stores: []types.StoreResult{{
+			StoreName: "westside.com",
+			Products:  []types.Product{{ProductURL: "https://www.westside.com/products/a"}},
+			MissingCharts: map[string][]types.MissingProduct{
+				types.MissingReasonTimedOut: {{ProductURL: "https://www.westside.com/products/b", Error: "deadline exceeded"}},
+			},
+		}},
+	}
+	require.NoError(t, SaveRun(dir, result))
+
+	loaded, err := LoadRun(dir, "run-1")
+	require.NoError(t, err)
+	assert.Equal(t, result, loaded)
+
+	_, err = LoadRun(dir, "run-2")
+	assert.ErrorContains(t, err, "not found")
+	_, err = LoadRun(dir, "../run-1")
+	assert.ErrorContains(t, err, "invalid run ID")
+	assert.Error(t, SaveRun(dir, &types.ExtractionResult{}))
+}
+
+func TestRetryURLs(t *testing.T) {
+	store := types.StoreResult{MissingCharts: map[string][]types.MissingProduct{
+		types.MissingReasonTimedOut:     {{ProductURL: "c"}},
+		types.MissingReasonFetchBlocked: {{ProductURL: "a"}},
+		types.MissingReasonNoTable:      {{ProductURL: "b"}},
+		types.MissingReasonRejected:     {{ProductURL: "d"}},
+	}}
+	assert.Equal(t, []string{"a", "c"}, RetryURLs(store))
+	assert.Empty(t, RetryURLs(types.StoreResult{}))
+}
+
+func TestMergeRetry(t *testing.T) {
+	chart := &types.SizeChart{Parser: "table", Headers: []string{"Size"}}
+	store := types.StoreResult{
+		Products: []types.Product{{ProductURL: "a", SizeCharts: []*types.SizeChart{chart}}},
+		MissingCharts: map[string][]types.MissingProduct{
+			types.MissingReasonTimedOut:     {{ProductURL: "b"}, {ProductURL: "c"}},
+			types.MissingReasonFetchBlocked: {{ProductURL: "d"}},
+			types.MissingReasonNoTable:      {{ProductURL: "e"}},
+		},
+	}
+
+	MergeRetry(&store, []string{"b", "c", "d"},
+		[]types.Product{{ProductURL: "b", SizeCharts: []*types.SizeChart{chart}}, {ProductURL: "d", SizeCharts: []*types.SizeChart{chart}}},
+		map[string][]types.MissingProduct{types.MissingReasonChallenge: {{ProductURL: "c"}}})
+
+	urls := make([]string, len(store.Products))
+	for i, product := range store.Products {
+		urls[i] = product.ProductURL
+	}
+	assert.Equal(t, []string{"a", "b", "d"}, urls)
+	assert.Equal(t, map[string][]types.MissingProduct{
+		types.MissingReasonChallenge: {{ProductURL: "c"}},
+		types.MissingReasonNoTable:   {{ProductURL: "e"}},
+	}, store.MissingCharts)
+	assert.Equal(t, map[string]int{"table": 3}, store.ChartsByParser)
+}
@@ -0,0 +1,97 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"shopify-extractor/internal/types"
+)
+
+// ObjectPutter uploads a single object. It is satisfied by a thin wrapper around
+// the AWS SDK (or any S3-compatible client), keeping this package free of SDK dependencies.
+type ObjectPutter interface {
+	PutObject(ctx context.Context, bucket, key string, body []byte, contentType string) error
+}
+
+// S3Sink uploads results to an S3-compatible bucket. Complete results are uploaded
+// as a single JSON object; streamed products are buffered and uploaded as one
+// NDJSON object per store when the sink is closed.
+type S3Sink struct {
+	putter ObjectPutter
+	bucket string
+	prefix string
+
+	mu      sync.Mutex
+	buffers map[string]*bytes.Buffer
+}
+
+// NewS3Sink creates a sink uploading to bucket under the given key prefix
+func NewS3Sink(putter ObjectPutter, bucket, prefix string) *S3Sink {
+	return &S3Sink{
+		putter:  putter,
+		bucket:  bucket,
+		prefix:  strings.Trim(prefix, "/"),
+		buffers: make(map[string]*bytes.Buffer),
+	}
+}
+
+func (s *S3Sink) key(name string) string {
+	if s.prefix == "" {
+		return name
+	}
+	return path.Join(s.prefix, name)
+}
+
+// Write uploads the complete result as results-<timestamp>.json
+func (s *S3Sink) Write(ctx context.Context, result *types.ExtractionResult) error {
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	key := s.key(fmt.Sprintf("results-%s.json", time.Now().UTC().Format("20060102T150405Z")))
+	if err := s.putter.PutObject(ctx, s.bucket, key, jsonData, "application/json"); err != nil {
+		return fmt.Errorf("failed to upload results to s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return nil
+}
+
+// WriteProduct buffers a product until Close uploads the store's NDJSON object
+func (s *S3Sink) WriteProduct(ctx context.Context, storeName string, product types.Product) error {
+	line, err := json.Marshal(productRecord{StoreName: storeName, Product: product})
+	if err != nil {
+		return fmt.Errorf("failed to marshal product: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf, ok := s.buffers[storeName]
+	if !ok {
+		buf = &bytes.Buffer{}
+		s.buffers[storeName] = buf
+	}
+	buf.Write(line)
+	buf.WriteByte('\n')
+	return nil
+}
+
+// Close uploads any buffered products
+func (s *S3Sink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for storeName, buf := range s.buffers {
+		key := s.key(storeName + ".ndjson")
+		if err := s.putter.PutObject(context.Background(), s.bucket, key, buf.Bytes(), "application/x-ndjson"); err != nil {
+			return fmt.Errorf("failed to upload products to s3://%s/%s: %w", s.bucket, key, err)
+		}
+		delete(s.buffers, storeName)
+	}
+	return nil
+}
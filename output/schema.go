@@ -0,0 +1,43 @@
+package output
+
+import (
+	"context"
+	"fmt"
+
+	"shopify-extractor/internal/types"
+)
+
+// SchemaSink writes results to another sink in a given structure version (see
+// types.ExtractionResult.AsSchema), so consumers of an older structure keep working
+type SchemaSink struct {
+	sink    Sink
+	version int
+}
+
+// NewSchemaSink creates a sink writing results to sink in version, 0 being the current
+// structure
+func NewSchemaSink(sink Sink, version int) (*SchemaSink, error) {
+	if !types.ValidSchemaVersion(version) {
+		return nil, fmt.Errorf("unsupported schema version %d (supported: %v)", version, types.SchemaVersions())
+	}
+	return &SchemaSink{sink: sink, version: version}, nil
+}
+
+// Write writes the result in the sink's version
+func (s *SchemaSink) Write(ctx context.Context, result *types.ExtractionResult) error {
+	converted, err := result.AsSchema(s.version)
+	if err != nil {
+		return err
+	}
+	return s.sink.Write(ctx, converted)
+}
+
+// WriteProduct writes a single product in the sink's version
+func (s *SchemaSink) WriteProduct(ctx context.Context, storeName string, product types.Product) error {
+	return s.sink.WriteProduct(ctx, storeName, types.ProductAsSchema(product, s.version))
+}
+
+// Close closes the underlying sink
+func (s *SchemaSink) Close() error {
+	return s.sink.Close()
+}
@@ -0,0 +1,48 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func TestSchemaSink(t *testing.T) {
+	result := &types.ExtractionResult{
+		SchemaVersion: types.CurrentSchemaVersion,
+		RunID:         "run-1",
+		Stores: []types.StoreResult{{StoreName: "westside.com", Products: []types.Product{{
+			ProductTitle: "Dress",
+			ProductURL:   "https://westside.com/products/dress",
+			SizeCharts:   []*types.SizeChart{{Unit: "in", Headers: []string{"Size"}, Rows: []map[string]string{{"Size": "M"}}}},
+		}}}},
+	}
+
+	var buf bytes.Buffer
+	sink, err := NewSchemaSink(NewWriterSink(&buf), types.SchemaV1)
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(context.Background(), result))
+	require.NoError(t, sink.WriteProduct(context.Background(), "westside.com", result.Stores[0].Products[0]))
+	assert.NotContains(t, buf.String(), "schema_version")
+	assert.NotContains(t, buf.String(), `"unit"`)
+	assert.NotContains(t, buf.String(), "run_id")
+
+	read, err := ReadResults(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 0, read.SchemaVersion)
+
+	buf.Reset()
+	sink, err = NewSchemaSink(NewWriterSink(&buf), 0)
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(context.Background(), result))
+	assert.Contains(t, buf.String(), `"schema_version": 2`)
+	read, err = ReadResults(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, types.SchemaV2, read.SchemaVersion)
+
+	_, err = NewSchemaSink(NewWriterSink(&buf), 3)
+	assert.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"shopify-extractor/internal/types"
+)
+
+// Sink receives extraction results. Write delivers a complete ExtractionResult,
+// while WriteProduct streams individual products as soon as they are extracted,
+// so embedding applications can forward results without re-marshaling them.
+type Sink interface {
+	// Write stores a complete extraction result
+	Write(ctx context.Context, result *types.ExtractionResult) error
+
+	// WriteProduct stores a single product belonging to the given store
+	WriteProduct(ctx context.Context, storeName string, product types.Product) error
+
+	// Close flushes any buffered data and releases resources
+	Close() error
+}
+
+// SinkFactory builds a sink from a target string (file path, table name, bucket URL, ...)
+type SinkFactory func(target string) (Sink, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]SinkFactory{}
+)
+
+func init() {
+	RegisterSink("stdout", func(target string) (Sink, error) {
+		return NewStdoutSink(), nil
+	})
+	RegisterSink("file", func(target string) (Sink, error) {
+		if target == "" {
+			return nil, fmt.Errorf("file sink requires a path")
+		}
+		return NewFileSink(target), nil
+	})
+}
+
+// RegisterSink makes a sink available by name. Registering an existing name replaces it,
+// which lets library users override the built-in sinks.
+func RegisterSink(name string, factory SinkFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// NewSink creates a registered sink by name
+func NewSink(name, target string) (Sink, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown output sink: %s (available: %v)", name, SinkNames())
+	}
+	return factory(target)
+}
+
+// SinkNames returns the names of all registered sinks in sorted order
+func SinkNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MultiSink fans every write out to several sinks, stopping at the first error
+type MultiSink struct {
+	sinks []Sink
+}
+
+// NewMultiSink combines several sinks into one
+func NewMultiSink(sinks ...Sink) *MultiSink {
+	return &MultiSink{sinks: sinks}
+}
+
+// Write forwards the result to every sink
+func (m *MultiSink) Write(ctx context.Context, result *types.ExtractionResult) error {
+	for _, s := range m.sinks {
+		if err := s.Write(ctx, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteProduct forwards the product to every sink
+func (m *MultiSink) WriteProduct(ctx context.Context, storeName string, product types.Product) error {
+	for _, s := range m.sinks {
+		if err := s.WriteProduct(ctx, storeName, product); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes every sink and returns the first error encountered
+func (m *MultiSink) Close() error {
+	var firstErr error
+	for _, s := range m.sinks {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
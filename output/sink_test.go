@@ -0,0 +1,141 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func TestWriterSink_WriteProduct(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	ctx := context.Background()
+	require.NoError(t, sink.WriteProduct(ctx, "westside.com", types.Product{ProductTitle: "Top", ProductURL: "https://www.westside.com/products/top"}))
+	require.NoError(t, sink.WriteProduct(ctx, "suqah.com", types.Product{ProductTitle: "Dress", ProductURL: "https://www.suqah.com/products/dress"}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var record productRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "suqah.com", record.StoreName)
+	assert.Equal(t, "Dress", record.Product.ProductTitle)
+}
+
+type recordingSink struct {
+	results int
+}
+
+func (r *recordingSink) Write(ctx context.Context, result *types.ExtractionResult) error {
+	r.results++
+	return nil
+}
+
+func (r *recordingSink) WriteProduct(ctx context.Context, storeName string, product types.Product) error {
+	return nil
+}
+
+func (r *recordingSink) Close() error { return nil }
+
+func TestFileSink_ReplacesFileOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	require.NoError(t, os.WriteFile(path, []byte("previous"), 0644))
+
+	sink := NewFileSink(path)
+	require.NoError(t, sink.WriteProduct(context.Background(), "westside.com", types.Product{ProductTitle: "Top"}))
+
+	// Until the run completes the previous results stay in place
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "previous", string(data))
+
+	require.NoError(t, sink.Close())
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"product_title":"Top"`)
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "the temporary file was renamed")
+}
+
+func TestRegisterSink(t *testing.T) {
+	custom := &recordingSink{}
+	RegisterSink("recording", func(target string) (Sink, error) { return custom, nil })
+
+	sink, err := NewSink("recording", "")
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(context.Background(), &types.ExtractionResult{}))
+	assert.Equal(t, 1, custom.results)
+	assert.Contains(t, SinkNames(), "recording")
+}
+
+func TestNewSink_Unknown(t *testing.T) {
+	_, err := NewSink("does-not-exist", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown output sink")
+}
+
+func TestReadResults_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	result := &types.ExtractionResult{Stores: []types.StoreResult{
+		{StoreName: "westside.com", Products: []types.Product{{ProductTitle: "Top"}}},
+	}}
+
+	var document bytes.Buffer
+	require.NoError(t, NewWriterSink(&document).Write(ctx, result))
+	read, err := ReadResults(&document)
+	require.NoError(t, err)
+	assert.Equal(t, result, read)
+
+	var stream bytes.Buffer
+	sink := NewWriterSink(&stream)
+	require.NoError(t, sink.WriteProduct(ctx, "westside.com", types.Product{ProductTitle: "Top"}))
+	require.NoError(t, sink.WriteProduct(ctx, "suqah.com", types.Product{ProductTitle: "Dress"}))
+	require.NoError(t, sink.WriteProduct(ctx, "westside.com", types.Product{ProductTitle: "Shirt"}))
+	read, err = ReadResults(&stream)
+	require.NoError(t, err)
+	require.Len(t, read.Stores, 2)
+	assert.Equal(t, "westside.com", read.Stores[0].StoreName)
+	assert.Len(t, read.Stores[0].Products, 2)
+	assert.Equal(t, "Dress", read.Stores[1].Products[0].ProductTitle)
+}
+
+func TestDedupeSink(t *testing.T) {
+	ctx := context.Background()
+	chart := &types.SizeChart{Unit: types.UnitInches, Headers: []string{"Size", "Bust"}, Rows: []map[string]string{{"Size": "S", "Bust": "34"}}}
+	products := []types.Product{
+		{ProductTitle: "Top", SizeCharts: []*types.SizeChart{chart}},
+		{ProductTitle: "Shirt", SizeCharts: []*types.SizeChart{chart}},
+	}
+	result := &types.ExtractionResult{Stores: []types.StoreResult{{StoreName: "westside.com", Products: products}}}
+
+	var document bytes.Buffer
+	require.NoError(t, NewDedupeSink(NewWriterSink(&document)).Write(ctx, result))
+	assert.Equal(t, 1, strings.Count(document.String(), `"Bust"`+": \"34\""))
+	read, err := ReadResults(&document)
+	require.NoError(t, err)
+	assert.Equal(t, result, read)
+
+	var stream bytes.Buffer
+	sink := NewDedupeSink(NewWriterSink(&stream))
+	for _, product := range products {
+		require.NoError(t, sink.WriteProduct(ctx, "westside.com", product))
+	}
+	lines := strings.Split(strings.TrimSpace(stream.String()), "\n")
+	require.Len(t, lines, 3, "one chart line followed by two product lines")
+	assert.Contains(t, lines[0], `"chart_id":"`+chart.ComputeFingerprint()+`"`)
+	assert.Contains(t, lines[2], `"chart_ids":["`+chart.ComputeFingerprint()+`"]`)
+
+	read, err = ReadResults(&stream)
+	require.NoError(t, err)
+	assert.Equal(t, result, read)
+}
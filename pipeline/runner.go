@@ -0,0 +1,127 @@
+// Package pipeline runs extractions over a list of stores. The CLI and the API server
+// both extract through a Runner, so stores registered with extractor.Register (built-in
+// or plugin) are available to both without further wiring.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"shopify-extractor/extractor"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/stats"
+	"shopify-extractor/utils"
+)
+
+// ErrUnknownStore is returned for stores without a registered extractor
+var ErrUnknownStore = errors.New("unknown store")
+
+// StoreFunc extracts one store
+type StoreFunc func(ctx context.Context, store string, config *types.Config, logger types.Logger) (types.StoreResult, error)
+
+// Runner extracts stores one after the other into an ExtractionResult. Every field is
+// optional.
+type Runner struct {
+	// Extract extracts one store; nil creates the store's extractor with extractor.New
+	// for the store and closes it afterwards
+	Extract StoreFunc
+
+	// Stats receives per-product statistics and Writer the products as they are
+	// extracted (see StoreExtractor.SetResultWriter); both apply when Extract is nil
+	Stats  *stats.Collector
+	Writer extractor.ResultWriter
+
+	// OnStore is called before a store is extracted, and OnStoreDone with its result and
+	// error once it is; index is the store's position in the store list
+	OnStore     func(index int, store string)
+	OnStoreDone func(index int, result types.StoreResult, err error)
+}
+
+// Run extracts the stores with config. A store that fails is reported in its result with
+// Error set rather than failing the run; the portfolios requested by name (see
+// config.ResolveStores) aggregate their stores' results.
+func (r *Runner) Run(ctx context.Context, stores []string, portfolios map[string][]string, config *types.Config, logger types.Logger) *types.ExtractionResult {
+	extract := r.Extract
+	if extract == nil {
+		extract = r.extractStore
+	}
+
+	storeResults := make([]types.StoreResult, 0, len(stores))
+	for i, store := range stores {
+		logger.Infof("Processing store: %s", store)
+		if r.OnStore != nil {
+			r.OnStore(i, store)
+		}
+
+		result, err := extract(ctx, store, config, utils.WithField(logger, "store", store))
+		if err != nil {
+			logger.Warnf("Failed to extract from %s: %v", store, err)
+			result.StoreName = store
+			result.Error = err.Error()
+			if result.Products == nil {
+				result.Products = []types.Product{}
+			}
+		}
+		for reason, missing := range result.MissingCharts {
+			logger.Infof("%s: %d products without size chart (%s)", store, len(missing), reason)
+		}
+		if len(result.ChartsByParser) > 0 {
+			logger.Infof("%s: size charts by parser: %s", store, types.FormatChartsByParser(result.ChartsByParser))
+		}
+
+		storeResults = append(storeResults, result)
+		if r.OnStoreDone != nil {
+			r.OnStoreDone(i, result, err)
+		}
+	}
+
+	return &types.ExtractionResult{
+		SchemaVersion: types.CurrentSchemaVersion,
+		RunID:         config.RunID,
+		Stores:        storeResults,
+		Portfolios:    types.NewPortfolioResults(portfolios, storeResults),
+	}
+}
+
+// extractStore extracts a store with a new extractor
+func (r *Runner) extractStore(ctx context.Context, store string, config *types.Config, logger types.Logger) (types.StoreResult, error) {
+	storeExtractor := extractor.New(store, config, logger)
+	if storeExtractor == nil {
+		return types.StoreResult{}, fmt.Errorf("%w: %s", ErrUnknownStore, store)
+	}
+	defer storeExtractor.Close()
+
+	storeExtractor.SetStatsCollector(r.Stats)
+	if r.Writer != nil {
+		storeExtractor.SetResultWriter(r.Writer)
+	}
+	return ExtractStore(ctx, storeExtractor, store, config)
+}
+
+// ExtractStore runs a store extractor's ExtractAll and reports its outcome as the
+// store's result. A store aborted for exceeding its failure budget keeps the products
+// it missed in the result returned with the error.
+func ExtractStore(ctx context.Context, storeExtractor extractor.StoreExtractor, store string, config *types.Config) (types.StoreResult, error) {
+	products, err := storeExtractor.ExtractAll(ctx)
+	if errors.Is(err, extractor.ErrStoreUnreachable) {
+		return types.StoreResult{
+			StoreName:     store,
+			Products:      []types.Product{},
+			MissingCharts: storeExtractor.MissingCharts(),
+		}, err
+	}
+	if err != nil {
+		return types.StoreResult{}, err
+	}
+	return types.StoreResult{
+		StoreName:          store,
+		Products:           products,
+		MissingCharts:      storeExtractor.MissingCharts(),
+		Coverage:           storeExtractor.Coverage(),
+		ChartsByParser:     types.CountChartsByParser(products),
+		DiscoveryTruncated: storeExtractor.DiscoveryTruncated(),
+		Partial:            config.Sampling(),
+		WrittenProducts:    storeExtractor.WrittenProducts(),
+	}, nil
+}
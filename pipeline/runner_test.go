@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/extractor"
+	"shopify-extractor/internal/types"
+	"shopify-extractor/stats"
+)
+
+// fakeStoreExtractor returns fixed products, or err, from ExtractAll
+type fakeStoreExtractor struct {
+	products []types.Product
+	missing  map[string][]types.MissingProduct
+	err      error
+	closed   bool
+	stats    *stats.Collector
+}
+
+func (f *fakeStoreExtractor) ExtractAll(ctx context.Context) ([]types.Product, error) {
+	return f.products, f.err
+}
+
+func (f *fakeStoreExtractor) DiscoverProductURLs(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeStoreExtractor) ExtractProduct(ctx context.Context, productURL string) (*types.Product, error) {
+	return nil, nil
+}
+
+func (f *fakeStoreExtractor) MissingCharts() map[string][]types.MissingProduct { return f.missing }
+func (f *fakeStoreExtractor) Coverage() *types.Coverage                        { return nil }
+func (f *fakeStoreExtractor) DiscoveryTruncated() bool                         { return false }
+func (f *fakeStoreExtractor) WrittenProducts() int                             { return 0 }
+func (f *fakeStoreExtractor) SetStatsCollector(c *stats.Collector)             { f.stats = c }
+func (f *fakeStoreExtractor) SetResultWriter(w extractor.ResultWriter)         {}
+func (f *fakeStoreExtractor) Close()                                           { f.closed = true }
+
+func TestRunner_Run(t *testing.T) {
+	var started []string
+	var failed []int
+	runner := Runner{
+		Extract: func(ctx context.Context, store string, config *types.Config, logger types.Logger) (types.StoreResult, error) {
+			if store == "suqah.com" {
+				return types.StoreResult{}, errors.New("blocked")
+			}
+			return types.StoreResult{StoreName: store, Products: []types.Product{{ProductURL: "https://westside.com/products/a"}}}, nil
+		},
+		OnStore: func(index int, store string) { started = append(started, store) },
+		OnStoreDone: func(index int, result types.StoreResult, err error) {
+			if err != nil {
+				failed = append(failed, index)
+			}
+		},
+	}
+
+	config := types.DefaultConfig()
+	config.RunID = "run-1"
+	result := runner.Run(context.Background(), []string{"westside.com", "suqah.com"},
+		map[string][]string{"womenswear": {"westside.com", "suqah.com"}}, config, logrus.New())
+
+	assert.Equal(t, "run-1", result.RunID)
+	require.Len(t, result.Stores, 2)
+	assert.Len(t, result.Stores[0].Products, 1)
+	assert.Equal(t, "suqah.com", result.Stores[1].StoreName)
+	assert.Equal(t, "blocked", result.Stores[1].Error)
+	assert.NotNil(t, result.Stores[1].Products)
+	assert.Equal(t, []string{"westside.com", "suqah.com"}, started)
+	assert.Equal(t, []int{1}, failed)
+	require.Len(t, result.Portfolios, 1)
+	assert.Equal(t, []string{"suqah.com"}, result.Portfolios[0].FailedStores)
+}
+
+func TestRunner_RegisteredStore(t *testing.T) {
+	fake := &fakeStoreExtractor{products: []types.Product{{ProductURL: "https://runner-test.com/products/a"}}}
+	extractor.Register("runner-test.com", func(config *types.Config, logger types.Logger) extractor.StoreExtractor {
+		return fake
+	})
+
+	collector := stats.NewCollector()
+	runner := Runner{Stats: collector}
+	result := runner.Run(context.Background(), []string{"www.runner-test.com", "unregistered.example"}, nil, types.DefaultConfig(), logrus.New())
+
+	require.Len(t, result.Stores, 2)
+	assert.Len(t, result.Stores[0].Products, 1)
+	assert.True(t, fake.closed)
+	assert.Same(t, collector, fake.stats)
+	assert.Contains(t, result.Stores[1].Error, ErrUnknownStore.Error())
+}
+
+func TestExtractStore_Unreachable(t *testing.T) {
+	missing := map[string][]types.MissingProduct{types.MissingReasonFetchBlocked: {{ProductURL: "a"}}}
+	fake := &fakeStoreExtractor{missing: missing, err: fmt.Errorf("%w: 5 of 5 failed", extractor.ErrStoreUnreachable)}
+
+	result, err := ExtractStore(context.Background(), fake, "westside.com", types.DefaultConfig())
+	assert.ErrorIs(t, err, extractor.ErrStoreUnreachable)
+	assert.Equal(t, missing, result.MissingCharts)
+	assert.NotNil(t, result.Products)
+
+	fake = &fakeStoreExtractor{products: []types.Product{{ProductURL: "a", SizeCharts: []*types.SizeChart{{Parser: "kiwi"}}}}}
+	result, err = ExtractStore(context.Background(), fake, "westside.com", types.DefaultConfig())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"kiwi": 1}, result.ChartsByParser)
+}
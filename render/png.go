@@ -0,0 +1,139 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+
+	"shopify-extractor/internal/types"
+	"shopify-extractor/utils"
+)
+
+// PNG layout, in font dots; every dot is pngScale pixels square
+const (
+	pngScale    = 2
+	charWidth   = 6 // 5x7 glyph plus spacing
+	charHeight  = 8
+	cellPadding = 3
+)
+
+var (
+	pngBackground = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	pngHeader     = color.RGBA{0xE8, 0xE8, 0xE8, 0xFF}
+	pngGrid       = color.RGBA{0x99, 0x99, 0x99, 0xFF}
+	pngText       = color.RGBA{0x22, 0x22, 0x22, 0xFF}
+)
+
+// canvas draws on an image in font dots
+type canvas struct {
+	img *image.RGBA
+}
+
+// fill paints the dots from (x0, y0) up to but excluding (x1, y1)
+func (c canvas) fill(x0, y0, x1, y1 int, col color.RGBA) {
+	for y := y0 * pngScale; y < y1*pngScale; y++ {
+		for x := x0 * pngScale; x < x1*pngScale; x++ {
+			c.img.SetRGBA(x, y, col)
+		}
+	}
+}
+
+// text draws a line of text with its top left corner at (x, y)
+func (c canvas) text(x, y int, s string, col color.RGBA) {
+	for _, r := range []rune(s) {
+		g := glyph(r)
+		for column, bits := range g {
+			for row := 0; row < 7; row++ {
+				if bits&(1<<row) != 0 {
+					c.fill(x+column, y+row, x+column+1, y+row+1, col)
+				}
+			}
+		}
+		x += charWidth
+	}
+}
+
+// PNG writes a size chart as a PNG image of a table: its name and unit above a grid of
+// cells with a shaded header row. Characters outside printable ASCII are drawn as "?".
+func PNG(w io.Writer, chart *types.SizeChart) error {
+	table := [][]string{chart.Headers}
+	for _, row := range chart.Rows {
+		table = append(table, cells(chart, row))
+	}
+
+	// Column widths in dots, grid lines included
+	widths := make([]int, len(chart.Headers))
+	for _, row := range table {
+		for i, cell := range row {
+			if width := len([]rune(cell))*charWidth + 2*cellPadding; width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+	title := chartTitle(chart)
+	rowHeight := charHeight + 2*cellPadding
+	tableWidth := 1
+	for _, width := range widths {
+		tableWidth += width + 1
+	}
+	width := tableWidth
+	if titleWidth := len([]rune(title))*charWidth + 2*cellPadding; titleWidth > width {
+		width = titleWidth
+	}
+	height := rowHeight + len(table)*(rowHeight+1) + 1
+
+	c := canvas{img: image.NewRGBA(image.Rect(0, 0, width*pngScale, height*pngScale))}
+	c.fill(0, 0, width, height, pngBackground)
+	c.text(cellPadding, cellPadding, title, pngText)
+
+	top := rowHeight
+	c.fill(0, top, tableWidth, top+rowHeight+1, pngHeader)
+	for r, row := range table {
+		y := top + r*(rowHeight+1)
+		c.fill(0, y, tableWidth, y+1, pngGrid)
+		x := 0
+		for i, cell := range row {
+			c.fill(x, y, x+1, y+rowHeight+1, pngGrid)
+			c.text(x+1+cellPadding, y+1+cellPadding, cell, pngText)
+			x += widths[i] + 1
+		}
+		c.fill(x, y, x+1, y+rowHeight+1, pngGrid)
+	}
+	c.fill(0, height-1, tableWidth, height, pngGrid)
+
+	if err := png.Encode(w, c.img); err != nil {
+		return fmt.Errorf("failed to encode size chart image: %w", err)
+	}
+	return nil
+}
+
+// SaveChartImages writes every size chart of a product as a PNG file in dir, named
+// after the product handle and the chart's position, e.g. linen-dress-1.png, and
+// returns the paths written
+func SaveChartImages(dir string, product types.Product) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create chart image directory: %w", err)
+	}
+	handle := utils.ProductHandle(product.ProductURL)
+	var paths []string
+	for i, chart := range product.SizeCharts {
+		path := filepath.Join(dir, fmt.Sprintf("%s-%d.png", handle, i+1))
+		file, err := os.Create(path)
+		if err != nil {
+			return paths, fmt.Errorf("failed to create chart image: %w", err)
+		}
+		err = PNG(file, chart)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return paths, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
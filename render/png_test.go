@@ -0,0 +1,44 @@
+package render
+
+import (
+	"bytes"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPNG(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, PNG(&out, testProduct().SizeCharts[0]))
+
+	img, err := png.Decode(&out)
+	require.NoError(t, err)
+	bounds := img.Bounds()
+	// Title row plus a header and two data rows, each with a grid line
+	rowHeight := charHeight + 2*cellPadding
+	assert.Equal(t, (rowHeight+3*(rowHeight+1)+1)*pngScale, bounds.Dy())
+	// "Body Measurements (in)" is wider than the table
+	assert.Equal(t, (22*charWidth+2*cellPadding)*pngScale, bounds.Dx())
+
+	r, g, b, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, [3]uint32{0xFFFF, 0xFFFF, 0xFFFF}, [3]uint32{r, g, b}, "white background")
+	r, g, b, _ = img.At(0, rowHeight*pngScale).RGBA()
+	assert.Equal(t, [3]uint32{0x9999, 0x9999, 0x9999}, [3]uint32{r, g, b}, "grid line above the header")
+}
+
+func TestSaveChartImages(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "run", "westside.com")
+	paths, err := SaveChartImages(dir, testProduct())
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "linen-dress-1.png")}, paths)
+
+	file, err := os.Open(paths[0])
+	require.NoError(t, err)
+	defer file.Close()
+	_, err = png.Decode(file)
+	assert.NoError(t, err)
+}
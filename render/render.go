@@ -0,0 +1,172 @@
+// Package render prints extraction results as human-readable size chart tables, for
+// reviewing extraction quality in a terminal or pasting into tickets and PR descriptions.
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"shopify-extractor/internal/types"
+)
+
+// Render formats
+const (
+	FormatMarkdown = "markdown" // GitHub-flavored Markdown tables
+	FormatASCII    = "ascii"    // aligned plain text tables
+)
+
+// Formats returns the supported render formats
+func Formats() []string {
+	return []string{FormatMarkdown, FormatASCII}
+}
+
+// IsFormat reports whether format is a supported render format
+func IsFormat(format string) bool {
+	return format == FormatMarkdown || format == FormatASCII
+}
+
+// Result writes every product of a result with its size charts, grouped by store
+func Result(w io.Writer, result *types.ExtractionResult, format string) error {
+	if !IsFormat(format) {
+		return fmt.Errorf("unsupported render format %q (available: %s)", format, strings.Join(Formats(), ", "))
+	}
+	for i, store := range result.Stores {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		title := fmt.Sprintf("%s (%d products)", store.StoreName, len(store.Products))
+		if store.Error != "" {
+			title += ": " + store.Error
+		}
+		writeStoreTitle(w, title, format)
+		for _, product := range store.Products {
+			fmt.Fprintln(w)
+			Product(w, product, format)
+		}
+	}
+	return nil
+}
+
+// writeStoreTitle writes a store heading
+func writeStoreTitle(w io.Writer, title, format string) {
+	if format == FormatMarkdown {
+		fmt.Fprintf(w, "## %s\n", markdownText(title))
+	} else {
+		fmt.Fprintf(w, "%s\n%s\n", title, strings.Repeat("=", utf8.RuneCountInString(title)))
+	}
+}
+
+// Product writes a product's title and URL followed by its size charts
+func Product(w io.Writer, product types.Product, format string) {
+	title := product.ProductTitle
+	if title == "" {
+		title = product.ProductURL
+	}
+	if format == FormatMarkdown {
+		fmt.Fprintf(w, "### [%s](%s)\n", markdownText(title), product.ProductURL)
+	} else {
+		fmt.Fprintf(w, "%s\n%s\n", title, product.ProductURL)
+	}
+	for _, chart := range product.SizeCharts {
+		fmt.Fprintln(w)
+		if format == FormatMarkdown {
+			Markdown(w, chart)
+		} else {
+			ASCII(w, chart)
+		}
+	}
+}
+
+// chartTitle is the name and unit of a chart
+func chartTitle(chart *types.SizeChart) string {
+	title := chart.Name
+	if title == "" {
+		title = "Size Chart"
+	}
+	if chart.Unit != "" {
+		title += " (" + chart.Unit + ")"
+	}
+	return title
+}
+
+// cells returns the cells of a chart row in header order
+func cells(chart *types.SizeChart, row map[string]string) []string {
+	cells := make([]string, len(chart.Headers))
+	for i, header := range chart.Headers {
+		cells[i] = row[header]
+	}
+	return cells
+}
+
+// ASCII writes a size chart as an aligned text table
+func ASCII(w io.Writer, chart *types.SizeChart) {
+	fmt.Fprintln(w, chartTitle(chart))
+
+	widths := make([]int, len(chart.Headers))
+	for i, header := range chart.Headers {
+		widths[i] = utf8.RuneCountInString(header)
+		for _, row := range chart.Rows {
+			if n := utf8.RuneCountInString(row[header]); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	writeRow := func(cells []string) {
+		for i, cell := range cells {
+			if i > 0 {
+				fmt.Fprint(w, " | ")
+			}
+			if i == len(cells)-1 {
+				fmt.Fprint(w, cell)
+			} else {
+				fmt.Fprint(w, cell+strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)))
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	writeRow(chart.Headers)
+	separators := make([]string, len(widths))
+	for i, width := range widths {
+		separators[i] = strings.Repeat("-", width)
+	}
+	fmt.Fprintln(w, strings.Join(separators, "-+-"))
+	for _, row := range chart.Rows {
+		writeRow(cells(chart, row))
+	}
+}
+
+// Markdown writes a size chart as a bold title followed by a GitHub-flavored Markdown table
+func Markdown(w io.Writer, chart *types.SizeChart) {
+	fmt.Fprintf(w, "**%s**\n\n", markdownText(chartTitle(chart)))
+
+	writeRow := func(cells []string) {
+		escaped := make([]string, len(cells))
+		for i, cell := range cells {
+			escaped[i] = markdownCell(cell)
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(escaped, " | "))
+	}
+
+	writeRow(chart.Headers)
+	separators := make([]string, len(chart.Headers))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	fmt.Fprintf(w, "| %s |\n", strings.Join(separators, " | "))
+	for _, row := range chart.Rows {
+		writeRow(cells(chart, row))
+	}
+}
+
+// markdownText escapes the characters that would start emphasis or a link
+var markdownText = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`).Replace
+
+// markdownCell makes a value safe inside a table cell: pipes would end the cell and
+// line breaks the row
+func markdownCell(value string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ").Replace(markdownText(value))
+}
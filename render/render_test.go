@@ -0,0 +1,75 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func testProduct() types.Product {
+	return types.Product{
+		ProductTitle: "Linen Dress",
+		ProductURL:   "https://www.westside.com/products/linen-dress",
+		SizeCharts: []*types.SizeChart{{
+			Name:    "Body Measurements",
+			Unit:    types.UnitInches,
+			Headers: []string{"Size", "Bust (in)"},
+			Rows: []map[string]string{
+				{"Size": "S", "Bust (in)": "34"},
+				{"Size": "M", "Bust (in)": "36|37"},
+			},
+		}},
+	}
+}
+
+func TestResult_Markdown(t *testing.T) {
+	result := &types.ExtractionResult{Stores: []types.StoreResult{
+		{StoreName: "westside.com", Products: []types.Product{testProduct()}},
+	}}
+	var out bytes.Buffer
+	require.NoError(t, Result(&out, result, FormatMarkdown))
+	assert.Equal(t, strings.Join([]string{
+		"## westside.com (1 products)",
+		"",
+		"### [Linen Dress](https://www.westside.com/products/linen-dress)",
+		"",
+		"**Body Measurements (in)**",
+		"",
+		"| Size | Bust (in) |",
+		"| --- | --- |",
+		"| S | 34 |",
+		`| M | 36\|37 |`,
+	}, "\n")+"\n", out.String())
+
+	assert.Error(t, Result(&out, result, "html"))
+}
+
+func TestASCII(t *testing.T) {
+	var out bytes.Buffer
+	ASCII(&out, testProduct().SizeCharts[0])
+	assert.Equal(t, "Body Measurements (in)\nSize | Bust (in)\n-----+----------\nS    | 34\nM    | 36|37\n", out.String())
+}
+
+func TestSink_WriteProduct(t *testing.T) {
+	var out bytes.Buffer
+	sink, err := NewSink(&out, nil, FormatASCII)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, sink.WriteProduct(ctx, "westside.com", testProduct()))
+	require.NoError(t, sink.WriteProduct(ctx, "westside.com", testProduct()))
+	require.NoError(t, sink.WriteProduct(ctx, "suqah.com", testProduct()))
+	require.NoError(t, sink.Close())
+
+	assert.Equal(t, 1, strings.Count(out.String(), "westside.com\n"), "one heading per store")
+	assert.Equal(t, 1, strings.Count(out.String(), "suqah.com\n"))
+	assert.Equal(t, 3, strings.Count(out.String(), "Linen Dress\n"))
+
+	_, err = NewSink(&out, nil, "html")
+	assert.Error(t, err)
+}
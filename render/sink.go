@@ -0,0 +1,70 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"shopify-extractor/internal/types"
+)
+
+// Sink renders extraction results as they are written, implementing output.Sink.
+// Streamed products are rendered as they arrive, with a store heading whenever the
+// store changes.
+type Sink struct {
+	w      io.Writer
+	closer io.Closer // closed by Close, may be nil
+	format string
+
+	mu      sync.Mutex
+	store   string // store of the last streamed product
+	written bool
+}
+
+// NewSink creates a sink rendering to w in the given format; closer, when not nil, is
+// closed with the sink
+func NewSink(w io.Writer, closer io.Closer, format string) (*Sink, error) {
+	if !IsFormat(format) {
+		return nil, fmt.Errorf("unsupported render format %q (available: %s)", format, strings.Join(Formats(), ", "))
+	}
+	return &Sink{w: w, closer: closer, format: format}, nil
+}
+
+// Write renders a complete result
+func (s *Sink) Write(ctx context.Context, result *types.ExtractionResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.written {
+		fmt.Fprintln(s.w)
+	}
+	s.written = true
+	return Result(s.w, result, s.format)
+}
+
+// WriteProduct renders a single product, preceded by a heading for its store when it
+// belongs to another store than the previous one
+func (s *Sink) WriteProduct(ctx context.Context, storeName string, product types.Product) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if storeName != s.store {
+		if s.written {
+			fmt.Fprintln(s.w)
+		}
+		writeStoreTitle(s.w, storeName, s.format)
+		s.store = storeName
+	}
+	s.written = true
+	fmt.Fprintln(s.w)
+	Product(s.w, product, s.format)
+	return nil
+}
+
+// Close closes the underlying writer when the sink owns it
+func (s *Sink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
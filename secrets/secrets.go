@@ -0,0 +1,112 @@
+// Package secrets resolves credentials referenced from configuration values, so store
+// passwords, tokens and proxy credentials need not appear in plain command line flags or
+// config files.
+//
+// A reference names a provider and a key:
+//
+//	env:NAME          the environment variable NAME
+//	file:/path        the contents of a file, e.g. a Docker or Kubernetes secret mount
+//	vault:path#field  a field of a HashiCorp Vault secret, read with VAULT_ADDR and VAULT_TOKEN
+//
+// Values without a registered provider prefix are literals and are returned unchanged.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Provider looks secrets up by key
+type Provider interface {
+	Lookup(ctx context.Context, key string) (string, error)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{
+		"env":   envProvider{},
+		"file":  fileProvider{},
+		"vault": NewVaultProvider(),
+	}
+)
+
+// Register makes a provider available under a reference prefix, replacing any provider
+// registered under it before
+func Register(scheme string, provider Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[scheme] = provider
+}
+
+// parse splits a reference into its provider and key; ok is false for literals
+func parse(value string) (Provider, string, bool) {
+	scheme, key, found := strings.Cut(value, ":")
+	if !found || key == "" {
+		return nil, "", false
+	}
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	provider, ok := providers[scheme]
+	return provider, key, ok
+}
+
+// IsReference reports whether value is a secret reference rather than a literal
+func IsReference(value string) bool {
+	_, _, ok := parse(value)
+	return ok
+}
+
+// Resolve returns the secret a reference points to, or value itself when it is a literal
+func Resolve(ctx context.Context, value string) (string, error) {
+	provider, key, ok := parse(value)
+	if !ok {
+		return value, nil
+	}
+	secret, err := provider.Lookup(ctx, key)
+	if err != nil {
+		scheme, _, _ := strings.Cut(value, ":")
+		return "", fmt.Errorf("failed to resolve %s secret %s: %w", scheme, key, err)
+	}
+	return secret, nil
+}
+
+// ResolveAll resolves every reference among the values in place
+func ResolveAll(ctx context.Context, values ...*string) error {
+	for _, value := range values {
+		if value == nil || *value == "" {
+			continue
+		}
+		resolved, err := Resolve(ctx, *value)
+		if err != nil {
+			return err
+		}
+		*value = resolved
+	}
+	return nil
+}
+
+// envProvider reads secrets from environment variables
+type envProvider struct{}
+
+func (envProvider) Lookup(ctx context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable is not set")
+	}
+	return value, nil
+}
+
+// fileProvider reads secrets from files, dropping the trailing newline editors and
+// secret mounts tend to leave
+type fileProvider struct{}
+
+func (fileProvider) Lookup(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
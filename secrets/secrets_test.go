@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("STORE_PASSWORD", "hunter2")
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("s3cret\n"), 0o600))
+
+	for value, want := range map[string]string{
+		"env:STORE_PASSWORD": "hunter2",
+		"file:" + path:       "s3cret",
+		"plain-password":     "plain-password",
+		"http://proxy:3128":  "http://proxy:3128",
+		"env:":               "env:",
+	} {
+		got, err := Resolve(context.Background(), value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+
+	_, err := Resolve(context.Background(), "env:SHOPIFY_EXTRACTOR_UNSET_SECRET")
+	assert.ErrorContains(t, err, "env secret SHOPIFY_EXTRACTOR_UNSET_SECRET")
+	assert.True(t, IsReference("file:/run/secrets/token"))
+	assert.False(t, IsReference("https://example.com"))
+
+	password := "env:STORE_PASSWORD"
+	require.NoError(t, ResolveAll(context.Background(), &password, nil))
+	assert.Equal(t, "hunter2", password)
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/westside":
+			w.Write([]byte(`{"data": {"data": {"password": "kv2"}, "metadata": {"version": 3}}}`))
+		case "/v1/kv/suqah":
+			w.Write([]byte(`{"data": {"password": "kv1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	vault := &VaultProvider{Addr: server.URL, Token: "root"}
+	got, err := vault.Lookup(context.Background(), "secret/data/westside#password")
+	require.NoError(t, err)
+	assert.Equal(t, "kv2", got)
+
+	got, err = vault.Lookup(context.Background(), "kv/suqah#password")
+	require.NoError(t, err)
+	assert.Equal(t, "kv1", got)
+
+	_, err = vault.Lookup(context.Background(), "kv/suqah#token")
+	assert.Error(t, err)
+	_, err = vault.Lookup(context.Background(), "kv/missing#password")
+	assert.Error(t, err)
+	_, err = vault.Lookup(context.Background(), "kv/suqah")
+	assert.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// vaultTimeout bounds a single Vault request
+const vaultTimeout = 10 * time.Second
+
+// VaultProvider reads fields of HashiCorp Vault secrets over the HTTP API. A key is the
+// secret's API path and a field, e.g. "secret/data/westside#password" for a KV v2 engine
+// mounted at secret/.
+type VaultProvider struct {
+	Addr      string // Vault address, e.g. https://vault.internal:8200
+	Token     string
+	Namespace string // Vault Enterprise namespace ("" = none)
+	Client    *http.Client
+}
+
+// NewVaultProvider creates a provider configured from VAULT_ADDR, VAULT_TOKEN and
+// VAULT_NAMESPACE. The environment is read on every lookup when those are unset.
+func NewVaultProvider() *VaultProvider {
+	return &VaultProvider{Client: &http.Client{Timeout: vaultTimeout}}
+}
+
+// vaultResponse is the subset of a Vault read response holding secret data. KV v2
+// nests the fields in data.data, KV v1 and other engines return them in data.
+type vaultResponse struct {
+	Data map[string]json.RawMessage `json:"data"`
+}
+
+// Lookup reads one field of a secret
+func (v *VaultProvider) Lookup(ctx context.Context, key string) (string, error) {
+	path, field, ok := strings.Cut(key, "#")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("reference must be path#field")
+	}
+	addr, token, namespace := v.Addr, v.Token, v.Namespace
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if namespace == "" {
+		namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if addr == "" || token == "" {
+		return "", fmt.Errorf("VAULT_ADDR and VAULT_TOKEN must be set")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(addr, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	client := v.Client
+	if client == nil {
+		client = &http.Client{Timeout: vaultTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned status %d", resp.StatusCode)
+	}
+
+	var body vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode vault response: %w", err)
+	}
+	fields := body.Data
+	if nested, ok := fields["data"]; ok {
+		var kv2 map[string]json.RawMessage
+		if json.Unmarshal(nested, &kv2) == nil {
+			fields = kv2
+		}
+	}
+	raw, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("field %s not found", field)
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("field %s is not a string", field)
+	}
+	return value, nil
+}
@@ -0,0 +1,124 @@
+// Package smoke checks the invariants a live extraction must meet, so a scheduled canary
+// can tell when a store's theme changed under its adapter.
+package smoke
+
+import (
+	"fmt"
+	"strings"
+
+	"shopify-extractor/internal/types"
+)
+
+// Invariants a store's result is checked against
+const (
+	CheckStore      = "store"       // the store was extracted without error
+	CheckCharts     = "charts"      // at least one product has a size chart
+	CheckHeaders    = "headers"     // every chart has headers and rows
+	CheckNumeric    = "numeric"     // every measurement cell parses as a number
+	CheckSizeColumn = "size_column" // every row names its size
+)
+
+// Failure is an invariant a store's result broke
+type Failure struct {
+	Store      string `json:"store"`
+	ProductURL string `json:"product_url,omitempty"`
+	Check      string `json:"check"`
+	Message    string `json:"message"`
+}
+
+// StoreReport counts what was checked of one store
+type StoreReport struct {
+	StoreName string `json:"store_name"`
+	Products  int    `json:"products"`
+	Charts    int    `json:"charts"`
+	Passed    bool   `json:"passed"`
+}
+
+// Report is the outcome of checking an extraction result
+type Report struct {
+	Passed   bool          `json:"passed"`
+	Stores   []StoreReport `json:"stores"`
+	Failures []Failure     `json:"failures,omitempty"`
+}
+
+// Check checks every store of result against the invariants
+func Check(result *types.ExtractionResult) *Report {
+	report := &Report{Passed: true, Stores: []StoreReport{}}
+	for _, store := range result.Stores {
+		failures := checkStore(store)
+		summary := StoreReport{StoreName: store.StoreName, Products: len(store.Products), Passed: len(failures) == 0}
+		for _, product := range store.Products {
+			summary.Charts += len(product.SizeCharts)
+		}
+		report.Stores = append(report.Stores, summary)
+		report.Failures = append(report.Failures, failures...)
+	}
+	report.Passed = len(report.Failures) == 0
+	return report
+}
+
+// checkStore returns the invariants a store result broke
+func checkStore(store types.StoreResult) []Failure {
+	if store.Error != "" {
+		return []Failure{{Store: store.StoreName, Check: CheckStore, Message: store.Error}}
+	}
+
+	var failures []Failure
+	charts := 0
+	for _, product := range store.Products {
+		for _, chart := range product.SizeCharts {
+			if chart == nil {
+				continue
+			}
+			charts++
+			for _, message := range checkChart(chart) {
+				failures = append(failures, Failure{Store: store.StoreName, ProductURL: product.ProductURL, Check: message.check, Message: message.text})
+			}
+		}
+	}
+	if charts == 0 {
+		missing := 0
+		for _, products := range store.MissingCharts {
+			missing += len(products)
+		}
+		failures = append(failures, Failure{
+			Store:   store.StoreName,
+			Check:   CheckCharts,
+			Message: fmt.Sprintf("no size chart in %d products (%d without chart)", len(store.Products), missing),
+		})
+	}
+	return failures
+}
+
+type chartProblem struct {
+	check string
+	text  string
+}
+
+// checkChart returns the problems of one size chart
+func checkChart(chart *types.SizeChart) []chartProblem {
+	if len(chart.Headers) == 0 {
+		return []chartProblem{{CheckHeaders, "chart has no headers"}}
+	}
+	if len(chart.Rows) == 0 {
+		return []chartProblem{{CheckHeaders, fmt.Sprintf("chart %s has no rows", strings.Join(chart.Headers, "/"))}}
+	}
+
+	var problems []chartProblem
+	sizeColumn := chart.SizeColumn()
+	for i, row := range chart.Rows {
+		if strings.TrimSpace(row[sizeColumn]) == "" {
+			problems = append(problems, chartProblem{CheckSizeColumn, fmt.Sprintf("row %d has no %s", i+1, sizeColumn)})
+		}
+		for _, header := range chart.Headers {
+			cell := strings.TrimSpace(row[header])
+			if header == sizeColumn || cell == "" {
+				continue
+			}
+			if _, ok := types.ParseMeasurement(cell); !ok {
+				problems = append(problems, chartProblem{CheckNumeric, fmt.Sprintf("row %d: %s %q is not a number", i+1, header, cell)})
+			}
+		}
+	}
+	return problems
+}
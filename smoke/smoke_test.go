@@ -0,0 +1,61 @@
+package smoke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func chart(rows ...map[string]string) *types.SizeChart {
+	return &types.SizeChart{Headers: []string{"Size", "Bust", "Waist"}, Rows: rows}
+}
+
+func TestCheck_Passes(t *testing.T) {
+	result := &types.ExtractionResult{Stores: []types.StoreResult{{
+		StoreName: "westside.com",
+		Products: []types.Product{
+			{ProductURL: "a", SizeCharts: []*types.SizeChart{chart(
+				map[string]string{"Size": "S", "Bust": "34", "Waist": "28-30"},
+				map[string]string{"Size": "M", "Bust": "36½", "Waist": ""},
+			)}},
+			{ProductURL: "b"},
+		},
+	}}}
+
+	report := Check(result)
+	assert.True(t, report.Passed)
+	assert.Empty(t, report.Failures)
+	assert.Equal(t, []StoreReport{{StoreName: "westside.com", Products: 2, Charts: 1, Passed: true}}, report.Stores)
+}
+
+func TestCheck_Failures(t *testing.T) {
+	result := &types.ExtractionResult{Stores: []types.StoreResult{
+		{StoreName: "suqah.com", Error: "blocked"},
+		{StoreName: "littleboxindia.com", Products: []types.Product{}, MissingCharts: map[string][]types.MissingProduct{
+			types.MissingReasonNoTable: {{ProductURL: "x"}, {ProductURL: "y"}},
+		}},
+		{StoreName: "westside.com", Products: []types.Product{{ProductURL: "a", SizeCharts: []*types.SizeChart{
+			chart(map[string]string{"Size": "", "Bust": "Regular", "Waist": "30"}),
+			{Headers: []string{}},
+		}}}},
+	}}
+
+	report := Check(result)
+	assert.False(t, report.Passed)
+	require.Len(t, report.Stores, 3)
+	assert.False(t, report.Stores[0].Passed)
+
+	checks := map[string][]string{}
+	for _, failure := range report.Failures {
+		checks[failure.Store] = append(checks[failure.Store], failure.Check)
+	}
+	assert.Equal(t, map[string][]string{
+		"suqah.com":          {CheckStore},
+		"littleboxindia.com": {CheckCharts},
+		"westside.com":       {CheckSizeColumn, CheckNumeric, CheckHeaders},
+	}, checks)
+	assert.Equal(t, "no size chart in 0 products (2 without chart)", report.Failures[1].Message)
+	assert.Equal(t, `row 1: Bust "Regular" is not a number`, report.Failures[3].Message)
+}
@@ -0,0 +1,238 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// durationBuckets are the upper bounds of the product duration histogram
+var durationBuckets = []time.Duration{
+	500 * time.Millisecond,
+	1 * time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	60 * time.Second,
+}
+
+// Histogram is a fixed-bucket duration histogram safe for concurrent use
+type Histogram struct {
+	buckets []atomic.Int64 // one per durationBuckets entry plus an overflow bucket
+	count   atomic.Int64
+	sumNs   atomic.Int64
+	maxNs   atomic.Int64
+}
+
+func newHistogram() *Histogram {
+	return &Histogram{buckets: make([]atomic.Int64, len(durationBuckets)+1)}
+}
+
+// Observe records a single duration
+func (h *Histogram) Observe(d time.Duration) {
+	i := sort.Search(len(durationBuckets), func(i int) bool { return d <= durationBuckets[i] })
+	h.buckets[i].Add(1)
+	h.count.Add(1)
+	h.sumNs.Add(int64(d))
+	for {
+		current := h.maxNs.Load()
+		if int64(d) <= current || h.maxNs.CompareAndSwap(current, int64(d)) {
+			break
+		}
+	}
+}
+
+// HistogramSnapshot is a point-in-time copy of a Histogram
+type HistogramSnapshot struct {
+	Count   int64            `json:"count"`
+	Mean    time.Duration    `json:"mean_ns"`
+	Max     time.Duration    `json:"max_ns"`
+	Buckets map[string]int64 `json:"buckets"` // keyed by upper bound, "+Inf" for overflow
+}
+
+func (h *Histogram) snapshot() HistogramSnapshot {
+	snap := HistogramSnapshot{
+		Count:   h.count.Load(),
+		Max:     time.Duration(h.maxNs.Load()),
+		Buckets: make(map[string]int64, len(h.buckets)),
+	}
+	if snap.Count > 0 {
+		snap.Mean = time.Duration(h.sumNs.Load() / snap.Count)
+	}
+	for i := range h.buckets {
+		label := "+Inf"
+		if i < len(durationBuckets) {
+			label = durationBuckets[i].String()
+		}
+		snap.Buckets[label] = h.buckets[i].Load()
+	}
+	return snap
+}
+
+// Counters holds the extraction counters of one store (or of all stores combined)
+type Counters struct {
+	ProductsDiscovered atomic.Int64
+	ProductsProcessed  atomic.Int64
+	ProductsWithCharts atomic.Int64
+	ProductsFailed     atomic.Int64
+	ProductsTimedOut   atomic.Int64
+	ChartsExtracted    atomic.Int64
+	ProductDuration    *Histogram
+
+	// Charts extracted per chart parser
+	parsersMu      sync.Mutex
+	chartsByParser map[string]int64
+}
+
+func newCounters() *Counters {
+	return &Counters{ProductDuration: newHistogram(), chartsByParser: make(map[string]int64)}
+}
+
+// Snapshot is a point-in-time copy of a store's counters
+type Snapshot struct {
+	ProductsDiscovered int64             `json:"products_discovered"`
+	ProductsProcessed  int64             `json:"products_processed"`
+	ProductsWithCharts int64             `json:"products_with_charts"`
+	ProductsFailed     int64             `json:"products_failed"`
+	ProductsTimedOut   int64             `json:"products_timed_out"` // failed products that hit their deadline
+	ChartsExtracted    int64             `json:"charts_extracted"`
+	ProductDuration    HistogramSnapshot `json:"product_duration"`
+	ChartsByParser     map[string]int64  `json:"charts_by_parser"`
+}
+
+func (c *Counters) snapshot() Snapshot {
+	c.parsersMu.Lock()
+	chartsByParser := make(map[string]int64, len(c.chartsByParser))
+	for parser, count := range c.chartsByParser {
+		chartsByParser[parser] = count
+	}
+	c.parsersMu.Unlock()
+
+	return Snapshot{
+		ProductsDiscovered: c.ProductsDiscovered.Load(),
+		ProductsProcessed:  c.ProductsProcessed.Load(),
+		ProductsWithCharts: c.ProductsWithCharts.Load(),
+		ProductsFailed:     c.ProductsFailed.Load(),
+		ProductsTimedOut:   c.ProductsTimedOut.Load(),
+		ChartsExtracted:    c.ChartsExtracted.Load(),
+		ProductDuration:    c.ProductDuration.snapshot(),
+		ChartsByParser:     chartsByParser,
+	}
+}
+
+// Collector aggregates per-store and global extraction statistics. All methods are
+// safe for concurrent use by extraction workers, and a nil *Collector ignores records.
+type Collector struct {
+	mu     sync.RWMutex
+	stores map[string]*Counters
+	global *Counters
+}
+
+// NewCollector creates an empty collector
+func NewCollector() *Collector {
+	return &Collector{
+		stores: make(map[string]*Counters),
+		global: newCounters(),
+	}
+}
+
+// store returns the counters of a store, creating them on first use
+func (c *Collector) store(name string) *Counters {
+	c.mu.RLock()
+	counters, ok := c.stores[name]
+	c.mu.RUnlock()
+	if ok {
+		return counters
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if counters, ok = c.stores[name]; !ok {
+		counters = newCounters()
+		c.stores[name] = counters
+	}
+	return counters
+}
+
+// RecordDiscovered records the number of product URLs discovered for a store
+func (c *Collector) RecordDiscovered(storeName string, count int) {
+	if c == nil {
+		return
+	}
+	c.store(storeName).ProductsDiscovered.Add(int64(count))
+	c.global.ProductsDiscovered.Add(int64(count))
+}
+
+// RecordProduct records the outcome of extracting a single product. A failure wrapping
+// context.DeadlineExceeded is also counted as timed out.
+func (c *Collector) RecordProduct(storeName string, duration time.Duration, charts int, err error) {
+	if c == nil {
+		return
+	}
+	for _, counters := range []*Counters{c.store(storeName), c.global} {
+		counters.ProductsProcessed.Add(1)
+		counters.ProductDuration.Observe(duration)
+		switch {
+		case err != nil:
+			counters.ProductsFailed.Add(1)
+			if errors.Is(err, context.DeadlineExceeded) {
+				counters.ProductsTimedOut.Add(1)
+			}
+		case charts > 0:
+			counters.ProductsWithCharts.Add(1)
+			counters.ChartsExtracted.Add(int64(charts))
+		}
+	}
+}
+
+// RecordChartParsers records how many of a product's size charts each chart parser
+// extracted (see types.CountChartsByParser)
+func (c *Collector) RecordChartParsers(storeName string, chartsByParser map[string]int) {
+	if c == nil || len(chartsByParser) == 0 {
+		return
+	}
+	for _, counters := range []*Counters{c.store(storeName), c.global} {
+		counters.parsersMu.Lock()
+		for parser, count := range chartsByParser {
+			counters.chartsByParser[parser] += int64(count)
+		}
+		counters.parsersMu.Unlock()
+	}
+}
+
+// Stats is a point-in-time copy of all collected statistics
+type Stats struct {
+	Global Snapshot            `json:"global"`
+	Stores map[string]Snapshot `json:"stores"`
+}
+
+// Snapshot returns a consistent copy of the current statistics
+func (c *Collector) Snapshot() Stats {
+	if c == nil {
+		return Stats{Stores: map[string]Snapshot{}}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := Stats{
+		Global: c.global.snapshot(),
+		Stores: make(map[string]Snapshot, len(c.stores)),
+	}
+	for name, counters := range c.stores {
+		stats.Stores[name] = counters.snapshot()
+	}
+	return stats
+}
+
+// Store returns the snapshot of a single store
+func (c *Collector) Store(storeName string) Snapshot {
+	if c == nil {
+		return Snapshot{}
+	}
+	return c.store(storeName).snapshot()
+}
@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector_ConcurrentRecords(t *testing.T) {
+	c := NewCollector()
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			store := "westside.com"
+			if worker%2 == 1 {
+				store = "suqah.com"
+			}
+			for i := 0; i < 100; i++ {
+				c.RecordProduct(store, 50*time.Millisecond, 2, nil)
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	snap := c.Snapshot()
+	assert.Equal(t, int64(800), snap.Global.ProductsProcessed)
+	assert.Equal(t, int64(1600), snap.Global.ChartsExtracted)
+	assert.Equal(t, int64(400), snap.Stores["westside.com"].ProductsWithCharts)
+	assert.Equal(t, int64(400), snap.Stores["suqah.com"].ProductDuration.Count)
+	assert.Equal(t, int64(800), snap.Global.ProductDuration.Buckets["500ms"])
+}
+
+func TestCollector_Outcomes(t *testing.T) {
+	c := NewCollector()
+	c.RecordDiscovered("suqah.com", 3)
+	c.RecordProduct("suqah.com", time.Second, 1, nil)
+	c.RecordProduct("suqah.com", 2*time.Minute, 0, errors.New("timeout"))
+	c.RecordProduct("suqah.com", 3*time.Second, 0, nil)
+	c.RecordProduct("suqah.com", 45*time.Second, 0, fmt.Errorf("product timed out: %w", context.DeadlineExceeded))
+
+	snap := c.Store("suqah.com")
+	assert.Equal(t, int64(3), snap.ProductsDiscovered)
+	assert.Equal(t, int64(4), snap.ProductsProcessed)
+	assert.Equal(t, int64(1), snap.ProductsWithCharts)
+	assert.Equal(t, int64(2), snap.ProductsFailed)
+	assert.Equal(t, int64(1), snap.ProductsTimedOut)
+	assert.Equal(t, 2*time.Minute, snap.ProductDuration.Max)
+	assert.Equal(t, int64(1), snap.ProductDuration.Buckets["+Inf"])
+}
+
+func TestCollector_Nil(t *testing.T) {
+	var c *Collector
+	c.RecordProduct("westside.com", time.Second, 1, nil)
+	assert.Empty(t, c.Snapshot().Stores)
+}
+
+func TestCollector_RecordChartParsers(t *testing.T) {
+	c := NewCollector()
+	c.RecordChartParsers("westside.com", map[string]int{"kiwi": 2, "generic-table": 1})
+	c.RecordChartParsers("westside.com", map[string]int{"kiwi": 1})
+	c.RecordChartParsers("suqah.com", map[string]int{"ocr": 1})
+
+	snap := c.Snapshot()
+	assert.Equal(t, map[string]int64{"kiwi": 3, "generic-table": 1}, snap.Stores["westside.com"].ChartsByParser)
+	assert.Equal(t, map[string]int64{"kiwi": 3, "generic-table": 1, "ocr": 1}, snap.Global.ChartsByParser)
+
+	var nilCollector *Collector
+	nilCollector.RecordChartParsers("westside.com", map[string]int{"kiwi": 1})
+}
@@ -0,0 +1,132 @@
+package stats
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// progressWindow is the period over which the throughput behind ETA estimates is measured
+const progressWindow = time.Minute
+
+// Progress tracks how far one extraction job got: products discovered, completed and
+// failed, and the rolling throughput that estimates its remaining time. It is safe for
+// concurrent use, and a nil *Progress ignores records.
+type Progress struct {
+	now func() time.Time
+
+	mu         sync.Mutex
+	started    time.Time
+	discovered int64
+	completed  int64
+	failed     int64
+	recent     []time.Time // completion times within progressWindow, oldest first
+}
+
+// NewProgress creates the progress tracker of a job starting now
+func NewProgress() *Progress {
+	return newProgress(time.Now)
+}
+
+func newProgress(now func() time.Time) *Progress {
+	return &Progress{now: now, started: now()}
+}
+
+// ProgressSnapshot is a point-in-time copy of a job's progress
+type ProgressSnapshot struct {
+	Discovered int64   `json:"discovered"`
+	Completed  int64   `json:"completed"` // products extracted, with or without a chart, or failed
+	Failed     int64   `json:"failed"`
+	Percent    float64 `json:"percent"`    // completed share of the products discovered so far
+	Throughput float64 `json:"throughput"` // products completed per second over the last minute
+
+	// ETASeconds estimates the seconds left to complete the products discovered so far;
+	// nil while no product completed within the last minute
+	ETASeconds *int64 `json:"eta_seconds,omitempty"`
+
+	Elapsed time.Duration `json:"elapsed_ns"`
+}
+
+type progressKey struct{}
+
+// ContextWithProgress returns a context whose extractions record their progress to p
+func ContextWithProgress(ctx context.Context, p *Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// ProgressFrom returns the progress tracker carried by ctx, nil when there is none
+func ProgressFrom(ctx context.Context) *Progress {
+	p, _ := ctx.Value(progressKey{}).(*Progress)
+	return p
+}
+
+// RecordDiscovered records discovered product URLs
+func (p *Progress) RecordDiscovered(count int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.discovered += int64(count)
+}
+
+// RecordCompleted records a product whose extraction finished, failed or not
+func (p *Progress) RecordCompleted(failed bool) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.completed++
+	if failed {
+		p.failed++
+	}
+	p.recent = append(p.recent, p.now())
+}
+
+// Snapshot returns the job's progress and its estimated time left
+func (p *Progress) Snapshot() ProgressSnapshot {
+	if p == nil {
+		return ProgressSnapshot{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	cutoff := now.Add(-progressWindow)
+	trim := 0
+	for trim < len(p.recent) && p.recent[trim].Before(cutoff) {
+		trim++
+	}
+	p.recent = p.recent[trim:]
+
+	snap := ProgressSnapshot{
+		Discovered: p.discovered,
+		Completed:  p.completed,
+		Failed:     p.failed,
+		Elapsed:    now.Sub(p.started),
+	}
+	if p.discovered > 0 {
+		snap.Percent = math.Round(float64(p.completed)/float64(p.discovered)*1000) / 10
+	}
+
+	// Throughput over the window, or since the start while the job is younger
+	window := progressWindow
+	if snap.Elapsed < window {
+		window = snap.Elapsed
+	}
+	if window > 0 {
+		snap.Throughput = float64(len(p.recent)) / window.Seconds()
+	}
+	remaining := p.discovered - p.completed
+	switch {
+	case p.completed > 0 && remaining <= 0:
+		eta := int64(0)
+		snap.ETASeconds = &eta
+	case snap.Throughput > 0:
+		eta := int64(math.Ceil(float64(remaining) / snap.Throughput))
+		snap.ETASeconds = &eta
+	}
+	return snap
+}
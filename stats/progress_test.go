@@ -0,0 +1,52 @@
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress_Snapshot(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := newProgress(func() time.Time { return now })
+
+	snap := p.Snapshot()
+	assert.Nil(t, snap.ETASeconds, "no estimate before a product completed")
+
+	p.RecordDiscovered(100)
+	for i := 0; i < 10; i++ {
+		now = now.Add(time.Second)
+		p.RecordCompleted(i == 0)
+	}
+	snap = p.Snapshot()
+	assert.Equal(t, int64(100), snap.Discovered)
+	assert.Equal(t, int64(10), snap.Completed)
+	assert.Equal(t, int64(1), snap.Failed)
+	assert.Equal(t, 10.0, snap.Percent)
+	assert.Equal(t, 1.0, snap.Throughput)
+	require.NotNil(t, snap.ETASeconds)
+	assert.Equal(t, int64(90), *snap.ETASeconds)
+
+	// Throughput is measured over the last minute only
+	now = now.Add(2 * time.Minute)
+	snap = p.Snapshot()
+	assert.Zero(t, snap.Throughput)
+	assert.Nil(t, snap.ETASeconds)
+	for i := 0; i < 30; i++ {
+		p.RecordCompleted(false)
+	}
+	snap = p.Snapshot()
+	assert.Equal(t, 0.5, snap.Throughput)
+	assert.Equal(t, int64(120), *snap.ETASeconds)
+}
+
+func TestProgress_Context(t *testing.T) {
+	assert.Nil(t, ProgressFrom(context.Background()))
+	ProgressFrom(context.Background()).RecordCompleted(false) // a nil tracker ignores records
+
+	p := NewProgress()
+	assert.Same(t, p, ProgressFrom(ContextWithProgress(context.Background(), p)))
+}
@@ -2,154 +2,277 @@ package utils
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"strings"
 	"time"
 
-	"github.com/chromedp/chromedp"
 	"shopify-extractor/internal/types"
 )
 
-// BrowserClient provides headless browser functionality
+// Browser backends selectable through types.BrowserOptions.Backend
+const (
+	BrowserBackendChromedp = "chromedp"
+	BrowserBackendRod      = "rod"
+	BrowserBackendRender   = "render"
+)
+
+// BrowserBackend renders pages in a headless browser. Implementations navigate to the
+// URL themselves, so adapters never depend on a specific automation library.
+type BrowserBackend interface {
+	// GetPageContent returns the rendered HTML of the page
+	GetPageContent(ctx context.Context, url string) (string, error)
+
+	// GetPageContentWhenVisible returns the rendered HTML of the page once the element
+	// matching selector is visible, or when it is still missing after wait
+	GetPageContentWhenVisible(ctx context.Context, url, selector string, wait time.Duration) (string, error)
+
+	// GetPageContentScrolled returns the rendered HTML of a page that loads more items on
+	// scroll, after scrolling until the item count stops increasing, see ScrollOptions
+	GetPageContentScrolled(ctx context.Context, url string, scroll ScrollOptions) (string, error)
+
+	// ExecuteJavaScript evaluates a JavaScript expression on the page and returns its string result
+	ExecuteJavaScript(ctx context.Context, url string, script string) (string, error)
+
+	// WaitForElement waits until the element matching selector is visible
+	WaitForElement(ctx context.Context, url string, selector string) error
+
+	// GetElementText returns the text content of the element matching selector
+	GetElementText(ctx context.Context, url string, selector string) (string, error)
+
+	// GetElementAttribute returns an attribute of the element matching selector
+	GetElementAttribute(ctx context.Context, url string, selector string, attribute string) (string, error)
+
+	// CheckRender renders a trivial inline page to verify the browser works
+	CheckRender(ctx context.Context) error
+}
+
+// BrowserBackendNames lists the supported browser backends
+func BrowserBackendNames() []string {
+	return []string{BrowserBackendChromedp, BrowserBackendRod, BrowserBackendRender}
+}
+
+// UsesLocalChrome reports whether the configured backend launches a local Chrome binary
+func UsesLocalChrome(opts types.BrowserOptions) bool {
+	return strings.ToLower(opts.Backend) != BrowserBackendRender
+}
+
+// NewBrowserBackend creates the backend named by the browser options. An empty name
+// selects chromedp.
+func NewBrowserBackend(config *types.Config, logger types.Logger) (BrowserBackend, error) {
+	switch strings.ToLower(config.Browser.Backend) {
+	case "", BrowserBackendChromedp:
+		return newChromedpBackend(config, logger), nil
+	case BrowserBackendRod:
+		return newRodBackend(config, logger), nil
+	case BrowserBackendRender:
+		if config.Browser.RenderServiceURL == "" {
+			return nil, fmt.Errorf("browser backend %q requires a render service URL", BrowserBackendRender)
+		}
+		switch strings.ToLower(config.Browser.RenderServiceType) {
+		case "", RenderServiceBrowserless, RenderServiceSplash, RenderServiceGeneric:
+		default:
+			return nil, fmt.Errorf("unknown render service type %q (available: %s, %s, %s)", config.Browser.RenderServiceType, RenderServiceBrowserless, RenderServiceSplash, RenderServiceGeneric)
+		}
+		return newRenderServiceBackend(config, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown browser backend %q (available: %s)", config.Browser.Backend, strings.Join(BrowserBackendNames(), ", "))
+	}
+}
+
+// BrowserClient provides headless browser functionality on top of a BrowserBackend,
+// applying the per-host rate limiter before every navigation
 type BrowserClient struct {
-	config *types.Config
-	logger types.Logger
+	config  *types.Config
+	logger  types.Logger
+	limiter *HostLimiter
+	backend BrowserBackend
 }
 
 // NewBrowserClient creates a new browser client
 func NewBrowserClient(config *types.Config, logger types.Logger) *BrowserClient {
-	// Suppress chromedp debug logging
+	return NewBrowserClientWithLimiter(config, logger, NewConfigLimiter(config))
+}
+
+// NewBrowserClientWithLimiter creates a new browser client that shares the given per-host limiter
+func NewBrowserClientWithLimiter(config *types.Config, logger types.Logger, limiter *HostLimiter) *BrowserClient {
+	// Suppress browser library debug logging
 	log.SetOutput(io.Discard)
-	
+
+	backend, err := NewBrowserBackend(config, logger)
+	if err != nil {
+		logger.Warnf("%v, falling back to %s", err, BrowserBackendChromedp)
+		backend = newChromedpBackend(config, logger)
+	}
+
 	return &BrowserClient{
-		config: config,
-		logger: logger,
+		config:  config,
+		logger:  logger,
+		limiter: limiter,
+		backend: backend,
 	}
 }
 
-// GetPageContent retrieves the HTML content of a page using headless browser
-func (b *BrowserClient) GetPageContent(ctx context.Context, url string) (string, error) {
-	// Create a new browser context
-	browserCtx, cancel := chromedp.NewContext(ctx)
-	defer cancel()
+// CheckRender launches the browser and renders a trivial inline page, verifying that
+// Chrome can start and produce HTML in this environment
+func (b *BrowserClient) CheckRender(ctx context.Context) error {
+	return b.backend.CheckRender(ctx)
+}
 
-	// Set timeout
-	browserCtx, cancel = context.WithTimeout(browserCtx, b.config.Timeout)
-	defer cancel()
+// retry runs a browser navigation with the retry policy of retryFetch
+func (b *BrowserClient) retry(ctx context.Context, url string, navigate func() error) error {
+	return retryFetch(ctx, b.config, LoggerFrom(ctx, b.logger), b.limiter, url, func(attempt int) error {
+		return navigate()
+	})
+}
 
+// GetPageContent retrieves the HTML content of a page using headless browser
+func (b *BrowserClient) GetPageContent(ctx context.Context, url string) (string, error) {
 	var html string
-	
-	// Navigate to the page and wait for it to load
-	err := chromedp.Run(browserCtx,
-		chromedp.Navigate(url),
-		chromedp.Sleep(500*time.Millisecond), // Reduced wait time for dynamic content
-		chromedp.OuterHTML("html", &html),
-	)
-
+	err := b.retry(ctx, url, func() (err error) {
+		html, err = b.backend.GetPageContent(ctx, url)
+		return b.checkPage(ctx, url, html, err)
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to get page content: %w", err)
+		return "", err
 	}
-
-	b.logger.Debugf("Successfully retrieved page content from %s (%d bytes)", url, len(html))
 	return html, nil
 }
 
-// ExecuteJavaScript executes JavaScript code on the page
-func (b *BrowserClient) ExecuteJavaScript(ctx context.Context, url string, script string) (string, error) {
-	// Create a new browser context
-	browserCtx, cancel := chromedp.NewContext(ctx)
-	defer cancel()
-
-	// Set timeout
-	browserCtx, cancel = context.WithTimeout(browserCtx, b.config.Timeout)
-	defer cancel()
+// GetPageContentWhenVisible retrieves the HTML of a page whose content is rendered by
+// a script, e.g. a search widget, waiting up to wait for the element matching selector
+// instead of a fixed settle time. A page where it never appears is returned as is.
+func (b *BrowserClient) GetPageContentWhenVisible(ctx context.Context, url, selector string, wait time.Duration) (string, error) {
+	var html string
+	err := b.retry(ctx, url, func() (err error) {
+		html, err = b.backend.GetPageContentWhenVisible(ctx, url, selector, wait)
+		return b.checkPage(ctx, url, html, err)
+	})
+	if err != nil {
+		return "", err
+	}
+	return html, nil
+}
 
-	var result string
-	
-	// Navigate to the page and execute JavaScript
-	err := chromedp.Run(browserCtx,
-		chromedp.Navigate(url),
-		chromedp.Sleep(500*time.Millisecond),
-		chromedp.Evaluate(script, &result),
-	)
+// GetPageContentScrolled retrieves the HTML of a page that loads more items as it is
+// scrolled (infinite scroll), with the retry policy of GetPageContent
+func (b *BrowserClient) GetPageContentScrolled(ctx context.Context, url string, scroll ScrollOptions) (string, error) {
+	var html string
+	err := b.retry(ctx, url, func() (err error) {
+		html, err = b.backend.GetPageContentScrolled(ctx, url, scroll)
+		return b.checkPage(ctx, url, html, err)
+	})
+	if err != nil {
+		return "", err
+	}
+	return html, nil
+}
 
+// checkPage returns the error of a navigation, or the interstitial error when the
+// rendered page is a password, challenge or queue page
+func (b *BrowserClient) checkPage(ctx context.Context, url, html string, err error) error {
 	if err != nil {
-		return "", fmt.Errorf("failed to execute JavaScript: %w", err)
+		return err
 	}
+	if kind := interstitialKind(nil, html); kind != "" {
+		return interstitialError(b.config, LoggerFrom(ctx, b.logger), b.limiter, url, kind, nil)
+	}
+	return nil
+}
 
-	return result, nil
+// ExecuteJavaScript executes JavaScript code on the page
+func (b *BrowserClient) ExecuteJavaScript(ctx context.Context, url string, script string) (string, error) {
+	var result string
+	err := b.retry(ctx, url, func() (err error) {
+		result, err = b.backend.ExecuteJavaScript(ctx, url, script)
+		return err
+	})
+	return result, err
 }
 
 // WaitForElement waits for a specific element to appear on the page
 func (b *BrowserClient) WaitForElement(ctx context.Context, url string, selector string) error {
-	// Create a new browser context
-	browserCtx, cancel := chromedp.NewContext(ctx)
-	defer cancel()
-
-	// Set timeout
-	browserCtx, cancel = context.WithTimeout(browserCtx, b.config.Timeout)
-	defer cancel()
-
-	// Navigate to the page and wait for element
-	err := chromedp.Run(browserCtx,
-		chromedp.Navigate(url),
-		chromedp.WaitVisible(selector),
-	)
-
-	if err != nil {
-		return fmt.Errorf("failed to wait for element %s: %w", selector, err)
-	}
-
-	return nil
+	return b.retry(ctx, url, func() error {
+		return b.backend.WaitForElement(ctx, url, selector)
+	})
 }
 
 // GetElementText retrieves the text content of a specific element
 func (b *BrowserClient) GetElementText(ctx context.Context, url string, selector string) (string, error) {
-	// Create a new browser context
-	browserCtx, cancel := chromedp.NewContext(ctx)
-	defer cancel()
-
-	// Set timeout
-	browserCtx, cancel = context.WithTimeout(browserCtx, b.config.Timeout)
-	defer cancel()
-
 	var text string
-	
-	// Navigate to the page and get element text
-	err := chromedp.Run(browserCtx,
-		chromedp.Navigate(url),
-		chromedp.Text(selector, &text),
-	)
-
-	if err != nil {
-		return "", fmt.Errorf("failed to get element text for %s: %w", selector, err)
-	}
-
-	return text, nil
+	err := b.retry(ctx, url, func() (err error) {
+		text, err = b.backend.GetElementText(ctx, url, selector)
+		return err
+	})
+	return text, err
 }
 
 // GetElementAttribute retrieves the value of a specific attribute of an element
 func (b *BrowserClient) GetElementAttribute(ctx context.Context, url string, selector string, attribute string) (string, error) {
-	// Create a new browser context
-	browserCtx, cancel := chromedp.NewContext(ctx)
-	defer cancel()
+	var value string
+	err := b.retry(ctx, url, func() (err error) {
+		value, err = b.backend.GetElementAttribute(ctx, url, selector, attribute)
+		return err
+	})
+	return value, err
+}
 
-	// Set timeout
-	browserCtx, cancel = context.WithTimeout(browserCtx, b.config.Timeout)
-	defer cancel()
+// ScrollOptions drive the scrolling of a page that loads more items on scroll
+type ScrollOptions struct {
+	// Items matches the loaded items, e.g. the product links of a grid. The page is
+	// scrolled to the last item until their count stops increasing.
+	Items string
 
-	var value string
-	
-	// Navigate to the page and get element attribute
-	err := chromedp.Run(browserCtx,
-		chromedp.Navigate(url),
-		chromedp.AttributeValue(selector, attribute, &value, nil),
-	)
+	// Wait bounds the wait for the first item to be visible, see GetPageContentWhenVisible
+	Wait time.Duration
 
-	if err != nil {
-		return "", fmt.Errorf("failed to get attribute %s for %s: %w", attribute, selector, err)
+	// Settle is the time given to the page to load more items after each scroll
+	Settle time.Duration
+
+	// MaxScrolls caps the number of scrolls
+	MaxScrolls int
+}
+
+// scrollExpression is a JavaScript expression that scrolls the last item matching
+// selector into view, and the window to the bottom, and returns the number of items
+func scrollExpression(selector string) string {
+	quoted, _ := json.Marshal(selector)
+	return `(() => {
+	const items = document.querySelectorAll(` + string(quoted) + `);
+	if (items.length > 0) {
+		items[items.length - 1].scrollIntoView({block: "end"});
 	}
+	window.scrollTo(0, document.body.scrollHeight);
+	return items.length;
+})()`
+}
 
-	return value, nil
-} 
\ No newline at end of file
+// scrollUntilStable scrolls with scroll, which scrolls once and returns the item count
+// before scrolling, until the count stops increasing, MaxScrolls is reached or ctx
+// has no time left for another settle period. It returns the last item count.
+func scrollUntilStable(ctx context.Context, opts ScrollOptions, scroll func() (int, error)) (int, error) {
+	count := -1
+	for i := 0; i <= opts.MaxScrolls; i++ {
+		n, err := scroll()
+		if err != nil {
+			return count, err
+		}
+		if n <= count {
+			break
+		}
+		count = n
+		if i == opts.MaxScrolls {
+			break
+		}
+		// Keep enough of the navigation deadline to capture the page
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < 2*opts.Settle {
+			break
+		}
+		if err := sleepContext(ctx, opts.Settle); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,357 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"shopify-extractor/internal/types"
+)
+
+// chromedpBackend drives Chrome through the DevTools protocol with chromedp.
+// Every call launches a fresh browser with the configured launch options.
+type chromedpBackend struct {
+	config *types.Config
+	logger types.Logger
+}
+
+// newChromedpBackend creates the chromedp browser backend
+func newChromedpBackend(config *types.Config, logger types.Logger) *chromedpBackend {
+	return &chromedpBackend{
+		config: config,
+		logger: logger,
+	}
+}
+
+// newContext launches a browser with the configured launch options
+func (b *chromedpBackend) newContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx, allocatorOptions(b.config.Browser, b.config.UserAgent)...)
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	return browserCtx, func() {
+		cancelBrowser()
+		cancelAlloc()
+	}
+}
+
+// stealthTasks prepares a new tab for stealth mode before navigation: the stealth script
+// runs ahead of page scripts and the timezone and locale are overridden
+func (b *chromedpBackend) stealthTasks() chromedp.Tasks {
+	opts := b.config.Browser
+	if !opts.Stealth {
+		return nil
+	}
+
+	tasks := chromedp.Tasks{
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			_, err := page.AddScriptToEvaluateOnNewDocument(stealthScript(opts)).Do(ctx)
+			return err
+		}),
+	}
+	if opts.Timezone != "" {
+		tasks = append(tasks, emulation.SetTimezoneOverride(opts.Timezone))
+	}
+	if opts.Language != "" {
+		tasks = append(tasks, emulation.SetLocaleOverride().WithLocale(opts.Language))
+	}
+	return tasks
+}
+
+// headerTasks sends the store's configured headers with every request of the tab
+func (b *chromedpBackend) headerTasks(url string) chromedp.Tasks {
+	headers := requestHeaders(b.config, url)
+	if len(headers) == 0 {
+		return nil
+	}
+
+	extra := make(network.Headers, len(headers))
+	for name, value := range headers {
+		extra[name] = value
+	}
+	return chromedp.Tasks{network.Enable(), network.SetExtraHTTPHeaders(extra)}
+}
+
+// authTasks answers HTTP authentication challenges of the tab with the store's basic
+// auth credentials, so protected pages load instead of stalling on the login prompt
+func (b *chromedpBackend) authTasks(browserCtx context.Context, url string) chromedp.Tasks {
+	auth := StoreOptionsFor(b.config, url).BasicAuth
+	if auth == nil {
+		return nil
+	}
+
+	chromedp.ListenTarget(browserCtx, func(ev interface{}) {
+		switch ev := ev.(type) {
+		case *fetch.EventRequestPaused:
+			go chromedp.Run(browserCtx, fetch.ContinueRequest(ev.RequestID))
+		case *fetch.EventAuthRequired:
+			go chromedp.Run(browserCtx, fetch.ContinueWithAuth(ev.RequestID, &fetch.AuthChallengeResponse{
+				Response: fetch.AuthChallengeResponseResponseProvideCredentials,
+				Username: auth.Username,
+				Password: auth.Password,
+			}))
+		}
+	})
+	return chromedp.Tasks{fetch.Enable().WithHandleAuthRequests(true)}
+}
+
+// CheckRender launches the browser and renders a trivial inline page, verifying that
+// Chrome can start and produce HTML in this environment
+func (b *chromedpBackend) CheckRender(ctx context.Context) error {
+	browserCtx, cancel := b.newContext(ctx)
+	defer cancel()
+
+	browserCtx, cancel = context.WithTimeout(browserCtx, b.config.Timeout)
+	defer cancel()
+
+	var title string
+	err := chromedp.Run(browserCtx,
+		b.stealthTasks(),
+		chromedp.Navigate("data:text/html,<html><head><title>ready</title></head><body>ok</body></html>"),
+		chromedp.Title(&title),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to render test page: %w", err)
+	}
+	if title != "ready" {
+		return fmt.Errorf("unexpected test page title %q", title)
+	}
+	return nil
+}
+
+// GetPageContent retrieves the HTML content of a page
+func (b *chromedpBackend) GetPageContent(ctx context.Context, url string) (string, error) {
+	// Create a new browser context
+	browserCtx, cancel := b.newContext(ctx)
+	defer cancel()
+
+	// Set timeout
+	browserCtx, cancel = context.WithTimeout(browserCtx, b.config.Timeout)
+	defer cancel()
+
+	var html string
+	
+	// Navigate to the page and wait for it to load
+	err := chromedp.Run(browserCtx,
+		b.stealthTasks(),
+		b.headerTasks(url),
+		b.authTasks(browserCtx, url),
+		chromedp.Navigate(url),
+		chromedp.Sleep(500*time.Millisecond), // Reduced wait time for dynamic content
+		chromedp.OuterHTML("html", &html),
+	)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to get page content: %w", err)
+	}
+
+	LoggerFrom(ctx, b.logger).Debugf("Successfully retrieved page content from %s (%d bytes)", url, len(html))
+	return html, nil
+}
+
+// GetPageContentWhenVisible retrieves the HTML content of a page once selector is visible,
+// or after wait when it never shows up
+func (b *chromedpBackend) GetPageContentWhenVisible(ctx context.Context, url, selector string, wait time.Duration) (string, error) {
+	browserCtx, cancel := b.newContext(ctx)
+	defer cancel()
+
+	browserCtx, cancel = context.WithTimeout(browserCtx, b.config.Timeout)
+	defer cancel()
+
+	var html string
+	visible := true
+	err := chromedp.Run(browserCtx,
+		b.stealthTasks(),
+		b.headerTasks(url),
+		b.authTasks(browserCtx, url),
+		chromedp.Navigate(url),
+		waitVisibleAction(selector, wait, &visible),
+		chromedp.OuterHTML("html", &html),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to get page content: %w", err)
+	}
+
+	logger := LoggerFrom(ctx, b.logger)
+	if !visible {
+		logger.Debugf("%s not visible on %s after %v, using the page as rendered", selector, url, wait)
+	}
+	logger.Debugf("Successfully retrieved page content from %s (%d bytes)", url, len(html))
+	return html, nil
+}
+
+// waitVisibleAction waits up to wait for selector to be visible, clearing visible when
+// it is not, so a page without the element is still captured
+func waitVisibleAction(selector string, wait time.Duration, visible *bool) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		waitCtx, cancel := context.WithTimeout(ctx, wait)
+		defer cancel()
+		if err := chromedp.WaitVisible(selector).Do(waitCtx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			*visible = false
+		}
+		return nil
+	})
+}
+
+// GetPageContentScrolled retrieves the HTML content of a page after scrolling it until
+// no more items load
+func (b *chromedpBackend) GetPageContentScrolled(ctx context.Context, url string, scroll ScrollOptions) (string, error) {
+	browserCtx, cancel := b.newContext(ctx)
+	defer cancel()
+
+	browserCtx, cancel = context.WithTimeout(browserCtx, b.config.Timeout)
+	defer cancel()
+
+	var html string
+	visible := true
+	items := 0
+	err := chromedp.Run(browserCtx,
+		b.stealthTasks(),
+		b.headerTasks(url),
+		b.authTasks(browserCtx, url),
+		chromedp.Navigate(url),
+		waitVisibleAction(scroll.Items, scroll.Wait, &visible),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if !visible {
+				return nil
+			}
+			var err error
+			items, err = scrollUntilStable(ctx, scroll, func() (int, error) {
+				var n int
+				err := chromedp.Evaluate(scrollExpression(scroll.Items), &n).Do(ctx)
+				return n, err
+			})
+			return err
+		}),
+		chromedp.OuterHTML("html", &html),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to get page content: %w", err)
+	}
+
+	logger := LoggerFrom(ctx, b.logger)
+	if !visible {
+		logger.Debugf("%s not visible on %s after %v, using the page as rendered", scroll.Items, url, scroll.Wait)
+	} else {
+		logger.Debugf("Scrolled %s until %d items loaded", url, items)
+	}
+	logger.Debugf("Successfully retrieved page content from %s (%d bytes)", url, len(html))
+	return html, nil
+}
+
+// ExecuteJavaScript executes JavaScript code on the page
+func (b *chromedpBackend) ExecuteJavaScript(ctx context.Context, url string, script string) (string, error) {
+	// Create a new browser context
+	browserCtx, cancel := b.newContext(ctx)
+	defer cancel()
+
+	// Set timeout
+	browserCtx, cancel = context.WithTimeout(browserCtx, b.config.Timeout)
+	defer cancel()
+
+	var result string
+	
+	// Navigate to the page and execute JavaScript
+	err := chromedp.Run(browserCtx,
+		b.stealthTasks(),
+		b.headerTasks(url),
+		b.authTasks(browserCtx, url),
+		chromedp.Navigate(url),
+		chromedp.Sleep(500*time.Millisecond),
+		chromedp.Evaluate(script, &result),
+	)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to execute JavaScript: %w", err)
+	}
+
+	return result, nil
+}
+
+// WaitForElement waits for a specific element to appear on the page
+func (b *chromedpBackend) WaitForElement(ctx context.Context, url string, selector string) error {
+	// Create a new browser context
+	browserCtx, cancel := b.newContext(ctx)
+	defer cancel()
+
+	// Set timeout
+	browserCtx, cancel = context.WithTimeout(browserCtx, b.config.Timeout)
+	defer cancel()
+
+	// Navigate to the page and wait for element
+	err := chromedp.Run(browserCtx,
+		b.stealthTasks(),
+		b.headerTasks(url),
+		b.authTasks(browserCtx, url),
+		chromedp.Navigate(url),
+		chromedp.WaitVisible(selector),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to wait for element %s: %w", selector, err)
+	}
+
+	return nil
+}
+
+// GetElementText retrieves the text content of a specific element
+func (b *chromedpBackend) GetElementText(ctx context.Context, url string, selector string) (string, error) {
+	// Create a new browser context
+	browserCtx, cancel := b.newContext(ctx)
+	defer cancel()
+
+	// Set timeout
+	browserCtx, cancel = context.WithTimeout(browserCtx, b.config.Timeout)
+	defer cancel()
+
+	var text string
+	
+	// Navigate to the page and get element text
+	err := chromedp.Run(browserCtx,
+		b.stealthTasks(),
+		b.headerTasks(url),
+		b.authTasks(browserCtx, url),
+		chromedp.Navigate(url),
+		chromedp.Text(selector, &text),
+	)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to get element text for %s: %w", selector, err)
+	}
+
+	return text, nil
+}
+
+// GetElementAttribute retrieves the value of a specific attribute of an element
+func (b *chromedpBackend) GetElementAttribute(ctx context.Context, url string, selector string, attribute string) (string, error) {
+	// Create a new browser context
+	browserCtx, cancel := b.newContext(ctx)
+	defer cancel()
+
+	// Set timeout
+	browserCtx, cancel = context.WithTimeout(browserCtx, b.config.Timeout)
+	defer cancel()
+
+	var value string
+	
+	// Navigate to the page and get element attribute
+	err := chromedp.Run(browserCtx,
+		b.stealthTasks(),
+		b.headerTasks(url),
+		b.authTasks(browserCtx, url),
+		chromedp.Navigate(url),
+		chromedp.AttributeValue(selector, attribute, &value, nil),
+	)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to get attribute %s for %s: %w", attribute, selector, err)
+	}
+
+	return value, nil
+} 
\ No newline at end of file
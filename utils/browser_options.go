@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+	"shopify-extractor/internal/types"
+)
+
+// LoadBrowserOptions applies CHROME_* environment variables on top of opts:
+//
+//	CHROME_HEADFUL         true shows the browser window (CHROME_HEADLESS=false is still accepted)
+//	CHROME_NEW_HEADLESS    true/false
+//	CHROME_NO_SANDBOX      true/false
+//	CHROME_WINDOW_SIZE     WIDTHxHEIGHT, e.g. 1280x800
+//	CHROME_USER_DATA_DIR   profile directory
+//	CHROME_PROXY           proxy server URL
+//	CHROME_LANG            browser language, e.g. en-US
+//	CHROME_FLAGS           comma-separated extra switches, e.g. --disable-gpu,--foo=bar
+//	CHROME_PATH            Chrome binary to launch
+//	BROWSER_BACKEND        chromedp (default), rod or render
+//	CHROME_STEALTH         true enables headless detection countermeasures
+//	CHROME_TIMEZONE        IANA timezone reported in stealth mode, e.g. Asia/Kolkata
+//	RENDER_SERVICE_URL     rendering service base URL; selects the render backend when BROWSER_BACKEND is unset
+//	RENDER_SERVICE_TYPE    browserless, splash or generic (default)
+//	RENDER_SERVICE_TOKEN   rendering service API token
+//	CONTAINER_MODE         true applies ContainerBrowserOptions
+func LoadBrowserOptions(opts types.BrowserOptions) types.BrowserOptions {
+	if v, ok := envBool("CONTAINER_MODE"); ok && v {
+		opts = ContainerBrowserOptions(opts)
+	}
+	if v, ok := envBool("CHROME_HEADFUL"); ok {
+		opts.Headful = v
+	} else if v, ok := envBool("CHROME_HEADLESS"); ok {
+		opts.Headful = !v
+	}
+	if v, ok := envBool("CHROME_NEW_HEADLESS"); ok {
+		opts.NewHeadless = v
+	}
+	if v, ok := envBool("CHROME_NO_SANDBOX"); ok {
+		opts.NoSandbox = v
+	}
+	if size := os.Getenv("CHROME_WINDOW_SIZE"); size != "" {
+		if width, height, ok := ParseWindowSize(size); ok {
+			opts.WindowWidth, opts.WindowHeight = width, height
+		}
+	}
+	if dir := os.Getenv("CHROME_USER_DATA_DIR"); dir != "" {
+		opts.UserDataDir = dir
+	}
+	if proxy := os.Getenv("CHROME_PROXY"); proxy != "" {
+		opts.ProxyServer = proxy
+	}
+	if lang := os.Getenv("CHROME_LANG"); lang != "" {
+		opts.Language = lang
+	}
+	if flags := os.Getenv("CHROME_FLAGS"); flags != "" {
+		for _, flag := range strings.Split(flags, ",") {
+			if flag = strings.TrimSpace(flag); flag != "" {
+				opts.ExtraFlags = append(opts.ExtraFlags, flag)
+			}
+		}
+	}
+	if path := os.Getenv("CHROME_PATH"); path != "" {
+		opts.ExecPath = path
+	}
+	if v, ok := envBool("CHROME_STEALTH"); ok {
+		opts.Stealth = v
+	}
+	if tz := os.Getenv("CHROME_TIMEZONE"); tz != "" {
+		opts.Timezone = tz
+	}
+	if backend := os.Getenv("BROWSER_BACKEND"); backend != "" {
+		opts.Backend = strings.ToLower(strings.TrimSpace(backend))
+	}
+	if serviceURL := os.Getenv("RENDER_SERVICE_URL"); serviceURL != "" {
+		opts.RenderServiceURL = serviceURL
+		if opts.Backend == "" {
+			opts.Backend = BrowserBackendRender
+		}
+	}
+	if serviceType := os.Getenv("RENDER_SERVICE_TYPE"); serviceType != "" {
+		opts.RenderServiceType = strings.ToLower(strings.TrimSpace(serviceType))
+	}
+	if token := os.Getenv("RENDER_SERVICE_TOKEN"); token != "" {
+		opts.RenderServiceToken = token
+	}
+	return opts
+}
+
+// ContainerBrowserOptions tunes opts for running inside a container: the Chrome sandbox
+// needs privileges containers rarely grant, /dev/shm is usually too small for Chrome's
+// shared memory and there is no GPU.
+func ContainerBrowserOptions(opts types.BrowserOptions) types.BrowserOptions {
+	opts.NoSandbox = true
+	for _, flag := range []string{"--disable-dev-shm-usage", "--disable-gpu"} {
+		if !containsString(opts.ExtraFlags, flag) {
+			opts.ExtraFlags = append(opts.ExtraFlags, flag)
+		}
+	}
+	return opts
+}
+
+// chromeCandidates are the binaries searched for when no ExecPath is configured
+var chromeCandidates = []string{
+	"headless-shell",
+	"chromium",
+	"chromium-browser",
+	"google-chrome",
+	"google-chrome-stable",
+	"google-chrome-beta",
+	"chrome",
+	"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+	"/Applications/Chromium.app/Contents/MacOS/Chromium",
+}
+
+// FindChrome returns the Chrome binary that will be launched for opts, or an error
+// explaining how to make one available
+func FindChrome(opts types.BrowserOptions) (string, error) {
+	if opts.ExecPath != "" {
+		path, err := exec.LookPath(opts.ExecPath)
+		if err != nil {
+			return "", fmt.Errorf("chrome binary %q from CHROME_PATH is not usable: %w", opts.ExecPath, err)
+		}
+		return path, nil
+	}
+	for _, candidate := range chromeCandidates {
+		if path, err := exec.LookPath(candidate); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no Chrome or Chromium binary found in PATH; install one, set CHROME_PATH, or disable the headless browser")
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseWindowSize parses a WIDTHxHEIGHT string
+func ParseWindowSize(size string) (int, int, bool) {
+	parts := strings.SplitN(strings.ToLower(strings.TrimSpace(size)), "x", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	width, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || width <= 0 {
+		return 0, 0, false
+	}
+	height, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// envBool reads a boolean environment variable, reporting whether it was set and valid
+func envBool(name string) (bool, bool) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return false, false
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, false
+	}
+	return v, true
+}
+
+// parseFlag splits a command line switch such as --foo=bar into its name and value.
+// Switches without a value are enabled with true.
+func parseFlag(flag string) (string, interface{}) {
+	flag = strings.TrimLeft(strings.TrimSpace(flag), "-")
+	if name, value, ok := strings.Cut(flag, "="); ok {
+		return name, value
+	}
+	return flag, true
+}
+
+// allocatorOptions converts browser options into chromedp allocator options
+func allocatorOptions(opts types.BrowserOptions, userAgent string) []chromedp.ExecAllocatorOption {
+	allocOpts := append([]chromedp.ExecAllocatorOption{}, chromedp.DefaultExecAllocatorOptions[:]...)
+
+	switch {
+	case opts.Headful:
+		allocOpts = append(allocOpts, chromedp.Flag("headless", false), chromedp.Flag("hide-scrollbars", false), chromedp.Flag("mute-audio", false))
+	case opts.NewHeadless:
+		allocOpts = append(allocOpts, chromedp.Flag("headless", "new"))
+	}
+	if opts.ExecPath != "" {
+		allocOpts = append(allocOpts, chromedp.ExecPath(opts.ExecPath))
+	}
+	if opts.NoSandbox {
+		allocOpts = append(allocOpts, chromedp.NoSandbox)
+	}
+	if opts.WindowWidth > 0 && opts.WindowHeight > 0 {
+		allocOpts = append(allocOpts, chromedp.WindowSize(opts.WindowWidth, opts.WindowHeight))
+	}
+	if opts.UserDataDir != "" {
+		allocOpts = append(allocOpts, chromedp.UserDataDir(opts.UserDataDir))
+	}
+	if opts.ProxyServer != "" {
+		allocOpts = append(allocOpts, chromedp.ProxyServer(opts.ProxyServer))
+	}
+	if opts.Language != "" {
+		allocOpts = append(allocOpts, chromedp.Flag("lang", opts.Language))
+	}
+	if opts.Stealth {
+		for name, value := range stealthFlags(userAgent) {
+			allocOpts = append(allocOpts, chromedp.Flag(name, value))
+		}
+	}
+	for _, flag := range opts.ExtraFlags {
+		if name, value := parseFlag(flag); name != "" {
+			allocOpts = append(allocOpts, chromedp.Flag(name, value))
+		}
+	}
+	return allocOpts
+}
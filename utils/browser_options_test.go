@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"shopify-extractor/internal/types"
+)
+
+func TestLoadBrowserOptions(t *testing.T) {
+	t.Setenv("CHROME_HEADFUL", "true")
+	t.Setenv("CHROME_NO_SANDBOX", "true")
+	t.Setenv("CHROME_WINDOW_SIZE", "1280x800")
+	t.Setenv("CHROME_PROXY", "http://proxy:3128")
+	t.Setenv("CHROME_LANG", "en-IN")
+	t.Setenv("CHROME_FLAGS", "--disable-gpu, --foo=bar")
+
+	opts := LoadBrowserOptions(types.DefaultBrowserOptions())
+
+	assert.True(t, opts.Headful)
+	assert.True(t, opts.NoSandbox)
+	assert.Equal(t, 1280, opts.WindowWidth)
+	assert.Equal(t, 800, opts.WindowHeight)
+	assert.Equal(t, "http://proxy:3128", opts.ProxyServer)
+	assert.Equal(t, "en-IN", opts.Language)
+	assert.Equal(t, []string{"--disable-gpu", "--foo=bar"}, opts.ExtraFlags)
+}
+
+func TestLoadBrowserOptions_InvalidValuesKeepDefaults(t *testing.T) {
+	t.Setenv("CHROME_HEADFUL", "maybe")
+	t.Setenv("CHROME_WINDOW_SIZE", "wide")
+
+	opts := LoadBrowserOptions(types.DefaultBrowserOptions())
+
+	assert.Equal(t, types.DefaultBrowserOptions(), opts)
+}
+
+func TestLoadBrowserOptions_Headful(t *testing.T) {
+	// CHROME_HEADLESS keeps working, inverted, when CHROME_HEADFUL is unset
+	t.Setenv("CHROME_HEADLESS", "false")
+	assert.True(t, LoadBrowserOptions(types.DefaultBrowserOptions()).Headful)
+
+	t.Setenv("CHROME_HEADFUL", "false")
+	assert.False(t, LoadBrowserOptions(types.DefaultBrowserOptions()).Headful)
+}
+
+func TestParseFlag(t *testing.T) {
+	name, value := parseFlag("--disable-gpu")
+	assert.Equal(t, "disable-gpu", name)
+	assert.Equal(t, true, value)
+
+	name, value = parseFlag("--proxy-bypass-list=*.local")
+	assert.Equal(t, "proxy-bypass-list", name)
+	assert.Equal(t, "*.local", value)
+}
+
+func TestContainerBrowserOptions(t *testing.T) {
+	opts := types.DefaultBrowserOptions()
+	opts.ExtraFlags = []string{"--disable-gpu"}
+
+	opts = ContainerBrowserOptions(opts)
+
+	assert.True(t, opts.NoSandbox)
+	assert.Equal(t, []string{"--disable-gpu", "--disable-dev-shm-usage"}, opts.ExtraFlags)
+}
+
+func TestFindChrome_MissingExecPath(t *testing.T) {
+	opts := types.DefaultBrowserOptions()
+	opts.ExecPath = "/nonexistent/chrome"
+
+	_, err := FindChrome(opts)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "CHROME_PATH")
+}
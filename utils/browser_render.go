@@ -0,0 +1,280 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"shopify-extractor/internal/types"
+)
+
+// Rendering services supported by the "render" backend
+const (
+	RenderServiceBrowserless = "browserless"
+	RenderServiceSplash      = "splash"
+	RenderServiceGeneric     = "generic"
+)
+
+// maxRenderedPageBytes caps the HTML accepted from a rendering service
+const maxRenderedPageBytes = 20 << 20
+
+// renderServiceBackend fetches rendered HTML from an external rendering service, so
+// the extractor can run on hosts without Chrome. Element queries are answered from
+// the rendered HTML; arbitrary JavaScript is not supported.
+type renderServiceBackend struct {
+	config *types.Config
+	logger types.Logger
+	client *http.Client
+}
+
+// newRenderServiceBackend creates the rendering service backend
+func newRenderServiceBackend(config *types.Config, logger types.Logger) *renderServiceBackend {
+	return &renderServiceBackend{
+		config: config,
+		logger: logger,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// serviceType returns the configured service type, defaulting to generic
+func (b *renderServiceBackend) serviceType() string {
+	if t := strings.ToLower(b.config.Browser.RenderServiceType); t != "" {
+		return t
+	}
+	return RenderServiceGeneric
+}
+
+// endpoint joins path onto the service base URL, adding the browserless token
+func (b *renderServiceBackend) endpoint(path string) (string, error) {
+	base := strings.TrimRight(b.config.Browser.RenderServiceURL, "/")
+	if base == "" {
+		return "", fmt.Errorf("render service URL is not configured (set RENDER_SERVICE_URL)")
+	}
+	endpoint, err := url.Parse(base + path)
+	if err != nil {
+		return "", fmt.Errorf("invalid render service URL: %w", err)
+	}
+	if b.serviceType() == RenderServiceBrowserless {
+		query := endpoint.Query()
+		if b.config.Browser.RenderServiceToken != "" {
+			query.Set("token", b.config.Browser.RenderServiceToken)
+		}
+		// browserless applies its own headless detection countermeasures
+		if b.config.Browser.Stealth {
+			query.Set("stealth", "true")
+		}
+		endpoint.RawQuery = query.Encode()
+	}
+	return endpoint.String(), nil
+}
+
+// renderRequest builds the service-specific request that renders pageURL
+func (b *renderServiceBackend) renderRequest(ctx context.Context, pageURL string) (*http.Request, error) {
+	var (
+		path    string
+		payload map[string]interface{}
+	)
+	headers := requestHeaders(b.config, pageURL)
+	switch b.serviceType() {
+	case RenderServiceBrowserless:
+		path = "/content"
+		payload = map[string]interface{}{
+			"url":         pageURL,
+			"gotoOptions": map[string]interface{}{"waitUntil": "networkidle2", "timeout": b.config.Timeout.Milliseconds()},
+		}
+		if len(headers) > 0 {
+			payload["setExtraHTTPHeaders"] = headers
+		}
+	case RenderServiceSplash:
+		path = "/render.html"
+		payload = map[string]interface{}{
+			"url":     pageURL,
+			"wait":    0.5,
+			"timeout": b.config.Timeout.Seconds(),
+		}
+		if len(headers) > 0 {
+			payload["headers"] = headers
+		}
+	case RenderServiceGeneric:
+		payload = map[string]interface{}{"url": pageURL}
+		if len(headers) > 0 {
+			payload["headers"] = headers
+		}
+	default:
+		return nil, fmt.Errorf("unknown render service type %q", b.config.Browser.RenderServiceType)
+	}
+
+	endpoint, err := b.endpoint(path)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode render request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create render request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/html, application/json")
+	if token := b.config.Browser.RenderServiceToken; token != "" && b.serviceType() != RenderServiceBrowserless {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+// render asks the service to render pageURL and returns the resulting HTML
+func (b *renderServiceBackend) render(ctx context.Context, pageURL string) (string, error) {
+	req, err := b.renderRequest(ctx, pageURL)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("render request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRenderedPageBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read rendered page: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("render service returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	// Generic services may wrap the HTML in a JSON envelope
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		var envelope struct {
+			HTML    string `json:"html"`
+			Content string `json:"content"`
+		}
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			return "", fmt.Errorf("failed to decode render response: %w", err)
+		}
+		if envelope.HTML != "" {
+			return envelope.HTML, nil
+		}
+		return envelope.Content, nil
+	}
+	return string(body), nil
+}
+
+// renderDocument renders pageURL and parses it for element queries
+func (b *renderServiceBackend) renderDocument(ctx context.Context, pageURL string) (*goquery.Document, error) {
+	html, err := b.render(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rendered page: %w", err)
+	}
+	return doc, nil
+}
+
+// CheckRender verifies that the rendering service is reachable
+func (b *renderServiceBackend) CheckRender(ctx context.Context) error {
+	path := "/"
+	switch b.serviceType() {
+	case RenderServiceBrowserless:
+		path = "/json/version"
+	case RenderServiceSplash:
+		path = "/_ping"
+	}
+	endpoint, err := b.endpoint(path)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create render service check: %w", err)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("render service unreachable: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("render service unhealthy: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// GetPageContent retrieves the HTML content of a page
+func (b *renderServiceBackend) GetPageContent(ctx context.Context, url string) (string, error) {
+	html, err := b.render(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("failed to get page content: %w", err)
+	}
+
+	LoggerFrom(ctx, b.logger).Debugf("Successfully retrieved page content from %s via render service (%d bytes)", url, len(html))
+	return html, nil
+}
+
+// GetPageContentWhenVisible retrieves the HTML content of a page. Rendering services
+// return pages once they finished loading, so there is no element to wait for.
+func (b *renderServiceBackend) GetPageContentWhenVisible(ctx context.Context, url, selector string, wait time.Duration) (string, error) {
+	return b.GetPageContent(ctx, url)
+}
+
+// GetPageContentScrolled retrieves the HTML content of a page. Rendering services cannot
+// interact with pages, so only the items loaded without scrolling are included.
+func (b *renderServiceBackend) GetPageContentScrolled(ctx context.Context, url string, scroll ScrollOptions) (string, error) {
+	return b.GetPageContent(ctx, url)
+}
+
+// ExecuteJavaScript is not supported by rendering services
+func (b *renderServiceBackend) ExecuteJavaScript(ctx context.Context, url string, script string) (string, error) {
+	return "", fmt.Errorf("failed to execute JavaScript: not supported by the %s render service", b.serviceType())
+}
+
+// WaitForElement checks that the element is present in the rendered page
+func (b *renderServiceBackend) WaitForElement(ctx context.Context, url string, selector string) error {
+	doc, err := b.renderDocument(ctx, url)
+	if err != nil {
+		return fmt.Errorf("failed to wait for element %s: %w", selector, err)
+	}
+	if doc.Find(selector).Length() == 0 {
+		return fmt.Errorf("failed to wait for element %s: not present in rendered page", selector)
+	}
+	return nil
+}
+
+// GetElementText retrieves the text content of a specific element
+func (b *renderServiceBackend) GetElementText(ctx context.Context, url string, selector string) (string, error) {
+	doc, err := b.renderDocument(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("failed to get element text for %s: %w", selector, err)
+	}
+	selection := doc.Find(selector).First()
+	if selection.Length() == 0 {
+		return "", fmt.Errorf("failed to get element text for %s: not present in rendered page", selector)
+	}
+	return selection.Text(), nil
+}
+
+// GetElementAttribute retrieves the value of a specific attribute of an element
+func (b *renderServiceBackend) GetElementAttribute(ctx context.Context, url string, selector string, attribute string) (string, error) {
+	doc, err := b.renderDocument(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("failed to get attribute %s for %s: %w", attribute, selector, err)
+	}
+	selection := doc.Find(selector).First()
+	if selection.Length() == 0 {
+		return "", fmt.Errorf("failed to get attribute %s for %s: not present in rendered page", attribute, selector)
+	}
+	value, _ := selection.Attr(attribute)
+	return value, nil
+}
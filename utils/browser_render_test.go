@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+const renderedPage = `<html><body><table class="size-chart" data-unit="in"><tr><td>M</td></tr></table></body></html>`
+
+func newRenderTestBackend(serviceURL, serviceType, token string) *renderServiceBackend {
+	config := types.DefaultConfig()
+	config.Browser.Backend = BrowserBackendRender
+	config.Browser.RenderServiceURL = serviceURL
+	config.Browser.RenderServiceType = serviceType
+	config.Browser.RenderServiceToken = token
+	return newRenderServiceBackend(config, logrus.New())
+}
+
+func TestRenderServiceBackend_Browserless(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/content", r.URL.Path)
+		assert.Equal(t, "secret", r.URL.Query().Get("token"))
+
+		var payload map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "https://www.westside.com/products/a", payload["url"])
+		assert.Equal(t, map[string]interface{}{"Referer": "https://www.westside.com/"}, payload["setExtraHTTPHeaders"])
+
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(renderedPage))
+	}))
+	defer server.Close()
+
+	backend := newRenderTestBackend(server.URL, RenderServiceBrowserless, "secret")
+	backend.config.Stores = map[string]types.StoreOptions{"westside.com": {Headers: map[string]string{"Referer": "https://www.westside.com/"}}}
+	html, err := backend.GetPageContent(context.Background(), "https://www.westside.com/products/a")
+
+	require.NoError(t, err)
+	assert.Equal(t, renderedPage, html)
+}
+
+func TestRenderServiceBackend_Splash(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/render.html", r.URL.Path)
+		w.Write([]byte(renderedPage))
+	}))
+	defer server.Close()
+
+	backend := newRenderTestBackend(server.URL, RenderServiceSplash, "")
+	text, err := backend.GetElementText(context.Background(), "https://suqah.com/products/b", ".size-chart td")
+	require.NoError(t, err)
+	assert.Equal(t, "M", text)
+
+	unit, err := backend.GetElementAttribute(context.Background(), "https://suqah.com/products/b", ".size-chart", "data-unit")
+	require.NoError(t, err)
+	assert.Equal(t, "in", unit)
+
+	assert.Error(t, backend.WaitForElement(context.Background(), "https://suqah.com/products/b", ".missing"))
+}
+
+func TestRenderServiceBackend_GenericJSONEnvelope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"html": renderedPage})
+	}))
+	defer server.Close()
+
+	backend := newRenderTestBackend(server.URL, "", "secret")
+	html, err := backend.GetPageContent(context.Background(), "https://littleboxindia.com/products/c")
+
+	require.NoError(t, err)
+	assert.Equal(t, renderedPage, html)
+}
+
+func TestRenderServiceBackend_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "too many sessions", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	backend := newRenderTestBackend(server.URL, RenderServiceBrowserless, "")
+	_, err := backend.GetPageContent(context.Background(), "https://www.westside.com/products/a")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 429")
+}
+
+func TestNewBrowserBackend_RenderRequiresURL(t *testing.T) {
+	config := types.DefaultConfig()
+	config.Browser.Backend = BrowserBackendRender
+
+	_, err := NewBrowserBackend(config, logrus.New())
+	assert.Error(t, err)
+
+	config.Browser.RenderServiceURL = "http://splash:8050"
+	backend, err := NewBrowserBackend(config, logrus.New())
+	require.NoError(t, err)
+	assert.False(t, UsesLocalChrome(config.Browser))
+	assert.IsType(t, &renderServiceBackend{}, backend)
+}
@@ -0,0 +1,341 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/launcher/flags"
+	"github.com/go-rod/rod/lib/proto"
+	"shopify-extractor/internal/types"
+)
+
+// rodBackend drives Chrome with go-rod. rod's launcher supervises the browser process
+// through leakless, so Chrome is killed even if the extractor exits abruptly.
+type rodBackend struct {
+	config *types.Config
+	logger types.Logger
+}
+
+// newRodBackend creates the go-rod browser backend
+func newRodBackend(config *types.Config, logger types.Logger) *rodBackend {
+	return &rodBackend{
+		config: config,
+		logger: logger,
+	}
+}
+
+// newLauncher converts browser options into a rod launcher
+func (b *rodBackend) newLauncher(ctx context.Context) *launcher.Launcher {
+	opts := b.config.Browser
+	l := launcher.New().Context(ctx).Headless(!opts.Headful).Leakless(true)
+
+	if opts.NewHeadless && !opts.Headful {
+		l = l.Set(flags.Headless, "new")
+	}
+	if opts.ExecPath != "" {
+		l = l.Bin(opts.ExecPath)
+	}
+	if opts.NoSandbox {
+		l = l.NoSandbox(true)
+	}
+	if opts.WindowWidth > 0 && opts.WindowHeight > 0 {
+		l = l.Set("window-size", strconv.Itoa(opts.WindowWidth)+","+strconv.Itoa(opts.WindowHeight))
+	}
+	if opts.UserDataDir != "" {
+		l = l.UserDataDir(opts.UserDataDir)
+	}
+	if opts.ProxyServer != "" {
+		l = l.Proxy(opts.ProxyServer)
+	}
+	if opts.Language != "" {
+		l = l.Set("lang", opts.Language)
+	}
+	if opts.Stealth {
+		for name, value := range stealthFlags(b.config.UserAgent) {
+			l = l.Set(flags.Flag(name), value)
+		}
+	}
+	for _, flag := range opts.ExtraFlags {
+		name, value := parseFlag(flag)
+		switch v := value.(type) {
+		case string:
+			l = l.Set(flags.Flag(name), v)
+		case bool:
+			l = l.Set(flags.Flag(name))
+		}
+	}
+	return l
+}
+
+// withPage launches a browser, opens url in a new page and runs fn against it
+func (b *rodBackend) withPage(ctx context.Context, url string, fn func(page *rod.Page) error) error {
+	ctx, cancel := context.WithTimeout(ctx, b.config.Timeout)
+	defer cancel()
+
+	l := b.newLauncher(ctx)
+	defer l.Cleanup()
+
+	controlURL, err := l.Launch()
+	if err != nil {
+		return fmt.Errorf("failed to launch browser: %w", err)
+	}
+
+	browser := rod.New().ControlURL(controlURL).Context(ctx)
+	if err := browser.Connect(); err != nil {
+		l.Kill()
+		return fmt.Errorf("failed to connect to browser: %w", err)
+	}
+	defer browser.Close()
+
+	page, err := browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return fmt.Errorf("failed to open page: %w", err)
+	}
+	if err := b.applyStealth(page); err != nil {
+		return fmt.Errorf("failed to apply stealth options: %w", err)
+	}
+	if headers := requestHeaders(b.config, url); len(headers) > 0 {
+		dict := make([]string, 0, 2*len(headers))
+		for name, value := range headers {
+			dict = append(dict, name, value)
+		}
+		cleanup, err := page.SetExtraHeaders(dict)
+		if err != nil {
+			return fmt.Errorf("failed to set store headers: %w", err)
+		}
+		defer cleanup()
+	}
+	if err := page.Navigate(url); err != nil {
+		return fmt.Errorf("failed to navigate: %w", err)
+	}
+	if err := page.WaitLoad(); err != nil {
+		return fmt.Errorf("failed to load page: %w", err)
+	}
+
+	return fn(page)
+}
+
+// applyStealth prepares a blank page for stealth mode before navigation
+func (b *rodBackend) applyStealth(page *rod.Page) error {
+	opts := b.config.Browser
+	if !opts.Stealth {
+		return nil
+	}
+
+	if _, err := page.EvalOnNewDocument(stealthScript(opts)); err != nil {
+		return err
+	}
+	if opts.Timezone != "" {
+		if err := (proto.EmulationSetTimezoneOverride{TimezoneID: opts.Timezone}).Call(page); err != nil {
+			return err
+		}
+	}
+	if opts.Language != "" {
+		if err := (proto.EmulationSetLocaleOverride{Locale: opts.Language}).Call(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckRender renders a trivial inline page to verify the browser works
+func (b *rodBackend) CheckRender(ctx context.Context) error {
+	var title string
+	err := b.withPage(ctx, "data:text/html,<html><head><title>ready</title></head><body>ok</body></html>", func(page *rod.Page) error {
+		info, err := page.Info()
+		if err != nil {
+			return err
+		}
+		title = info.Title
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render test page: %w", err)
+	}
+	if title != "ready" {
+		return fmt.Errorf("unexpected test page title %q", title)
+	}
+	return nil
+}
+
+// GetPageContent retrieves the HTML content of a page
+func (b *rodBackend) GetPageContent(ctx context.Context, url string) (string, error) {
+	var html string
+	err := b.withPage(ctx, url, func(page *rod.Page) error {
+		// Give dynamic content the same short settle time as the chromedp backend
+		select {
+		case <-time.After(500 * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		var err error
+		html, err = page.HTML()
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get page content: %w", err)
+	}
+
+	LoggerFrom(ctx, b.logger).Debugf("Successfully retrieved page content from %s (%d bytes)", url, len(html))
+	return html, nil
+}
+
+// GetPageContentWhenVisible retrieves the HTML content of a page once selector is visible,
+// or after wait when it never shows up
+func (b *rodBackend) GetPageContentWhenVisible(ctx context.Context, url, selector string, wait time.Duration) (string, error) {
+	var html string
+	err := b.withPage(ctx, url, func(page *rod.Page) error {
+		visible, err := waitVisible(ctx, page, selector, wait)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			LoggerFrom(ctx, b.logger).Debugf("%s not visible on %s after %v, using the page as rendered", selector, url, wait)
+		}
+
+		html, err = page.HTML()
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get page content: %w", err)
+	}
+
+	LoggerFrom(ctx, b.logger).Debugf("Successfully retrieved page content from %s (%d bytes)", url, len(html))
+	return html, nil
+}
+
+// waitVisible waits up to wait for selector to be visible on the page, so a page without
+// the element is still captured, and reports whether it became visible
+func waitVisible(ctx context.Context, page *rod.Page, selector string, wait time.Duration) (bool, error) {
+	timed := page.Timeout(wait)
+	defer timed.CancelTimeout()
+	element, err := timed.Element(selector)
+	if err == nil {
+		err = element.WaitVisible()
+	}
+	if err != nil && ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	return err == nil, nil
+}
+
+// GetPageContentScrolled retrieves the HTML content of a page after scrolling it until
+// no more items load
+func (b *rodBackend) GetPageContentScrolled(ctx context.Context, url string, scroll ScrollOptions) (string, error) {
+	var html string
+	err := b.withPage(ctx, url, func(page *rod.Page) error {
+		logger := LoggerFrom(ctx, b.logger)
+		visible, err := waitVisible(ctx, page, scroll.Items, scroll.Wait)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			logger.Debugf("%s not visible on %s after %v, using the page as rendered", scroll.Items, url, scroll.Wait)
+		} else {
+			items, err := scrollUntilStable(page.GetContext(), scroll, func() (int, error) {
+				res, err := proto.RuntimeEvaluate{Expression: scrollExpression(scroll.Items), ReturnByValue: true}.Call(page)
+				if err != nil {
+					return 0, err
+				}
+				return res.Result.Value.Int(), nil
+			})
+			if err != nil {
+				return err
+			}
+			logger.Debugf("Scrolled %s until %d items loaded", url, items)
+		}
+
+		html, err = page.HTML()
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get page content: %w", err)
+	}
+
+	LoggerFrom(ctx, b.logger).Debugf("Successfully retrieved page content from %s (%d bytes)", url, len(html))
+	return html, nil
+}
+
+// ExecuteJavaScript evaluates a JavaScript expression on the page
+func (b *rodBackend) ExecuteJavaScript(ctx context.Context, url string, script string) (string, error) {
+	var result string
+	err := b.withPage(ctx, url, func(page *rod.Page) error {
+		res, err := proto.RuntimeEvaluate{
+			Expression:    script,
+			ReturnByValue: true,
+			AwaitPromise:  true,
+		}.Call(page)
+		if err != nil {
+			return err
+		}
+		if res.ExceptionDetails != nil {
+			return fmt.Errorf("script error: %s", res.ExceptionDetails.Text)
+		}
+		result = res.Result.Value.Str()
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute JavaScript: %w", err)
+	}
+	return result, nil
+}
+
+// WaitForElement waits for a specific element to appear on the page
+func (b *rodBackend) WaitForElement(ctx context.Context, url string, selector string) error {
+	err := b.withPage(ctx, url, func(page *rod.Page) error {
+		el, err := page.Element(selector)
+		if err != nil {
+			return err
+		}
+		return el.WaitVisible()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wait for element %s: %w", selector, err)
+	}
+	return nil
+}
+
+// GetElementText retrieves the text content of a specific element
+func (b *rodBackend) GetElementText(ctx context.Context, url string, selector string) (string, error) {
+	var text string
+	err := b.withPage(ctx, url, func(page *rod.Page) error {
+		el, err := page.Element(selector)
+		if err != nil {
+			return err
+		}
+		text, err = el.Text()
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get element text for %s: %w", selector, err)
+	}
+	return text, nil
+}
+
+// GetElementAttribute retrieves the value of a specific attribute of an element
+func (b *rodBackend) GetElementAttribute(ctx context.Context, url string, selector string, attribute string) (string, error) {
+	var value string
+	err := b.withPage(ctx, url, func(page *rod.Page) error {
+		el, err := page.Element(selector)
+		if err != nil {
+			return err
+		}
+		attr, err := el.Attribute(attribute)
+		if err != nil {
+			return err
+		}
+		if attr != nil {
+			value = *attr
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get attribute %s for %s: %w", attribute, selector, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func TestNewBrowserBackend(t *testing.T) {
+	config := types.DefaultConfig()
+	logger := logrus.New()
+
+	backend, err := NewBrowserBackend(config, logger)
+	require.NoError(t, err)
+	assert.IsType(t, &chromedpBackend{}, backend)
+
+	config.Browser.Backend = "Rod"
+	backend, err = NewBrowserBackend(config, logger)
+	require.NoError(t, err)
+	assert.IsType(t, &rodBackend{}, backend)
+
+	config.Browser.Backend = "playwright"
+	_, err = NewBrowserBackend(config, logger)
+	assert.Error(t, err)
+}
+
+func TestNewBrowserClient_UnknownBackendFallsBack(t *testing.T) {
+	config := types.DefaultConfig()
+	config.Browser.Backend = "playwright"
+
+	client := NewBrowserClient(config, logrus.New())
+
+	assert.IsType(t, &chromedpBackend{}, client.backend)
+}
+
+func TestScrollUntilStable(t *testing.T) {
+	opts := ScrollOptions{Items: ".grid a", Settle: time.Millisecond, MaxScrolls: 10}
+
+	// The grid grows by 24 items per scroll until all 60 loaded
+	loaded, scrolls := 24, 0
+	count, err := scrollUntilStable(context.Background(), opts, func() (int, error) {
+		scrolls++
+		n := loaded
+		if loaded < 60 {
+			loaded += 24
+		}
+		if loaded > 60 {
+			loaded = 60
+		}
+		return n, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 60, count)
+	assert.Equal(t, 4, scrolls)
+
+	// Endless grids stop after MaxScrolls
+	scrolls = 0
+	count, err = scrollUntilStable(context.Background(), opts, func() (int, error) {
+		scrolls++
+		return scrolls * 24, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 11, scrolls)
+	assert.Equal(t, 11*24, count)
+}
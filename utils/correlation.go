@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"shopify-extractor/internal/types"
+)
+
+// NewRunID generates a random identifier for a run or request
+func NewRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithField returns a logger that attaches key=value to every line, so logs of
+// concurrent runs, stores and products can be told apart. A Tracer keeps recording to
+// its trace; loggers other than logrus are returned unchanged.
+func WithField(logger types.Logger, key string, value interface{}) types.Logger {
+	switch l := logger.(type) {
+	case *logrus.Logger:
+		return l.WithField(key, value)
+	case *logrus.Entry:
+		return l.WithField(key, value)
+	case *Tracer:
+		return l.withNext(WithField(l.next, key, value))
+	}
+	return logger
+}
+
+// WithFields returns a logger that attaches every field to every line, see WithField
+func WithFields(logger types.Logger, fields map[string]interface{}) types.Logger {
+	switch l := logger.(type) {
+	case *logrus.Logger:
+		return l.WithFields(fields)
+	case *logrus.Entry:
+		return l.WithFields(fields)
+	case *Tracer:
+		return l.withNext(WithFields(l.next, fields))
+	}
+	return logger
+}
+
+type loggerKey struct{}
+
+// ContextWithLogger returns a context carrying logger, so code further down the
+// pipeline logs with the fields of the run, store and product it works for even when it
+// was created for another run (e.g. a pooled extractor)
+func ContextWithLogger(ctx context.Context, logger types.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFrom returns the logger carried by ctx, or fallback when there is none
+func LoggerFrom(ctx context.Context, fallback types.Logger) types.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(types.Logger); ok {
+			return logger
+		}
+	}
+	return fallback
+}
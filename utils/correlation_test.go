@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"shopify-extractor/internal/types"
+)
+
+func TestWithField(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+	logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+
+	runLogger := WithField(logger, "run_id", "abc")
+	WithField(runLogger, "product_url", "https://suqah.com/products/kurta").Info("extracted")
+	assert.Contains(t, out.String(), "run_id=abc")
+	assert.Contains(t, out.String(), `product_url="https://suqah.com/products/kurta"`)
+
+	assert.Len(t, NewRunID(), 16)
+}
+
+func TestContextWithLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+	logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+
+	assert.Equal(t, types.Logger(logger), LoggerFrom(context.Background(), logger))
+
+	runLogger := WithFields(logger, map[string]interface{}{"run_id": "abc", "store": "suqah.com"})
+	ctx := ContextWithLogger(context.Background(), runLogger)
+	LoggerFrom(ctx, logger).Info("extracted")
+	assert.Contains(t, out.String(), "run_id=abc")
+	assert.Contains(t, out.String(), "store=suqah.com")
+}
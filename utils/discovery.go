@@ -0,0 +1,36 @@
+package utils
+
+import "shopify-extractor/internal/types"
+
+// Product discovery modes of Shopify stores
+const (
+	DiscoveryHTML = "html" // storefront listing and collection pages
+	DiscoveryJSON = "json" // the public /products.json catalog endpoint
+)
+
+// DiscoveryModes lists the accepted discovery modes
+func DiscoveryModes() []string {
+	return []string{DiscoveryHTML, DiscoveryJSON}
+}
+
+// ValidDiscoveryMode reports whether mode is empty (HTML) or a known discovery mode
+func ValidDiscoveryMode(mode string) bool {
+	switch mode {
+	case "", DiscoveryHTML, DiscoveryJSON:
+		return true
+	}
+	return false
+}
+
+// StoreDiscoveryMode returns how a store's products are discovered: its configured
+// discovery, or DiscoveryMode, and HTML when neither is set
+func StoreDiscoveryMode(config *types.Config, store string) string {
+	mode := config.Stores[storeKey(store)].Discovery
+	if mode == "" {
+		mode = config.DiscoveryMode
+	}
+	if mode == "" {
+		mode = DiscoveryHTML
+	}
+	return mode
+}
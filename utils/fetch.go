@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"shopify-extractor/internal/types"
+)
+
+// Fetcher retrieves the HTML of a page. HTTPClient and BrowserClient both implement it and
+// share one retry policy (retryFetch): Config.MaxRetries, exponential backoff and the
+// error classification of Retryable apply to requests and browser navigations alike.
+type Fetcher interface {
+	GetPageContent(ctx context.Context, url string) (string, error)
+}
+
+var (
+	_ Fetcher = (*HTTPClient)(nil)
+	_ Fetcher = (*BrowserClient)(nil)
+)
+
+// StatusError reports an HTTP response with an unexpected status code
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// Retryable reports whether a failed fetch attempt may succeed when repeated. Rate
+// limiting, server errors, timeouts (including a browser navigation running past
+// Config.Timeout) and connection failures are retried; cancellation, client errors such
+// as 404, interstitial pages (but queue pages with Config.QueueRetryDelay) and a missing
+// browser binary are not.
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, exec.ErrNotFound) {
+		return false
+	}
+	var interstitial *InterstitialError
+	if errors.As(err, &interstitial) {
+		return interstitial.retry
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusRequestTimeout
+	}
+	return true
+}
+
+// retryFetch runs attempt until it succeeds, up to Config.MaxRetries retries. Before every
+// attempt it waits for the host's rate limiter and unlocks a password-protected
+// storefront; failed attempts are logged and retried after an exponential backoff unless
+// Retryable rejects the error or ctx is done.
+func retryFetch(ctx context.Context, config *types.Config, logger types.Logger, limiter *HostLimiter, url string, attempt func(attempt int) error) error {
+	var lastErr error
+	for i := 0; i <= config.MaxRetries; i++ {
+		// Back off before retrying. Time spent here also refills the rate limiter,
+		// so the limiter wait below does not add a second delay on top of it.
+		if i > 0 {
+			if err := sleepContext(ctx, retryBackoff(config.RequestDelay, i)); err != nil {
+				return err
+			}
+		}
+
+		if err := limiter.Wait(ctx, url); err != nil {
+			return err
+		}
+		if err := ensureStorefrontAccess(ctx, config, logger, url); err != nil {
+			return err
+		}
+
+		err := attempt(i)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		// A non-retryable failure, such as the 404 past a listing's last page, is
+		// expected by some callers; they decide whether it is worth a warning
+		if !Retryable(err) {
+			logger.Debugf("Fetching %s failed: %v", url, err)
+			return err
+		}
+		logger.Warnf("Fetching %s failed (attempt %d/%d): %v", url, i+1, config.MaxRetries+1, err)
+		lastErr = err
+	}
+
+	return fmt.Errorf("all retry attempts failed: %w", lastErr)
+}
+
+// retryBackoff returns the exponential backoff before the given retry attempt (1-based)
+func retryBackoff(base time.Duration, attempt int) time.Duration {
+	if base < minRetryBackoff {
+		base = minRetryBackoff
+	}
+	backoff := base
+	for i := 1; i < attempt && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+// flakyBackend is a BrowserBackend whose navigations fail with errs in turn, then succeed
+type flakyBackend struct {
+	BrowserBackend
+	errs  []error
+	calls int
+}
+
+func (f *flakyBackend) GetPageContent(ctx context.Context, url string) (string, error) {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		return "", f.errs[f.calls-1]
+	}
+	return "<html>rendered</html>", nil
+}
+
+func newFlakyBrowser(maxRetries int, errs ...error) (*BrowserClient, *flakyBackend) {
+	config := types.DefaultConfig()
+	config.RequestDelay = 0
+	config.MaxRetries = maxRetries
+	backend := &flakyBackend{errs: errs}
+	client := NewBrowserClient(config, logrus.New())
+	client.backend = backend
+	return client, backend
+}
+
+func TestRetryable(t *testing.T) {
+	assert.False(t, Retryable(nil))
+	assert.False(t, Retryable(context.Canceled))
+	assert.False(t, Retryable(&StatusError{StatusCode: http.StatusNotFound}))
+	assert.False(t, Retryable(fmt.Errorf("failed to start browser: %w", exec.ErrNotFound)))
+
+	assert.True(t, Retryable(context.DeadlineExceeded))
+	assert.True(t, Retryable(&StatusError{StatusCode: http.StatusBadGateway}))
+	assert.True(t, Retryable(&StatusError{StatusCode: http.StatusRequestTimeout}))
+	assert.True(t, Retryable(fmt.Errorf("%w: status code 429", ErrRateLimited)))
+	assert.True(t, Retryable(errors.New("connection reset by peer")))
+}
+
+func TestBrowserClient_RetriesNavigationDeadline(t *testing.T) {
+	client, backend := newFlakyBrowser(1, context.DeadlineExceeded)
+
+	html, err := client.GetPageContent(context.Background(), "https://shop.example/products/a")
+	require.NoError(t, err)
+	assert.Equal(t, "<html>rendered</html>", html)
+	assert.Equal(t, 2, backend.calls)
+}
+
+func TestBrowserClient_GivesUpAfterMaxRetries(t *testing.T) {
+	client, backend := newFlakyBrowser(1, context.DeadlineExceeded, context.DeadlineExceeded, context.DeadlineExceeded)
+
+	_, err := client.GetPageContent(context.Background(), "https://shop.example/products/a")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 2, backend.calls)
+}
+
+func TestBrowserClient_DoesNotRetryMissingChrome(t *testing.T) {
+	client, backend := newFlakyBrowser(3, exec.ErrNotFound)
+
+	_, err := client.GetPageContent(context.Background(), "https://shop.example/products/a")
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+	assert.Equal(t, 1, backend.calls)
+}
+
+func TestHTTPClient_Get_DoesNotRetryClientErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	config := types.DefaultConfig()
+	config.RequestDelay = 0
+	config.MaxRetries = 3
+	client := NewHTTPClient(config, logrus.New())
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), server.URL)
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.Equal(t, 1, requests)
+}
@@ -2,24 +2,67 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"shopify-extractor/internal/types"
 )
 
+const (
+	// minRetryBackoff is the smallest wait between retry attempts
+	minRetryBackoff = 250 * time.Millisecond
+
+	// maxRetryBackoff caps the exponential backoff between retry attempts
+	maxRetryBackoff = 30 * time.Second
+
+	// defaultCooldown pauses a host that answers 429 without a Retry-After header
+	defaultCooldown = 30 * time.Second
+
+	// maxCooldown caps the pause requested through Retry-After
+	maxCooldown = 10 * time.Minute
+)
+
+// ErrRateLimited is returned (wrapped) when a store answers 429 Too Many Requests
+var ErrRateLimited = errors.New("rate limited by store")
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date, falling
+// back to defaultCooldown, and caps the result at maxCooldown
+func retryAfter(header string, now time.Time) time.Duration {
+	wait := defaultCooldown
+	if seconds, err := strconv.Atoi(strings.TrimSpace(header)); err == nil && seconds >= 0 {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		wait = date.Sub(now)
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	if wait > maxCooldown {
+		wait = maxCooldown
+	}
+	return wait
+}
+
 // HTTPClient provides HTTP functionality with rate limiting and retries
 type HTTPClient struct {
 	client  *http.Client
 	config  *types.Config
 	logger  types.Logger
-	limiter *time.Ticker
+	limiter *HostLimiter
 }
 
 // NewHTTPClient creates a new HTTP client with the given configuration
 func NewHTTPClient(config *types.Config, logger types.Logger) *HTTPClient {
+	return NewHTTPClientWithLimiter(config, logger, NewConfigLimiter(config))
+}
+
+// NewHTTPClientWithLimiter creates a new HTTP client that shares the given per-host limiter
+func NewHTTPClientWithLimiter(config *types.Config, logger types.Logger, limiter *HostLimiter) *HTTPClient {
 	client := &http.Client{
 		Timeout: config.Timeout,
 		Transport: &http.Transport{
@@ -33,73 +76,179 @@ func NewHTTPClient(config *types.Config, logger types.Logger) *HTTPClient {
 		client:  client,
 		config:  config,
 		logger:  logger,
-		limiter: time.NewTicker(config.RequestDelay),
+		limiter: limiter,
 	}
 }
 
-// Get performs a GET request with rate limiting and retries
+// Get performs a GET request with rate limiting and retries, see retryFetch
 func (h *HTTPClient) Get(ctx context.Context, url string) ([]byte, error) {
-	var lastErr error
-	
-	for attempt := 0; attempt <= h.config.MaxRetries; attempt++ {
-		// Wait for rate limiter
-		select {
-		case <-h.limiter.C:
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		}
+	var body []byte
+	err := retryFetch(ctx, h.config, LoggerFrom(ctx, h.logger), h.limiter, url, func(attempt int) error {
+		var err error
+		body, err = h.do(ctx, url, attempt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	LoggerFrom(ctx, h.logger).Debugf("Successfully retrieved %d bytes from %s", len(body), url)
+	return body, nil
+}
 
-		// Create request
-		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
-		}
+// GetPageContent retrieves the HTML of a page, see Get
+func (h *HTTPClient) GetPageContent(ctx context.Context, url string) (string, error) {
+	body, err := h.Get(ctx, url)
+	return string(body), err
+}
 
-		// Set headers
-		req.Header.Set("User-Agent", h.config.UserAgent)
-		req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
-		req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-		req.Header.Set("Accept-Encoding", "gzip, deflate")
-		req.Header.Set("Connection", "keep-alive")
-		req.Header.Set("Upgrade-Insecure-Requests", "1")
-
-		// Make request
-		h.logger.Debugf("Making request to %s (attempt %d/%d)", url, attempt+1, h.config.MaxRetries+1)
-		
-		resp, err := h.client.Do(req)
-		if err != nil {
-			lastErr = fmt.Errorf("request failed: %w", err)
-			h.logger.Warnf("Request failed (attempt %d): %v", attempt+1, err)
-			continue
-		}
+// do performs a single request attempt
+func (h *HTTPClient) do(ctx context.Context, url string, attempt int) ([]byte, error) {
+	// Create request
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
 
-		defer resp.Body.Close()
+	h.setHeaders(req, url)
 
-		// Check status code
-		if resp.StatusCode != http.StatusOK {
-			lastErr = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-			h.logger.Warnf("Unexpected status code %d (attempt %d)", resp.StatusCode, attempt+1)
-			continue
-		}
+	// Make request
+	LoggerFrom(ctx, h.logger).Debugf("Making request to %s (attempt %d/%d)", url, attempt+1, h.config.MaxRetries+1)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := h.checkRateLimit(ctx, resp, url); err != nil {
+		return nil, err
+	}
+
+	// An expired storefront session lands on the password form; unlock again on retry
+	if isPasswordPage(resp) && StoreOptionsFor(h.config, url).Password != "" {
+		forgetStorefrontSession(resp.Request.URL.Host)
+		return nil, fmt.Errorf("redirected to the storefront password page")
+	}
+
+	// Check status code
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
 
-		// Read response body
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			lastErr = fmt.Errorf("failed to read response body: %w", err)
-			h.logger.Warnf("Failed to read response body (attempt %d): %v", attempt+1, err)
-			continue
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Password forms, bot challenges and queues answer 200 after a redirect
+	if kind := interstitialKind(resp.Request.URL, string(body)); kind != "" {
+		return nil, interstitialError(h.config, LoggerFrom(ctx, h.logger), h.limiter, url, kind, resp.Request.URL)
+	}
+
+	return body, nil
+}
+
+// setHeaders sets the browser-like default headers and the store's configured headers
+func (h *HTTPClient) setHeaders(req *http.Request, url string) {
+	req.Header.Set("User-Agent", h.config.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	req.Header.Set("Connection", "keep-alive")
+	req.Header.Set("Upgrade-Insecure-Requests", "1")
+	for name, value := range requestHeaders(h.config, url) {
+		req.Header.Set(name, value)
+	}
+}
+
+// checkRateLimit returns an ErrRateLimited error for a 429 response. A 429 puts the whole
+// host in cooldown: every worker sharing the limiter pauses instead of retrying on its
+// own and prolonging the block.
+func (h *HTTPClient) checkRateLimit(ctx context.Context, resp *http.Response, url string) error {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	wait := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if h.limiter.Cooldown(url, time.Now().Add(wait)) {
+		LoggerFrom(ctx, h.logger).Warnf("Store rate limited %s (429), pausing requests to the host for %v", url, wait.Round(time.Second))
+	}
+	return fmt.Errorf("%w: status code %d", ErrRateLimited, resp.StatusCode)
+}
+
+// Status returns the HTTP status url finally answers with, after redirects, with rate
+// limiting and retries (server errors and 429 are retried, see retryFetch). It sends HEAD,
+// falling back to GET for servers that refuse HEAD, and never reads the body.
+func (h *HTTPClient) Status(ctx context.Context, url string) (int, error) {
+	var status int
+	err := retryFetch(ctx, h.config, LoggerFrom(ctx, h.logger), h.limiter, url, func(attempt int) error {
+		var err error
+		status, err = h.status(ctx, "HEAD", url)
+		if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+			status, err = h.status(ctx, "GET", url)
+		}
+		if err == nil && status >= 500 {
+			return &StatusError{StatusCode: status}
 		}
+		return err
+	})
+	return status, err
+}
 
-		h.logger.Debugf("Successfully retrieved %d bytes from %s", len(body), url)
-		return body, nil
+// status performs a single request and returns its status code
+func (h *HTTPClient) status(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
+	h.setHeaders(req, url)
 
-	return nil, fmt.Errorf("all retry attempts failed: %w", lastErr)
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := h.checkRateLimit(ctx, resp, url); err != nil {
+		return 0, err
+	}
+	// Drain a little of a GET body so the connection can be reused; large bodies are
+	// cut off instead of downloaded
+	io.CopyN(io.Discard, resp.Body, 4096)
+	return resp.StatusCode, nil
+}
+
+// Warm requests url once, without retries, so the client holds an open keep-alive
+// connection to its host (DNS resolved, TLS handshake done, storefront unlocked) before
+// the first real request. Any HTTP response counts as success.
+func (h *HTTPClient) Warm(ctx context.Context, url string) error {
+	if err := h.limiter.Wait(ctx, url); err != nil {
+		return err
+	}
+	if err := ensureStorefrontAccess(ctx, h.config, LoggerFrom(ctx, h.logger), url); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", h.config.UserAgent)
+	for name, value := range requestHeaders(h.config, url) {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so the connection goes back to the idle pool
+	io.Copy(io.Discard, resp.Body)
+	LoggerFrom(ctx, h.logger).Debugf("Warmed connection to %s (status %d)", url, resp.StatusCode)
+	return nil
 }
 
 // Close cleans up resources
 func (h *HTTPClient) Close() {
-	if h.limiter != nil {
-		h.limiter.Stop()
-	}
-} 
\ No newline at end of file
+	h.client.CloseIdleConnections()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -93,4 +94,188 @@ func TestHTTPClient_Close(t *testing.T) {
 	
 	// Should not panic
 	client.Close()
-} 
\ No newline at end of file
+} 
+func TestHTTPClient_Get_FirstRequestNotDelayed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := types.DefaultConfig()
+	config.RequestDelay = time.Second
+	client := NewHTTPClient(config, logrus.New())
+	defer client.Close()
+
+	start := time.Now()
+	_, err := client.Get(context.Background(), server.URL)
+
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	assert.Equal(t, minRetryBackoff, retryBackoff(0, 1))
+	assert.Equal(t, 2*time.Second, retryBackoff(time.Second, 2))
+	assert.Equal(t, 4*time.Second, retryBackoff(time.Second, 3))
+	assert.Equal(t, maxRetryBackoff, retryBackoff(time.Second, 20))
+}
+
+func TestHTTPClient_Get_RateLimitedCooldown(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	config := types.DefaultConfig()
+	config.RequestDelay = 10 * time.Millisecond
+	client := NewHTTPClient(config, logrus.New())
+	defer client.Close()
+
+	// The warning goes to the request's logger, so traced requests record it
+	tracer := NewTracer(logrus.New())
+	ctx := ContextWithLogger(context.Background(), tracer)
+	start := time.Now()
+	body, err := client.Get(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond, "the retry waits for Retry-After")
+	var warned bool
+	for _, event := range tracer.Events() {
+		warned = warned || strings.Contains(event.Message, "rate limited")
+	}
+	assert.True(t, warned, "the rate limit warning is traced")
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 5*time.Second, retryAfter("5", now))
+	assert.Equal(t, 90*time.Second, retryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, defaultCooldown, retryAfter("", now))
+	assert.Equal(t, maxCooldown, retryAfter("86400", now))
+}
+
+func TestHTTPClient_Get_StoreHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "https://shop.example/", r.Header.Get("Referer"))
+		assert.Equal(t, "en-IN", r.Header.Get("Accept-Language"))
+		assert.Equal(t, "1", r.Header.Get("X-Preview"))
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	config := types.DefaultConfig()
+	config.RequestDelay = 0
+	config.Stores = map[string]types.StoreOptions{
+		"127.0.0.1":    {Headers: map[string]string{"Referer": "https://shop.example/", "Accept-Language": "en-IN", "X-Preview": "1"}},
+		"westside.com": {Headers: map[string]string{"X-Preview": "other store"}},
+	}
+	client := NewHTTPClient(config, logrus.New())
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+}
+
+func TestHTTPClient_Get_StoreCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "qa" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	config := types.DefaultConfig()
+	config.RequestDelay = 0
+	config.MaxRetries = 0
+	config.Stores = map[string]types.StoreOptions{
+		"127.0.0.1": {BasicAuth: &types.BasicAuth{Username: "qa", Password: "secret"}},
+	}
+	client := NewHTTPClient(config, logrus.New())
+	defer client.Close()
+
+	body, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+}
+
+func TestAuthorizationHeader(t *testing.T) {
+	assert.Equal(t, "", authorizationHeader(types.StoreOptions{}))
+	assert.Equal(t, "Bearer abc", authorizationHeader(types.StoreOptions{BearerToken: "abc"}))
+	assert.Equal(t, "Basic cWE6c2VjcmV0", authorizationHeader(types.StoreOptions{BasicAuth: &types.BasicAuth{Username: "qa", Password: "secret"}}))
+	assert.Equal(t, "Bearer abc", authorizationHeader(types.StoreOptions{BearerToken: "abc", BasicAuth: &types.BasicAuth{Username: "qa"}}))
+}
+
+func TestHTTPClient_Warm(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	config := types.DefaultConfig()
+	config.RequestDelay = 10 * time.Millisecond
+	client := NewHTTPClient(config, logrus.New())
+	defer client.Close()
+
+	// Any response warms the connection, and a missing page is not retried
+	assert.NoError(t, client.Warm(context.Background(), server.URL+"/sitemap.xml"))
+	assert.Equal(t, 1, requests)
+
+	server.Close()
+	assert.Error(t, client.Warm(context.Background(), server.URL+"/robots.txt"))
+}
+
+func TestHTTPClient_Status(t *testing.T) {
+	var gets, flaky int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/products/live":
+			w.WriteHeader(http.StatusOK)
+		case "/products/removed":
+			w.WriteHeader(http.StatusNotFound)
+		case "/products/no-head":
+			if r.Method == "HEAD" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			gets++
+			w.WriteHeader(http.StatusGone)
+		case "/products/flaky":
+			if flaky++; flaky == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	config := types.DefaultConfig()
+	config.RequestDelay = time.Millisecond
+	client := NewHTTPClient(config, logrus.New())
+	defer client.Close()
+
+	for path, want := range map[string]int{
+		"/products/live":    http.StatusOK,
+		"/products/removed": http.StatusNotFound,
+		"/products/no-head": http.StatusGone,
+		"/products/flaky":   http.StatusOK,
+	} {
+		status, err := client.Status(context.Background(), server.URL+path)
+		require.NoError(t, err, path)
+		assert.Equal(t, want, status, path)
+	}
+	assert.Equal(t, 1, gets)
+	assert.Equal(t, 2, flaky, "server errors are retried")
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"shopify-extractor/internal/types"
+)
+
+// InterstitialError reports that a store answered with an interstitial page instead of
+// the requested one: its password form, a bot challenge or a queue (waiting room). Such
+// pages render fine but hold no product, so they are reported instead of parsed.
+type InterstitialError struct {
+	Kind     string // types.MissingReasonPasswordPage, MissingReasonChallenge or MissingReasonQueue
+	Location string // final URL of the interstitial, when known
+
+	// retry is set for queue pages when Config.QueueRetryDelay allows waiting them out
+	retry bool
+}
+
+func (e *InterstitialError) Error() string {
+	names := map[string]string{
+		types.MissingReasonPasswordPage: "storefront password page",
+		types.MissingReasonChallenge:    "bot challenge",
+		types.MissingReasonQueue:        "queue page",
+	}
+	if e.Location == "" {
+		return "served the " + names[e.Kind]
+	}
+	return fmt.Sprintf("redirected to the %s %s", names[e.Kind], e.Location)
+}
+
+// interstitialMarkers identify interstitial pages by their HTML when the final URL is
+// unknown (browser navigations) or unremarkable
+var interstitialMarkers = []struct {
+	kind   string
+	marker string
+}{
+	{types.MissingReasonPasswordPage, `value="storefront_password"`},
+	{types.MissingReasonChallenge, `action="/challenge"`},
+	{types.MissingReasonChallenge, "<title>Just a moment...</title>"},
+	{types.MissingReasonQueue, "<title>Queue-it</title>"},
+}
+
+// interstitialKind classifies a page by its final URL (nil when unknown) and HTML,
+// returning a types.MissingReason* interstitial kind or ""
+func interstitialKind(final *url.URL, html string) string {
+	if final != nil {
+		host, path := strings.ToLower(final.Hostname()), strings.ToLower(final.Path)
+		switch {
+		case path == "/password":
+			return types.MissingReasonPasswordPage
+		case path == "/challenge" || strings.HasPrefix(path, "/challenge/"):
+			return types.MissingReasonChallenge
+		case host == "queue-it.net" || strings.HasSuffix(host, ".queue-it.net") || strings.HasPrefix(path, "/throttle/queue"):
+			return types.MissingReasonQueue
+		}
+	}
+	for _, m := range interstitialMarkers {
+		if strings.Contains(html, m.marker) {
+			return m.kind
+		}
+	}
+	return ""
+}
+
+// interstitialError returns the error of an interstitial page fetched for pageURL. A
+// queue page puts the host in cooldown for Config.QueueRetryDelay, when set, and is
+// retried once the cooldown ends.
+func interstitialError(config *types.Config, logger types.Logger, limiter *HostLimiter, pageURL, kind string, final *url.URL) error {
+	err := &InterstitialError{Kind: kind}
+	if final != nil && final.String() != pageURL {
+		err.Location = final.String()
+	}
+	if kind == types.MissingReasonQueue && config.QueueRetryDelay > 0 {
+		err.retry = true
+		if limiter.Cooldown(pageURL, time.Now().Add(config.QueueRetryDelay)) {
+			logger.Warnf("Store sent %s to a queue page, pausing requests to the host for %v", pageURL, config.QueueRetryDelay)
+		}
+	}
+	return err
+}
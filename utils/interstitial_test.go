@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func TestHTTPClient_Get_Interstitials(t *testing.T) {
+	requests := 0
+	queued := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/products/locked":
+			http.Redirect(w, r, "/password", http.StatusFound)
+		case "/password":
+			w.Write([]byte(`<form action="/password"><input type="hidden" name="form_type" value="storefront_password"></form>`))
+		case "/products/challenged":
+			w.Write([]byte(`<html><head><title>Just a moment...</title></head></html>`))
+		case "/products/busy":
+			if queued {
+				queued = false
+				http.Redirect(w, r, "/throttle/queue?checkout=1", http.StatusFound)
+				return
+			}
+			w.Write([]byte("product"))
+		case "/throttle/queue":
+			w.Write([]byte("You are in line"))
+		}
+	}))
+	defer server.Close()
+
+	config := types.DefaultConfig()
+	config.RequestDelay = 10 * time.Millisecond
+	client := NewHTTPClient(config, logrus.New())
+	defer client.Close()
+	ctx := context.Background()
+
+	_, err := client.Get(ctx, server.URL+"/products/locked")
+	var interstitial *InterstitialError
+	require.True(t, errors.As(err, &interstitial), "got %v", err)
+	assert.Equal(t, types.MissingReasonPasswordPage, interstitial.Kind)
+	assert.Equal(t, server.URL+"/password", interstitial.Location)
+	assert.Equal(t, 2, requests, "password pages are not retried")
+
+	_, err = client.Get(ctx, server.URL+"/products/challenged")
+	require.True(t, errors.As(err, &interstitial))
+	assert.Equal(t, types.MissingReasonChallenge, interstitial.Kind)
+	assert.Equal(t, "served the bot challenge", err.Error())
+
+	_, err = client.Get(ctx, server.URL+"/products/busy")
+	require.True(t, errors.As(err, &interstitial))
+	assert.Equal(t, types.MissingReasonQueue, interstitial.Kind)
+
+	// With a queue retry delay the client waits the queue out
+	queued = true
+	config.QueueRetryDelay = 300 * time.Millisecond
+	start := time.Now()
+	body, err := client.Get(ctx, server.URL+"/products/busy")
+	require.NoError(t, err)
+	assert.Equal(t, "product", string(body))
+	assert.GreaterOrEqual(t, time.Since(start), 250*time.Millisecond)
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"math/rand"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Crawl orders accepted by OrderURLs
+const (
+	OrderDiscovery    = "discovery"    // the order the store's listings yielded products
+	OrderAlphabetical = "alphabetical" // by product handle
+	OrderRandom       = "random"       // seeded shuffle
+)
+
+// CrawlOrders lists the accepted crawl orders
+func CrawlOrders() []string {
+	return []string{OrderDiscovery, OrderAlphabetical, OrderRandom}
+}
+
+// ValidCrawlOrder reports whether order is empty (discovery order) or a known crawl order
+func ValidCrawlOrder(order string) bool {
+	switch order {
+	case "", OrderDiscovery, OrderAlphabetical, OrderRandom:
+		return true
+	}
+	return false
+}
+
+// OrderURLs returns the product URLs in the given crawl order. Random order shuffles
+// with seed, or with a time-based seed when seed is zero so successive runs visit
+// different parts of the catalog first. The input slice is not modified.
+func OrderURLs(urls []string, order string, seed int64) []string {
+	ordered := append([]string(nil), urls...)
+	switch order {
+	case OrderAlphabetical:
+		sort.SliceStable(ordered, func(a, b int) bool {
+			return ProductHandle(ordered[a]) < ProductHandle(ordered[b])
+		})
+	case OrderRandom:
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		rng := rand.New(rand.NewSource(seed))
+		rng.Shuffle(len(ordered), func(a, b int) { ordered[a], ordered[b] = ordered[b], ordered[a] })
+	}
+	return ordered
+}
+
+// ProductHandle returns the last path segment of a product URL, e.g. "linen-dress"
+// for https://store.com/collections/new/products/linen-dress?variant=1
+func ProductHandle(productURL string) string {
+	if parsed, err := url.Parse(productURL); err == nil {
+		productURL = parsed.Path
+	}
+	return strings.ToLower(path.Base(strings.TrimRight(productURL, "/")))
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderURLs(t *testing.T) {
+	urls := []string{
+		"https://example.com/products/zebra-top",
+		"https://example.com/collections/new/products/Apple-dress?variant=1",
+		"https://example.com/products/mango-skirt/",
+	}
+
+	assert.Equal(t, urls, OrderURLs(urls, OrderDiscovery, 0))
+	assert.Equal(t, []string{urls[1], urls[2], urls[0]}, OrderURLs(urls, OrderAlphabetical, 0))
+
+	many := testURLs(50)
+	shuffled := OrderURLs(many, OrderRandom, 42)
+	assert.Equal(t, shuffled, OrderURLs(many, OrderRandom, 42), "the same seed gives the same order")
+	assert.NotEqual(t, many, shuffled)
+	assert.ElementsMatch(t, many, shuffled)
+	assert.Equal(t, testURLs(50), many, "input is not modified")
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"shopify-extractor/internal/types"
+)
+
+// politenessPool is the rate limit shared by the hosts of a types.PolitenessPool. Pools
+// are process-wide, so every adapter and client crawling one of their hosts, whichever
+// store it belongs to, draws from the same budget.
+type politenessPool struct {
+	name    string
+	hosts   []string
+	limiter *rate.Limiter
+
+	mu       sync.Mutex
+	cooldown time.Time
+}
+
+var (
+	sharedPoolsMu sync.Mutex
+	sharedPools   = make(map[string]*politenessPool)
+)
+
+// sharedPool returns the process-wide limiter of a pool, creating it on first use. A
+// changed configuration, e.g. after a config reload, updates the existing pool.
+func sharedPool(name string, config types.PolitenessPool) *politenessPool {
+	sharedPoolsMu.Lock()
+	defer sharedPoolsMu.Unlock()
+
+	pool, ok := sharedPools[name]
+	if !ok {
+		pool = &politenessPool{name: name, limiter: NewRateLimiter(config.Delay)}
+		sharedPools[name] = pool
+	} else if limit := rate.Every(config.Delay); pool.limiter.Limit() != limit {
+		pool.limiter.SetLimit(limit)
+	}
+	pool.hosts = config.Hosts
+	return pool
+}
+
+// matches reports whether host, as returned by hostOf, is one of the pool's hosts or a
+// subdomain of one
+func (p *politenessPool) matches(host string) bool {
+	for _, h := range p.hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// extendCooldown pauses the whole pool until the given time, keeping a later deadline
+func (p *politenessPool) extendCooldown(until time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if until.After(p.cooldown) {
+		p.cooldown = until
+	}
+}
+
+func (p *politenessPool) cooldownRemaining() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return time.Until(p.cooldown)
+}
+
+// UsePools makes the limiter also enforce the shared rate limits of politeness pools
+// on their hosts: requests wait for both the host's own delay and the pool's, and a
+// cooldown of one pool host pauses the whole pool
+func (l *HostLimiter) UsePools(pools map[string]types.PolitenessPool) {
+	names := make([]string, 0, len(pools))
+	for name := range pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	shared := make([]*politenessPool, 0, len(names))
+	for _, name := range names {
+		shared = append(shared, sharedPool(name, pools[name]))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pools = shared
+}
+
+// poolFor returns the politeness pool of host, the first by name when several match,
+// or nil
+func (l *HostLimiter) poolFor(host string) *politenessPool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, pool := range l.pools {
+		if pool.matches(host) {
+			return pool
+		}
+	}
+	return nil
+}
+
+// NewConfigLimiter creates the host limiter of a configuration: RequestDelay per host
+// and the configured politeness pools
+func NewConfigLimiter(config *types.Config) *HostLimiter {
+	limiter := NewHostLimiter(config.RequestDelay)
+	limiter.UsePools(config.PolitenessPools)
+	return limiter
+}
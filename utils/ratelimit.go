@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"shopify-extractor/internal/types"
+)
+
+// HostLimiter rate limits requests per host. HTTP and browser clients that share a
+// HostLimiter draw from the same budget, so a store is crawled at the configured
+// pace regardless of how each page is fetched. A host that answers 429 can be put in
+// cooldown, pausing every request to it until the deadline passes. Hosts in a
+// politeness pool (see UsePools) are additionally limited by the pool.
+type HostLimiter struct {
+	delay     time.Duration
+	mu        sync.Mutex
+	limiters  map[string]*rate.Limiter
+	cooldowns map[string]time.Time
+	pools     []*politenessPool
+}
+
+// NewHostLimiter creates a limiter allowing one request per delay for each host
+func NewHostLimiter(delay time.Duration) *HostLimiter {
+	return &HostLimiter{
+		delay:     delay,
+		limiters:  make(map[string]*rate.Limiter),
+		cooldowns: make(map[string]time.Time),
+	}
+}
+
+// NewRateLimiter creates a limiter allowing one request per delay. The first request
+// is allowed immediately; a zero or negative delay disables limiting.
+func NewRateLimiter(delay time.Duration) *rate.Limiter {
+	if delay <= 0 {
+		return rate.NewLimiter(rate.Inf, 1)
+	}
+	return rate.NewLimiter(rate.Every(delay), 1)
+}
+
+// Wait blocks until a request to rawURL's host is allowed or ctx is done. Requests to
+// a host in cooldown wait for the cooldown to end first.
+func (l *HostLimiter) Wait(ctx context.Context, rawURL string) error {
+	host := hostOf(rawURL)
+	for {
+		remaining := l.cooldownRemaining(host)
+		if remaining <= 0 {
+			break
+		}
+		if err := sleepContext(ctx, remaining); err != nil {
+			return err
+		}
+	}
+
+	if err := l.forHost(host).Wait(ctx); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	if pool := l.poolFor(host); pool != nil {
+		if err := pool.limiter.Wait(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Cooldown pauses requests to rawURL's host until the given time. An existing later
+// deadline is kept, so concurrent workers hitting 429s never shorten a cooldown. It
+// reports whether the deadline was extended.
+func (l *HostLimiter) Cooldown(rawURL string, until time.Time) bool {
+	host := hostOf(rawURL)
+	if pool := l.poolFor(host); pool != nil {
+		pool.extendCooldown(until)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.cooldowns[host]) {
+		l.cooldowns[host] = until
+		return true
+	}
+	return false
+}
+
+// CooldownRemaining returns how long requests to rawURL's host are still paused
+func (l *HostLimiter) CooldownRemaining(rawURL string) time.Duration {
+	return l.cooldownRemaining(hostOf(rawURL))
+}
+
+func (l *HostLimiter) cooldownRemaining(host string) time.Duration {
+	var poolRemaining time.Duration
+	if pool := l.poolFor(host); pool != nil {
+		poolRemaining = pool.cooldownRemaining()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until, ok := l.cooldowns[host]
+	if !ok {
+		return poolRemaining
+	}
+	remaining := time.Until(until)
+	if remaining <= 0 {
+		delete(l.cooldowns, host)
+	}
+	if poolRemaining > remaining {
+		return poolRemaining
+	}
+	return remaining
+}
+
+// forHost returns the limiter for host, creating it on first use
+func (l *HostLimiter) forHost(host string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limiter, ok := l.limiters[host]
+	if !ok {
+		limiter = NewRateLimiter(l.delay)
+		l.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// hostOf returns the canonical store of rawURL's host (see types.CanonicalStore), so
+// the aliases of a store share its limiter
+func hostOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return rawURL
+	}
+	return types.CanonicalStore(parsed.Host)
+}
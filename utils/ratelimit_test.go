@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func TestHostLimiter_SharedPerHost(t *testing.T) {
+	limiter := NewHostLimiter(200 * time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+	require.NoError(t, limiter.Wait(ctx, "https://www.westside.com/products/a"))
+	require.NoError(t, limiter.Wait(ctx, "https://suqah.com/products/b"))
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "different hosts should not wait on each other")
+
+	require.NoError(t, limiter.Wait(ctx, "https://westside.com/products/c"))
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond, "same host should be delayed")
+}
+
+func TestHostLimiter_ContextCancelled(t *testing.T) {
+	limiter := NewHostLimiter(time.Hour)
+	require.NoError(t, limiter.Wait(context.Background(), "https://suqah.com"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.Equal(t, context.Canceled, limiter.Wait(ctx, "https://suqah.com"))
+}
+
+func TestHostLimiter_Cooldown(t *testing.T) {
+	limiter := NewHostLimiter(0)
+	ctx := context.Background()
+
+	assert.True(t, limiter.Cooldown("https://www.westside.com/products/a", time.Now().Add(200*time.Millisecond)))
+	assert.False(t, limiter.Cooldown("https://westside.com/products/b", time.Now().Add(50*time.Millisecond)), "an earlier deadline does not shorten the cooldown")
+	assert.Greater(t, limiter.CooldownRemaining("https://westside.com"), 100*time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, limiter.Wait(ctx, "https://suqah.com/products/c"))
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "other hosts are not paused")
+
+	require.NoError(t, limiter.Wait(ctx, "https://westside.com/products/d"))
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	assert.Zero(t, limiter.CooldownRemaining("https://westside.com"))
+}
+
+func TestHostLimiter_PolitenessPool(t *testing.T) {
+	pools := map[string]types.PolitenessPool{
+		"test-sibling-brands": {Hosts: []string{"westside.com", "zudio.com"}, Delay: 200 * time.Millisecond},
+	}
+	// Two stores' limiters, as created by their adapters
+	westside, zudio := NewHostLimiter(0), NewHostLimiter(0)
+	westside.UsePools(pools)
+	zudio.UsePools(pools)
+	ctx := context.Background()
+
+	start := time.Now()
+	require.NoError(t, westside.Wait(ctx, "https://www.westside.com/products/a"))
+	require.NoError(t, zudio.Wait(ctx, "https://suqah.com/products/b"))
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "hosts outside the pool are not limited by it")
+
+	require.NoError(t, zudio.Wait(ctx, "https://shop.zudio.com/products/c"))
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond, "pool hosts share one budget across limiters")
+
+	westside.Cooldown("https://westside.com/products/d", time.Now().Add(time.Second))
+	assert.Greater(t, zudio.CooldownRemaining("https://zudio.com"), 500*time.Millisecond, "a cooldown pauses the whole pool")
+	assert.Zero(t, zudio.CooldownRemaining("https://suqah.com"))
+}
@@ -0,0 +1,38 @@
+package utils
+
+import "time"
+
+// Retention decides which stored data (archived pages, WARC files, catalog products,
+// runs) a purge removes
+type Retention struct {
+	// MaxAge removes data older than this (0 = keep data regardless of age)
+	MaxAge time.Duration
+
+	// Registered reports whether a store is still served; data of other (deregistered)
+	// stores is removed. Nil keeps data of every store.
+	Registered func(store string) bool
+
+	// Now is the reference time for MaxAge (zero = time.Now())
+	Now time.Time
+}
+
+// Expired reports whether data of a store stored at the given time is to be removed.
+// Data of no particular store (empty store) only expires by age.
+func (r Retention) Expired(store string, at time.Time) bool {
+	if r.Registered != nil && store != "" && !r.Registered(storeKey(store)) {
+		return true
+	}
+	if r.MaxAge <= 0 {
+		return false
+	}
+	now := r.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return at.Before(now.Add(-r.MaxAge))
+}
+
+// Empty reports whether the retention keeps everything
+func (r Retention) Empty() bool {
+	return r.MaxAge <= 0 && r.Registered == nil
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionExpired(t *testing.T) {
+	now := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	retention := Retention{
+		MaxAge:     30 * 24 * time.Hour,
+		Registered: func(store string) bool { return store == "westside.com" },
+		Now:        now,
+	}
+
+	assert.False(t, retention.Expired("www.Westside.com", now.AddDate(0, 0, -10)))
+	assert.True(t, retention.Expired("westside.com", now.AddDate(0, 0, -31)))
+	assert.True(t, retention.Expired("oldstore.com", now), "deregistered store")
+	assert.False(t, retention.Expired("", now), "data of every store only expires by age")
+
+	assert.True(t, Retention{}.Empty())
+	assert.False(t, Retention{}.Expired("oldstore.com", time.Time{}))
+}
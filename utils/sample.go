@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// SampleURLs returns a random subset of urls, preserving their original order.
+// When count is positive it selects exactly count URLs; otherwise rate (0 < rate < 1)
+// selects that fraction, rounded up so that a non-empty input never yields an empty sample.
+// A non-zero seed makes the selection reproducible across runs.
+func SampleURLs(urls []string, rate float64, count int, seed int64) []string {
+	n := len(urls)
+	switch {
+	case count > 0:
+		if count < n {
+			n = count
+		}
+	case rate > 0 && rate < 1:
+		n = int(math.Ceil(float64(len(urls)) * rate))
+	default:
+		return urls
+	}
+
+	if n >= len(urls) {
+		return urls
+	}
+
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	indices := rng.Perm(len(urls))[:n]
+	sort.Ints(indices)
+
+	sampled := make([]string, 0, n)
+	for _, i := range indices {
+		sampled = append(sampled, urls[i])
+	}
+	return sampled
+}
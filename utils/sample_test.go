@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testURLs(n int) []string {
+	urls := make([]string, n)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://example.com/products/%d", i)
+	}
+	return urls
+}
+
+func TestSampleURLs_Rate(t *testing.T) {
+	urls := testURLs(100)
+
+	sampled := SampleURLs(urls, 0.1, 0, 42)
+	assert.Len(t, sampled, 10)
+
+	// Same seed, same sample
+	assert.Equal(t, sampled, SampleURLs(urls, 0.1, 0, 42))
+}
+
+func TestSampleURLs_CountPreservesOrder(t *testing.T) {
+	urls := testURLs(50)
+
+	sampled := SampleURLs(urls, 0, 5, 7)
+	assert.Len(t, sampled, 5)
+
+	index := make(map[string]int)
+	for i, u := range urls {
+		index[u] = i
+	}
+	for i := 1; i < len(sampled); i++ {
+		assert.Less(t, index[sampled[i-1]], index[sampled[i]])
+	}
+}
+
+func TestSampleURLs_NoSampling(t *testing.T) {
+	urls := testURLs(3)
+
+	assert.Equal(t, urls, SampleURLs(urls, 0, 0, 1))
+	assert.Equal(t, urls, SampleURLs(urls, 1, 0, 1))
+	assert.Equal(t, urls, SampleURLs(urls, 0, 10, 1))
+	assert.Len(t, SampleURLs(urls, 0.01, 0, 1), 1)
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+
+	"shopify-extractor/internal/types"
+)
+
+// Default WebGL identity reported in stealth mode, matching a common desktop GPU
+const (
+	defaultWebGLVendor   = "Intel Inc."
+	defaultWebGLRenderer = "Intel Iris OpenGL Engine"
+)
+
+// stealthScriptTemplate hides the most common headless Chrome tells. It runs before any
+// page script; __LANGUAGES__, __VENDOR__ and __RENDERER__ are replaced with JSON values.
+const stealthScriptTemplate = `(() => {
+  Object.defineProperty(Navigator.prototype, 'webdriver', { get: () => undefined });
+  Object.defineProperty(Navigator.prototype, 'languages', { get: () => __LANGUAGES__ });
+  Object.defineProperty(Navigator.prototype, 'plugins', { get: () => [1, 2, 3, 4, 5] });
+  if (!window.chrome) { window.chrome = { runtime: {} }; }
+
+  const patchWebGL = (proto) => {
+    const getParameter = proto.getParameter;
+    proto.getParameter = function (parameter) {
+      if (parameter === 37445) { return __VENDOR__; }   // UNMASKED_VENDOR_WEBGL
+      if (parameter === 37446) { return __RENDERER__; } // UNMASKED_RENDERER_WEBGL
+      return getParameter.call(this, parameter);
+    };
+  };
+  if (window.WebGLRenderingContext) { patchWebGL(WebGLRenderingContext.prototype); }
+  if (window.WebGL2RenderingContext) { patchWebGL(WebGL2RenderingContext.prototype); }
+})();`
+
+// stealthScript returns the script injected into every document in stealth mode
+func stealthScript(opts types.BrowserOptions) string {
+	languages := []string{"en-US", "en"}
+	if opts.Language != "" {
+		languages = []string{opts.Language}
+		if base, _, ok := strings.Cut(opts.Language, "-"); ok {
+			languages = append(languages, base)
+		}
+	}
+
+	vendor, renderer := opts.WebGLVendor, opts.WebGLRenderer
+	if vendor == "" {
+		vendor = defaultWebGLVendor
+	}
+	if renderer == "" {
+		renderer = defaultWebGLRenderer
+	}
+
+	return strings.NewReplacer(
+		"__LANGUAGES__", jsonString(languages),
+		"__VENDOR__", jsonString(vendor),
+		"__RENDERER__", jsonString(renderer),
+	).Replace(stealthScriptTemplate)
+}
+
+// stealthFlags returns the launch switches used in stealth mode. The user agent
+// replaces the default one, which advertises HeadlessChrome.
+func stealthFlags(userAgent string) map[string]string {
+	flags := map[string]string{
+		"disable-blink-features": "AutomationControlled",
+	}
+	if userAgent != "" {
+		flags["user-agent"] = userAgent
+	}
+	return flags
+}
+
+// jsonString encodes v as a JavaScript literal
+func jsonString(v interface{}) string {
+	encoded, _ := json.Marshal(v)
+	return string(encoded)
+}
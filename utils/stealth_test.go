@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"shopify-extractor/internal/types"
+)
+
+func TestStealthScript(t *testing.T) {
+	opts := types.DefaultBrowserOptions()
+	opts.Language = "en-IN"
+	opts.WebGLVendor = "NVIDIA Corporation"
+
+	script := stealthScript(opts)
+
+	assert.Contains(t, script, `'webdriver', { get: () => undefined }`)
+	assert.Contains(t, script, `["en-IN","en"]`)
+	assert.Contains(t, script, `"NVIDIA Corporation"`)
+	assert.Contains(t, script, `"Intel Iris OpenGL Engine"`)
+	assert.NotContains(t, script, "__")
+}
+
+func TestStealthFlags(t *testing.T) {
+	flags := stealthFlags("Mozilla/5.0 Test")
+
+	assert.Equal(t, "AutomationControlled", flags["disable-blink-features"])
+	assert.Equal(t, "Mozilla/5.0 Test", flags["user-agent"])
+	assert.NotContains(t, stealthFlags(""), "user-agent")
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"shopify-extractor/internal/types"
+)
+
+// ErrStorefrontPassword is returned (wrapped) when a password-protected storefront
+// rejects the configured password
+var ErrStorefrontPassword = errors.New("storefront password rejected")
+
+// storefrontSessions holds the session cookies of unlocked password-protected
+// storefronts by host, shared by every HTTP and browser client of the process
+var storefrontSessions = struct {
+	sync.Mutex
+	cookies map[string]string
+	locks   map[string]*sync.Mutex
+}{cookies: map[string]string{}, locks: map[string]*sync.Mutex{}}
+
+// storefrontCookie returns the session cookie of an unlocked storefront host
+func storefrontCookie(host string) string {
+	storefrontSessions.Lock()
+	defer storefrontSessions.Unlock()
+	return storefrontSessions.cookies[host]
+}
+
+// forgetStorefrontSession drops the session of a host so the next request unlocks again
+func forgetStorefrontSession(host string) {
+	storefrontSessions.Lock()
+	defer storefrontSessions.Unlock()
+	delete(storefrontSessions.cookies, host)
+}
+
+// hostLock serialises unlock attempts for one host
+func hostLock(host string) *sync.Mutex {
+	storefrontSessions.Lock()
+	defer storefrontSessions.Unlock()
+	lock, ok := storefrontSessions.locks[host]
+	if !ok {
+		lock = &sync.Mutex{}
+		storefrontSessions.locks[host] = lock
+	}
+	return lock
+}
+
+// requestHeaders returns the extra headers of a request to pageURL: the store's
+// configured headers, its credentials and the session cookie of an unlocked storefront
+func requestHeaders(config *types.Config, pageURL string) map[string]string {
+	options := StoreOptionsFor(config, pageURL)
+	auth := authorizationHeader(options)
+	cookie := ""
+	if options.Password != "" {
+		if parsed, err := url.Parse(pageURL); err == nil {
+			cookie = storefrontCookie(parsed.Host)
+		}
+	}
+	if auth == "" && cookie == "" {
+		return options.Headers
+	}
+
+	headers := make(map[string]string, len(options.Headers)+2)
+	for name, value := range options.Headers {
+		headers[name] = value
+	}
+	if auth != "" {
+		headers["Authorization"] = auth
+	}
+	if cookie != "" {
+		if existing := headers["Cookie"]; existing != "" {
+			cookie = existing + "; " + cookie
+		}
+		headers["Cookie"] = cookie
+	}
+	return headers
+}
+
+// authorizationHeader returns the Authorization header value of the store's credentials,
+// preferring a bearer token over basic auth
+func authorizationHeader(options types.StoreOptions) string {
+	switch {
+	case options.BearerToken != "":
+		return "Bearer " + options.BearerToken
+	case options.BasicAuth != nil:
+		credentials := options.BasicAuth.Username + ":" + options.BasicAuth.Password
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+	return ""
+}
+
+// ensureStorefrontAccess unlocks the storefront serving pageURL when the store has a
+// password configured and no session yet
+func ensureStorefrontAccess(ctx context.Context, config *types.Config, logger types.Logger, pageURL string) error {
+	password := StoreOptionsFor(config, pageURL).Password
+	if password == "" {
+		return nil
+	}
+	parsed, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	lock := hostLock(parsed.Host)
+	lock.Lock()
+	defer lock.Unlock()
+	if storefrontCookie(parsed.Host) != "" {
+		return nil
+	}
+
+	cookie, err := unlockStorefront(ctx, config, parsed.Scheme+"://"+parsed.Host, password)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Unlocked password-protected storefront %s", parsed.Host)
+
+	storefrontSessions.Lock()
+	defer storefrontSessions.Unlock()
+	storefrontSessions.cookies[parsed.Host] = cookie
+	return nil
+}
+
+// unlockStorefront submits the storefront password form and returns the session cookies
+// it sets, formatted as a Cookie header value
+func unlockStorefront(ctx context.Context, config *types.Config, baseURL, password string) (string, error) {
+	form := url.Values{
+		"form_type": {"storefront_password"},
+		"utf8":      {"✓"},
+		"password":  {password},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/password", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create password request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", config.UserAgent)
+	for name, value := range requestHeaders(config, baseURL) {
+		req.Header.Set(name, value)
+	}
+
+	// The session cookies come with the redirect answering the form, so it is not followed
+	client := &http.Client{
+		Timeout:       config.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("password request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	// A rejected password re-renders the form, or redirects back to it
+	location := resp.Header.Get("Location")
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 || strings.Contains(location, "/password") {
+		return "", fmt.Errorf("%w for %s (status %d)", ErrStorefrontPassword, baseURL, resp.StatusCode)
+	}
+
+	var cookies []string
+	for _, cookie := range resp.Cookies() {
+		cookies = append(cookies, cookie.Name+"="+cookie.Value)
+	}
+	if len(cookies) == 0 {
+		return "", fmt.Errorf("%w for %s: no session cookie set", ErrStorefrontPassword, baseURL)
+	}
+	return strings.Join(cookies, "; "), nil
+}
+
+// isPasswordPage reports whether a response was redirected to the storefront password form
+func isPasswordPage(resp *http.Response) bool {
+	return resp.Request != nil && resp.Request.URL.Path == "/password"
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+// newPasswordStore serves a storefront that only shows product pages once unlocked
+func newPasswordStore(t *testing.T, password string) (*httptest.Server, *int) {
+	unlocks := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/password" && r.Method == http.MethodPost:
+			require.NoError(t, r.ParseForm())
+			if r.PostForm.Get("password") != password {
+				w.Write([]byte("<form>wrong password</form>"))
+				return
+			}
+			unlocks++
+			http.SetCookie(w, &http.Cookie{Name: "storefront_digest", Value: "abc"})
+			http.Redirect(w, r, "/", http.StatusFound)
+		case r.URL.Path == "/password":
+			w.Write([]byte("<form>password</form>"))
+		default:
+			if cookie, err := r.Cookie("storefront_digest"); err != nil || cookie.Value != "abc" {
+				http.Redirect(w, r, "/password", http.StatusFound)
+				return
+			}
+			w.Write([]byte("product page"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &unlocks
+}
+
+func TestHTTPClient_Get_UnlocksPasswordStorefront(t *testing.T) {
+	server, unlocks := newPasswordStore(t, "secret")
+
+	config := types.DefaultConfig()
+	config.RequestDelay = 0
+	config.MaxRetries = 1
+	config.Stores = map[string]types.StoreOptions{"127.0.0.1": {Password: "secret"}}
+	client := NewHTTPClient(config, logrus.New())
+	defer client.Close()
+
+	for i := 0; i < 2; i++ {
+		body, err := client.Get(context.Background(), server.URL+"/products/dress")
+		require.NoError(t, err)
+		assert.Equal(t, "product page", string(body))
+	}
+	assert.Equal(t, 1, *unlocks, "the session cookie is reused")
+}
+
+func TestHTTPClient_Get_WrongStorefrontPassword(t *testing.T) {
+	server, _ := newPasswordStore(t, "secret")
+
+	config := types.DefaultConfig()
+	config.RequestDelay = 0
+	config.Stores = map[string]types.StoreOptions{"127.0.0.1": {Password: "wrong"}}
+	client := NewHTTPClient(config, logrus.New())
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), server.URL+"/products/dress")
+	assert.True(t, errors.Is(err, ErrStorefrontPassword), "got %v", err)
+}
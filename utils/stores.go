@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"shopify-extractor/internal/types"
+	"shopify-extractor/secrets"
+)
+
+// storeKey returns the canonical store of a domain or host, see types.CanonicalStore
+func storeKey(domain string) string {
+	return types.CanonicalStore(domain)
+}
+
+// storeResolveClient requests the *.myshopify.com domains resolved by ResolveStore
+var storeResolveClient = &http.Client{Timeout: 10 * time.Second}
+
+// ResolveStore returns the canonical store of a domain (see types.CanonicalStore). A
+// *.myshopify.com domain that is no registered alias is resolved through the redirect
+// Shopify sends to the store's primary domain, which is then registered as its alias. A
+// domain that cannot be resolved is returned in its canonical form.
+func ResolveStore(ctx context.Context, store string) string {
+	canonical := types.CanonicalStore(store)
+	if !strings.HasSuffix(canonical, types.ShopifyDomainSuffix) || strings.ContainsAny(canonical, "/:?#@ ") {
+		return canonical
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, "https://"+canonical+"/", nil)
+	if err != nil {
+		return canonical
+	}
+	resp, err := storeResolveClient.Do(req)
+	if err != nil {
+		return canonical
+	}
+	resp.Body.Close()
+
+	primary := types.CanonicalStore(resp.Request.URL.Hostname())
+	if primary == "" || primary == canonical || strings.HasSuffix(primary, types.ShopifyDomainSuffix) {
+		return canonical
+	}
+	types.RegisterStoreAlias(canonical, primary)
+	return primary
+}
+
+// CanonicalStores resolves every store with ResolveStore, dropping the stores that turn
+// out to be another spelling of an earlier one
+func CanonicalStores(ctx context.Context, stores []string) []string {
+	resolved := make([]string, 0, len(stores))
+	seen := make(map[string]bool, len(stores))
+	for _, store := range stores {
+		canonical := ResolveStore(ctx, store)
+		if !seen[canonical] {
+			seen[canonical] = true
+			resolved = append(resolved, canonical)
+		}
+	}
+	return resolved
+}
+
+// StoreOptionsFor returns the options of the store serving pageURL, or zero options
+// when none are configured
+func StoreOptionsFor(config *types.Config, pageURL string) types.StoreOptions {
+	if len(config.Stores) == 0 {
+		return types.StoreOptions{}
+	}
+	parsed, err := url.Parse(pageURL)
+	if err != nil {
+		return types.StoreOptions{}
+	}
+	return config.Stores[storeKey(parsed.Hostname())]
+}
+
+// StoreConcurrency returns the number of extraction workers of a store: its configured
+// concurrency, or MaxConcurrentRequests, and at least 1
+func StoreConcurrency(config *types.Config, store string) int {
+	workers := config.Stores[storeKey(store)].Concurrency
+	if workers == 0 {
+		workers = config.MaxConcurrentRequests
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+// LoadStoreOptions reads per-store options from a JSON file mapping store domains to
+// options, e.g. {"westside.com": {"headers": {"Referer": "https://www.westside.com/"}}}
+func LoadStoreOptions(path string) (map[string]types.StoreOptions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store options: %w", err)
+	}
+	var raw map[string]types.StoreOptions
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse store options %s: %w", path, err)
+	}
+
+	stores := make(map[string]types.StoreOptions, len(raw))
+	for domain, options := range raw {
+		if err := resolveStoreSecrets(&options); err != nil {
+			return nil, fmt.Errorf("invalid store options for %s: %w", domain, err)
+		}
+		stores[storeKey(domain)] = options
+		for _, alias := range options.Aliases {
+			types.RegisterStoreAlias(alias, domain)
+		}
+	}
+	return stores, nil
+}
+
+// resolveStoreSecrets replaces the secret references (env:, file:, vault:) among a
+// store's credentials and header values with the secrets they point to
+func resolveStoreSecrets(options *types.StoreOptions) error {
+	values := []*string{&options.Password, &options.BearerToken}
+	if options.BasicAuth != nil {
+		auth := *options.BasicAuth
+		options.BasicAuth = &auth
+		values = append(values, &auth.Username, &auth.Password)
+	}
+	if len(options.Headers) > 0 {
+		headers := make(map[string]string, len(options.Headers))
+		for name, value := range options.Headers {
+			resolved, err := secrets.Resolve(context.Background(), value)
+			if err != nil {
+				return fmt.Errorf("header %s: %w", name, err)
+			}
+			headers[name] = resolved
+		}
+		options.Headers = headers
+	}
+	return secrets.ResolveAll(context.Background(), values...)
+}
+
+// CollectionWeight returns the priority weight of a collection URL under the store's
+// collection_priority rules: the highest weight among the globs matching its handle, or
+// 0 when none match
+func CollectionWeight(options types.StoreOptions, collectionURL string) int {
+	handle := collectionHandle(collectionURL)
+	weight, matched := 0, false
+	for pattern, w := range options.CollectionPriority {
+		if ok, _ := path.Match(strings.ToLower(pattern), handle); ok && (!matched || w > weight) {
+			weight, matched = w, true
+		}
+	}
+	return weight
+}
+
+// PrioritizeCollections orders collection URLs by descending weight under the store's
+// collection_priority rules, keeping the page order among equally weighted collections
+func PrioritizeCollections(config *types.Config, collectionURLs []string) []string {
+	if len(collectionURLs) == 0 {
+		return collectionURLs
+	}
+	options := StoreOptionsFor(config, collectionURLs[0])
+	if len(options.CollectionPriority) == 0 {
+		return collectionURLs
+	}
+
+	weights := make(map[string]int, len(collectionURLs))
+	for _, collectionURL := range collectionURLs {
+		weights[collectionURL] = CollectionWeight(options, collectionURL)
+	}
+	sorted := append([]string(nil), collectionURLs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return weights[sorted[i]] > weights[sorted[j]]
+	})
+	return sorted
+}
+
+// collectionHandle returns the lowercased handle of a collection URL, the path segment
+// after /collections/ ("new-arrivals" for /collections/new-arrivals?page=2)
+func collectionHandle(collectionURL string) string {
+	parsed, err := url.Parse(collectionURL)
+	if err != nil {
+		return ""
+	}
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	for i, segment := range segments {
+		if segment == "collections" && i+1 < len(segments) {
+			return strings.ToLower(segments[i+1])
+		}
+	}
+	return ""
+}
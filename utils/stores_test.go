@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shopify-extractor/internal/types"
+)
+
+func TestLoadStoreOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stores.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"WWW.Westside.com": {"headers": {"Referer": "https://www.westside.com/"}}}`), 0o644))
+
+	stores, err := LoadStoreOptions(path)
+	require.NoError(t, err)
+
+	config := &types.Config{Stores: stores}
+	assert.Equal(t, "https://www.westside.com/", StoreOptionsFor(config, "https://www.westside.com/products/a").Headers["Referer"])
+	assert.Empty(t, StoreOptionsFor(config, "https://suqah.com/products/b").Headers)
+
+	_, err = LoadStoreOptions(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestPrioritizeCollections(t *testing.T) {
+	config := &types.Config{Stores: map[string]types.StoreOptions{
+		"westside.com": {CollectionPriority: map[string]int{"new-arrivals": 10, "sale*": -5, "*dresses": 3}},
+	}}
+	collections := []string{
+		"https://www.westside.com/collections/sale-tops",
+		"https://www.westside.com/collections/kurtas",
+		"https://www.westside.com/collections/maxi-dresses",
+		"https://www.westside.com/collections/new-arrivals?page=2",
+		"https://www.westside.com/collections/jeans",
+	}
+
+	assert.Equal(t, []string{
+		"https://www.westside.com/collections/new-arrivals?page=2",
+		"https://www.westside.com/collections/maxi-dresses",
+		"https://www.westside.com/collections/kurtas",
+		"https://www.westside.com/collections/jeans",
+		"https://www.westside.com/collections/sale-tops",
+	}, PrioritizeCollections(config, collections))
+	assert.Equal(t, "https://www.westside.com/collections/sale-tops", collections[0], "input is not reordered")
+
+	// Stores without rules keep the page order
+	suqah := []string{"https://www.suqah.com/collections/b", "https://www.suqah.com/collections/a"}
+	assert.Equal(t, suqah, PrioritizeCollections(config, suqah))
+}
+
+func TestLoadStoreOptions_Secrets(t *testing.T) {
+	t.Setenv("WESTSIDE_STAGING_PASSWORD", "hunter2")
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cookie"), []byte("session=abc\n"), 0o600))
+	path := filepath.Join(dir, "stores.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"staging.westside.com": {
+			"basic_auth": {"username": "qa", "password": "env:WESTSIDE_STAGING_PASSWORD"},
+			"headers": {"Cookie": "file:`+filepath.Join(dir, "cookie")+`"}
+		}
+	}`), 0o644))
+
+	stores, err := LoadStoreOptions(path)
+	require.NoError(t, err)
+	options := stores["staging.westside.com"]
+	assert.Equal(t, "qa", options.BasicAuth.Username)
+	assert.Equal(t, "hunter2", options.BasicAuth.Password)
+	assert.Equal(t, "session=abc", options.Headers["Cookie"])
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"suqah.com": {"password": "env:SHOPIFY_EXTRACTOR_UNSET_SECRET"}}`), 0o644))
+	_, err = LoadStoreOptions(path)
+	assert.ErrorContains(t, err, "suqah.com")
+}
+
+func TestStoreConcurrency(t *testing.T) {
+	config := types.DefaultConfig()
+	config.MaxConcurrentRequests = 8
+	config.Stores = map[string]types.StoreOptions{"westside.com": {Concurrency: 2}}
+
+	assert.Equal(t, 2, StoreConcurrency(config, "www.westside.com"))
+	assert.Equal(t, 8, StoreConcurrency(config, "suqah.com"))
+
+	config.MaxConcurrentRequests = 0
+	assert.Equal(t, 1, StoreConcurrency(config, "suqah.com"))
+}
+
+func TestLoadStoreOptions_Aliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stores.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"alias-test.com": {"aliases": ["alias-test-store.myshopify.com"], "concurrency": 2}}`), 0o644))
+
+	stores, err := LoadStoreOptions(path)
+	require.NoError(t, err)
+
+	config := &types.Config{Stores: stores}
+	assert.Equal(t, "alias-test.com", types.CanonicalStore("alias-test-store.myshopify.com"))
+	assert.Equal(t, 2, StoreConcurrency(config, "alias-test-store.myshopify.com"))
+	assert.Equal(t, 2, StoreOptionsFor(config, "https://alias-test-store.myshopify.com/products/a").Concurrency)
+}
+
+// roundTripFunc serves requests with a function
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestResolveStore(t *testing.T) {
+	original := storeResolveClient
+	t.Cleanup(func() { storeResolveClient = original })
+	requests := 0
+	storeResolveClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		resp := &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}, Request: req}
+		switch req.URL.Host {
+		case "resolve-test.myshopify.com":
+			resp.StatusCode = http.StatusMovedPermanently
+			resp.Header.Set("Location", "https://www.resolve-test.com/")
+		case "closed-test.myshopify.com":
+			resp.StatusCode = http.StatusUnauthorized
+		}
+		return resp, nil
+	})}
+
+	ctx := context.Background()
+	assert.Equal(t, "resolve-test.com", ResolveStore(ctx, "Resolve-Test.myshopify.com"))
+	assert.Equal(t, 2, requests)
+
+	// The alias is remembered
+	assert.Equal(t, "resolve-test.com", ResolveStore(ctx, "resolve-test.myshopify.com"))
+	assert.Equal(t, 2, requests)
+
+	// Without a redirect to a primary domain the myshopify domain stays the store
+	assert.Equal(t, "closed-test.myshopify.com", ResolveStore(ctx, "closed-test.myshopify.com"))
+
+	// Other domains are not requested
+	requests = 0
+	assert.Equal(t, "westside.com", ResolveStore(ctx, "www.westside.com"))
+	assert.Zero(t, requests)
+
+	assert.Equal(t, []string{"resolve-test.com", "westside.com"},
+		CanonicalStores(ctx, []string{"resolve-test.myshopify.com", "www.resolve-test.com", "westside.com", "WWW.westside.com"}))
+}
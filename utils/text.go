@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// invisibleChars strips zero-width characters and soft hyphens, which survive
+// strings.TrimSpace and make otherwise identical headers differ
+var invisibleChars = strings.NewReplacer(
+	"\u200b", "", // zero-width space
+	"\u200c", "", // zero-width non-joiner
+	"\u200d", "", // zero-width joiner
+	"\u2060", "", // word joiner
+	"\ufeff", "", // byte order mark
+	"\u00ad", "", // soft hyphen
+)
+
+// CleanText normalizes text scraped from a page: HTML entities left encoded (such as
+// "&quot;" or "&nbsp;" in attributes or double-encoded markup) are decoded, zero-width
+// characters removed and runs of whitespace, non-breaking spaces included, collapsed
+// into single spaces
+func CleanText(text string) string {
+	if strings.Contains(text, "&") {
+		text = html.UnescapeString(text)
+	}
+	return strings.Join(strings.Fields(invisibleChars.Replace(text)), " ")
+}
+
+// CollapseRepeats reduces text made of one label repeated back to back, as produced by
+// reading the visible and the hidden copy of an element, to a single copy:
+// "XS - 36XS - 36" and "XS - 36 XS - 36" both give "XS - 36". Spaces are ignored when
+// looking for the repetition and the first copy is returned as written. Other text is
+// returned with its whitespace collapsed.
+func CollapseRepeats(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	compact := []rune(strings.ReplaceAll(text, " ", ""))
+
+	// Single characters are never collapsed, so "XX" stays a size of its own
+	for period := 2; period <= len(compact)/2; period++ {
+		if len(compact)%period != 0 || !repeats(compact, period) {
+			continue
+		}
+		// Return the original text up to the period-th non-space character
+		seen := 0
+		for i, r := range text {
+			if r == ' ' {
+				continue
+			}
+			if seen++; seen == period {
+				return strings.TrimSpace(text[:i+utf8.RuneLen(r)])
+			}
+		}
+	}
+	return text
+}
+
+// repeats reports whether runes is its first period runes repeated
+func repeats(runes []rune, period int) bool {
+	for i := period; i < len(runes); i++ {
+		if runes[i] != runes[i-period] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanText(t *testing.T) {
+	tests := map[string]string{
+		"  Bust \n\t (in) ":              "Bust (in)",
+		"Bust\u00a0(in)":                 "Bust (in)",
+		"Wa\u200bist":                    "Waist",
+		"\ufeffSize":                     "Size",
+		"To&nbsp;Fit&nbsp;Bust":          "To Fit Bust",
+		`{&quot;0&quot;:&quot;34&quot;}`: `{"0":"34"}`,
+		"S &amp; M":                      "S & M",
+		"":                               "",
+	}
+	for raw, want := range tests {
+		assert.Equal(t, want, CleanText(raw), "%q", raw)
+	}
+}
+
+func TestCollapseRepeats(t *testing.T) {
+	tests := map[string]string{
+		"XS - 36XS - 36":                     "XS - 36",
+		"XS - 36 XS - 36":                    "XS - 36",
+		"  XS - 36\n  XS - 36 ":              "XS - 36",
+		"XXS - 34 - PetiteXXS - 34 - Petite": "XXS - 34 - Petite",
+		"XXS - 34 - Petite":                  "XXS - 34 - Petite",
+		"3XL - 46 3XL - 46 3XL - 46":         "3XL - 46",
+		"Free SizeFree Size":                 "Free Size",
+		"XX":                                 "XX",
+		"XXL":                                "XXL",
+		"S":                                  "S",
+		"":                                   "",
+	}
+	for raw, want := range tests {
+		assert.Equal(t, want, CollapseRepeats(raw), "%q", raw)
+	}
+}
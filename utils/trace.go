@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"shopify-extractor/internal/types"
+)
+
+// maxTraceEvents bounds the events kept per trace; later lines are counted but dropped
+const maxTraceEvents = 200
+
+// traceBuffer holds the events of one trace, shared by the loggers derived from it
+type traceBuffer struct {
+	mu      sync.Mutex
+	events  []types.TraceEvent
+	dropped int
+}
+
+// Tracer is a Logger recording every line logged through it, at any level, as the
+// extraction trace of one product while forwarding the line to the wrapped logger.
+// Loggers derived from it with WithField keep recording to the same trace.
+type Tracer struct {
+	next   types.Logger
+	buffer *traceBuffer
+}
+
+// NewTracer returns a tracer forwarding to next
+func NewTracer(next types.Logger) *Tracer {
+	return &Tracer{next: next, buffer: &traceBuffer{}}
+}
+
+// withNext returns a tracer recording to the same trace and forwarding to next
+func (t *Tracer) withNext(next types.Logger) *Tracer {
+	return &Tracer{next: next, buffer: t.buffer}
+}
+
+// Events returns the recorded events in logging order, ending with a note of the lines
+// dropped past maxTraceEvents
+func (t *Tracer) Events() []types.TraceEvent {
+	t.buffer.mu.Lock()
+	defer t.buffer.mu.Unlock()
+	events := append([]types.TraceEvent(nil), t.buffer.events...)
+	if t.buffer.dropped > 0 {
+		events = append(events, types.TraceEvent{
+			Time:    time.Now(),
+			Level:   "warning",
+			Message: fmt.Sprintf("%d more lines dropped", t.buffer.dropped),
+		})
+	}
+	return events
+}
+
+func (t *Tracer) record(level, message string) {
+	t.buffer.mu.Lock()
+	defer t.buffer.mu.Unlock()
+	if len(t.buffer.events) >= maxTraceEvents {
+		t.buffer.dropped++
+		return
+	}
+	t.buffer.events = append(t.buffer.events, types.TraceEvent{Time: time.Now(), Level: level, Message: message})
+}
+
+// The Logger methods record the line in the trace, then forward it
+
+func (t *Tracer) Debug(args ...interface{}) {
+	t.record("debug", fmt.Sprint(args...))
+	t.next.Debug(args...)
+}
+
+func (t *Tracer) Info(args ...interface{}) {
+	t.record("info", fmt.Sprint(args...))
+	t.next.Info(args...)
+}
+
+func (t *Tracer) Warn(args ...interface{}) {
+	t.record("warning", fmt.Sprint(args...))
+	t.next.Warn(args...)
+}
+
+func (t *Tracer) Error(args ...interface{}) {
+	t.record("error", fmt.Sprint(args...))
+	t.next.Error(args...)
+}
+
+func (t *Tracer) Debugf(format string, args ...interface{}) {
+	t.record("debug", fmt.Sprintf(format, args...))
+	t.next.Debugf(format, args...)
+}
+
+func (t *Tracer) Infof(format string, args ...interface{}) {
+	t.record("info", fmt.Sprintf(format, args...))
+	t.next.Infof(format, args...)
+}
+
+func (t *Tracer) Warnf(format string, args ...interface{}) {
+	t.record("warning", fmt.Sprintf(format, args...))
+	t.next.Warnf(format, args...)
+}
+
+func (t *Tracer) Errorf(format string, args ...interface{}) {
+	t.record("error", fmt.Sprintf(format, args...))
+	t.next.Errorf(format, args...)
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracer(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+	logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	logger.SetLevel(logrus.InfoLevel)
+
+	tracer := NewTracer(WithField(logger, "product_url", "https://suqah.com/products/kurta"))
+	tracer.Debugf("Trying parser %s", "kiwi")
+	WithField(tracer, "selector", "table").Warn("no rows")
+
+	events := tracer.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "debug", events[0].Level)
+	assert.Equal(t, "Trying parser kiwi", events[0].Message)
+	assert.Equal(t, "warning", events[1].Level)
+	assert.Equal(t, "no rows", events[1].Message)
+
+	// Lines reach the wrapped logger at its level, with the fields of both loggers
+	assert.NotContains(t, out.String(), "Trying parser")
+	assert.Contains(t, out.String(), "selector=table")
+	assert.Contains(t, out.String(), `product_url="https://suqah.com/products/kurta"`)
+}
+
+func TestTracer_Bounded(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(&bytes.Buffer{})
+	tracer := NewTracer(logger)
+	for i := 0; i < maxTraceEvents+5; i++ {
+		tracer.Info("line")
+	}
+	events := tracer.Events()
+	require.Len(t, events, maxTraceEvents+1)
+	assert.True(t, strings.HasPrefix(events[maxTraceEvents].Message, "5 more lines"))
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query parameters that identify a visit, a campaign or a variant
+// rather than a page; utm_* parameters are stripped as well
+var trackingParams = map[string]bool{
+	"variant": true,
+	"fbclid":  true,
+	"gclid":   true,
+	"msclkid": true,
+	"_pos":    true, // Shopify search result position
+	"_sid":    true,
+	"_ss":     true,
+	"_psq":    true,
+	"_fid":    true,
+}
+
+// NormalizeURL puts a page URL in canonical form so the same page linked in different
+// ways deduplicates: the scheme and host are lowercased, default ports, fragments,
+// tracking and variant query parameters and trailing slashes are dropped, and the
+// remaining query parameters are sorted. Unparseable URLs are returned trimmed.
+func NormalizeURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		u.Host = u.Hostname()
+	}
+	u.Fragment, u.RawFragment = "", ""
+
+	query := u.Query()
+	for key := range query {
+		if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+	return u.String()
+}
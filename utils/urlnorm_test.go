@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := map[string]string{
+		"https://WWW.Suqah.com/products/Kurta":                                    "https://www.suqah.com/products/Kurta",
+		"https://www.suqah.com/products/kurta/":                                   "https://www.suqah.com/products/kurta",
+		"https://www.suqah.com/products/kurta?variant=4242&utm_source=ig#reviews": "https://www.suqah.com/products/kurta",
+		"https://www.suqah.com/products/kurta?_pos=1&_sid=ab12&_ss=r":             "https://www.suqah.com/products/kurta",
+		"https://www.suqah.com:443/collections/all?page=2&sort_by=price":          "https://www.suqah.com/collections/all?page=2&sort_by=price",
+		"https://www.suqah.com/collections/all?sort_by=price&page=2":              "https://www.suqah.com/collections/all?page=2&sort_by=price",
+		"https://www.suqah.com/":                                                  "https://www.suqah.com",
+		" /products/kurta ":                                                       "/products/kurta",
+	}
+	for raw, want := range tests {
+		assert.Equal(t, want, NormalizeURL(raw), "%q", raw)
+	}
+}